@@ -312,6 +312,29 @@ func processScheduleOptions(
 				continue
 			}
 			s.incArgs.UpdatesLastBackupMetric = updatesLastBackupMetric
+		case optIncrementalCompactionThreshold:
+			if s.incArgs == nil {
+				return errors.Newf("%s requires an incremental backup schedule", k)
+			}
+			// A threshold of 0 stops the compaction of the incremental layers.
+			var threshold int64
+			if v != "0" {
+				var err error
+				if threshold, err = parseIncrementalCompactionThreshold(v); err != nil {
+					return err
+				}
+			}
+			s.incArgs.IncrementalCompactionThreshold = threshold
+		case optVerifyBackups:
+			verifyBackups, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.Wrapf(err, "unexpected value for %s: %s", k, v)
+			}
+			s.fullArgs.VerifyBackups = verifyBackups
+			if s.incArgs == nil {
+				continue
+			}
+			s.incArgs.VerifyBackups = verifyBackups
 		default:
 			return errors.Newf("unexpected schedule option: %s = %s", k, v)
 		}
@@ -348,6 +371,10 @@ func processOptionsForArgs(inOpts tree.BackupOptions, outOpts *tree.BackupOption
 		outOpts.IncludeAllSecondaryTenants = inOpts.IncludeAllSecondaryTenants
 	}
 
+	if inOpts.ContentAddressed != nil {
+		outOpts.ContentAddressed = inOpts.ContentAddressed
+	}
+
 	// If a string-y option is set to empty, interpret this as "unset."
 	if inOpts.EncryptionPassphrase != nil {
 		if tree.AsStringWithFlags(inOpts.EncryptionPassphrase, tree.FmtBareStrings) == "" {
@@ -461,6 +488,7 @@ func processFullBackupRecurrence(
 			*s.fullJob.ScheduleDetails(),
 			jobs.InvalidScheduleID,
 			s.fullArgs.UpdatesLastBackupMetric,
+			s.fullArgs.VerifyBackups,
 			s.incStmt,
 			s.fullArgs.ChainProtectedTimestampRecords,
 		)
@@ -671,6 +699,9 @@ var alterBackupScheduleOptions = exprutil.KVOptionValidationMap{
 	optOnExecFailure:           exprutil.KVStringOptAny,
 	optOnPreviousRunning:       exprutil.KVStringOptAny,
 	optUpdatesLastBackupMetric: exprutil.KVStringOptAny,
+
+	optIncrementalCompactionThreshold: exprutil.KVStringOptAny,
+	optVerifyBackups:                  exprutil.KVStringOptAny,
 }
 
 func alterBackupScheduleTypeCheck(
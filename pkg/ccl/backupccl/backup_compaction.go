@@ -0,0 +1,926 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/build"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/cloud/cloudpb"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/volatility"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// A compaction merges a run of consecutive layers of a backup chain into a
+// single layer, so that restores of the chain read fewer layers. The compacted
+// layer is built from the files of the layers it replaces, without reading the
+// cluster, and it keeps every revision of those layers if all of them were
+// taken with revision history.
+//
+// A compacted incremental layer is written to the incrementals directory of
+// the chain, in a sub-directory named after its end time followed by its start
+// time, which sorts it right after the layer it shares its end time with. The
+// layers it replaces are left in place: resolving the chain to restore from,
+// or to append to, skips them (see backupinfo.ElideCompactedLayers), but a
+// restore to a time before the end of the compacted layer that does not fall
+// on a layer boundary still requires revision history, just like a restore
+// into the middle of any other layer. A compaction that starts at the full
+// backup instead writes a new full backup to the collection, which does not
+// become the LATEST backup of the collection.
+//
+// Compactions are run by the backup job, with BackupDetails.Compact set, and
+// are started with crdb_internal.backup_compaction or by backup schedules
+// with an incremental_compaction_threshold.
+
+// resumeCompaction runs a backup job that compacts layers of a backup chain.
+func (b *backupResumer) resumeCompaction(
+	ctx context.Context, p sql.JobExecContext, details jobspb.BackupDetails,
+) error {
+	execCfg := p.ExecCfg()
+	if err := requireEnterprise(execCfg, "backup compaction"); err != nil {
+		return err
+	}
+	kmsEnv := backupencryption.MakeBackupKMSEnv(
+		execCfg.Settings, &execCfg.ExternalIODirConfig, execCfg.InternalDB, p.User(),
+	)
+	encryption := details.EncryptionOptions
+	if encryption != nil && encryption.Mode == jobspb.EncryptionMode_None {
+		encryption = nil
+	}
+
+	// A compaction that already wrote its manifest is done, and its layer now
+	// replaces the run of layers it was compacted from.
+	if details.URI != "" {
+		done, err := compactionWroteManifest(ctx, execCfg, details.URI, p.User())
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+	chain, err := resolveCompactionChain(ctx, execCfg, p.User(), details, encryption, &kmsEnv, &mem)
+	if err != nil {
+		return err
+	}
+	defer mem.Shrink(ctx, chain.memSize)
+
+	first, last, err := compactionRun(chain.manifests, details.StartTime, details.EndTime)
+	if err != nil {
+		return err
+	}
+	run := chain.manifests[first : last+1]
+	for i := range run {
+		if len(run[i].LocalityKVs) > 0 || len(run[i].PartitionDescriptorFilenames) > 0 {
+			return errors.New("compaction of locality-aware backups is not supported")
+		}
+		if run[i].HasExternalManifestSSTs {
+			return errors.New("compaction of backups with external manifest SSTs is not supported")
+		}
+	}
+
+	if details.URI == "" {
+		var uri string
+		if first == 0 {
+			uri, err = appendURLPath(details.Destination.To[0],
+				run[len(run)-1].EndTime.GoTime().Format(backupbase.DateBasedIntoFolderName))
+		} else {
+			uri, err = appendURLPath(chain.incDir,
+				run[len(run)-1].EndTime.GoTime().Format(backupbase.DateBasedIncFolderName)+
+					run[0].StartTime.GoTime().Format(backupbase.CompactedIncFolderSuffix))
+		}
+		if err != nil {
+			return err
+		}
+		if err := backupinfo.CheckForPreviousBackup(ctx, execCfg, uri, b.job.ID(), p.User()); err != nil {
+			return err
+		}
+		if err := backupinfo.WriteBackupLock(ctx, execCfg, uri, b.job.ID(), p.User()); err != nil {
+			return err
+		}
+		details.URI = uri
+		details.CollectionURI = details.Destination.To[0]
+		details.StartTime = run[0].StartTime
+		details.EndTime = run[len(run)-1].EndTime
+		if err := b.job.NoTxn().Update(ctx, func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+			if err := md.CheckRunningOrReverting(); err != nil {
+				return err
+			}
+			md.Payload.Details = jobspb.WrapPayloadDetails(details)
+			ju.UpdatePayload(md.Payload)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	manifest := makeCompactedManifest(run)
+	manifest.ID = uuid.MakeV4()
+	manifest.BuildInfo = build.GetInfo()
+
+	defaultStore, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, details.URI, p.User())
+	if err != nil {
+		return errors.Wrapf(err, "make storage")
+	}
+	defer defaultStore.Close()
+
+	// Files written by a previous attempt of the job are not reused: each
+	// attempt rewrites the compacted layer, and the manifest only references
+	// the files of the attempt that wrote it.
+	if err := b.compactLayers(ctx, p, run, &manifest, defaultStore, encryption, &kmsEnv); err != nil {
+		return errors.Wrap(err, "compacting backup layers")
+	}
+
+	lastStore, err := execCfg.DistSQLSrv.ExternalStorage(ctx, run[len(run)-1].Dir)
+	if err != nil {
+		return err
+	}
+	defer lastStore.Close()
+	tableStats, err := backupinfo.GetStatisticsFromBackup(ctx, lastStore, encryption, &kmsEnv, run[len(run)-1])
+	if err != nil {
+		return errors.Wrap(err, "reading table statistics of the last compacted layer")
+	}
+	statsTable := backuppb.StatsTable{Statistics: tableStats}
+	if err := backupinfo.WriteTableStatistics(ctx, defaultStore, encryption, &kmsEnv, &statsTable); err != nil {
+		return err
+	}
+	if backupinfo.WriteMetadataSST.Get(&execCfg.Settings.SV) {
+		if err := backupinfo.WriteBackupMetadataSST(ctx, defaultStore, encryption, &kmsEnv, &manifest,
+			statsTable.Statistics); err != nil {
+			err = errors.Wrap(err, "writing forward-compat metadata sst")
+			if !build.IsRelease() {
+				return err
+			}
+			log.Warningf(ctx, "%+v", err)
+		}
+	}
+	// The manifest is written last, as a layer with a manifest is picked up by
+	// the resolution of the chain.
+	if err := backupinfo.WriteBackupManifest(ctx, defaultStore, backupbase.BackupManifestName,
+		encryption, &kmsEnv, &manifest); err != nil {
+		return err
+	}
+	b.backupStats = manifest.EntryCounts
+	return nil
+}
+
+// compactionWroteManifest returns whether the compacted layer at uri has a
+// manifest.
+func compactionWroteManifest(
+	ctx context.Context, execCfg *sql.ExecutorConfig, uri string, user username.SQLUsername,
+) (bool, error) {
+	store, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, uri, user)
+	if err != nil {
+		return false, err
+	}
+	defer store.Close()
+	r, _, err := store.ReadFile(ctx, backupbase.BackupManifestName, cloud.ReadOptions{NoFileSize: true})
+	if err != nil {
+		if errors.Is(err, cloud.ErrFileDoesNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, r.Close(ctx)
+}
+
+func appendURLPath(uri string, tailDir string) (string, error) {
+	uris, err := backuputils.AppendPaths([]string{uri}, tailDir)
+	if err != nil {
+		return "", err
+	}
+	return uris[0], nil
+}
+
+// compactionChain is the chain of layers of a backup, as read by a restore of
+// the chain, along with the directory holding its incremental layers.
+type compactionChain struct {
+	defaultURIs []string
+	manifests   []backuppb.BackupManifest
+	incDir      string
+	memSize     int64
+}
+
+// resolveCompactionChain resolves the chain of the backup in the collection
+// and subdirectory of the destination of a compaction.
+func resolveCompactionChain(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+	mem *mon.BoundAccount,
+) (compactionChain, error) {
+	dest := details.Destination
+	if len(dest.To) != 1 {
+		return compactionChain{}, errors.New("compaction of locality-aware backups is not supported")
+	}
+	fullDirs, err := backuputils.AppendPaths(dest.To, dest.Subdir)
+	if err != nil {
+		return compactionChain{}, err
+	}
+	incDirs, err := backupdest.ResolveIncrementalsBackupLocation(
+		ctx, user, execCfg, dest.IncrementalStorage, dest.To, dest.Subdir)
+	if err != nil {
+		return compactionChain{}, err
+	}
+
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+	baseStores, cleanupBase, err := backupdest.MakeBackupDestinationStores(ctx, user, mkStore, fullDirs)
+	if err != nil {
+		return compactionChain{}, err
+	}
+	defer func() {
+		if err := cleanupBase(); err != nil {
+			log.Warningf(ctx, "failed to close base store: %+v", err)
+		}
+	}()
+	incStores, cleanupInc, err := backupdest.MakeBackupDestinationStores(ctx, user, mkStore, incDirs)
+	if err != nil {
+		return compactionChain{}, err
+	}
+	defer func() {
+		if err := cleanupInc(); err != nil {
+			log.Warningf(ctx, "failed to close incremental store: %+v", err)
+		}
+	}()
+
+	defaultURIs, manifests, _, memSize, err := backupdest.ResolveBackupManifests(
+		ctx, mem, baseStores, incStores, mkStore, fullDirs, incDirs, hlc.Timestamp{} /* endTime */, encryption,
+		kmsEnv, user,
+	)
+	if err != nil {
+		return compactionChain{}, err
+	}
+	return compactionChain{
+		defaultURIs: defaultURIs,
+		manifests:   manifests,
+		incDir:      incDirs[0],
+		memSize:     memSize,
+	}, nil
+}
+
+// compactionRun returns the indexes of the first and the last layer of the run
+// of layers of the chain that a compaction from startTime to endTime merges.
+// The run starts at the full backup if startTime is empty. Layers are matched
+// on the wall time of their start and end times.
+func compactionRun(
+	manifests []backuppb.BackupManifest, startTime, endTime hlc.Timestamp,
+) (first, last int, _ error) {
+	first, last = -1, -1
+	for i := range manifests {
+		if startTime.IsEmpty() {
+			first = 0
+		} else if i > 0 && manifests[i].StartTime.WallTime == startTime.WallTime {
+			first = i
+		}
+		if manifests[i].EndTime.WallTime == endTime.WallTime {
+			last = i
+		}
+	}
+	if first == -1 {
+		return 0, 0, errors.Newf("no layer of the backup chain starts at %s", startTime.GoTime())
+	}
+	if last == -1 {
+		return 0, 0, errors.Newf("no layer of the backup chain ends at %s", endTime.GoTime())
+	}
+	if last <= first {
+		found := last - first + 1
+		if found < 0 {
+			found = 0
+		}
+		return 0, 0, errors.Newf("at least two backup layers are required to compact, found %d", found)
+	}
+	return first, last, nil
+}
+
+// makeCompactedManifest returns the manifest of the layer compacted from the
+// given run of layers, without its files.
+func makeCompactedManifest(run []backuppb.BackupManifest) backuppb.BackupManifest {
+	keepRevisions := true
+	for i := range run {
+		keepRevisions = keepRevisions && run[i].MVCCFilter == backuppb.MVCCFilter_All
+	}
+
+	// The compacted layer describes the state of the chain at the end of the
+	// run, so it takes the descriptors of its last layer.
+	m := run[len(run)-1]
+	m.StartTime = run[0].StartTime
+	m.Dir = cloudpb.ExternalStorage{}
+	m.Files = nil
+	m.EntryCounts = roachpb.RowCount{}
+	m.LocalityKVs = nil
+	m.PartitionDescriptorFilenames = nil
+	m.DeprecatedStatistics = nil
+	m.MVCCFilter = backuppb.MVCCFilter_Latest
+	m.DescriptorChanges = nil
+	m.RevisionStartTime = hlc.Timestamp{}
+	// The compacted layer writes its files to its own directory.
+	m.ContentAddressedDir = ""
+
+	var spans roachpb.Spans
+	if keepRevisions {
+		// With revision history, the run may hold the history of spans which are
+		// no longer backed up by its last layer, such as those of dropped
+		// tables, and which a restore to a time in the run still reads.
+		m.MVCCFilter = backuppb.MVCCFilter_All
+		for i := range run {
+			spans = append(spans, run[i].Spans...)
+			m.DescriptorChanges = append(m.DescriptorChanges, run[i].DescriptorChanges...)
+			m.RevisionStartTime.Forward(run[i].RevisionStartTime)
+		}
+	} else {
+		spans = append(spans, m.Spans...)
+	}
+	m.Spans, _ = roachpb.MergeSpans(&spans)
+
+	m.IntroducedSpans = nil
+	if !m.StartTime.IsEmpty() {
+		var introduced roachpb.Spans
+		for i := range run {
+			for _, sp := range run[i].IntroducedSpans {
+				for _, s := range m.Spans {
+					if overlap := sp.Intersect(s); overlap.Valid() {
+						introduced = append(introduced, overlap)
+					}
+				}
+			}
+		}
+		m.IntroducedSpans, _ = roachpb.MergeSpans(&introduced)
+	}
+	return m
+}
+
+// compactionSpan is a span of the compacted layer, along with the first layer
+// of the run its data is read from. The data of a span which a layer of the run
+// introduced is read from that layer on, as the data of earlier layers may
+// have been removed without leaving MVCC history, e.g. by an IMPORT rollback.
+type compactionSpan struct {
+	span       roachpb.Span
+	firstLayer int
+	introduced bool
+}
+
+// makeCompactionSpans splits the spans of the compacted layer on the
+// boundaries of the spans introduced by the layers of the run.
+func makeCompactionSpans(run []backuppb.BackupManifest, spans roachpb.Spans) []compactionSpan {
+	var res []compactionSpan
+	for _, sp := range spans {
+		bounds := []roachpb.Key{sp.Key, sp.EndKey}
+		for i := range run {
+			for _, in := range run[i].IntroducedSpans {
+				for _, k := range []roachpb.Key{in.Key, in.EndKey} {
+					if sp.ContainsKey(k) && !k.Equal(sp.Key) {
+						bounds = append(bounds, k)
+					}
+				}
+			}
+		}
+		sort.Slice(bounds, func(i, j int) bool { return bounds[i].Compare(bounds[j]) < 0 })
+
+		for i := 0; i+1 < len(bounds); i++ {
+			if bounds[i].Equal(bounds[i+1]) {
+				continue
+			}
+			piece := compactionSpan{span: roachpb.Span{Key: bounds[i], EndKey: bounds[i+1]}}
+			for layer := range run {
+				for _, in := range run[layer].IntroducedSpans {
+					if in.Contains(piece.span) {
+						piece.firstLayer = layer
+						piece.introduced = true
+					}
+				}
+			}
+			if l := len(res) - 1; l >= 0 && res[l].span.EndKey.Equal(piece.span.Key) &&
+				res[l].firstLayer == piece.firstLayer && res[l].introduced == piece.introduced {
+				res[l].span.EndKey = piece.span.EndKey
+				continue
+			}
+			res = append(res, piece)
+		}
+	}
+	return res
+}
+
+// compactLayers writes the data of the run of layers to the files of the
+// compacted layer, adding them to its manifest.
+func (b *backupResumer) compactLayers(
+	ctx context.Context,
+	p sql.JobExecContext,
+	run []backuppb.BackupManifest,
+	manifest *backuppb.BackupManifest,
+	defaultStore cloud.ExternalStorage,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+) error {
+	execCfg := p.ExecCfg()
+
+	var fileEncryption *kvpb.FileEncryptionOptions
+	if encryption != nil {
+		key, err := backupencryption.GetEncryptionKey(ctx, encryption, kmsEnv)
+		if err != nil {
+			return err
+		}
+		fileEncryption = &kvpb.FileEncryptionOptions{Key: key}
+	}
+
+	// Load the files of each layer, and open the stores they live in.
+	iterFactories, err := backupinfo.GetBackupManifestIterFactories(
+		ctx, execCfg.DistSQLSrv.ExternalStorage, run, encryption, kmsEnv)
+	if err != nil {
+		return err
+	}
+	files := make([][]backuppb.BackupManifest_File, len(run))
+	stores := make([]cloud.ExternalStorage, len(run))
+	defer func() {
+		for _, s := range stores {
+			if s != nil {
+				s.Close()
+			}
+		}
+	}()
+	for layer := range run {
+		if stores[layer], err = execCfg.DistSQLSrv.ExternalStorage(ctx, run[layer].Dir); err != nil {
+			return err
+		}
+		if err := func() error {
+			it, err := iterFactories[layer].NewFileIter(ctx)
+			if err != nil {
+				return err
+			}
+			defer it.Close()
+			for ; ; it.Next() {
+				if ok, err := it.Valid(); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+				files[layer] = append(files[layer], *it.Value())
+			}
+		}(); err != nil {
+			return err
+		}
+	}
+
+	pkIDs := make(map[uint64]bool)
+	for i := range manifest.Descriptors {
+		if t, _, _, _, _ := descpb.GetDescriptors(&manifest.Descriptors[i]); t != nil {
+			pkIDs[kvpb.BulkOpSummaryID(uint64(t.ID), uint64(t.PrimaryIndex.ID))] = true
+		}
+	}
+
+	spans := makeCompactionSpans(run, manifest.Spans)
+	progressLogger := jobs.NewChunkProgressLogger(b.job, len(spans), b.job.FractionCompleted(), jobs.ProgressUpdateOnly)
+	requestFinishedCh := make(chan struct{}, len(spans)) // enough buffer to never block
+	progCh := make(chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress)
+
+	var jobProgressLoop func(ctx context.Context) error
+	if len(spans) > 0 {
+		jobProgressLoop = func(ctx context.Context) error {
+			return progressLogger.Loop(ctx, requestFinishedCh)
+		}
+	}
+	collectFiles := func(ctx context.Context) error {
+		defer close(requestFinishedCh)
+		for progress := range progCh {
+			var progDetails backuppb.BackupManifest_Progress
+			if err := gogotypes.UnmarshalAny(&progress.ProgressDetails, &progDetails); err != nil {
+				return err
+			}
+			for _, file := range progDetails.Files {
+				manifest.Files = append(manifest.Files, file)
+				manifest.EntryCounts.Add(file.EntryCounts)
+			}
+			for i := int32(0); i < progDetails.CompletedSpans; i++ {
+				requestFinishedCh <- struct{}{}
+			}
+		}
+		return nil
+	}
+	writeSpans := func(ctx context.Context) error {
+		defer close(progCh)
+		sink := makeFileSSTSink(sstSinkConf{
+			progCh:   progCh,
+			enc:      fileEncryption,
+			id:       execCfg.NodeInfo.NodeID.SQLInstanceID(),
+			settings: &execCfg.Settings.SV,
+		}, defaultStore)
+		defer func() {
+			if err := sink.Close(); err != nil {
+				log.Warningf(ctx, "failed to close backup compaction sink: %+v", err)
+			}
+		}()
+		w := compactionWriter{
+			sink:          sink,
+			manifest:      manifest,
+			pkIDs:         pkIDs,
+			keepRevisions: manifest.MVCCFilter == backuppb.MVCCFilter_All,
+		}
+		for _, sp := range spans {
+			var storeFiles []storageccl.StoreFile
+			for layer := sp.firstLayer; layer < len(run); layer++ {
+				for _, f := range files[layer] {
+					if f.Span.Overlaps(sp.span) {
+						storeFiles = append(storeFiles, storageccl.StoreFile{Store: stores[layer], FilePath: f.Path})
+					}
+				}
+			}
+			if err := w.writeSpan(ctx, execCfg, sp, storeFiles, fileEncryption); err != nil {
+				return err
+			}
+		}
+		return sink.flush(ctx)
+	}
+	return ctxgroup.GoAndWait(ctx, jobProgressLoop, collectFiles, writeSpans)
+}
+
+// compactionWriter merges the data of the layers of a run for each span of the
+// compacted layer into files of the compacted layer.
+type compactionWriter struct {
+	sink          *fileSSTSink
+	manifest      *backuppb.BackupManifest
+	pkIDs         map[uint64]bool
+	keepRevisions bool
+
+	// The chunk of the span currently being written.
+	buf        bytes.Buffer
+	sst        storage.SSTWriter
+	chunkStart roachpb.Key
+	rows       storage.RowCounter
+	// rangeKeys are the range keys overlapping the chunk, which are written
+	// once the end of the chunk is known.
+	rangeKeys []storage.MVCCRangeKeyStack
+}
+
+func (w *compactionWriter) writeSpan(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	sp compactionSpan,
+	storeFiles []storageccl.StoreFile,
+	enc *kvpb.FileEncryptionOptions,
+) error {
+	if len(storeFiles) == 0 {
+		w.sink.writeWithNoData(exportedSpan{completedSpans: 1})
+		return nil
+	}
+	iter, err := storageccl.ExternalSSTReader(ctx, storeFiles, enc, storage.IterOptions{
+		KeyTypes:   storage.IterKeyTypePointsAndRanges,
+		LowerBound: sp.span.Key,
+		UpperBound: sp.span.EndKey,
+	})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	// A compacted full backup without revision history only holds the live
+	// values at its end time.
+	var it storage.SimpleMVCCIterator = iter
+	fullLatest := w.manifest.StartTime.IsEmpty() && !w.keepRevisions
+	if fullLatest {
+		it = storage.NewReadAsOfIterator(iter, hlc.Timestamp{})
+	}
+
+	w.startChunk(ctx, execCfg, sp.span.Key)
+	var wrote bool
+	var prevKey roachpb.Key
+	for it.SeekGE(storage.MVCCKey{Key: sp.span.Key}); ; {
+		if ok, err := it.Valid(); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		hasPoint, hasRange := it.HasPointAndRange()
+		if hasRange && !fullLatest && it.RangeKeyChanged() {
+			w.rangeKeys = append(w.rangeKeys, it.RangeKeys().Clone())
+		}
+		if !hasPoint {
+			it.Next()
+			continue
+		}
+
+		key := it.UnsafeKey()
+		newKey := !key.Key.Equal(prevKey)
+		if newKey && w.rows.DataSize > targetFileSize.Get(&execCfg.Settings.SV) {
+			// Only split chunks between keys, so that all the revisions of a key
+			// are in the same file.
+			if err := w.finishChunk(ctx, sp, key.Key.Clone(), 0 /* completedSpans */); err != nil {
+				return err
+			}
+			w.startChunk(ctx, execCfg, key.Key.Clone())
+		}
+		v, err := it.UnsafeValue()
+		if err != nil {
+			return err
+		}
+		if key.Timestamp.IsEmpty() {
+			err = w.sst.PutUnversioned(key.Key, v)
+		} else {
+			err = w.sst.PutRawMVCC(key, v)
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.rows.Count(key.Key); err != nil {
+			return err
+		}
+		w.rows.DataSize += int64(len(key.Key) + len(v))
+		wrote = true
+		prevKey = append(prevKey[:0], key.Key...)
+
+		if w.keepRevisions {
+			it.Next()
+		} else {
+			// Without revision history, only the latest revision of each key in
+			// the run is kept, which may be a deletion.
+			it.NextKey()
+		}
+	}
+	if !wrote && len(w.rangeKeys) == 0 {
+		w.sst.Close()
+		w.sink.writeWithNoData(exportedSpan{completedSpans: 1})
+		return nil
+	}
+	return w.finishChunk(ctx, sp, sp.span.EndKey, 1 /* completedSpans */)
+}
+
+func (w *compactionWriter) startChunk(
+	ctx context.Context, execCfg *sql.ExecutorConfig, start roachpb.Key,
+) {
+	w.buf.Reset()
+	w.sst = storage.MakeBackupSSTWriter(ctx, execCfg.Settings, &w.buf)
+	w.chunkStart = start
+	w.rows = storage.RowCounter{}
+}
+
+// finishChunk writes the range keys overlapping the chunk ending at end, and
+// hands the chunk to the sink.
+func (w *compactionWriter) finishChunk(
+	ctx context.Context, sp compactionSpan, end roachpb.Key, completedSpans int32,
+) error {
+	chunk := roachpb.Span{Key: w.chunkStart, EndKey: end}
+	var remaining []storage.MVCCRangeKeyStack
+	for _, rk := range w.rangeKeys {
+		bounds := rk.Bounds.Intersect(chunk)
+		if bounds.Valid() {
+			clipped := rk
+			clipped.Bounds = bounds
+			for _, v := range clipped.Versions {
+				if err := w.sst.PutRawMVCCRangeKey(clipped.AsRangeKey(v), v.Value); err != nil {
+					return err
+				}
+			}
+		}
+		if end.Compare(rk.Bounds.EndKey) < 0 {
+			remaining = append(remaining, rk)
+		}
+	}
+	w.rangeKeys = remaining
+
+	if err := w.sst.Finish(); err != nil {
+		return err
+	}
+	w.sst.Close()
+
+	file := backuppb.BackupManifest_File{
+		Span:        chunk,
+		EntryCounts: countRows(w.rows.BulkOpSummary, w.pkIDs),
+	}
+	if sp.introduced {
+		file.EndTime = w.manifest.EndTime
+	}
+	return w.sink.write(ctx, exportedSpan{
+		metadata:       file,
+		dataSST:        append([]byte(nil), w.buf.Bytes()...),
+		completedSpans: completedSpans,
+		atKeyBoundary:  true,
+	})
+}
+
+// maybeStartScheduledCompaction starts a job that compacts the incremental
+// layers of the chain that a scheduled incremental backup was appended to, if
+// the chain has reached the incremental compaction threshold of the schedule.
+// Errors are only logged, as the backup itself succeeded.
+func (b *backupResumer) maybeStartScheduledCompaction(
+	ctx context.Context, p sql.JobExecContext, details jobspb.BackupDetails,
+) {
+	if err := func() error {
+		execCfg := p.ExecCfg()
+		env := scheduledjobs.ProdJobSchedulerEnv
+		if knobs := execCfg.JobsKnobs(); knobs != nil && knobs.JobSchedulerEnv != nil {
+			env = knobs.JobSchedulerEnv
+		}
+		var args *backuppb.ScheduledBackupExecutionArgs
+		if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+			var err error
+			_, args, err = getScheduledBackupExecutionArgsFromSchedule(
+				ctx, env, jobs.ScheduledJobTxn(txn), details.ScheduleID)
+			return err
+		}); err != nil {
+			return err
+		}
+		if args.IncrementalCompactionThreshold <= 0 {
+			return nil
+		}
+
+		compaction, err := makeScheduledCompactionDetails(details)
+		if err != nil {
+			return err
+		}
+		kmsEnv := backupencryption.MakeBackupKMSEnv(
+			execCfg.Settings, &execCfg.ExternalIODirConfig, execCfg.InternalDB, p.User(),
+		)
+		mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+		defer mem.Close(ctx)
+		chain, err := resolveCompactionChain(ctx, execCfg, p.User(), compaction,
+			compaction.EncryptionOptions, &kmsEnv, &mem)
+		if err != nil {
+			return err
+		}
+		defer mem.Shrink(ctx, chain.memSize)
+		if int64(len(chain.manifests)-1) < args.IncrementalCompactionThreshold {
+			return nil
+		}
+
+		compaction.StartTime = chain.manifests[0].EndTime
+		compaction.EndTime = chain.manifests[len(chain.manifests)-1].EndTime
+		record := jobs.Record{
+			Description: compactionJobDescription(compaction),
+			Username:    p.User(),
+			Details:     compaction,
+			Progress:    jobspb.BackupProgress{},
+		}
+		return execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+			jobID := execCfg.JobRegistry.MakeJobID()
+			if _, err := execCfg.JobRegistry.CreateAdoptableJobWithTxn(ctx, record, jobID, txn); err != nil {
+				return err
+			}
+			log.Infof(ctx, "backup job %d started compaction job %d of %d incremental layers",
+				b.job.ID(), jobID, len(chain.manifests)-1)
+			return nil
+		})
+	}(); err != nil {
+		log.Warningf(ctx, "failed to start compaction of the backup chain: %+v", err)
+	}
+}
+
+// makeScheduledCompactionDetails returns the details of a compaction of the
+// chain that the resolved incremental backup was appended to.
+func makeScheduledCompactionDetails(details jobspb.BackupDetails) (jobspb.BackupDetails, error) {
+	if len(details.URIsByLocalityKV) > 0 {
+		return jobspb.BackupDetails{}, errors.New("compaction of locality-aware backups is not supported")
+	}
+	// The incremental layer lives in <incrementals>/<subdir>/<date>/<time>.
+	u, err := url.Parse(details.URI)
+	if err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+	chainDir := path.Dir(path.Dir(path.Clean(u.Path)))
+	subdir := path.Clean("/" + details.Destination.Subdir)
+	if !strings.HasSuffix(chainDir, subdir) {
+		return jobspb.BackupDetails{}, errors.Newf("unexpected incremental backup path %s",
+			backuputils.RedactURIForErrorMessage(details.URI))
+	}
+	u.Path = strings.TrimSuffix(chainDir, subdir)
+
+	return jobspb.BackupDetails{
+		Compact: true,
+		Destination: jobspb.BackupDetails_Destination{
+			To:                 []string{details.CollectionURI},
+			Subdir:             details.Destination.Subdir,
+			IncrementalStorage: []string{u.String()},
+		},
+		EncryptionOptions: details.EncryptionOptions,
+	}, nil
+}
+
+func compactionJobDescription(details jobspb.BackupDetails) string {
+	collection := backuputils.RedactURIForErrorMessage(details.Destination.To[0])
+	if details.StartTime.IsEmpty() {
+		return fmt.Sprintf("compacting backup %s in %s up to %s into a full backup",
+			details.Destination.Subdir, collection, details.EndTime.GoTime())
+	}
+	return fmt.Sprintf("compacting backup %s in %s from %s to %s",
+		details.Destination.Subdir, collection, details.StartTime.GoTime(), details.EndTime.GoTime())
+}
+
+func init() {
+	utilccl.RegisterCCLBuiltin("crdb_internal.backup_compaction",
+		`Starts a job that compacts the layers of the backup in the collection and subdirectory, `+
+			`from the layer starting at start_time to the layer ending at end_time, into a single `+
+			`incremental backup, or into a new full backup if start_time is NULL. Returns the job ID.`,
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "collection_uri", Typ: types.String},
+				{Name: "subdir", Typ: types.String},
+				{Name: "start_time", Typ: types.TimestampTZ},
+				{Name: "end_time", Typ: types.TimestampTZ},
+			},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				if args[0] == tree.DNull || args[1] == tree.DNull || args[3] == tree.DNull {
+					return nil, pgerror.New(pgcode.InvalidParameterValue,
+						"collection_uri, subdir and end_time must not be NULL")
+				}
+				isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+				if err != nil {
+					return nil, err
+				}
+				if !isAdmin {
+					return nil, pgerror.New(pgcode.InsufficientPrivilege,
+						"only users with the admin role are allowed to compact backups")
+				}
+				p, ok := evalCtx.Planner.(sql.PlanHookState)
+				if !ok {
+					return nil, errors.AssertionFailedf("unexpected planner type %T", evalCtx.Planner)
+				}
+				if err := requireEnterprise(p.ExecCfg(), "backup compaction"); err != nil {
+					return nil, err
+				}
+
+				details := jobspb.BackupDetails{
+					Compact: true,
+					Destination: jobspb.BackupDetails_Destination{
+						To:     []string{string(tree.MustBeDString(args[0]))},
+						Subdir: string(tree.MustBeDString(args[1])),
+					},
+					EndTime: hlc.Timestamp{WallTime: tree.MustBeDTimestampTZ(args[3]).UnixNano()},
+				}
+				if args[2] != tree.DNull {
+					details.StartTime = hlc.Timestamp{WallTime: tree.MustBeDTimestampTZ(args[2]).UnixNano()}
+				}
+				if details.Destination.Subdir == backupbase.LatestFileName {
+					subdir, err := backupdest.ReadLatestFile(ctx, details.Destination.To[0],
+						p.ExecCfg().DistSQLSrv.ExternalStorageFromURI, p.User())
+					if err != nil {
+						return nil, errors.Wrap(err, "read LATEST path")
+					}
+					details.Destination.Subdir = subdir
+				}
+
+				record := jobs.Record{
+					Description: compactionJobDescription(details),
+					Username:    p.User(),
+					Details:     details,
+					Progress:    jobspb.BackupProgress{},
+				}
+				jobID := p.ExecCfg().JobRegistry.MakeJobID()
+				if _, err := p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(
+					ctx, record, jobID, p.InternalSQLTxn(),
+				); err != nil {
+					return nil, err
+				}
+				return tree.NewDInt(tree.DInt(jobID)), nil
+			},
+			Class:             tree.NormalClass,
+			CalledOnNullInput: true,
+			Volatility:        volatility.Volatile,
+		})
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func compactionTestSpan(start, end string) roachpb.Span {
+	return roachpb.Span{Key: roachpb.Key(start), EndKey: roachpb.Key(end)}
+}
+
+func TestCompactionRun(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(wall int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wall} }
+	chain := []backuppb.BackupManifest{
+		{EndTime: ts(10)},
+		{StartTime: ts(10), EndTime: ts(20)},
+		{StartTime: ts(20), EndTime: ts(30)},
+		{StartTime: ts(30), EndTime: ts(40)},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		start, end  hlc.Timestamp
+		first, last int
+		err         string
+	}{
+		{name: "incrementals", start: ts(10), end: ts(40), first: 1, last: 3},
+		{name: "full", end: ts(30), first: 0, last: 2},
+		{name: "logical time ignored", start: hlc.Timestamp{WallTime: 20, Logical: 1}, end: ts(40),
+			first: 2, last: 3},
+		{name: "no start", start: ts(15), end: ts(40), err: "no layer of the backup chain starts at"},
+		{name: "no end", start: ts(10), end: ts(35), err: "no layer of the backup chain ends at"},
+		{name: "single layer", start: ts(20), end: ts(30),
+			err: "at least two backup layers are required to compact, found 1"},
+		{name: "reversed", start: ts(30), end: ts(20),
+			err: "at least two backup layers are required to compact, found 0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			first, last, err := compactionRun(chain, tc.start, tc.end)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.first, first)
+			require.Equal(t, tc.last, last)
+		})
+	}
+}
+
+func TestMakeCompactedManifest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(wall int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wall} }
+	run := []backuppb.BackupManifest{
+		{
+			StartTime:         ts(10),
+			EndTime:           ts(20),
+			MVCCFilter:        backuppb.MVCCFilter_All,
+			RevisionStartTime: ts(10),
+			Spans:             roachpb.Spans{compactionTestSpan("a", "c"), compactionTestSpan("x", "z")},
+			Files:             []backuppb.BackupManifest_File{{Path: "1.sst"}},
+			EntryCounts:       roachpb.RowCount{Rows: 1},
+		},
+		{
+			StartTime:         ts(20),
+			EndTime:           ts(30),
+			MVCCFilter:        backuppb.MVCCFilter_All,
+			RevisionStartTime: ts(15),
+			Spans:             roachpb.Spans{compactionTestSpan("a", "e")},
+			IntroducedSpans:   roachpb.Spans{compactionTestSpan("c", "f")},
+			Files:             []backuppb.BackupManifest_File{{Path: "2.sst"}},
+			EntryCounts:       roachpb.RowCount{Rows: 2},
+		},
+	}
+
+	t.Run("revision history", func(t *testing.T) {
+		m := makeCompactedManifest(run)
+		require.Equal(t, ts(10), m.StartTime)
+		require.Equal(t, ts(30), m.EndTime)
+		require.Equal(t, backuppb.MVCCFilter_All, m.MVCCFilter)
+		require.Equal(t, ts(15), m.RevisionStartTime)
+		// The spans of dropped tables are kept for restores within the run.
+		require.Equal(t, roachpb.Spans{compactionTestSpan("a", "e"), compactionTestSpan("x", "z")}, m.Spans)
+		// Introduced spans are clipped to the spans of the layer.
+		require.Equal(t, roachpb.Spans{compactionTestSpan("c", "e")}, m.IntroducedSpans)
+		require.Nil(t, m.Files)
+		require.Equal(t, roachpb.RowCount{}, m.EntryCounts)
+		// The layers of the run are left unchanged.
+		require.Len(t, run[1].Files, 1)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		latestRun := append([]backuppb.BackupManifest(nil), run...)
+		latestRun[0].MVCCFilter = backuppb.MVCCFilter_Latest
+		m := makeCompactedManifest(latestRun)
+		require.Equal(t, backuppb.MVCCFilter_Latest, m.MVCCFilter)
+		require.True(t, m.RevisionStartTime.IsEmpty())
+		require.Equal(t, roachpb.Spans{compactionTestSpan("a", "e")}, m.Spans)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		fullRun := append([]backuppb.BackupManifest(nil), run...)
+		fullRun[0].StartTime = hlc.Timestamp{}
+		m := makeCompactedManifest(fullRun)
+		require.True(t, m.StartTime.IsEmpty())
+		require.Nil(t, m.IntroducedSpans)
+	})
+}
+
+func TestMakeCompactionSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	run := []backuppb.BackupManifest{
+		{IntroducedSpans: roachpb.Spans{compactionTestSpan("b", "c")}},
+		{IntroducedSpans: roachpb.Spans{compactionTestSpan("b", "d")}},
+		{IntroducedSpans: roachpb.Spans{compactionTestSpan("g", "h")}},
+	}
+	spans := roachpb.Spans{compactionTestSpan("a", "f"), compactionTestSpan("g", "k")}
+	require.Equal(t, []compactionSpan{
+		{span: compactionTestSpan("a", "b")},
+		{span: compactionTestSpan("b", "d"), firstLayer: 1, introduced: true},
+		{span: compactionTestSpan("d", "f")},
+		{span: compactionTestSpan("g", "h"), firstLayer: 2, introduced: true},
+		{span: compactionTestSpan("h", "k")},
+	}, makeCompactionSpans(run, spans))
+}
+
+func TestMakeScheduledCompactionDetails(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	details := jobspb.BackupDetails{
+		URI:           "nodelocal://1/inc/2023/01/02-030405.00/20230103/040506.00?AUTH=implicit",
+		CollectionURI: "nodelocal://1/full?AUTH=implicit",
+		Destination:   jobspb.BackupDetails_Destination{Subdir: "2023/01/02-030405.00"},
+	}
+	compaction, err := makeScheduledCompactionDetails(details)
+	require.NoError(t, err)
+	require.True(t, compaction.Compact)
+	require.Equal(t, jobspb.BackupDetails_Destination{
+		To:                 []string{"nodelocal://1/full?AUTH=implicit"},
+		Subdir:             "2023/01/02-030405.00",
+		IncrementalStorage: []string{"nodelocal://1/inc?AUTH=implicit"},
+	}, compaction.Destination)
+
+	details.URIsByLocalityKV = map[string]string{"region=east": "nodelocal://2/inc"}
+	_, err = makeScheduledCompactionDetails(details)
+	require.ErrorContains(t, err, "locality-aware backups")
+}
@@ -0,0 +1,403 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/volatility"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// A content-addressed backup writes its data files to the
+// backupbase.ContentAddressedDirectory of its collection, named by the SHA-256
+// hash of their contents, and skips the files that are already there. The
+// files record their path relative to the directory of the backup, like any
+// other data file, so the readers of a backup need not know whether it is
+// content-addressed. A file in the content-addressed directory may be
+// referenced by any number of backups in the collection, so deleting a backup
+// does not delete its files; crdb_internal.backup_collection_gc deletes the
+// files that no backup in the collection references anymore.
+
+// contentAddressedGCGracePeriod is the minimum time between garbage collection
+// finding a content-addressed file unreferenced and deleting it.
+var contentAddressedGCGracePeriod = settings.RegisterDurationSetting(
+	settings.TenantWritable,
+	"bulkio.backup.content_addressed.gc_grace_period",
+	"the minimum time a content-addressed backup file must stay unreferenced by the "+
+		"backups of its collection before crdb_internal.backup_collection_gc deletes it",
+	24*time.Hour,
+	settings.NonNegativeDuration,
+)
+
+// checkContentAddressedBackupOptions returns an error if the options of a
+// BACKUP statement are not supported for content-addressed backups, which
+// must be written into a collection whose directory holds all of their
+// layers.
+func checkContentAddressedBackupOptions(
+	backupStmt *tree.Backup, to []string, incrementalStorage []string, mode jobspb.EncryptionMode,
+) error {
+	if !backupStmt.Nested {
+		return errors.New("the content_addressed option is not supported with `BACKUP TO` syntax")
+	}
+	if len(to) > 1 {
+		return errors.New("the content_addressed option is not supported for locality aware backups")
+	}
+	if len(incrementalStorage) > 0 {
+		return errors.New("the content_addressed option is not supported with the incremental_location option")
+	}
+	if mode != jobspb.EncryptionMode_None {
+		return errors.New("the content_addressed option is not supported for encrypted backups")
+	}
+	return nil
+}
+
+// contentAddressedDirForBackup returns the path of the content-addressed
+// directory of the collection relative to the directory of a backup in it.
+func contentAddressedDirForBackup(collectionURI, backupURI string) (string, error) {
+	collection, err := url.Parse(collectionURI)
+	if err != nil {
+		return "", err
+	}
+	backup, err := url.Parse(backupURI)
+	if err != nil {
+		return "", err
+	}
+	collectionPath := strings.TrimSuffix(path.Clean("/"+collection.Path), "/")
+	backupPath := path.Clean("/" + backup.Path)
+	if collection.Scheme != backup.Scheme || collection.Host != backup.Host ||
+		!strings.HasPrefix(backupPath, collectionPath+"/") {
+		return "", errors.Newf("backup %s is not in collection %s",
+			backuputils.RedactURIForErrorMessage(backupURI),
+			backuputils.RedactURIForErrorMessage(collectionURI))
+	}
+	depth := len(strings.Split(strings.TrimPrefix(backupPath, collectionPath+"/"), "/"))
+	return strings.Repeat("../", depth) + backupbase.ContentAddressedDirectory, nil
+}
+
+// contentAddressedSSTName returns the name of the content-addressed file with
+// the given contents.
+func contentAddressedSSTName(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + ".sst"
+}
+
+// sameCollection returns true if the two URIs point to the same location,
+// ignoring their parameters.
+func sameCollection(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Scheme == ub.Scheme && ua.Host == ub.Host &&
+		path.Clean("/"+ua.Path) == path.Clean("/"+ub.Path)
+}
+
+// checkNoContentAddressedBackupRunning returns an error if a content-addressed
+// backup into the collection has not finished, since it may reference files
+// in the content-addressed directory that are not yet referenced by any of the
+// manifests of the collection.
+func checkNoContentAddressedBackupRunning(
+	ctx context.Context, db isql.DB, collectionURI string,
+) error {
+	return db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		rows, err := txn.QueryBufferedEx(ctx, "find-content-addressed-backups", txn.KV(),
+			sessiondata.NodeUserSessionDataOverride,
+			`SELECT id, payload FROM crdb_internal.system_jobs WHERE job_type = $1 AND status IN `+
+				jobs.NonTerminalStatusTupleString,
+			jobspb.TypeBackup.String(),
+		)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			payload, err := jobs.UnmarshalPayload(row[1])
+			if err != nil {
+				return err
+			}
+			details := payload.GetBackup()
+			if details == nil || !details.ContentAddressed {
+				continue
+			}
+			dest := details.CollectionURI
+			if dest == "" && len(details.Destination.To) > 0 {
+				dest = details.Destination.To[0]
+			}
+			if sameCollection(dest, collectionURI) {
+				return errors.Newf("content-addressed backup job %d into the collection has not finished",
+					tree.MustBeDInt(row[0]))
+			}
+		}
+		return nil
+	})
+}
+
+// referencedContentAddressedFiles returns the names of the files in the
+// content-addressed directory of the collection that are referenced by a
+// backup in the collection.
+func referencedContentAddressedFiles(
+	ctx context.Context, execCfg *sql.ExecutorConfig, collectionURI string, user username.SQLUsername,
+) (map[string]struct{}, error) {
+	collection, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, collectionURI, user)
+	if err != nil {
+		return nil, err
+	}
+	defer collection.Close()
+
+	// Every layer of a backup in the collection, full, incremental or
+	// compacted, has a manifest in its own directory.
+	var dirs []string
+	if err := collection.List(ctx, "", backupbase.ListingDelimDataSlash, func(f string) error {
+		if path.Base(f) == backupbase.BackupManifestName {
+			dirs = append(dirs, strings.TrimPrefix(path.Dir(f), "/"))
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "listing backups in collection")
+	}
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+	referenced := make(map[string]struct{})
+	for _, dir := range dirs {
+		if err := func() error {
+			dirURIs, err := backuputils.AppendPaths([]string{collectionURI}, dir)
+			if err != nil {
+				return err
+			}
+			store, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, dirURIs[0], user)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+			// Content-addressed backups are never encrypted, so a manifest that
+			// cannot be read without a key is an error rather than one that
+			// can be skipped.
+			m, memSize, err := backupinfo.ReadBackupManifestFromStore(ctx, &mem, store,
+				nil /* encryption */, nil /* kmsEnv */)
+			if err != nil {
+				return err
+			}
+			defer mem.Shrink(ctx, memSize)
+			it, err := backupinfo.NewIterFactory(&m, store, nil /* encryption */, nil /* kmsEnv */).NewFileIter(ctx)
+			if err != nil {
+				return err
+			}
+			defer it.Close()
+			for ; ; it.Next() {
+				if ok, err := it.Valid(); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+				f := it.Value()
+				if !f.ContentAddressed {
+					continue
+				}
+				p := path.Join(dir, f.Path)
+				if path.Dir(p) != backupbase.ContentAddressedDirectory {
+					return errors.AssertionFailedf("content-addressed file %s of backup %s is not in %s",
+						f.Path, dir, backupbase.ContentAddressedDirectory)
+				}
+				referenced[path.Base(p)] = struct{}{}
+			}
+		}(); err != nil {
+			return nil, errors.Wrapf(err, "reading backup %s", dir)
+		}
+	}
+	return referenced, nil
+}
+
+// readContentAddressedGCMarks reads the file at the given path which maps the
+// names of the content-addressed files that garbage collection marked for
+// deletion to the time, in nanoseconds since the epoch, at which they were
+// first found unreferenced. It returns an empty map if there is no such file.
+func readContentAddressedGCMarks(
+	ctx context.Context, store cloud.ExternalStorage, marksPath string,
+) (map[string]int64, error) {
+	marks := make(map[string]int64)
+	r, _, err := store.ReadFile(ctx, marksPath, cloud.ReadOptions{NoFileSize: true})
+	if errors.Is(err, cloud.ErrFileDoesNotExist) {
+		return marks, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading content-addressed garbage collection marks")
+	}
+	defer r.Close(ctx)
+	data, err := ioctx.ReadAll(ctx, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading content-addressed garbage collection marks")
+	}
+	if err := json.Unmarshal(data, &marks); err != nil {
+		return nil, errors.Wrap(err, "decoding content-addressed garbage collection marks")
+	}
+	return marks, nil
+}
+
+// gcBackupCollection deletes the files in the content-addressed directory of
+// the collection that are not referenced by any backup in it, and returns the
+// number of files deleted.
+//
+// A backup that is running may be reusing files which none of the manifests
+// of the collection reference yet, and a backup may start at any time while
+// the files are being deleted. Therefore, the files are deleted in two phases:
+// a file that is found unreferenced is first marked for deletion, and is only
+// deleted by a later garbage collection, at least
+// bulkio.backup.content_addressed.gc_grace_period later, if it is still
+// unreferenced and no content-addressed backup into the collection is running.
+// The backups never reuse the files which are marked, so any backup that could
+// have reused a deleted file was running when it was marked and has either
+// finished, in which case its manifest is read, or is still running, in which
+// case the garbage collection fails.
+func gcBackupCollection(
+	ctx context.Context, execCfg *sql.ExecutorConfig, collectionURI string, user username.SQLUsername,
+) (int, error) {
+	if err := checkNoContentAddressedBackupRunning(ctx, execCfg.InternalDB, collectionURI); err != nil {
+		return 0, err
+	}
+
+	sharedURIs, err := backuputils.AppendPaths([]string{collectionURI}, path.Dir(backupbase.ContentAddressedDirectory))
+	if err != nil {
+		return 0, err
+	}
+	shared, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, sharedURIs[0], user)
+	if err != nil {
+		return 0, err
+	}
+	defer shared.Close()
+	dataDir := path.Base(backupbase.ContentAddressedDirectory)
+
+	marks, err := readContentAddressedGCMarks(ctx, shared, backupbase.ContentAddressedGCMarksName)
+	if err != nil {
+		return 0, err
+	}
+	// The files are listed before the manifests are read, so a file written by
+	// a backup that finishes after the listing is never a candidate for
+	// deletion, even though the manifest of that backup may not have been read.
+	var files []string
+	if err := shared.List(ctx, dataDir+"/", "", func(f string) error {
+		files = append(files, strings.TrimPrefix(f, "/"))
+		return nil
+	}); err != nil {
+		return 0, errors.Wrap(err, "listing content-addressed files")
+	}
+	referenced, err := referencedContentAddressedFiles(ctx, execCfg, collectionURI, user)
+	if err != nil {
+		return 0, err
+	}
+
+	now := timeutil.Now().UnixNano()
+	gracePeriod := contentAddressedGCGracePeriod.Get(&execCfg.Settings.SV).Nanoseconds()
+	newMarks := make(map[string]int64)
+	var toDelete []string
+	for _, f := range files {
+		if _, ok := referenced[f]; ok {
+			continue
+		}
+		markedAt, ok := marks[f]
+		if !ok {
+			markedAt = now
+		} else if now-markedAt >= gracePeriod {
+			toDelete = append(toDelete, f)
+		}
+		newMarks[f] = markedAt
+	}
+	// The files to delete stay marked until a later garbage collection no
+	// longer finds them, so that the backups don't reuse them in the meantime.
+	data, err := json.Marshal(newMarks)
+	if err != nil {
+		return 0, err
+	}
+	if err := cloud.WriteFile(ctx, shared, backupbase.ContentAddressedGCMarksName, bytes.NewReader(data)); err != nil {
+		return 0, errors.Wrap(err, "writing content-addressed garbage collection marks")
+	}
+
+	var deleted int
+	for _, f := range toDelete {
+		if err := shared.Delete(ctx, path.Join(dataDir, f)); err != nil {
+			return deleted, errors.Wrapf(err, "deleting content-addressed file %s", f)
+		}
+		deleted++
+	}
+	log.Infof(ctx, "deleted %d of %d content-addressed files in %s, %d remain marked for deletion",
+		deleted, len(files), backuputils.RedactURIForErrorMessage(collectionURI), len(newMarks)-deleted)
+	return deleted, nil
+}
+
+func init() {
+	utilccl.RegisterCCLBuiltin("crdb_internal.backup_collection_gc",
+		`Deletes the data files written by content-addressed backups into the collection `+
+			`that are no longer referenced by any backup in it, such as after the backups that `+
+			`referenced them were deleted. Files found unreferenced are first marked for deletion, `+
+			`and are deleted by a later call once bulkio.backup.content_addressed.gc_grace_period `+
+			`has elapsed. Returns the number of files deleted. Fails if a content-addressed backup `+
+			`into the collection is running or paused.`,
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "collection_uri", Typ: types.String},
+			},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+				if err != nil {
+					return nil, err
+				}
+				if !isAdmin {
+					return nil, pgerror.New(pgcode.InsufficientPrivilege,
+						"only users with the admin role are allowed to garbage collect backups")
+				}
+				p, ok := evalCtx.Planner.(sql.PlanHookState)
+				if !ok {
+					return nil, errors.AssertionFailedf("unexpected planner type %T", evalCtx.Planner)
+				}
+				if err := requireEnterprise(p.ExecCfg(), "content_addressed"); err != nil {
+					return nil, err
+				}
+				deleted, err := gcBackupCollection(
+					ctx, p.ExecCfg(), string(tree.MustBeDString(args[0])), p.User())
+				if err != nil {
+					return nil, err
+				}
+				return tree.NewDInt(tree.DInt(deleted)), nil
+			},
+			Class:      tree.NormalClass,
+			Volatility: volatility.Volatile,
+		})
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentAddressedDirForBackup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		collection, backup, expected, err string
+	}{
+		{collection: "nodelocal://1/foo", backup: "nodelocal://1/foo/2024/01/02-030405.00",
+			expected: "../../../shared/data"},
+		{collection: "s3://bucket/foo/?AUTH=implicit",
+			backup:   "s3://bucket/foo/incrementals/2024/01/02-030405.00/20240103/030405.00?AUTH=implicit",
+			expected: "../../../../../../shared/data"},
+		{collection: "gs://bucket", backup: "gs://bucket/mysubdir", expected: "../shared/data"},
+		{collection: "nodelocal://1/foo", backup: "nodelocal://1/foobar/x", err: "is not in collection"},
+		{collection: "nodelocal://1/foo", backup: "nodelocal://2/foo/x", err: "is not in collection"},
+	} {
+		dir, err := contentAddressedDirForBackup(tc.collection, tc.backup)
+		if tc.err != "" {
+			require.ErrorContains(t, err, tc.err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, dir)
+	}
+}
+
+func TestContentAddressedBackup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	_, sqlDB, dir, cleanupFn := backupRestoreTestSetup(t, singleNode, 0, InitManualReplication)
+	defer cleanupFn()
+
+	const collection = "nodelocal://1/ca"
+	sharedFiles := func() []string {
+		entries, err := os.ReadDir(filepath.Join(dir, "ca", backupbase.ContentAddressedDirectory))
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+	fullBackups := func() []string {
+		var subdirs []string
+		for _, row := range sqlDB.QueryStr(t, `SHOW BACKUPS IN $1`, collection) {
+			subdirs = append(subdirs, row[0])
+		}
+		return subdirs
+	}
+	gc := func() string {
+		return sqlDB.QueryStr(t, `SELECT crdb_internal.backup_collection_gc($1)`, collection)[0][0]
+	}
+
+	sqlDB.ExpectErr(t, "not supported with `BACKUP TO` syntax",
+		`BACKUP TABLE data.bank TO 'nodelocal://1/to' WITH content_addressed`)
+	sqlDB.ExpectErr(t, "not supported for encrypted backups",
+		`BACKUP TABLE data.bank INTO $1 WITH content_addressed, encryption_passphrase = 'abc'`, collection)
+
+	sqlDB.Exec(t, `CREATE TABLE data.t (k INT PRIMARY KEY, v STRING)`)
+	sqlDB.Exec(t, `INSERT INTO data.t SELECT i, repeat('x', 100) FROM generate_series(1, 1000) AS g(i)`)
+
+	sqlDB.Exec(t, `BACKUP TABLE data.t INTO $1 WITH content_addressed`, collection)
+	files := sharedFiles()
+	require.NotEmpty(t, files)
+
+	// A second full backup of the same data does not write its files again.
+	sqlDB.Exec(t, `BACKUP TABLE data.t INTO $1 WITH content_addressed`, collection)
+	require.Equal(t, files, sharedFiles())
+	sqlDB.Exec(t, `UPDATE data.t SET v = 'y' WHERE k = 1`)
+	sqlDB.Exec(t, `BACKUP TABLE data.t INTO LATEST IN $1 WITH content_addressed`, collection)
+	require.Greater(t, len(sharedFiles()), len(files))
+
+	// Files are not deleted while a backup still references them.
+	subdirs := fullBackups()
+	require.Len(t, subdirs, 2)
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "ca", subdirs[0])))
+	require.Equal(t, "0", gc())
+	sqlDB.Exec(t, `CREATE DATABASE restored`)
+	sqlDB.Exec(t, `RESTORE TABLE data.t FROM LATEST IN $1 WITH into_db = 'restored'`, collection)
+	sqlDB.CheckQueryResults(t, `SELECT count(*), max(v) FROM restored.t`, [][]string{{"1000", "y"}})
+
+	sqlDB.Exec(t, `DELETE FROM data.t WHERE k > 500`)
+	sqlDB.Exec(t, `BACKUP TABLE data.t INTO $1 WITH content_addressed`, collection)
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "ca", subdirs[1])))
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "ca", backupbase.DefaultIncrementalsSubdir, subdirs[1])))
+	// The files that are no longer referenced are first marked for deletion,
+	// and only deleted by a later garbage collection after the grace period.
+	files = sharedFiles()
+	require.Equal(t, "0", gc())
+	require.Equal(t, files, sharedFiles())
+	sqlDB.Exec(t, `SET CLUSTER SETTING bulkio.backup.content_addressed.gc_grace_period = '0s'`)
+	require.NotEqual(t, "0", gc())
+	require.Less(t, len(sharedFiles()), len(files))
+	require.Equal(t, "0", gc())
+
+	sqlDB.Exec(t, `DROP TABLE restored.t`)
+	sqlDB.Exec(t, `RESTORE TABLE data.t FROM LATEST IN $1 WITH into_db = 'restored'`, collection)
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM restored.t`, [][]string{{"500"}})
+}
@@ -52,6 +52,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log/logutil"
@@ -121,6 +122,7 @@ func backup(
 	encryption *jobspb.BackupEncryptionOptions,
 	statsCache *stats.TableStatisticsCache,
 	execLocality roachpb.Locality,
+	fingerprintTenants bool,
 ) (_ roachpb.RowCount, numBackupInstances int, _ error) {
 	resumerSpan := tracing.SpanFromContext(ctx)
 	var lastCheckpoint time.Time
@@ -195,6 +197,7 @@ func backup(
 		kvpb.MVCCFilter(backupManifest.MVCCFilter),
 		backupManifest.StartTime,
 		backupManifest.EndTime,
+		backupManifest.ContentAddressedDir,
 	)
 	if err != nil {
 		return roachpb.RowCount{}, 0, err
@@ -324,6 +327,13 @@ func backup(
 		return roachpb.RowCount{}, 0, errors.Wrapf(err, "exporting %d ranges", errors.Safe(numTotalSpans))
 	}
 
+	if fingerprintTenants && len(backupManifest.Tenants) > 0 {
+		resumerSpan.RecordStructured(&types.StringValue{Value: "fingerprinting virtual clusters"})
+		if err := fingerprintBackupTenants(ctx, execCtx.ExecCfg(), backupManifest); err != nil {
+			return roachpb.RowCount{}, 0, err
+		}
+	}
+
 	backupID := uuid.MakeV4()
 	backupManifest.ID = backupID
 	// Write additional partial descriptors to each node for partitioned backups.
@@ -496,6 +506,10 @@ func (b *backupResumer) Resume(ctx context.Context, execCtx interface{}) error {
 		return err
 	}
 
+	if details.Compact {
+		return b.resumeCompaction(ctx, p, details)
+	}
+
 	kmsEnv := backupencryption.MakeBackupKMSEnv(
 		p.ExecCfg().Settings,
 		&p.ExecCfg().ExternalIODirConfig,
@@ -715,6 +729,14 @@ func (b *backupResumer) Resume(ctx context.Context, execCtx interface{}) error {
 		}
 	}
 
+	// A schedule that verifies its backups needs them to record fingerprints of
+	// the virtual clusters they contain.
+	var verifySchedule *backuppb.ScheduledBackupExecutionArgs
+	if details.ScheduleID != 0 {
+		verifySchedule = verifyingScheduleArgs(ctx, p.ExecCfg(), details.ScheduleID)
+	}
+	fingerprintTenants := verifySchedule != nil || fingerprintVirtualClustersEnabled.Get(&p.ExecCfg().Settings.SV)
+
 	statsCache := p.ExecCfg().TableStatsCache
 	// We retry on pretty generic failures -- any rpc error. If a worker node were
 	// to restart, it would produce this kind of error, but there may be other
@@ -747,6 +769,7 @@ func (b *backupResumer) Resume(ctx context.Context, execCtx interface{}) error {
 			details.EncryptionOptions,
 			statsCache,
 			details.ExecutionLocality,
+			fingerprintTenants,
 		)
 		if err == nil {
 			break
@@ -868,6 +891,13 @@ func (b *backupResumer) Resume(ctx context.Context, execCtx interface{}) error {
 		logutil.LogJobCompletion(ctx, b.getTelemetryEventType(), b.job.ID(), true, nil, res.Rows)
 	}
 
+	if details.ScheduleID != 0 && !details.StartTime.IsEmpty() {
+		b.maybeStartScheduledCompaction(ctx, p, details)
+	}
+	if verifySchedule != nil {
+		b.startScheduledVerification(ctx, p, details, backupManifest, verifySchedule)
+	}
+
 	return b.maybeNotifyScheduledJobCompletion(
 		ctx, jobs.StatusSucceeded, p.ExecCfg().JobsKnobs(), p.ExecCfg().InternalDB,
 	)
@@ -967,6 +997,11 @@ func getBackupDetailAndManifest(
 			return jobspb.BackupDetails{}, backuppb.BackupManifest{}, err
 		}
 		defer mem.Shrink(ctx, memSize)
+
+		// Build on the layers that a restore of the chain reads, which excludes
+		// the layers a compacted layer has replaced.
+		_, prevBackups, _ = backupinfo.ElideCompactedLayers(
+			backupDestination.PrevBackupURIs, prevBackups, nil /* localityInfo */, hlc.Timestamp{})
 	}
 
 	if len(prevBackups) > 0 {
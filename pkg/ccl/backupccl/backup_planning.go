@@ -199,6 +199,7 @@ func resolveOptionsForBackupJobDescription(
 		CaptureRevisionHistory: opts.CaptureRevisionHistory,
 		Detached:               opts.Detached,
 		ExecutionLocality:      opts.ExecutionLocality,
+		ContentAddressed:       opts.ContentAddressed,
 	}
 
 	if opts.EncryptionPassphrase != nil {
@@ -532,6 +533,7 @@ func backupTypeCheck(
 		exprutil.Bools{
 			backupStmt.Options.CaptureRevisionHistory,
 			backupStmt.Options.IncludeAllSecondaryTenants,
+			backupStmt.Options.ContentAddressed,
 		}); err != nil {
 		return false, nil, err
 	}
@@ -627,6 +629,14 @@ func backupPlanHook(
 		}
 	}
 
+	var contentAddressed bool
+	if backupStmt.Options.ContentAddressed != nil {
+		contentAddressed, err = exprEval.Bool(ctx, backupStmt.Options.ContentAddressed)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+
 	encryptionParams := jobspb.BackupEncryptionOptions{
 		Mode: jobspb.EncryptionMode_None,
 	}
@@ -685,6 +695,17 @@ func backupPlanHook(
 			return errors.New("the include_all_virtual_clusters option is only supported for full cluster backups")
 		}
 
+		if contentAddressed {
+			if err := checkContentAddressedBackupOptions(
+				backupStmt, to, incrementalStorage, encryptionParams.Mode,
+			); err != nil {
+				return err
+			}
+			if err := requireEnterprise(p.ExecCfg(), "content_addressed"); err != nil {
+				return err
+			}
+		}
+
 		var asOfInterval int64
 		endTime := p.ExecCfg().Clock.Now()
 		if backupStmt.AsOf.Expr != nil {
@@ -763,6 +784,7 @@ func backupPlanHook(
 			Detached:                   detached,
 			ApplicationName:            p.SessionData().ApplicationName,
 			ExecutionLocality:          executionLocality,
+			ContentAddressed:           contentAddressed,
 		}
 		if backupStmt.CreatedByInfo != nil && backupStmt.CreatedByInfo.Name == jobs.CreatedByScheduledJobs {
 			initialDetails.ScheduleID = backupStmt.CreatedByInfo.ID
@@ -1581,6 +1603,20 @@ func createBackupManifest(
 		StatisticsFilenames: statsFiles,
 		DescriptorCoverage:  coverage,
 	}
+	if jobDetails.ContentAddressed {
+		// The encryption of a backup that is appended to an existing chain is
+		// only known once the chain is resolved.
+		if jobDetails.EncryptionOptions != nil &&
+			jobDetails.EncryptionOptions.Mode != jobspb.EncryptionMode_None {
+			return backuppb.BackupManifest{}, errors.New(
+				"the content_addressed option is not supported for encrypted backups")
+		}
+		backupManifest.ContentAddressedDir, err = contentAddressedDirForBackup(
+			jobDetails.CollectionURI, jobDetails.URI)
+		if err != nil {
+			return backuppb.BackupManifest{}, err
+		}
+	}
 	if err := checkCoverage(ctx, backupManifest.Spans, append(prevBackups, backupManifest)); err != nil {
 		return backuppb.BackupManifest{}, errors.Wrap(err, "new backup would not cover expected time")
 	}
@@ -123,8 +123,10 @@ type backupDataProcessor struct {
 	progCh                 chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress
 	backupErr              error
 
-	// BoundAccount that reserves the memory usage of the backup processor.
-	memAcc *mon.BoundAccount
+	// BoundAccount that reserves the memory usage of the backup processor, and
+	// the monitor from which it and the accounts of its sinks are made.
+	memAcc     *mon.BoundAccount
+	memMonitor *mon.BytesMonitor
 
 	// Aggregator that aggregates StructuredEvents emitted in the
 	// backupDataProcessors' trace recording.
@@ -155,10 +157,11 @@ func newBackupDataProcessor(
 	}
 	ba := memMonitor.MakeBoundAccount()
 	bp := &backupDataProcessor{
-		flowCtx: flowCtx,
-		spec:    spec,
-		progCh:  make(chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress),
-		memAcc:  &ba,
+		flowCtx:    flowCtx,
+		spec:       spec,
+		progCh:     make(chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress),
+		memAcc:     &ba,
+		memMonitor: memMonitor,
 	}
 	if err := bp.Init(ctx, bp, post, backupOutputTypes, flowCtx, processorID, nil, /* memMonitor */
 		execinfra.ProcStateOpts{
@@ -195,7 +198,7 @@ func (bp *backupDataProcessor) Start(ctx context.Context) {
 		TaskName: "backupDataProcessor.runBackupProcessor",
 		SpanOpt:  stop.ChildSpan,
 	}, func(ctx context.Context) {
-		bp.backupErr = runBackupProcessor(ctx, bp.flowCtx, &bp.spec, bp.progCh, bp.memAcc)
+		bp.backupErr = runBackupProcessor(ctx, bp.flowCtx, &bp.spec, bp.progCh, bp.memAcc, bp.memMonitor)
 		cancel()
 		close(bp.progCh)
 	}); err != nil {
@@ -285,6 +288,7 @@ func runBackupProcessor(
 	spec *execinfrapb.BackupDataSpec,
 	progCh chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
 	memAcc *mon.BoundAccount,
+	memMonitor *mon.BytesMonitor,
 ) error {
 	backupProcessorSpan := tracing.SpanFromContext(ctx)
 	clusterSettings := flowCtx.Cfg.Settings
@@ -343,10 +347,15 @@ func runBackupProcessor(
 	}
 
 	sinkConf := sstSinkConf{
-		id:       flowCtx.NodeID.SQLInstanceID(),
-		enc:      spec.Encryption,
-		progCh:   progCh,
-		settings: &flowCtx.Cfg.Settings.SV,
+		id:                  flowCtx.NodeID.SQLInstanceID(),
+		enc:                 spec.Encryption,
+		progCh:              progCh,
+		settings:            &flowCtx.Cfg.Settings.SV,
+		contentAddressedDir: spec.ContentAddressedDir,
+		memMon:              memMonitor,
+	}
+	if sinkConf.contentAddressedDir != "" && sinkConf.enc != nil {
+		return errors.AssertionFailedf("content-addressed backups cannot be encrypted")
 	}
 	storage, err := flowCtx.Cfg.ExternalStorage(ctx, dest)
 	if err != nil {
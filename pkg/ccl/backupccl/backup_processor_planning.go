@@ -45,6 +45,7 @@ func distBackupPlanSpecs(
 	kmsEnv cloud.KMSEnv,
 	mvccFilter kvpb.MVCCFilter,
 	startTime, endTime hlc.Timestamp,
+	contentAddressedDir string,
 ) (map[base.SQLInstanceID]*execinfrapb.BackupDataSpec, error) {
 	var span *tracing.Span
 	ctx, span = tracing.ChildSpan(ctx, "backupccl.distBackupPlanSpecs")
@@ -97,16 +98,17 @@ func distBackupPlanSpecs(
 	sqlInstanceIDToSpec := make(map[base.SQLInstanceID]*execinfrapb.BackupDataSpec)
 	for _, partition := range spanPartitions {
 		spec := &execinfrapb.BackupDataSpec{
-			JobID:            jobID,
-			Spans:            partition.Spans,
-			DefaultURI:       defaultURI,
-			URIsByLocalityKV: urisByLocalityKV,
-			MVCCFilter:       mvccFilter,
-			Encryption:       fileEncryption,
-			PKIDs:            pkIDs,
-			BackupStartTime:  startTime,
-			BackupEndTime:    endTime,
-			UserProto:        user.EncodeProto(),
+			JobID:               jobID,
+			Spans:               partition.Spans,
+			DefaultURI:          defaultURI,
+			URIsByLocalityKV:    urisByLocalityKV,
+			MVCCFilter:          mvccFilter,
+			Encryption:          fileEncryption,
+			PKIDs:               pkIDs,
+			BackupStartTime:     startTime,
+			BackupEndTime:       endTime,
+			UserProto:           user.EncodeProto(),
+			ContentAddressedDir: contentAddressedDir,
 		}
 		sqlInstanceIDToSpec[partition.SQLInstanceID] = spec
 	}
@@ -119,16 +121,17 @@ func distBackupPlanSpecs(
 			// which is not the leaseholder for any of the spans, but is for an
 			// introduced span.
 			spec := &execinfrapb.BackupDataSpec{
-				JobID:            jobID,
-				IntroducedSpans:  partition.Spans,
-				DefaultURI:       defaultURI,
-				URIsByLocalityKV: urisByLocalityKV,
-				MVCCFilter:       mvccFilter,
-				Encryption:       fileEncryption,
-				PKIDs:            pkIDs,
-				BackupStartTime:  startTime,
-				BackupEndTime:    endTime,
-				UserProto:        user.EncodeProto(),
+				JobID:               jobID,
+				IntroducedSpans:     partition.Spans,
+				DefaultURI:          defaultURI,
+				URIsByLocalityKV:    urisByLocalityKV,
+				MVCCFilter:          mvccFilter,
+				Encryption:          fileEncryption,
+				PKIDs:               pkIDs,
+				BackupStartTime:     startTime,
+				BackupEndTime:       endTime,
+				UserProto:           user.EncodeProto(),
+				ContentAddressedDir: contentAddressedDir,
 			}
 			sqlInstanceIDToSpec[partition.SQLInstanceID] = spec
 		}
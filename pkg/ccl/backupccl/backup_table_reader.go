@@ -0,0 +1,467 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/cloud/cloudprivilege"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondatapb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// backupTableSource identifies a backup in a collection, and the time to read
+// it as of, from which the rows of a table are read by SHOW BACKUP DIFF and
+// RESTORE ROWS.
+type backupTableSource struct {
+	collection []string
+	subdir     string
+	// asOf is the time to read the backup as of. If empty, the end time of the
+	// backup is used; otherwise the backup must have revision history.
+	asOf               hlc.Timestamp
+	incrementalStorage []string
+	encryption         jobspb.BackupEncryptionOptions
+}
+
+// evalBackupReadOptions evaluates the options of a statement that reads the
+// data of a backup: how to decrypt it, and where its incremental layers are.
+func evalBackupReadOptions(
+	ctx context.Context,
+	exprEval exprutil.Evaluator,
+	passphrase tree.Expr,
+	kms tree.StringOrPlaceholderOptList,
+	incrementalStorage tree.StringOrPlaceholderOptList,
+) (jobspb.BackupEncryptionOptions, []string, error) {
+	enc := jobspb.BackupEncryptionOptions{Mode: jobspb.EncryptionMode_None}
+	if passphrase != nil {
+		pw, err := exprEval.String(ctx, passphrase)
+		if err != nil {
+			return enc, nil, err
+		}
+		enc = jobspb.BackupEncryptionOptions{Mode: jobspb.EncryptionMode_Passphrase, RawPassphrase: pw}
+	} else if kms != nil {
+		uris, err := exprEval.StringArray(ctx, tree.Exprs(kms))
+		if err != nil {
+			return enc, nil, err
+		}
+		enc = jobspb.BackupEncryptionOptions{Mode: jobspb.EncryptionMode_KMS, RawKmsUris: uris}
+	}
+	var incStorage []string
+	if incrementalStorage != nil {
+		var err error
+		if incStorage, err = exprEval.StringArray(ctx, tree.Exprs(incrementalStorage)); err != nil {
+			return enc, nil, err
+		}
+	}
+	return enc, incStorage, nil
+}
+
+// backupTable is a table in a backup chain as of some time, along with the data
+// files of the chain that hold its rows.
+type backupTable struct {
+	desc  catalog.TableDescriptor
+	codec keys.SQLCodec
+	asOf  hlc.Timestamp
+	enc   *kvpb.FileEncryptionOptions
+
+	files  []storageccl.StoreFile
+	stores []cloud.ExternalStorage
+}
+
+// openBackupTable resolves the backup chain of src, and the descriptor of the
+// named table in it as of src.asOf. The caller must close the returned table.
+func openBackupTable(
+	ctx context.Context, p sql.PlanHookState, src backupTableSource, tn tree.TableName,
+) (_ *backupTable, retErr error) {
+	execCfg := p.ExecCfg()
+	if err := cloudprivilege.CheckDestinationPrivileges(ctx, p, src.collection); err != nil {
+		return nil, err
+	}
+	if len(src.collection) > 1 {
+		return nil, errors.New("reading rows from locality-aware backups is not supported")
+	}
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+
+	subdir := src.subdir
+	if strings.EqualFold(subdir, backupbase.LatestFileName) {
+		var err error
+		subdir, err = backupdest.ReadLatestFile(ctx, src.collection[0], mkStore, p.User())
+		if err != nil {
+			return nil, errors.Wrap(err, "read LATEST path")
+		}
+	}
+	fullyResolvedDest, err := backuputils.AppendPaths(src.collection, subdir)
+	if err != nil {
+		return nil, err
+	}
+
+	kmsEnv := backupencryption.MakeBackupKMSEnv(
+		execCfg.Settings,
+		&execCfg.ExternalIODirConfig,
+		execCfg.InternalDB,
+		p.User(),
+	)
+	encryption, err := backupencryption.GetEncryptionFromBase(
+		ctx, p.User(), mkStore, fullyResolvedDest[0], src.encryption, &kmsEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, computedSubdir, err := backupdest.CollectionsAndSubdir(src.collection, subdir)
+	if err != nil {
+		return nil, err
+	}
+	incDirs, err := backupdest.ResolveIncrementalsBackupLocation(
+		ctx, p.User(), execCfg, src.incrementalStorage, collections, computedSubdir)
+	if err != nil {
+		if !errors.Is(err, cloud.ErrListingUnsupported) {
+			return nil, err
+		}
+		log.Warningf(ctx, "storage sink %v does not support listing, only reading the base backup",
+			src.incrementalStorage)
+	}
+
+	baseStores, cleanupBase, err := backupdest.MakeBackupDestinationStores(ctx, p.User(), mkStore, fullyResolvedDest)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := cleanupBase(); err != nil {
+			log.Warningf(ctx, "failed to close base store: %+v", err)
+		}
+	}()
+	incStores, cleanupInc, err := backupdest.MakeBackupDestinationStores(ctx, p.User(), mkStore, incDirs)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := cleanupInc(); err != nil {
+			log.Warningf(ctx, "failed to close incremental store: %+v", err)
+		}
+	}()
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+	// ResolveBackupManifests truncates the chain to the layers needed to read
+	// it as of src.asOf, and checks that the chain can be read at that time.
+	_, manifests, _, memSize, err := backupdest.ResolveBackupManifests(
+		ctx, &mem, baseStores, incStores, mkStore, fullyResolvedDest, incDirs,
+		src.asOf, encryption, &kmsEnv, p.User())
+	if err != nil {
+		return nil, err
+	}
+	defer mem.Shrink(ctx, memSize)
+
+	iterFactories, err := backupinfo.GetBackupManifestIterFactories(
+		ctx, execCfg.DistSQLSrv.ExternalStorage, manifests, encryption, &kmsEnv)
+	if err != nil {
+		return nil, err
+	}
+	descs, _, err := backupinfo.LoadSQLDescsFromBackupsAtTime(ctx, manifests, iterFactories, src.asOf)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := findBackupTable(descs, tn, p.SessionData().Database)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := backupinfo.MakeBackupCodec(manifests[0])
+	if err != nil {
+		return nil, err
+	}
+
+	t := &backupTable{desc: desc, codec: codec, asOf: src.asOf}
+	if t.asOf.IsEmpty() {
+		t.asOf = manifests[len(manifests)-1].EndTime
+	}
+	if encryption != nil {
+		key, err := backupencryption.GetEncryptionKey(ctx, encryption, &kmsEnv)
+		if err != nil {
+			return nil, err
+		}
+		t.enc = &kvpb.FileEncryptionOptions{Key: key}
+	}
+	defer func() {
+		if retErr != nil {
+			t.close()
+		}
+	}()
+
+	span := desc.PrimaryIndexSpan(codec)
+	for layer := range manifests {
+		store, err := execCfg.DistSQLSrv.ExternalStorage(ctx, manifests[layer].Dir)
+		if err != nil {
+			return nil, err
+		}
+		t.stores = append(t.stores, store)
+		if err := func() error {
+			it, err := iterFactories[layer].NewFileIter(ctx)
+			if err != nil {
+				return err
+			}
+			defer it.Close()
+			for ; ; it.Next() {
+				if ok, err := it.Valid(); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+				if f := it.Value(); f.Span.Overlaps(span) {
+					t.files = append(t.files, storageccl.StoreFile{Store: store, FilePath: f.Path})
+				}
+			}
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *backupTable) close() {
+	for _, s := range t.stores {
+		_ = s.Close()
+	}
+	t.stores = nil
+}
+
+// findBackupTable returns the descriptor of the named table among the
+// descriptors of a backup. A name without a database refers to the current
+// database, and one without a schema to the public schema.
+func findBackupTable(
+	descs []catalog.Descriptor, tn tree.TableName, currentDatabase string,
+) (catalog.TableDescriptor, error) {
+	dbNames := make(map[descpb.ID]string)
+	schemaNames := map[descpb.ID]string{keys.PublicSchemaID: catconstants.PublicSchemaName}
+	for _, desc := range descs {
+		switch desc := desc.(type) {
+		case catalog.DatabaseDescriptor:
+			dbNames[desc.GetID()] = desc.GetName()
+		case catalog.SchemaDescriptor:
+			schemaNames[desc.GetID()] = desc.GetName()
+		}
+	}
+
+	type qualifiedName struct{ db, schema string }
+	var candidates []qualifiedName
+	switch {
+	case tn.ExplicitCatalog:
+		candidates = []qualifiedName{{db: tn.Catalog(), schema: tn.Schema()}}
+	case tn.ExplicitSchema:
+		// A two-part name is either a table in a schema of the current database
+		// or a table in the public schema of a database.
+		candidates = []qualifiedName{
+			{db: currentDatabase, schema: tn.Schema()},
+			{db: tn.Schema(), schema: catconstants.PublicSchemaName},
+		}
+	default:
+		candidates = []qualifiedName{{db: currentDatabase, schema: catconstants.PublicSchemaName}}
+	}
+
+	for _, c := range candidates {
+		for _, desc := range descs {
+			table, ok := desc.(catalog.TableDescriptor)
+			if !ok || table.GetName() != tn.Table() || table.Dropped() {
+				continue
+			}
+			if dbNames[table.GetParentID()] != c.db || schemaNames[table.GetParentSchemaID()] != c.schema {
+				continue
+			}
+			if !table.IsTable() {
+				return nil, errors.Newf("%q is not a table", tn.Table())
+			}
+			return table, nil
+		}
+	}
+	return nil, errors.Newf("table %s does not exist in the backup", tree.ErrString(&tn))
+}
+
+// backupRowIter iterates over the rows of a backupTable in primary key order.
+type backupRowIter struct {
+	it    storage.SimpleMVCCIterator
+	rf    row.Fetcher
+	alloc tree.DatumAlloc
+
+	// cols are the columns that are decoded from each row, and keyOrds the
+	// ordinals in cols of the primary key columns.
+	cols    []catalog.Column
+	keyOrds []int
+	kvs     []roachpb.KeyValue
+
+	// key is the primary index key of the current row, without its column
+	// family suffix, and datums are its decoded cols.
+	key    roachpb.Key
+	datums tree.Datums
+}
+
+// newRowIter returns an iterator over the rows of the table. The stored columns
+// of every row are decoded, which is not supported for columns of user-defined
+// types, as their types cannot be hydrated from the backup.
+func (t *backupTable) newRowIter(ctx context.Context) (_ *backupRowIter, retErr error) {
+	r := &backupRowIter{}
+	var colIDs []descpb.ColumnID
+	var colOrds catalog.TableColMap
+	for _, col := range t.desc.PublicColumns() {
+		if col.IsVirtual() {
+			continue
+		}
+		if col.GetType().UserDefined() {
+			return nil, errors.Newf("column %q has a user-defined type, which is not supported", col.GetName())
+		}
+		colOrds.Set(col.GetID(), len(r.cols))
+		r.cols = append(r.cols, col)
+		colIDs = append(colIDs, col.GetID())
+	}
+	pk := t.desc.GetPrimaryIndex()
+	for i := 0; i < pk.NumKeyColumns(); i++ {
+		r.keyOrds = append(r.keyOrds, colOrds.GetDefault(pk.GetKeyColumnID(i)))
+	}
+
+	var spec fetchpb.IndexFetchSpec
+	if err := rowenc.InitIndexFetchSpec(&spec, t.codec, t.desc, pk, colIDs); err != nil {
+		return nil, err
+	}
+	if err := r.rf.Init(ctx, row.FetcherInitArgs{
+		WillUseKVProvider: true,
+		Alloc:             &r.alloc,
+		Spec:              &spec,
+	}); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr != nil {
+			r.close(ctx)
+		}
+	}()
+
+	if len(t.files) == 0 {
+		return r, nil
+	}
+	span := t.desc.PrimaryIndexSpan(t.codec)
+	iter, err := storageccl.ExternalSSTReader(ctx, t.files, t.enc, storage.IterOptions{
+		RangeKeyMaskingBelow: t.asOf,
+		KeyTypes:             storage.IterKeyTypePointsAndRanges,
+		LowerBound:           span.Key,
+		UpperBound:           span.EndKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.it = storage.NewReadAsOfIterator(iter, t.asOf)
+	r.it.SeekGE(storage.MVCCKey{Key: span.Key})
+	return r, nil
+}
+
+// next moves to the next row, returning false once there are no more rows.
+func (r *backupRowIter) next(ctx context.Context) (bool, error) {
+	r.key, r.kvs, r.datums = nil, r.kvs[:0], nil
+	for r.it != nil {
+		if ok, err := r.it.Valid(); err != nil {
+			return false, err
+		} else if !ok {
+			break
+		}
+		key := r.it.UnsafeKey().Key
+		rowKey, err := keys.EnsureSafeSplitKey(key)
+		if err != nil {
+			return false, err
+		}
+		if r.key == nil {
+			r.key = rowKey.Clone()
+		} else if !rowKey.Equal(r.key) {
+			break
+		}
+		v, err := r.it.UnsafeValue()
+		if err != nil {
+			return false, err
+		}
+		mvccValue, err := storage.DecodeMVCCValue(v)
+		if err != nil {
+			return false, err
+		}
+		r.kvs = append(r.kvs, roachpb.KeyValue{
+			Key:   key.Clone(),
+			Value: roachpb.Value{RawBytes: append([]byte(nil), mvccValue.Value.RawBytes...)},
+		})
+		r.it.Next()
+	}
+	if r.key == nil {
+		return false, nil
+	}
+
+	if err := r.rf.ConsumeKVProvider(ctx, &row.KVProvider{KVs: r.kvs}); err != nil {
+		return false, err
+	}
+	datums, err := r.rf.NextRowDecoded(ctx)
+	if err != nil {
+		return false, err
+	}
+	if datums == nil {
+		return false, errors.AssertionFailedf("failed to decode row from key %s", r.key)
+	}
+	r.datums = datums
+	return true, nil
+}
+
+// primaryKey returns the primary key of the current row, formatted like the
+// key columns of a pretty-printed index key.
+func (r *backupRowIter) primaryKey() string {
+	var b strings.Builder
+	for _, ord := range r.keyOrds {
+		b.WriteByte('/')
+		b.WriteString(tree.AsString(r.datums[ord]))
+	}
+	return b.String()
+}
+
+// rowJSON returns the current row as a JSON object from column name to value.
+func (r *backupRowIter) rowJSON() (json.JSON, error) {
+	b := json.NewObjectBuilder(len(r.cols))
+	for i, col := range r.cols {
+		j, err := tree.AsJSON(r.datums[i], sessiondatapb.DataConversionConfig{}, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		b.Add(col.GetName(), j)
+	}
+	return b.Build(), nil
+}
+
+func (r *backupRowIter) close(ctx context.Context) {
+	if r.it != nil {
+		r.it.Close()
+		r.it = nil
+	}
+	r.rf.Close(ctx)
+}
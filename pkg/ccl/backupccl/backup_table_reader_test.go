@@ -0,0 +1,94 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowBackupDiffAndRestoreRows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tc, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, 0, InitManualReplication)
+	defer cleanupFn()
+
+	const collection = "nodelocal://1/diff"
+	sqlDB.Exec(t, `CREATE TABLE data.t (k INT PRIMARY KEY, v STRING, w INT)`)
+	sqlDB.Exec(t, `INSERT INTO data.t VALUES (1, 'a', 1), (2, 'b', 2), (3, 'c', 3)`)
+	var beforeUpdate string
+	sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&beforeUpdate)
+
+	sqlDB.Exec(t, `UPDATE data.t SET v = 'bad' WHERE k >= 2`)
+	sqlDB.Exec(t, `DELETE FROM data.t WHERE k = 1`)
+	sqlDB.Exec(t, `INSERT INTO data.t VALUES (4, 'd', 4)`)
+	sqlDB.Exec(t, `BACKUP TABLE data.t INTO $1 WITH revision_history`, collection)
+
+	t.Run("diff", func(t *testing.T) {
+		sqlDB.CheckQueryResults(t, fmt.Sprintf(`
+SELECT change, primary_key, old_row->>'v', new_row->>'v'
+FROM [SHOW BACKUP DIFF TABLE data.t IN '%s'
+      FROM LATEST AS OF SYSTEM TIME '%s' TO LATEST]`, collection, beforeUpdate),
+			[][]string{
+				{"removed", "/1", "a", "NULL"},
+				{"modified", "/2", "b", "bad"},
+				{"modified", "/3", "c", "bad"},
+				{"added", "/4", "NULL", "d"},
+			})
+
+		// A backup does not differ from itself.
+		sqlDB.CheckQueryResults(t, fmt.Sprintf(
+			`SELECT count(*) FROM [SHOW BACKUP DIFF TABLE data.t IN '%s' FROM LATEST TO LATEST]`, collection),
+			[][]string{{"0"}})
+
+		sqlDB.ExpectErr(t, "table data.public.missing does not exist in the backup", fmt.Sprintf(
+			`SHOW BACKUP DIFF TABLE data.public.missing IN '%s' FROM LATEST TO LATEST`, collection))
+	})
+
+	t.Run("restore rows", func(t *testing.T) {
+		sqlDB.ExpectErr(t, "RESTORE ROWS only supports", fmt.Sprintf(
+			`RESTORE ROWS OF TABLE data.t FROM LATEST IN '%s' WHERE true WITH skip_missing_views`, collection))
+
+		// Rows restored in a transaction that is rolled back are not written.
+		tx, err := tc.Conns[0].Begin()
+		require.NoError(t, err)
+		_, err = tx.Exec(fmt.Sprintf(`RESTORE ROWS OF TABLE data.t FROM LATEST IN '%s' AS OF SYSTEM TIME '%s' WHERE true`,
+			collection, beforeUpdate))
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+		sqlDB.CheckQueryResults(t, `SELECT k, v FROM data.t`, [][]string{{"2", "bad"}, {"3", "bad"}, {"4", "d"}})
+
+		sqlDB.CheckQueryResults(t, fmt.Sprintf(
+			`RESTORE ROWS OF TABLE data.t FROM LATEST IN '%s' AS OF SYSTEM TIME '%s' WHERE k <= 2`,
+			collection, beforeUpdate),
+			[][]string{{"2"}})
+		sqlDB.CheckQueryResults(t, `SELECT k, v, w FROM data.t`, [][]string{
+			{"1", "a", "1"}, {"2", "b", "2"}, {"3", "bad", "3"}, {"4", "d", "4"},
+		})
+
+		// The filter is evaluated against the rows of the backup, so it may only
+		// reference, by unqualified name, the columns that are restored from it.
+		sqlDB.Exec(t, `ALTER TABLE data.t ADD COLUMN c INT AS (w * 2) STORED, ADD COLUMN x INT`)
+		for _, check := range []struct{ where, err string }{
+			{where: `c = 2`, err: `computed column "c" can't be referenced`},
+			{where: `x IS NULL`, err: `column "x" referenced in the WHERE clause of RESTORE ROWS is not in the backup`},
+			{where: `t.k = 1`, err: `column reference t.k must not be qualified`},
+			{where: `missing = 1`, err: `column "missing" does not exist`},
+			{where: `k IN (SELECT 1)`, err: `subqueries are not supported`},
+		} {
+			sqlDB.ExpectErr(t, check.err, fmt.Sprintf(
+				`RESTORE ROWS OF TABLE data.t FROM LATEST IN '%s' WHERE %s`, collection, check.where))
+		}
+	})
+}
@@ -27,6 +27,12 @@ const (
 	// It is exported for testing backup inspection tooling.
 	DateBasedIncFolderName = "/20060102/150405.00"
 
+	// CompactedIncFolderSuffix is the date format of the start time appended to
+	// the DateBasedIncFolderName of the end time of a compacted incremental
+	// backup. The suffix sorts the compacted layer after the layer it shares an
+	// end time with, while still matching the incremental sub-directory glob.
+	CompactedIncFolderSuffix = "-20060102-150405.00"
+
 	// DateBasedIntoFolderName is the date format used when creating sub-directories
 	// for storing backups in a collection.
 	// Also exported for testing backup inspection tooling.
@@ -56,6 +62,19 @@ const (
 	// incremental backups will be written.
 	DefaultIncrementalsSubdir = "incrementals"
 
+	// ContentAddressedDirectory is the directory of a backup collection to which
+	// content-addressed backups write their data files, named by the hash of
+	// their contents so that identical files are shared by the backups. It ends
+	// in "data" so that listings of the collection that are delimited by
+	// ListingDelimDataSlash skip over its files.
+	ContentAddressedDirectory = "shared/data"
+
+	// ContentAddressedGCMarksName is the name of the file, next to
+	// ContentAddressedDirectory, which lists the content-addressed files that
+	// garbage collection found unreferenced and will delete once they have
+	// stayed unreferenced for a grace period.
+	ContentAddressedGCMarksName = "GC_MARKS"
+
 	// ListingDelimDataSlash is used when listing to find backups/backup metadata
 	// and groups all the data sst files in each backup, which start with "data/",
 	// into a single result that can be skipped over quickly.
@@ -632,6 +632,10 @@ func ResolveBackupManifests(
 	totalMemSize := ownedMemSize
 	ownedMemSize = 0
 
+	// Skip the layers that a compacted layer of the chain has replaced.
+	defaultURIs, mainBackupManifests, localityInfo = backupinfo.ElideCompactedLayers(
+		defaultURIs, mainBackupManifests, localityInfo, endTime)
+
 	validatedDefaultURIs, validatedMainBackupManifests, validatedLocalityInfo, err := backupinfo.ValidateEndTimeAndTruncate(
 		defaultURIs, mainBackupManifests, localityInfo, endTime)
 
@@ -711,6 +715,10 @@ func DeprecatedResolveBackupManifestsExplicitIncrementals(
 	totalMemSize := ownedMemSize
 	ownedMemSize = 0
 
+	// Skip the layers that a compacted layer of the chain has replaced.
+	defaultURIs, mainBackupManifests, localityInfo = backupinfo.ElideCompactedLayers(
+		defaultURIs, mainBackupManifests, localityInfo, endTime)
+
 	validatedDefaultURIs, validatedMainBackupManifests, validatedLocalityInfo, err :=
 		backupinfo.ValidateEndTimeAndTruncate(defaultURIs, mainBackupManifests, localityInfo, endTime)
 
@@ -895,6 +895,83 @@ func GetLocalityInfo(
 	return info, nil
 }
 
+// ElideCompactedLayers removes the layers of a backup chain that are covered by
+// a compacted layer. A compacted layer spans the time of the run of layers it
+// was merged from and is sorted after the last of them, so the chain read by a
+// restore to endTime is found by walking back from the last layer ending at
+// endTime (or covering it, or the last layer if endTime is empty), each time
+// stepping to the last layer ending at the start time of the current one.
+//
+// The layers are returned unchanged if the walk does not reach the full backup,
+// leaving any gap in the chain to be reported by the callers' checks.
+// localityInfo may be nil.
+func ElideCompactedLayers(
+	defaultURIs []string,
+	mainBackupManifests []backuppb.BackupManifest,
+	localityInfo []jobspb.RestoreDetails_BackupLocalityInfo,
+	endTime hlc.Timestamp,
+) ([]string, []backuppb.BackupManifest, []jobspb.RestoreDetails_BackupLocalityInfo) {
+	if len(mainBackupManifests) < 2 {
+		return defaultURIs, mainBackupManifests, localityInfo
+	}
+
+	last := len(mainBackupManifests) - 1
+	if !endTime.IsEmpty() {
+		last = -1
+		for i := range mainBackupManifests {
+			if mainBackupManifests[i].EndTime.Equal(endTime) {
+				last = i
+			}
+		}
+		if last == -1 {
+			for i := range mainBackupManifests {
+				m := &mainBackupManifests[i]
+				if m.StartTime.Less(endTime) && endTime.LessEq(m.EndTime) {
+					last = i
+				}
+			}
+		}
+		if last == -1 {
+			return defaultURIs, mainBackupManifests, localityInfo
+		}
+	}
+
+	layers := []int{last}
+	for cur := last; !mainBackupManifests[cur].StartTime.IsEmpty(); {
+		prev := -1
+		for i := cur - 1; i >= 0; i-- {
+			if mainBackupManifests[i].EndTime.Equal(mainBackupManifests[cur].StartTime) {
+				prev = i
+				break
+			}
+		}
+		if prev == -1 {
+			return defaultURIs, mainBackupManifests, localityInfo
+		}
+		layers = append(layers, prev)
+		cur = prev
+	}
+	if len(layers) == last+1 {
+		// No layer was elided.
+		return defaultURIs, mainBackupManifests, localityInfo
+	}
+
+	elidedURIs := make([]string, 0, len(layers))
+	elidedManifests := make([]backuppb.BackupManifest, 0, len(layers))
+	var elidedLocalityInfo []jobspb.RestoreDetails_BackupLocalityInfo
+	if localityInfo != nil {
+		elidedLocalityInfo = make([]jobspb.RestoreDetails_BackupLocalityInfo, 0, len(layers))
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		elidedURIs = append(elidedURIs, defaultURIs[layers[i]])
+		elidedManifests = append(elidedManifests, mainBackupManifests[layers[i]])
+		if localityInfo != nil {
+			elidedLocalityInfo = append(elidedLocalityInfo, localityInfo[layers[i]])
+		}
+	}
+	return elidedURIs, elidedManifests, elidedLocalityInfo
+}
+
 // ValidateEndTimeAndTruncate checks that the requested target time, if
 // specified, is valid for the list of incremental backups resolved, truncating
 // the results to the backup that contains the target time.
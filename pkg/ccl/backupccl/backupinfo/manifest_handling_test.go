@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
 	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
@@ -287,3 +288,63 @@ func mustCreateFileIterFactory(
 		return it
 	}
 }
+
+// TestElideCompactedLayers tests that the layers which a compacted layer of a
+// backup chain replaces are skipped when resolving the chain.
+func TestElideCompactedLayers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wall int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wall} }
+	layer := func(start, end int64) backuppb.BackupManifest {
+		return backuppb.BackupManifest{StartTime: ts(start), EndTime: ts(end)}
+	}
+	// The chain as listed in the collection: a full backup, three
+	// incrementals, the compaction of the first two incrementals and a last
+	// incremental appended after the compaction.
+	chain := []backuppb.BackupManifest{
+		layer(0, 10), layer(10, 20), layer(20, 30), layer(10, 30), layer(30, 40),
+	}
+	uris := []string{"full", "inc1", "inc2", "compacted", "inc3"}
+	localityInfo := make([]jobspb.RestoreDetails_BackupLocalityInfo, len(chain))
+	for i := range localityInfo {
+		localityInfo[i].URIsByOriginalLocalityKV = map[string]string{"region=east": uris[i]}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		chain    []backuppb.BackupManifest
+		endTime  hlc.Timestamp
+		expected []string
+	}{
+		{name: "latest", chain: chain, expected: []string{"full", "compacted", "inc3"}},
+		{name: "end of compacted layer", chain: chain, endTime: ts(30),
+			expected: []string{"full", "compacted"}},
+		{name: "within compacted layer", chain: chain, endTime: ts(25),
+			expected: []string{"full", "compacted"}},
+		{name: "before compacted layer", chain: chain, endTime: ts(20),
+			expected: []string{"full", "inc1", "inc2", "compacted", "inc3"}},
+		{name: "within last layer", chain: chain, endTime: ts(35),
+			expected: []string{"full", "compacted", "inc3"}},
+		{name: "after chain", chain: chain, endTime: ts(50),
+			expected: []string{"full", "inc1", "inc2", "compacted", "inc3"}},
+		{name: "gap in chain", chain: []backuppb.BackupManifest{layer(0, 10), layer(15, 20)},
+			expected: []string{"full", "inc1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tcURIs := uris[:len(tc.chain)]
+			gotURIs, gotManifests, gotLocalityInfo := backupinfo.ElideCompactedLayers(
+				tcURIs, tc.chain, localityInfo[:len(tc.chain)], tc.endTime)
+			require.Equal(t, tc.expected, gotURIs)
+			require.Len(t, gotManifests, len(tc.expected))
+			require.Len(t, gotLocalityInfo, len(tc.expected))
+			for i := range tc.expected {
+				require.Equal(t, tc.expected[i], gotLocalityInfo[i].URIsByOriginalLocalityKV["region=east"])
+			}
+
+			gotURIs, _, gotLocalityInfo = backupinfo.ElideCompactedLayers(
+				tcURIs, tc.chain, nil /* localityInfo */, tc.endTime)
+			require.Equal(t, tc.expected, gotURIs)
+			require.Nil(t, gotLocalityInfo)
+		})
+	}
+}
@@ -11,6 +11,7 @@ package backupccl
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
@@ -27,6 +28,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -43,6 +46,12 @@ const (
 	optOnPreviousRunning       = "on_previous_running"
 	optIgnoreExistingBackups   = "ignore_existing_backups"
 	optUpdatesLastBackupMetric = "updates_cluster_last_backup_time_metric"
+	// optIncrementalCompactionThreshold is the number of incremental layers of
+	// a chain above which the incremental schedule compacts them.
+	optIncrementalCompactionThreshold = "incremental_compaction_threshold"
+	// optVerifyBackups makes the schedule verify that its backups of virtual
+	// clusters can be restored.
+	optVerifyBackups = "verify_backups"
 )
 
 var scheduledBackupOptionExpectValues = map[string]exprutil.KVStringOptValidate{
@@ -51,6 +60,9 @@ var scheduledBackupOptionExpectValues = map[string]exprutil.KVStringOptValidate{
 	optOnPreviousRunning:       exprutil.KVStringOptRequireValue,
 	optIgnoreExistingBackups:   exprutil.KVStringOptRequireNoValue,
 	optUpdatesLastBackupMetric: exprutil.KVStringOptRequireNoValue,
+
+	optIncrementalCompactionThreshold: exprutil.KVStringOptRequireValue,
+	optVerifyBackups:                  exprutil.KVStringOptRequireNoValue,
 }
 
 // scheduledBackupGCProtectionEnabled is used to enable and disable the chaining
@@ -86,6 +98,7 @@ type scheduledBackupSpec struct {
 	incrementalStorage         []string
 	includeAllSecondaryTenants *bool
 	execLoc                    *string
+	contentAddressed           *bool
 }
 
 func makeScheduleDetails(opts map[string]string) (jobspb.ScheduleDetails, error) {
@@ -239,6 +252,10 @@ func doCreateBackupSchedules(
 		backupNode.Options.ExecutionLocality = tree.NewStrVal(*eval.execLoc)
 	}
 
+	if eval.contentAddressed != nil {
+		backupNode.Options.ContentAddressed = tree.MakeDBool(tree.DBool(*eval.contentAddressed))
+	}
+
 	// Evaluate encryption KMS URIs if set.
 	// Only one of encryption passphrase and KMS URI should be set, but this check
 	// is done during backup planning so we do not need to worry about it here.
@@ -291,6 +308,21 @@ func doCreateBackupSchedules(
 		}
 	}
 
+	var compactionThreshold int64
+	if v, ok := scheduleOptions[optIncrementalCompactionThreshold]; ok {
+		if incRecurrence == nil {
+			return errors.Newf("%s requires an incremental backup schedule", optIncrementalCompactionThreshold)
+		}
+		if compactionThreshold, err = parseIncrementalCompactionThreshold(v); err != nil {
+			return err
+		}
+	}
+
+	_, verifyBackups := scheduleOptions[optVerifyBackups]
+	if verifyBackups && backupNode.Targets != nil && !backupNode.Targets.TenantID.IsSet() {
+		return errors.Newf("%s requires a backup of the cluster or of a virtual cluster", optVerifyBackups)
+	}
+
 	evalCtx := &p.ExtendedEvalContext().Context
 	firstRun, err := scheduleFirstRun(evalCtx, scheduleOptions)
 	if err != nil {
@@ -338,10 +370,12 @@ func doCreateBackupSchedules(
 		}
 		inc, incScheduledBackupArgs, err = makeBackupSchedule(
 			env, p.User(), scheduleLabel, incRecurrence, incrementalScheduleDetails, unpauseOnSuccessID,
-			updateMetricOnSuccess, backupNode, chainProtectedTimestampRecords)
+			updateMetricOnSuccess, verifyBackups, backupNode, chainProtectedTimestampRecords)
 		if err != nil {
 			return err
 		}
+		// The args are persisted along with the dependent schedule ID below.
+		incScheduledBackupArgs.IncrementalCompactionThreshold = compactionThreshold
 		// Incremental is paused until FULL completes.
 		inc.Pause()
 		inc.SetScheduleStatus("Waiting for initial backup to complete")
@@ -362,7 +396,7 @@ func doCreateBackupSchedules(
 	var fullScheduledBackupArgs *backuppb.ScheduledBackupExecutionArgs
 	full, fullScheduledBackupArgs, err := makeBackupSchedule(
 		env, p.User(), scheduleLabel, fullRecurrence, details, unpauseOnSuccessID,
-		updateMetricOnSuccess, backupNode, chainProtectedTimestampRecords)
+		updateMetricOnSuccess, verifyBackups, backupNode, chainProtectedTimestampRecords)
 	if err != nil {
 		return err
 	}
@@ -405,6 +439,17 @@ func doCreateBackupSchedules(
 		kmsURIs, nil, resultsCh)
 }
 
+// parseIncrementalCompactionThreshold parses the value of the
+// incremental_compaction_threshold schedule option.
+func parseIncrementalCompactionThreshold(v string) (int64, error) {
+	threshold, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || threshold < 2 {
+		return 0, pgerror.Newf(pgcode.InvalidParameterValue,
+			"%s must be an integer greater than 1, found %q", optIncrementalCompactionThreshold, v)
+	}
+	return threshold, nil
+}
+
 func setDependentSchedule(
 	ctx context.Context,
 	storage jobs.ScheduledJobStorage,
@@ -467,6 +512,7 @@ func makeBackupSchedule(
 	details jobspb.ScheduleDetails,
 	unpauseOnSuccess int64,
 	updateLastMetricOnSuccess bool,
+	verifyBackups bool,
 	backupNode *tree.Backup,
 	chainProtectedTimestampRecords bool,
 ) (*jobs.ScheduledJob, *backuppb.ScheduledBackupExecutionArgs, error) {
@@ -479,6 +525,7 @@ func makeBackupSchedule(
 		UnpauseOnSuccess:               unpauseOnSuccess,
 		UpdatesLastBackupMetric:        updateLastMetricOnSuccess,
 		ChainProtectedTimestampRecords: chainProtectedTimestampRecords,
+		VerifyBackups:                  verifyBackups,
 	}
 	if backupNode.AppendToLatest {
 		args.BackupType = backuppb.ScheduledBackupExecutionArgs_INCREMENTAL
@@ -713,6 +760,14 @@ func makeScheduledBackupSpec(
 		spec.includeAllSecondaryTenants = &includeSecondary
 	}
 
+	if schedule.BackupOptions.ContentAddressed != nil {
+		contentAddressed, err := exprEval.Bool(ctx, schedule.BackupOptions.ContentAddressed)
+		if err != nil {
+			return nil, err
+		}
+		spec.contentAddressed = &contentAddressed
+	}
+
 	return spec, nil
 }
 
@@ -794,6 +849,7 @@ func createBackupScheduleTypeCheck(
 	bools := exprutil.Bools{
 		schedule.BackupOptions.CaptureRevisionHistory,
 		schedule.BackupOptions.IncludeAllSecondaryTenants,
+		schedule.BackupOptions.ContentAddressed,
 	}
 	if err := exprutil.TypeCheck(
 		ctx, scheduleBackupOp, p.SemaCtx(), stringExprs, bools, stringArrays, opts,
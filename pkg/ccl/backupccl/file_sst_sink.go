@@ -9,11 +9,15 @@
 package backupccl
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	io "io"
+	"path"
+	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
 	"github.com/cockroachdb/cockroach/pkg/cloud"
@@ -25,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	hlc "github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/errors"
 	gogotypes "github.com/gogo/protobuf/types"
 	"github.com/kr/pretty"
@@ -35,6 +40,17 @@ type sstSinkConf struct {
 	enc      *kvpb.FileEncryptionOptions
 	id       base.SQLInstanceID
 	settings *settings.Values
+
+	// contentAddressedDir, if set, is the directory relative to the sink's
+	// destination to which the sink writes files named by the hash of their
+	// contents. Each file is buffered in memory until it is flushed, so that its
+	// name is known before it is written, and is not written at all if a file
+	// of that name already exists, unless garbage collection marked that file
+	// for deletion.
+	contentAddressedDir string
+	// memMon, if set, accounts for the memory of the file buffered by a
+	// content-addressed sink.
+	memMon *mon.BytesMonitor
 }
 
 type fileSSTSink struct {
@@ -47,6 +63,15 @@ type fileSSTSink struct {
 	out     io.WriteCloser
 	outName string
 
+	// buf holds the file being written when the sink is content-addressed, and
+	// bufAcc accounts for its memory.
+	buf    bytes.Buffer
+	bufAcc *mon.BoundAccount
+	// gcMarks are the content-addressed files that garbage collection marked
+	// for deletion, which the sink does not reuse. They are read before the
+	// first content-addressed file is written.
+	gcMarks map[string]int64
+
 	flushedFiles []backuppb.BackupManifest_File
 	flushedSize  int64
 
@@ -70,7 +95,12 @@ type fileSSTSink struct {
 }
 
 func makeFileSSTSink(conf sstSinkConf, dest cloud.ExternalStorage) *fileSSTSink {
-	return &fileSSTSink{conf: conf, dest: dest}
+	s := &fileSSTSink{conf: conf, dest: dest}
+	if conf.memMon != nil {
+		acc := conf.memMon.MakeBoundAccount()
+		s.bufAcc = &acc
+	}
+	return s
 }
 
 func (s *fileSSTSink) Close() error {
@@ -81,6 +111,10 @@ func (s *fileSSTSink) Close() error {
 	if s.cancel != nil {
 		s.cancel()
 	}
+	if s.bufAcc != nil && s.ctx != nil {
+		s.buf = bytes.Buffer{}
+		s.bufAcc.Close(s.ctx)
+	}
 	if s.out != nil {
 		return s.out.Close()
 	}
@@ -129,6 +163,17 @@ func (s *fileSSTSink) flushFile(ctx context.Context) error {
 	s.outName = ""
 	s.out = nil
 
+	if s.conf.contentAddressedDir != "" {
+		name, err := s.writeContentAddressed(ctx)
+		if err != nil {
+			return err
+		}
+		for i := range s.flushedFiles {
+			s.flushedFiles[i].Path = name
+			s.flushedFiles[i].ContentAddressed = true
+		}
+	}
+
 	for i := range s.flushedFiles {
 		s.flushedFiles[i].BackingFileSize = wroteSize
 	}
@@ -159,10 +204,19 @@ func (s *fileSSTSink) flushFile(ctx context.Context) error {
 }
 
 func (s *fileSSTSink) open(ctx context.Context) error {
-	s.outName = generateUniqueSSTName(s.conf.id)
 	if s.ctx == nil {
 		s.ctx, s.cancel = context.WithCancel(ctx)
 	}
+	if s.conf.contentAddressedDir != "" {
+		// The name of the file, which the spans written to it record as their
+		// path, is only known once the file is flushed.
+		s.outName = s.conf.contentAddressedDir
+		s.buf.Reset()
+		s.out = accountedBuffer{ctx: s.ctx, buf: &s.buf, acc: s.bufAcc}
+		s.sst = storage.MakeBackupSSTWriter(ctx, s.dest.Settings(), s.out)
+		return nil
+	}
+	s.outName = generateUniqueSSTName(s.conf.id)
 	w, err := s.dest.Writer(s.ctx, s.outName)
 	if err != nil {
 		return err
@@ -322,6 +376,68 @@ func (s *fileSSTSink) copyRangeKeys(dataSST []byte) error {
 	return nil
 }
 
+// writeContentAddressed writes the buffered file to the content-addressed
+// directory, unless a file with the same contents was already written there by
+// this or another backup in the collection, and returns its path. A file that
+// garbage collection marked for deletion may be deleted at any time, so the
+// buffered file is written under another name instead of reusing it; see
+// gcBackupCollection.
+func (s *fileSSTSink) writeContentAddressed(ctx context.Context) (string, error) {
+	if s.gcMarks == nil {
+		marksPath := path.Join(path.Dir(s.conf.contentAddressedDir), backupbase.ContentAddressedGCMarksName)
+		marks, err := readContentAddressedGCMarks(ctx, s.dest, marksPath)
+		if err != nil {
+			return "", err
+		}
+		s.gcMarks = marks
+	}
+	sstName := contentAddressedSSTName(s.buf.Bytes())
+	name := path.Join(s.conf.contentAddressedDir, sstName)
+	if _, marked := s.gcMarks[sstName]; marked {
+		name = path.Join(s.conf.contentAddressedDir, fmt.Sprintf("%s-%d.sst",
+			strings.TrimSuffix(sstName, ".sst"), builtins.GenerateUniqueInt(builtins.ProcessUniqueID(s.conf.id))))
+		log.VEventf(ctx, 2, "backup file %s is marked for deletion, writing %s instead", sstName, name)
+	} else {
+		r, _, err := s.dest.ReadFile(ctx, name, cloud.ReadOptions{NoFileSize: true})
+		if err == nil {
+			r.Close(ctx)
+			log.VEventf(ctx, 2, "backup file %s already exists, not writing it again", name)
+			return name, nil
+		} else if !errors.Is(err, cloud.ErrFileDoesNotExist) {
+			return "", errors.Wrapf(err, "checking for backup file %s", name)
+		}
+	}
+	if err := cloud.WriteFile(ctx, s.dest, name, bytes.NewReader(s.buf.Bytes())); err != nil {
+		return "", errors.Wrapf(err, "writing backup file %s", name)
+	}
+	return name, nil
+}
+
+// accountedBuffer is the io.WriteCloser to which a content-addressed sink
+// writes its file. It accounts for the memory of the buffer before the buffer
+// grows.
+type accountedBuffer struct {
+	ctx context.Context
+	buf *bytes.Buffer
+	acc *mon.BoundAccount
+}
+
+// Write implements io.Writer.
+func (b accountedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.buf.Cap() {
+		// A bytes.Buffer grows to at most twice its capacity plus the size of
+		// the write.
+		if err := b.acc.ResizeTo(b.ctx, int64(2*b.buf.Cap()+len(p))); err != nil {
+			return 0, err
+		}
+	}
+	n, err := b.buf.Write(p)
+	return n, errors.CombineErrors(err, b.acc.ResizeTo(b.ctx, int64(b.buf.Cap())))
+}
+
+// Close implements io.Closer.
+func (accountedBuffer) Close() error { return nil }
+
 func generateUniqueSSTName(nodeID base.SQLInstanceID) string {
 	// The data/ prefix, including a /, is intended to group SSTs in most of the
 	// common file/bucket browse UIs.
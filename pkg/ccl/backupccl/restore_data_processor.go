@@ -22,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/bulk"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
@@ -32,6 +33,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	bulkutil "github.com/cockroachdb/cockroach/pkg/util/bulk"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
@@ -472,6 +474,13 @@ func (rd *restoreDataProcessor) openSSTs(
 func (rd *restoreDataProcessor) runRestoreWorkers(
 	ctx context.Context, entries chan execinfrapb.RestoreSpanEntry,
 ) error {
+	var urisByDir map[string]string
+	if rd.spec.LinkRemoteFiles {
+		var err error
+		if urisByDir, err = remoteFileURIsByDir(rd.spec.RemoteFileURIs); err != nil {
+			return err
+		}
+	}
 	return ctxgroup.GroupWorkers(ctx, rd.numWorkers, func(ctx context.Context, worker int) error {
 		kr, err := MakeKeyRewriterFromRekeys(rd.FlowCtx.Codec(), rd.spec.TableRekeys, rd.spec.TenantRekeys,
 			false /* restoreTenantFromStream */)
@@ -492,6 +501,21 @@ func (rd *restoreDataProcessor) runRestoreWorkers(
 					return done, nil
 				}
 
+				if rd.spec.LinkRemoteFiles {
+					progDetails, linked, err := rd.linkRestoreSpanEntry(ctx, kr, entry, urisByDir)
+					if err != nil {
+						return done, err
+					}
+					if linked {
+						select {
+						case rd.progCh <- progDetails:
+						case <-ctx.Done():
+							return done, ctx.Err()
+						}
+						return done, nil
+					}
+				}
+
 				var res *resumeEntry
 				for {
 					sstIter, res, err = rd.openSSTs(ctx, entry, res)
@@ -667,6 +691,91 @@ func (rd *restoreDataProcessor) processRestoreSpanEntry(
 	return batcher.GetSummary(), nil
 }
 
+// remoteFileURIsByDir maps the parsed form of each backup location URI, which
+// is what the restore span entries carry, back to the URI, which is what
+// locates a linked file.
+func remoteFileURIsByDir(uris []string) (map[string]string, error) {
+	urisByDir := make(map[string]string, len(uris))
+	for _, u := range uris {
+		dir, err := cloud.ExternalStorageConfFromURI(u, username.SQLUsername{})
+		if err != nil {
+			return nil, err
+		}
+		urisByDir[dir.String()] = u
+	}
+	return urisByDir, nil
+}
+
+// linkRestoreSpanEntry links the files of the entry into its span with
+// AddRemoteSSTable requests rather than ingesting copies of their keys. The
+// stores read the linked files from external storage until the restore's
+// download job has them rewritten as local files. Linked files can't have
+// their keys rewritten, so entries whose keys are restored under new descriptor
+// or tenant IDs are not linked, in which case false is returned and the entry
+// must be ingested.
+func (rd *restoreDataProcessor) linkRestoreSpanEntry(
+	ctx context.Context,
+	kr *KeyRewriter,
+	entry execinfrapb.RestoreSpanEntry,
+	urisByDir map[string]string,
+) (progDetails backuppb.RestoreProgress, linked bool, _ error) {
+	if rd.spec.ValidateOnly {
+		return progDetails, false, nil
+	}
+	rewritten, ok, err := kr.RewriteKey(append(roachpb.Key(nil), entry.Span.Key...), 0 /* wallTime */)
+	if err != nil || !ok || !entry.Span.Key.Equal(rewritten) {
+		return progDetails, false, err
+	}
+
+	db := rd.flowCtx.Cfg.DB.KV()
+	for _, file := range entry.Files {
+		uri, ok := urisByDir[file.Dir.String()]
+		if !ok {
+			return progDetails, false, errors.AssertionFailedf("no URI for backup location %s", file.Dir.String())
+		}
+		size := file.BackingFileSize
+		if size == 0 {
+			es, err := rd.flowCtx.Cfg.ExternalStorage(ctx, file.Dir)
+			if err != nil {
+				return progDetails, false, err
+			}
+			sz, err := es.Size(ctx, file.Path)
+			_ = es.Close()
+			if err != nil {
+				return progDetails, false, err
+			}
+			size = uint64(sz)
+		}
+
+		// The counts are those of the whole file, which may cover more than the
+		// entry, so the stats are only estimates.
+		counts := file.BackupFileEntryCounts
+		stats := &enginepb.MVCCStats{
+			ContainsEstimates: 1,
+			KeyBytes:          counts.DataSize / 2,
+			ValBytes:          counts.DataSize / 2,
+			LiveBytes:         counts.DataSize,
+			KeyCount:          counts.Rows + counts.IndexEntries,
+			LiveCount:         counts.Rows + counts.IndexEntries,
+		}
+		loc := kvpb.AddSSTableRequest_RemoteFile{
+			Locator:         uri,
+			Path:            file.Path,
+			BackingFileSize: size,
+		}
+		if _, _, err := db.AddRemoteSSTable(
+			ctx, file.BackupFileEntrySpan.Intersect(entry.Span), loc, stats,
+		); err != nil {
+			return progDetails, false, errors.Wrapf(err, "linking %s", file.Path)
+		}
+		progDetails.Summary.Add(counts)
+	}
+	progDetails.ProgressIdx = entry.ProgressIdx
+	progDetails.DataSpan = entry.Span
+	progDetails.CompleteUpTo = rd.spec.RestoreTime
+	return progDetails, true, nil
+}
+
 func makeProgressUpdate(
 	summary kvpb.BulkOpSummary,
 	entry execinfrapb.RestoreSpanEntry,
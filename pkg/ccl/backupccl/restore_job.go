@@ -61,10 +61,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
-	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
-	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/interval"
@@ -91,6 +89,18 @@ var useSimpleImportSpans = settings.RegisterBoolSetting(
 	false,
 )
 
+// onlineRestoreEnabled gates RESTORE ... WITH EXPERIMENTAL DEFERRED COPY, which
+// links the backup's files into the restored spans rather than ingesting copies
+// of them, and downloads them into the stores in a separate job once the
+// restored descriptors are published.
+var onlineRestoreEnabled = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"bulkio.restore.experimental_deferred_copy.enabled",
+	"if set to true, RESTORE may use the experimental deferred copy option, which makes the "+
+		"restored data available before it is copied out of the backup",
+	false,
+)
+
 var restoreStatsInsertionConcurrency = settings.RegisterIntSetting(
 	settings.TenantWritable,
 	"bulkio.restore.insert_stats_workers",
@@ -403,25 +413,6 @@ func restore(
 	tasks = append(tasks, generativeCheckpointLoop)
 
 	runRestore := func(ctx context.Context) error {
-		if details.ExperimentalOnline {
-			log.Warningf(ctx, "EXPERIMENTAL ONLINE RESTORE being used")
-			return sendAddRemoteSSTs(
-				ctx,
-				execCtx,
-				job,
-				dataToRestore,
-				endTime,
-				encryption,
-				kmsEnv,
-				details.URIs,
-				backupLocalityInfo,
-				filter,
-				numImportSpans,
-				simpleImportSpans,
-				progCh,
-				genSpan,
-			)
-		}
 		return distRestore(
 			ctx,
 			execCtx,
@@ -435,6 +426,7 @@ func restore(
 			filter,
 			numImportSpans,
 			simpleImportSpans,
+			details.ExperimentalOnline,
 			details.ExecutionLocality,
 			progCh,
 		)
@@ -2315,6 +2307,15 @@ func (r *restoreResumer) publishDescriptors(
 		}
 	}
 
+	// The linked files of an online restore are downloaded by a job created
+	// along with the publication of the descriptors, so that exactly one is
+	// created however often this job is resumed.
+	if details.ExperimentalOnline {
+		if err := r.createDownloadJob(ctx, jobsRegistry, txn, newTables, details.Tenants); err != nil {
+			return err
+		}
+	}
+
 	// Update and persist the state of the job.
 	details.DescriptorsPublished = true
 	details.TableDescs = newTables
@@ -3146,175 +3147,6 @@ func (r *restoreResumer) cleanupTempSystemTables(ctx context.Context) error {
 	return nil
 }
 
-var onlineRestoreGate = envutil.EnvOrDefaultBool("COCKROACH_UNSAFE_RESTORE", false)
-
-// sendAddRemoteSSTs is a stubbed out, very simplisitic version of restore used
-// to test out ingesting "remote" SSTs. It will be replaced with a real distsql
-// plan and processors in the future.
-func sendAddRemoteSSTs(
-	ctx context.Context,
-	execCtx sql.JobExecContext,
-	job *jobs.Job,
-	dataToRestore restorationData,
-	restoreTime hlc.Timestamp,
-	encryption *jobspb.BackupEncryptionOptions,
-	kmsEnv cloud.KMSEnv,
-	uris []string,
-	backupLocalityInfo []jobspb.RestoreDetails_BackupLocalityInfo,
-	spanFilter spanCoveringFilter,
-	numImportSpans int,
-	useSimpleImportSpans bool,
-	progCh chan *execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
-	genSpan func(ctx context.Context, spanCh chan execinfrapb.RestoreSpanEntry) error,
-) error {
-	defer close(progCh)
-
-	if !onlineRestoreGate {
-		return errors.AssertionFailedf("experimental restore mode not supported")
-	}
-
-	if encryption != nil {
-		return errors.AssertionFailedf("encryption not supported with online restore")
-	}
-	if useSimpleImportSpans {
-		return errors.AssertionFailedf("useSimpleImportSpans is not supported with online restore")
-	}
-	if len(uris) > 1 {
-		return errors.AssertionFailedf("online restore can only restore data from a full backup")
-	}
-
-	restoreSpanEntriesCh := make(chan execinfrapb.RestoreSpanEntry, 1)
-
-	g := ctxgroup.WithContext(ctx)
-	g.GoCtx(func(ctx context.Context) error {
-		return genSpan(ctx, restoreSpanEntriesCh)
-	})
-	remainingBytesInTargetRange := int64(512 << 20)
-
-	// We lost the string URIs for the backup storage locations very early in the
-	// process of planning the restore, when the backups were resolved, and the
-	// parsed proto versions -- which we usually prefer -- were attached to the
-	// backup manifests and the individual files during span generation. However
-	// for telling pebble the locations of the files we need those raw string URIs
-	// again. We could plumb them side-by-side with the proto versions, but for
-	// now we'll just reverse engineer them: we'll make a map that has the proto
-	// version of every URI we might have parsed -- all the default backup URIs
-	// and any locality bucket URIs -- to the raw URI that produces that proto.
-	// We can then look in this map using the proto attached to each file to find
-	// the URI for that file.
-	// TODO(dt/butler): should we plumb the original string instead?
-	urisForDirs := make(map[string]string)
-	for _, u := range uris {
-		dir, err := cloud.ExternalStorageConfFromURI(u, username.SQLUsername{})
-		if err != nil {
-			return err
-		}
-		urisForDirs[dir.String()] = u
-	}
-	for _, loc := range backupLocalityInfo {
-		for _, u := range loc.URIsByOriginalLocalityKV {
-			dir, err := cloud.ExternalStorageConfFromURI(u, username.SQLUsername{})
-			if err != nil {
-				return err
-			}
-			urisForDirs[dir.String()] = u
-		}
-	}
-
-	openedStorages := make(map[cloudpb.ExternalStorage]cloud.ExternalStorage)
-	defer func() {
-		for _, es := range openedStorages {
-			es.Close()
-		}
-	}()
-
-	for entry := range restoreSpanEntriesCh {
-		for _, file := range entry.Files {
-
-			log.Infof(ctx, "Experimental restore: sending span %s of file %s",
-				file.BackupFileEntrySpan, file.Path)
-
-			restoringSubspan := file.BackupFileEntrySpan.Intersect(entry.Span)
-
-			// NB: Since the restored span is a subset of the BackupFileEntrySpan,
-			// these counts may be an overestimate of what actually gets restored.
-			counts := file.BackupFileEntryCounts
-
-			if counts.DataSize > remainingBytesInTargetRange {
-				log.Infof(ctx, "Experimental restore: need to split since %d > %d",
-					counts.DataSize, remainingBytesInTargetRange,
-				)
-				expiration := execCtx.ExecCfg().Clock.Now().AddDuration(time.Hour)
-				if err := execCtx.ExecCfg().DB.AdminSplit(ctx, restoringSubspan.Key, expiration); err != nil {
-					log.Warningf(ctx, "failed to split during experimental restore: %v", err)
-				}
-				if _, err := execCtx.ExecCfg().DB.AdminScatter(ctx, restoringSubspan.Key, 4<<20); err != nil {
-					log.Warningf(ctx, "failed to scatter during experimental restore: %v", err)
-				}
-			}
-
-			if file.BackingFileSize == 0 {
-				if _, ok := openedStorages[file.Dir]; !ok {
-					es, err := execCtx.ExecCfg().DistSQLSrv.ExternalStorage(ctx, file.Dir)
-					if err != nil {
-						return err
-					}
-					openedStorages[file.Dir] = es
-				}
-
-				sz, err := openedStorages[file.Dir].Size(ctx, file.Path)
-				if err != nil {
-					return err
-				}
-				file.BackingFileSize = uint64(sz)
-			}
-			uri, ok := urisForDirs[file.Dir.String()]
-			if !ok {
-				return errors.AssertionFailedf("URI not found for %s", file.Dir.String())
-			}
-
-			loc := kvpb.AddSSTableRequest_RemoteFile{
-				Locator:         uri,
-				Path:            file.Path,
-				BackingFileSize: file.BackingFileSize,
-			}
-			// TODO(dt): see if KV has any better ideas for making these up.
-			fileStats := &enginepb.MVCCStats{
-				ContainsEstimates: 1,
-				KeyBytes:          counts.DataSize / 2,
-				ValBytes:          counts.DataSize / 2,
-				LiveBytes:         counts.DataSize,
-				KeyCount:          counts.Rows + counts.IndexEntries,
-				LiveCount:         counts.Rows + counts.IndexEntries,
-			}
-			var err error
-			_, remainingBytesInTargetRange, err = execCtx.ExecCfg().DB.AddRemoteSSTable(ctx,
-				restoringSubspan, loc,
-				fileStats)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	downloadSpans := dataToRestore.getSpans()
-
-	log.Infof(ctx, "creating job to track downloads in %d spans", len(downloadSpans))
-	downloadJobRecord := jobs.Record{
-		Description: fmt.Sprintf("Background Data Download for %s", job.Payload().Description),
-		Username:    job.Payload().UsernameProto.Decode(),
-		Details:     jobspb.RestoreDetails{DownloadSpans: downloadSpans},
-		Progress:    jobspb.RestoreProgress{},
-	}
-
-	return execCtx.ExecCfg().InternalDB.DescsTxn(ctx, func(
-		ctx context.Context, txn descs.Txn,
-	) error {
-		_, err := execCtx.ExecCfg().JobRegistry.CreateJobWithTxn(ctx, downloadJobRecord, job.ID()+1, txn)
-		return err
-	})
-}
-
 var _ jobs.Resumer = &restoreResumer{}
 
 func init() {
@@ -3330,8 +3162,51 @@ func init() {
 	)
 }
 
+// createDownloadJob creates the job which downloads the linked files of an
+// online restore into the stores, which resumes as a download-only restore job
+// since its details only hold the spans to download.
+func (r *restoreResumer) createDownloadJob(
+	ctx context.Context,
+	jobsRegistry *jobs.Registry,
+	txn descs.Txn,
+	tables []*descpb.TableDescriptor,
+	tenants []mtinfopb.TenantInfoWithUsage,
+) error {
+	var spans []roachpb.Span
+	for _, tbl := range tables {
+		spans = append(spans, tabledesc.NewBuilder(tbl).BuildImmutableTable().TableSpan(r.execCfg.Codec))
+	}
+	for _, tenant := range tenants {
+		tenantID, err := roachpb.MakeTenantID(tenant.ID)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, keys.MakeSQLCodec(tenantID).TenantSpan())
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	payload := r.job.Payload()
+	record := jobs.Record{
+		Description: fmt.Sprintf("Background Data Download for %s", payload.Description),
+		Username:    payload.UsernameProto.Decode(),
+		Details:     jobspb.RestoreDetails{DownloadSpans: spans},
+		Progress:    jobspb.RestoreProgress{},
+	}
+	jobID := jobsRegistry.MakeJobID()
+	if _, err := jobsRegistry.CreateJobWithTxn(ctx, record, jobID, txn); err != nil {
+		return errors.Wrap(err, "creating download job")
+	}
+	log.Infof(ctx, "created job %d to download %d restored spans", jobID, len(spans))
+	return nil
+}
+
 func (r *restoreResumer) doDownloadFiles(ctx context.Context, execCtx sql.JobExecContext) error {
 	details := r.job.Details().(jobspb.RestoreDetails)
+	if err := execCtx.ExecCfg().JobRegistry.CheckPausepoint("restore.before_download"); err != nil {
+		return err
+	}
 	total := r.job.Progress().Details.(*jobspb.Progress_Restore).Restore.TotalDownloadRequired
 
 	// If this is the first resumption of this job, we need to find out the total
@@ -3376,6 +3251,32 @@ func (r *restoreResumer) doDownloadFiles(ctx context.Context, execCtx sql.JobExe
 		}
 	}
 
+	// Pebble rewrites the external files backing a span as local files when it
+	// compacts the span, so rather than waiting for background compactions to
+	// get to the restored spans, ask every store with a replica of them to
+	// compact them while we track the progress of the download.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g := ctxgroup.WithContext(ctx)
+	g.GoCtx(func(ctx context.Context) error {
+		if err := downloadSpans(ctx, execCtx.ExecCfg(), details.DownloadSpans); err != nil && ctx.Err() == nil {
+			log.Warningf(ctx, "failed to download restored spans, waiting for compactions instead: %v", err)
+		}
+		return nil
+	})
+	g.GoCtx(func(ctx context.Context) error {
+		defer cancel()
+		return r.waitForDownload(ctx, execCtx, total)
+	})
+	return g.Wait()
+}
+
+// waitForDownload polls the amount of data in the download spans which is still
+// in external files until it reaches zero, updating the job's progress.
+func (r *restoreResumer) waitForDownload(
+	ctx context.Context, execCtx sql.JobExecContext, total uint64,
+) error {
+	details := r.job.Details().(jobspb.RestoreDetails)
 	var lastProgressUpdate time.Time
 	for rt := retry.StartWithCtx(
 		ctx, retry.Options{InitialBackoff: time.Second * 10, Multiplier: 1.2, MaxBackoff: time.Minute * 5},
@@ -3414,6 +3315,47 @@ func (r *restoreResumer) doDownloadFiles(ctx context.Context, execCtx sql.JobExe
 	}
 }
 
+// downloadSpans asks every store with a replica of the spans to compact the
+// part of the spans the replica covers. Each store compacts one span at a time,
+// but all stores work in parallel.
+func downloadSpans(ctx context.Context, execCfg *sql.ExecutorConfig, spans []roachpb.Span) error {
+	type storeKey struct {
+		nodeID  roachpb.NodeID
+		storeID roachpb.StoreID
+	}
+	spansByStore := make(map[storeKey][]roachpb.Span)
+	for _, span := range spans {
+		iter, err := execCfg.RangeDescIteratorFactory.NewIterator(ctx, span)
+		if err != nil {
+			return err
+		}
+		for ; iter.Valid(); iter.Next() {
+			desc := iter.CurRangeDescriptor()
+			replicaSpan := span.Intersect(desc.KeySpan().AsRawSpanWithNoLocals())
+			for _, replica := range desc.Replicas().Descriptors() {
+				store := storeKey{nodeID: replica.NodeID, storeID: replica.StoreID}
+				spansByStore[store] = append(spansByStore[store], replicaSpan)
+			}
+		}
+	}
+
+	g := ctxgroup.WithContext(ctx)
+	for store, storeSpans := range spansByStore {
+		store, storeSpans := store, storeSpans
+		g.GoCtx(func(ctx context.Context) error {
+			for _, span := range storeSpans {
+				if err := execCfg.CompactEngineSpanFunc(
+					ctx, int32(store.nodeID), int32(store.storeID), span.Key, span.EndKey,
+				); err != nil {
+					return errors.Wrapf(err, "downloading %s on n%d,s%d", span, store.nodeID, store.storeID)
+				}
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
 type sz int64
 
 func (b sz) String() string { return string(humanizeutil.IBytes(int64(b))) }
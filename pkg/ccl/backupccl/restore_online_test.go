@@ -0,0 +1,73 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// TestOnlineRestore restores a cluster backup with experimental deferred copy
+// and checks that the restored data can be read while its download is paused,
+// and after the download has completed.
+func TestOnlineRestore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	skip.IgnoreLint(t, "the stores can't apply linked files until Pebble can ingest external files")
+
+	const numAccounts = 1000
+	_, sqlDB, dir, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+	sqlDB.Exec(t, `BACKUP INTO $1`, localFoo)
+	fingerprint := sqlDB.QueryStr(t, `SHOW EXPERIMENTAL_FINGERPRINTS FROM TABLE data.bank`)
+
+	_, restoreDB, cleanupEmptyCluster := backupRestoreTestSetupEmpty(
+		t, singleNode, dir, InitManualReplication, base.TestClusterArgs{},
+	)
+	defer cleanupEmptyCluster()
+
+	// Experimental deferred copy must be enabled.
+	restoreDB.ExpectErr(t, `experimental deferred copy is disabled`,
+		`RESTORE FROM LATEST IN $1 WITH EXPERIMENTAL DEFERRED COPY`, localFoo)
+	restoreDB.Exec(t, `SET CLUSTER SETTING bulkio.restore.experimental_deferred_copy.enabled = true`)
+
+	// Pause the restore once it has linked the files, to check that it creates
+	// a single download job when resumed.
+	restoreDB.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = 'restore.before_publishing_descriptors'`)
+	var restoreJobID jobspb.JobID
+	restoreDB.QueryRow(t, `RESTORE FROM LATEST IN $1 WITH EXPERIMENTAL DEFERRED COPY, DETACHED`,
+		localFoo).Scan(&restoreJobID)
+	jobutils.WaitForJobToPause(t, restoreDB, restoreJobID)
+	restoreDB.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = 'restore.before_download'`)
+	restoreDB.Exec(t, `RESUME JOB $1`, restoreJobID)
+	jobutils.WaitForJobToSucceed(t, restoreDB, restoreJobID)
+
+	var downloadJobID jobspb.JobID
+	restoreDB.QueryRow(t, `SELECT job_id FROM [SHOW JOBS]
+		WHERE description LIKE 'Background Data Download%'`).Scan(&downloadJobID)
+	jobutils.WaitForJobToPause(t, restoreDB, downloadJobID)
+
+	// The restored data is read from the backup before it is downloaded.
+	restoreDB.CheckQueryResults(t, `SELECT count(*) FROM data.bank`,
+		[][]string{{strconv.Itoa(numAccounts)}})
+	restoreDB.CheckQueryResults(t, `SHOW EXPERIMENTAL_FINGERPRINTS FROM TABLE data.bank`, fingerprint)
+
+	restoreDB.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = ''`)
+	restoreDB.Exec(t, `RESUME JOB $1`, downloadJobID)
+	jobutils.WaitForJobToSucceed(t, restoreDB, downloadJobID)
+	restoreDB.CheckQueryResults(t, `SHOW EXPERIMENTAL_FINGERPRINTS FROM TABLE data.bank`, fingerprint)
+}
@@ -1046,6 +1046,39 @@ func logSanitizedRestoreDestination(ctx context.Context, restoreDestinations str
 	log.Ops.Infof(ctx, "restore planning to connect to destination %v", redact.Safe(restoreDestinations))
 }
 
+// checkOnlineRestoreSupported returns an error if the backup can't be restored
+// by linking its files into the restored spans rather than ingesting copies of
+// them, which is only possible if the files hold exactly the data to restore.
+func checkOnlineRestoreSupported(
+	sv *settings.Values,
+	mainBackupManifests []backuppb.BackupManifest,
+	endTime hlc.Timestamp,
+	encryption *jobspb.BackupEncryptionOptions,
+	schemaOnly bool,
+) error {
+	if !onlineRestoreEnabled.Get(sv) {
+		return errors.WithHintf(errors.New("experimental deferred copy is disabled"),
+			"enable it with the %s cluster setting", onlineRestoreEnabled.Key())
+	}
+	if schemaOnly {
+		return errors.New("experimental deferred copy cannot be used with schema_only")
+	}
+	if encryption != nil {
+		return errors.New("experimental deferred copy cannot restore encrypted backups")
+	}
+	if len(mainBackupManifests) > 1 {
+		return errors.New("experimental deferred copy can only restore a full backup without incremental backups")
+	}
+	// The files of a revision history backup hold every revision up to the end
+	// of the backup, so they can't be linked to restore an earlier time.
+	if m := mainBackupManifests[0]; m.MVCCFilter == backuppb.MVCCFilter_All &&
+		!endTime.IsEmpty() && endTime.Less(m.EndTime) {
+		return errors.Newf("experimental deferred copy cannot restore a revision history backup "+
+			"as of %s, before its end time %s", endTime, m.EndTime)
+	}
+	return nil
+}
+
 // restorePlanHook implements sql.PlanHookFn.
 func restorePlanHook(
 	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
@@ -1689,6 +1722,14 @@ func doRestorePlan(
 		return err
 	}
 
+	if restoreStmt.Options.ExperimentalOnline {
+		if err := checkOnlineRestoreSupported(
+			&p.ExecCfg().Settings.SV, mainBackupManifests, endTime, encryption, restoreStmt.Options.SchemaOnly,
+		); err != nil {
+			return err
+		}
+	}
+
 	if restoreStmt.DescriptorCoverage == tree.AllDescriptors {
 		// Validate that the backup is a full cluster backup if a full cluster restore was requested.
 		if mainBackupManifests[0].DescriptorCoverage == tree.RequestedDescriptors {
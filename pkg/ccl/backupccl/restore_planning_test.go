@@ -14,8 +14,10 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
 	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/stretchr/testify/require"
 )
@@ -94,3 +96,69 @@ func TestBackupManifestVersionCompatibility(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckOnlineRestoreSupported(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	onlineRestoreEnabled.Override(ctx, &st.SV, true)
+
+	full := backuppb.BackupManifest{EndTime: hlc.Timestamp{WallTime: 10}}
+	revisions := full
+	revisions.MVCCFilter = backuppb.MVCCFilter_All
+	inc := backuppb.BackupManifest{StartTime: full.EndTime, EndTime: hlc.Timestamp{WallTime: 20}}
+
+	for _, tc := range []struct {
+		name        string
+		manifests   []backuppb.BackupManifest
+		endTime     hlc.Timestamp
+		encryption  *jobspb.BackupEncryptionOptions
+		schemaOnly  bool
+		expectedErr string
+	}{
+		{name: "full", manifests: []backuppb.BackupManifest{full}},
+		{name: "revision-history", manifests: []backuppb.BackupManifest{revisions}},
+		{
+			name:      "revision-history-at-end-time",
+			manifests: []backuppb.BackupManifest{revisions},
+			endTime:   revisions.EndTime,
+		},
+		{
+			name:        "revision-history-before-end-time",
+			manifests:   []backuppb.BackupManifest{revisions},
+			endTime:     hlc.Timestamp{WallTime: 5},
+			expectedErr: "cannot restore a revision history backup as of 0.000000005,0",
+		},
+		{
+			name:        "incremental",
+			manifests:   []backuppb.BackupManifest{full, inc},
+			expectedErr: "can only restore a full backup",
+		},
+		{
+			name:        "encrypted",
+			manifests:   []backuppb.BackupManifest{full},
+			encryption:  &jobspb.BackupEncryptionOptions{Mode: jobspb.EncryptionMode_Passphrase},
+			expectedErr: "cannot restore encrypted backups",
+		},
+		{
+			name:        "schema-only",
+			manifests:   []backuppb.BackupManifest{full},
+			schemaOnly:  true,
+			expectedErr: "cannot be used with schema_only",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkOnlineRestoreSupported(&st.SV, tc.manifests, tc.endTime, tc.encryption, tc.schemaOnly)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+
+	onlineRestoreEnabled.Override(ctx, &st.SV, false)
+	require.ErrorContains(t, checkOnlineRestoreSupported(
+		&st.SV, []backuppb.BackupManifest{full}, hlc.Timestamp{}, nil, false), "experimental deferred copy is disabled")
+}
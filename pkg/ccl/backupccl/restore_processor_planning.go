@@ -63,7 +63,8 @@ var memoryMonitorSSTs = settings.RegisterBoolSetting(
 // Those processors will then route the spans after they have split and
 // scattered them to the restore data processors - the second stage. The spans
 // should be routed to the node that is the leaseholder of that span. The
-// restore data processor will finally download and insert the data, and this is
+// restore data processor will finally download and insert the data, or link the
+// backup files into the restored spans if linkRemoteFiles is set, and this is
 // reported back to the coordinator via the progCh.
 // This method also closes the given progCh.
 func distRestore(
@@ -79,6 +80,7 @@ func distRestore(
 	spanFilter spanCoveringFilter,
 	numImportSpans int,
 	useSimpleImportSpans bool,
+	linkRemoteFiles bool,
 	execLocality roachpb.Locality,
 	progCh chan *execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
 ) error {
@@ -133,6 +135,15 @@ func distRestore(
 			PKIDs:             dataToRestore.getPKIDs(),
 			ValidateOnly:      dataToRestore.isValidateOnly(),
 			MemoryMonitorSSTs: memMonSSTs,
+			LinkRemoteFiles:   linkRemoteFiles,
+		}
+		if linkRemoteFiles {
+			restoreDataSpec.RemoteFileURIs = append(restoreDataSpec.RemoteFileURIs, uris...)
+			for _, loc := range backupLocalityInfo {
+				for _, u := range loc.URIsByOriginalLocalityKV {
+					restoreDataSpec.RemoteFileURIs = append(restoreDataSpec.RemoteFileURIs, u)
+				}
+			}
 		}
 
 		// Plan SplitAndScatter in a round-robin fashion.
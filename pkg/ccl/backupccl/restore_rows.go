@@ -0,0 +1,326 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/resolver"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+// restoreRowsBatchSize is the number of rows of the backup that RESTORE ROWS
+// writes with each UPSERT statement.
+const restoreRowsBatchSize = 100
+
+var restoreRowsHeader = colinfo.ResultColumns{
+	{Name: "rows", Typ: types.Int},
+}
+
+func restoreRowsTypeCheck(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (matched bool, header colinfo.ResultColumns, _ error) {
+	restoreStmt, ok := stmt.(*tree.RestoreRows)
+	if !ok {
+		return false, nil, nil
+	}
+	if err := exprutil.TypeCheck(
+		ctx, "RESTORE ROWS", p.SemaCtx(),
+		exprutil.Strings{
+			restoreStmt.Subdir,
+			restoreStmt.Options.EncryptionPassphrase,
+		},
+		exprutil.StringArrays{
+			tree.Exprs(restoreStmt.InCollection),
+			tree.Exprs(restoreStmt.Options.IncrementalStorage),
+			tree.Exprs(restoreStmt.Options.DecryptionKMSURI),
+		},
+	); err != nil {
+		return false, nil, err
+	}
+	return true, restoreRowsHeader, nil
+}
+
+// restoreRowsPlanHook implements PlanHookFn for RESTORE ROWS, which writes the
+// rows of a table in a backup that match a filter back into the live table, in
+// the statement's transaction. Rows that are in the live table but not in the
+// backup are left alone.
+func restoreRowsPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	restoreStmt, ok := stmt.(*tree.RestoreRows)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	unsupported := restoreStmt.Options
+	unsupported.EncryptionPassphrase = nil
+	unsupported.DecryptionKMSURI = nil
+	unsupported.IncrementalStorage = nil
+	if !unsupported.IsDefault() {
+		return nil, nil, nil, false, errors.New(
+			"RESTORE ROWS only supports the encryption_passphrase, kms and incremental_location options")
+	}
+
+	exprEval := p.ExprEvaluator("RESTORE ROWS")
+	collection, err := exprEval.StringArray(ctx, tree.Exprs(restoreStmt.InCollection))
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	subdir, err := exprEval.String(ctx, restoreStmt.Subdir)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	enc, incStorage, err := evalBackupReadOptions(
+		ctx, exprEval, restoreStmt.Options.EncryptionPassphrase, restoreStmt.Options.DecryptionKMSURI,
+		restoreStmt.Options.IncrementalStorage)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	var asOf hlc.Timestamp
+	if restoreStmt.AsOf.Expr != nil {
+		ts, err := p.EvalAsOfTimestamp(ctx, restoreStmt.AsOf)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		asOf = ts.Timestamp
+	}
+
+	tn := restoreStmt.Table
+	prefix, live, err := resolver.ResolveExistingTableObject(ctx, p, &tn, tree.ObjectLookupFlags{
+		Required:             true,
+		DesiredObjectKind:    tree.TableObject,
+		DesiredTableDescKind: tree.ResolveRequireTableDesc,
+	})
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	filterCols, err := restoreRowsFilterColumns(restoreStmt.Where.Expr, live)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	filter := formatRestoreRowsFilter(p, restoreStmt.Where.Expr)
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer span.Finish()
+
+		// The table is looked up in the backup by the fully qualified name that
+		// it resolved to.
+		backupName := tree.MakeTableNameWithSchema(
+			tree.Name(prefix.Database.GetName()), tree.Name(prefix.Schema.GetName()), tree.Name(live.GetName()))
+		bt, err := openBackupTable(ctx, p, backupTableSource{
+			collection:         collection,
+			subdir:             subdir,
+			asOf:               asOf,
+			incrementalStorage: incStorage,
+			encryption:         enc,
+		}, backupName)
+		if err != nil {
+			return err
+		}
+		defer bt.close()
+
+		n, err := restoreBackupRows(ctx, p, bt, live, filter, filterCols)
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(n))}:
+			return nil
+		}
+	}
+	return fn, restoreRowsHeader, nil, false, nil
+}
+
+// restoreRowsFilterColumns returns the names of the columns referenced by the
+// WHERE clause of a RESTORE ROWS, which is evaluated against the rows of the
+// backup rather than those of the live table. The clause may therefore only
+// reference, by unqualified name, the columns of the live table that are
+// restored, which excludes computed columns, and may not contain subqueries.
+// Whether the backup has the columns is only known once it is read.
+func restoreRowsFilterColumns(filter tree.Expr, live catalog.TableDescriptor) ([]string, error) {
+	var cols []string
+	_, err := tree.SimpleVisit(filter, func(expr tree.Expr) (bool, tree.Expr, error) {
+		switch t := expr.(type) {
+		case *tree.Subquery:
+			return false, expr, pgerror.New(pgcode.FeatureNotSupported,
+				"subqueries are not supported in the WHERE clause of RESTORE ROWS")
+		case *tree.UnresolvedName:
+			if t.Star || t.NumParts > 1 {
+				return false, expr, pgerror.Newf(pgcode.FeatureNotSupported,
+					"column reference %s must not be qualified in the WHERE clause of RESTORE ROWS",
+					tree.ErrString(t))
+			}
+			col := catalog.FindColumnByName(live, t.Parts[0])
+			if col == nil || !col.Public() {
+				return false, expr, colinfo.NewUndefinedColumnError(t.Parts[0])
+			}
+			if col.IsComputed() {
+				return false, expr, pgerror.Newf(pgcode.FeatureNotSupported,
+					"computed column %q can't be referenced in the WHERE clause of RESTORE ROWS "+
+						"since it is not restored", col.GetName())
+			}
+			cols = append(cols, col.GetName())
+		}
+		return true, expr, nil
+	})
+	return cols, err
+}
+
+// formatRestoreRowsFilter formats the WHERE clause of a RESTORE ROWS to be used
+// in the statements that write the rows, substituting any placeholders with
+// their values.
+func formatRestoreRowsFilter(p sql.PlanHookState, filter tree.Expr) string {
+	placeholders := p.ExtendedEvalContext().Placeholders
+	f := tree.NewFmtCtx(
+		tree.FmtSerializable,
+		tree.FmtPlaceholderFormat(func(ctx *tree.FmtCtx, placeholder *tree.Placeholder) {
+			if placeholders != nil {
+				if v, ok := placeholders.Value(placeholder.Idx); ok {
+					ctx.FormatNode(v)
+					return
+				}
+			}
+			ctx.WriteString(placeholder.String())
+		}),
+	)
+	f.FormatNode(filter)
+	return f.CloseAndGetString()
+}
+
+// restoreBackupRows upserts the rows of the table in the backup that match the
+// filter into the live table, and returns the number of rows written. Columns
+// are matched by name; the live columns that the backup does not have keep
+// their current values, or get their default values in rows that are added.
+// The filter must only reference filterCols, which must be in the backup.
+func restoreBackupRows(
+	ctx context.Context,
+	p sql.PlanHookState,
+	bt *backupTable,
+	live catalog.TableDescriptor,
+	filter string,
+	filterCols []string,
+) (int, error) {
+	rows, err := bt.newRowIter(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.close(ctx)
+
+	// Only the live columns that can be written are restored, from the
+	// ordinals in the backup rows of the columns of the same name.
+	var names tree.NameList
+	var casts []string
+	var ords []int
+	pkCols := live.GetPrimaryIndex().CollectKeyColumnIDs()
+	for _, col := range live.PublicColumns() {
+		if col.IsComputed() {
+			continue
+		}
+		ord := -1
+		for i, c := range rows.cols {
+			if c.GetName() == col.GetName() {
+				ord = i
+				break
+			}
+		}
+		if ord < 0 {
+			if pkCols.Contains(col.GetID()) {
+				return 0, errors.Newf("primary key column %q of table %q is not in the backup",
+					col.GetName(), live.GetName())
+			}
+			continue
+		}
+		names = append(names, tree.Name(col.GetName()))
+		casts = append(casts, col.GetType().SQLString())
+		ords = append(ords, ord)
+	}
+	for _, name := range filterCols {
+		var found bool
+		for _, n := range names {
+			found = found || string(n) == name
+		}
+		if !found {
+			return 0, pgerror.Newf(pgcode.UndefinedColumn,
+				"column %q referenced in the WHERE clause of RESTORE ROWS is not in the backup of table %q",
+				name, live.GetName())
+		}
+	}
+	cols := tree.AsString(&names)
+
+	var total int
+	var batch []interface{}
+	var values strings.Builder
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		stmt := fmt.Sprintf(`UPSERT INTO [%d AS t] (%s) SELECT * FROM (VALUES %s) AS v (%s) WHERE %s`,
+			live.GetID(), cols, values.String(), cols, filter)
+		n, err := p.InternalSQLTxn().ExecEx(ctx, "restore-rows", p.Txn(),
+			sessiondata.InternalExecutorOverride{User: p.User()}, stmt, batch...)
+		if err != nil {
+			return err
+		}
+		total += n
+		batch = batch[:0]
+		values.Reset()
+		return nil
+	}
+	for {
+		ok, err := rows.next(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		if len(batch) > 0 {
+			values.WriteString(", ")
+		}
+		values.WriteByte('(')
+		for i, ord := range ords {
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			batch = append(batch, rows.datums[ord])
+			fmt.Fprintf(&values, "$%d::%s", len(batch), casts[i])
+		}
+		values.WriteByte(')')
+		if len(batch) >= restoreRowsBatchSize*len(ords) {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func init() {
+	sql.AddPlanHook("backupccl.restoreRowsPlanHook", restoreRowsPlanHook, restoreRowsTypeCheck)
+}
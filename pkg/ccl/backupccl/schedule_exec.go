@@ -10,6 +10,7 @@ package backupccl
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
@@ -283,6 +284,25 @@ func (e *scheduledBackupExecutor) GetCreateScheduleStatement(
 		},
 	}
 
+	// The compaction threshold is an argument of the incremental schedule.
+	compactionThreshold := args.IncrementalCompactionThreshold
+	if !backupNode.AppendToLatest && dependentSchedule != nil {
+		incArgs := &backuppb.ScheduledBackupExecutionArgs{}
+		if err := pbtypes.UnmarshalAny(dependentSchedule.ExecutionArgs().Args, incArgs); err != nil {
+			return "", errors.Wrap(err, "un-marshaling args")
+		}
+		compactionThreshold = incArgs.IncrementalCompactionThreshold
+	}
+	if compactionThreshold > 0 {
+		scheduleOptions = append(scheduleOptions, tree.KVOption{
+			Key:   optIncrementalCompactionThreshold,
+			Value: tree.NewDString(strconv.FormatInt(compactionThreshold, 10)),
+		})
+	}
+	if args.VerifyBackups {
+		scheduleOptions = append(scheduleOptions, tree.KVOption{Key: optVerifyBackups})
+	}
+
 	var destinations []string
 	for i := range backupNode.To {
 		dest, ok := backupNode.To[i].(*tree.StrVal)
@@ -0,0 +1,234 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+var showBackupDiffHeader = colinfo.ResultColumns{
+	{Name: "change", Typ: types.String},
+	{Name: "primary_key", Typ: types.String},
+	{Name: "old_row", Typ: types.Jsonb},
+	{Name: "new_row", Typ: types.Jsonb},
+}
+
+func showBackupDiffTypeCheck(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (matched bool, header colinfo.ResultColumns, _ error) {
+	diff, ok := stmt.(*tree.ShowBackupDiff)
+	if !ok {
+		return false, nil, nil
+	}
+	if err := exprutil.TypeCheck(
+		ctx, "SHOW BACKUP DIFF", p.SemaCtx(),
+		exprutil.Strings{
+			diff.From.Path,
+			diff.To.Path,
+			diff.Options.EncryptionPassphrase,
+		},
+		exprutil.StringArrays{
+			tree.Exprs(diff.InCollection),
+			tree.Exprs(diff.Options.IncrementalStorage),
+			tree.Exprs(diff.Options.DecryptionKMSURI),
+		},
+	); err != nil {
+		return false, nil, err
+	}
+	return true, showBackupDiffHeader, nil
+}
+
+// showBackupDiffPlanHook implements PlanHookFn for SHOW BACKUP DIFF, which
+// compares the rows of a table between two backups in a collection, or two
+// times of a backup with revision history, by reading the backups' data files.
+func showBackupDiffPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	diff, ok := stmt.(*tree.ShowBackupDiff)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	unsupported := diff.Options
+	unsupported.EncryptionPassphrase = nil
+	unsupported.DecryptionKMSURI = nil
+	unsupported.IncrementalStorage = nil
+	if !unsupported.IsDefault() {
+		return nil, nil, nil, false, errors.New(
+			"SHOW BACKUP DIFF only supports the encryption_passphrase, kms and incremental_location options")
+	}
+
+	exprEval := p.ExprEvaluator("SHOW BACKUP DIFF")
+	collection, err := exprEval.StringArray(ctx, tree.Exprs(diff.InCollection))
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	enc, incStorage, err := evalBackupReadOptions(
+		ctx, exprEval, diff.Options.EncryptionPassphrase, diff.Options.DecryptionKMSURI,
+		diff.Options.IncrementalStorage)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	var sources [2]backupTableSource
+	for i, side := range []*tree.BackupDiffSource{&diff.From, &diff.To} {
+		subdir, err := exprEval.String(ctx, side.Path)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		var asOf hlc.Timestamp
+		if side.AsOf.Expr != nil {
+			ts, err := p.EvalAsOfTimestamp(ctx, side.AsOf)
+			if err != nil {
+				return nil, nil, nil, false, err
+			}
+			asOf = ts.Timestamp
+		}
+		sources[i] = backupTableSource{
+			collection:         collection,
+			subdir:             subdir,
+			asOf:               asOf,
+			incrementalStorage: incStorage,
+			encryption:         enc,
+		}
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer span.Finish()
+
+		from, err := openBackupTable(ctx, p, sources[0], diff.Table)
+		if err != nil {
+			return err
+		}
+		defer from.close()
+		to, err := openBackupTable(ctx, p, sources[1], diff.Table)
+		if err != nil {
+			return err
+		}
+		defer to.close()
+
+		if from.desc.GetID() != to.desc.GetID() {
+			return errors.Newf("table %s is not the same table in the two backups", tree.ErrString(&diff.Table))
+		}
+		if from.desc.GetPrimaryIndexID() != to.desc.GetPrimaryIndexID() {
+			return errors.Newf("the primary key of table %s changed between the two backups",
+				tree.ErrString(&diff.Table))
+		}
+		return diffBackupTables(ctx, from, to, resultsCh)
+	}
+	return fn, showBackupDiffHeader, nil, false, nil
+}
+
+// diffBackupTables sends a row to resultsCh for every row of the table that was
+// added, removed or modified between the from and to backups. Rows are matched
+// by primary key and compared by the values of their columns, by name, so a
+// column added or dropped between the backups makes every row modified.
+func diffBackupTables(
+	ctx context.Context, from, to *backupTable, resultsCh chan<- tree.Datums,
+) error {
+	fromRows, err := from.newRowIter(ctx)
+	if err != nil {
+		return err
+	}
+	defer fromRows.close(ctx)
+	toRows, err := to.newRowIter(ctx)
+	if err != nil {
+		return err
+	}
+	defer toRows.close(ctx)
+
+	fromOK, err := fromRows.next(ctx)
+	if err != nil {
+		return err
+	}
+	toOK, err := toRows.next(ctx)
+	if err != nil {
+		return err
+	}
+	for fromOK || toOK {
+		var c int
+		switch {
+		case !toOK:
+			c = -1
+		case !fromOK:
+			c = 1
+		default:
+			c = fromRows.key.Compare(toRows.key)
+		}
+
+		var primaryKey string
+		var oldRow, newRow json.JSON
+		if c <= 0 {
+			primaryKey = fromRows.primaryKey()
+			if oldRow, err = fromRows.rowJSON(); err != nil {
+				return err
+			}
+		}
+		if c >= 0 {
+			primaryKey = toRows.primaryKey()
+			if newRow, err = toRows.rowJSON(); err != nil {
+				return err
+			}
+		}
+
+		var change string
+		switch {
+		case c < 0:
+			change = "removed"
+		case c > 0:
+			change = "added"
+		default:
+			if cmp, err := oldRow.Compare(newRow); err != nil {
+				return err
+			} else if cmp != 0 {
+				change = "modified"
+			}
+		}
+		if change != "" {
+			res := tree.Datums{tree.NewDString(change), tree.NewDString(primaryKey), tree.DNull, tree.DNull}
+			if oldRow != nil {
+				res[2] = tree.NewDJSON(oldRow)
+			}
+			if newRow != nil {
+				res[3] = tree.NewDJSON(newRow)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case resultsCh <- res:
+			}
+		}
+
+		if c <= 0 {
+			if fromOK, err = fromRows.next(ctx); err != nil {
+				return err
+			}
+		}
+		if c >= 0 {
+			if toOK, err = toRows.next(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	sql.AddPlanHook("backupccl.showBackupDiffPlanHook", showBackupDiffPlanHook, showBackupDiffTypeCheck)
+}
@@ -0,0 +1,748 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+// Verification of backups of virtual clusters.
+//
+// SHOW BACKUP ... WITH check_files only checks that the files of a backup
+// exist. To check that a backup can actually be restored, a backup of virtual
+// clusters records, in its manifest, a fingerprint of each virtual cluster as
+// of the end time of the backup. A VERIFY BACKUP job then restores each of
+// them into an ephemeral virtual cluster, fingerprints the restored keyspace,
+// records the two fingerprints in system.job_info, which
+// crdb_internal.verify_backup_results shows, and drops the ephemeral virtual
+// cluster. The job fails if any of the fingerprints differ.
+//
+// The fingerprints are stripped of timestamps, which restore rewrites, and of
+// tenant prefixes, so that a virtual cluster restored under a new ID has the
+// same fingerprint as the one that was backed up.
+//
+// Backups record fingerprints if the fingerprint_virtual_clusters cluster
+// setting is enabled, or if they are run by a schedule created with the
+// verify_backups option, which also starts a VERIFY BACKUP job after each of
+// its backups succeeds.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud/cloudprivilege"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/multitenant/mtinfopb"
+	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+var fingerprintVirtualClustersEnabled = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"bulkio.backup.fingerprint_virtual_clusters.enabled",
+	"record in each backup of virtual clusters a fingerprint of each of them, "+
+		"which VERIFY BACKUP compares to the virtual clusters restored from the backup",
+	false,
+)
+
+// verifyBackupRestoreInfoKeyPrefix is the prefix of the job_info keys under
+// which a VERIFY BACKUP job records the ID of the job restoring each virtual
+// cluster of the backup.
+const verifyBackupRestoreInfoKeyPrefix = "verify-backup-restore/"
+
+func verifyBackupResultInfoKey(tenantID uint64) string {
+	return fmt.Sprintf("%s%d", jobspb.VerifyBackupResultInfoKeyPrefix, tenantID)
+}
+
+func verifyBackupRestoreInfoKey(tenantID uint64) string {
+	return fmt.Sprintf("%s%d", verifyBackupRestoreInfoKeyPrefix, tenantID)
+}
+
+// verifyBackupTenantName returns the name of the ephemeral virtual cluster into
+// which a VERIFY BACKUP job restores a virtual cluster of the backup.
+func verifyBackupTenantName(jobID jobspb.JobID, tenantID uint64) string {
+	return fmt.Sprintf("verify-backup-%d-%d", jobID, tenantID)
+}
+
+// fingerprintBackupTenants records in the manifest a fingerprint of each
+// virtual cluster in the backup as of the end time of the backup.
+func fingerprintBackupTenants(
+	ctx context.Context, execCfg *sql.ExecutorConfig, m *backuppb.BackupManifest,
+) error {
+	m.TenantFingerprints = make(map[uint64]int64, len(m.Tenants))
+	for _, tenant := range m.Tenants {
+		fingerprint, err := fingerprintTenant(ctx, execCfg.InternalDB, tenant.ID, m.EndTime)
+		if err != nil {
+			return errors.Wrapf(err, "fingerprinting virtual cluster %d", tenant.ID)
+		}
+		m.TenantFingerprints[tenant.ID] = fingerprint
+	}
+	return nil
+}
+
+// fingerprintTenant returns the stripped fingerprint of the latest values of
+// the keyspace of a virtual cluster, as of the given time if it is set.
+func fingerprintTenant(
+	ctx context.Context, db isql.DB, tenantID uint64, asOf hlc.Timestamp,
+) (int64, error) {
+	query := `SELECT crdb_internal.fingerprint(crdb_internal.tenant_span($1::INT), true)`
+	if !asOf.IsEmpty() {
+		query += ` AS OF SYSTEM TIME ` + asOf.AsOfSystemTime()
+	}
+	row, err := db.Executor().QueryRowEx(ctx, "fingerprint-virtual-cluster", nil, /* txn */
+		sessiondata.NodeUserSessionDataOverride, query, int64(tenantID))
+	if err != nil {
+		return 0, err
+	}
+	return int64(tree.MustBeDInt(row[0])), nil
+}
+
+// verifyingScheduleArgs returns the execution arguments of the schedule that
+// started a backup job if it was created with the verify_backups option, and
+// nil otherwise.
+func verifyingScheduleArgs(
+	ctx context.Context, execCfg *sql.ExecutorConfig, scheduleID int64,
+) *backuppb.ScheduledBackupExecutionArgs {
+	env := scheduledjobs.ProdJobSchedulerEnv
+	if knobs := execCfg.JobsKnobs(); knobs != nil && knobs.JobSchedulerEnv != nil {
+		env = knobs.JobSchedulerEnv
+	}
+	var args *backuppb.ScheduledBackupExecutionArgs
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		var err error
+		_, args, err = getScheduledBackupExecutionArgsFromSchedule(
+			ctx, env, jobs.ScheduledJobTxn(txn), scheduleID)
+		return err
+	}); err != nil {
+		log.Warningf(ctx, "failed to load backup schedule %d: %+v", scheduleID, err)
+		return nil
+	}
+	if !args.VerifyBackups {
+		return nil
+	}
+	return args
+}
+
+// scheduledBackupEncryption returns the raw encryption options with which the
+// backup statement of a schedule encrypts its backups, or nil if it does not.
+func scheduledBackupEncryption(
+	args *backuppb.ScheduledBackupExecutionArgs,
+) (*jobspb.BackupEncryptionOptions, error) {
+	stmt, err := parser.ParseOne(args.BackupStatement)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing backup statement")
+	}
+	backupStmt, ok := stmt.AST.(*tree.Backup)
+	if !ok {
+		return nil, errors.AssertionFailedf("unexpected node type %T", stmt.AST)
+	}
+	// The schedule stores the options it evaluated as string literals.
+	rawString := func(e tree.Expr) (string, error) {
+		s, ok := e.(*tree.StrVal)
+		if !ok {
+			return "", errors.AssertionFailedf("unexpected encryption option %T", e)
+		}
+		return s.RawString(), nil
+	}
+	opts := backupStmt.Options
+	if opts.EncryptionPassphrase != nil {
+		pw, err := rawString(opts.EncryptionPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		return &jobspb.BackupEncryptionOptions{
+			Mode: jobspb.EncryptionMode_Passphrase, RawPassphrase: pw,
+		}, nil
+	}
+	if opts.EncryptionKMSURI != nil {
+		uris := make([]string, len(opts.EncryptionKMSURI))
+		for i, e := range opts.EncryptionKMSURI {
+			if uris[i], err = rawString(e); err != nil {
+				return nil, err
+			}
+		}
+		return &jobspb.BackupEncryptionOptions{
+			Mode: jobspb.EncryptionMode_KMS, RawKmsUris: uris,
+		}, nil
+	}
+	return nil, nil
+}
+
+// startScheduledVerification starts a job that verifies the backup taken by a
+// schedule that verifies its backups. Errors are only logged, as the backup
+// itself succeeded.
+func (b *backupResumer) startScheduledVerification(
+	ctx context.Context,
+	p sql.JobExecContext,
+	details jobspb.BackupDetails,
+	backupManifest *backuppb.BackupManifest,
+	scheduleArgs *backuppb.ScheduledBackupExecutionArgs,
+) {
+	if len(backupManifest.TenantFingerprints) == 0 {
+		log.Infof(ctx, "backup job %d backed up no virtual clusters to verify", b.job.ID())
+		return
+	}
+	// The details of the backup job only hold the key derived from the
+	// passphrase, or the KMS URI that encrypted the data key, but the restores
+	// that verify the backup need the options of the backup statement.
+	encryption, err := scheduledBackupEncryption(scheduleArgs)
+	if err != nil {
+		log.Warningf(ctx, "not verifying backup job %d: %+v", b.job.ID(), err)
+		return
+	}
+
+	verify := jobspb.VerifyBackupDetails{
+		CollectionURI:      details.CollectionURI,
+		Subdir:             details.Destination.Subdir,
+		EndTime:            details.EndTime,
+		IncrementalStorage: details.Destination.IncrementalStorage,
+		EncryptionOptions:  encryption,
+	}
+	record := jobs.Record{
+		Description: verifyBackupJobDescription(verify),
+		Username:    p.User(),
+		Details:     verify,
+		Progress:    jobspb.VerifyBackupProgress{},
+	}
+	execCfg := p.ExecCfg()
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		jobID := execCfg.JobRegistry.MakeJobID()
+		if _, err := execCfg.JobRegistry.CreateAdoptableJobWithTxn(ctx, record, jobID, txn); err != nil {
+			return err
+		}
+		log.Infof(ctx, "backup job %d started verification job %d", b.job.ID(), jobID)
+		return nil
+	}); err != nil {
+		log.Warningf(ctx, "failed to start verification of the backup: %+v", err)
+	}
+}
+
+func verifyBackupJobDescription(details jobspb.VerifyBackupDetails) string {
+	collection := backuputils.RedactURIForErrorMessage(details.CollectionURI)
+	if details.EndTime.IsEmpty() {
+		return fmt.Sprintf("verifying backup %s in %s", details.Subdir, collection)
+	}
+	return fmt.Sprintf("verifying backup %s in %s as of %s",
+		details.Subdir, collection, details.EndTime.GoTime())
+}
+
+var verifyBackupHeader = colinfo.ResultColumns{
+	{Name: "job_id", Typ: types.Int},
+}
+
+func verifyBackupTypeCheck(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (matched bool, header colinfo.ResultColumns, _ error) {
+	verifyStmt, ok := stmt.(*tree.VerifyBackup)
+	if !ok {
+		return false, nil, nil
+	}
+	if err := exprutil.TypeCheck(
+		ctx, "VERIFY BACKUP", p.SemaCtx(),
+		exprutil.Strings{
+			verifyStmt.Subdir,
+			verifyStmt.Options.EncryptionPassphrase,
+		},
+		exprutil.StringArrays{
+			tree.Exprs(verifyStmt.InCollection),
+			tree.Exprs(verifyStmt.Options.IncrementalStorage),
+			tree.Exprs(verifyStmt.Options.DecryptionKMSURI),
+		},
+	); err != nil {
+		return false, nil, err
+	}
+	return true, verifyBackupHeader, nil
+}
+
+// verifyBackupPlanHook implements PlanHookFn for VERIFY BACKUP, which starts a
+// job that verifies that the virtual clusters in a backup can be restored.
+func verifyBackupPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	verifyStmt, ok := stmt.(*tree.VerifyBackup)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	unsupported := verifyStmt.Options
+	unsupported.EncryptionPassphrase = nil
+	unsupported.DecryptionKMSURI = nil
+	unsupported.IncrementalStorage = nil
+	if !unsupported.IsDefault() {
+		return nil, nil, nil, false, errors.New(
+			"VERIFY BACKUP only supports the encryption_passphrase, kms and incremental_location options")
+	}
+
+	exprEval := p.ExprEvaluator("VERIFY BACKUP")
+	collection, err := exprEval.StringArray(ctx, tree.Exprs(verifyStmt.InCollection))
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	enc, incStorage, err := evalBackupReadOptions(
+		ctx, exprEval, verifyStmt.Options.EncryptionPassphrase, verifyStmt.Options.DecryptionKMSURI,
+		verifyStmt.Options.IncrementalStorage)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	subdir, err := exprEval.String(ctx, verifyStmt.Subdir)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	var endTime hlc.Timestamp
+	if verifyStmt.AsOf.Expr != nil {
+		asOf, err := p.EvalAsOfTimestamp(ctx, verifyStmt.AsOf)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		endTime = asOf.Timestamp
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer span.Finish()
+
+		execCfg := p.ExecCfg()
+		if !execCfg.Codec.ForSystemTenant() {
+			return pgerror.New(pgcode.InsufficientPrivilege,
+				"VERIFY BACKUP can only be run by the system tenant")
+		}
+		// The job restores and fingerprints virtual clusters.
+		if err := p.RequireAdminRole(ctx, "VERIFY BACKUP"); err != nil {
+			return err
+		}
+		if err := utilccl.CheckEnterpriseEnabled(
+			execCfg.Settings, execCfg.NodeInfo.LogicalClusterID(), "VERIFY BACKUP",
+		); err != nil {
+			return err
+		}
+		if len(collection) > 1 {
+			return errors.New("verifying locality-aware backups is not supported")
+		}
+		if err := cloudprivilege.CheckDestinationPrivileges(ctx, p, collection); err != nil {
+			return err
+		}
+
+		details := jobspb.VerifyBackupDetails{
+			CollectionURI:      collection[0],
+			Subdir:             subdir,
+			EndTime:            endTime,
+			IncrementalStorage: incStorage,
+		}
+		if enc.Mode != jobspb.EncryptionMode_None {
+			details.EncryptionOptions = &enc
+		}
+		if strings.EqualFold(details.Subdir, backupbase.LatestFileName) {
+			details.Subdir, err = backupdest.ReadLatestFile(ctx, details.CollectionURI,
+				execCfg.DistSQLSrv.ExternalStorageFromURI, p.User())
+			if err != nil {
+				return errors.Wrap(err, "read LATEST path")
+			}
+		}
+		// Check that the backup can be read with the given options, and that it
+		// recorded fingerprints as of the given time, before starting the job.
+		if _, err := readBackupTenantFingerprints(ctx, execCfg, p.User(), details); err != nil {
+			return err
+		}
+
+		record := jobs.Record{
+			Description: verifyBackupJobDescription(details),
+			Username:    p.User(),
+			Details:     details,
+			Progress:    jobspb.VerifyBackupProgress{},
+		}
+		jobID := execCfg.JobRegistry.MakeJobID()
+		if _, err := execCfg.JobRegistry.CreateAdoptableJobWithTxn(
+			ctx, record, jobID, p.InternalSQLTxn(),
+		); err != nil {
+			return err
+		}
+		resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(jobID))}
+		return nil
+	}
+	return fn, verifyBackupHeader, nil, false, nil
+}
+
+type verifyBackupResumer struct {
+	job *jobs.Job
+}
+
+var _ jobs.Resumer = &verifyBackupResumer{}
+
+// Resume is part of the jobs.Resumer interface.
+func (r *verifyBackupResumer) Resume(ctx context.Context, execCtx interface{}) error {
+	p := execCtx.(sql.JobExecContext)
+	details := r.job.Details().(jobspb.VerifyBackupDetails)
+
+	fingerprints, err := readBackupTenantFingerprints(ctx, p.ExecCfg(), p.User(), details)
+	if err != nil {
+		return err
+	}
+	if len(fingerprints) == 0 {
+		return jobs.MarkAsPermanentJobError(errors.WithHint(
+			errors.New("the backup holds no fingerprints of virtual clusters to verify"),
+			"Backups of virtual clusters record fingerprints when the "+
+				"bulkio.backup.fingerprint_virtual_clusters.enabled cluster setting is enabled, "+
+				"or when they are run by a schedule created with the verify_backups option."))
+	}
+	tenantIDs := make([]uint64, 0, len(fingerprints))
+	for id := range fingerprints {
+		tenantIDs = append(tenantIDs, id)
+	}
+	sort.Slice(tenantIDs, func(i, j int) bool { return tenantIDs[i] < tenantIDs[j] })
+
+	var mismatched []string
+	for _, id := range tenantIDs {
+		res, err := r.verifyTenant(ctx, p, details, id, fingerprints[id])
+		if err != nil {
+			return errors.Wrapf(err, "verifying virtual cluster %d", id)
+		}
+		if res.RestoredFingerprint != res.BackupFingerprint {
+			mismatched = append(mismatched, strconv.FormatUint(id, 10))
+		}
+	}
+	if len(mismatched) > 0 {
+		return jobs.MarkAsPermanentJobError(errors.Newf(
+			"the fingerprints of restored virtual clusters %s do not match those of the backup",
+			strings.Join(mismatched, ", ")))
+	}
+	return nil
+}
+
+// readBackupTenantFingerprints returns the fingerprints of the virtual clusters
+// recorded by the layer of the backup chain that the job verifies. As they are
+// recorded as of the end time of the layer, the job can only verify the backup
+// as of the end time of one of its layers.
+func readBackupTenantFingerprints(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	details jobspb.VerifyBackupDetails,
+) (map[uint64]int64, error) {
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+	collection := []string{details.CollectionURI}
+	fullyResolvedDest, err := backuputils.AppendPaths(collection, details.Subdir)
+	if err != nil {
+		return nil, err
+	}
+	collections, computedSubdir, err := backupdest.CollectionsAndSubdir(collection, details.Subdir)
+	if err != nil {
+		return nil, err
+	}
+	incDirs, err := backupdest.ResolveIncrementalsBackupLocation(
+		ctx, user, execCfg, details.IncrementalStorage, collections, computedSubdir)
+	if err != nil {
+		return nil, err
+	}
+
+	baseStores, cleanupBase, err := backupdest.MakeBackupDestinationStores(ctx, user, mkStore, fullyResolvedDest)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := cleanupBase(); err != nil {
+			log.Warningf(ctx, "failed to close base store: %+v", err)
+		}
+	}()
+	incStores, cleanupInc, err := backupdest.MakeBackupDestinationStores(ctx, user, mkStore, incDirs)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := cleanupInc(); err != nil {
+			log.Warningf(ctx, "failed to close incremental store: %+v", err)
+		}
+	}()
+
+	kmsEnv := backupencryption.MakeBackupKMSEnv(
+		execCfg.Settings, &execCfg.ExternalIODirConfig, execCfg.InternalDB, user,
+	)
+	var encryption *jobspb.BackupEncryptionOptions
+	if details.EncryptionOptions != nil {
+		encryption, err = backupencryption.GetEncryptionFromBase(
+			ctx, user, mkStore, fullyResolvedDest[0], *details.EncryptionOptions, &kmsEnv)
+		if err != nil {
+			return nil, err
+		}
+	}
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+	_, manifests, _, memSize, err := backupdest.ResolveBackupManifests(
+		ctx, &mem, baseStores, incStores, mkStore, fullyResolvedDest, incDirs,
+		details.EndTime, encryption, &kmsEnv, user)
+	if err != nil {
+		return nil, err
+	}
+	defer mem.Shrink(ctx, memSize)
+
+	layer := manifests[len(manifests)-1]
+	if !details.EndTime.IsEmpty() && !details.EndTime.Equal(layer.EndTime) {
+		return nil, errors.WithHintf(
+			pgerror.Newf(pgcode.InvalidParameterValue,
+				"the backup did not record fingerprints as of %s", details.EndTime.GoTime()),
+			"Fingerprints are recorded as of the end time of each layer of a backup chain; "+
+				"the layer that includes the given time ends at %s.", layer.EndTime.GoTime())
+	}
+	return layer.TenantFingerprints, nil
+}
+
+// verifyTenant restores a virtual cluster of the backup into an ephemeral
+// virtual cluster, records the result of the comparison of its fingerprint to
+// that of the backup, and drops it. Each step is recorded in system.job_info,
+// so that a resumed job picks up where it left off.
+func (r *verifyBackupResumer) verifyTenant(
+	ctx context.Context,
+	p sql.JobExecContext,
+	details jobspb.VerifyBackupDetails,
+	tenantID uint64,
+	backupFingerprint int64,
+) (jobspb.VerifyBackupResult, error) {
+	execCfg := p.ExecCfg()
+	name := verifyBackupTenantName(r.job.ID(), tenantID)
+
+	var res jobspb.VerifyBackupResult
+	var verified bool
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		v, found, err := jobs.InfoStorageForJob(txn, r.job.ID()).Get(ctx, verifyBackupResultInfoKey(tenantID))
+		if err != nil || !found {
+			return err
+		}
+		verified = true
+		return protoutil.Unmarshal(v, &res)
+	}); err != nil {
+		return res, err
+	}
+
+	if !verified {
+		restoreJobID, err := r.restoreTenant(ctx, p, details, tenantID, name)
+		if err != nil {
+			return res, err
+		}
+		if err := execCfg.JobRegistry.WaitForJobs(ctx, []jobspb.JobID{restoreJobID}); err != nil {
+			return res, errors.Wrapf(err, "restoring into virtual cluster %s", name)
+		}
+
+		row, err := execCfg.InternalDB.Executor().QueryRowEx(ctx, "verify-backup-tenant-id", nil, /* txn */
+			sessiondata.NodeUserSessionDataOverride, `SELECT id FROM system.tenants WHERE name = $1`, name)
+		if err != nil {
+			return res, err
+		}
+		if row == nil {
+			return res, errors.Newf("restored virtual cluster %s not found", name)
+		}
+		restoredFingerprint, err := fingerprintTenant(
+			ctx, execCfg.InternalDB, uint64(tree.MustBeDInt(row[0])), hlc.Timestamp{})
+		if err != nil {
+			return res, errors.Wrapf(err, "fingerprinting virtual cluster %s", name)
+		}
+
+		res = jobspb.VerifyBackupResult{
+			TenantID:            tenantID,
+			BackupFingerprint:   backupFingerprint,
+			RestoredFingerprint: restoredFingerprint,
+			RestoreJobID:        restoreJobID,
+		}
+		v, err := protoutil.Marshal(&res)
+		if err != nil {
+			return res, err
+		}
+		if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+			return jobs.InfoStorageForJob(txn, r.job.ID()).Write(ctx, verifyBackupResultInfoKey(tenantID), v)
+		}); err != nil {
+			return res, err
+		}
+		if res.RestoredFingerprint != res.BackupFingerprint {
+			log.Warningf(ctx, "fingerprint %d of virtual cluster %s does not match fingerprint %d of virtual cluster %d in the backup",
+				res.RestoredFingerprint, name, res.BackupFingerprint, tenantID)
+		}
+	}
+
+	return res, dropVerifyBackupTenant(ctx, execCfg.InternalDB, p.User(), name)
+}
+
+// restoreTenant starts, unless a previous attempt already did, a job that
+// restores a virtual cluster of the backup into an ephemeral virtual cluster,
+// and returns its ID.
+func (r *verifyBackupResumer) restoreTenant(
+	ctx context.Context,
+	p sql.JobExecContext,
+	details jobspb.VerifyBackupDetails,
+	tenantID uint64,
+	name string,
+) (jobspb.JobID, error) {
+	execCfg := p.ExecCfg()
+	var restoreJobID jobspb.JobID
+	err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		info := jobs.InfoStorageForJob(txn, r.job.ID())
+		v, found, err := info.Get(ctx, verifyBackupRestoreInfoKey(tenantID))
+		if err != nil {
+			return err
+		}
+		if found {
+			id, err := strconv.ParseInt(string(v), 10, 64)
+			restoreJobID = jobspb.JobID(id)
+			return err
+		}
+
+		query, args := verifyBackupRestoreQuery(details, tenantID, name)
+		row, err := txn.QueryRowEx(ctx, "verify-backup-restore", txn.KV(),
+			sessiondata.InternalExecutorOverride{User: p.User()}, query, args...)
+		if err != nil {
+			return err
+		}
+		restoreJobID = jobspb.JobID(tree.MustBeDInt(row[0]))
+
+		// A restored virtual cluster is activated in the service mode it was
+		// backed up in. The ephemeral virtual cluster must not be started, as its
+		// SQL servers would write to the keyspace being fingerprinted.
+		if err := execCfg.JobRegistry.UpdateJobWithTxn(ctx, restoreJobID, txn, false, /* useReadLock */
+			func(_ isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+				restore := md.Payload.GetRestore()
+				for i := range restore.Tenants {
+					restore.Tenants[i].ServiceMode = mtinfopb.ServiceModeNone
+				}
+				ju.UpdatePayload(md.Payload)
+				return nil
+			}); err != nil {
+			return err
+		}
+		return info.Write(ctx, verifyBackupRestoreInfoKey(tenantID),
+			[]byte(strconv.FormatInt(int64(restoreJobID), 10)))
+	})
+	return restoreJobID, err
+}
+
+// verifyBackupRestoreQuery returns the RESTORE statement, and its arguments,
+// that starts a job restoring a virtual cluster of the backup under the given
+// name.
+func verifyBackupRestoreQuery(
+	details jobspb.VerifyBackupDetails, tenantID uint64, name string,
+) (string, []interface{}) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "RESTORE VIRTUAL CLUSTER %d FROM $1 IN $2", tenantID)
+	args := []interface{}{details.Subdir, details.CollectionURI, name}
+	if !details.EndTime.IsEmpty() {
+		fmt.Fprintf(&buf, " AS OF SYSTEM TIME %s", details.EndTime.AsOfSystemTime())
+	}
+	buf.WriteString(" WITH detached, virtual_cluster_name = $3")
+	writeList := func(option string, values []string) {
+		fmt.Fprintf(&buf, ", %s = (", option)
+		for i, v := range values {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			args = append(args, v)
+			fmt.Fprintf(&buf, "$%d", len(args))
+		}
+		buf.WriteString(")")
+	}
+	if len(details.IncrementalStorage) > 0 {
+		writeList("incremental_location", details.IncrementalStorage)
+	}
+	if enc := details.EncryptionOptions; enc != nil {
+		switch enc.Mode {
+		case jobspb.EncryptionMode_Passphrase:
+			args = append(args, enc.RawPassphrase)
+			fmt.Fprintf(&buf, ", encryption_passphrase = $%d", len(args))
+		case jobspb.EncryptionMode_KMS:
+			writeList("kms", enc.RawKmsUris)
+		}
+	}
+	return buf.String(), args
+}
+
+func dropVerifyBackupTenant(
+	ctx context.Context, db isql.DB, user username.SQLUsername, name string,
+) error {
+	_, err := db.Executor().ExecEx(ctx, "verify-backup-drop", nil, /* txn */
+		sessiondata.InternalExecutorOverride{User: user},
+		`DROP VIRTUAL CLUSTER IF EXISTS $1 IMMEDIATE`, name)
+	return errors.Wrapf(err, "dropping virtual cluster %s", name)
+}
+
+// OnFailOrCancel is part of the jobs.Resumer interface. It cancels the
+// restores that are still running, which clean up after themselves, and drops
+// the virtual clusters that were restored.
+func (r *verifyBackupResumer) OnFailOrCancel(
+	ctx context.Context, execCtx interface{}, _ error,
+) error {
+	p := execCtx.(sql.JobExecContext)
+	execCfg := p.ExecCfg()
+
+	restores := make(map[uint64]jobspb.JobID)
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		return jobs.InfoStorageForJob(txn, r.job.ID()).Iterate(ctx, verifyBackupRestoreInfoKeyPrefix,
+			func(infoKey string, value []byte) error {
+				tenantID, err := strconv.ParseUint(strings.TrimPrefix(infoKey, verifyBackupRestoreInfoKeyPrefix), 10, 64)
+				if err != nil {
+					return err
+				}
+				id, err := strconv.ParseInt(string(value), 10, 64)
+				if err != nil {
+					return err
+				}
+				restores[tenantID] = jobspb.JobID(id)
+				return nil
+			})
+	}); err != nil {
+		return err
+	}
+
+	for tenantID, restoreJobID := range restores {
+		if _, err := execCfg.InternalDB.Executor().ExecEx(ctx, "verify-backup-cancel-restore", nil, /* txn */
+			sessiondata.NodeUserSessionDataOverride, `CANCEL JOB $1`, restoreJobID); err != nil {
+			// The restore has most likely already finished.
+			log.Infof(ctx, "could not cancel restore job %d: %v", restoreJobID, err)
+		}
+		if err := execCfg.JobRegistry.WaitForJobsIgnoringJobErrors(ctx, []jobspb.JobID{restoreJobID}); err != nil {
+			return err
+		}
+		if err := dropVerifyBackupTenant(
+			ctx, execCfg.InternalDB, p.User(), verifyBackupTenantName(r.job.ID(), tenantID),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	sql.AddPlanHook("backupccl.verifyBackupPlanHook", verifyBackupPlanHook, verifyBackupTypeCheck)
+	jobs.RegisterConstructor(
+		jobspb.TypeVerifyBackup,
+		func(job *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return &verifyBackupResumer{
+				job: job,
+			}
+		},
+		jobs.UsesTenantCostControl,
+	)
+}
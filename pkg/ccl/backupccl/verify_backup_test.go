@@ -0,0 +1,102 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/testcluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBackup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := testcluster.StartTestCluster(t, 1, base.TestClusterArgs{
+		ServerArgs: base.TestServerArgs{
+			DefaultTestTenant: base.TestControlsTenantsExplicitly,
+			ExternalIODir:     t.TempDir(),
+		},
+	})
+	defer tc.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(tc.Conns[0])
+
+	_, tSQL := serverutils.StartTenant(t, tc.Server(0), base.TestTenantArgs{
+		TenantID: roachpb.MustMakeTenantID(10),
+	})
+	defer tSQL.Close()
+	tenantDB := sqlutils.MakeSQLRunner(tSQL)
+	tenantDB.Exec(t, `CREATE TABLE t (k INT PRIMARY KEY, v STRING)`)
+	tenantDB.Exec(t, `INSERT INTO t SELECT i, 'v' || i::STRING FROM generate_series(1, 100) AS g(i)`)
+
+	const collection = "nodelocal://1/verify"
+	verify := func() jobspb.JobID {
+		var jobID jobspb.JobID
+		sqlDB.QueryRow(t, `VERIFY BACKUP LATEST IN $1`, collection).Scan(&jobID)
+		return jobID
+	}
+
+	sqlDB.ExpectErr(t, "locality-aware backups is not supported",
+		`VERIFY BACKUP LATEST IN ($1, $2)`, collection, "nodelocal://1/other")
+	sqlDB.ExpectErr(t, "verify_backups requires a backup of the cluster or of a virtual cluster",
+		`CREATE SCHEDULE FOR BACKUP TABLE system.users INTO $1 WITH SCHEDULE OPTIONS verify_backups
+RECURRING '@daily' FULL BACKUP ALWAYS`, collection)
+
+	// Backups only record fingerprints if asked to.
+	sqlDB.Exec(t, `BACKUP VIRTUAL CLUSTER 10 INTO $1`, collection)
+	jobID := verify()
+	jobutils.WaitForJobToFail(t, sqlDB, jobID)
+	require.Equal(t, [][]string{{"true"}}, sqlDB.QueryStr(t,
+		`SELECT error LIKE '%no fingerprints%' FROM [SHOW JOBS] WHERE job_id = $1`, jobID))
+
+	sqlDB.Exec(t, `SET CLUSTER SETTING bulkio.backup.fingerprint_virtual_clusters.enabled = true`)
+	sqlDB.Exec(t, `BACKUP VIRTUAL CLUSTER 10 INTO $1`, collection)
+	tenantDB.Exec(t, `INSERT INTO t VALUES (101, 'not in the backup')`)
+	jobID = verify()
+	jobutils.WaitForJobToSucceed(t, sqlDB, jobID)
+	sqlDB.CheckQueryResults(t,
+		`SELECT virtual_cluster_id, matched FROM crdb_internal.verify_backup_results WHERE job_id = $1`,
+		[][]string{{"10", "true"}}, jobID)
+
+	// Encrypted backups are verified with the options that decrypt them.
+	const encrypted = "nodelocal://1/verify-encrypted"
+	sqlDB.Exec(t, `BACKUP VIRTUAL CLUSTER 10 INTO $1 WITH encryption_passphrase = 'secret'`, encrypted)
+	sqlDB.ExpectErr(t, "file appears encrypted", `VERIFY BACKUP LATEST IN $1`, encrypted)
+	sqlDB.QueryRow(t, `VERIFY BACKUP LATEST IN $1 WITH encryption_passphrase = 'secret'`,
+		encrypted).Scan(&jobID)
+	jobutils.WaitForJobToSucceed(t, sqlDB, jobID)
+	sqlDB.CheckQueryResults(t,
+		`SELECT virtual_cluster_id, matched FROM crdb_internal.verify_backup_results WHERE job_id = $1`,
+		[][]string{{"10", "true"}}, jobID)
+
+	// The fingerprints are recorded as of the end time of each layer, so the
+	// backup can't be verified as of a time within a layer.
+	const revisions = "nodelocal://1/verify-revisions"
+	var beforeBackup string
+	sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&beforeBackup)
+	sqlDB.Exec(t, `BACKUP VIRTUAL CLUSTER 10 INTO $1 WITH revision_history`, revisions)
+	sqlDB.ExpectErr(t, "the backup did not record fingerprints as of",
+		fmt.Sprintf(`VERIFY BACKUP LATEST IN $1 AS OF SYSTEM TIME %s`, beforeBackup), revisions)
+
+	// The virtual clusters restored for the verification are dropped.
+	sqlDB.CheckQueryResults(t,
+		`SELECT count(*) FROM system.tenants WHERE name LIKE 'verify-backup-%'`, [][]string{{"0"}})
+}
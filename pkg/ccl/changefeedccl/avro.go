@@ -775,6 +775,75 @@ func newSchemaForRow(
 	return schema, nil
 }
 
+// newSchemaForColumns constructs avro schema for rows that are not read from a
+// table, such as the results of the query of an EXPORT. sqlName can be any
+// string but should uniquely identify a schema.
+func newSchemaForColumns(
+	names []string, typs []*types.T, sqlName string, namespace string,
+) (*avroDataRecord, error) {
+	schema := &avroDataRecord{
+		avroRecord: avroRecord{
+			Name:       sqlName,
+			SchemaType: `record`,
+			Namespace:  namespace,
+		},
+		fieldIdxByName:   make(map[string]int),
+		colIdxByFieldIdx: make(map[int]int),
+	}
+	for i, typ := range typs {
+		field, err := typeToAvroSchema(typ)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %s", names[i])
+		}
+		field.Name = SQLNameToAvroName(names[i])
+		field.Metadata = typ.SQLString()
+		if _, ok := schema.fieldIdxByName[field.Name]; ok {
+			return nil, errors.Errorf("column %s maps to duplicate avro field %s", names[i], field.Name)
+		}
+		schema.colIdxByFieldIdx[len(schema.Fields)] = i
+		schema.fieldIdxByName[field.Name] = len(schema.Fields)
+		schema.Fields = append(schema.Fields, field)
+	}
+
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	schema.codec, err = goavro.NewCodec(string(schemaJSON))
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// nativeFromDatums encodes the datums, which are in the order of the fields of
+// the record, as go "native" values. Unlike nativeFromRow, the result shares
+// no memory with previous results, so that several rows can be buffered before
+// they are encoded.
+func (r *avroDataRecord) nativeFromDatums(datums tree.Datums) (map[string]interface{}, error) {
+	native := make(map[string]interface{}, len(r.Fields))
+	for i, field := range r.Fields {
+		d := datums[r.colIdxByFieldIdx[i]]
+		if d == tree.DNull {
+			native[field.Name] = nil
+			continue
+		}
+		encoded, err := field.encodeDatum(d, nil /* memo */)
+		if err != nil {
+			return nil, err
+		}
+		// Every field is a union of null and its type, and perhaps of a string
+		// for special values; see typeToAvroSchema.
+		union := field.SchemaType.([]avroSchemaType)
+		unionKey := avroUnionKey(union[1])
+		if _, isString := encoded.(string); isString && len(union) > 2 {
+			unionKey = avroUnionKey(union[2])
+		}
+		native[field.Name] = map[string]interface{}{unionKey: encoded}
+	}
+	return native, nil
+}
+
 // primaryIndexToAvroSchema constructs schema for primary index.
 func primaryIndexToAvroSchema(
 	row cdcevent.Row, sqlName string, namespace string,
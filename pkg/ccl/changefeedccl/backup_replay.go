@@ -0,0 +1,502 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+)
+
+// ReplayBackup writes to out the events which the sinkless changefeed of the
+// given statement would emit between its cursor and end_time, reading the
+// changes from a chain of backups taken with revision_history rather than from
+// rangefeeds. The stores hold the backups of the chain in order, starting with
+// the full backup. The end time defaults to the end time of the last backup.
+//
+// Each event is written as a line holding the topic, the key and the value
+// separated by tabs, like the rows returned by a sinkless changefeed. The
+// events of each row are written in timestamp order, which is the ordering
+// guarantee of changefeeds, but the events of different rows are written in
+// key order.
+//
+// The tables are decoded with the descriptors in the backups, so the changes
+// can be replayed after the tables were dropped or garbage collected on the
+// cluster. CDC queries, tables with user defined types and encrypted backups
+// can only be replayed against a running cluster, and schema changes are not
+// backfilled: each change is decoded with the descriptor of the table as of
+// the change.
+func ReplayBackup(
+	ctx context.Context,
+	st *cluster.Settings,
+	stores []cloud.ExternalStorage,
+	stmt *tree.CreateChangefeed,
+	out io.Writer,
+) error {
+	if stmt.Select != nil {
+		return errors.New("CDC queries cannot be replayed from a backup")
+	}
+	if len(stores) == 0 {
+		return errors.AssertionFailedf("no backups to replay")
+	}
+	opts, err := makeReplayOptions(stmt.Options)
+	if err != nil {
+		return err
+	}
+	encodingOpts, err := opts.GetEncodingOptions()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(encodingOpts.SchemaRegistryURI, changefeedbase.SinkSchemeExternalConnection+":") {
+		return errors.New("external connections cannot be used when replaying a backup")
+	}
+
+	memMon := mon.NewUnlimitedMonitor(ctx, "debug-changefeed-backup", mon.MemoryResource,
+		nil /* curCount */, nil /* maxHist */, math.MaxInt64, st)
+	defer memMon.Stop(ctx)
+	mem := memMon.MakeBoundAccount()
+	defer mem.Close(ctx)
+	manifests := make([]backuppb.BackupManifest, len(stores))
+	iterFactories := make(backupinfo.LayerToBackupManifestFileIterFactory, len(stores))
+	for i, store := range stores {
+		manifests[i], _, err = backupinfo.ReadBackupManifestFromStore(
+			ctx, &mem, store, nil /* encryption */, nil /* kmsEnv */)
+		if err != nil {
+			return err
+		}
+		iterFactories[i] = backupinfo.NewIterFactory(&manifests[i], store, nil /* encryption */, nil /* kmsEnv */)
+	}
+	cursor, endTime, err := replayTimeRange(opts, manifests)
+	if err != nil {
+		return err
+	}
+	codec, err := backupinfo.MakeBackupCodec(manifests[0])
+	if err != nil {
+		return err
+	}
+
+	descs, _, err := backupinfo.LoadSQLDescsFromBackupsAtTime(ctx, manifests, iterFactories, endTime)
+	if err != nil {
+		return err
+	}
+	targets, tables, err := resolveReplayTargets(stmt.Targets, descs, opts.IsSet(changefeedbase.OptFullTableName))
+	if err != nil {
+		return err
+	}
+	lookup, err := makeBackupDescriptorLookup(ctx, iterFactories, len(manifests), tables)
+	if err != nil {
+		return err
+	}
+	decoder, err := cdcevent.NewEventDecoderWithLookup(
+		codec, lookup, targets, opts.IncludeVirtual(), opts.KeyOnly())
+	if err != nil {
+		return err
+	}
+	encoder, err := getEncoder(encodingOpts, targets, false /* encodeForQuery */, nil /* p */, nil /* sliMetrics */)
+	if err != nil {
+		return err
+	}
+	if encoder == nil {
+		return errors.Newf("%s=%s cannot be replayed", changefeedbase.OptFormat, encodingOpts.Format)
+	}
+
+	r := backupReplayer{
+		cursor:   cursor,
+		endTime:  endTime,
+		withDiff: opts.GetFilters().WithDiff,
+		keyOnly:  opts.KeyOnly(),
+		targets:  targets,
+		decoder:  decoder,
+		encoder:  encoder,
+		out:      out,
+	}
+	for _, table := range tables {
+		prefix := codec.IndexPrefix(uint32(table.GetID()), uint32(table.GetPrimaryIndexID()))
+		span := roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()}
+		if err := r.replaySpan(ctx, stores, iterFactories, span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// makeReplayOptions returns the options of a changefeed statement, whose
+// values must be literals since they can't be evaluated outside of a cluster.
+func makeReplayOptions(kvOpts tree.KVOptions) (changefeedbase.StatementOptions, error) {
+	m := make(map[string]string, len(kvOpts))
+	for _, o := range kvOpts {
+		var v string
+		if o.Value != nil {
+			s, ok := o.Value.(*tree.StrVal)
+			if !ok {
+				return changefeedbase.StatementOptions{}, errors.Newf(
+					"the value of option %s must be a string literal", o.Key)
+			}
+			v = s.RawString()
+		}
+		m[string(o.Key)] = v
+	}
+	opts := changefeedbase.MakeStatementOptions(m)
+	if err := opts.ValidateForCreateChangefeed(false /* isPredicateChangefeed */); err != nil {
+		return changefeedbase.StatementOptions{}, err
+	}
+	return opts, nil
+}
+
+// replayTimeRange returns the time range of the changes to replay, which must
+// be covered by the revision history of the chain of backups.
+func replayTimeRange(
+	opts changefeedbase.StatementOptions, manifests []backuppb.BackupManifest,
+) (cursor, endTime hlc.Timestamp, _ error) {
+	for i := range manifests {
+		if manifests[i].MVCCFilter != backuppb.MVCCFilter_All {
+			return hlc.Timestamp{}, hlc.Timestamp{}, errors.Newf(
+				"backup %d of the chain was not taken with revision_history", i)
+		}
+		if i > 0 && !manifests[i].StartTime.Equal(manifests[i-1].EndTime) {
+			return hlc.Timestamp{}, hlc.Timestamp{}, errors.Newf(
+				"backup %d of the chain starts at %s rather than at the end time %s of the previous one",
+				i, manifests[i].StartTime, manifests[i-1].EndTime)
+		}
+	}
+	start := manifests[0].StartTime
+	start.Forward(manifests[0].RevisionStartTime)
+	end := manifests[len(manifests)-1].EndTime
+
+	cursor, err := hlc.ParseHLC(opts.GetCursor())
+	if err != nil {
+		return hlc.Timestamp{}, hlc.Timestamp{}, errors.Wrapf(err,
+			"the %s of a replay from a backup must be a decimal timestamp", changefeedbase.OptCursor)
+	}
+	endTime = end
+	if s := opts.GetEndTime(); s != "" {
+		if endTime, err = hlc.ParseHLC(s); err != nil {
+			return hlc.Timestamp{}, hlc.Timestamp{}, errors.Wrapf(err,
+				"the %s of a replay from a backup must be a decimal timestamp", changefeedbase.OptEndTime)
+		}
+	}
+	if cursor.Less(start) || end.Less(endTime) || endTime.Less(cursor) {
+		return hlc.Timestamp{}, hlc.Timestamp{}, errors.Newf(
+			"the time range (%s, %s] is not covered by the revision history of the backups, (%s, %s]",
+			cursor, endTime, start, end)
+	}
+	return cursor, endTime, nil
+}
+
+// resolveReplayTargets resolves the targets of a changefeed against the
+// descriptors of a backup.
+func resolveReplayTargets(
+	rawTargets tree.ChangefeedTargets, descs []catalog.Descriptor, fullTableName bool,
+) (changefeedbase.Targets, []catalog.TableDescriptor, error) {
+	names := make(map[descpb.ID]string)
+	for _, desc := range descs {
+		switch desc.(type) {
+		case catalog.DatabaseDescriptor, catalog.SchemaDescriptor:
+			names[desc.GetID()] = desc.GetName()
+		}
+	}
+
+	var targets changefeedbase.Targets
+	var tables []catalog.TableDescriptor
+	seen := make(map[descpb.ID]struct{})
+	for _, ct := range rawTargets {
+		pattern, err := ct.TableName.NormalizeTablePattern()
+		if err != nil {
+			return changefeedbase.Targets{}, nil, err
+		}
+		tn, ok := pattern.(*tree.TableName)
+		if !ok {
+			return changefeedbase.Targets{}, nil, errors.Errorf(`CHANGEFEED cannot target %s`, tree.AsString(&ct))
+		}
+		var match catalog.TableDescriptor
+		for _, desc := range descs {
+			table, ok := desc.(catalog.TableDescriptor)
+			if !ok || table.GetName() != tn.Table() {
+				continue
+			}
+			dbName, scName := names[table.GetParentID()], names[table.GetParentSchemaID()]
+			switch {
+			case tn.ExplicitCatalog && (dbName != tn.Catalog() || scName != tn.Schema()):
+				continue
+			case tn.ExplicitSchema && !tn.ExplicitCatalog && scName != tn.Schema() &&
+				(dbName != tn.Schema() || scName != catconstants.PublicSchemaName):
+				continue
+			}
+			if match != nil {
+				return changefeedbase.Targets{}, nil, errors.Errorf(
+					"table name %q is ambiguous in the backup", tree.AsString(tn))
+			}
+			match = table
+		}
+		if match == nil {
+			return changefeedbase.Targets{}, nil, errors.Errorf(
+				"table %q does not exist in the backup", tree.AsString(tn))
+		}
+
+		name := match.GetName()
+		if fullTableName {
+			qualified := tree.MakeTableNameWithSchema(tree.Name(names[match.GetParentID()]),
+				tree.Name(names[match.GetParentSchemaID()]), tree.Name(match.GetName()))
+			name = qualified.String()
+		}
+		typ := jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY
+		if ct.FamilyName != "" {
+			typ = jobspb.ChangefeedTargetSpecification_COLUMN_FAMILY
+		} else if match.NumFamilies() > 1 {
+			typ = jobspb.ChangefeedTargetSpecification_EACH_FAMILY
+		}
+		targets.Add(changefeedbase.Target{
+			Type:              typ,
+			TableID:           match.GetID(),
+			FamilyName:        string(ct.FamilyName),
+			StatementTimeName: changefeedbase.StatementTimeName(name),
+		})
+		if _, ok := seen[match.GetID()]; !ok {
+			seen[match.GetID()] = struct{}{}
+			tables = append(tables, match)
+		}
+	}
+	return targets, tables, nil
+}
+
+// backupDescriptorRevision is a revision of a table descriptor in a backup.
+type backupDescriptorRevision struct {
+	time hlc.Timestamp
+	desc *descpb.Descriptor
+}
+
+// makeBackupDescriptorLookup returns a lookup function for the descriptors of
+// the given tables as of the timestamps of their changes in the backups.
+func makeBackupDescriptorLookup(
+	ctx context.Context,
+	iterFactories backupinfo.LayerToBackupManifestFileIterFactory,
+	numLayers int,
+	tables []catalog.TableDescriptor,
+) (cdcevent.TableDescriptorLookup, error) {
+	revisions := make(map[descpb.ID][]backupDescriptorRevision, len(tables))
+	for _, table := range tables {
+		revisions[table.GetID()] = nil
+	}
+	for layer := 0; layer < numLayers; layer++ {
+		if err := func() error {
+			it := iterFactories[layer].NewDescriptorChangesIter(ctx)
+			defer it.Close()
+			for ; ; it.Next() {
+				if ok, err := it.Valid(); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+				rev := it.Value()
+				if revs, ok := revisions[rev.ID]; ok {
+					revisions[rev.ID] = append(revs, backupDescriptorRevision{time: rev.Time, desc: rev.Desc})
+				}
+			}
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	for _, revs := range revisions {
+		sort.Slice(revs, func(i, j int) bool { return revs[i].time.Less(revs[j].time) })
+	}
+
+	return func(
+		ctx context.Context, tableID descpb.ID, ts hlc.Timestamp,
+	) (catalog.TableDescriptor, error) {
+		revs := revisions[tableID]
+		i := sort.Search(len(revs), func(i int) bool { return ts.Less(revs[i].time) })
+		if i == 0 {
+			return nil, changefeedbase.WithTerminalError(errors.Newf(
+				"the backups hold no descriptor of table %d as of %s", tableID, ts))
+		}
+		rev := revs[i-1]
+		tbl, _, _, _, _ := descpb.GetDescriptors(rev.desc)
+		if tbl == nil {
+			return nil, changefeedbase.WithTerminalError(errors.Newf(
+				"table %d was dropped as of %s", tableID, ts))
+		}
+		b := tabledesc.NewBuilderWithMVCCTimestamp(tbl, rev.time)
+		if err := b.RunPostDeserializationChanges(); err != nil {
+			return nil, err
+		}
+		desc := b.BuildImmutableTable()
+		if catalog.MaybeRequiresHydration(desc) {
+			return nil, changefeedbase.WithTerminalError(errors.Newf(
+				"table %s uses user defined types, which cannot be replayed from a backup", desc.GetName()))
+		}
+		return desc, nil
+	}, nil
+}
+
+// backupReplayer decodes and encodes the changes read from backups.
+type backupReplayer struct {
+	cursor, endTime hlc.Timestamp
+	withDiff        bool
+	keyOnly         bool
+	targets         changefeedbase.Targets
+	decoder         cdcevent.Decoder
+	encoder         Encoder
+	out             io.Writer
+}
+
+// replaySpan replays the changes to the keys of a span.
+func (r *backupReplayer) replaySpan(
+	ctx context.Context,
+	stores []cloud.ExternalStorage,
+	iterFactories backupinfo.LayerToBackupManifestFileIterFactory,
+	span roachpb.Span,
+) error {
+	var storeFiles []storageccl.StoreFile
+	for layer := range stores {
+		if err := func() error {
+			it, err := iterFactories[layer].NewFileIter(ctx)
+			if err != nil {
+				return err
+			}
+			defer it.Close()
+			paths := make(map[string]struct{})
+			for ; ; it.Next() {
+				if ok, err := it.Valid(); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+				f := it.Value()
+				if _, ok := paths[f.Path]; ok || !f.Span.Overlaps(span) {
+					continue
+				}
+				paths[f.Path] = struct{}{}
+				storeFiles = append(storeFiles, storageccl.StoreFile{Store: stores[layer], FilePath: f.Path})
+			}
+		}(); err != nil {
+			return err
+		}
+	}
+	if len(storeFiles) == 0 {
+		return nil
+	}
+
+	iter, err := storageccl.ExternalSSTReader(ctx, storeFiles, nil /* encryption */, storage.IterOptions{
+		KeyTypes:   storage.IterKeyTypePointsOnly,
+		LowerBound: span.Key,
+		UpperBound: span.EndKey,
+	})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	// The revisions of the current key, newest first.
+	var revisions []roachpb.KeyValue
+	for iter.SeekGE(storage.MVCCKey{Key: span.Key}); ; iter.Next() {
+		ok, err := iter.Valid()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		key := iter.UnsafeKey()
+		if key.Timestamp.IsEmpty() {
+			// Intents are not backed up, but skip any inline value.
+			continue
+		}
+		if len(revisions) > 0 && !key.Key.Equal(revisions[0].Key) {
+			if err := r.replayKey(ctx, revisions); err != nil {
+				return err
+			}
+			revisions = revisions[:0]
+		}
+		v, err := storage.DecodeMVCCValueAndErr(iter.UnsafeValue())
+		if err != nil {
+			return err
+		}
+		value := roachpb.Value{RawBytes: append([]byte(nil), v.Value.RawBytes...), Timestamp: key.Timestamp}
+		revisions = append(revisions, roachpb.KeyValue{Key: key.Key.Clone(), Value: value})
+	}
+	return r.replayKey(ctx, revisions)
+}
+
+// replayKey writes out the events of the changes to a key within the replayed
+// time range, given the revisions of the key, newest first.
+func (r *backupReplayer) replayKey(ctx context.Context, revisions []roachpb.KeyValue) error {
+	for i := len(revisions) - 1; i >= 0; i-- {
+		kv := revisions[i]
+		ts := kv.Value.Timestamp
+		if ts.LessEq(r.cursor) || r.endTime.Less(ts) {
+			continue
+		}
+		updatedRow, err := r.decoder.DecodeKV(ctx, kv, cdcevent.CurrentRow, ts, r.keyOnly)
+		if err != nil {
+			if errors.Is(err, cdcevent.ErrUnwatchedFamily) {
+				return nil
+			}
+			return err
+		}
+		var prevRow cdcevent.Row
+		if r.withDiff {
+			prev := roachpb.KeyValue{Key: kv.Key}
+			if i+1 < len(revisions) {
+				prev.Value = revisions[i+1].Value
+			}
+			prevRow, err = r.decoder.DecodeKV(ctx, prev, cdcevent.PrevRow, ts.Prev(), r.keyOnly)
+			if err != nil {
+				return err
+			}
+		}
+
+		target, found := r.targets.FindByTableIDAndFamilyName(
+			updatedRow.TableID, updatedRow.FamilyName)
+		if !found {
+			return errors.AssertionFailedf("no target for row %s", updatedRow.DebugString())
+		}
+		topic, err := makeTopicDescriptorFromSpec(target, updatedRow.Metadata)
+		if err != nil {
+			return err
+		}
+		name, components := topic.GetNameComponents()
+		topicName := strings.Join(append([]string{string(name)}, components...), ".")
+
+		evCtx := eventContext{updated: ts, mvcc: ts, topic: topicName}
+		key, err := r.encoder.EncodeKey(ctx, updatedRow)
+		if err != nil {
+			return err
+		}
+		value, err := r.encoder.EncodeValue(ctx, evCtx, updatedRow, prevRow)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(r.out, "%s\t%s\t%s\n", topicName, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayTimeRange(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	manifest := func(start, end int64, filter backuppb.MVCCFilter) backuppb.BackupManifest {
+		return backuppb.BackupManifest{StartTime: ts(start), EndTime: ts(end), MVCCFilter: filter}
+	}
+	full := manifest(0, 20, backuppb.MVCCFilter_All)
+	full.RevisionStartTime = ts(5)
+	chain := []backuppb.BackupManifest{full, manifest(20, 30, backuppb.MVCCFilter_All)}
+
+	for _, tc := range []struct {
+		name      string
+		manifests []backuppb.BackupManifest
+		cursor    string
+		endTime   string
+		expCursor hlc.Timestamp
+		expEnd    hlc.Timestamp
+		expErr    string
+	}{
+		{name: "default end time", manifests: chain, cursor: "10", expCursor: ts(10), expEnd: ts(30)},
+		{name: "end time", manifests: chain, cursor: "10", endTime: "25", expCursor: ts(10), expEnd: ts(25)},
+		{
+			name:      "no revision history",
+			manifests: []backuppb.BackupManifest{full, manifest(20, 30, backuppb.MVCCFilter_Latest)},
+			cursor:    "10",
+			expErr:    "backup 1 of the chain was not taken with revision_history",
+		},
+		{
+			name:      "gap",
+			manifests: []backuppb.BackupManifest{full, manifest(25, 30, backuppb.MVCCFilter_All)},
+			cursor:    "10",
+			expErr:    "backup 1 of the chain starts at",
+		},
+		{name: "relative cursor", manifests: chain, cursor: "-1h", expErr: "must be a decimal timestamp"},
+		{name: "before revisions", manifests: chain, cursor: "1", expErr: "is not covered"},
+		{name: "after backups", manifests: chain, cursor: "10", endTime: "40", expErr: "is not covered"},
+		{name: "inverted", manifests: chain, cursor: "25", endTime: "10", expErr: "is not covered"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := map[string]string{changefeedbase.OptCursor: tc.cursor}
+			if tc.endTime != "" {
+				m[changefeedbase.OptEndTime] = tc.endTime
+			}
+			cursor, endTime, err := replayTimeRange(changefeedbase.MakeStatementOptions(m), tc.manifests)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expCursor, cursor)
+			require.Equal(t, tc.expEnd, endTime)
+		})
+	}
+}
@@ -99,6 +99,17 @@ type rowEvent struct {
 	mvcc  hlc.Timestamp
 }
 
+// commitGroupEvent holds the rows of a commit group, which are emitted together
+// in a single batch.
+type commitGroupEvent struct {
+	rows []*rowEvent
+}
+
+// commitGroupBatchKey is added to the keys of every commit group batch so that
+// parallelIO emits commit groups in the order they were received. Row keys are
+// hashed to non-negative values, so it never overlaps with them.
+const commitGroupBatchKey = -1
+
 // Flush implements the Sink interface, returning the first error that has
 // occured in the past EmitRow calls.
 func (s *batchingSink) Flush(ctx context.Context) error {
@@ -191,6 +202,32 @@ func (s *batchingSink) EmitRow(
 	return nil
 }
 
+// EmitCommitGroup implements the commitGroupingSink interface.
+func (s *batchingSink) EmitCommitGroup(ctx context.Context, rows []commitGroupRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	group := &commitGroupEvent{rows: make([]*rowEvent, 0, len(rows))}
+	for _, r := range rows {
+		s.metrics.recordMessageSize(int64(len(r.key) + len(r.value)))
+		payload := newRowEvent()
+		payload.key = r.key
+		payload.val = r.value
+		payload.topicDescriptor = r.topic
+		payload.mvcc = r.mvcc
+		group.rows = append(group.rows, payload)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.eventCh <- group:
+	case <-s.doneCh:
+	}
+
+	return nil
+}
+
 // EmitResolvedTimestamp implements the Sink interface.
 func (s *batchingSink) EmitResolvedTimestamp(
 	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
@@ -353,13 +390,7 @@ func (s *batchingSink) runBatchingWorker(ctx context.Context) {
 		freeSinkBatchEvent(batch)
 	}
 
-	tryFlushBatch := func(topic string) error {
-		batchBuffer, ok := topicBatches[topic]
-		if !ok || batchBuffer.isEmpty() {
-			return nil
-		}
-		topicBatches[topic] = s.newBatchBuffer(topic)
-
+	emitBatch := func(batchBuffer *sinkBatch) error {
 		if err := batchBuffer.FinalizePayload(); err != nil {
 			return err
 		}
@@ -382,6 +413,15 @@ func (s *batchingSink) runBatchingWorker(ctx context.Context) {
 		return nil
 	}
 
+	tryFlushBatch := func(topic string) error {
+		batchBuffer, ok := topicBatches[topic]
+		if !ok || batchBuffer.isEmpty() {
+			return nil
+		}
+		topicBatches[topic] = s.newBatchBuffer(topic)
+		return emitBatch(batchBuffer)
+	}
+
 	flushAll := func() error {
 		for topic := range topicBatches {
 			if err := tryFlushBatch(topic); err != nil {
@@ -456,6 +496,41 @@ func (s *batchingSink) runBatchingWorker(ctx context.Context) {
 						s.handleError(err)
 					}
 				}
+			case *commitGroupEvent:
+				if s.termErr != nil {
+					continue
+				}
+
+				// Send out batched rows first so that parallelIO orders them ahead of
+				// the commit group's rows which share their keys.
+				if err := flushAll(); err != nil {
+					s.handleError(err)
+					continue
+				}
+
+				var topic string
+				var err error
+				if s.topicNamer != nil {
+					topic, err = s.topicNamer.Name(r.rows[0].topicDescriptor)
+					if err != nil {
+						s.handleError(err)
+						continue
+					}
+				}
+
+				inflight += len(r.rows)
+				batchBuffer := s.newBatchBuffer(topic)
+				for _, row := range r.rows {
+					batchBuffer.Append(row)
+					if s.knobs.OnAppend != nil {
+						s.knobs.OnAppend(row)
+					}
+					freeRowEvent(row)
+				}
+				batchBuffer.keys.Add(commitGroupBatchKey)
+				if err := emitBatch(batchBuffer); err != nil {
+					s.handleError(err)
+				}
 			case flushReq:
 				if inflight == 0 || s.termErr != nil {
 					close(r.waiter)
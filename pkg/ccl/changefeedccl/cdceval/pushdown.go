@@ -0,0 +1,525 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package cdceval
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedpb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc/valueside"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treecmp"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+)
+
+// rangefeedFilterType is the type under which the rangefeed value filter of
+// CDC queries is registered with KV.
+const rangefeedFilterType = "cdc"
+
+// RangefeedValueFilter returns the filter that a changefeed with the specified
+// normalized select clause can push down to its rangefeeds, or nil if the
+// expression gives KV nothing to filter.
+//
+// The filter is an optimization: the changefeed still evaluates the expression
+// on every event it receives. It drops the values that fail one of the
+// top-level conjuncts of the WHERE clause that compare a column with
+// constants, and, unless withDiff is set or the expression may reference the
+// whole row, omits the values of the columns that the expression does not
+// reference. Primary key columns are not filtered on, since the spans of the
+// changefeed are already constrained by the predicates on them.
+func RangefeedValueFilter(
+	ctx context.Context,
+	desc catalog.TableDescriptor,
+	target jobspb.ChangefeedTargetSpecification,
+	sc *tree.SelectClause,
+	withDiff bool,
+) (*kvpb.RangeFeedValueFilter, error) {
+	family, err := getTargetFamilyDescriptor(desc, target)
+	if err != nil {
+		return nil, err
+	}
+	spec := changefeedpb.RangefeedFilterSpec{
+		TableID:         desc.GetID(),
+		IndexID:         desc.GetPrimaryIndexID(),
+		FamilyID:        family.ID,
+		DefaultColumnID: family.DefaultColumnID,
+		FamilyColumnIDs: family.ColumnIDs,
+	}
+	familyCols := catalog.MakeTableColSet(family.ColumnIDs...)
+	keyCols := desc.GetPrimaryIndex().CollectKeyColumnIDs()
+
+	// resolve returns the column of the table that a name references, or nil
+	// if the name may reference something else.
+	resolve := func(n *tree.UnresolvedName) catalog.Column {
+		if n.Star || n.NumParts > 2 {
+			return nil
+		}
+		col := catalog.FindColumnByName(desc, n.Parts[0])
+		if col == nil || !col.Public() {
+			return nil
+		}
+		return col
+	}
+
+	if sc.Where != nil {
+		for _, conjunct := range splitConjuncts(sc.Where.Expr) {
+			p, ok, err := makeRangefeedFilterPredicate(ctx, conjunct, resolve)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || keyCols.Contains(p.ColumnID) || !familyCols.Contains(p.ColumnID) {
+				continue
+			}
+			spec.Predicates = append(spec.Predicates, p)
+		}
+	}
+
+	if !withDiff {
+		projected, ok := referencedColumns(sc, resolve)
+		if ok {
+			projected.UnionWith(keyCols)
+			for _, col := range desc.PublicColumns() {
+				if !col.IsNullable() {
+					projected.Add(col.GetID())
+				}
+			}
+			for _, id := range family.ColumnIDs {
+				if projected.Contains(id) {
+					spec.ProjectedColumnIDs = append(spec.ProjectedColumnIDs, id)
+				}
+			}
+			if len(spec.ProjectedColumnIDs) == len(family.ColumnIDs) {
+				// The expression references every column of the family.
+				spec.ProjectedColumnIDs = nil
+			}
+		}
+	}
+
+	if len(spec.Predicates) == 0 && len(spec.ProjectedColumnIDs) == 0 {
+		return nil, nil
+	}
+	specBytes, err := protoutil.Marshal(&spec)
+	if err != nil {
+		return nil, err
+	}
+	return &kvpb.RangeFeedValueFilter{Type: rangefeedFilterType, Spec: specBytes}, nil
+}
+
+// splitConjuncts returns the top-level conjuncts of a boolean expression.
+func splitConjuncts(expr tree.Expr) []tree.Expr {
+	switch t := expr.(type) {
+	case *tree.AndExpr:
+		return append(splitConjuncts(t.Left), splitConjuncts(t.Right)...)
+	case *tree.ParenExpr:
+		return splitConjuncts(t.Expr)
+	default:
+		return []tree.Expr{expr}
+	}
+}
+
+// referencedColumns returns the columns that a select clause references. It
+// returns false if the clause may reference the whole row, or the previous
+// row, or columns that are computed from others.
+func referencedColumns(
+	sc *tree.SelectClause, resolve func(*tree.UnresolvedName) catalog.Column,
+) (cols catalog.TableColSet, ok bool) {
+	ok = true
+	visit := func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		switch t := expr.(type) {
+		case tree.UnqualifiedStar, *tree.AllColumnsSelector, *tree.TupleStar:
+			ok = false
+		case *tree.UnresolvedName:
+			col := resolve(t)
+			if col == nil || col.IsVirtual() {
+				// The name may reference the table itself, or cdc_prev.
+				ok = false
+			} else {
+				cols.Add(col.GetID())
+			}
+		}
+		return ok, expr, nil
+	}
+	exprs := make([]tree.Expr, 0, len(sc.Exprs)+1)
+	for _, e := range sc.Exprs {
+		exprs = append(exprs, e.Expr)
+	}
+	if sc.Where != nil {
+		exprs = append(exprs, sc.Where.Expr)
+	}
+	for _, e := range exprs {
+		if _, err := tree.SimpleVisit(e, visit); err != nil {
+			return cols, false
+		}
+		if !ok {
+			return cols, false
+		}
+	}
+	return cols, true
+}
+
+var rangefeedFilterOps = map[treecmp.ComparisonOperatorSymbol]changefeedpb.RangefeedFilterSpec_Op{
+	treecmp.EQ: changefeedpb.RangefeedFilterSpec_EQ,
+	treecmp.NE: changefeedpb.RangefeedFilterSpec_NE,
+	treecmp.LT: changefeedpb.RangefeedFilterSpec_LT,
+	treecmp.LE: changefeedpb.RangefeedFilterSpec_LE,
+	treecmp.GT: changefeedpb.RangefeedFilterSpec_GT,
+	treecmp.GE: changefeedpb.RangefeedFilterSpec_GE,
+	treecmp.In: changefeedpb.RangefeedFilterSpec_IN,
+}
+
+// flippedRangefeedFilterOps maps the operators of "constant op column" to
+// those of the equivalent "column op constant".
+var flippedRangefeedFilterOps = map[changefeedpb.RangefeedFilterSpec_Op]changefeedpb.RangefeedFilterSpec_Op{
+	changefeedpb.RangefeedFilterSpec_EQ: changefeedpb.RangefeedFilterSpec_EQ,
+	changefeedpb.RangefeedFilterSpec_NE: changefeedpb.RangefeedFilterSpec_NE,
+	changefeedpb.RangefeedFilterSpec_LT: changefeedpb.RangefeedFilterSpec_GT,
+	changefeedpb.RangefeedFilterSpec_LE: changefeedpb.RangefeedFilterSpec_GE,
+	changefeedpb.RangefeedFilterSpec_GT: changefeedpb.RangefeedFilterSpec_LT,
+	changefeedpb.RangefeedFilterSpec_GE: changefeedpb.RangefeedFilterSpec_LE,
+}
+
+// makeRangefeedFilterPredicate returns the predicate of a conjunct of the
+// WHERE clause, and false if the conjunct cannot be evaluated by the filter.
+func makeRangefeedFilterPredicate(
+	ctx context.Context, expr tree.Expr, resolve func(*tree.UnresolvedName) catalog.Column,
+) (p changefeedpb.RangefeedFilterSpec_Predicate, ok bool, _ error) {
+	column := func(e tree.Expr) catalog.Column {
+		for {
+			paren, ok := e.(*tree.ParenExpr)
+			if !ok {
+				break
+			}
+			e = paren.Expr
+		}
+		n, ok := e.(*tree.UnresolvedName)
+		if !ok {
+			return nil
+		}
+		col := resolve(n)
+		if col == nil || col.IsVirtual() || !isRangefeedFilterType(col.GetType()) {
+			return nil
+		}
+		return col
+	}
+
+	var col catalog.Column
+	var constants []tree.Expr
+	switch t := expr.(type) {
+	case *tree.IsNullExpr:
+		if col = column(t.Expr); col == nil {
+			return p, false, nil
+		}
+		p.Op = changefeedpb.RangefeedFilterSpec_IS_NULL
+	case *tree.IsNotNullExpr:
+		if col = column(t.Expr); col == nil {
+			return p, false, nil
+		}
+		p.Op = changefeedpb.RangefeedFilterSpec_IS_NOT_NULL
+	case *tree.ComparisonExpr:
+		op, ok := rangefeedFilterOps[t.Operator.Symbol]
+		if !ok || t.Operator.IsExplicitOperator {
+			return p, false, nil
+		}
+		p.Op = op
+		if col = column(t.Left); col != nil {
+			constants = []tree.Expr{t.Right}
+		} else if col = column(t.Right); col != nil && op != changefeedpb.RangefeedFilterSpec_IN {
+			constants = []tree.Expr{t.Left}
+			p.Op = flippedRangefeedFilterOps[op]
+		} else {
+			return p, false, nil
+		}
+		if op == changefeedpb.RangefeedFilterSpec_IN {
+			tuple, ok := constants[0].(*tree.Tuple)
+			if !ok {
+				return p, false, nil
+			}
+			constants = tuple.Exprs
+		}
+	default:
+		return p, false, nil
+	}
+
+	p.ColumnID = col.GetID()
+	p.Type = col.GetType()
+	semaCtx := tree.MakeSemaContext()
+	for _, c := range constants {
+		typed, err := tree.TypeCheck(ctx, c, &semaCtx, p.Type)
+		if err != nil {
+			// The constant does not have the type of the column, and may not
+			// compare the same way as the values of the column.
+			return p, false, nil //nolint:returnerrcheck
+		}
+		d, ok := typed.(tree.Datum)
+		if !ok || d == tree.DNull || !d.ResolvedType().Equivalent(p.Type) {
+			return p, false, nil
+		}
+		b, err := valueside.Encode(nil, valueside.NoColumnID, d, nil)
+		if err != nil {
+			return p, false, err
+		}
+		p.Values = append(p.Values, b)
+	}
+	return p, true, nil
+}
+
+// isRangefeedFilterType returns whether the filter can compare the values of a
+// column type the same way as SQL.
+func isRangefeedFilterType(typ *types.T) bool {
+	switch typ.Family() {
+	case types.IntFamily, types.FloatFamily, types.DecimalFamily, types.StringFamily,
+		types.BytesFamily, types.BoolFamily, types.UuidFamily, types.DateFamily,
+		types.TimestampFamily, types.TimestampTZFamily:
+		return true
+	default:
+		return false
+	}
+}
+
+// rangefeedValueFilter is the rangefeed.ValueFilter of CDC queries, which
+// evaluates a changefeedpb.RangefeedFilterSpec.
+type rangefeedValueFilter struct {
+	spec       changefeedpb.RangefeedFilterSpec
+	familyCols catalog.TableColSet
+	projected  catalog.TableColSet
+	predicates []rangefeedFilterPredicate
+}
+
+type rangefeedFilterPredicate struct {
+	colID  descpb.ColumnID
+	typ    *types.T
+	op     changefeedpb.RangefeedFilterSpec_Op
+	values tree.Datums
+}
+
+var _ rangefeed.ValueFilter = (*rangefeedValueFilter)(nil)
+
+func newRangefeedValueFilter(specBytes []byte) (rangefeed.ValueFilter, error) {
+	f := &rangefeedValueFilter{}
+	if err := protoutil.Unmarshal(specBytes, &f.spec); err != nil {
+		return nil, err
+	}
+	f.familyCols = catalog.MakeTableColSet(f.spec.FamilyColumnIDs...)
+	f.projected = catalog.MakeTableColSet(f.spec.ProjectedColumnIDs...)
+	var a tree.DatumAlloc
+	for _, p := range f.spec.Predicates {
+		if p.Type == nil {
+			return nil, errors.AssertionFailedf("predicate on column %d has no type", p.ColumnID)
+		}
+		pred := rangefeedFilterPredicate{colID: p.ColumnID, typ: p.Type, op: p.Op}
+		for _, b := range p.Values {
+			d, _, err := valueside.Decode(&a, p.Type, b)
+			if err != nil {
+				return nil, err
+			}
+			pred.values = append(pred.values, d)
+		}
+		f.predicates = append(f.predicates, pred)
+	}
+	return f, nil
+}
+
+// FilterValue implements the rangefeed.ValueFilter interface.
+func (f *rangefeedValueFilter) FilterValue(v *kvpb.RangeFeedValue) *kvpb.RangeFeedValue {
+	if !f.matchesFamily(v.Key) {
+		return v
+	}
+	datums := make(tree.Datums, len(f.predicates))
+	for i := range datums {
+		datums[i] = tree.DNull
+	}
+	var a tree.DatumAlloc
+	if v.Value.GetTag() != roachpb.ValueType_TUPLE {
+		// The family has a single column, whose value is encoded on its own.
+		if f.spec.DefaultColumnID == 0 {
+			return v
+		}
+		for i, p := range f.predicates {
+			if p.colID != f.spec.DefaultColumnID {
+				continue
+			}
+			d, err := valueside.UnmarshalLegacy(&a, p.typ, v.Value)
+			if err != nil {
+				return v
+			}
+			datums[i] = d
+		}
+		if !f.evalPredicates(datums) {
+			return nil
+		}
+		return v
+	}
+
+	b, err := v.Value.GetTuple()
+	if err != nil {
+		return v
+	}
+	var colID descpb.ColumnID
+	project := false
+	for len(b) > 0 {
+		_, dataOffset, delta, typ, err := encoding.DecodeValueTag(b)
+		if err != nil {
+			return v
+		}
+		colID += descpb.ColumnID(delta)
+		if !f.familyCols.Contains(colID) {
+			// The schema of the table changed since the filter was planned.
+			return v
+		}
+		n, err := encoding.PeekValueLengthWithOffsetsAndType(b, dataOffset, typ)
+		if err != nil {
+			return v
+		}
+		for i, p := range f.predicates {
+			if p.colID != colID {
+				continue
+			}
+			d, _, err := valueside.Decode(&a, p.typ, b[:n])
+			if err != nil {
+				return v
+			}
+			datums[i] = d
+		}
+		project = project || (!f.projected.Empty() && !f.projected.Contains(colID))
+		b = b[n:]
+	}
+	if !f.evalPredicates(datums) {
+		return nil
+	}
+	if !project {
+		return v
+	}
+
+	projected := *v
+	var ok bool
+	if projected.Value, ok = f.project(v.Key, v.Value); !ok {
+		return v
+	}
+	if v.PrevValue.IsPresent() {
+		if projected.PrevValue, ok = f.project(v.Key, v.PrevValue); !ok {
+			return v
+		}
+	}
+	return &projected
+}
+
+// matchesFamily returns whether a key is a key of the family of the filter.
+func (f *rangefeedValueFilter) matchesFamily(key roachpb.Key) bool {
+	rest, _, err := keys.DecodeTenantPrefix(key)
+	if err != nil {
+		return false
+	}
+	_, tableID, indexID, err := keys.DecodeTableIDIndexID(rest)
+	if err != nil || descpb.ID(tableID) != f.spec.TableID || descpb.IndexID(indexID) != f.spec.IndexID {
+		return false
+	}
+	familyID, err := keys.DecodeFamilyKey(key)
+	return err == nil && descpb.FamilyID(familyID) == f.spec.FamilyID
+}
+
+// evalPredicates returns whether the values of the columns of the predicates
+// satisfy all of them.
+func (f *rangefeedValueFilter) evalPredicates(datums tree.Datums) bool {
+	for i, p := range f.predicates {
+		d := datums[i]
+		switch p.op {
+		case changefeedpb.RangefeedFilterSpec_IS_NULL:
+			if d != tree.DNull {
+				return false
+			}
+			continue
+		case changefeedpb.RangefeedFilterSpec_IS_NOT_NULL:
+			if d == tree.DNull {
+				return false
+			}
+			continue
+		}
+		if d == tree.DNull {
+			return false
+		}
+		matched := false
+		for _, c := range p.values {
+			cmp, err := d.CompareError((*eval.Context)(nil), c)
+			if err != nil {
+				// Send the value; the changefeed will surface the error, if any.
+				return true
+			}
+			switch p.op {
+			case changefeedpb.RangefeedFilterSpec_EQ, changefeedpb.RangefeedFilterSpec_IN:
+				matched = cmp == 0
+			case changefeedpb.RangefeedFilterSpec_NE:
+				matched = cmp != 0
+			case changefeedpb.RangefeedFilterSpec_LT:
+				matched = cmp < 0
+			case changefeedpb.RangefeedFilterSpec_LE:
+				matched = cmp <= 0
+			case changefeedpb.RangefeedFilterSpec_GT:
+				matched = cmp > 0
+			case changefeedpb.RangefeedFilterSpec_GE:
+				matched = cmp >= 0
+			default:
+				return true
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// project returns the value of the tuple that omits the columns which are not
+// projected, and false if the value is not a tuple that can be decoded.
+func (f *rangefeedValueFilter) project(key roachpb.Key, v roachpb.Value) (roachpb.Value, bool) {
+	b, err := v.GetTuple()
+	if err != nil {
+		return v, false
+	}
+	out := make([]byte, 0, len(b))
+	var colID, lastColID descpb.ColumnID
+	for len(b) > 0 {
+		_, dataOffset, delta, typ, err := encoding.DecodeValueTag(b)
+		if err != nil {
+			return v, false
+		}
+		colID += descpb.ColumnID(delta)
+		n, err := encoding.PeekValueLengthWithOffsetsAndType(b, dataOffset, typ)
+		if err != nil {
+			return v, false
+		}
+		if f.projected.Contains(colID) {
+			out = encoding.EncodeValueTag(out, uint32(colID-lastColID), typ)
+			out = append(out, b[dataOffset:n]...)
+			lastColID = colID
+		}
+		b = b[n:]
+	}
+	projected := roachpb.Value{Timestamp: v.Timestamp}
+	projected.SetTuple(out)
+	projected.InitChecksum(key)
+	return projected, true
+}
+
+func init() {
+	rangefeed.RegisterValueFilter(rangefeedFilterType, newRangefeedValueFilter)
+}
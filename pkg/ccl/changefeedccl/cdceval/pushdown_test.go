@@ -0,0 +1,173 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package cdceval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdctest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangefeedValueFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `
+CREATE TABLE foo (
+  a INT PRIMARY KEY,
+  b INT,
+  c STRING,
+  d STRING NOT NULL DEFAULT 'd',
+  e STRING DEFAULT 'e'
+)`)
+	sqlDB.Exec(t, `INSERT INTO foo (a, b, c) VALUES (1, 1, 'x'), (2, 2, 'y'), (3, NULL, 'z'), (4, 5, NULL)`)
+
+	execCfg := s.ExecutorConfig().(sql.ExecutorConfig)
+	desc := cdctest.GetHydratedTableDescriptor(t, s.ExecutorConfig(), "foo")
+	target := jobspb.ChangefeedTargetSpecification{
+		Type:    jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY,
+		TableID: desc.GetID(),
+	}
+	var targets changefeedbase.Targets
+	targets.Add(changefeedbase.Target{Type: target.Type, TableID: desc.GetID()})
+	decoder, err := cdcevent.NewEventDecoder(ctx, &execCfg, targets, false, false)
+	require.NoError(t, err)
+
+	// scan returns the values of the rows of the table.
+	scan := func() (vals []*kvpb.RangeFeedValue) {
+		span := desc.PrimaryIndexSpan(keys.SystemSQLCodec)
+		kvs, err := kvDB.Scan(ctx, span.Key, span.EndKey, 0 /* maxRows */)
+		require.NoError(t, err)
+		for _, kv := range kvs {
+			vals = append(vals, &kvpb.RangeFeedValue{Key: kv.Key, Value: *kv.Value})
+		}
+		return vals
+	}
+	makeFilter := func(t *testing.T, desc catalog.TableDescriptor, stmt string) rangefeed.ValueFilter {
+		sc, err := ParseChangefeedExpression(stmt)
+		require.NoError(t, err)
+		f, err := RangefeedValueFilter(ctx, desc, target, sc, false /* withDiff */)
+		require.NoError(t, err)
+		if f == nil {
+			return nil
+		}
+		vf, err := rangefeed.NewValueFilter(f)
+		require.NoError(t, err)
+		require.NotNil(t, vf)
+		return vf
+	}
+
+	row := func(a, b, c, e string) map[string]string {
+		return map[string]string{"a": a, "b": b, "c": c, "d": "d", "e": e}
+	}
+	for _, tc := range []struct {
+		stmt     string
+		noFilter bool
+		expect   map[string]map[string]string
+	}{
+		{
+			stmt: `SELECT a, c FROM foo WHERE b > 1`,
+			expect: map[string]map[string]string{
+				"2": row("2", "2", "y", "NULL"),
+				"4": row("4", "5", "NULL", "NULL"),
+			},
+		},
+		{
+			stmt: `SELECT * FROM foo WHERE c IN ('x', 'z')`,
+			expect: map[string]map[string]string{
+				"1": row("1", "1", "x", "e"),
+				"3": row("3", "NULL", "z", "e"),
+			},
+		},
+		{
+			stmt: `SELECT a, e FROM foo WHERE 2 <= b AND c IS NOT NULL`,
+			expect: map[string]map[string]string{
+				"2": row("2", "2", "y", "e"),
+			},
+		},
+		{
+			stmt: `SELECT a FROM foo WHERE b IS NULL OR c = 'x'`,
+			expect: map[string]map[string]string{
+				"1": row("1", "1", "x", "NULL"),
+				"2": row("2", "2", "y", "NULL"),
+				"3": row("3", "NULL", "z", "NULL"),
+				"4": row("4", "5", "NULL", "NULL"),
+			},
+		},
+		{
+			stmt: `SELECT a, c FROM foo WHERE b IS NULL`,
+			expect: map[string]map[string]string{
+				"3": row("3", "NULL", "z", "NULL"),
+			},
+		},
+		{
+			// Predicates on the primary key constrain the spans instead, and
+			// cdc_prev references the whole previous row.
+			stmt:     `SELECT a, cdc_prev FROM foo WHERE a > 2`,
+			noFilter: true,
+		},
+		{
+			// The constant does not have the type of the column.
+			stmt:     `SELECT * FROM foo WHERE b = 1.5`,
+			noFilter: true,
+		},
+	} {
+		t.Run(tc.stmt, func(t *testing.T) {
+			f := makeFilter(t, desc, tc.stmt)
+			if tc.noFilter {
+				require.Nil(t, f)
+				return
+			}
+			require.NotNil(t, f)
+
+			actual := make(map[string]map[string]string)
+			for _, v := range scan() {
+				filtered := f.FilterValue(v)
+				if filtered == nil {
+					continue
+				}
+				r := decodeRow(t, decoder, filtered, cdcevent.CurrentRow)
+				actual[slurpKeys(t, r)[0]] = slurpValues(t, r)
+			}
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+
+	t.Run("schema change", func(t *testing.T) {
+		f := makeFilter(t, desc, `SELECT a FROM foo WHERE b > 100`)
+		require.NotNil(t, f)
+		sqlDB.Exec(t, `ALTER TABLE foo ADD COLUMN f INT DEFAULT 7`)
+
+		// The values have a column that the filter does not know about, so it
+		// sends them unchanged.
+		for _, v := range scan() {
+			require.Equal(t, v, f.FilterValue(v))
+		}
+	})
+}
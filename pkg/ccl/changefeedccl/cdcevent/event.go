@@ -152,6 +152,16 @@ func (r Row) HasValues() bool {
 	return r.datums != nil
 }
 
+// Copy returns a copy of this row which does not share its datums with the
+// original. Decoders and projections may reuse the datums of the rows they
+// return, so rows which need to outlive the next decoded event must be copied.
+func (r Row) Copy() Row {
+	if r.datums != nil {
+		r.datums = append(make(rowenc.EncDatumRow, 0, len(r.datums)), r.datums...)
+	}
+	return r
+}
+
 // DebugString returns debug string describing event source.
 func (m Metadata) DebugString() string {
 	return fmt.Sprintf("{table: %d family: %d}", m.TableID, m.FamilyID)
@@ -471,7 +481,28 @@ func NewEventDecoder(
 	if err != nil {
 		return nil, err
 	}
+	return newEventDecoder(rfCache, includeVirtual, keyOnly), nil
+}
 
+// NewEventDecoderWithLookup returns a key value decoder which retrieves the
+// table descriptors with the given lookup function rather than from the
+// cluster. It allows decoding KVs read from outside of the cluster, such as
+// from a backup.
+func NewEventDecoderWithLookup(
+	codec keys.SQLCodec,
+	lookup TableDescriptorLookup,
+	targets changefeedbase.Targets,
+	includeVirtual bool,
+	keyOnly bool,
+) (Decoder, error) {
+	rfCache, err := newRowFetcherCacheWithLookup(codec, lookup, targets)
+	if err != nil {
+		return nil, err
+	}
+	return newEventDecoder(rfCache, includeVirtual, keyOnly), nil
+}
+
+func newEventDecoder(rfCache *rowFetcherCache, includeVirtual bool, keyOnly bool) *eventDecoder {
 	eventDescriptorCache := cache.NewUnorderedCache(DefaultCacheConfig)
 	getEventDescriptor := func(
 		desc catalog.TableDescriptor,
@@ -484,7 +515,7 @@ func NewEventDecoder(
 	return &eventDecoder{
 		getEventDescriptor: getEventDescriptor,
 		rfCache:            rfCache,
-	}, nil
+	}
 }
 
 // RowType is the type of the row being decoded.
@@ -36,16 +36,19 @@ import (
 // the column families of one row) into a row.
 type rowFetcherCache struct {
 	codec           keys.SQLCodec
-	leaseMgr        *lease.Manager
+	lookupTableDesc TableDescriptorLookup
 	fetchers        *cache.UnorderedCache
 	watchedFamilies map[watchedFamily]struct{}
 
-	collection *descs.Collection
-	db         *kv.DB
-
 	a tree.DatumAlloc
 }
 
+// TableDescriptorLookup returns the descriptor of a table as of the given
+// timestamp, with its user defined types hydrated.
+type TableDescriptorLookup func(
+	ctx context.Context, tableID descpb.ID, ts hlc.Timestamp,
+) (catalog.TableDescriptor, error)
+
 type cachedFetcher struct {
 	tableDesc  catalog.TableDescriptor
 	fetcher    row.Fetcher
@@ -66,6 +69,35 @@ func newRowFetcherCache(
 	cf *descs.CollectionFactory,
 	db *kv.DB,
 	targets changefeedbase.Targets,
+) (*rowFetcherCache, error) {
+	collection := cf.NewCollection(ctx)
+	lookup := func(
+		ctx context.Context, tableID descpb.ID, ts hlc.Timestamp,
+	) (catalog.TableDescriptor, error) {
+		// Retrieve the target TableDescriptor from the lease manager. No caching
+		// is attempted because the lease manager does its own caching.
+		desc, err := leaseMgr.Acquire(ctx, ts, tableID)
+		if err != nil {
+			// Manager can return all kinds of errors during chaos, but based on
+			// its usage, none of them should ever be terminal.
+			return nil, changefeedbase.MarkRetryableError(err)
+		}
+		tableDesc := desc.Underlying().(catalog.TableDescriptor)
+		// Immediately release the lease, since we only need it for the exact
+		// timestamp requested.
+		desc.Release(ctx)
+		if catalog.MaybeRequiresHydration(tableDesc) {
+			return refreshUDT(ctx, tableID, db, collection, ts)
+		}
+		return tableDesc, nil
+	}
+	return newRowFetcherCacheWithLookup(codec, lookup, targets)
+}
+
+// newRowFetcherCacheWithLookup constructs a row fetcher cache which retrieves
+// the table descriptors with the given lookup function.
+func newRowFetcherCacheWithLookup(
+	codec keys.SQLCodec, lookup TableDescriptorLookup, targets changefeedbase.Targets,
 ) (*rowFetcherCache, error) {
 	if targets.Size == 0 {
 		return nil, errors.AssertionFailedf("Expected at least one target, found 0")
@@ -80,9 +112,7 @@ func newRowFetcherCache(
 	}
 	return &rowFetcherCache{
 		codec:           codec,
-		leaseMgr:        leaseMgr,
-		collection:      cf.NewCollection(ctx),
-		db:              db,
+		lookupTableDesc: lookup,
 		fetchers:        cache.NewUnorderedCache(DefaultCacheConfig),
 		watchedFamilies: watchedFamilies,
 	}, err
@@ -127,7 +157,6 @@ func refreshUDT(
 func (c *rowFetcherCache) tableDescForKey(
 	ctx context.Context, key roachpb.Key, ts hlc.Timestamp,
 ) (catalog.TableDescriptor, descpb.FamilyID, error) {
-	var tableDesc catalog.TableDescriptor
 	key, err := c.codec.StripTenantPrefix(key)
 	if err != nil {
 		return nil, descpb.FamilyID(0), err
@@ -144,23 +173,9 @@ func (c *rowFetcherCache) tableDescForKey(
 
 	family := descpb.FamilyID(familyID)
 
-	// Retrieve the target TableDescriptor from the lease manager. No caching
-	// is attempted because the lease manager does its own caching.
-	desc, err := c.leaseMgr.Acquire(ctx, ts, tableID)
+	tableDesc, err := c.lookupTableDesc(ctx, tableID, ts)
 	if err != nil {
-		// Manager can return all kinds of errors during chaos, but based on
-		// its usage, none of them should ever be terminal.
-		return nil, family, changefeedbase.MarkRetryableError(err)
-	}
-	tableDesc = desc.Underlying().(catalog.TableDescriptor)
-	// Immediately release the lease, since we only need it for the exact
-	// timestamp requested.
-	desc.Release(ctx)
-	if catalog.MaybeRequiresHydration(tableDesc) {
-		tableDesc, err = refreshUDT(ctx, tableID, c.db, c.collection, ts)
-		if err != nil {
-			return nil, family, err
-		}
+		return nil, family, err
 	}
 
 	// Skip over the column data.
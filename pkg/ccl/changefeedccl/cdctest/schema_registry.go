@@ -12,9 +12,12 @@ import (
 	"crypto/tls"
 	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
@@ -32,6 +35,9 @@ type SchemaRegistry struct {
 		idAlloc  int32
 		schemas  map[int32]string
 		subjects map[string]int32
+		// compatibility is the compatibility mode enforced by
+		// compatibility checks, either NONE or BACKWARD.
+		compatibility string
 	}
 }
 
@@ -74,6 +80,7 @@ func makeTestSchemaRegistry() *SchemaRegistry {
 	r := &SchemaRegistry{}
 	r.mu.schemas = make(map[int32]string)
 	r.mu.subjects = make(map[string]int32)
+	r.mu.compatibility = "NONE"
 	r.server = httptest.NewUnstartedServer(http.HandlerFunc(r.requestHandler))
 	return r
 }
@@ -116,6 +123,19 @@ func (r *SchemaRegistry) registerSchema(subject string, schema string) int32 {
 	return id
 }
 
+// SetCompatibility sets the compatibility mode compatibility checks enforce.
+// Like a confluent schema registry, the test registry accepts NONE, under which
+// every schema is compatible, and BACKWARD, under which a schema must be able to
+// read data written with the latest schema of its subject. The BACKWARD check
+// is a simplification of the avro rules: fields of a record may be added if
+// they have a default, and may not change type. Registration itself is never
+// rejected.
+func (r *SchemaRegistry) SetCompatibility(mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.compatibility = mode
+}
+
 // RegistrationCount returns the number of Registration requests received.
 func (r *SchemaRegistry) RegistrationCount() int {
 	r.mu.Lock()
@@ -127,6 +147,7 @@ var (
 	// We are slightly stricter than confluent here as they allow
 	// a trailing slash.
 	subjectVersionsRegexp = regexp.MustCompile("^/subjects/[^/]+/versions$")
+	compatibilityRegexp   = regexp.MustCompile("^/compatibility/subjects/[^/]+/versions/latest$")
 )
 
 // requestHandler routes requests based on the Method and Path of the request.
@@ -143,6 +164,8 @@ func (r *SchemaRegistry) requestHandler(hw http.ResponseWriter, hr *http.Request
 	switch {
 	case method == http.MethodPost && subjectVersionsRegexp.MatchString(path):
 		err = r.register(hw, hr)
+	case method == http.MethodPost && compatibilityRegexp.MatchString(path):
+		err = r.checkCompatibility(hw, hr)
 	case method == http.MethodGet && path == "/mode":
 		err = r.mode(hw, hr)
 	default:
@@ -184,6 +207,159 @@ func (r *SchemaRegistry) register(hw http.ResponseWriter, hr *http.Request) (err
 	return err
 }
 
+// checkCompatibility is an http handler for the underlying server which checks
+// a schema against the latest schema of a subject.
+func (r *SchemaRegistry) checkCompatibility(hw http.ResponseWriter, hr *http.Request) (err error) {
+	type confluentSchemaVersionRequest struct {
+		Schema string `json:"schema"`
+	}
+	type confluentSchemaCompatibilityResponse struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages,omitempty"`
+	}
+
+	defer func() {
+		err = hr.Body.Close()
+	}()
+
+	var req confluentSchemaVersionRequest
+	if err := json.NewDecoder(hr.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	subject := strings.Split(hr.URL.Path, "/")[3]
+	r.mu.Lock()
+	id, ok := r.mu.subjects[subject]
+	latest, mode := r.mu.schemas[id], r.mu.compatibility
+	r.mu.Unlock()
+	if !ok {
+		http.Error(hw, `{"error_code":40401,"message":"Subject not found."}`, http.StatusNotFound)
+		return nil
+	}
+
+	res := confluentSchemaCompatibilityResponse{IsCompatible: true}
+	if mode == "BACKWARD" {
+		messages, err := backwardIncompatibilities(latest, req.Schema)
+		if err != nil {
+			return err
+		}
+		res = confluentSchemaCompatibilityResponse{IsCompatible: len(messages) == 0, Messages: messages}
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	hw.Header().Set(`Content-type`, `application/json`)
+	_, err = hw.Write(body)
+	return err
+}
+
+// backwardIncompatibilities returns the reasons why data written with the old
+// schema can't be read with the new one.
+func backwardIncompatibilities(oldSchema, newSchema string) ([]string, error) {
+	oldRecords, err := avroRecordFields(oldSchema)
+	if err != nil {
+		return nil, err
+	}
+	newRecords, err := avroRecordFields(newSchema)
+	if err != nil {
+		return nil, err
+	}
+	var messages []string
+	for name, newFields := range newRecords {
+		oldFields, ok := oldRecords[name]
+		if !ok {
+			continue
+		}
+		for field, newField := range newFields {
+			oldField, ok := oldFields[field]
+			if !ok {
+				if _, hasDefault := newField["default"]; !hasDefault {
+					messages = append(messages, fmt.Sprintf(
+						"READER_FIELD_MISSING_DEFAULT_VALUE: %s.%s", name, field))
+				}
+				continue
+			}
+			if !reflect.DeepEqual(avroTypeRef(oldField["type"]), avroTypeRef(newField["type"])) {
+				messages = append(messages, fmt.Sprintf("TYPE_MISMATCH: %s.%s", name, field))
+			}
+		}
+	}
+	sort.Strings(messages)
+	return messages, nil
+}
+
+// avroRecordFields returns the fields of every record type defined by the avro
+// schema, by record name and field name.
+func avroRecordFields(schema string) (map[string]map[string]map[string]interface{}, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(schema), &parsed); err != nil {
+		return nil, err
+	}
+	records := make(map[string]map[string]map[string]interface{})
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		case map[string]interface{}:
+			if t["type"] == "record" {
+				fields := make(map[string]map[string]interface{})
+				fieldList, _ := t["fields"].([]interface{})
+				for _, f := range fieldList {
+					if field, ok := f.(map[string]interface{}); ok {
+						name, _ := field["name"].(string)
+						fields[name] = field
+						walk(field["type"])
+					}
+				}
+				records[avroFullName(t)] = fields
+				return
+			}
+			for _, e := range t {
+				walk(e)
+			}
+		}
+	}
+	walk(parsed)
+	return records, nil
+}
+
+// avroTypeRef returns the avro type with nested records replaced by their
+// names, so that types can be compared without comparing the records' fields.
+func avroTypeRef(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		ref := make([]interface{}, len(t))
+		for i, e := range t {
+			ref[i] = avroTypeRef(e)
+		}
+		return ref
+	case map[string]interface{}:
+		if t["type"] == "record" {
+			return avroFullName(t)
+		}
+		ref := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			ref[k] = avroTypeRef(e)
+		}
+		return ref
+	default:
+		return v
+	}
+}
+
+func avroFullName(record map[string]interface{}) string {
+	name, _ := record["name"].(string)
+	if ns, _ := record["namespace"].(string); ns != "" && !strings.Contains(name, ".") {
+		return ns + "." + name
+	}
+	return name
+}
+
 // mode is an http handler for the /mode endpoint. Our implementation
 // returns an empty response as we currently don't care about the
 // response.
@@ -10,6 +10,7 @@ package changefeedccl
 
 import (
 	"context"
+	"net/url"
 	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdceval"
@@ -19,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql"
@@ -37,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
 )
 
@@ -211,6 +214,26 @@ func fetchSpansForTables(
 		sd, tableDescs[0], initialHighwater, target, sc)
 }
 
+// fetchRangefeedValueFilter returns the filter that the rangefeeds of the
+// changefeed push down to KV, if any. See cdceval.RangefeedValueFilter.
+func fetchRangefeedValueFilter(
+	ctx context.Context,
+	execCtx sql.JobExecContext,
+	tableDescs []catalog.TableDescriptor,
+	details jobspb.ChangefeedDetails,
+) (*kvpb.RangeFeedValueFilter, error) {
+	if details.Select == "" || len(tableDescs) != 1 ||
+		!changefeedbase.PushDownRangefeedFilters.Get(&execCtx.ExecCfg().Settings.SV) {
+		return nil, nil
+	}
+	sc, err := cdceval.ParseChangefeedExpression(details.Select)
+	if err != nil {
+		return nil, err
+	}
+	_, withDiff := details.Opts[changefeedbase.OptDiff]
+	return cdceval.RangefeedValueFilter(ctx, tableDescs[0], details.TargetSpecifications[0], sc, withDiff)
+}
+
 // startDistChangefeed starts distributed changefeed execution.
 func startDistChangefeed(
 	ctx context.Context,
@@ -236,6 +259,12 @@ func startDistChangefeed(
 		return err
 	}
 	localState.trackedSpans = trackedSpans
+	valueFilter, err := fetchRangefeedValueFilter(ctx, execCtx, tableDescs, details)
+	if err != nil {
+		// The filter is only an optimization.
+		log.Warningf(ctx, "not pushing down the filter of the changefeed: %v", err)
+		valueFilter = nil
+	}
 
 	// Changefeed flows handle transactional consistency themselves.
 	var noTxn *kv.Txn
@@ -248,7 +277,7 @@ func startDistChangefeed(
 		checkpoint = progress.Checkpoint
 	}
 	p, planCtx, err := makePlan(execCtx, jobID, details, initialHighWater,
-		trackedSpans, checkpoint, localState.drainingNodes)(ctx, dsp)
+		trackedSpans, valueFilter, checkpoint, localState.drainingNodes)(ctx, dsp)
 	if err != nil {
 		return err
 	}
@@ -325,6 +354,7 @@ func makePlan(
 	details jobspb.ChangefeedDetails,
 	initialHighWater hlc.Timestamp,
 	trackedSpans []roachpb.Span,
+	valueFilter *kvpb.RangeFeedValueFilter,
 	checkpoint *jobspb.ChangefeedProgress_Checkpoint,
 	drainingNodes []roachpb.NodeID,
 ) func(context.Context, *sql.DistSQLPlanner) (*sql.PhysicalPlan, *sql.PlanningCtx, error) {
@@ -336,6 +366,20 @@ func makePlan(
 			// Sinkless feeds get one ChangeAggregator on this node.
 			distMode = sql.DistributionTypeNone
 		}
+		if _, ok := details.Opts[changefeedbase.OptCommitGroupInfo]; ok {
+			// A commit group's rows may live on ranges watched by any aggregator;
+			// a single aggregator is needed to assemble the group.
+			distMode = sql.DistributionTypeNone
+			if err := checkCommitGroupInfoRanges(ctx, execCtx, trackedSpans); err != nil {
+				return nil, nil, err
+			}
+		}
+		if u, err := url.Parse(details.SinkURI); err == nil && isPostgresSink(u) {
+			// The postgres sink applies the changes up to the local frontier in
+			// one transaction; with a single aggregator, that is a resolved
+			// timestamp.
+			distMode = sql.DistributionTypeNone
+		}
 
 		var locFilter roachpb.Locality
 		if loc := details.Opts[changefeedbase.OptExecutionLocality]; loc != "" {
@@ -414,6 +458,8 @@ func makePlan(
 				UserProto:  execCtx.User().EncodeProto(),
 				JobID:      jobID,
 				Select:     execinfrapb.Expression{Expr: details.Select},
+
+				RangefeedValueFilter: valueFilter,
 			}
 		}
 
@@ -514,6 +560,34 @@ type rangeResolver interface {
 	getRangesForSpans(ctx context.Context, spans []roachpb.Span) ([]roachpb.Span, error)
 }
 
+// checkCommitGroupInfoRanges returns a terminal error if a changefeed using
+// commit_group_info watches more ranges than allowed by the
+// changefeed.commit_group_info.max_ranges setting, since all of them are
+// watched by a single aggregator.
+func checkCommitGroupInfoRanges(
+	ctx context.Context, execCtx sql.JobExecContext, spans []roachpb.Span,
+) error {
+	maxRanges := changefeedbase.CommitGroupInfoMaxRanges.Get(&execCtx.ExecCfg().Settings.SV)
+	if maxRanges == 0 {
+		return nil
+	}
+	sender := execCtx.ExecCfg().DB.NonTransactionalSender()
+	distSender := sender.(*kv.CrossRangeTxnWrapperSender).Wrapped().(*kvcoord.DistSender)
+	ranges, err := (&distResolver{distSender}).getRangesForSpans(ctx, spans)
+	if err != nil {
+		return err
+	}
+	if int64(len(ranges)) > maxRanges {
+		return changefeedbase.WithTerminalError(errors.WithHintf(
+			errors.Newf("changefeed with %s watches %d ranges, more than the limit of %d",
+				changefeedbase.OptCommitGroupInfo, len(ranges), maxRanges),
+			"a single node watches all of the ranges of such a changefeed; "+
+				"consider watching fewer tables or increasing the %s cluster setting",
+			changefeedbase.CommitGroupInfoMaxRanges.Key()))
+	}
+	return nil
+}
+
 type distResolver struct {
 	*kvcoord.DistSender
 }
@@ -440,6 +440,7 @@ func (ca *changeAggregator) makeKVFeedCfg(
 		SchemaFeed:              sf,
 		Knobs:                   ca.knobs.FeedKnobs,
 		UseMux:                  changefeedbase.UseMuxRangeFeed.Get(&cfg.Settings.SV),
+		ValueFilter:             ca.spec.RangefeedValueFilter,
 	}, nil
 }
 
@@ -550,7 +551,7 @@ func (ca *changeAggregator) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMet
 			spans = append(spans,
 				execinfrapb.ChangefeedMeta_FrontierSpan{
 					Span:      r,
-					Timestamp: ts,
+					Timestamp: ca.checkpointTimestamp(ts),
 				})
 			return span.ContinueMatch
 		})
@@ -728,6 +729,7 @@ func (ca *changeAggregator) flushFrontier() error {
 	// Iterate frontier spans and build a list of spans to emit.
 	var batch jobspb.ResolvedSpans
 	ca.frontier.Entries(func(s roachpb.Span, ts hlc.Timestamp) span.OpResult {
+		ts = ca.checkpointTimestamp(ts)
 		boundaryType := jobspb.ResolvedSpan_NONE
 		if ca.frontier.boundaryTime.Equal(ts) {
 			boundaryType = ca.frontier.boundaryType
@@ -744,6 +746,17 @@ func (ca *changeAggregator) flushFrontier() error {
 	return ca.emitResolved(batch)
 }
 
+// checkpointTimestamp returns the timestamp at which a span resolved at ts may
+// be reported to the changeFrontier. Ordinarily this is ts itself, but when
+// the event consumer holds on to events past the point at which the sink is
+// flushed, spans cannot be reported as resolved beyond those events.
+func (ca *changeAggregator) checkpointTimestamp(ts hlc.Timestamp) hlc.Timestamp {
+	if c, ok := ca.eventConsumer.(*kvEventToRowConsumer); ok {
+		return c.checkpointTimestamp(ts)
+	}
+	return ts
+}
+
 func (ca *changeAggregator) emitResolved(batch jobspb.ResolvedSpans) error {
 	progressUpdate := jobspb.ResolvedSpans{
 		ResolvedSpans: batch.ResolvedSpans,
@@ -1213,10 +1226,18 @@ func (cf *changeFrontier) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMetad
 				// Detect whether this boundary should be used to kill or restart the
 				// changefeed.
 				if cf.frontier.boundaryType == jobspb.ResolvedSpan_EXIT {
-					err = changefeedbase.WithTerminalError(errors.Wrapf(err,
-						"shut down due to schema change and %s=%q",
-						changefeedbase.OptSchemaChangePolicy,
-						changefeedbase.OptSchemaChangePolicyStop))
+					policy := changefeedbase.OptSchemaChangePolicyStop
+					if schemaChange, optsErr := changefeedbase.MakeStatementOptions(
+						cf.spec.Feed.Opts).GetSchemaChangeHandlingOptions(); optsErr == nil {
+						policy = schemaChange.Policy
+					}
+					err = errors.Wrapf(err, "shut down due to schema change and %s=%q",
+						changefeedbase.OptSchemaChangePolicy, policy)
+					if policy == changefeedbase.OptSchemaChangePolicyPause {
+						err = changefeedbase.WithPauseRequestedError(err)
+					} else {
+						err = changefeedbase.WithTerminalError(err)
+					}
 				} else {
 					err = changefeedbase.MarkRetryableError(err)
 				}
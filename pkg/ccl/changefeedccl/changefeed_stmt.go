@@ -458,8 +458,14 @@ func createChangefeedJobRecord(
 		return nil, err
 	}
 
+	// The postgres sink replicates each table into the table of the same schema
+	// and name, which it finds from the table's statement time name.
+	fullTableName := opts.ShouldUseFullStatementTimeName()
+	if u, err := url.Parse(sinkURI); err == nil && isPostgresSink(u) {
+		fullTableName = true
+	}
 	targets, tables, err := getTargetsAndTables(ctx, p, targetDescs, changefeedStmt.Targets,
-		changefeedStmt.originalSpecs, opts.ShouldUseFullStatementTimeName(), sinkURI)
+		changefeedStmt.originalSpecs, fullTableName, sinkURI)
 
 	if err != nil {
 		return nil, err
@@ -913,6 +919,12 @@ func validateSink(
 	if err := canarySink.Close(); err != nil {
 		return err
 	}
+	if _, ok := canarySink.(*icebergCloudStorageSink); ok &&
+		!opts.IsSet(changefeedbase.OptResolvedTimestamps) {
+		return errors.Newf("%s=%s requires the %s option because tables are committed "+
+			"when resolved timestamps are emitted", changefeedbase.SinkParamTableFormat,
+			changefeedbase.SinkTableFormatIceberg, changefeedbase.OptResolvedTimestamps)
+	}
 	// If there's no projection we may need to force some options to ensure messages
 	// have enough information.
 	if details.Select == `` {
@@ -921,7 +933,7 @@ func validateSink(
 				return err
 			}
 		}
-		if requiresTopicInValue(canarySink) {
+		if requiresTopicInValue(canarySink, opts) {
 			if err = opts.ForceTopicInValue(); err != nil {
 				return err
 			}
@@ -953,8 +965,16 @@ func requiresKeyInValue(s Sink) bool {
 	}
 }
 
-func requiresTopicInValue(s Sink) bool {
-	return s.getConcreteType() == sinkTypeWebhook
+func requiresTopicInValue(s Sink, opts changefeedbase.StatementOptions) bool {
+	switch s.getConcreteType() {
+	case sinkTypeWebhook:
+		return true
+	case sinkTypeCloudstorage:
+		// Commit-grouped files hold rows from all of the watched tables.
+		return opts.IsSet(changefeedbase.OptCommitGrouping)
+	default:
+		return false
+	}
 }
 
 func changefeedJobDescription(
@@ -1187,6 +1207,13 @@ func (b *changefeedResumer) handleChangefeedError(
 	if errErr != nil {
 		return errors.CombineErrors(changefeedErr, errErr)
 	}
+	pauseReason := fmt.Sprintf("%s=%s", changefeedbase.OptOnError, changefeedbase.OptOnErrorPause)
+	if changefeedbase.IsPauseRequestedError(changefeedErr) {
+		// The error asks for the changefeed to be paused so that it can be
+		// resumed once its cause has been addressed.
+		onError = changefeedbase.OptOnErrorPause
+		pauseReason = "an error requiring operator intervention"
+	}
 	switch onError {
 	// default behavior
 	case changefeedbase.OptOnErrorFail:
@@ -1196,14 +1223,13 @@ func (b *changefeedResumer) handleChangefeedError(
 		// note: we only want the job to pause here if a failure happens, not a
 		// user-initiated cancellation. if the job has been canceled, the ctx
 		// will handle it and the pause will return an error.
-		const errorFmt = "job failed (%v) but is being paused because of %s=%s"
-		errorMessage := fmt.Sprintf(errorFmt, changefeedErr,
-			changefeedbase.OptOnError, changefeedbase.OptOnErrorPause)
+		const errorFmt = "job failed (%v) but is being paused because of %s"
+		errorMessage := fmt.Sprintf(errorFmt, changefeedErr, pauseReason)
 		return b.job.NoTxn().PauseRequestedWithFunc(ctx, func(ctx context.Context,
 			planHookState interface{}, txn isql.Txn, progress *jobspb.Progress) error {
 			// directly update running status to avoid the running/reverted job status check
 			progress.RunningStatus = errorMessage
-			log.Warningf(ctx, errorFmt, changefeedErr, changefeedbase.OptOnError, changefeedbase.OptOnErrorPause)
+			log.Warningf(ctx, errorFmt, changefeedErr, pauseReason)
 			return nil
 		}, errorMessage)
 	default:
@@ -76,6 +76,12 @@ func (e *terminalError) Error() string {
 	return "terminal changefeed error"
 }
 
+type pauseRequestedError struct{}
+
+func (e *pauseRequestedError) Error() string {
+	return "changefeed pause requested"
+}
+
 // TODO(yevgeniy): retryableError and all machinery related
 // to MarkRetryableError maybe removed once 23.1 is released.
 type retryableError struct{}
@@ -93,6 +99,22 @@ func WithTerminalError(cause error) error {
 	return errors.Mark(cause, &terminalError{})
 }
 
+// WithPauseRequestedError decorates underlying error to indicate that the
+// changefeed should be paused, rather than failed, regardless of its on_error
+// option. The error is also a terminal changefeed error.
+func WithPauseRequestedError(cause error) error {
+	if cause == nil {
+		return nil
+	}
+	return WithTerminalError(errors.Mark(cause, &pauseRequestedError{}))
+}
+
+// IsPauseRequestedError returns true if the error indicates that the
+// changefeed should be paused.
+func IsPauseRequestedError(err error) bool {
+	return errors.Is(err, &pauseRequestedError{})
+}
+
 // MarkRetryableError wraps the given error, marking it as retryable.s
 func MarkRetryableError(cause error) error {
 	if cause == nil {
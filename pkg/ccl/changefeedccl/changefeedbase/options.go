@@ -100,6 +100,8 @@ const (
 	OptUnordered               = `unordered`
 	OptVirtualColumns          = `virtual_columns`
 	OptExecutionLocality       = `execution_locality`
+	OptCommitGroupInfo         = `commit_group_info`
+	OptCommitGrouping          = `commit_grouping`
 
 	OptVirtualColumnsOmitted VirtualColumnVisibility = `omitted`
 	OptVirtualColumnsNull    VirtualColumnVisibility = `null`
@@ -125,6 +127,15 @@ const (
 	// OptSchemaChangePolicyIgnore indicates that all schema change events should
 	// be ignored.
 	OptSchemaChangePolicyIgnore SchemaChangePolicy = `ignore`
+	// OptSchemaChangePolicyPause is like OptSchemaChangePolicyStop, except that
+	// the changefeed is paused rather than failed, so that it can be resumed
+	// once consumers are ready for the new schema.
+	OptSchemaChangePolicyPause SchemaChangePolicy = `pause`
+	// OptSchemaChangePolicyVersionedSubject is like
+	// OptSchemaChangePolicyBackfill, except that an Avro schema which is
+	// incompatible with the schema last registered for its subject is
+	// registered under a new subject which includes the table version.
+	OptSchemaChangePolicyVersionedSubject SchemaChangePolicy = `versioned_subject`
 
 	// OptInitialScan enables an initial scan. This is the default when no
 	// cursor is specified, leading to an initial scan at the statement time of
@@ -180,6 +191,7 @@ const (
 	SinkParamFileSize               = `file_size`
 	SinkParamPartitionFormat        = `partition_format`
 	SinkParamSchemaTopic            = `schema_topic`
+	SinkParamTableFormat            = `table_format`
 	SinkParamTLSEnabled             = `tls_enabled`
 	SinkParamSkipTLSVerify          = `insecure_tls_skip_verify`
 	SinkParamTopicPrefix            = `topic_prefix`
@@ -195,6 +207,8 @@ const (
 	SinkSchemeHTTPS                 = `https`
 	SinkSchemeKafka                 = `kafka`
 	SinkSchemeNull                  = `null`
+	SinkSchemePostgres              = `postgres`
+	SinkSchemePostgresql            = `postgresql`
 	SinkSchemeWebhookHTTP           = `webhook-http`
 	SinkSchemeWebhookHTTPS          = `webhook-https`
 	SinkSchemeExternalConnection    = `external`
@@ -209,6 +223,10 @@ const (
 	SinkParamSASLScopes             = `sasl_scopes`
 	SinkParamSASLGrantType          = `sasl_grant_type`
 
+	// SinkTableFormatIceberg is the value of SinkParamTableFormat which makes
+	// a cloud storage sink maintain Apache Iceberg tables.
+	SinkTableFormatIceberg = `iceberg`
+
 	RegistryParamCACert     = `ca_cert`
 	RegistryParamClientCert = `client_cert`
 	RegistryParamClientKey  = `client_key`
@@ -328,7 +346,7 @@ var ChangefeedOptionExpectValues = map[string]OptionPermittedValues{
 	OptDiff:                               flagOption,
 	OptCompression:                        enum("gzip", "zstd"),
 	OptSchemaChangeEvents:                 enum("column_changes", "default"),
-	OptSchemaChangePolicy:                 enum("backfill", "nobackfill", "stop", "ignore"),
+	OptSchemaChangePolicy:                 enum("backfill", "nobackfill", "stop", "ignore", "pause", "versioned_subject"),
 	OptSplitColumnFamilies:                flagOption,
 	OptInitialScan:                        enum("yes", "no", "only").orEmptyMeans("yes"),
 	OptNoInitialScan:                      flagOption,
@@ -345,6 +363,8 @@ var ChangefeedOptionExpectValues = map[string]OptionPermittedValues{
 	OptUnordered:                          flagOption,
 	OptVirtualColumns:                     enum("omitted", "null"),
 	OptExecutionLocality:                  stringOption,
+	OptCommitGroupInfo:                    flagOption,
+	OptCommitGrouping:                     flagOption,
 }
 
 // CommonOptions is options common to all sinks
@@ -357,20 +377,24 @@ var CommonOptions = makeStringSet(OptCursor, OptEndTime, OptEnvelope,
 	OptOnError,
 	OptInitialScan, OptNoInitialScan, OptInitialScanOnly, OptUnordered, OptCustomKeyColumn,
 	OptMinCheckpointFrequency, OptMetricsScope, OptVirtualColumns, Topics, OptExpirePTSAfter,
-	OptExecutionLocality,
+	OptExecutionLocality, OptCommitGroupInfo,
 )
 
 // SQLValidOptions is options exclusive to SQL sink
 var SQLValidOptions map[string]struct{} = nil
 
+// PostgresValidOptions is options exclusive to the postgres sink
+var PostgresValidOptions map[string]struct{} = nil
+
 // KafkaValidOptions is options exclusive to Kafka sink
 var KafkaValidOptions = makeStringSet(OptAvroSchemaPrefix, OptConfluentSchemaRegistry, OptKafkaSinkConfig)
 
 // CloudStorageValidOptions is options exclusive to cloud storage sink
-var CloudStorageValidOptions = makeStringSet(OptCompression)
+var CloudStorageValidOptions = makeStringSet(OptCompression, OptCommitGrouping)
 
 // WebhookValidOptions is options exclusive to webhook sink
-var WebhookValidOptions = makeStringSet(OptWebhookAuthHeader, OptWebhookClientTimeout, OptWebhookSinkConfig)
+var WebhookValidOptions = makeStringSet(OptWebhookAuthHeader, OptWebhookClientTimeout, OptWebhookSinkConfig,
+	OptCommitGrouping)
 
 // PubsubValidOptions is options exclusive to pubsub sink
 var PubsubValidOptions = makeStringSet(OptPubsubSinkConfig)
@@ -381,7 +405,7 @@ var PubsubValidOptions = makeStringSet(OptPubsubSinkConfig)
 // TODO(adityamaru): Some of these options should be supported when creating the
 // external connection rather than when setting up the changefeed. Move them once
 // we support `CREATE EXTERNAL CONNECTION ... WITH <options>`.
-var ExternalConnectionValidOptions = unionStringSets(SQLValidOptions, KafkaValidOptions, CloudStorageValidOptions, WebhookValidOptions, PubsubValidOptions)
+var ExternalConnectionValidOptions = unionStringSets(SQLValidOptions, PostgresValidOptions, KafkaValidOptions, CloudStorageValidOptions, WebhookValidOptions, PubsubValidOptions)
 
 // CaseInsensitiveOpts options which supports case Insensitive value
 var CaseInsensitiveOpts = makeStringSet(OptFormat, OptEnvelope, OptCompression, OptSchemaChangeEvents,
@@ -434,7 +458,7 @@ type OptionsSet map[string]struct{}
 // InitialScanOnlyUnsupportedOptions is options that are not supported with the
 // initial scan only option
 var InitialScanOnlyUnsupportedOptions OptionsSet = makeStringSet(OptEndTime, OptResolvedTimestamps, OptDiff,
-	OptMVCCTimestamps, OptUpdatedTimestamps)
+	OptMVCCTimestamps, OptUpdatedTimestamps, OptCommitGroupInfo, OptCommitGrouping)
 
 // ParquetFormatUnsupportedOptions is options that are not supported with the
 // parquet format.
@@ -498,10 +522,12 @@ func makeDirectedInvertedIndex(pairs []dependentOption) map[string][]dependentOp
 
 var incompatibleOptionsMap = makeInvertedIndex([]incompatibleOptions{
 	{opt1: OptUnordered, opt2: OptResolvedTimestamps, reason: `resolved timestamps cannot be guaranteed to be correct in unordered mode`},
+	{opt1: OptUnordered, opt2: OptCommitGroupInfo, reason: `commit groups are assembled in commit timestamp order`},
 })
 
 var dependentOptionsMap = makeDirectedInvertedIndex([]dependentOption{
 	{opt1: OptCustomKeyColumn, opt2: OptUnordered, reason: `using a value other than the primary key as the message key means end-to-end ordering cannot be preserved`},
+	{opt1: OptCommitGrouping, opt2: OptCommitGroupInfo, reason: `consumers need the commit group metadata to tell the groups in a batch apart`},
 })
 
 // MakeStatementOptions wraps and canonicalizes the options we get
@@ -752,6 +778,21 @@ type EncodingOptions struct {
 	SchemaRegistryURI string
 	Compression       string
 	CustomKeyColumn   string
+	// SchemaChangePolicy determines whether the avro encoder checks each new
+	// schema against the schema registry before registering it, and what
+	// happens to schemas the registry considers incompatible. See
+	// SchemaChangePolicy.ChecksSchemaCompatibility.
+	SchemaChangePolicy SchemaChangePolicy
+	// CommitGroupInfo tags every event written by a transaction with its
+	// commit group: the commit timestamp shared by all of the events which
+	// committed at that timestamp, the event's sequence number within the group
+	// and the total number of events in the group. A commit group holds whole
+	// transactions, but transactions which commit at the same timestamp share
+	// a group.
+	CommitGroupInfo bool
+	// CommitGrouping makes sinks which support it emit all of the events of a
+	// commit group as a single batch.
+	CommitGrouping bool
 }
 
 // GetEncodingOptions populates and validates an EncodingOptions.
@@ -793,8 +834,15 @@ func (s StatementOptions) GetEncodingOptions() (EncodingOptions, error) {
 	_, o.UpdatedTimestamps = s.m[OptUpdatedTimestamps]
 	_, o.MVCCTimestamps = s.m[OptMVCCTimestamps]
 	_, o.Diff = s.m[OptDiff]
+	_, o.CommitGroupInfo = s.m[OptCommitGroupInfo]
+	_, o.CommitGrouping = s.m[OptCommitGrouping]
 
 	o.SchemaRegistryURI = s.m[OptConfluentSchemaRegistry]
+	schemaChange, err := s.GetSchemaChangeHandlingOptions()
+	if err != nil {
+		return o, err
+	}
+	o.SchemaChangePolicy = schemaChange.Policy
 	o.AvroSchemaPrefix = s.m[OptAvroSchemaPrefix]
 	o.Compression = s.m[OptCompression]
 	o.CustomKeyColumn = s.m[OptCustomKeyColumn]
@@ -810,6 +858,17 @@ func (e EncodingOptions) Validate() error {
 			OptEnvelope, OptEnvelopeRow, OptFormat, OptFormatAvro,
 		)
 	}
+	if e.SchemaChangePolicy == OptSchemaChangePolicyVersionedSubject && e.Format != OptFormatAvro {
+		return errors.Errorf(`%s=%s is only usable with %s=%s`,
+			OptSchemaChangePolicy, OptSchemaChangePolicyVersionedSubject, OptFormat, OptFormatAvro)
+	}
+	if (e.CommitGroupInfo || e.CommitGrouping) && e.Format != OptFormatJSON {
+		opt := OptCommitGroupInfo
+		if e.CommitGrouping {
+			opt = OptCommitGrouping
+		}
+		return errors.Errorf(`%s is only usable with %s=%s`, opt, OptFormat, OptFormatJSON)
+	}
 	if e.Envelope != OptEnvelopeWrapped && e.Format != OptFormatJSON && e.Format != OptFormatParquet {
 		requiresWrap := []struct {
 			k string
@@ -831,6 +890,21 @@ func (e EncodingOptions) Validate() error {
 	return nil
 }
 
+// StopsOnSchemaChange returns whether the policy stops the changefeed when a
+// schema change event occurs, by either failing or pausing it.
+func (p SchemaChangePolicy) StopsOnSchemaChange() bool {
+	return p == OptSchemaChangePolicyStop || p == OptSchemaChangePolicyPause
+}
+
+// ChecksSchemaCompatibility returns whether the policy requires each new Avro
+// schema to be checked against the schema last registered for its subject.
+// Incompatible schemas then fail or pause the changefeed, or are registered
+// under a versioned subject, according to the policy.
+func (p SchemaChangePolicy) ChecksSchemaCompatibility() bool {
+	return p == OptSchemaChangePolicyStop || p == OptSchemaChangePolicyPause ||
+		p == OptSchemaChangePolicyVersionedSubject
+}
+
 // SchemaChangeHandlingOptions specify how the feed should
 // behave when a target is affected by a schema change.
 type SchemaChangeHandlingOptions struct {
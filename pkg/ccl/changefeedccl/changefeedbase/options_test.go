@@ -40,6 +40,10 @@ func TestOptionsValidations(t *testing.T) {
 		{map[string]string{"initial_scan_only": "", "resolved": ""}, true, "cannot specify both initial_scan='only'"},
 		{map[string]string{"initial_scan_only": "", "resolved": ""}, true, "cannot specify both initial_scan='only'"},
 		{map[string]string{"key_column": "b"}, false, "requires the unordered option"},
+		{map[string]string{"commit_grouping": ""}, false, "requires the commit_group_info option"},
+		{map[string]string{"commit_group_info": "", "unordered": ""}, false, "not usable with"},
+		{map[string]string{"initial_scan_only": "", "commit_group_info": ""}, false, "cannot specify both initial_scan='only'"},
+		{map[string]string{"commit_group_info": "", "commit_grouping": ""}, false, ""},
 	}
 
 	for _, test := range tests {
@@ -53,3 +57,24 @@ func TestOptionsValidations(t *testing.T) {
 		}
 	}
 }
+
+func TestCommitGroupOptionsRequireJSON(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	o := MakeStatementOptions(map[string]string{"commit_group_info": ""})
+	encodingOpts, err := o.GetEncodingOptions()
+	require.NoError(t, err)
+	require.True(t, encodingOpts.CommitGroupInfo)
+	require.False(t, encodingOpts.CommitGrouping)
+
+	o = MakeStatementOptions(map[string]string{"commit_group_info": "", "format": "avro"})
+	_, err = o.GetEncodingOptions()
+	require.ErrorContains(t, err, "commit_group_info is only usable with format=json")
+
+	o = MakeStatementOptions(map[string]string{
+		"commit_group_info": "", "commit_grouping": "", "format": "parquet",
+	})
+	_, err = o.GetEncodingOptions()
+	require.ErrorContains(t, err, "commit_grouping is only usable with format=json")
+}
@@ -51,6 +51,38 @@ var PerChangefeedMemLimit = settings.RegisterByteSizeSetting(
 	1<<29, // 512MiB
 )
 
+// CommitGroupBufferMemLimit controls how much data a changefeed using
+// commit_group_info may hold while waiting for the resolved timestamp to
+// pass the commit groups it has seen.
+var CommitGroupBufferMemLimit = settings.RegisterByteSizeSetting(
+	settings.TenantWritable,
+	"changefeed.memory.commit_group_buffer_limit",
+	"controls amount of data that can be buffered per changefeed while assembling commit groups",
+	1<<28, // 256MiB
+)
+
+// CommitGroupInfoMaxRanges limits the number of ranges watched by a changefeed
+// using commit_group_info. All of them are watched by a single aggregator, so
+// the feed doesn't scale with the size of the cluster.
+var CommitGroupInfoMaxRanges = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"changefeed.commit_group_info.max_ranges",
+	"the maximum number of ranges watched by a changefeed using commit_group_info, "+
+		"all of which are watched by a single node; 0 disables the limit",
+	10000,
+	settings.NonNegativeInt,
+)
+
+// PostgresSinkBufferLimit controls how much data a changefeed using the
+// postgres sink may hold while waiting for the frontier to pass the rows it
+// has seen.
+var PostgresSinkBufferLimit = settings.RegisterByteSizeSetting(
+	settings.TenantWritable,
+	"changefeed.memory.postgres_sink_buffer_limit",
+	"controls amount of data that can be buffered per changefeed by the postgres sink before it is applied",
+	1<<28, // 256MiB
+)
+
 // SlowSpanLogThreshold controls when we will log slow spans.
 var SlowSpanLogThreshold = settings.RegisterDurationSetting(
 	settings.TenantWritable,
@@ -314,3 +346,14 @@ var SinkPacerRequestSize = settings.RegisterDurationSetting(
 	50*time.Millisecond,
 	settings.PositiveDuration,
 )
+
+// PushDownRangefeedFilters controls whether changefeeds with a CDC query push
+// the filter and projection of the query down to the rangefeeds, so that KV
+// does not send the values that the query discards.
+var PushDownRangefeedFilters = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"changefeed.push_down_rangefeed_filters.enabled",
+	"if enabled, changefeeds with a CDC query ask KV to filter and project the values "+
+		"of their rangefeeds according to the query, to reduce the data sent to the changefeed",
+	true,
+)
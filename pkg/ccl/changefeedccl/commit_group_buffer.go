@@ -0,0 +1,176 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// commitGroupInfo describes the commit group of an event when the
+// commit_group_info option is set. A commit group holds all of the events
+// which committed at the same MVCC timestamp.
+//
+// Events are grouped by commit timestamp rather than tagged with the ID of
+// the transaction which wrote them: rangefeeds don't surface transaction IDs,
+// since values written by one phase commit transactions and values read by
+// catch-up scans carry nothing but their MVCC timestamp, and resolved intents
+// don't retain the ID of their transaction. Every value committed by a
+// transaction does, however, share the transaction's commit timestamp, so a
+// commit group always holds whole transactions. Two transactions which wrote
+// disjoint keys may commit at the same timestamp, in which case they are
+// reported in the same commit group; since neither transaction observed the
+// other, applying them as one atomic unit preserves the consistency consumers
+// rely on.
+type commitGroupInfo struct {
+	// commitTS is the commit timestamp of the group, in the same format as the
+	// `updated` and `mvcc_timestamp` fields.
+	commitTS string
+	// seq is the 1-based position of the event among the events of the group
+	// on the watched tables.
+	seq int
+	// count is the total number of events of the group on the watched tables.
+	count int
+}
+
+// asJSON returns the representation of the commit group metadata used by the
+// JSON encoder. Events which are not attributed to a commit group, such as
+// those produced by initial scans and schema change backfills, encode as null.
+func (t *commitGroupInfo) asJSON() json.JSON {
+	if t == nil {
+		return json.NullJSONValue
+	}
+	b := json.NewObjectBuilder(3)
+	b.Add("commit_timestamp", json.FromString(t.commitTS))
+	b.Add("seq", json.FromInt(t.seq))
+	b.Add("count", json.FromInt(t.count))
+	return b.Build()
+}
+
+// bufferedRow is a decoded row held by commitGroupBuffer.
+type bufferedRow struct {
+	key              string
+	updated, prev    cdcevent.Row
+	schemaTS         hlc.Timestamp
+	approximateBytes int64
+}
+
+// bufferedCommitGroup holds the rows which committed at a single timestamp.
+type bufferedCommitGroup struct {
+	commitTS hlc.Timestamp
+	rows     []bufferedRow
+	// seen tracks the keys already buffered for this group. Rangefeeds may
+	// deliver the same value more than once; a key has at most one version at
+	// a given timestamp, so repeats are dropped.
+	seen map[string]struct{}
+}
+
+// commitGroupBuffer holds the rows of commit groups until the local resolved
+// timestamp frontier passes their commit timestamp. Once that happens, no
+// further rows for the group can arrive, so its row count is known and it can
+// be emitted as a whole.
+//
+// The memory held by the buffer is accounted separately from the kvfeed
+// memory pool: the kvfeed must keep delivering resolved timestamps in order
+// for buffered groups to be released, and resolved events acquire
+// memory from the same pool as the KV events buffered here.
+type commitGroupBuffer struct {
+	limit  int64
+	bytes  int64
+	groups map[hlc.Timestamp]*bufferedCommitGroup
+}
+
+func newCommitGroupBuffer(limit int64) *commitGroupBuffer {
+	return &commitGroupBuffer{
+		limit:  limit,
+		groups: make(map[hlc.Timestamp]*bufferedCommitGroup),
+	}
+}
+
+// add buffers a row written at commitTS. The rows are retained by the buffer
+// and must not be reused by the caller.
+func (b *commitGroupBuffer) add(
+	key roachpb.Key, updated, prev cdcevent.Row, commitTS, schemaTS hlc.Timestamp, size int64,
+) error {
+	group, ok := b.groups[commitTS]
+	if ok {
+		if _, dup := group.seen[string(key)]; dup {
+			return nil
+		}
+	}
+	if b.bytes+size > b.limit {
+		return errors.WithHintf(
+			errors.Newf("commit group buffer exceeded its limit of %s while waiting for the resolved timestamp to advance past %s",
+				humanizeutil.IBytes(b.limit), b.earliest()),
+			"consider increasing the %s cluster setting", changefeedbase.CommitGroupBufferMemLimit.Key())
+	}
+	if !ok {
+		group = &bufferedCommitGroup{commitTS: commitTS, seen: make(map[string]struct{})}
+		b.groups[commitTS] = group
+	}
+	group.seen[string(key)] = struct{}{}
+	group.rows = append(group.rows, bufferedRow{
+		key:              string(key),
+		updated:          updated,
+		prev:             prev,
+		schemaTS:         schemaTS,
+		approximateBytes: size,
+	})
+	b.bytes += size
+	return nil
+}
+
+// take removes and returns the commit groups at or below the frontier, ordered
+// by commit timestamp. The rows of each group are ordered by key so that
+// sequence numbers are deterministic.
+func (b *commitGroupBuffer) take(frontier hlc.Timestamp) []*bufferedCommitGroup {
+	var ready []*bufferedCommitGroup
+	for ts, group := range b.groups {
+		if !ts.LessEq(frontier) {
+			continue
+		}
+		delete(b.groups, ts)
+		for _, r := range group.rows {
+			b.bytes -= r.approximateBytes
+		}
+		sort.Slice(group.rows, func(i, j int) bool { return group.rows[i].key < group.rows[j].key })
+		ready = append(ready, group)
+	}
+	sort.Slice(ready, func(i, j int) bool { return ready[i].commitTS.Less(ready[j].commitTS) })
+	return ready
+}
+
+// earliest returns the lowest commit timestamp of any buffered group,
+// or the empty timestamp if the buffer is empty.
+func (b *commitGroupBuffer) earliest() hlc.Timestamp {
+	var earliest hlc.Timestamp
+	for ts := range b.groups {
+		if earliest.IsEmpty() || ts.Less(earliest) {
+			earliest = ts
+		}
+	}
+	return earliest
+}
+
+// checkpointTimestamp bounds a span's resolved timestamp so that it may be
+// checkpointed: the rows of buffered groups have not yet been emitted,
+// so no span may be reported as resolved at or above their commit timestamp.
+func (b *commitGroupBuffer) checkpointTimestamp(ts hlc.Timestamp) hlc.Timestamp {
+	if earliest := b.earliest(); !earliest.IsEmpty() && earliest.LessEq(ts) {
+		return earliest.Prev()
+	}
+	return ts
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func makeCommitGroupTestRow(k int64) cdcevent.Row {
+	return cdcevent.TestingMakeEventRowFromEncDatums(
+		rowenc.EncDatumRow{rowenc.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(k)))},
+		[]*types.T{types.Int}, 1 /* numKeyCols */, false /* deleted */)
+}
+
+func TestCommitGroupBuffer(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	add := func(b *commitGroupBuffer, key string, commit int64) error {
+		return b.add(roachpb.Key(key), makeCommitGroupTestRow(commit), cdcevent.Row{}, ts(commit), ts(commit), 10)
+	}
+
+	b := newCommitGroupBuffer(100)
+	require.NoError(t, add(b, "b", 20))
+	require.NoError(t, add(b, "a", 20))
+	require.NoError(t, add(b, "c", 10))
+	require.NoError(t, add(b, "d", 30))
+	// Duplicates delivered by the rangefeed are dropped.
+	require.NoError(t, add(b, "a", 20))
+	require.EqualValues(t, 40, b.bytes)
+
+	// Spans cannot be checkpointed at or above a buffered commit group.
+	require.Equal(t, ts(9), b.checkpointTimestamp(ts(10)))
+	require.Equal(t, ts(9), b.checkpointTimestamp(ts(25)))
+	require.Equal(t, ts(5), b.checkpointTimestamp(ts(5)))
+
+	groups := b.take(ts(25))
+	require.Len(t, groups, 2)
+	require.Equal(t, ts(10), groups[0].commitTS)
+	require.Len(t, groups[0].rows, 1)
+	require.Equal(t, ts(20), groups[1].commitTS)
+	require.Equal(t, "a", groups[1].rows[0].key)
+	require.Equal(t, "b", groups[1].rows[1].key)
+	require.EqualValues(t, 10, b.bytes)
+	require.Equal(t, ts(29), b.checkpointTimestamp(ts(40)))
+
+	require.Empty(t, b.take(ts(25)))
+	require.Len(t, b.take(ts(30)), 1)
+	require.Equal(t, ts(40), b.checkpointTimestamp(ts(40)))
+
+	// Exceeding the limit is an error which points at the setting.
+	small := newCommitGroupBuffer(15)
+	require.NoError(t, add(small, "a", 10))
+	err := add(small, "b", 10)
+	require.ErrorContains(t, err, "commit group buffer exceeded its limit")
+}
+
+func TestJSONEncoderCommitGroupInfo(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	row := makeCommitGroupTestRow(1)
+	group := &commitGroupInfo{commitTS: "10.0000000000", seq: 2, count: 3}
+
+	for _, tc := range []struct {
+		envelope    changefeedbase.EnvelopeType
+		commitGroup *commitGroupInfo
+		expected    string
+	}{
+		{
+			envelope:    changefeedbase.OptEnvelopeWrapped,
+			commitGroup: group,
+			expected:    `{"after": {"col_int": 1}, "commit_group": {"commit_timestamp": "10.0000000000", "count": 3, "seq": 2}}`,
+		},
+		{
+			envelope: changefeedbase.OptEnvelopeWrapped,
+			expected: `{"after": {"col_int": 1}, "commit_group": null}`,
+		},
+		{
+			envelope:    changefeedbase.OptEnvelopeBare,
+			commitGroup: group,
+			expected:    `{"__crdb__": {"commit_group": {"commit_timestamp": "10.0000000000", "count": 3, "seq": 2}}, "col_int": 1}`,
+		},
+	} {
+		t.Run(string(tc.envelope), func(t *testing.T) {
+			e, err := makeJSONEncoder(jsonEncoderOptions{
+				EncodingOptions: changefeedbase.EncodingOptions{
+					Format:          changefeedbase.OptFormatJSON,
+					Envelope:        tc.envelope,
+					CommitGroupInfo: true,
+				},
+			})
+			require.NoError(t, err)
+			value, err := e.EncodeValue(context.Background(), eventContext{commitGroup: tc.commitGroup}, row, cdcevent.Row{})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(value))
+		})
+	}
+}
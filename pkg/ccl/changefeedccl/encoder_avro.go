@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/util/cache"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
 )
 
@@ -38,6 +39,7 @@ type confluentAvroEncoder struct {
 	targets                   changefeedbase.Targets
 	envelopeType              changefeedbase.EnvelopeType
 	customKeyColumn           string
+	schemaChangePolicy        changefeedbase.SchemaChangePolicy
 
 	keyCache   *cache.UnorderedCache // [tableIDAndVersion]confluentRegisteredKeySchema
 	valueCache *cache.UnorderedCache // [tableIDAndVersionPair]confluentRegisteredEnvelopeSchema
@@ -85,6 +87,7 @@ func newConfluentAvroEncoder(
 		targets:                 targets,
 		virtualColumnVisibility: opts.VirtualColumns,
 		envelopeType:            opts.Envelope,
+		schemaChangePolicy:      opts.SchemaChangePolicy,
 	}
 
 	e.updatedField = opts.UpdatedTimestamps
@@ -172,10 +175,8 @@ func (e *confluentAvroEncoder) EncodeKey(ctx context.Context, row cdcevent.Row)
 			}
 		}
 
-		// NB: This uses the kafka name escaper because it has to match the name
-		// of the kafka topic.
-		subject := SQLNameToKafkaName(tableName) + confluentSubjectSuffixKey
-		registered.registryID, err = e.register(ctx, &registered.schema.avroRecord, subject)
+		registered.registryID, err = e.registerTableSchema(
+			ctx, &registered.schema.avroRecord, tableName, confluentSubjectSuffixKey, row.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -267,10 +268,8 @@ func (e *confluentAvroEncoder) EncodeValue(
 			return nil, err
 		}
 
-		// NB: This uses the kafka name escaper because it has to match the name
-		// of the kafka topic.
-		subject := SQLNameToKafkaName(name) + confluentSubjectSuffixValue
-		registered.registryID, err = e.register(ctx, &registered.schema.avroRecord, subject)
+		registered.registryID, err = e.registerTableSchema(
+			ctx, &registered.schema.avroRecord, name, confluentSubjectSuffixValue, updatedRow.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -337,3 +336,47 @@ func (e *confluentAvroEncoder) register(
 ) (int32, error) {
 	return e.schemaRegistry.RegisterSchemaForSubject(ctx, subject, schema.codec.Schema())
 }
+
+// registerTableSchema registers the schema of a table's keys or values,
+// depending on the subject suffix. If the schema change policy asks for it, the
+// schema is first checked against the schema last registered for the subject
+// and the policy decides what happens to incompatible schemas, so that
+// consumers never see an event with such a schema unless they opted into it.
+func (e *confluentAvroEncoder) registerTableSchema(
+	ctx context.Context,
+	schema *avroRecord,
+	tableName string,
+	suffix string,
+	version descpb.DescriptorVersion,
+) (int32, error) {
+	// NB: This uses the kafka name escaper because it has to match the name
+	// of the kafka topic.
+	subject := SQLNameToKafkaName(tableName) + suffix
+	if !e.schemaChangePolicy.ChecksSchemaCompatibility() {
+		return e.register(ctx, schema, subject)
+	}
+
+	compatible, reason, err := e.schemaRegistry.CheckSchemaCompatibility(ctx, subject, schema.codec.Schema())
+	if err != nil {
+		return 0, err
+	}
+	if compatible {
+		return e.register(ctx, schema, subject)
+	}
+
+	err = errors.Newf(`schema of %s version %d is incompatible with the schema registered for subject %s: %s`,
+		tableName, version, subject, reason)
+	switch e.schemaChangePolicy {
+	case changefeedbase.OptSchemaChangePolicyStop:
+		return 0, changefeedbase.WithTerminalError(err)
+	case changefeedbase.OptSchemaChangePolicyPause:
+		return 0, changefeedbase.WithPauseRequestedError(err)
+	case changefeedbase.OptSchemaChangePolicyVersionedSubject:
+		versionedSubject := fmt.Sprintf(`%s-v%d%s`, SQLNameToKafkaName(tableName), version, suffix)
+		log.Warningf(ctx, "%v; registering it for subject %s instead", err, versionedSubject)
+		return e.register(ctx, schema, versionedSubject)
+	default:
+		return 0, errors.AssertionFailedf(`unknown %s %q`,
+			changefeedbase.OptSchemaChangePolicy, e.schemaChangePolicy)
+	}
+}
@@ -36,8 +36,8 @@ import (
 // to its value. Updated timestamps in rows and resolved timestamp payloads are
 // stored in a sub-object under the `__crdb__` key in the top-level JSON object.
 type jsonEncoder struct {
-	updatedField, mvccTimestampField, beforeField, keyInValue, topicInValue bool
-	envelopeType                                                            changefeedbase.EnvelopeType
+	updatedField, mvccTimestampField, beforeField, keyInValue, topicInValue, commitGroupField bool
+	envelopeType                                                                              changefeedbase.EnvelopeType
 
 	buf             bytes.Buffer
 	versionEncoder  func(ed *cdcevent.EventDescriptor, isPrev bool) *versionEncoder
@@ -84,9 +84,10 @@ func makeJSONEncoder(opts jsonEncoderOptions) (*jsonEncoder, error) {
 		customKeyColumn:    opts.CustomKeyColumn,
 		// In the bare envelope we don't output diff directly, it's incorporated into the
 		// projection as desired.
-		beforeField:  opts.Diff && opts.Envelope != changefeedbase.OptEnvelopeBare,
-		keyInValue:   opts.KeyInValue,
-		topicInValue: opts.TopicInValue,
+		beforeField:      opts.Diff && opts.Envelope != changefeedbase.OptEnvelopeBare,
+		keyInValue:       opts.KeyInValue,
+		topicInValue:     opts.TopicInValue,
+		commitGroupField: opts.CommitGroupInfo,
 		versionEncoder: func(ed *cdcevent.EventDescriptor, isPrev bool) *versionEncoder {
 			key := jsonEncoderVersionKey{
 				CacheKey: cdcevent.CacheKey{
@@ -242,6 +243,9 @@ func (e *jsonEncoder) initRawEnvelope() error {
 	if e.topicInValue {
 		metaKeys = append(metaKeys, "topic")
 	}
+	if e.commitGroupField {
+		metaKeys = append(metaKeys, "commit_group")
+	}
 
 	// Setup builder for crdb meta if needed.
 	var metaBuilder *json.FixedKeysObjectBuilder
@@ -284,6 +288,12 @@ func (e *jsonEncoder) initRawEnvelope() error {
 			}
 		}
 
+		if e.commitGroupField {
+			if err := metaBuilder.Set("commit_group", evCtx.commitGroup.asJSON()); err != nil {
+				return nil, err
+			}
+		}
+
 		meta, err := metaBuilder.Build()
 		if err != nil {
 			return nil, err
@@ -310,6 +320,9 @@ func (e *jsonEncoder) initWrappedEnvelope() error {
 	if e.mvccTimestampField {
 		keys = append(keys, "mvcc_timestamp")
 	}
+	if e.commitGroupField {
+		keys = append(keys, "commit_group")
+	}
 	b, err := json.NewFixedKeysObjectBuilder(keys)
 	if err != nil {
 		return err
@@ -366,6 +379,12 @@ func (e *jsonEncoder) initWrappedEnvelope() error {
 			}
 		}
 
+		if e.commitGroupField {
+			if err := b.Set("commit_group", evCtx.commitGroup.asJSON()); err != nil {
+				return nil, err
+			}
+		}
+
 		return b.Build()
 	}
 	return nil
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net/url"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -439,6 +441,86 @@ func TestAvroEncoderWithTLS(t *testing.T) {
 	}
 }
 
+func TestAvroEncoderSchemaCompatibility(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	v1, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+	require.NoError(t, err)
+	v2, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b INT)`)
+	require.NoError(t, err)
+	v1.(*tabledesc.Mutable).Version = 1
+	v2.(*tabledesc.Mutable).Version = 2
+	rows := []cdcevent.Row{
+		cdcevent.TestingMakeEventRow(v1, 0, rowenc.EncDatumRow{
+			rowenc.EncDatum{Datum: tree.NewDInt(1)},
+			rowenc.EncDatum{Datum: tree.NewDString(`bar`)},
+		}, false),
+		cdcevent.TestingMakeEventRow(v2, 0, rowenc.EncDatumRow{
+			rowenc.EncDatum{Datum: tree.NewDInt(1)},
+			rowenc.EncDatum{Datum: tree.NewDInt(2)},
+		}, false),
+	}
+	targets := changefeedbase.Targets{}
+	targets.Add(changefeedbase.Target{
+		Type:              jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY,
+		TableID:           v1.GetID(),
+		StatementTimeName: changefeedbase.StatementTimeName(v1.GetName()),
+	})
+	evCtx := eventContext{updated: hlc.Timestamp{WallTime: 1}}
+
+	// Changing the type of b is not a backward compatible change of the value
+	// schema, but the key schema stays the same.
+	const incompatible = `schema of foo version 2 is incompatible with the schema ` +
+		`registered for subject foo-value: TYPE_MISMATCH: foo.b`
+	for _, tc := range []struct {
+		policy   changefeedbase.SchemaChangePolicy
+		expErr   string
+		subjects []string
+	}{
+		{policy: changefeedbase.OptSchemaChangePolicyBackfill, subjects: []string{`foo-key`, `foo-value`}},
+		{policy: changefeedbase.OptSchemaChangePolicyStop, expErr: incompatible},
+		{policy: changefeedbase.OptSchemaChangePolicyPause, expErr: incompatible},
+		{
+			policy:   changefeedbase.OptSchemaChangePolicyVersionedSubject,
+			subjects: []string{`foo-key`, `foo-v2-value`, `foo-value`},
+		},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			reg := cdctest.StartTestSchemaRegistry()
+			defer reg.Close()
+			reg.SetCompatibility(`BACKWARD`)
+
+			opts := changefeedbase.EncodingOptions{
+				Format:             changefeedbase.OptFormatAvro,
+				Envelope:           changefeedbase.OptEnvelopeWrapped,
+				SchemaRegistryURI:  reg.URL(),
+				SchemaChangePolicy: tc.policy,
+			}
+			e, err := getEncoder(opts, targets, false, nil, nil)
+			require.NoError(t, err)
+
+			for _, row := range rows {
+				_, err := e.EncodeKey(ctx, row)
+				require.NoError(t, err)
+				_, err = e.EncodeValue(ctx, evCtx, row, cdcevent.Row{})
+				if tc.expErr != `` && row.Version == 2 {
+					require.Regexp(t, tc.expErr, err)
+					require.Equal(t, tc.policy == changefeedbase.OptSchemaChangePolicyPause,
+						changefeedbase.IsPauseRequestedError(err))
+					require.Len(t, reg.Subjects(), 2)
+					return
+				}
+				require.NoError(t, err)
+			}
+			subjects := reg.Subjects()
+			sort.Strings(subjects)
+			require.Equal(t, tc.subjects, subjects)
+		})
+	}
+}
+
 func TestAvroArray(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -46,6 +46,9 @@ type eventContext struct {
 	updated, mvcc hlc.Timestamp
 	// topic is set to the string to be included if TopicInValue is true
 	topic string
+	// commitGroup is set when CommitGroupInfo is true and the event was
+	// written by a transaction, rather than read by a scan.
+	commitGroup *commitGroupInfo
 }
 
 type eventConsumer interface {
@@ -73,6 +76,11 @@ type kvEventToRowConsumer struct {
 
 	metrics *sliMetrics
 
+	// commitGroups, if set, buffers the rows of commit groups until the local
+	// frontier passes their commit timestamp. It is only set when the
+	// commit_group_info option is used.
+	commitGroups *commitGroupBuffer
+
 	// This pacer is used to incorporate event consumption to elastic CPU
 	// control. This helps ensure that event encoding/decoding does not throttle
 	// foreground SQL traffic.
@@ -158,11 +166,16 @@ func newEventConsumer(
 	// TODO (ganeshb) Add support for parallel encoding when using parquet.
 	// We cannot have a separate encoder and sink for parquet format (see
 	// parquet_sink_cloudstorage.go). Because of this the current nprox solution
-	// does not work for parquet format.
+	// does not work for parquet format. The same holds for the postgres sink,
+	// which is also handed decoded rows.
 	//
 	// TODO (jayshrivastava) enable parallel consumers for sinkless changefeeds.
+	//
+	// Commit groups are assembled by a single consumer since their rows may be
+	// sharded to any worker.
 	isSinkless := spec.JobID == 0
-	if numWorkers <= 1 || isSinkless || encodingOpts.Format == changefeedbase.OptFormatParquet {
+	if numWorkers <= 1 || isSinkless || emitsDecodedRows(sink, encodingOpts) ||
+		encodingOpts.CommitGroupInfo {
 		c, err := makeConsumer(sink, spanFrontier)
 		if err != nil {
 			return nil, nil, err
@@ -245,6 +258,12 @@ func newKVEventToRowConsumer(
 		return nil, err
 	}
 
+	var commitGroups *commitGroupBuffer
+	if encodingOpts.CommitGroupInfo {
+		commitGroups = newCommitGroupBuffer(
+			changefeedbase.CommitGroupBufferMemLimit.Get(&cfg.Settings.SV))
+	}
+
 	return &kvEventToRowConsumer{
 		frontier:             frontier,
 		encoder:              encoder,
@@ -258,6 +277,7 @@ func newKVEventToRowConsumer(
 		evaluator:            evaluator,
 		encodingOpts:         encodingOpts,
 		metrics:              metrics,
+		commitGroups:         commitGroups,
 		pacer:                pacer,
 	}, nil
 }
@@ -383,45 +403,110 @@ func (c *kvEventToRowConsumer) ConsumeEvent(ctx context.Context, ev kvevent.Even
 		}
 	}
 
-	return c.encodeAndEmit(ctx, updatedRow, prevRow, schemaTimestamp, ev.DetachAlloc())
-}
-
-func (c *kvEventToRowConsumer) encodeAndEmit(
-	ctx context.Context,
-	updatedRow cdcevent.Row,
-	prevRow cdcevent.Row,
-	schemaTS hlc.Timestamp,
-	alloc kvevent.Alloc,
-) error {
-	topic, err := c.topicForEvent(updatedRow.Metadata)
-	if err != nil {
-		return err
-	}
-
 	// Ensure that r updates are strictly newer than the least resolved timestamp
 	// being tracked by the local span frontier. The poller should not be forwarding
 	// r updates that have timestamps less than or equal to any resolved timestamp
 	// it's forwarded before.
 	// TODO(dan): This should be an assertion once we're confident this can never
 	// happen under any circumstance.
-	if schemaTS.LessEq(c.frontier.Frontier()) && !schemaTS.Equal(c.cursor) {
+	if schemaTimestamp.LessEq(c.frontier.Frontier()) && !schemaTimestamp.Equal(c.cursor) {
 		log.Errorf(ctx, "cdc ux violation: detected timestamp %s that is less than "+
-			"or equal to the local frontier %s.", schemaTS, c.frontier.Frontier())
+			"or equal to the local frontier %s.", schemaTimestamp, c.frontier.Frontier())
 		return nil
 	}
 
+	// Rows read by initial scans and backfills are reported at the scan
+	// timestamp rather than with the transaction which wrote them, so they are
+	// emitted right away, without commit group metadata.
+	if c.commitGroups != nil && ev.BackfillTimestamp().IsEmpty() {
+		return c.bufferCommitGroupRow(ctx, ev, updatedRow, prevRow, schemaTimestamp)
+	}
+
+	return c.encodeAndEmit(ctx, updatedRow, prevRow, schemaTimestamp, nil /* commitGroup */, ev.DetachAlloc())
+}
+
+// bufferCommitGroupRow holds on to a row until its commit group can be emitted
+// in its entirety.
+func (c *kvEventToRowConsumer) bufferCommitGroupRow(
+	ctx context.Context,
+	ev kvevent.Event,
+	updatedRow cdcevent.Row,
+	prevRow cdcevent.Row,
+	schemaTS hlc.Timestamp,
+) error {
+	if err := c.commitGroups.add(
+		ev.KV().Key, updatedRow.Copy(), prevRow.Copy(), ev.MVCCTimestamp(), schemaTS,
+		int64(ev.ApproximateSize()),
+	); err != nil {
+		return err
+	}
+	// The memory held by the row is now accounted for by the commit group
+	// buffer; see commitGroupBuffer.
+	a := ev.DetachAlloc()
+	a.Release(ctx)
+	return nil
+}
+
+// makeEventContext returns the metadata the encoder needs for a row.
+func (c *kvEventToRowConsumer) makeEventContext(
+	topic TopicDescriptor,
+	updatedRow cdcevent.Row,
+	schemaTS hlc.Timestamp,
+	commitGroup *commitGroupInfo,
+) (eventContext, error) {
 	evCtx := eventContext{
-		updated: schemaTS,
-		mvcc:    updatedRow.MvccTimestamp,
+		updated:     schemaTS,
+		mvcc:        updatedRow.MvccTimestamp,
+		commitGroup: commitGroup,
 	}
 
 	if c.topicNamer != nil {
 		topic, err := c.topicNamer.Name(topic)
 		if err != nil {
-			return err
+			return eventContext{}, err
 		}
 		evCtx.topic = topic
 	}
+	return evCtx, nil
+}
+
+// encodeRow encodes the key and value of a row. The returned slices remain
+// valid after subsequent calls.
+func (c *kvEventToRowConsumer) encodeRow(
+	ctx context.Context, evCtx eventContext, updatedRow cdcevent.Row, prevRow cdcevent.Row,
+) (keyCopy, valueCopy []byte, _ error) {
+	encodedKey, err := c.encoder.EncodeKey(ctx, updatedRow)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.scratch, keyCopy = c.scratch.Copy(encodedKey, 0 /* extraCap */)
+	// TODO(yevgeniy): Some refactoring is needed in the encoder: namely, prevRow
+	// might not be available at all when working with changefeed expressions.
+	encodedValue, err := c.encoder.EncodeValue(ctx, evCtx, updatedRow, prevRow)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.scratch, valueCopy = c.scratch.Copy(encodedValue, 0 /* extraCap */)
+	return keyCopy, valueCopy, nil
+}
+
+func (c *kvEventToRowConsumer) encodeAndEmit(
+	ctx context.Context,
+	updatedRow cdcevent.Row,
+	prevRow cdcevent.Row,
+	schemaTS hlc.Timestamp,
+	commitGroup *commitGroupInfo,
+	alloc kvevent.Alloc,
+) error {
+	topic, err := c.topicForEvent(updatedRow.Metadata)
+	if err != nil {
+		return err
+	}
+
+	evCtx, err := c.makeEventContext(topic, updatedRow, schemaTS, commitGroup)
+	if err != nil {
+		return err
+	}
 
 	if c.knobs.BeforeEmitRow != nil {
 		if err := c.knobs.BeforeEmitRow(ctx); err != nil {
@@ -429,25 +514,16 @@ func (c *kvEventToRowConsumer) encodeAndEmit(
 		}
 	}
 
-	if c.encodingOpts.Format == changefeedbase.OptFormatParquet {
-		return c.encodeForParquet(
+	if emitsDecodedRows(c.sink, c.encodingOpts) {
+		return c.emitDecodedRow(
 			ctx, updatedRow, prevRow, topic, schemaTS, updatedRow.MvccTimestamp,
 			c.encodingOpts, alloc,
 		)
 	}
-	var keyCopy, valueCopy []byte
-	encodedKey, err := c.encoder.EncodeKey(ctx, updatedRow)
+	keyCopy, valueCopy, err := c.encodeRow(ctx, evCtx, updatedRow, prevRow)
 	if err != nil {
 		return err
 	}
-	c.scratch, keyCopy = c.scratch.Copy(encodedKey, 0 /* extraCap */)
-	// TODO(yevgeniy): Some refactoring is needed in the encoder: namely, prevRow
-	// might not be available at all when working with changefeed expressions.
-	encodedValue, err := c.encoder.EncodeValue(ctx, evCtx, updatedRow, prevRow)
-	if err != nil {
-		return err
-	}
-	c.scratch, valueCopy = c.scratch.Copy(encodedValue, 0 /* extraCap */)
 
 	// Since we're done processing/converting this event, and will not use much more
 	// than len(key)+len(bytes) worth of resources, adjust allocation to match.
@@ -473,7 +549,14 @@ func (c *kvEventToRowConsumer) Close() error {
 	return nil
 }
 
-func (c *kvEventToRowConsumer) encodeForParquet(
+// emitsDecodedRows returns whether rows are handed to the sink's
+// EncodeAndEmitRow, rather than being encoded by the consumer.
+func emitsDecodedRows(sink EventSink, encodingOpts changefeedbase.EncodingOptions) bool {
+	return encodingOpts.Format == changefeedbase.OptFormatParquet ||
+		sink.getConcreteType() == sinkTypePostgres
+}
+
+func (c *kvEventToRowConsumer) emitDecodedRow(
 	ctx context.Context,
 	updatedRow cdcevent.Row,
 	prevRow cdcevent.Row,
@@ -484,7 +567,7 @@ func (c *kvEventToRowConsumer) encodeForParquet(
 ) error {
 	sinkWithEncoder, ok := c.sink.(SinkWithEncoder)
 	if !ok {
-		return errors.AssertionFailedf("Expected a SinkWithEncoder for parquet format, found %T", c.sink)
+		return errors.AssertionFailedf("Expected a SinkWithEncoder, found %T", c.sink)
 	}
 	if err := sinkWithEncoder.EncodeAndEmitRow(
 		ctx, updatedRow, prevRow, topic, updated, mvcc, encodingOpts, alloc,
@@ -494,11 +577,84 @@ func (c *kvEventToRowConsumer) encodeForParquet(
 	return nil
 }
 
-// Flush is a noop for the kvEventToRowConsumer because it does not buffer any events.
+// Flush emits the buffered commit groups which committed at or below the local
+// frontier. It is a noop unless the commit_group_info option is set, since
+// otherwise the kvEventToRowConsumer does not buffer any events.
 func (c *kvEventToRowConsumer) Flush(ctx context.Context) error {
+	if c.commitGroups == nil {
+		return nil
+	}
+	for _, group := range c.commitGroups.take(c.frontier.Frontier()) {
+		if err := c.emitCommitGroup(ctx, group); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// emitCommitGroup emits the rows of a commit group, either one at a time or,
+// when commit_grouping is set, as a single batch.
+func (c *kvEventToRowConsumer) emitCommitGroup(
+	ctx context.Context, group *bufferedCommitGroup,
+) error {
+	commitTS := timestampToString(group.commitTS)
+	if !c.encodingOpts.CommitGrouping {
+		for i, r := range group.rows {
+			info := &commitGroupInfo{commitTS: commitTS, seq: i + 1, count: len(group.rows)}
+			if err := c.encodeAndEmit(
+				ctx, r.updated, r.prev, r.schemaTS, info, kvevent.Alloc{},
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	groupingSink, ok := c.sink.(commitGroupingSink)
+	if !ok {
+		return errors.AssertionFailedf("expected a sink supporting %s, found %T",
+			changefeedbase.OptCommitGrouping, c.sink)
+	}
+	rows := make([]commitGroupRow, 0, len(group.rows))
+	for i, r := range group.rows {
+		topic, err := c.topicForEvent(r.updated.Metadata)
+		if err != nil {
+			return err
+		}
+		info := &commitGroupInfo{commitTS: commitTS, seq: i + 1, count: len(group.rows)}
+		evCtx, err := c.makeEventContext(topic, r.updated, r.schemaTS, info)
+		if err != nil {
+			return err
+		}
+		if c.knobs.BeforeEmitRow != nil {
+			if err := c.knobs.BeforeEmitRow(ctx); err != nil {
+				return err
+			}
+		}
+		key, value, err := c.encodeRow(ctx, evCtx, r.updated, r.prev)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, commitGroupRow{
+			topic:   topic,
+			key:     key,
+			value:   value,
+			updated: r.schemaTS,
+			mvcc:    r.updated.MvccTimestamp,
+		})
+	}
+	return groupingSink.EmitCommitGroup(ctx, rows)
+}
+
+// checkpointTimestamp returns the timestamp up to which a span resolved at ts
+// may be checkpointed given the events this consumer still holds.
+func (c *kvEventToRowConsumer) checkpointTimestamp(ts hlc.Timestamp) hlc.Timestamp {
+	if c.commitGroups == nil {
+		return ts
+	}
+	return c.commitGroups.checkpointTimestamp(ts)
+}
+
 type parallelEventConsumer struct {
 	// g is a group used to manage worker goroutines.
 	g ctxgroup.Group
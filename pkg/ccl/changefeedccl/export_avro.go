@@ -0,0 +1,308 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+	"github.com/linkedin/goavro/v2"
+)
+
+const (
+	exportFilePatternPart        = "%part%"
+	exportAvroFilePatternDefault = exportFilePatternPart + ".avro"
+	// exportAvroRecordName is the name of the record schema embedded in
+	// exported files.
+	exportAvroRecordName = "export"
+
+	// Rows are buffered and written as a block of the file once there are
+	// avroExportBlockRows of them, or once their datums take up at least
+	// avroExportBlockBytes. Compression is applied to each block.
+	avroExportBlockRows  = 1000
+	avroExportBlockBytes = 1 << 20
+)
+
+// avroExporter writes rows to Avro object container files, which embed the
+// schema of their records. The schema is derived from the exported columns in
+// the same way as the schemas of changefeeds.
+type avroExporter struct {
+	schema          *avroDataRecord
+	compressionName string
+	buf             bytes.Buffer
+	ocf             *goavro.OCFWriter
+
+	pending      []interface{}
+	pendingBytes int64
+}
+
+func newAvroExporter(
+	colNames []string, typs []*types.T, compression roachpb.IOFileFormat_Compression,
+) (*avroExporter, error) {
+	schema, err := newSchemaForColumns(colNames, typs, exportAvroRecordName, "" /* namespace */)
+	if err != nil {
+		return nil, err
+	}
+	e := &avroExporter{schema: schema}
+	switch compression {
+	case roachpb.IOFileFormat_Gzip:
+		// Gzip is the deflate algorithm, which is the closest codec defined by the
+		// Avro specification.
+		e.compressionName = goavro.CompressionDeflateLabel
+	case roachpb.IOFileFormat_Snappy:
+		e.compressionName = goavro.CompressionSnappyLabel
+	case roachpb.IOFileFormat_Auto, roachpb.IOFileFormat_None:
+		e.compressionName = goavro.CompressionNullLabel
+	default:
+		return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+			"avro writer does not support compression format %s", compression)
+	}
+	return e, nil
+}
+
+// Reset starts a new file.
+func (e *avroExporter) Reset() error {
+	e.buf.Reset()
+	e.pending = e.pending[:0]
+	e.pendingBytes = 0
+	var err error
+	e.ocf, err = goavro.NewOCFWriter(goavro.OCFConfig{
+		W:               &e.buf,
+		Codec:           e.schema.codec,
+		CompressionName: e.compressionName,
+	})
+	return err
+}
+
+// Write buffers the row, writing a block to the file once enough rows are
+// buffered. size is the size of the datums of the row.
+func (e *avroExporter) Write(datums tree.Datums, size int64) error {
+	native, err := e.schema.nativeFromDatums(datums)
+	if err != nil {
+		return err
+	}
+	e.pending = append(e.pending, native)
+	e.pendingBytes += size
+	if len(e.pending) >= avroExportBlockRows || e.pendingBytes >= avroExportBlockBytes {
+		return e.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows to the file as a block.
+func (e *avroExporter) Flush() error {
+	if len(e.pending) == 0 {
+		return nil
+	}
+	if err := e.ocf.Append(e.pending); err != nil {
+		return err
+	}
+	for i := range e.pending {
+		e.pending[i] = nil
+	}
+	e.pending = e.pending[:0]
+	e.pendingBytes = 0
+	return nil
+}
+
+// Len returns the length of the file, excluding the buffered rows.
+func (e *avroExporter) Len() int {
+	return e.buf.Len()
+}
+
+func (e *avroExporter) FileName(spec execinfrapb.ExportSpec, part string) string {
+	pattern := exportAvroFilePatternDefault
+	if spec.NamePattern != "" {
+		pattern = spec.NamePattern
+	}
+	return strings.Replace(pattern, exportFilePatternPart, part, -1)
+}
+
+func newAvroWriterProcessor(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	processorID int32,
+	spec execinfrapb.ExportSpec,
+	post *execinfrapb.PostProcessSpec,
+	input execinfra.RowSource,
+) (execinfra.Processor, error) {
+	c := &avroWriter{
+		flowCtx:     flowCtx,
+		processorID: processorID,
+		spec:        spec,
+		input:       input,
+	}
+	semaCtx := tree.MakeSemaContext()
+	if err := c.out.Init(ctx, post, colinfo.ExportColumnTypes, &semaCtx, flowCtx.NewEvalCtx()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type avroWriter struct {
+	flowCtx     *execinfra.FlowCtx
+	processorID int32
+	spec        execinfrapb.ExportSpec
+	input       execinfra.RowSource
+	out         execinfra.ProcOutputHelper
+}
+
+var _ execinfra.Processor = &avroWriter{}
+
+func (sp *avroWriter) OutputTypes() []*types.T {
+	return sp.out.OutputTypes
+}
+
+func (sp *avroWriter) MustBeStreaming() bool {
+	return false
+}
+
+func (sp *avroWriter) Run(ctx context.Context, output execinfra.RowReceiver) {
+	ctx, span := tracing.ChildSpan(ctx, "avroWriter")
+	defer span.Finish()
+
+	instanceID := sp.flowCtx.EvalCtx.NodeID.SQLInstanceID()
+	uniqueID := builtins.GenerateUniqueInt(builtins.ProcessUniqueID(instanceID))
+
+	err := func() error {
+		typs := sp.input.OutputTypes()
+		sp.input.Start(ctx)
+		input := execinfra.MakeNoMetadataRowSource(sp.input, output)
+
+		alloc := &tree.DatumAlloc{}
+
+		writer, err := newAvroExporter(sp.spec.ColNames, typs, sp.spec.Format.Compression)
+		if err != nil {
+			return err
+		}
+		datums := make(tree.Datums, len(typs))
+
+		chunk := 0
+		done := false
+		for {
+			var rows int64
+			if err := writer.Reset(); err != nil {
+				return err
+			}
+			for {
+				// If the file exceeds the target size, we flush before exporting any
+				// additional rows.
+				if int64(writer.Len()) >= sp.spec.ChunkSize {
+					break
+				}
+				if sp.spec.ChunkRows > 0 && rows >= sp.spec.ChunkRows {
+					break
+				}
+				row, err := input.NextRow()
+				if err != nil {
+					return err
+				}
+				if row == nil {
+					done = true
+					break
+				}
+				rows++
+
+				var size int64
+				for i, ed := range row {
+					if err := ed.EnsureDecoded(typs[i], alloc); err != nil {
+						return err
+					}
+					datums[i] = tree.UnwrapDOidWrapper(ed.Datum)
+					size += int64(ed.Size())
+				}
+				if err := writer.Write(datums, size); err != nil {
+					return err
+				}
+			}
+			if rows < 1 {
+				break
+			}
+			if err := writer.Flush(); err != nil {
+				return errors.Wrap(err, "failed to flush avro writer")
+			}
+
+			conf, err := cloud.ExternalStorageConfFromURI(sp.spec.Destination, sp.spec.User())
+			if err != nil {
+				return err
+			}
+			es, err := sp.flowCtx.Cfg.ExternalStorage(ctx, conf)
+			if err != nil {
+				return err
+			}
+			defer es.Close()
+
+			part := fmt.Sprintf("n%d.%d", uniqueID, chunk)
+			chunk++
+			filename := writer.FileName(sp.spec, part)
+			size := writer.Len()
+
+			if err := cloud.WriteFile(ctx, es, filename, bytes.NewReader(writer.buf.Bytes())); err != nil {
+				return err
+			}
+			res := rowenc.EncDatumRow{
+				rowenc.DatumToEncDatum(
+					types.String,
+					tree.NewDString(filename),
+				),
+				rowenc.DatumToEncDatum(
+					types.Int,
+					tree.NewDInt(tree.DInt(rows)),
+				),
+				rowenc.DatumToEncDatum(
+					types.Int,
+					tree.NewDInt(tree.DInt(size)),
+				),
+			}
+
+			cs, err := sp.out.EmitRow(ctx, res, output)
+			if err != nil {
+				return err
+			}
+			if cs != execinfra.NeedMoreRows {
+				// We don't return an error here because we want the error (if any) that
+				// actually caused the consumer to enter a closed/draining state to take precendence.
+				return nil
+			}
+			if done {
+				break
+			}
+		}
+
+		return nil
+	}()
+
+	execinfra.DrainAndClose(
+		ctx, output, err, func(context.Context, execinfra.RowReceiver) {} /* pushTrailingMeta */, sp.input)
+}
+
+// Resume is part of the execinfra.Processor interface.
+func (sp *avroWriter) Resume(output execinfra.RowReceiver) {
+	panic("not implemented")
+}
+
+func init() {
+	rowexec.NewAvroWriterProcessor = newAvroWriterProcessor
+}
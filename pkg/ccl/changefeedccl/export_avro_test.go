@@ -0,0 +1,97 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvroExporter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	names := []string{"id", "name", "price", "tags", "col with spaces"}
+	typs := []*types.T{
+		types.Int, types.String, types.MakeDecimal(10, 2), types.StringArray, types.Float,
+	}
+	dec := func(s string) tree.Datum {
+		d, err := tree.ParseDDecimal(s)
+		require.NoError(t, err)
+		return d
+	}
+	arr := tree.NewDArray(types.String)
+	require.NoError(t, arr.Append(tree.NewDString("a")))
+	require.NoError(t, arr.Append(tree.DNull))
+
+	var rows []tree.Datums
+	for i := 0; i < avroExportBlockRows+10; i++ {
+		rows = append(rows, tree.Datums{
+			tree.NewDInt(tree.DInt(i)), tree.NewDString("x"), dec("1.25"), arr, tree.NewDFloat(1.5),
+		})
+	}
+	// Include NULLs, and special values which are encoded as strings.
+	rows = append(rows, tree.Datums{tree.NewDInt(-1), tree.DNull, dec("NaN"), tree.DNull, tree.DNull})
+
+	evalCtx := eval.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	for _, compression := range []roachpb.IOFileFormat_Compression{
+		roachpb.IOFileFormat_None, roachpb.IOFileFormat_Gzip, roachpb.IOFileFormat_Snappy,
+	} {
+		t.Run(compression.String(), func(t *testing.T) {
+			e, err := newAvroExporter(names, typs, compression)
+			require.NoError(t, err)
+			require.NoError(t, e.Reset())
+			for _, r := range rows {
+				require.NoError(t, e.Write(r, 10 /* size */))
+			}
+			// A block was written once enough rows were buffered.
+			require.Len(t, e.pending, 11)
+			require.NoError(t, e.Flush())
+
+			r, err := goavro.NewOCFReader(bytes.NewReader(e.buf.Bytes()))
+			require.NoError(t, err)
+			require.Equal(t, e.compressionName, r.CompressionName())
+			// The schema is embedded in the file.
+			require.Contains(t, r.Codec().Schema(), `"name":"col_with_spaces"`)
+
+			var i int
+			for r.Scan() {
+				native, err := r.Read()
+				require.NoError(t, err)
+				decoded, err := e.schema.rowFromNative(native)
+				require.NoError(t, err)
+				require.Len(t, decoded, len(typs))
+				for j, ed := range decoded {
+					cmp, err := rows[i][j].CompareError(evalCtx, ed.Datum)
+					require.NoError(t, err)
+					require.Zerof(t, cmp, "row %d column %s: expected %s, found %s", i, names[j], rows[i][j], ed.Datum)
+				}
+				i++
+			}
+			require.NoError(t, r.Err())
+			require.Equal(t, len(rows), i)
+		})
+	}
+
+	_, err := newAvroExporter([]string{"a", "a"}, []*types.T{types.Int, types.Int}, roachpb.IOFileFormat_None)
+	require.ErrorContains(t, err, "column a maps to duplicate avro field a")
+
+	_, err = newAvroExporter([]string{"d"}, []*types.T{types.Decimal}, roachpb.IOFileFormat_None)
+	require.ErrorContains(t, err, "column d")
+}
@@ -0,0 +1,810 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
+	"github.com/linkedin/goavro/v2"
+)
+
+// An Iceberg table is laid out under the sink's destination as follows:
+//
+//	<table>/data/<name>.parquet           data files
+//	<table>/data/<name>-deletes.parquet   equality delete files
+//	<table>/metadata/v<N>.metadata.json   table metadata, one per commit
+//	<table>/metadata/version-hint.text    the latest metadata version
+//	<table>/metadata/*.avro               manifests and manifest lists
+//	_crdb_pending/<name>.json             files waiting to be committed
+//
+// Aggregators write data and delete files when they flush, followed by a
+// pending descriptor naming them. The descriptor's name follows the same
+// scheme as the files written by the cloud storage sink, so the invariants
+// which order data files before RESOLVED files also hold here: once a
+// resolved timestamp is emitted, every descriptor named at or below it has been
+// written. The change frontier commits those descriptors to their tables when
+// it emits the resolved timestamp, and removes them afterwards.
+const (
+	icebergFormatVersion   = 2
+	icebergPendingDir      = `_crdb_pending`
+	icebergDataDir         = `data`
+	icebergMetadataDir     = `metadata`
+	icebergVersionHintFile = `version-hint.text`
+
+	// icebergPendingFilesSummaryKey is the snapshot summary property which
+	// lists the pending descriptors committed by the snapshot. It makes
+	// commits idempotent if the frontier fails after committing a table but
+	// before removing the descriptors.
+	icebergPendingFilesSummaryKey = `crdb.pending-files`
+	// icebergSchemaVersionProperty is the table property which records the
+	// descriptor version of the table's current schema.
+	icebergSchemaVersionProperty = `crdb.schema-version`
+)
+
+// Manifest entry status and content values defined by the Iceberg spec.
+const (
+	icebergEntryStatusAdded = 1
+
+	icebergContentData            = 0
+	icebergContentEqualityDeletes = 2
+
+	icebergManifestContentData    = 0
+	icebergManifestContentDeletes = 1
+)
+
+// icebergField is a field of an Iceberg schema. Field IDs are the column IDs
+// (as reported by PGAttributeNum) of the table, which are stable across
+// renames and never reused, so they identify a column across schema versions
+// without a separate mapping. The same IDs are written to the Parquet files.
+type icebergField struct {
+	ID       int32  `json:"id"`
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Type     string `json:"type"`
+}
+
+// icebergSchema is an Iceberg schema.
+type icebergSchema struct {
+	Type               string         `json:"type"`
+	SchemaID           int            `json:"schema-id"`
+	IdentifierFieldIDs []int32        `json:"identifier-field-ids,omitempty"`
+	Fields             []icebergField `json:"fields"`
+}
+
+// sameFields returns true if the schema consists of the given fields and
+// identifier fields.
+func (s icebergSchema) sameFields(fields []icebergField, keyIDs []int32) bool {
+	if len(s.Fields) != len(fields) || len(s.IdentifierFieldIDs) != len(keyIDs) {
+		return false
+	}
+	for i := range fields {
+		if s.Fields[i] != fields[i] {
+			return false
+		}
+	}
+	for i := range keyIDs {
+		if s.IdentifierFieldIDs[i] != keyIDs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type icebergPartitionField struct {
+	Name      string `json:"name"`
+	Transform string `json:"transform"`
+	SourceID  int32  `json:"source-id"`
+	FieldID   int32  `json:"field-id"`
+}
+
+type icebergPartitionSpec struct {
+	SpecID int                     `json:"spec-id"`
+	Fields []icebergPartitionField `json:"fields"`
+}
+
+type icebergSortField struct {
+	Transform string `json:"transform"`
+	SourceID  int32  `json:"source-id"`
+	Direction string `json:"direction"`
+	NullOrder string `json:"null-order"`
+}
+
+type icebergSortOrder struct {
+	OrderID int                `json:"order-id"`
+	Fields  []icebergSortField `json:"fields"`
+}
+
+type icebergSnapshot struct {
+	SnapshotID       int64             `json:"snapshot-id"`
+	ParentSnapshotID *int64            `json:"parent-snapshot-id,omitempty"`
+	SequenceNumber   int64             `json:"sequence-number"`
+	TimestampMs      int64             `json:"timestamp-ms"`
+	ManifestList     string            `json:"manifest-list"`
+	Summary          map[string]string `json:"summary"`
+	SchemaID         int               `json:"schema-id"`
+}
+
+type icebergSnapshotLogEntry struct {
+	SnapshotID  int64 `json:"snapshot-id"`
+	TimestampMs int64 `json:"timestamp-ms"`
+}
+
+type icebergMetadataLogEntry struct {
+	MetadataFile string `json:"metadata-file"`
+	TimestampMs  int64  `json:"timestamp-ms"`
+}
+
+type icebergRef struct {
+	SnapshotID int64  `json:"snapshot-id"`
+	Type       string `json:"type"`
+}
+
+// icebergTableMetadata is the table metadata file of a format version 2
+// Iceberg table. Tables written by changefeeds are unpartitioned and
+// unsorted.
+type icebergTableMetadata struct {
+	FormatVersion      int                       `json:"format-version"`
+	TableUUID          string                    `json:"table-uuid"`
+	Location           string                    `json:"location"`
+	LastSequenceNumber int64                     `json:"last-sequence-number"`
+	LastUpdatedMs      int64                     `json:"last-updated-ms"`
+	LastColumnID       int32                     `json:"last-column-id"`
+	CurrentSchemaID    int                       `json:"current-schema-id"`
+	Schemas            []icebergSchema           `json:"schemas"`
+	DefaultSpecID      int                       `json:"default-spec-id"`
+	PartitionSpecs     []icebergPartitionSpec    `json:"partition-specs"`
+	LastPartitionID    int32                     `json:"last-partition-id"`
+	DefaultSortOrderID int                       `json:"default-sort-order-id"`
+	SortOrders         []icebergSortOrder        `json:"sort-orders"`
+	Properties         map[string]string         `json:"properties"`
+	CurrentSnapshotID  int64                     `json:"current-snapshot-id"`
+	Refs               map[string]icebergRef     `json:"refs"`
+	Snapshots          []icebergSnapshot         `json:"snapshots"`
+	SnapshotLog        []icebergSnapshotLogEntry `json:"snapshot-log"`
+	MetadataLog        []icebergMetadataLogEntry `json:"metadata-log"`
+}
+
+// icebergNoSnapshot is the current-snapshot-id of a table without snapshots.
+const icebergNoSnapshot = -1
+
+func newIcebergTableMetadata(location string, now time.Time) *icebergTableMetadata {
+	return &icebergTableMetadata{
+		FormatVersion:     icebergFormatVersion,
+		TableUUID:         uuid.MakeV4().String(),
+		Location:          location,
+		LastUpdatedMs:     now.UnixMilli(),
+		CurrentSchemaID:   -1,
+		PartitionSpecs:    []icebergPartitionSpec{{SpecID: 0, Fields: []icebergPartitionField{}}},
+		LastPartitionID:   999, // Partition field IDs start at 1000.
+		SortOrders:        []icebergSortOrder{{OrderID: 0, Fields: []icebergSortField{}}},
+		Properties:        map[string]string{},
+		CurrentSnapshotID: icebergNoSnapshot,
+		Refs:              map[string]icebergRef{},
+		Snapshots:         []icebergSnapshot{},
+		SnapshotLog:       []icebergSnapshotLogEntry{},
+		MetadataLog:       []icebergMetadataLogEntry{},
+	}
+}
+
+// currentSnapshot returns the current snapshot, or nil if there is none.
+func (m *icebergTableMetadata) currentSnapshot() *icebergSnapshot {
+	for i := range m.Snapshots {
+		if m.Snapshots[i].SnapshotID == m.CurrentSnapshotID {
+			return &m.Snapshots[i]
+		}
+	}
+	return nil
+}
+
+// currentSchema returns the current schema, or nil if there is none.
+func (m *icebergTableMetadata) currentSchema() *icebergSchema {
+	for i := range m.Schemas {
+		if m.Schemas[i].SchemaID == m.CurrentSchemaID {
+			return &m.Schemas[i]
+		}
+	}
+	return nil
+}
+
+// committedPendingFiles returns the names of the pending descriptors which
+// have already been committed to the table.
+func (m *icebergTableMetadata) committedPendingFiles() map[string]struct{} {
+	committed := make(map[string]struct{})
+	for _, snap := range m.Snapshots {
+		if files := snap.Summary[icebergPendingFilesSummaryKey]; files != "" {
+			for _, name := range strings.Split(files, ",") {
+				committed[name] = struct{}{}
+			}
+		}
+	}
+	return committed
+}
+
+// evolveSchema makes the fields of the pending files the table's current
+// schema, if they were written with a newer descriptor version than the
+// current schema. Files written with older versions, which may be committed
+// after newer ones when several aggregators are involved, are read using the
+// newer schema: their fields are resolved by ID.
+func (m *icebergTableMetadata) evolveSchema(p *icebergPendingFiles) {
+	if cur := m.currentSchema(); cur != nil {
+		version, _ := strconv.ParseInt(m.Properties[icebergSchemaVersionProperty], 10, 64)
+		if p.SchemaVersion <= version || cur.sameFields(p.Fields, p.KeyIDs) {
+			return
+		}
+	}
+	schemaID := 0
+	for _, s := range m.Schemas {
+		if s.SchemaID >= schemaID {
+			schemaID = s.SchemaID + 1
+		}
+	}
+	m.Schemas = append(m.Schemas, icebergSchema{
+		Type:               "struct",
+		SchemaID:           schemaID,
+		IdentifierFieldIDs: p.KeyIDs,
+		Fields:             p.Fields,
+	})
+	m.CurrentSchemaID = schemaID
+	for _, f := range p.Fields {
+		if f.ID > m.LastColumnID {
+			m.LastColumnID = f.ID
+		}
+	}
+	m.Properties[icebergSchemaVersionProperty] = strconv.FormatInt(p.SchemaVersion, 10)
+}
+
+// icebergPendingFile describes a data or delete file which has been written
+// but not yet committed.
+type icebergPendingFile struct {
+	// Path is the path of the file relative to the sink's destination.
+	Path        string `json:"path"`
+	RecordCount int64  `json:"record_count"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+// icebergPendingFiles is the descriptor written by an aggregator after it has
+// written the files produced by one flush of one table.
+type icebergPendingFiles struct {
+	Table string `json:"table"`
+	// Writer identifies the sink which wrote the files. Descriptors written by
+	// the same sink are committed in order, each in its own snapshot.
+	Writer        string         `json:"writer"`
+	SchemaVersion int64          `json:"schema_version"`
+	Fields        []icebergField `json:"fields"`
+	KeyIDs        []int32        `json:"key_ids"`
+	// Data holds the latest value of every row upserted by the flush.
+	Data *icebergPendingFile `json:"data,omitempty"`
+	// Deletes holds the primary key of every row which was updated or deleted
+	// by the flush. It removes the previous versions of those rows.
+	Deletes *icebergPendingFile `json:"deletes,omitempty"`
+
+	// name is the name of the descriptor, relative to icebergPendingDir.
+	name string
+}
+
+// groupIcebergPendingFiles splits the pending descriptors of a table, ordered
+// by name, into groups which are each committed as one snapshot. Equality
+// deletes only apply to files committed with a lower sequence number, so two
+// descriptors written by the same sink, which may both touch a row, must be
+// committed in separate snapshots. Descriptors written by different sinks
+// never touch the same row at the same time, since a span is watched by a
+// single aggregator, and may share a snapshot.
+func groupIcebergPendingFiles(pending []*icebergPendingFiles) [][]*icebergPendingFiles {
+	var groups [][]*icebergPendingFiles
+	var cur []*icebergPendingFiles
+	writers := make(map[string]struct{})
+	for _, p := range pending {
+		if _, ok := writers[p.Writer]; ok {
+			groups = append(groups, cur)
+			cur = nil
+			writers = make(map[string]struct{})
+		}
+		writers[p.Writer] = struct{}{}
+		cur = append(cur, p)
+	}
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+	}
+	return groups
+}
+
+// icebergType returns the Iceberg type of a column and the type used to write
+// it to Parquet files. Types which have a direct Iceberg counterpart are
+// written natively. All others, including those which util/parquet writes as
+// strings annotated with a different logical type, are written as their
+// string representation so that every Iceberg reader can interpret them.
+func icebergType(typ *types.T) (string, *types.T) {
+	switch typ.Family() {
+	case types.BoolFamily:
+		return "boolean", typ
+	case types.IntFamily:
+		if typ.Oid() == oid.T_int8 {
+			return "long", typ
+		}
+		return "int", typ
+	case types.FloatFamily:
+		if typ.Oid() == oid.T_float4 {
+			return "float", typ
+		}
+		return "double", typ
+	case types.StringFamily:
+		return "string", typ
+	case types.UuidFamily:
+		return "uuid", typ
+	case types.BytesFamily:
+		return "binary", typ
+	default:
+		return "string", types.String
+	}
+}
+
+// icebergDatum converts a datum to the type it is written as.
+func icebergDatum(d tree.Datum, writeType *types.T) tree.Datum {
+	if d == tree.DNull || writeType.Family() != types.StringFamily {
+		return d
+	}
+	if _, ok := d.(*tree.DString); ok {
+		return d
+	}
+	return tree.NewDString(tree.AsStringWithFlags(d, tree.FmtBareStrings))
+}
+
+// icebergCommitter commits pending descriptors to Iceberg tables.
+type icebergCommitter struct {
+	es cloud.ExternalStorage
+	// location is the URI of the sink's destination, without credentials.
+	location string
+	now      func() time.Time
+}
+
+// commit commits every pending descriptor named at or below the resolved
+// timestamp and removes the descriptors once all tables have been committed.
+func (c *icebergCommitter) commit(ctx context.Context, resolved hlc.Timestamp) error {
+	upTo := cloudStorageFormatTime(resolved)
+	var names []string
+	if err := c.es.List(ctx, icebergPendingDir+"/", "", func(name string) error {
+		name = strings.TrimPrefix(name, "/")
+		if strings.HasSuffix(name, ".json") && len(name) > len(upTo) && name[:len(upTo)] <= upTo {
+			names = append(names, name)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	byTable := make(map[string][]*icebergPendingFiles)
+	var tables []string
+	for _, name := range names {
+		raw, err := c.readFile(ctx, path.Join(icebergPendingDir, name))
+		if err != nil {
+			return err
+		}
+		p := &icebergPendingFiles{name: name}
+		if err := json.Unmarshal(raw, p); err != nil {
+			return errors.Wrapf(err, "decoding pending iceberg files %s", name)
+		}
+		if _, ok := byTable[p.Table]; !ok {
+			tables = append(tables, p.Table)
+		}
+		byTable[p.Table] = append(byTable[p.Table], p)
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		if err := c.commitTable(ctx, table, byTable[table]); err != nil {
+			return errors.Wrapf(err, "committing iceberg table %s", table)
+		}
+	}
+	for _, name := range names {
+		if err := c.es.Delete(ctx, path.Join(icebergPendingDir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitTable adds one snapshot per group of pending descriptors to the table
+// and writes a new version of its metadata.
+func (c *icebergCommitter) commitTable(
+	ctx context.Context, table string, pending []*icebergPendingFiles,
+) error {
+	md, version, err := c.loadMetadata(ctx, table)
+	if err != nil {
+		return err
+	}
+	now := c.now()
+	if md == nil {
+		md = newIcebergTableMetadata(c.location+"/"+table, now)
+	}
+
+	committed := md.committedPendingFiles()
+	var uncommitted []*icebergPendingFiles
+	for _, p := range pending {
+		if _, ok := committed[p.name]; !ok {
+			uncommitted = append(uncommitted, p)
+		}
+	}
+	if len(uncommitted) == 0 {
+		return nil
+	}
+
+	for _, group := range groupIcebergPendingFiles(uncommitted) {
+		for _, p := range group {
+			md.evolveSchema(p)
+		}
+		if err := c.addSnapshot(ctx, table, md, group, now); err != nil {
+			return err
+		}
+	}
+
+	if version > 0 {
+		md.MetadataLog = append(md.MetadataLog, icebergMetadataLogEntry{
+			MetadataFile: md.Location + "/" + path.Join(icebergMetadataDir, icebergMetadataFileName(version)),
+			TimestampMs:  md.LastUpdatedMs,
+		})
+	}
+	md.LastUpdatedMs = now.UnixMilli()
+
+	raw, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	version++
+	metadataDir := path.Join(table, icebergMetadataDir)
+	if err := cloud.WriteFile(ctx, c.es, path.Join(metadataDir, icebergMetadataFileName(version)),
+		bytes.NewReader(raw)); err != nil {
+		return err
+	}
+	if log.V(1) {
+		log.Infof(ctx, "committed %d pending files to iceberg table %s at version %d",
+			len(uncommitted), table, version)
+	}
+	return cloud.WriteFile(ctx, c.es, path.Join(metadataDir, icebergVersionHintFile),
+		strings.NewReader(strconv.Itoa(version)))
+}
+
+// addSnapshot writes the manifests for a group of pending descriptors and
+// adds a snapshot referencing them, along with the manifests of the current
+// snapshot, to the table metadata.
+func (c *icebergCommitter) addSnapshot(
+	ctx context.Context,
+	table string,
+	md *icebergTableMetadata,
+	group []*icebergPendingFiles,
+	now time.Time,
+) error {
+	seq := md.LastSequenceNumber + 1
+	snapshotID := newIcebergSnapshotID()
+	schemaJSON, err := json.Marshal(md.currentSchema())
+	if err != nil {
+		return err
+	}
+
+	var dataEntries, deleteEntries []interface{}
+	var addedRows, addedDeletes int64
+	names := make([]string, 0, len(group))
+	for _, p := range group {
+		names = append(names, p.name)
+		if p.Data != nil {
+			dataEntries = append(dataEntries, c.manifestEntry(
+				snapshotID, seq, icebergContentData, p.Data, nil /* equalityIDs */))
+			addedRows += p.Data.RecordCount
+		}
+		if p.Deletes != nil {
+			deleteEntries = append(deleteEntries, c.manifestEntry(
+				snapshotID, seq, icebergContentEqualityDeletes, p.Deletes, p.KeyIDs))
+			addedDeletes += p.Deletes.RecordCount
+		}
+	}
+
+	var manifests []interface{}
+	if cur := md.currentSnapshot(); cur != nil {
+		if manifests, err = c.readManifestList(ctx, cur.ManifestList); err != nil {
+			return err
+		}
+	}
+	metadataDir := path.Join(table, icebergMetadataDir)
+	for i, entries := range [][]interface{}{dataEntries, deleteEntries} {
+		if len(entries) == 0 {
+			continue
+		}
+		content := icebergManifestContentData
+		contentName := "data"
+		if i == 1 {
+			content = icebergManifestContentDeletes
+			contentName = "deletes"
+		}
+		name := path.Join(metadataDir, fmt.Sprintf("%s-m%d.avro", uuid.MakeV4(), i))
+		size, err := c.writeAvro(ctx, name, icebergManifestEntrySchema, map[string][]byte{
+			"schema":            schemaJSON,
+			"schema-id":         []byte(strconv.Itoa(md.CurrentSchemaID)),
+			"partition-spec":    []byte("[]"),
+			"partition-spec-id": []byte("0"),
+			"format-version":    []byte(strconv.Itoa(icebergFormatVersion)),
+			"content":           []byte(contentName),
+		}, entries)
+		if err != nil {
+			return err
+		}
+		var rows int64
+		for _, e := range entries {
+			rows += e.(map[string]interface{})["data_file"].(map[string]interface{})["record_count"].(int64)
+		}
+		manifests = append(manifests, map[string]interface{}{
+			"manifest_path":        md.Location + "/" + strings.TrimPrefix(name, table+"/"),
+			"manifest_length":      size,
+			"partition_spec_id":    int32(0),
+			"content":              int32(content),
+			"sequence_number":      seq,
+			"min_sequence_number":  seq,
+			"added_snapshot_id":    snapshotID,
+			"added_files_count":    int32(len(entries)),
+			"existing_files_count": int32(0),
+			"deleted_files_count":  int32(0),
+			"added_rows_count":     rows,
+			"existing_rows_count":  int64(0),
+			"deleted_rows_count":   int64(0),
+			"partitions":           nil,
+		})
+	}
+
+	var parent *int64
+	parentMeta := []byte("null")
+	if md.CurrentSnapshotID != icebergNoSnapshot {
+		id := md.CurrentSnapshotID
+		parent = &id
+		parentMeta = []byte(strconv.FormatInt(id, 10))
+	}
+	listName := path.Join(metadataDir, fmt.Sprintf("snap-%d-%s.avro", snapshotID, uuid.MakeV4()))
+	if _, err := c.writeAvro(ctx, listName, icebergManifestFileSchema, map[string][]byte{
+		"snapshot-id":        []byte(strconv.FormatInt(snapshotID, 10)),
+		"parent-snapshot-id": parentMeta,
+		"sequence-number":    []byte(strconv.FormatInt(seq, 10)),
+		"format-version":     []byte(strconv.Itoa(icebergFormatVersion)),
+	}, manifests); err != nil {
+		return err
+	}
+
+	md.Snapshots = append(md.Snapshots, icebergSnapshot{
+		SnapshotID:       snapshotID,
+		ParentSnapshotID: parent,
+		SequenceNumber:   seq,
+		TimestampMs:      now.UnixMilli(),
+		ManifestList:     md.Location + "/" + strings.TrimPrefix(listName, table+"/"),
+		Summary: map[string]string{
+			"operation":                   "overwrite",
+			"added-data-files":            strconv.Itoa(len(dataEntries)),
+			"added-delete-files":          strconv.Itoa(len(deleteEntries)),
+			"added-records":               strconv.FormatInt(addedRows, 10),
+			"added-equality-deletes":      strconv.FormatInt(addedDeletes, 10),
+			icebergPendingFilesSummaryKey: strings.Join(names, ","),
+		},
+		SchemaID: md.CurrentSchemaID,
+	})
+	md.SnapshotLog = append(md.SnapshotLog, icebergSnapshotLogEntry{
+		SnapshotID: snapshotID, TimestampMs: now.UnixMilli(),
+	})
+	md.CurrentSnapshotID = snapshotID
+	md.LastSequenceNumber = seq
+	md.Refs["main"] = icebergRef{SnapshotID: snapshotID, Type: "branch"}
+	return nil
+}
+
+// manifestEntry returns the manifest entry for a newly added file.
+func (c *icebergCommitter) manifestEntry(
+	snapshotID, seq int64, content int32, f *icebergPendingFile, equalityIDs []int32,
+) map[string]interface{} {
+	var eqIDs interface{}
+	if len(equalityIDs) > 0 {
+		ids := make([]interface{}, len(equalityIDs))
+		for i, id := range equalityIDs {
+			ids[i] = id
+		}
+		eqIDs = goavro.Union("array", ids)
+	}
+	return map[string]interface{}{
+		"status":               int32(icebergEntryStatusAdded),
+		"snapshot_id":          goavro.Union("long", snapshotID),
+		"sequence_number":      goavro.Union("long", seq),
+		"file_sequence_number": goavro.Union("long", seq),
+		"data_file": map[string]interface{}{
+			"content":            content,
+			"file_path":          c.location + "/" + f.Path,
+			"file_format":        "PARQUET",
+			"partition":          map[string]interface{}{},
+			"record_count":       f.RecordCount,
+			"file_size_in_bytes": f.SizeBytes,
+			"equality_ids":       eqIDs,
+			"sort_order_id":      nil,
+		},
+	}
+}
+
+// loadMetadata reads the latest metadata of a table, as named by its version
+// hint. It returns nil if the table has not been created yet.
+func (c *icebergCommitter) loadMetadata(
+	ctx context.Context, table string,
+) (*icebergTableMetadata, int, error) {
+	metadataDir := path.Join(table, icebergMetadataDir)
+	hint, err := c.readFile(ctx, path.Join(metadataDir, icebergVersionHintFile))
+	if errors.Is(err, cloud.ErrFileDoesNotExist) {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(hint)))
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "parsing version hint of iceberg table %s", table)
+	}
+	raw, err := c.readFile(ctx, path.Join(metadataDir, icebergMetadataFileName(version)))
+	if err != nil {
+		return nil, 0, err
+	}
+	md := &icebergTableMetadata{}
+	if err := json.Unmarshal(raw, md); err != nil {
+		return nil, 0, errors.Wrapf(err, "decoding metadata of iceberg table %s", table)
+	}
+	if md.FormatVersion != icebergFormatVersion {
+		return nil, 0, errors.Newf("iceberg table %s has unsupported format version %d",
+			table, md.FormatVersion)
+	}
+	if md.Properties == nil {
+		md.Properties = map[string]string{}
+	}
+	if md.Refs == nil {
+		md.Refs = map[string]icebergRef{}
+	}
+	return md, version, nil
+}
+
+// readManifestList returns the entries of a manifest list, given its URI.
+func (c *icebergCommitter) readManifestList(
+	ctx context.Context, uri string,
+) ([]interface{}, error) {
+	if !strings.HasPrefix(uri, c.location+"/") {
+		return nil, errors.Newf("manifest list %s is not stored under %s", uri, c.location)
+	}
+	raw, err := c.readFile(ctx, strings.TrimPrefix(uri, c.location+"/"))
+	if err != nil {
+		return nil, err
+	}
+	r, err := goavro.NewOCFReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	var entries []interface{}
+	for r.Scan() {
+		e, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, r.Err()
+}
+
+// writeAvro writes records to an Avro object container file and returns its
+// size.
+func (c *icebergCommitter) writeAvro(
+	ctx context.Context, name string, schema string, meta map[string][]byte, records []interface{},
+) (int64, error) {
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{W: &buf, Schema: schema, MetaData: meta})
+	if err != nil {
+		return 0, err
+	}
+	if len(records) > 0 {
+		if err := w.Append(records); err != nil {
+			return 0, err
+		}
+	}
+	size := int64(buf.Len())
+	return size, cloud.WriteFile(ctx, c.es, name, &buf)
+}
+
+func (c *icebergCommitter) readFile(ctx context.Context, name string) ([]byte, error) {
+	r, _, err := c.es.ReadFile(ctx, name, cloud.ReadOptions{NoFileSize: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close(ctx)
+	return ioctx.ReadAll(ctx, r)
+}
+
+func icebergMetadataFileName(version int) string {
+	return fmt.Sprintf("v%d.metadata.json", version)
+}
+
+// newIcebergSnapshotID returns a random, positive snapshot ID.
+func newIcebergSnapshotID() int64 {
+	id := uuid.MakeV4()
+	return int64(binary.BigEndian.Uint64(id.GetBytes()) & math.MaxInt64)
+}
+
+// icebergManifestEntrySchema is the Avro schema of the entries of a format
+// version 2 manifest, restricted to the fields written by changefeeds.
+const icebergManifestEntrySchema = `{
+  "type": "record",
+  "name": "manifest_entry",
+  "fields": [
+    {"name": "status", "type": "int", "field-id": 0},
+    {"name": "snapshot_id", "type": ["null", "long"], "default": null, "field-id": 1},
+    {"name": "sequence_number", "type": ["null", "long"], "default": null, "field-id": 3},
+    {"name": "file_sequence_number", "type": ["null", "long"], "default": null, "field-id": 4},
+    {"name": "data_file", "field-id": 2, "type": {
+      "type": "record",
+      "name": "r2",
+      "fields": [
+        {"name": "content", "type": "int", "field-id": 134},
+        {"name": "file_path", "type": "string", "field-id": 100},
+        {"name": "file_format", "type": "string", "field-id": 101},
+        {"name": "partition", "type": {"type": "record", "name": "r102", "fields": []}, "field-id": 102},
+        {"name": "record_count", "type": "long", "field-id": 103},
+        {"name": "file_size_in_bytes", "type": "long", "field-id": 104},
+        {"name": "equality_ids", "type": ["null", {"type": "array", "items": "int", "element-id": 136}], "default": null, "field-id": 135},
+        {"name": "sort_order_id", "type": ["null", "int"], "default": null, "field-id": 140}
+      ]
+    }}
+  ]
+}`
+
+// icebergManifestFileSchema is the Avro schema of the entries of a format
+// version 2 manifest list.
+const icebergManifestFileSchema = `{
+  "type": "record",
+  "name": "manifest_file",
+  "fields": [
+    {"name": "manifest_path", "type": "string", "field-id": 500},
+    {"name": "manifest_length", "type": "long", "field-id": 501},
+    {"name": "partition_spec_id", "type": "int", "field-id": 502},
+    {"name": "content", "type": "int", "field-id": 517},
+    {"name": "sequence_number", "type": "long", "field-id": 515},
+    {"name": "min_sequence_number", "type": "long", "field-id": 516},
+    {"name": "added_snapshot_id", "type": "long", "field-id": 503},
+    {"name": "added_files_count", "type": "int", "field-id": 504},
+    {"name": "existing_files_count", "type": "int", "field-id": 505},
+    {"name": "deleted_files_count", "type": "int", "field-id": 506},
+    {"name": "added_rows_count", "type": "long", "field-id": 512},
+    {"name": "existing_rows_count", "type": "long", "field-id": 513},
+    {"name": "deleted_rows_count", "type": "long", "field-id": 514},
+    {"name": "partitions", "type": ["null", {"type": "array", "element-id": 508, "items": {
+      "type": "record",
+      "name": "r508",
+      "fields": [
+        {"name": "contains_null", "type": "boolean", "field-id": 509},
+        {"name": "contains_nan", "type": ["null", "boolean"], "default": null, "field-id": 518},
+        {"name": "lower_bound", "type": ["null", "bytes"], "default": null, "field-id": 510},
+        {"name": "upper_bound", "type": ["null", "bytes"], "default": null, "field-id": 511}
+      ]
+    }}], "default": null, "field-id": 507}
+  ]
+}`
@@ -0,0 +1,439 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/parquet"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// icebergCloudStorageSink maintains Iceberg tables, one per topic, under the
+// destination of a cloud storage sink (see iceberg.go for the layout). It is
+// used when the sink URI sets table_format=iceberg.
+//
+// Rows are buffered per table until the sink is flushed, keeping only the
+// latest version of each row. A flush writes a Parquet data file holding the
+// rows which were upserted and a Parquet equality delete file holding the keys
+// of the rows which were updated or deleted, followed by a descriptor of the
+// two files. The descriptors are committed to the tables when the change
+// frontier emits a resolved timestamp, which therefore must be enabled.
+//
+// A table's buffer is flushed early when it exceeds the sink's file size, or
+// when a row written with a different descriptor version arrives: Iceberg
+// files have a single schema, and the version change is what drives the
+// evolution of the table's schema.
+type icebergCloudStorageSink struct {
+	wrapped     *cloudStorageSink
+	compression parquet.CompressionCodec
+	committer   icebergCommitter
+	tables      map[string]*icebergTableBuffer
+}
+
+// icebergTableBuffer holds the rows of one table until the sink is flushed.
+type icebergTableBuffer struct {
+	topic   string
+	version descpb.DescriptorVersion
+	fields  []icebergField
+	types   []*types.T
+	// ords maps column IDs to ordinals in fields.
+	ords map[int32]int
+	// keyOrds are the ordinals, in fields, of the primary key columns.
+	keyOrds    []int
+	rows       map[string]*icebergBufferedRow
+	bytes      int64
+	oldestMVCC hlc.Timestamp
+	alloc      kvevent.Alloc
+}
+
+// icebergBufferedRow is the latest version of a buffered row.
+type icebergBufferedRow struct {
+	// datums holds the row, converted to the types it is written as. For a
+	// deleted row, only the primary key columns are set.
+	datums  []tree.Datum
+	deleted bool
+	// needsDelete is set if a previous version of the row may have been
+	// committed to the table.
+	needsDelete bool
+}
+
+func makeIcebergCloudStorageSink(
+	baseCloudStorageSink *cloudStorageSink, u sinkURL,
+) (*icebergCloudStorageSink, error) {
+	parquetSink, err := makeParquetCloudStorageSink(baseCloudStorageSink)
+	if err != nil {
+		return nil, err
+	}
+	return &icebergCloudStorageSink{
+		wrapped:     baseCloudStorageSink,
+		compression: parquetSink.compression,
+		committer: icebergCommitter{
+			es:       baseCloudStorageSink.es,
+			location: icebergLocation(u.URL),
+			now:      timeutil.Now,
+		},
+		tables: make(map[string]*icebergTableBuffer),
+	}, nil
+}
+
+// icebergLocation returns the URI of the sink's destination as written to the
+// table metadata. Credentials and other parameters are stripped.
+func icebergLocation(u *url.URL) string {
+	loc := *u
+	loc.User = nil
+	loc.RawQuery = ""
+	loc.Fragment = ""
+	return loc.String()
+}
+
+// getConcreteType implements the Sink interface.
+func (s *icebergCloudStorageSink) getConcreteType() sinkType {
+	return s.wrapped.getConcreteType()
+}
+
+// EmitRow does not do anything. It must not be called. It is present so that
+// icebergCloudStorageSink implements the Sink interface.
+func (s *icebergCloudStorageSink) EmitRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	key, value []byte,
+	updated, mvcc hlc.Timestamp,
+	alloc kvevent.Alloc,
+) error {
+	return errors.AssertionFailedf("EmitRow unimplemented by the iceberg cloud storage sink")
+}
+
+// Dial implements the Sink interface.
+func (s *icebergCloudStorageSink) Dial() error {
+	return s.wrapped.Dial()
+}
+
+// Close implements the Sink interface.
+func (s *icebergCloudStorageSink) Close() error {
+	for _, t := range s.tables {
+		t.alloc.Release(context.Background())
+	}
+	s.tables = nil
+	return s.wrapped.Close()
+}
+
+// EncodeAndEmitRow buffers a row until the next flush. Implements the
+// SinkWithEncoder interface.
+func (s *icebergCloudStorageSink) EncodeAndEmitRow(
+	ctx context.Context,
+	updatedRow cdcevent.Row,
+	prevRow cdcevent.Row,
+	topic TopicDescriptor,
+	updated, mvcc hlc.Timestamp,
+	encodingOpts changefeedbase.EncodingOptions,
+	alloc kvevent.Alloc,
+) error {
+	if s.tables == nil {
+		return errors.New(`cannot EmitRow on a closed sink`)
+	}
+	name, err := s.wrapped.topicNamer.Name(topic)
+	if err != nil {
+		return err
+	}
+	t := s.tables[name]
+	if t != nil && t.version != topic.GetVersion() {
+		if err := s.flushTable(ctx, t); err != nil {
+			return err
+		}
+		t = nil
+	}
+	if t == nil {
+		if t, err = newIcebergTableBuffer(name, topic.GetVersion(), updatedRow); err != nil {
+			return err
+		}
+		s.tables[name] = t
+	}
+
+	if err := t.add(updatedRow, prevRow); err != nil {
+		return err
+	}
+	t.alloc.Merge(&alloc)
+	if t.oldestMVCC.IsEmpty() || mvcc.Less(t.oldestMVCC) {
+		t.oldestMVCC = mvcc
+	}
+
+	if t.bytes > s.wrapped.targetMaxFileSize {
+		s.wrapped.metrics.recordSizeBasedFlush()
+		return s.flushTable(ctx, t)
+	}
+	return nil
+}
+
+func newIcebergTableBuffer(
+	topic string, version descpb.DescriptorVersion, row cdcevent.Row,
+) (*icebergTableBuffer, error) {
+	t := &icebergTableBuffer{
+		topic:   topic,
+		version: version,
+		ords:    make(map[int32]int),
+		rows:    make(map[string]*icebergBufferedRow),
+	}
+	if err := row.ForAllColumns().Col(func(col cdcevent.ResultColumn) error {
+		if col.PGAttributeNum == 0 {
+			return errors.Newf("column %q of %s cannot be written to an iceberg table: "+
+				"only table columns are supported", col.Name, topic)
+		}
+		typ, writeType := icebergType(col.Typ)
+		t.ords[int32(col.PGAttributeNum)] = len(t.fields)
+		t.fields = append(t.fields, icebergField{
+			ID:   int32(col.PGAttributeNum),
+			Name: col.Name,
+			Type: typ,
+		})
+		t.types = append(t.types, writeType)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := row.ForEachKeyColumn().Col(func(col cdcevent.ResultColumn) error {
+		ord, ok := t.ords[int32(col.PGAttributeNum)]
+		if !ok {
+			return errors.AssertionFailedf("key column %q is not part of the schema of %s", col.Name, topic)
+		}
+		// Identifier fields must be required.
+		t.fields[ord].Required = true
+		t.keyOrds = append(t.keyOrds, ord)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// add buffers a row, replacing any buffered version of it.
+func (t *icebergTableBuffer) add(updatedRow, prevRow cdcevent.Row) error {
+	datums := make([]tree.Datum, len(t.fields))
+	for i := range datums {
+		datums[i] = tree.DNull
+	}
+	var size int64
+	it := updatedRow.ForAllColumns()
+	if updatedRow.IsDeleted() {
+		it = updatedRow.ForEachKeyColumn()
+	}
+	if err := it.Datum(func(d tree.Datum, col cdcevent.ResultColumn) error {
+		i, ok := t.ords[int32(col.PGAttributeNum)]
+		if !ok {
+			return errors.AssertionFailedf("column %q is not part of the schema of %s", col.Name, t.topic)
+		}
+		datums[i] = icebergDatum(d, t.types[i])
+		size += int64(d.Size())
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	f := tree.NewFmtCtx(tree.FmtParsable)
+	for n, ord := range t.keyOrds {
+		if n > 0 {
+			f.WriteString(",")
+		}
+		f.FormatNode(datums[ord])
+	}
+	key := f.CloseAndGetString()
+
+	// Without the diff option the previous row is unknown, so a delete must
+	// be written in case the row existed before.
+	needsDelete := !prevRow.IsInitialized() || !prevRow.IsDeleted()
+	if prev, ok := t.rows[key]; ok {
+		needsDelete = needsDelete || prev.needsDelete
+		t.bytes -= prev.size()
+	}
+	r := &icebergBufferedRow{datums: datums, deleted: updatedRow.IsDeleted(), needsDelete: needsDelete}
+	t.rows[key] = r
+	t.bytes += size
+	return nil
+}
+
+func (r *icebergBufferedRow) size() (size int64) {
+	for _, d := range r.datums {
+		size += int64(d.Size())
+	}
+	return size
+}
+
+// Flush implements the Sink interface.
+func (s *icebergCloudStorageSink) Flush(ctx context.Context) error {
+	if s.tables == nil {
+		return errors.New(`cannot Flush on a closed sink`)
+	}
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := s.flushTable(ctx, s.tables[name]); err != nil {
+			return err
+		}
+	}
+	// Flushing the wrapped sink advances the timestamp used to name files.
+	return s.wrapped.Flush(ctx)
+}
+
+// flushTable writes the buffered rows of a table to data and delete files,
+// followed by the descriptor which makes them visible to the committer.
+func (s *icebergCloudStorageSink) flushTable(ctx context.Context, t *icebergTableBuffer) error {
+	delete(s.tables, t.topic)
+	defer t.alloc.Release(ctx)
+	if len(t.rows) == 0 {
+		return nil
+	}
+	start := timeutil.Now()
+	w := s.wrapped
+
+	// Descriptors and files are named like the files of the cloud storage
+	// sink, which guarantees the ordering the committer relies on.
+	fileID := w.fileID
+	w.fileID++
+	name := fmt.Sprintf(`%s-%s-%d-%d-%08x-%s-%x`, w.dataFileTs,
+		w.jobSessionID, w.srcID, w.sinkID, fileID, t.topic, t.version)
+
+	keys := make([]string, 0, len(t.rows))
+	for k := range t.rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pending := &icebergPendingFiles{
+		Table:         t.topic,
+		Writer:        fmt.Sprintf(`%s-%d-%d`, w.jobSessionID, w.srcID, w.sinkID),
+		SchemaVersion: int64(t.version),
+		Fields:        t.fields,
+	}
+	keyNames := make([]string, len(t.keyOrds))
+	keyTypes := make([]*types.T, len(t.keyOrds))
+	keyIDs := make([]int32, len(t.keyOrds))
+	for i, ord := range t.keyOrds {
+		keyNames[i] = t.fields[ord].Name
+		keyTypes[i] = t.types[ord]
+		keyIDs[i] = t.fields[ord].ID
+	}
+	pending.KeyIDs = keyIDs
+
+	var err error
+	var upserts [][]tree.Datum
+	var deletes [][]tree.Datum
+	for _, k := range keys {
+		r := t.rows[k]
+		if r.needsDelete {
+			key := make([]tree.Datum, len(t.keyOrds))
+			for i, ord := range t.keyOrds {
+				key[i] = r.datums[ord]
+			}
+			deletes = append(deletes, key)
+		}
+		if !r.deleted {
+			upserts = append(upserts, r.datums)
+		}
+	}
+	names := make([]string, len(t.fields))
+	ids := make([]int32, len(t.fields))
+	for i, f := range t.fields {
+		names[i] = f.Name
+		ids[i] = f.ID
+	}
+	dataPath := path.Join(t.topic, icebergDataDir, name+`.parquet`)
+	if pending.Data, err = s.writeParquet(ctx, dataPath, names, t.types, ids, upserts); err != nil {
+		return err
+	}
+	deletesPath := path.Join(t.topic, icebergDataDir, name+`-deletes.parquet`)
+	if pending.Deletes, err = s.writeParquet(ctx, deletesPath, keyNames, keyTypes, keyIDs, deletes); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	if err := cloud.WriteFile(ctx, w.es, path.Join(icebergPendingDir, name+`.json`),
+		bytes.NewReader(raw)); err != nil {
+		return err
+	}
+
+	var written int
+	if pending.Data != nil {
+		written += int(pending.Data.SizeBytes)
+	}
+	if pending.Deletes != nil {
+		written += int(pending.Deletes.SizeBytes)
+	}
+	w.metrics.recordEmittedBatch(start, len(t.rows), t.oldestMVCC, written, written)
+	return nil
+}
+
+// writeParquet writes rows to a Parquet file and returns its description, or
+// nil if there are no rows.
+func (s *icebergCloudStorageSink) writeParquet(
+	ctx context.Context,
+	dest string,
+	names []string,
+	typs []*types.T,
+	ids []int32,
+	rows [][]tree.Datum,
+) (*icebergPendingFile, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	sch, err := parquet.NewSchemaWithFieldIDs(names, typs, ids)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer, err := parquet.NewWriter(sch, &buf, parquet.WithCompressionCodec(s.compression))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if err := writer.AddRow(r); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	size := int64(buf.Len())
+	if err := cloud.WriteFile(ctx, s.wrapped.es, dest, &buf); err != nil {
+		return nil, err
+	}
+	return &icebergPendingFile{Path: dest, RecordCount: int64(len(rows)), SizeBytes: size}, nil
+}
+
+// EmitResolvedTimestamp commits every file flushed at or below the resolved
+// timestamp to its table. Implements the Sink interface.
+func (s *icebergCloudStorageSink) EmitResolvedTimestamp(
+	ctx context.Context, _ Encoder, resolved hlc.Timestamp,
+) error {
+	if s.tables == nil {
+		return errors.New(`cannot EmitResolvedTimestamp on a closed sink`)
+	}
+	defer s.wrapped.metrics.recordResolvedCallback()()
+	return s.committer.commit(ctx, resolved)
+}
@@ -0,0 +1,239 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/blobs"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIcebergType(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		typ         *types.T
+		icebergType string
+		writeType   *types.T
+	}{
+		{typ: types.Bool, icebergType: "boolean", writeType: types.Bool},
+		{typ: types.Int, icebergType: "long", writeType: types.Int},
+		{typ: types.Int4, icebergType: "int", writeType: types.Int4},
+		{typ: types.Float4, icebergType: "float", writeType: types.Float4},
+		{typ: types.Float, icebergType: "double", writeType: types.Float},
+		{typ: types.String, icebergType: "string", writeType: types.String},
+		{typ: types.Uuid, icebergType: "uuid", writeType: types.Uuid},
+		{typ: types.Bytes, icebergType: "binary", writeType: types.Bytes},
+		{typ: types.Decimal, icebergType: "string", writeType: types.String},
+		{typ: types.TimestampTZ, icebergType: "string", writeType: types.String},
+		{typ: types.IntArray, icebergType: "string", writeType: types.String},
+	} {
+		t.Run(tc.typ.String(), func(t *testing.T) {
+			icebergTyp, writeType := icebergType(tc.typ)
+			require.Equal(t, tc.icebergType, icebergTyp)
+			require.Equal(t, tc.writeType, writeType)
+		})
+	}
+
+	dec, err := tree.ParseDDecimal("1.50")
+	require.NoError(t, err)
+	require.Equal(t, tree.NewDString("1.50"), icebergDatum(dec, types.String))
+	require.Equal(t, tree.DNull, icebergDatum(tree.DNull, types.String))
+	require.Equal(t, tree.NewDInt(1), icebergDatum(tree.NewDInt(1), types.Int))
+}
+
+func TestGroupIcebergPendingFiles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var pending []*icebergPendingFiles
+	for i, w := range []string{"a", "b", "a", "c", "a", "a"} {
+		pending = append(pending, &icebergPendingFiles{Writer: w, name: string(rune('0' + i))})
+	}
+	var names [][]string
+	for _, group := range groupIcebergPendingFiles(pending) {
+		var g []string
+		for _, p := range group {
+			g = append(g, p.name)
+		}
+		names = append(names, g)
+	}
+	require.Equal(t, [][]string{{"0", "1"}, {"2", "3"}, {"4"}, {"5"}}, names)
+}
+
+func TestIcebergCommitter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	dir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+	settings := cluster.MakeTestingClusterSettings()
+	settings.ExternalIODir = dir
+
+	const location = `nodelocal://1/iceberg`
+	es, err := cloud.ExternalStorageFromURI(ctx, location, base.ExternalIODirConfig{},
+		settings, blobs.TestBlobServiceClient(dir), username.RootUserName(),
+		nil /* db */, nil /* limiters */, cloud.NilMetrics)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, es.Close()) }()
+
+	u, err := url.Parse(`s3://user:secret@bucket/feed?AWS_ACCESS_KEY_ID=key&file_size=1MB`)
+	require.NoError(t, err)
+	require.Equal(t, `s3://bucket/feed`, icebergLocation(u))
+
+	c := icebergCommitter{
+		es:       es,
+		location: location,
+		now:      func() time.Time { return time.Unix(1700000000, 0) },
+	}
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	fieldsV1 := []icebergField{
+		{ID: 1, Name: "a", Required: true, Type: "long"},
+		{ID: 2, Name: "b", Type: "string"},
+	}
+	fieldsV2 := append(fieldsV1[:len(fieldsV1):len(fieldsV1)], icebergField{ID: 4, Name: "c", Type: "double"})
+	writePending := func(
+		fileTS hlc.Timestamp, writer string, fileID int, version int64, fields []icebergField,
+	) string {
+		name := cloudStorageFormatTime(fileTS) + "-" + writer + "-" + string(rune('0'+fileID)) + "-foo"
+		p := icebergPendingFiles{
+			Table:         "foo",
+			Writer:        writer,
+			SchemaVersion: version,
+			Fields:        fields,
+			KeyIDs:        []int32{1},
+			Data:          &icebergPendingFile{Path: "foo/data/" + name + ".parquet", RecordCount: 3, SizeBytes: 100},
+			Deletes:       &icebergPendingFile{Path: "foo/data/" + name + "-deletes.parquet", RecordCount: 1, SizeBytes: 10},
+		}
+		raw, err := json.Marshal(p)
+		require.NoError(t, err)
+		require.NoError(t, cloud.WriteFile(ctx, es, path.Join(icebergPendingDir, name+".json"),
+			bytes.NewReader(raw)))
+		return name + ".json"
+	}
+	listPending := func() (names []string) {
+		require.NoError(t, es.List(ctx, icebergPendingDir+"/", "", func(name string) error {
+			names = append(names, name)
+			return nil
+		}))
+		sort.Strings(names)
+		return names
+	}
+	loadMetadata := func() *icebergTableMetadata {
+		md, _, err := c.loadMetadata(ctx, "foo")
+		require.NoError(t, err)
+		require.NotNil(t, md)
+		return md
+	}
+	readManifestList := func(snap *icebergSnapshot) []map[string]interface{} {
+		entries, err := c.readManifestList(ctx, snap.ManifestList)
+		require.NoError(t, err)
+		var res []map[string]interface{}
+		for _, e := range entries {
+			res = append(res, e.(map[string]interface{}))
+		}
+		return res
+	}
+
+	// Nothing to commit.
+	require.NoError(t, c.commit(ctx, ts(1)))
+	md, _, err := c.loadMetadata(ctx, "foo")
+	require.NoError(t, err)
+	require.Nil(t, md)
+
+	// Two flushes by writer "a" and one by writer "b" are committed in two
+	// snapshots; a flush above the resolved timestamp is left pending.
+	writePending(ts(1), "a", 0, 1, fieldsV1)
+	writePending(ts(1), "b", 0, 1, fieldsV1)
+	writePending(ts(2), "a", 1, 1, fieldsV1)
+	later := writePending(ts(5), "a", 2, 2, fieldsV2)
+	require.NoError(t, c.commit(ctx, ts(3)))
+	require.Equal(t, []string{later}, listPending())
+
+	md = loadMetadata()
+	require.Equal(t, location+"/foo", md.Location)
+	require.Len(t, md.Snapshots, 2)
+	require.EqualValues(t, 2, md.LastSequenceNumber)
+	require.Equal(t, md.Snapshots[1].SnapshotID, md.CurrentSnapshotID)
+	require.Equal(t, md.Snapshots[0].SnapshotID, *md.Snapshots[1].ParentSnapshotID)
+	require.Equal(t, "2", md.Snapshots[0].Summary["added-data-files"])
+	require.Len(t, md.Schemas, 1)
+	require.Equal(t, []int32{1}, md.Schemas[0].IdentifierFieldIDs)
+
+	// The manifest list of the current snapshot includes the manifests of its
+	// parent, each with the sequence number it was committed with.
+	var seqs []int64
+	for _, m := range readManifestList(&md.Snapshots[1]) {
+		seqs = append(seqs, m["sequence_number"].(int64))
+	}
+	require.Equal(t, []int64{1, 1, 2, 2}, seqs)
+
+	// Committing a newer schema version evolves the table's schema. A flush
+	// written with the older version, committed in the same snapshot, does not
+	// revert it.
+	writePending(ts(5), "b", 1, 1, fieldsV1)
+	require.NoError(t, c.commit(ctx, ts(10)))
+	require.Empty(t, listPending())
+	md = loadMetadata()
+	require.Len(t, md.Snapshots, 3)
+	require.Len(t, md.Schemas, 2)
+	require.Equal(t, 1, md.CurrentSchemaID)
+	require.Equal(t, fieldsV2, md.currentSchema().Fields)
+	require.EqualValues(t, 4, md.LastColumnID)
+	require.Len(t, md.MetadataLog, 1)
+
+	// Descriptors which were committed but not removed, as happens when the
+	// frontier fails midway through a commit, are not committed again.
+	writePending(ts(5), "b", 1, 1, fieldsV1)
+	require.NoError(t, c.commit(ctx, ts(10)))
+	require.Empty(t, listPending())
+	require.Len(t, loadMetadata().Snapshots, 3)
+
+	// Delete files carry the table's key as equality field IDs.
+	var deleteManifest string
+	for _, m := range readManifestList(md.currentSnapshot()) {
+		if m["content"].(int32) == icebergManifestContentDeletes {
+			deleteManifest = m["manifest_path"].(string)
+		}
+	}
+	require.NotEmpty(t, deleteManifest)
+	raw, err := c.readFile(ctx, deleteManifest[len(location)+1:])
+	require.NoError(t, err)
+	r, err := goavro.NewOCFReader(bytes.NewReader(raw))
+	require.NoError(t, err)
+	require.Equal(t, "deletes", string(r.MetaData()["content"]))
+	require.True(t, r.Scan())
+	entry, err := r.Read()
+	require.NoError(t, err)
+	dataFile := entry.(map[string]interface{})["data_file"].(map[string]interface{})
+	require.EqualValues(t, icebergContentEqualityDeletes, dataFile["content"])
+	require.Equal(t, map[string]interface{}{"array": []interface{}{int32(1)}}, dataFile["equality_ids"])
+}
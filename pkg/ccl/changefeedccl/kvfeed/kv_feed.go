@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
@@ -77,6 +78,10 @@ type Config struct {
 
 	// UseMux enables MuxRangeFeed rpc
 	UseMux bool
+
+	// ValueFilter, if set, is pushed down to the rangefeeds, which then only
+	// send the values that pass it.
+	ValueFilter *kvpb.RangeFeedValueFilter
 }
 
 // Run will run the kvfeed. The feed runs synchronously and returns an
@@ -111,6 +116,7 @@ func Run(ctx context.Context, cfg Config) error {
 		cfg.SchemaFeed,
 		sc, pff, bf, cfg.UseMux, cfg.Targets, cfg.Knobs)
 	f.onBackfillCallback = cfg.OnBackfillCallback
+	f.valueFilter = cfg.ValueFilter
 
 	g := ctxgroup.WithContext(ctx)
 	g.GoCtx(cfg.SchemaFeed.Run)
@@ -185,7 +191,8 @@ type kvFeed struct {
 	schemaChangeEvents changefeedbase.SchemaChangeEventClass
 	schemaChangePolicy changefeedbase.SchemaChangePolicy
 
-	useMux bool
+	useMux      bool
+	valueFilter *kvpb.RangeFeedValueFilter
 
 	targets changefeedbase.Targets
 
@@ -318,9 +325,9 @@ func (f *kvFeed) run(ctx context.Context) (err error) {
 		// effectively invisible to consumers.
 		primaryIndexChange, noColumnChanges := isPrimaryKeyChange(events, f.targets)
 		if primaryIndexChange && (noColumnChanges ||
-			f.schemaChangePolicy != changefeedbase.OptSchemaChangePolicyStop) {
+			!f.schemaChangePolicy.StopsOnSchemaChange()) {
 			boundaryType = jobspb.ResolvedSpan_RESTART
-		} else if f.schemaChangePolicy == changefeedbase.OptSchemaChangePolicyStop {
+		} else if f.schemaChangePolicy.StopsOnSchemaChange() {
 			boundaryType = jobspb.ResolvedSpan_EXIT
 		}
 		// Resolve all of the spans as a boundary if the policy indicates that
@@ -492,11 +499,12 @@ func (f *kvFeed) runUntilTableEvent(
 
 	g := ctxgroup.WithContext(ctx)
 	physicalCfg := rangeFeedConfig{
-		Spans:    stps,
-		Frontier: resumeFrontier.Frontier(),
-		WithDiff: f.withDiff,
-		Knobs:    f.knobs,
-		UseMux:   f.useMux,
+		Spans:       stps,
+		Frontier:    resumeFrontier.Frontier(),
+		WithDiff:    f.withDiff,
+		Knobs:       f.knobs,
+		UseMux:      f.useMux,
+		ValueFilter: f.valueFilter,
 	}
 
 	// The following two synchronous calls works as follows:
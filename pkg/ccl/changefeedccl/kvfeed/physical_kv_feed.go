@@ -32,6 +32,8 @@ type rangeFeedConfig struct {
 	WithDiff bool
 	Knobs    TestingKnobs
 	UseMux   bool
+	// ValueFilter, if set, is pushed down to the rangefeeds.
+	ValueFilter *kvpb.RangeFeedValueFilter
 }
 
 type rangefeedFactory func(
@@ -81,6 +83,9 @@ func (p rangefeedFactory) Run(ctx context.Context, sink kvevent.Writer, cfg rang
 	if cfg.WithDiff {
 		rfOpts = append(rfOpts, kvcoord.WithDiff())
 	}
+	if cfg.ValueFilter != nil {
+		rfOpts = append(rfOpts, kvcoord.WithValueFilter(cfg.ValueFilter))
+	}
 
 	g.GoCtx(func(ctx context.Context) error {
 		return p(ctx, cfg.Spans, feed.eventC, rfOpts...)
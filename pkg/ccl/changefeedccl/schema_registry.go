@@ -14,8 +14,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -41,6 +43,13 @@ type schemaRegistry interface {
 	// be used in Avro wire messages or in other calls to the
 	// schema registry.
 	RegisterSchemaForSubject(ctx context.Context, subject string, schema string) (int32, error)
+
+	// CheckSchemaCompatibility tests whether the given schema may be
+	// registered for the given subject under the compatibility mode the
+	// registry enforces for it. If it may not, the returned string describes
+	// why. A schema is always compatible with a subject which has no schema
+	// registered yet.
+	CheckSchemaCompatibility(ctx context.Context, subject string, schema string) (bool, string, error)
 }
 
 type confluentSchemaVersionRequest struct {
@@ -51,6 +60,11 @@ type confluentSchemaVersionResponse struct {
 	ID int32 `json:"id"`
 }
 
+type confluentSchemaCompatibilityResponse struct {
+	IsCompatible bool     `json:"is_compatible"`
+	Messages     []string `json:"messages"`
+}
+
 type confluentSchemaRegistry struct {
 	baseURL *url.URL
 	// The current defaults for httputil.Client sets
@@ -263,6 +277,60 @@ func (r *confluentSchemaRegistry) RegisterSchemaForSubject(
 	return id, nil
 }
 
+// CheckSchemaCompatibility tests the given schema against the latest schema
+// registered for the given subject. The schema type is assumed to be AVRO. A
+// 404 response means that the subject has no schema yet, in which case any
+// schema is compatible.
+//
+//	https://docs.confluent.io/platform/current/schema-registry/develop/api.html#post--compatibility-subjects-(string-%20subject)-versions-(versionId-%20version)
+func (r *confluentSchemaRegistry) CheckSchemaCompatibility(
+	ctx context.Context, subject string, schema string,
+) (bool, string, error) {
+	u := r.urlForPath(fmt.Sprintf("compatibility/subjects/%s/versions/latest", subject))
+	u += "?verbose=true"
+	if log.V(1) {
+		log.Infof(ctx, "checking avro schema compatibility %s %s", u, schema)
+	}
+
+	req := confluentSchemaVersionRequest{Schema: schema}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(req); err != nil {
+		return false, "", err
+	}
+
+	var res confluentSchemaCompatibilityResponse
+	err := r.doWithRetry(ctx, func() (e error) {
+		resp, err := r.client.Post(ctx, u, confluentSchemaContentType, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			return errors.Wrap(err, "contacting confluent schema registry")
+		}
+		defer gracefulClose(ctx, resp.Body)
+		if resp.StatusCode == http.StatusNotFound {
+			res = confluentSchemaCompatibilityResponse{IsCompatible: true}
+			return nil
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(resp.Body)
+			return errors.Errorf("checking schema compatibility with %s %s: %s", u, resp.Status, body)
+		}
+		res = confluentSchemaCompatibilityResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			return errors.Wrap(err, "decoding confluent schema registry reply")
+		}
+		return nil
+	})
+	if err != nil {
+		return false, "", err
+	}
+	if res.IsCompatible {
+		return true, "", nil
+	}
+	if len(res.Messages) == 0 {
+		return false, "rejected by the registry's compatibility mode", nil
+	}
+	return false, strings.Join(res.Messages, "; "), nil
+}
+
 func (r *confluentSchemaRegistry) doWithRetry(ctx context.Context, fn func() error) error {
 	// Since network services are often a source of flakes, add a few retries here
 	// before we give up and return an error that will bubble up and tear down the
@@ -367,6 +435,24 @@ func (csr *schemaRegistryWithCache) RegisterSchemaForSubject(
 	return id, err
 }
 
+// CheckSchemaCompatibility implements the schemaRegistry interface. A schema
+// already registered for the subject is compatible with it, so the registry
+// is only consulted for new schemas.
+func (csr *schemaRegistryWithCache) CheckSchemaCompatibility(
+	ctx context.Context, subject string, schema string,
+) (bool, string, error) {
+	cacheKey := schemaRegistryCacheKey{
+		subject: subject, schema: schema,
+	}
+	csr.cache.mu.Lock()
+	_, ok := csr.cache.Get(cacheKey)
+	csr.cache.mu.Unlock()
+	if ok {
+		return true, "", nil
+	}
+	return csr.base.CheckSchemaCompatibility(ctx, subject, schema)
+}
+
 type sharedSchemaRegistryCaches struct {
 	mu               syncutil.Mutex
 	cachePerEndpoint map[string]*schemaRegistryCache
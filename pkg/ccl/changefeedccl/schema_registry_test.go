@@ -139,6 +139,47 @@ func TestConfluentSchemaRegistrySharedCache(t *testing.T) {
 
 }
 
+func TestConfluentSchemaRegistryCompatibility(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	regServer := cdctest.StartTestSchemaRegistry()
+	defer regServer.Close()
+	regServer.SetCompatibility("BACKWARD")
+	reg, err := newConfluentSchemaRegistry(regServer.URL(), nil, nil)
+	require.NoError(t, err)
+
+	const v1 = `{"type":"record","name":"foo","fields":[{"type":"long","name":"a"}]}`
+	const v2 = `{"type":"record","name":"foo","fields":[{"type":"long","name":"a"},` +
+		`{"type":["null","string"],"name":"b","default":null}]}`
+	const v3 = `{"type":"record","name":"foo","fields":[{"type":"string","name":"a"}]}`
+
+	// Any schema is compatible with a subject that has none yet.
+	compatible, _, err := reg.CheckSchemaCompatibility(ctx, "foo-value", v3)
+	require.NoError(t, err)
+	require.True(t, compatible)
+
+	_, err = reg.RegisterSchemaForSubject(ctx, "foo-value", v1)
+	require.NoError(t, err)
+	compatible, _, err = reg.CheckSchemaCompatibility(ctx, "foo-value", v2)
+	require.NoError(t, err)
+	require.True(t, compatible)
+	compatible, reason, err := reg.CheckSchemaCompatibility(ctx, "foo-value", v3)
+	require.NoError(t, err)
+	require.False(t, compatible)
+	require.Equal(t, "TYPE_MISMATCH: foo.a", reason)
+
+	// Registered schemas are compatible without asking the registry.
+	_, err = reg.RegisterSchemaForSubject(ctx, "foo-value", v3)
+	require.NoError(t, err)
+	_, err = reg.RegisterSchemaForSubject(ctx, "foo-value", v1)
+	require.NoError(t, err)
+	compatible, _, err = reg.CheckSchemaCompatibility(ctx, "foo-value", v3)
+	require.NoError(t, err)
+	require.True(t, compatible)
+}
+
 func TestConfluentSchemaRegistryPing(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -59,6 +59,7 @@ const (
 	sinkTypePubsub
 	sinkTypeCloudstorage
 	sinkTypeSQL
+	sinkTypePostgres
 )
 
 // externalResource is the interface common to both EventSink and
@@ -276,6 +277,11 @@ func getSink(
 					timestampOracle, serverCfg.ExternalStorageFromURI, user, metricsBuilder, testingKnobs,
 				)
 			})
+		case isPostgresSink(u):
+			return validateOptionsAndMakeSink(changefeedbase.PostgresValidOptions, func() (Sink, error) {
+				return makePostgresSink(sinkURL{URL: u}, AllTargets(feedCfg), encodingOpts, serverCfg.Settings,
+					timestampOracle, metricsBuilder)
+			})
 		case u.Scheme == changefeedbase.SinkSchemeExperimentalSQL:
 			return validateOptionsAndMakeSink(changefeedbase.SQLValidOptions, func() (Sink, error) {
 				return makeSQLSink(sinkURL{URL: u}, sqlSinkTableName, AllTargets(feedCfg), metricsBuilder)
@@ -459,6 +465,17 @@ func (s errorWrapperSink) EncodeAndEmitRow(
 	return errors.AssertionFailedf("Expected a sink with encoder for, found %T", s.wrapped)
 }
 
+// EmitCommitGroup implements commitGroupingSink interface.
+func (s errorWrapperSink) EmitCommitGroup(ctx context.Context, rows []commitGroupRow) error {
+	if groupingSink, ok := s.wrapped.(commitGroupingSink); ok {
+		if err := groupingSink.EmitCommitGroup(ctx, rows); err != nil {
+			return changefeedbase.MarkRetryableError(err)
+		}
+		return nil
+	}
+	return errors.AssertionFailedf("Expected a sink supporting commit grouping, found %T", s.wrapped)
+}
+
 // Dial implements Sink interface.
 func (s errorWrapperSink) Dial() error {
 	return s.wrapped.Dial()
@@ -724,6 +741,25 @@ type SinkWithEncoder interface {
 	Flush(ctx context.Context) error
 }
 
+// commitGroupRow is an encoded row of a commit group.
+type commitGroupRow struct {
+	topic         TopicDescriptor
+	key, value    []byte
+	updated, mvcc hlc.Timestamp
+}
+
+// commitGroupingSink is an EventSink which supports the commit_grouping
+// option by emitting all of the rows of a commit group as a single batch.
+type commitGroupingSink interface {
+	EventSink
+
+	// EmitCommitGroup emits the rows of a single commit group. Commit groups
+	// are emitted in commit timestamp order, and sinks must deliver them in
+	// that order without interleaving the rows of different groups. As with
+	// EmitRow, the rows are only guaranteed to be durable once Flush returns.
+	EmitCommitGroup(ctx context.Context, rows []commitGroupRow) error
+}
+
 // proper JSON schema for sink config:
 //
 //	{
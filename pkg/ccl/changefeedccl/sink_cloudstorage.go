@@ -410,7 +410,8 @@ func makeCloudStorageSink(
 	}
 	s.flushGroup.GoCtx(s.asyncFlusher)
 
-	if partitionFormat := u.consumeParam(changefeedbase.SinkParamPartitionFormat); partitionFormat != "" {
+	partitionFormat := u.consumeParam(changefeedbase.SinkParamPartitionFormat)
+	if partitionFormat != "" {
 		dateFormat, ok := partitionDateFormats[partitionFormat]
 		if !ok {
 			return nil, errors.Errorf("invalid partition_format of %s", partitionFormat)
@@ -419,6 +420,22 @@ func makeCloudStorageSink(
 		s.partitionFormat = dateFormat
 	}
 
+	tableFormat := u.consumeParam(changefeedbase.SinkParamTableFormat)
+	switch tableFormat {
+	case ``:
+	case changefeedbase.SinkTableFormatIceberg:
+		if encodingOpts.Format != changefeedbase.OptFormatParquet {
+			return nil, errors.Errorf(`%s=%s requires %s=%s`, changefeedbase.SinkParamTableFormat,
+				tableFormat, changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+		}
+		if partitionFormat != "" {
+			return nil, errors.Errorf(`%s cannot be used with %s=%s`,
+				changefeedbase.SinkParamPartitionFormat, changefeedbase.SinkParamTableFormat, tableFormat)
+		}
+	default:
+		return nil, errors.Errorf("invalid %s of %s", changefeedbase.SinkParamTableFormat, tableFormat)
+	}
+
 	if s.timestampOracle != nil {
 		s.setDataFileTimestamp()
 	}
@@ -475,6 +492,15 @@ func makeCloudStorageSink(
 		s.metrics = (*sliMetrics)(nil)
 	}
 
+	if tableFormat == changefeedbase.SinkTableFormatIceberg {
+		icebergSink, err := makeIcebergCloudStorageSink(s, u)
+		if err != nil {
+			return nil, err
+		}
+		s.compression = ""
+		return icebergSink, nil
+	}
+
 	if encodingOpts.Format == changefeedbase.OptFormatParquet {
 		parquetSinkWithEncoder, err := makeParquetCloudStorageSink(s)
 		if err != nil {
@@ -493,7 +519,12 @@ func (s *cloudStorageSink) getOrCreateFile(
 	topic TopicDescriptor, eventMVCC hlc.Timestamp,
 ) (*cloudStorageSinkFile, error) {
 	name, _ := s.topicNamer.Name(topic)
-	key := cloudStorageSinkKey{name, int64(topic.GetVersion())}
+	return s.getOrCreateFileForKey(cloudStorageSinkKey{name, int64(topic.GetVersion())}, eventMVCC)
+}
+
+func (s *cloudStorageSink) getOrCreateFileForKey(
+	key cloudStorageSinkKey, eventMVCC hlc.Timestamp,
+) (*cloudStorageSinkFile, error) {
 	if item := s.files.Get(key); item != nil {
 		f := item.(*cloudStorageSinkFile)
 		if eventMVCC.Less(f.oldestMVCC) {
@@ -530,22 +561,7 @@ func (s *cloudStorageSink) EmitRow(
 		return errors.New(`cannot EmitRow on a closed sink`)
 	}
 
-	defer func() {
-		if !s.compression.enabled() {
-			return
-		}
-		if retErr == nil {
-			retErr = ctx.Err()
-		}
-		if retErr != nil {
-			// If we are returning an error, immediately close all compression
-			// codecs to release resources.  This step is also done in the
-			// Close() method, but doing this clean-up as soon as we know
-			// an error has occurred, ensures that we do not leak resources,
-			// even if the Close() method is not called.
-			retErr = errors.CombineErrors(retErr, s.closeAllCodecs())
-		}
-	}()
+	defer s.closeCodecsOnError(ctx, &retErr)
 
 	s.metrics.recordMessageSize(int64(len(key) + len(value)))
 	file, err := s.getOrCreateFile(topic, mvcc)
@@ -571,6 +587,69 @@ func (s *cloudStorageSink) EmitRow(
 	return nil
 }
 
+// commitGroupFileTopic is the topic component of the names of files holding
+// commit-grouped output. These files use schema ID zero, which no
+// descriptor version uses, so they never share a key with the per-table files
+// holding rows read by scans.
+const commitGroupFileTopic = `commit_groups`
+
+// EmitCommitGroup implements the commitGroupingSink interface. The rows of all
+// commit groups are written, in commit timestamp order, to a single file which
+// spans all of the watched tables; topic_in_value is therefore always set
+// along with commit_grouping. A commit group is never split across files.
+func (s *cloudStorageSink) EmitCommitGroup(ctx context.Context, rows []commitGroupRow) (retErr error) {
+	if s.files == nil {
+		return errors.New(`cannot EmitCommitGroup on a closed sink`)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	defer s.closeCodecsOnError(ctx, &retErr)
+
+	file, err := s.getOrCreateFileForKey(cloudStorageSinkKey{topic: commitGroupFileTopic}, rows[0].mvcc)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		s.metrics.recordMessageSize(int64(len(r.key) + len(r.value)))
+		if _, err := file.Write(r.value); err != nil {
+			return err
+		}
+		if _, err := file.Write(s.rowDelimiter); err != nil {
+			return err
+		}
+		file.numMessages++
+	}
+
+	if int64(file.buf.Len()) > s.targetMaxFileSize {
+		s.metrics.recordSizeBasedFlush()
+		if err := s.flushTopicVersions(ctx, file.topic, file.schemaID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeCodecsOnError closes all compression codecs if *retErr is set, or the
+// context is done, upon returning from a method emitting rows.
+func (s *cloudStorageSink) closeCodecsOnError(ctx context.Context, retErr *error) {
+	if !s.compression.enabled() {
+		return
+	}
+	if *retErr == nil {
+		*retErr = ctx.Err()
+	}
+	if *retErr != nil {
+		// If we are returning an error, immediately close all compression
+		// codecs to release resources.  This step is also done in the
+		// Close() method, but doing this clean-up as soon as we know
+		// an error has occurred, ensures that we do not leak resources,
+		// even if the Close() method is not called.
+		*retErr = errors.CombineErrors(*retErr, s.closeAllCodecs())
+	}
+}
+
 // EmitResolvedTimestamp implements the Sink interface.
 func (s *cloudStorageSink) EmitResolvedTimestamp(
 	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
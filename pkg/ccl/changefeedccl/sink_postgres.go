@@ -0,0 +1,670 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq"
+)
+
+// postgresSinkMaxParams bounds the number of placeholders used by a single
+// statement. Postgres rejects statements with more than 65535 of them.
+const postgresSinkMaxParams = 1 << 14
+
+// postgresSerializationFailure is the SQLSTATE returned when a transaction
+// must be retried.
+const postgresSerializationFailure = "40001"
+
+// postgresSink applies the changes of a changefeed to tables in a PostgreSQL
+// compatible database, such as another CockroachDB cluster. Each watched table
+// is replicated into the table of the same schema and name in the database
+// named by the sink URI, so the watched tables must not share a schema and
+// table name across databases. Target schemas and tables which do not exist
+// are created, and columns which are added to a watched table are added to its
+// target table; other schema changes must be made to the target tables by
+// hand.
+//
+// Rows are buffered until the sink is flushed. A flush applies, in a single
+// transaction, the latest version of every buffered row which changed at or
+// below the local span frontier, and keeps the remaining rows buffered. The
+// changefeed is planned on a single node when it uses this sink, so the local
+// frontier is the changefeed's frontier and every transaction applies all of
+// the changes up to a resolved timestamp: the target tables always hold a
+// consistent snapshot of the watched tables. Since replaying changes is
+// idempotent, the changes between the last checkpoint and a restart are
+// simply applied again.
+type postgresSink struct {
+	uri     string
+	db      *gosql.DB
+	oracle  timestampLowerBoundOracle
+	limit   int64
+	retry   retry.Options
+	metrics metricsRecorder
+
+	// tables holds the target table of each watched table.
+	tables map[descpb.ID]postgresTable
+
+	rows  []*postgresRow
+	bytes int64
+	// schemas holds the target schemas known to exist.
+	schemas map[string]struct{}
+	// columns holds the columns known to exist in each target table.
+	columns map[string]map[string]struct{}
+}
+
+// postgresTable is the target table of a watched table.
+type postgresTable struct {
+	schema, name string
+}
+
+// String returns the escaped, schema qualified name of the table.
+func (t postgresTable) String() string {
+	return lexbase.EscapeSQLIdent(t.schema) + `.` + lexbase.EscapeSQLIdent(t.name)
+}
+
+// postgresTargetTables returns the target table of each watched table, which
+// has the schema and name of the watched table. The statement time names of
+// the targets are database qualified when the changefeed uses this sink, so
+// the schema is taken from them. Watched tables which would be replicated
+// into the same target table are rejected.
+func postgresTargetTables(targets changefeedbase.Targets) (map[descpb.ID]postgresTable, error) {
+	tables := make(map[descpb.ID]postgresTable, targets.NumUniqueTables())
+	sources := make(map[postgresTable]changefeedbase.StatementTimeName)
+	if err := targets.EachTarget(func(t changefeedbase.Target) error {
+		if _, ok := tables[t.TableID]; ok {
+			return nil
+		}
+		tn, err := parser.ParseQualifiedTableName(string(t.StatementTimeName))
+		if err != nil {
+			return err
+		}
+		table := postgresTable{schema: catconstants.PublicSchemaName, name: tn.Table()}
+		if tn.ExplicitSchema {
+			table.schema = tn.Schema()
+		}
+		if other, ok := sources[table]; ok {
+			return errors.WithHint(
+				errors.Newf("tables %s and %s would both be replicated into table %s",
+					other, t.StatementTimeName, table),
+				"watch the tables with separate changefeeds into different databases")
+		}
+		sources[table] = t.StatementTimeName
+		tables[t.TableID] = table
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// postgresRow is the latest known version of a row of a column family.
+type postgresRow struct {
+	table  postgresTable
+	family descpb.FamilyID
+	// key is the primary key of the row, formatted to identify it.
+	key string
+	// cols, types and datums describe the columns of the row, starting with
+	// the numKeyCols primary key columns. A deleted row only holds its primary
+	// key.
+	cols       []string
+	types      []*types.T
+	datums     tree.Datums
+	numKeyCols int
+	deleted    bool
+
+	updated, mvcc hlc.Timestamp
+	size          int64
+}
+
+var _ SinkWithEncoder = (*postgresSink)(nil)
+
+func makePostgresSink(
+	u sinkURL,
+	targets changefeedbase.Targets,
+	encodingOpts changefeedbase.EncodingOptions,
+	settings *cluster.Settings,
+	oracle timestampLowerBoundOracle,
+	mb metricsRecorderBuilder,
+) (Sink, error) {
+	if u.Path == `` || u.Path == `/` {
+		return nil, errors.Errorf(`must specify database`)
+	}
+	switch encodingOpts.Envelope {
+	case changefeedbase.OptEnvelopeWrapped, changefeedbase.OptEnvelopeBare:
+	default:
+		return nil, errors.Errorf(`%s=%s is not supported by the %s sink`,
+			changefeedbase.OptEnvelope, encodingOpts.Envelope, u.Scheme)
+	}
+
+	tables, err := postgresTargetTables(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := u.String()
+	u.consumeParam(`sslcert`)
+	u.consumeParam(`sslkey`)
+	u.consumeParam(`sslmode`)
+	u.consumeParam(`sslrootcert`)
+	u.consumeParam(`application_name`)
+
+	if unknownParams := u.remainingQueryParams(); len(unknownParams) > 0 {
+		return nil, errors.Errorf(
+			`unknown postgres sink query parameters: %s`, strings.Join(unknownParams, ", "))
+	}
+
+	return &postgresSink{
+		uri:    uri,
+		oracle: oracle,
+		limit:  changefeedbase.PostgresSinkBufferLimit.Get(&settings.SV),
+		retry: retry.Options{
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     time.Second,
+			MaxRetries:     10,
+		},
+		metrics: mb(requiresResourceAccounting),
+		tables:  tables,
+		schemas: make(map[string]struct{}),
+		columns: make(map[string]map[string]struct{}),
+	}, nil
+}
+
+func isPostgresSink(u *url.URL) bool {
+	switch u.Scheme {
+	case changefeedbase.SinkSchemePostgres, changefeedbase.SinkSchemePostgresql:
+		return true
+	default:
+		return false
+	}
+}
+
+// getConcreteType implements the Sink interface.
+func (s *postgresSink) getConcreteType() sinkType {
+	return sinkTypePostgres
+}
+
+// Dial implements the Sink interface.
+func (s *postgresSink) Dial() error {
+	db, err := gosql.Open(`postgres`, s.uri)
+	if err != nil {
+		return err
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return err
+	}
+	s.db = db
+	return nil
+}
+
+// Close implements the Sink interface.
+func (s *postgresSink) Close() error {
+	s.rows = nil
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// EmitRow does not do anything. It must not be called. It is present so that
+// postgresSink implements the Sink interface.
+func (s *postgresSink) EmitRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	key, value []byte,
+	updated, mvcc hlc.Timestamp,
+	alloc kvevent.Alloc,
+) error {
+	return errors.AssertionFailedf("EmitRow unimplemented by the postgres sink")
+}
+
+// EncodeAndEmitRow buffers a row until the next flush. Implements the
+// SinkWithEncoder interface.
+func (s *postgresSink) EncodeAndEmitRow(
+	ctx context.Context,
+	updatedRow cdcevent.Row,
+	prevRow cdcevent.Row,
+	topic TopicDescriptor,
+	updated, mvcc hlc.Timestamp,
+	encodingOpts changefeedbase.EncodingOptions,
+	alloc kvevent.Alloc,
+) error {
+	// The memory held by the row is accounted for by the sink's buffer limit,
+	// since rows are held until the frontier advances past them.
+	defer alloc.Release(ctx)
+
+	table, ok := s.tables[updatedRow.TableID]
+	if !ok {
+		return errors.AssertionFailedf("no target table for table %s (%d)",
+			updatedRow.TableName, updatedRow.TableID)
+	}
+	r, err := makePostgresRow(updatedRow, table, updated, mvcc)
+	if err != nil {
+		return err
+	}
+	if s.bytes+r.size > s.limit {
+		return errors.WithHintf(
+			errors.Newf("postgres sink buffer exceeded its limit of %s while waiting for the frontier to advance",
+				humanizeutil.IBytes(s.limit)),
+			"consider increasing the %s cluster setting", changefeedbase.PostgresSinkBufferLimit.Key())
+	}
+	s.rows = append(s.rows, r)
+	s.bytes += r.size
+	s.metrics.recordMessageSize(r.size)
+	return nil
+}
+
+func makePostgresRow(
+	row cdcevent.Row, table postgresTable, updated, mvcc hlc.Timestamp,
+) (*postgresRow, error) {
+	r := &postgresRow{
+		table:   table,
+		family:  row.FamilyID,
+		deleted: row.IsDeleted(),
+		updated: updated,
+		mvcc:    mvcc,
+	}
+	isKey := make(map[string]struct{})
+	add := func(d tree.Datum, col cdcevent.ResultColumn) error {
+		r.cols = append(r.cols, col.Name)
+		r.types = append(r.types, col.Typ)
+		r.datums = append(r.datums, d)
+		r.size += int64(d.Size())
+		return nil
+	}
+	if err := row.ForEachKeyColumn().Datum(func(d tree.Datum, col cdcevent.ResultColumn) error {
+		isKey[col.Name] = struct{}{}
+		return add(d, col)
+	}); err != nil {
+		return nil, err
+	}
+	r.numKeyCols = len(r.cols)
+	if r.numKeyCols == 0 {
+		return nil, errors.AssertionFailedf("row of %s has no primary key columns", r.table)
+	}
+
+	f := tree.NewFmtCtx(tree.FmtParsable)
+	f.FormatNode(&r.datums)
+	r.key = f.CloseAndGetString()
+
+	if !r.deleted {
+		if err := row.ForEachColumn().Datum(func(d tree.Datum, col cdcevent.ResultColumn) error {
+			if _, ok := isKey[col.Name]; ok {
+				return nil
+			}
+			return add(d, col)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// newerThan returns whether r is a later version of the row than other.
+func (r *postgresRow) newerThan(other *postgresRow) bool {
+	if r.updated != other.updated {
+		return other.updated.Less(r.updated)
+	}
+	return other.mvcc.Less(r.mvcc)
+}
+
+// Flush applies the buffered rows which changed at or below the local
+// frontier. Implements the Sink interface.
+func (s *postgresSink) Flush(ctx context.Context) error {
+	defer s.metrics.recordFlushRequestCallback()()
+	if s.oracle == nil || len(s.rows) == 0 {
+		return nil
+	}
+
+	// Rows at or above the inclusive lower bound may belong to transactions
+	// which have not been seen in their entirety yet.
+	bound := s.oracle.inclusiveLowerBoundTS()
+	var pending []*postgresRow
+	var pendingBytes int64
+	latest := make(map[string]*postgresRow)
+	var ids []string
+	oldestMVCC := hlc.MaxTimestamp
+	for _, r := range s.rows {
+		if !r.updated.Less(bound) {
+			pending = append(pending, r)
+			pendingBytes += r.size
+			continue
+		}
+		if r.mvcc.Less(oldestMVCC) {
+			oldestMVCC = r.mvcc
+		}
+		id := fmt.Sprintf("%s/%d/%s", r.table.String(), r.family, r.key)
+		if prev, ok := latest[id]; !ok {
+			ids = append(ids, id)
+		} else if prev.newerThan(r) {
+			continue
+		}
+		latest[id] = r
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	ready := make([]*postgresRow, len(ids))
+	for i, id := range ids {
+		ready[i] = latest[id]
+	}
+
+	start := timeutil.Now()
+	if err := s.ensureTables(ctx, ready); err != nil {
+		return err
+	}
+	stmts := makePostgresStatements(ready)
+	if err := s.apply(ctx, stmts); err != nil {
+		return err
+	}
+	s.metrics.recordEmittedBatch(start, len(ready), oldestMVCC, int(s.bytes-pendingBytes), sinkDoesNotCompress)
+	s.rows = pending
+	s.bytes = pendingBytes
+	return nil
+}
+
+// ensureTables creates the target schemas and tables of the rows and adds the
+// columns they are missing. Schema changes are made outside of the
+// transaction which applies the rows, as CockroachDB does not support mixing
+// them with writes to the same table.
+func (s *postgresSink) ensureTables(ctx context.Context, rows []*postgresRow) error {
+	for _, r := range rows {
+		if _, ok := s.schemas[r.table.schema]; !ok {
+			stmt := fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s`, lexbase.EscapeSQLIdent(r.table.schema))
+			if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+				return errors.Wrapf(err, "creating schema %s", r.table.schema)
+			}
+			s.schemas[r.table.schema] = struct{}{}
+		}
+		known, ok := s.columns[r.table.String()]
+		if !ok {
+			if _, err := s.db.ExecContext(ctx, postgresCreateTableStmt(r)); err != nil {
+				return errors.Wrapf(err, "creating table %s", r.table)
+			}
+			// The table may have existed before without some of the row's
+			// columns, so only its primary key is known to exist.
+			known = make(map[string]struct{})
+			for _, col := range r.cols[:r.numKeyCols] {
+				known[col] = struct{}{}
+			}
+			s.columns[r.table.String()] = known
+		}
+		for i, col := range r.cols {
+			if _, ok := known[col]; ok {
+				continue
+			}
+			stmt := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`,
+				r.table, lexbase.EscapeSQLIdent(col), postgresColumnType(r.types[i]))
+			if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+				return errors.Wrapf(err, "adding column %s to table %s", col, r.table)
+			}
+			known[col] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// apply executes the statements in a single transaction, retrying it on
+// serialization failures.
+func (s *postgresSink) apply(ctx context.Context, stmts []postgresStatement) error {
+	var err error
+	for r := retry.StartWithCtx(ctx, s.retry); r.Next(); {
+		if err = s.applyOnce(ctx, stmts); err == nil {
+			return nil
+		}
+		var pqErr *pq.Error
+		if !errors.As(err, &pqErr) || pqErr.Code != postgresSerializationFailure {
+			return err
+		}
+		s.metrics.recordInternalRetry(int64(len(stmts)), false /* reducedBatchSize */)
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+func (s *postgresSink) applyOnce(ctx context.Context, stmts []postgresStatement) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil /* opts */)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt.sql, stmt.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// EmitResolvedTimestamp implements the Sink interface. The rows are applied
+// when the sink is flushed, so there is nothing to emit.
+func (s *postgresSink) EmitResolvedTimestamp(
+	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
+) error {
+	defer s.metrics.recordResolvedCallback()()
+	return nil
+}
+
+// postgresStatement is a statement with its placeholder arguments.
+type postgresStatement struct {
+	sql  string
+	args []interface{}
+}
+
+// makePostgresStatements returns the statements which apply the rows, which
+// must each be the latest version of a distinct row. For each table, in name
+// order, the deleted rows are deleted before the other rows are upserted.
+func makePostgresStatements(rows []*postgresRow) []postgresStatement {
+	byTable := make(map[string][]*postgresRow)
+	var tables []string
+	for _, r := range rows {
+		table := r.table.String()
+		if _, ok := byTable[table]; !ok {
+			tables = append(tables, table)
+		}
+		byTable[table] = append(byTable[table], r)
+	}
+	sort.Strings(tables)
+
+	var stmts []postgresStatement
+	for _, table := range tables {
+		rows := byTable[table]
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].key != rows[j].key {
+				return rows[i].key < rows[j].key
+			}
+			return rows[i].family < rows[j].family
+		})
+
+		// Deletes are issued once per row rather than once per column family.
+		var deletes []*postgresRow
+		deleted := make(map[string]struct{})
+		// Upserts are grouped by the columns they write, since the rows of
+		// different column families write different columns.
+		upserts := make(map[string][]*postgresRow)
+		var upsertCols []string
+		for _, r := range rows {
+			if r.deleted {
+				if _, ok := deleted[r.key]; !ok {
+					deleted[r.key] = struct{}{}
+					deletes = append(deletes, r)
+				}
+				continue
+			}
+			cols := strings.Join(r.cols, ",")
+			if _, ok := upserts[cols]; !ok {
+				upsertCols = append(upsertCols, cols)
+			}
+			upserts[cols] = append(upserts[cols], r)
+		}
+
+		for _, chunk := range chunkPostgresRows(deletes) {
+			stmts = append(stmts, makePostgresDelete(table, chunk))
+		}
+		for _, cols := range upsertCols {
+			for _, chunk := range chunkPostgresRows(upserts[cols]) {
+				stmts = append(stmts, makePostgresUpsert(table, chunk))
+			}
+		}
+	}
+	return stmts
+}
+
+// chunkPostgresRows splits rows, which write the same columns, into chunks
+// that can be written by a single statement.
+func chunkPostgresRows(rows []*postgresRow) (chunks [][]*postgresRow) {
+	if len(rows) == 0 {
+		return nil
+	}
+	perChunk := postgresSinkMaxParams / len(rows[0].cols)
+	if perChunk == 0 {
+		perChunk = 1
+	}
+	for len(rows) > perChunk {
+		chunks = append(chunks, rows[:perChunk])
+		rows = rows[perChunk:]
+	}
+	return append(chunks, rows)
+}
+
+func makePostgresDelete(table string, rows []*postgresRow) postgresStatement {
+	var b strings.Builder
+	var args []interface{}
+	keyCols := rows[0].cols[:rows[0].numKeyCols]
+	fmt.Fprintf(&b, `DELETE FROM %s WHERE (%s) IN (`, table, postgresColumnList(keyCols))
+	for i, r := range rows {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		b.WriteString(`(`)
+		for j := 0; j < r.numKeyCols; j++ {
+			if j > 0 {
+				b.WriteString(`, `)
+			}
+			args = append(args, postgresArg(r.datums[j]))
+			fmt.Fprintf(&b, `$%d`, len(args))
+		}
+		b.WriteString(`)`)
+	}
+	b.WriteString(`)`)
+	return postgresStatement{sql: b.String(), args: args}
+}
+
+func makePostgresUpsert(table string, rows []*postgresRow) postgresStatement {
+	var b strings.Builder
+	var args []interface{}
+	cols := rows[0].cols
+	keyCols := cols[:rows[0].numKeyCols]
+	fmt.Fprintf(&b, `INSERT INTO %s (%s) VALUES `, table, postgresColumnList(cols))
+	for i, r := range rows {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		b.WriteString(`(`)
+		for j, d := range r.datums {
+			if j > 0 {
+				b.WriteString(`, `)
+			}
+			args = append(args, postgresArg(d))
+			fmt.Fprintf(&b, `$%d`, len(args))
+		}
+		b.WriteString(`)`)
+	}
+	fmt.Fprintf(&b, ` ON CONFLICT (%s) DO `, postgresColumnList(keyCols))
+	if len(cols) == len(keyCols) {
+		b.WriteString(`NOTHING`)
+	} else {
+		b.WriteString(`UPDATE SET `)
+		for i, col := range cols[len(keyCols):] {
+			if i > 0 {
+				b.WriteString(`, `)
+			}
+			col = lexbase.EscapeSQLIdent(col)
+			fmt.Fprintf(&b, `%s = excluded.%s`, col, col)
+		}
+	}
+	return postgresStatement{sql: b.String(), args: args}
+}
+
+// postgresCreateTableStmt returns the statement creating the target table of
+// a row if it does not exist.
+func postgresCreateTableStmt(r *postgresRow) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `CREATE TABLE IF NOT EXISTS %s (`, r.table)
+	for i, col := range r.cols {
+		fmt.Fprintf(&b, `%s %s, `, lexbase.EscapeSQLIdent(col), postgresColumnType(r.types[i]))
+	}
+	fmt.Fprintf(&b, `PRIMARY KEY (%s))`, postgresColumnList(r.cols[:r.numKeyCols]))
+	return b.String()
+}
+
+func postgresColumnList(cols []string) string {
+	escaped := make([]string, len(cols))
+	for i, col := range cols {
+		escaped[i] = lexbase.EscapeSQLIdent(col)
+	}
+	return strings.Join(escaped, `, `)
+}
+
+// postgresColumnType returns the type of the target column for a column of
+// the given type. User defined types do not exist in the target database, so
+// their values are written as text.
+func postgresColumnType(typ *types.T) string {
+	if typ.UserDefined() {
+		return `text`
+	}
+	return typ.SQLStandardName()
+}
+
+// postgresArg returns the placeholder argument for a datum. Values are passed
+// in their Postgres text format, which the target database parses according
+// to the type of the column they are written to.
+func postgresArg(d tree.Datum) interface{} {
+	if d == tree.DNull {
+		return nil
+	}
+	switch t := tree.UnwrapDOidWrapper(d).(type) {
+	case *tree.DBytes:
+		return []byte(*t)
+	case *tree.DString:
+		return string(*t)
+	case *tree.DEnum:
+		return t.LogicalRep
+	default:
+		return tree.AsStringWithFlags(d, tree.FmtPgwireText)
+	}
+}
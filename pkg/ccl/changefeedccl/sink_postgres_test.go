@@ -0,0 +1,204 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// testPostgresRow returns a row of the table `randtbl` with an INT primary key
+// and a STRING column, plus a DECIMAL column if dec is set.
+func testPostgresRow(k int, v string, dec *tree.DDecimal, deleted bool) cdcevent.Row {
+	encRow := rowenc.EncDatumRow{
+		rowenc.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(k))),
+		rowenc.DatumToEncDatum(types.String, tree.NewDString(v)),
+	}
+	colTypes := []*types.T{types.Int, types.String}
+	if dec != nil {
+		encRow = append(encRow, rowenc.DatumToEncDatum(types.Decimal, dec))
+		colTypes = append(colTypes, types.Decimal)
+	}
+	return cdcevent.TestingMakeEventRowFromEncDatums(encRow, colTypes, 1 /* numKeyCols */, deleted)
+}
+
+func TestPostgresSinkStatements(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := hlc.Timestamp{WallTime: 1}
+	var rows []*postgresRow
+	for _, row := range []cdcevent.Row{
+		testPostgresRow(2, `b`, nil, false),
+		testPostgresRow(1, `a`, nil, false),
+		testPostgresRow(3, ``, nil, true),
+	} {
+		r, err := makePostgresRow(row, postgresTable{schema: `public`, name: `randtbl`}, ts, ts)
+		require.NoError(t, err)
+		rows = append(rows, r)
+	}
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "public"."randtbl" ("col_int" bigint, "col_string" text, `+
+		`PRIMARY KEY ("col_int"))`, postgresCreateTableStmt(rows[0]))
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "public"."randtbl" ("col_int" bigint, PRIMARY KEY ("col_int"))`,
+		postgresCreateTableStmt(rows[2]))
+
+	stmts := makePostgresStatements(rows)
+	require.Equal(t, []postgresStatement{
+		{
+			sql:  `DELETE FROM "public"."randtbl" WHERE ("col_int") IN (($1))`,
+			args: []interface{}{`3`},
+		},
+		{
+			sql: `INSERT INTO "public"."randtbl" ("col_int", "col_string") VALUES ($1, $2), ($3, $4) ` +
+				`ON CONFLICT ("col_int") DO UPDATE SET "col_string" = excluded."col_string"`,
+			args: []interface{}{`1`, `a`, `2`, `b`},
+		},
+	}, stmts)
+
+	// Rows needing more placeholders than one statement allows are split up.
+	many := make([]*postgresRow, postgresSinkMaxParams)
+	for i := range many {
+		many[i] = rows[0]
+	}
+	chunks := chunkPostgresRows(many)
+	require.Len(t, chunks, 2)
+	require.Len(t, chunks[0], postgresSinkMaxParams/2)
+
+	require.Nil(t, postgresArg(tree.DNull))
+	require.Equal(t, []byte(`x`), postgresArg(tree.NewDBytes(`x`)))
+	require.Equal(t, `{1,2}`, postgresArg(&tree.DArray{
+		ParamTyp: types.Int, Array: tree.Datums{tree.NewDInt(1), tree.NewDInt(2)},
+	}))
+}
+
+func TestPostgresTargetTables(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	makeTargets := func(names ...string) changefeedbase.Targets {
+		var targets changefeedbase.Targets
+		for i, name := range names {
+			targets.Add(changefeedbase.Target{
+				TableID:           descpb.ID(100 + i),
+				StatementTimeName: changefeedbase.StatementTimeName(name),
+			})
+		}
+		return targets
+	}
+
+	// Tables are replicated into the table of the same schema and name.
+	tables, err := postgresTargetTables(makeTargets(`d.public.t`, `d.s.t`, `e.public.u`, `v`))
+	require.NoError(t, err)
+	require.Equal(t, map[descpb.ID]postgresTable{
+		100: {schema: `public`, name: `t`},
+		101: {schema: `s`, name: `t`},
+		102: {schema: `public`, name: `u`},
+		103: {schema: `public`, name: `v`},
+	}, tables)
+	require.Equal(t, `"s"."t"`, tables[101].String())
+
+	// Tables of different databases with the same schema and name collide.
+	_, err = postgresTargetTables(makeTargets(`d.public.t`, `e.public.t`))
+	require.Regexp(t, `would both be replicated into table "public"."t"`, err)
+}
+
+type testPostgresSinkOracle struct {
+	ts hlc.Timestamp
+}
+
+func (o *testPostgresSinkOracle) inclusiveLowerBoundTS() hlc.Timestamp {
+	return o.ts
+}
+
+func TestPostgresSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDBRaw, _ := serverutils.StartServer(t, base.TestServerArgs{UseDatabase: "d"})
+	defer s.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(sqlDBRaw)
+	sqlDB.Exec(t, `CREATE DATABASE d`)
+
+	pgURL, cleanup := sqlutils.PGUrl(t, s.ApplicationLayer().AdvSQLAddr(), t.Name(), url.User(username.RootUser))
+	defer cleanup()
+	pgURL.Path = `d`
+
+	var targets changefeedbase.Targets
+	targets.Add(changefeedbase.Target{TableID: 42, StatementTimeName: `d.public.randtbl`})
+	oracle := &testPostgresSinkOracle{}
+	sink, err := makePostgresSink(sinkURL{URL: &pgURL}, targets,
+		changefeedbase.EncodingOptions{Envelope: changefeedbase.OptEnvelopeWrapped},
+		cluster.MakeTestingClusterSettings(), oracle, nilMetricsRecorderBuilder)
+	require.NoError(t, err)
+	ps := sink.(*postgresSink)
+	require.NoError(t, ps.Dial())
+	defer func() { require.NoError(t, ps.Close()) }()
+
+	ts := func(wt int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wt} }
+	emit := func(row cdcevent.Row, wt int64) {
+		require.NoError(t, ps.EncodeAndEmitRow(ctx, row, cdcevent.Row{}, nil, /* topic */
+			ts(wt), ts(wt), changefeedbase.EncodingOptions{}, zeroAlloc))
+	}
+	query := `SELECT * FROM d.randtbl ORDER BY col_int`
+
+	// Rows are only applied once the frontier has passed them, and only their
+	// latest version is applied.
+	emit(testPostgresRow(1, `a`, nil, false), 1)
+	emit(testPostgresRow(2, `b`, nil, false), 1)
+	emit(testPostgresRow(1, `c`, nil, false), 2)
+	emit(testPostgresRow(2, `d`, nil, false), 3)
+	oracle.ts = ts(1)
+	require.NoError(t, ps.Flush(ctx))
+	require.Len(t, ps.rows, 4)
+	oracle.ts = ts(3)
+	require.NoError(t, ps.Flush(ctx))
+	sqlDB.CheckQueryResults(t, query, [][]string{{`1`, `c`}, {`2`, `b`}})
+	require.Len(t, ps.rows, 1)
+
+	// Deletes are applied, and columns added to the watched table are added to
+	// the target table.
+	dec, err := tree.ParseDDecimal(`1.5`)
+	require.NoError(t, err)
+	emit(testPostgresRow(1, ``, nil, true), 4)
+	emit(testPostgresRow(3, `e`, dec, false), 4)
+	oracle.ts = ts(5)
+	require.NoError(t, ps.Flush(ctx))
+	require.Empty(t, ps.rows)
+	sqlDB.CheckQueryResults(t, query, [][]string{{`2`, `d`, `NULL`}, {`3`, `e`, `1.5`}})
+
+	// Rows are buffered up to a limit.
+	ps.limit = 1
+	require.Regexp(t, `postgres sink buffer exceeded its limit`,
+		ps.EncodeAndEmitRow(ctx, testPostgresRow(4, `f`, nil, false), cdcevent.Row{}, nil, /* topic */
+			ts(6), ts(6), changefeedbase.EncodingOptions{}, zeroAlloc))
+
+	// The sink rejects envelopes without the full row.
+	_, err = makePostgresSink(sinkURL{URL: &pgURL}, targets,
+		changefeedbase.EncodingOptions{Envelope: changefeedbase.OptEnvelopeKeyOnly},
+		cluster.MakeTestingClusterSettings(), oracle, nilMetricsRecorderBuilder)
+	require.Regexp(t, `envelope=key_only is not supported`, err)
+}
@@ -141,10 +141,12 @@ type deprecatedMessagePayload struct {
 	mvcc     hlc.Timestamp
 }
 
-// webhookMessage contains either messagePayload or a flush request.
+// webhookMessage contains either messagePayload, the rows of a commit group
+// or a flush request.
 type webhookMessage struct {
-	flushDone *chan struct{}
-	payload   deprecatedMessagePayload
+	flushDone   *chan struct{}
+	payload     deprecatedMessagePayload
+	commitGroup []deprecatedMessagePayload
 }
 
 type batch struct {
@@ -445,16 +447,47 @@ func (s *deprecatedWebhookSink) splitAndSendBatch(batch []deprecatedMessagePaylo
 	return nil
 }
 
+// waitForWorker waits until the specified worker has processed all messages
+// sent to it.
+func (s *deprecatedWebhookSink) waitForWorker(i int) error {
+	// Ability to write a nil message to events channel indicates that
+	// the worker has processed all other messages.
+	select {
+	case <-s.workerCtx.Done():
+		return s.workerCtx.Err()
+	case s.eventsChans[i] <- nil:
+		return nil
+	}
+}
+
+// sendCommitGroup sends the rows of a commit group in a single request. The
+// request is only sent once every row sent before it has been delivered, and
+// sendCommitGroup returns once it has been delivered, so commit groups are
+// delivered in the order they were emitted, and rows emitted afterwards are
+// delivered after them.
+func (s *deprecatedWebhookSink) sendCommitGroup(pending, group []deprecatedMessagePayload) error {
+	if err := s.splitAndSendBatch(pending); err != nil {
+		return err
+	}
+	for i := 0; i < len(s.eventsChans); i++ {
+		if err := s.waitForWorker(i); err != nil {
+			return err
+		}
+	}
+	select {
+	case <-s.workerCtx.Done():
+		return s.workerCtx.Err()
+	case s.eventsChans[0] <- group:
+	}
+	return s.waitForWorker(0)
+}
+
 // flushWorkers sends flush request to each worker and waits for each one to
 // acknowledge.
 func (s *deprecatedWebhookSink) flushWorkers(done chan struct{}) error {
 	for i := 0; i < len(s.eventsChans); i++ {
-		// Ability to write a nil message to events channel indicates that
-		// the worker has processed all other messages.
-		select {
-		case <-s.workerCtx.Done():
-			return s.workerCtx.Err()
-		case s.eventsChans[i] <- nil:
+		if err := s.waitForWorker(i); err != nil {
+			return err
 		}
 	}
 
@@ -478,6 +511,15 @@ func (s *deprecatedWebhookSink) batchWorker() {
 		case <-s.workerCtx.Done():
 			return
 		case msg := <-s.batchChan:
+			if msg.commitGroup != nil {
+				if err := s.sendCommitGroup(batchTracker.buffer, msg.commitGroup); err != nil {
+					s.exitWorkersWithError(err)
+					return
+				}
+				batchTracker.reset()
+				continue
+			}
+
 			flushRequested := msg.flushDone != nil
 
 			if !flushRequested {
@@ -658,6 +700,40 @@ func (s *deprecatedWebhookSink) EmitRow(
 	return nil
 }
 
+// EmitCommitGroup implements the commitGroupingSink interface.
+func (s *deprecatedWebhookSink) EmitCommitGroup(ctx context.Context, rows []commitGroupRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	emitTime := timeutil.Now()
+	msgs := make([]deprecatedMessagePayload, len(rows))
+	for i, r := range rows {
+		msgs[i] = deprecatedMessagePayload{
+			key:      r.key,
+			val:      r.value,
+			emitTime: emitTime,
+			mvcc:     r.mvcc,
+		}
+	}
+
+	select {
+	// check the webhook sink context in case workers have been terminated
+	case <-s.workerCtx.Done():
+		// check again for error in case it triggered since last check
+		// will return more verbose error instead of "context canceled"
+		return errors.CombineErrors(s.workerCtx.Err(), s.sinkError())
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-s.errChan:
+		return err
+	case s.batchChan <- webhookMessage{commitGroup: msgs}:
+		for _, m := range msgs {
+			s.metrics.recordMessageSize(int64(len(m.key) + len(m.val)))
+		}
+	}
+	return nil
+}
+
 func (s *deprecatedWebhookSink) EmitResolvedTimestamp(
 	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
 ) error {
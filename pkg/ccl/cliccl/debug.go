@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -26,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/cli/cliflagcfg"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -56,7 +58,9 @@ func init() {
 Shows encryption status of the store located in 'directory'.
 Encryption keys must be specified in the '--enterprise-encryption' flag.
 
-Displays all store and data keys as well as files encrypted with each.
+Displays all store and data keys as well as files encrypted with each, followed
+by the progress of re-encrypting sstables after a store key rotation. The store
+is fully rotated once no sstable uses a data key of a retired store key.
 Specifying --active-store-key-id-only prints the key ID of the active store key
 and exits.
 `,
@@ -307,6 +311,23 @@ func runEncryptionStatus(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Printf("%s\n", j)
 
+	status, err := db.GetReencryptionStatus()
+	if err != nil {
+		return err
+	}
+	if status.FullyRotated() {
+		fmt.Printf("fully rotated: all %d files (%s) use data keys of the active store key\n",
+			status.TotalFiles, humanizeutil.IBytes(int64(status.TotalBytes)))
+	} else {
+		fmt.Printf("not fully rotated: %d of %d files (%s of %s) use data keys of retired store keys\n",
+			status.RetiredKeyFiles, status.TotalFiles,
+			humanizeutil.IBytes(int64(status.RetiredKeyBytes)), humanizeutil.IBytes(int64(status.TotalBytes)))
+		if len(status.UnrewritableFiles) > 0 {
+			fmt.Printf("files that are not re-encrypted while the store is running: %s\n",
+				strings.Join(status.UnrewritableFiles, ", "))
+		}
+	}
+
 	if len(fileKeyMap) > 0 {
 		fmt.Fprintf(os.Stderr, "WARNING: could not find key info for some files: %+v\n", fileKeyMap)
 	}
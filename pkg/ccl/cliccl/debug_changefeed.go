@@ -0,0 +1,59 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package cliccl
+
+import (
+	"context"
+	"io"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl"
+	"github.com/cockroachdb/cockroach/pkg/cli"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+func init() {
+	cli.DebugChangefeedFromBackupHook = runDebugChangefeedFromBackup
+}
+
+// runDebugChangefeedFromBackup replays the changefeed of the given statement
+// from the chain of backups at the given URIs, without a cluster.
+func runDebugChangefeedFromBackup(
+	ctx context.Context, out io.Writer, backupURIs []string, stmt *tree.CreateChangefeed,
+) (resErr error) {
+	st := cluster.MakeClusterSettings()
+	stores := make([]cloud.ExternalStorage, 0, len(backupURIs))
+	defer func() {
+		for _, store := range stores {
+			resErr = errors.CombineErrors(resErr, store.Close())
+		}
+	}()
+	for _, uri := range backupURIs {
+		store, err := cloud.ExternalStorageFromURI(
+			ctx,
+			uri,
+			base.ExternalIODirConfig{},
+			st,
+			nil, /* blobClientFactory */
+			username.RootUserName(),
+			nil, /* db */
+			nil, /* limiters */
+			cloud.NilMetrics,
+		)
+		if err != nil {
+			return errors.Wrap(err, "opening backup")
+		}
+		stores = append(stores, store)
+	}
+	return changefeedccl.ReplayBackup(ctx, st, stores, stmt, out)
+}
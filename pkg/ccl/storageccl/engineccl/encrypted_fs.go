@@ -290,6 +290,10 @@ func (e *encryptionStatsHandler) GetKeyIDFromSettings(settings []byte) (string,
 	return s.KeyId, nil
 }
 
+func (e *encryptionStatsHandler) IsDataKeyRetired(keyID string) bool {
+	return e.dataKM.isRetired(keyID)
+}
+
 // init initializes function hooks used in non-CCL code.
 func init() {
 	storage.NewEncryptedEnvFunc = newEncryptedEnv
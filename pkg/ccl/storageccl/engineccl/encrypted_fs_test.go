@@ -415,6 +415,99 @@ func TestPebbleEncryption2(t *testing.T) {
 	addKeyAndValidate("d", "d", "plain", "16v2.key")
 }
 
+func TestPebbleReencryption(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	memFS := vfs.NewMem()
+	writeToFile(t, memFS, "16v1.key", []byte("111111111111111111111111111111111234567890123456"))
+	writeToFile(t, memFS, "16v2.key", []byte("111111111111111111111111111111198765432198765432"))
+
+	open := func(encKeyFile, oldEncKeyFile string) *storage.Pebble {
+		encOptionsBytes, err := protoutil.Marshal(&baseccl.EncryptionOptions{
+			KeySource: baseccl.EncryptionKeySource_KeyFiles,
+			KeyFiles: &baseccl.EncryptionKeyFiles{
+				CurrentKey: encKeyFile,
+				OldKey:     oldEncKeyFile,
+			},
+			DataKeyRotationPeriod: 1000,
+		})
+		require.NoError(t, err)
+		opts := storage.DefaultPebbleOptions()
+		opts.FS = memFS
+		opts.Cache = pebble.NewCache(1 << 20)
+		defer opts.Cache.Unref()
+		db, err := storage.NewPebble(ctx, storage.PebbleConfig{
+			StorageConfig: base.StorageConfig{
+				Settings:          cluster.MakeTestingClusterSettings(),
+				MaxSize:           512 << 20,
+				UseFileRegistry:   true,
+				EncryptionOptions: encOptionsBytes,
+			},
+			Opts: opts,
+		})
+		require.NoError(t, err)
+		return db
+	}
+
+	// Write two sstables under the first store key.
+	db := open("16v1.key", "plain")
+	for _, k := range []string{"a", "b"} {
+		require.NoError(t, db.PutUnversioned(roachpb.Key(k), []byte(k)))
+		require.NoError(t, db.Flush())
+	}
+	status, err := db.GetReencryptionStatus()
+	require.NoError(t, err)
+	// The status covers the WAL, MANIFEST and OPTIONS files besides the
+	// sstables.
+	require.Greater(t, status.TotalFiles, uint64(2))
+	require.True(t, status.FullyRotated())
+	db.Close()
+
+	// After rotating the store key, both sstables use a retired data key, and
+	// so do the files written before the rotation that the engine keeps, such
+	// as the previous MANIFEST.
+	db = open("16v2.key", "16v1.key")
+	status, err = db.GetReencryptionStatus()
+	require.NoError(t, err)
+	require.Greater(t, status.RetiredKeyFiles, uint64(2))
+	require.False(t, status.FullyRotated())
+	stats, err := db.GetEnvStats()
+	require.NoError(t, err)
+	require.Equal(t, status.RetiredKeyFiles, stats.RetiredKeyFiles)
+	require.Equal(t, status.RetiredKeyBytes, stats.RetiredKeyBytes)
+
+	// Re-encryption rewrites the sstables and WALs, but not the MANIFEST and
+	// OPTIONS files, which are rewritten when the engine is restarted.
+	for i := 0; ; i++ {
+		require.Less(t, i, 10, "re-encryption did not complete")
+		_, err := db.ReencryptNextSSTable(ctx)
+		require.NoError(t, err)
+		status, err = db.GetReencryptionStatus()
+		require.NoError(t, err)
+		if status.RetiredKeyFiles == uint64(len(status.UnrewritableFiles)) {
+			break
+		}
+	}
+	for _, f := range status.UnrewritableFiles {
+		require.True(t, strings.HasPrefix(f, "MANIFEST") || strings.HasPrefix(f, "OPTIONS"), f)
+	}
+	db.Close()
+
+	db = open("16v2.key", "16v1.key")
+	defer db.Close()
+	status, err = db.GetReencryptionStatus()
+	require.NoError(t, err)
+	require.True(t, status.FullyRotated(), "%+v", status)
+	require.NotZero(t, status.TotalFiles)
+	n, err := db.ReencryptNextSSTable(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	for _, k := range []string{"a", "b"} {
+		require.Equal(t, []byte(k), storageutils.MVCCGetRaw(t, db, storageutils.PointKey(k, 0)))
+	}
+}
+
 func TestCanRegistryElide(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -345,6 +345,27 @@ func (m *DataKeyManager) getScrubbedRegistry() *enginepbccl.DataKeysRegistry {
 	return r
 }
 
+// isRetired returns whether the data key with the given ID was generated under
+// a store key other than the active one. An empty ID denotes plaintext, which
+// is only retired if the active store key is not plaintext. Before the active
+// store key is set, no key is retired.
+func (m *DataKeyManager) isRetired(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	activeStoreKey := m.mu.keyRegistry.StoreKeys[m.mu.keyRegistry.ActiveStoreKeyId]
+	if activeStoreKey == nil {
+		return false
+	}
+	if id == "" || id == plainKeyID {
+		return activeStoreKey.EncryptionType != enginepbccl.EncryptionType_Plaintext
+	}
+	key, found := m.mu.keyRegistry.DataKeys[id]
+	if !found {
+		return true
+	}
+	return key.Info.ParentKeyId != activeStoreKey.KeyId
+}
+
 func validateRegistry(keyRegistry *enginepbccl.DataKeysRegistry) error {
 	if keyRegistry.ActiveStoreKeyId != "" && keyRegistry.StoreKeys[keyRegistry.ActiveStoreKeyId] == nil {
 		return fmt.Errorf("active store key %s not found", keyRegistry.ActiveStoreKeyId)
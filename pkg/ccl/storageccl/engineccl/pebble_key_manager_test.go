@@ -372,6 +372,16 @@ func TestDataKeyManager(t *testing.T) {
 				rv := CompareKeys(lastActiveDataKey, key)
 				lastActiveDataKey = key
 				return rv
+			case "is-data-key-retired":
+				// Checks the data key with the given id, or else the last active data
+				// key.
+				var id string
+				if d.HasArg("id") {
+					d.ScanArgs(t, "id", &id)
+				} else if lastActiveDataKey != nil {
+					id = lastActiveDataKey.Info.KeyId
+				}
+				return fmt.Sprintf("%t\n", dkm.isRetired(id))
 			case "check-all-recorded-data-keys":
 				actual := fmt.Sprint(dkm.mu.keyRegistry.DataKeys)
 				expected := fmt.Sprint(keyMap)
@@ -0,0 +1,371 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package logical
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl/streamclient"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/repstream/streampb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/span"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// logicalReplicationResumer consumes a replication stream of tables of a
+// source cluster and applies the replicated rows to tables of this cluster.
+//
+// The rows are applied by the node the job runs on. Each partition of the
+// stream is consumed concurrently, and the progress of the job is the frontier
+// of the resolved timestamps of all of them.
+type logicalReplicationResumer struct {
+	job *jobs.Job
+}
+
+var _ jobs.Resumer = (*logicalReplicationResumer)(nil)
+
+// Resume is part of the jobs.Resumer interface.
+func (r *logicalReplicationResumer) Resume(ctx context.Context, execCtx interface{}) error {
+	jobExecCtx := execCtx.(sql.JobExecContext)
+	if err := r.ingestWithRetries(ctx, jobExecCtx); err != nil {
+		// Like physical replication, a logical replication job should never fail
+		// and lose its progress, only pause.
+		const errorFmt = "logical replication job failed (%s) but is being paused"
+		log.Warningf(ctx, errorFmt, err)
+		return jobs.MarkPauseRequestError(err)
+	}
+	return nil
+}
+
+func (r *logicalReplicationResumer) ingestWithRetries(
+	ctx context.Context, execCtx sql.JobExecContext,
+) error {
+	ro := retry.Options{
+		InitialBackoff: 100 * time.Millisecond,
+		Multiplier:     2,
+		MaxBackoff:     10 * time.Second,
+		MaxRetries:     20,
+	}
+	var err error
+	for rt := retry.StartWithCtx(ctx, ro); rt.Next(); {
+		err = r.ingest(ctx, execCtx)
+		if jobs.IsPermanentJobError(err) || errors.Is(err, context.Canceled) {
+			break
+		}
+		log.Warningf(ctx, "waiting before retrying error: %s", err)
+	}
+	return err
+}
+
+func (r *logicalReplicationResumer) ingest(ctx context.Context, execCtx sql.JobExecContext) error {
+	execCfg := execCtx.ExecCfg()
+	details := r.job.Details().(jobspb.LogicalReplicationDetails)
+	progress := r.job.Progress().GetLogicalReplication()
+	streamID := streampb.StreamID(details.StreamID)
+
+	client, err := streamclient.NewStreamClient(ctx,
+		streamingccl.StreamAddress(details.SourceClusterConnStr), execCfg.InternalDB)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(ctx); err != nil {
+			log.Warningf(ctx, "error encountered when closing stream client: %s", err)
+		}
+	}()
+
+	topology, err := client.Plan(ctx, streamID)
+	if err != nil {
+		return err
+	}
+
+	srcTables := make([]catalog.TableDescriptor, len(details.SourceDescriptors))
+	for i := range details.SourceDescriptors {
+		srcTables[i] = tabledesc.NewBuilder(&details.SourceDescriptors[i]).BuildImmutableTable()
+	}
+	dstTables := make([]catalog.TableDescriptor, len(details.ReplicationPairs))
+	if err := sql.DescsTxn(ctx, execCfg, func(ctx context.Context, txn isql.Txn, col *descs.Collection) error {
+		for i, pair := range details.ReplicationPairs {
+			td, err := col.ByIDWithLeased(txn.KV()).WithoutNonPublic().Get().Table(ctx, descpb.ID(pair.DstDescriptorID))
+			if err != nil {
+				return err
+			}
+			if err := validateTablePair(srcTables[i], td); err != nil {
+				return jobs.MarkAsPermanentJobError(err)
+			}
+			dstTables[i] = td
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var spans []roachpb.Span
+	for _, p := range topology.Partitions {
+		spans = append(spans, p.Spans...)
+	}
+	frontier, err := span.MakeFrontierAt(progress.ReplicatedTime, spans...)
+	if err != nil {
+		return err
+	}
+	for _, resolved := range progress.Checkpoint {
+		if _, err := frontier.Forward(resolved.Span, resolved.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	srcCodec := keys.MakeSQLCodec(topology.SourceTenantID)
+	c := &logicalReplicationConsumer{
+		job:      r.job,
+		client:   client,
+		streamID: streamID,
+		frontier: frontier,
+	}
+	atomic.StoreInt64(&c.appliedRows, progress.AppliedRows)
+	atomic.StoreInt64(&c.skippedRows, progress.SkippedRows)
+
+	g := ctxgroup.WithContext(ctx)
+	for _, partition := range topology.Partitions {
+		token, err := withFiltering(partition.SubscriptionToken)
+		if err != nil {
+			return err
+		}
+		sub, err := client.Subscribe(ctx, streamID, token,
+			details.ReplicationStartTime, progress.ReplicatedTime)
+		if err != nil {
+			return err
+		}
+		applier, err := newRowApplier(ctx, execCfg.DB, srcCodec, execCfg.Codec, srcTables, dstTables)
+		if err != nil {
+			return err
+		}
+		g.GoCtx(sub.Subscribe)
+		g.GoCtx(func(ctx context.Context) error {
+			return c.consume(ctx, sub, applier)
+		})
+	}
+	g.GoCtx(func(ctx context.Context) error {
+		return c.checkpointLoop(ctx, execCtx)
+	})
+	return g.Wait()
+}
+
+// withFiltering returns the subscription token with filtering of rows that were
+// themselves replicated into the source cluster turned on.
+func withFiltering(token streamclient.SubscriptionToken) (streamclient.SubscriptionToken, error) {
+	var spec streampb.StreamPartitionSpec
+	if err := protoutil.Unmarshal(token, &spec); err != nil {
+		return nil, err
+	}
+	spec.WithFiltering = true
+	return protoutil.Marshal(&spec)
+}
+
+// logicalReplicationConsumer holds the state of a running logical replication
+// job that is shared by the consumers of its partitions.
+type logicalReplicationConsumer struct {
+	job      *jobs.Job
+	client   streamclient.Client
+	streamID streampb.StreamID
+	frontier *span.Frontier
+
+	appliedRows int64 // accessed atomically
+	skippedRows int64 // accessed atomically
+}
+
+// consume applies the events of the subscription until it ends.
+func (c *logicalReplicationConsumer) consume(
+	ctx context.Context, sub streamclient.Subscription, applier *rowApplier,
+) error {
+	// The KVs of the column families of a row written by a transaction are
+	// received one after the other, and are buffered in order to apply them
+	// together.
+	var row []roachpb.KeyValue
+	flush := func() error {
+		if len(row) == 0 {
+			return nil
+		}
+		applied, err := applier.applyRow(ctx, row)
+		row = row[:0]
+		if err != nil {
+			return err
+		}
+		if applied {
+			atomic.AddInt64(&c.appliedRows, 1)
+		} else {
+			atomic.AddInt64(&c.skippedRows, 1)
+		}
+		return nil
+	}
+	for {
+		var event streamingccl.Event
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok = <-sub.Events():
+		default:
+			// Apply the buffered row rather than hold on to it until the next
+			// event is received.
+			if err := flush(); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case event, ok = <-sub.Events():
+			}
+		}
+		if !ok {
+			if err := sub.Err(); err != nil {
+				return err
+			}
+			return flush()
+		}
+		switch event.Type() {
+		case streamingccl.KVEvent:
+			kv := *event.GetKV()
+			if len(row) > 0 && !sameRow(row[0], kv) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			row = append(row, kv)
+		case streamingccl.CheckpointEvent:
+			// The KVs below the resolved timestamps must be applied before the
+			// frontier is forwarded.
+			if err := flush(); err != nil {
+				return err
+			}
+			for _, resolved := range event.GetResolvedSpans() {
+				if _, err := c.frontier.Forward(resolved.Span, resolved.Timestamp); err != nil {
+					return err
+				}
+			}
+		case streamingccl.SSTableEvent, streamingccl.DeleteRangeEvent:
+			return jobs.MarkAsPermanentJobError(errors.Newf(
+				"logical replication does not support event type %v; "+
+					"bulk operations on replicated tables are not supported", event.Type()))
+		default:
+			return errors.AssertionFailedf("unexpected event type %v", event.Type())
+		}
+	}
+}
+
+// checkpointLoop periodically persists the progress of the job and heartbeats
+// the producer job with it, which allows the source cluster to release the
+// history of the replicated tables that is no longer needed.
+func (c *logicalReplicationConsumer) checkpointLoop(
+	ctx context.Context, execCtx sql.JobExecContext,
+) error {
+	sv := &execCtx.ExecCfg().Settings.SV
+	timer := timeutil.NewTimer()
+	defer timer.Stop()
+	for {
+		timer.Reset(streamingccl.StreamReplicationConsumerHeartbeatFrequency.Get(sv))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			timer.Read = true
+		}
+		replicatedTime, err := c.checkpoint(ctx)
+		if err != nil {
+			return err
+		}
+		status, err := c.client.Heartbeat(ctx, c.streamID, replicatedTime)
+		if err != nil {
+			log.Warningf(ctx, "failed to heartbeat stream %d: %s", c.streamID, err)
+			continue
+		}
+		if status.StreamStatus == streampb.StreamReplicationStatus_STREAM_INACTIVE {
+			return jobs.MarkAsPermanentJobError(
+				streamingccl.NewStreamStatusErr(c.streamID, status.StreamStatus))
+		}
+	}
+}
+
+// checkpoint persists the current frontier and row counts in the job progress
+// and returns the replicated time.
+func (c *logicalReplicationConsumer) checkpoint(ctx context.Context) (hlc.Timestamp, error) {
+	replicatedTime := c.frontier.Frontier()
+	var checkpoint []jobspb.ResolvedSpan
+	c.frontier.Entries(func(sp roachpb.Span, ts hlc.Timestamp) span.OpResult {
+		if replicatedTime.Less(ts) {
+			checkpoint = append(checkpoint, jobspb.ResolvedSpan{Span: sp, Timestamp: ts})
+		}
+		return span.ContinueMatch
+	})
+
+	err := c.job.NoTxn().Update(ctx, func(_ isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+		if err := md.CheckRunningOrReverting(); err != nil {
+			return err
+		}
+		prog := md.Progress.GetLogicalReplication()
+		if replicatedTime.Less(prog.ReplicatedTime) {
+			return errors.AssertionFailedf("replicated time regressed from %s to %s",
+				prog.ReplicatedTime, replicatedTime)
+		}
+		prog.ReplicatedTime = replicatedTime
+		prog.Checkpoint = checkpoint
+		prog.AppliedRows = atomic.LoadInt64(&c.appliedRows)
+		prog.SkippedRows = atomic.LoadInt64(&c.skippedRows)
+		if !replicatedTime.IsEmpty() {
+			hw := replicatedTime
+			md.Progress.Progress = &jobspb.Progress_HighWater{HighWater: &hw}
+			md.Progress.RunningStatus = fmt.Sprintf("logical replication running: %s", replicatedTime.GoTime())
+		}
+		ju.UpdateProgress(md.Progress)
+		return nil
+	})
+	return replicatedTime, err
+}
+
+// OnFailOrCancel is part of the jobs.Resumer interface. It completes the
+// producer job on the source cluster, on a best effort basis, so that it
+// releases its protected timestamp.
+func (r *logicalReplicationResumer) OnFailOrCancel(
+	ctx context.Context, execCtx interface{}, _ error,
+) error {
+	jobExecCtx := execCtx.(sql.JobExecContext)
+	details := r.job.Details().(jobspb.LogicalReplicationDetails)
+	if err := timeutil.RunWithTimeout(ctx, "complete producer job", 30*time.Second,
+		func(ctx context.Context) error {
+			client, err := streamclient.NewStreamClient(ctx,
+				streamingccl.StreamAddress(details.SourceClusterConnStr), jobExecCtx.ExecCfg().InternalDB)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close(ctx) }()
+			return client.Complete(ctx, streampb.StreamID(details.StreamID), false /* successfulIngestion */)
+		},
+	); err != nil {
+		log.Warningf(ctx, "failed to complete the source cluster producer job %d: %s", details.StreamID, err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package logical
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/desctestutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLogicalReplicationBidirectional replicates two tables of the same
+// cluster into each other, which exercises the same code paths as replication
+// between two clusters, and checks that local writes on either side converge
+// and are not replicated back to where they came from.
+func TestLogicalReplicationBidirectional(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{
+		DefaultTestTenant: base.TODOTestTenantDisabled,
+		Knobs: base.TestingKnobs{
+			JobsTestingKnobs: jobs.NewTestingKnobsWithShortIntervals(),
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `SET CLUSTER SETTING kv.rangefeed.enabled = true`)
+	sqlDB.Exec(t, `SET CLUSTER SETTING cross_cluster_replication.enabled = true`)
+	sqlDB.Exec(t, `SET CLUSTER SETTING stream_replication.consumer_heartbeat_frequency = '100ms'`)
+	sqlDB.Exec(t, `SET CLUSTER SETTING stream_replication.min_checkpoint_frequency = '100ms'`)
+	sqlDB.Exec(t, `CREATE DATABASE a`)
+	sqlDB.Exec(t, `CREATE DATABASE b`)
+	for _, dbName := range []string{"a", "b"} {
+		sqlDB.Exec(t, `CREATE TABLE `+dbName+`.tab (pk INT PRIMARY KEY, payload STRING, extra INT, `+
+			`FAMILY (pk, payload), FAMILY (extra))`)
+	}
+	sqlDB.Exec(t, `INSERT INTO a.tab VALUES (1, 'a-1', 10), (2, 'a-2', 20)`)
+	sqlDB.Exec(t, `INSERT INTO b.tab VALUES (3, 'b-3', 30)`)
+
+	pgURL, cleanup := sqlutils.PGUrl(t, s.AdvSQLAddr(), t.Name(), url.User(username.RootUser))
+	defer cleanup()
+
+	var aToB, bToA jobspb.JobID
+	sqlDB.QueryRow(t, `CREATE LOGICAL REPLICATION STREAM FROM TABLE a.tab ON $1 INTO TABLE b.tab`,
+		pgURL.String()).Scan(&aToB)
+	sqlDB.QueryRow(t, `CREATE LOGICAL REPLICATION STREAM FROM TABLE b.tab ON $1 INTO TABLE a.tab`,
+		pgURL.String()).Scan(&bToA)
+	jobutils.WaitForJobToRun(t, sqlDB, aToB)
+	jobutils.WaitForJobToRun(t, sqlDB, bToA)
+
+	expected := [][]string{{"1", "a-1", "10"}, {"2", "a-2", "20"}, {"3", "b-3", "30"}}
+	sqlDB.CheckQueryResultsRetry(t, `SELECT * FROM a.tab ORDER BY pk`, expected)
+	sqlDB.CheckQueryResultsRetry(t, `SELECT * FROM b.tab ORDER BY pk`, expected)
+
+	// Changes on either side are applied to the other, and the last writer of a
+	// column family of a row wins.
+	sqlDB.Exec(t, `UPDATE a.tab SET payload = 'a-2-updated', extra = 21 WHERE pk = 2`)
+	sqlDB.Exec(t, `DELETE FROM b.tab WHERE pk = 1`)
+	sqlDB.Exec(t, `UPDATE b.tab SET payload = 'b-3-first', extra = NULL WHERE pk = 3`)
+	sqlDB.Exec(t, `UPDATE a.tab SET payload = 'b-3-last' WHERE pk = 3`)
+	expected = [][]string{{"2", "a-2-updated", "21"}, {"3", "b-3-last", "NULL"}}
+	sqlDB.CheckQueryResultsRetry(t, `SELECT * FROM a.tab ORDER BY pk`, expected)
+	sqlDB.CheckQueryResultsRetry(t, `SELECT * FROM b.tab ORDER BY pk`, expected)
+}
+
+func TestValidateTablePair(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, kvDB := serverutils.StartServer(t, base.TestServerArgs{
+		DefaultTestTenant: base.TODOTestTenantDisabled,
+	})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `CREATE TABLE src (pk INT PRIMARY KEY, a STRING, b INT)`)
+	sqlDB.Exec(t, `CREATE TABLE src_families (pk INT PRIMARY KEY, a STRING, b INT, FAMILY (pk, a), FAMILY (b))`)
+	for _, tc := range []struct {
+		name   string
+		src    string
+		schema string
+		expErr string
+	}{
+		{name: "same", schema: `(pk INT PRIMARY KEY, b INT, a STRING)`},
+		{name: "family", schema: `(pk INT PRIMARY KEY, a STRING, b INT, FAMILY (pk, a), FAMILY (b))`,
+			expErr: `source table "src" has 1 column families but destination table "dst_family" has 2`},
+		{name: "same_families", src: "src_families",
+			schema: `(pk INT PRIMARY KEY, a STRING, b INT, FAMILY f1 (a, pk), FAMILY f2 (b))`},
+		{name: "other_families", src: "src_families",
+			schema: `(pk INT PRIMARY KEY, a STRING, b INT, FAMILY (pk), FAMILY (a, b))`,
+			expErr: "have different columns"},
+		{name: "index", schema: `(pk INT PRIMARY KEY, a STRING, b INT, INDEX (a))`,
+			expErr: "secondary indexes"},
+		{name: "missing", schema: `(pk INT PRIMARY KEY, a STRING, c INT)`,
+			expErr: `has no column "b"`},
+		{name: "type", schema: `(pk INT PRIMARY KEY, a STRING, b STRING)`,
+			expErr: `column "b" has type INT8 in the source table but STRING`},
+		{name: "pk", schema: `(pk INT, a STRING, b INT, PRIMARY KEY (pk, b))`,
+			expErr: "different primary keys"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dstName := "dst_" + tc.name
+			sqlDB.Exec(t, `CREATE TABLE `+dstName+` `+tc.schema)
+			srcName := "src"
+			if tc.src != "" {
+				srcName = tc.src
+			}
+			src := desctestutils.TestingGetPublicTableDescriptor(kvDB, s.Codec(), "defaultdb", srcName)
+			dst := desctestutils.TestingGetPublicTableDescriptor(kvDB, s.Codec(), "defaultdb", dstName)
+			err := validateTablePair(src, dst)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package logical
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl/streamclient"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/repstream/streampb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+var logicalReplicationFeatureName = "CREATE LOGICAL REPLICATION STREAM"
+
+var createLogicalReplicationStreamHeader = colinfo.ResultColumns{
+	{Name: "job_id", Typ: types.Int},
+}
+
+func createLogicalReplicationStreamTypeCheck(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (matched bool, _ colinfo.ResultColumns, _ error) {
+	createStmt, ok := stmt.(*tree.CreateLogicalReplicationStream)
+	if !ok {
+		return false, nil, nil
+	}
+	if err := exprutil.TypeCheck(
+		ctx, "LOGICAL REPLICATION", p.SemaCtx(), exprutil.Strings{createStmt.PGURL},
+	); err != nil {
+		return false, nil, err
+	}
+	return true, createLogicalReplicationStreamHeader, nil
+}
+
+func createLogicalReplicationStreamPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	createStmt, ok := stmt.(*tree.CreateLogicalReplicationStream)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	if !streamingccl.CrossClusterReplicationEnabled.Get(&p.ExecCfg().Settings.SV) {
+		return nil, nil, nil, false, errors.WithTelemetry(
+			pgerror.WithCandidateCode(
+				errors.WithHint(
+					errors.Newf("cross cluster replication is disabled"),
+					"You can enable cross cluster replication by running `SET CLUSTER SETTING cross_cluster_replication.enabled = true`.",
+				),
+				pgcode.ExperimentalFeature,
+			),
+			"cross_cluster_replication.enabled",
+		)
+	}
+
+	if !p.ExecCfg().Settings.Version.IsActive(ctx, clusterversion.V23_2_LogicalReplicationOriginHeaders) {
+		return nil, nil, nil, false, pgerror.Newf(pgcode.FeatureNotSupported,
+			"logical replication is not supported until the cluster version is finalized")
+	}
+
+	if len(createStmt.From.Tables) != len(createStmt.Into.Tables) {
+		return nil, nil, nil, false, pgerror.Newf(pgcode.InvalidParameterValue,
+			"the number of source tables (%d) does not match the number of destination tables (%d)",
+			len(createStmt.From.Tables), len(createStmt.Into.Tables))
+	}
+
+	from, err := p.ExprEvaluator("LOGICAL REPLICATION").String(ctx, createStmt.PGURL)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer span.Finish()
+
+		if err := utilccl.CheckEnterpriseEnabled(
+			p.ExecCfg().Settings, p.ExecCfg().NodeInfo.LogicalClusterID(),
+			logicalReplicationFeatureName,
+		); err != nil {
+			return err
+		}
+
+		dstTables := make([]catalog.TableDescriptor, len(createStmt.Into.Tables))
+		for i := range createStmt.Into.Tables {
+			tn := createStmt.Into.Tables[i]
+			_, td, err := p.ResolveMutableTableDescriptor(ctx, &tn, true /* required */, tree.ResolveRequireTableDesc)
+			if err != nil {
+				return err
+			}
+			if err := p.CheckPrivilege(ctx, td, privilege.INSERT); err != nil {
+				return err
+			}
+			if err := p.CheckPrivilege(ctx, td, privilege.DELETE); err != nil {
+				return err
+			}
+			dstTables[i] = td
+		}
+
+		streamAddress := streamingccl.StreamAddress(from)
+		streamURL, err := streamAddress.URL()
+		if err != nil {
+			return err
+		}
+		streamAddress = streamingccl.StreamAddress(streamURL.String())
+
+		client, err := streamclient.NewStreamClient(ctx, streamAddress, p.ExecCfg().InternalDB)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = client.Close(ctx) }()
+		lrClient, ok := client.(streamclient.LogicalReplicationClient)
+		if !ok {
+			return errors.Newf("logical replication is not supported from scheme %q", streamURL.Scheme)
+		}
+
+		srcTableNames := make([]string, len(createStmt.From.Tables))
+		for i := range createStmt.From.Tables {
+			srcTableNames[i] = tree.AsString(&createStmt.From.Tables[i])
+		}
+		spec, err := lrClient.CreateForTables(ctx, &streampb.ReplicationProducerRequest{
+			TableNames: srcTableNames,
+		})
+		if err != nil {
+			return err
+		}
+		if len(spec.TableDescriptors) != len(dstTables) {
+			return errors.AssertionFailedf("expected %d source table descriptors, got %d",
+				len(dstTables), len(spec.TableDescriptors))
+		}
+
+		pairs := make([]jobspb.LogicalReplicationDetails_ReplicationPair, len(dstTables))
+		for i := range spec.TableDescriptors {
+			src := tabledesc.NewBuilder(&spec.TableDescriptors[i]).BuildImmutableTable()
+			if err := validateTablePair(src, dstTables[i]); err != nil {
+				return err
+			}
+			pairs[i] = jobspb.LogicalReplicationDetails_ReplicationPair{
+				SrcDescriptorID: int32(src.GetID()),
+				DstDescriptorID: int32(dstTables[i].GetID()),
+			}
+		}
+
+		description, err := logicalReplicationJobDescription(p, from, createStmt)
+		if err != nil {
+			return err
+		}
+		jr := jobs.Record{
+			JobID:       p.ExecCfg().JobRegistry.MakeJobID(),
+			Description: description,
+			Username:    p.User(),
+			Details: jobspb.LogicalReplicationDetails{
+				SourceClusterConnStr: string(streamAddress),
+				StreamID:             uint64(spec.StreamID),
+				ReplicationStartTime: spec.ReplicationStartTime,
+				ReplicationPairs:     pairs,
+				SourceDescriptors:    spec.TableDescriptors,
+			},
+			Progress: jobspb.LogicalReplicationProgress{},
+		}
+		if _, err := p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(
+			ctx, jr, jr.JobID, p.InternalSQLTxn(),
+		); err != nil {
+			return err
+		}
+		resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(jr.JobID))}
+		return nil
+	}
+
+	return fn, createLogicalReplicationStreamHeader, nil, false, nil
+}
+
+func logicalReplicationJobDescription(
+	p sql.PlanHookState, sourceAddr string, stmt *tree.CreateLogicalReplicationStream,
+) (string, error) {
+	redactedSourceAddr, err := cloud.SanitizeExternalStorageURI(sourceAddr, streamclient.RedactableURLParameters)
+	if err != nil {
+		return "", err
+	}
+	redactedStmt := &tree.CreateLogicalReplicationStream{
+		From:  stmt.From,
+		PGURL: tree.NewDString(redactedSourceAddr),
+		Into:  stmt.Into,
+	}
+	ann := p.ExtendedEvalContext().Annotations
+	return tree.AsStringWithFQNames(redactedStmt, ann), nil
+}
+
+func init() {
+	sql.AddPlanHook("logical replication", createLogicalReplicationStreamPlanHook,
+		createLogicalReplicationStreamTypeCheck)
+	jobs.RegisterConstructor(
+		jobspb.TypeLogicalReplication,
+		func(job *jobs.Job, settings *cluster.Settings) jobs.Resumer {
+			return &logicalReplicationResumer{
+				job: job,
+			}
+		},
+		jobs.UsesTenantCostControl,
+	)
+}
@@ -0,0 +1,378 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package logical
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+)
+
+// logicalReplicationOriginID is the origin ID that rows applied by logical
+// replication are tagged with. Any non-zero origin ID makes the producer of a
+// stream out of this cluster skip the row, which is what prevents a row from
+// bouncing back and forth between two clusters replicating into each other.
+const logicalReplicationOriginID = 1
+
+// validateTablePair checks that rows of the source table can be applied to the
+// destination table. The tables must have the same columns, by name and type,
+// the same primary key and the same column families, so that every KV of a
+// source row maps to a KV of the destination row. Tables with secondary indexes
+// are not supported, since applying a row would then require reading the
+// previous row in order to update its index entries.
+func validateTablePair(src, dst catalog.TableDescriptor) error {
+	for _, td := range []catalog.TableDescriptor{src, dst} {
+		if len(td.DeletableNonPrimaryIndexes()) != 0 {
+			return errors.Newf("table %q has secondary indexes, which are not supported", td.GetName())
+		}
+	}
+
+	srcCols := storedColumns(src)
+	dstCols := storedColumns(dst)
+	if len(srcCols) != len(dstCols) {
+		return errors.Newf("source table %q has %d columns but destination table %q has %d",
+			src.GetName(), len(srcCols), dst.GetName(), len(dstCols))
+	}
+	for _, srcCol := range srcCols {
+		dstCol := catalog.FindColumnByName(dst, srcCol.GetName())
+		if dstCol == nil || dstCol.IsVirtual() {
+			return errors.Newf("destination table %q has no column %q", dst.GetName(), srcCol.GetName())
+		}
+		if srcCol.GetType().UserDefined() {
+			return errors.Newf("column %q has a user-defined type, which is not supported", srcCol.GetName())
+		}
+		if !srcCol.GetType().Identical(dstCol.GetType()) {
+			return errors.Newf("column %q has type %s in the source table but %s in the destination table",
+				srcCol.GetName(), srcCol.GetType().SQLString(), dstCol.GetType().SQLString())
+		}
+	}
+
+	srcPK, dstPK := src.GetPrimaryIndex(), dst.GetPrimaryIndex()
+	if srcPK.NumKeyColumns() != dstPK.NumKeyColumns() {
+		return errors.Newf("source table %q and destination table %q have different primary keys",
+			src.GetName(), dst.GetName())
+	}
+	for i := 0; i < srcPK.NumKeyColumns(); i++ {
+		if srcPK.GetKeyColumnName(i) != dstPK.GetKeyColumnName(i) ||
+			srcPK.GetKeyColumnDirection(i) != dstPK.GetKeyColumnDirection(i) {
+			return errors.Newf("source table %q and destination table %q have different primary keys",
+				src.GetName(), dst.GetName())
+		}
+	}
+
+	srcFamilies, dstFamilies := src.GetFamilies(), dst.GetFamilies()
+	if len(srcFamilies) != len(dstFamilies) {
+		return errors.Newf("source table %q has %d column families but destination table %q has %d",
+			src.GetName(), len(srcFamilies), dst.GetName(), len(dstFamilies))
+	}
+	for i := range srcFamilies {
+		srcNames := append([]string(nil), srcFamilies[i].ColumnNames...)
+		dstNames := append([]string(nil), dstFamilies[i].ColumnNames...)
+		sort.Strings(srcNames)
+		sort.Strings(dstNames)
+		if !reflect.DeepEqual(srcNames, dstNames) {
+			return errors.Newf("column family %q of source table %q and column family %q of "+
+				"destination table %q have different columns", srcFamilies[i].Name, src.GetName(),
+				dstFamilies[i].Name, dst.GetName())
+		}
+	}
+	return nil
+}
+
+// storedColumns returns the public columns of the table that are stored, that
+// is, that are not virtual.
+func storedColumns(td catalog.TableDescriptor) []catalog.Column {
+	cols := make([]catalog.Column, 0, len(td.PublicColumns()))
+	for _, col := range td.PublicColumns() {
+		if !col.IsVirtual() {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// tableApplier decodes rows of one source table and writes them to the
+// destination table.
+type tableApplier struct {
+	dst catalog.TableDescriptor
+	// dstFamilies maps the IDs of the column families of the source table to
+	// the IDs of the corresponding families of the destination table.
+	dstFamilies map[descpb.FamilyID]descpb.FamilyID
+
+	// fetcher decodes all stored columns of a source row and keyFetcher only
+	// its primary key columns, which is all a deletion carries.
+	fetcher    row.Fetcher
+	keyFetcher row.Fetcher
+
+	// dstColMap maps the IDs of the destination columns to their ordinal in
+	// values, and srcToDst maps the ordinals of the columns decoded by the
+	// fetchers to ordinals in values.
+	dstColMap catalog.TableColMap
+	srcToDst  []int
+	keyToDst  []int
+	values    []tree.Datum
+}
+
+// rowApplier applies the KVs of replicated source rows to the destination
+// tables. It is not safe for concurrent use.
+type rowApplier struct {
+	db       *kv.DB
+	srcCodec keys.SQLCodec
+	dstCodec keys.SQLCodec
+	tables   map[descpb.ID]*tableApplier
+	alloc    tree.DatumAlloc
+}
+
+func newRowApplier(
+	ctx context.Context,
+	db *kv.DB,
+	srcCodec, dstCodec keys.SQLCodec,
+	srcTables, dstTables []catalog.TableDescriptor,
+) (*rowApplier, error) {
+	r := &rowApplier{
+		db:       db,
+		srcCodec: srcCodec,
+		dstCodec: dstCodec,
+		tables:   make(map[descpb.ID]*tableApplier, len(srcTables)),
+	}
+	for i, src := range srcTables {
+		t, err := r.newTableApplier(ctx, src, dstTables[i])
+		if err != nil {
+			return nil, err
+		}
+		r.tables[src.GetID()] = t
+	}
+	return r, nil
+}
+
+func (r *rowApplier) newTableApplier(
+	ctx context.Context, src, dst catalog.TableDescriptor,
+) (*tableApplier, error) {
+	t := &tableApplier{dst: dst, dstFamilies: make(map[descpb.FamilyID]descpb.FamilyID)}
+	dstFamilies := dst.GetFamilies()
+	for i, family := range src.GetFamilies() {
+		t.dstFamilies[family.ID] = dstFamilies[i].ID
+	}
+
+	dstCols := storedColumns(dst)
+	t.values = make([]tree.Datum, len(dstCols))
+	for i, col := range dstCols {
+		t.dstColMap.Set(col.GetID(), i)
+	}
+
+	srcCols := storedColumns(src)
+	colIDs := make([]descpb.ColumnID, len(srcCols))
+	t.srcToDst = make([]int, len(srcCols))
+	for i, col := range srcCols {
+		colIDs[i] = col.GetID()
+		dstCol := catalog.FindColumnByName(dst, col.GetName())
+		if dstCol == nil {
+			return nil, errors.AssertionFailedf("column %q not found in %q", col.GetName(), dst.GetName())
+		}
+		t.srcToDst[i] = t.dstColMap.GetDefault(dstCol.GetID())
+	}
+	if err := r.initFetcher(ctx, &t.fetcher, src, colIDs); err != nil {
+		return nil, err
+	}
+
+	srcPK := src.GetPrimaryIndex()
+	keyColIDs := make([]descpb.ColumnID, srcPK.NumKeyColumns())
+	t.keyToDst = make([]int, srcPK.NumKeyColumns())
+	for i := range keyColIDs {
+		keyColIDs[i] = srcPK.GetKeyColumnID(i)
+		dstCol := catalog.FindColumnByName(dst, srcPK.GetKeyColumnName(i))
+		if dstCol == nil {
+			return nil, errors.AssertionFailedf("column %q not found in %q", srcPK.GetKeyColumnName(i), dst.GetName())
+		}
+		t.keyToDst[i] = t.dstColMap.GetDefault(dstCol.GetID())
+	}
+	if err := r.initFetcher(ctx, &t.keyFetcher, src, keyColIDs); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *rowApplier) initFetcher(
+	ctx context.Context, rf *row.Fetcher, src catalog.TableDescriptor, colIDs []descpb.ColumnID,
+) error {
+	var spec fetchpb.IndexFetchSpec
+	if err := rowenc.InitIndexFetchSpec(&spec, r.srcCodec, src, src.GetPrimaryIndex(), colIDs); err != nil {
+		return err
+	}
+	return rf.Init(ctx, row.FetcherInitArgs{
+		WillUseKVProvider: true,
+		Alloc:             &r.alloc,
+		Spec:              &spec,
+	})
+}
+
+// sameRow returns whether two source KVs are writes to the column families of
+// the same row at the same timestamp, which makes them part of the same write
+// of the row by a transaction.
+func sameRow(a, b roachpb.KeyValue) bool {
+	if !a.Value.Timestamp.Equal(b.Value.Timestamp) {
+		return false
+	}
+	aLen, err := keys.GetRowPrefixLength(a.Key)
+	if err != nil {
+		return false
+	}
+	bLen, err := keys.GetRowPrefixLength(b.Key)
+	if err != nil {
+		return false
+	}
+	return a.Key[:aLen].Equal(b.Key[:bLen])
+}
+
+// applyRow writes the row of the given source KVs to its destination table.
+// The KVs are the writes of a transaction to the column families of the row,
+// see sameRow, and are written to the corresponding families of the destination
+// row in a single batch, so that the row is applied atomically. The writes carry
+// the MVCC timestamp of the source KVs as their origin timestamp, so that they
+// only take effect if they are newer than the row in the destination table,
+// which makes conflicting writes on the two clusters resolve to the last
+// writer. If some family of the destination row is newer, the families are
+// written one at a time instead, so that each of them resolves the conflict on
+// its own as it does on the other cluster. applyRow returns false if the whole
+// row lost to newer local writes.
+func (r *rowApplier) applyRow(ctx context.Context, kvs []roachpb.KeyValue) (applied bool, _ error) {
+	_, tableID, err := r.srcCodec.DecodeTablePrefix(kvs[0].Key)
+	if err != nil {
+		return false, err
+	}
+	t, ok := r.tables[descpb.ID(tableID)]
+	if !ok {
+		return false, errors.AssertionFailedf("unexpected key %s outside of replicated tables", kvs[0].Key)
+	}
+
+	// Decode the columns of the families that were written, or only the primary
+	// key if all of them were deleted. The primary key is decoded from the key of
+	// the first family, which every row has.
+	present := make([]roachpb.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		if kv.Value.IsPresent() {
+			present = append(present, kv)
+		}
+	}
+	rf, colMapping := &t.fetcher, t.srcToDst
+	if len(present) == 0 {
+		rowPrefixLen, err := keys.GetRowPrefixLength(kvs[0].Key)
+		if err != nil {
+			return false, err
+		}
+		rf, colMapping = &t.keyFetcher, t.keyToDst
+		present = append(present, roachpb.KeyValue{
+			Key:   roachpb.Key(keys.MakeFamilyKey(kvs[0].Key[:rowPrefixLen:rowPrefixLen], 0)),
+			Value: kvs[0].Value,
+		})
+	}
+	if err := rf.ConsumeKVProvider(ctx, &row.KVProvider{KVs: present}); err != nil {
+		return false, err
+	}
+	datums, err := rf.NextRowDecoded(ctx)
+	if err != nil {
+		return false, err
+	}
+	if datums == nil {
+		return false, errors.AssertionFailedf("failed to decode row from key %s", kvs[0].Key)
+	}
+	for i := range t.values {
+		t.values[i] = tree.DNull
+	}
+	for i, d := range datums {
+		t.values[colMapping[i]] = d
+	}
+
+	entries, err := rowenc.EncodePrimaryIndex(
+		r.dstCodec, t.dst, t.dst.GetPrimaryIndex(), t.dstColMap, t.values, true, /* includeEmpty */
+	)
+	if err != nil {
+		return false, err
+	}
+
+	writes := make([]rowenc.IndexEntry, len(kvs))
+	for i, kv := range kvs {
+		srcFamilyID, err := keys.DecodeFamilyKey(kv.Key)
+		if err != nil {
+			return false, err
+		}
+		entry := familyEntry(entries, t.dstFamilies[descpb.FamilyID(srcFamilyID)])
+		if entry == nil {
+			return false, errors.AssertionFailedf("no destination column family for key %s", kv.Key)
+		}
+		writes[i] = *entry
+		if !kv.Value.IsPresent() {
+			writes[i].Value = roachpb.Value{}
+		}
+	}
+	originTS := kvs[0].Value.Timestamp
+	if applied, err := r.write(ctx, writes, originTS); err != nil || applied || len(writes) == 1 {
+		return applied, err
+	}
+	for i := range writes {
+		familyApplied, err := r.write(ctx, writes[i:i+1], originTS)
+		if err != nil {
+			return false, err
+		}
+		applied = applied || familyApplied
+	}
+	return applied, nil
+}
+
+// write writes the given entries, or deletes the keys of those without a value,
+// in a single batch with the given origin timestamp. It returns false if the
+// batch lost to a newer value of one of the keys.
+func (r *rowApplier) write(
+	ctx context.Context, entries []rowenc.IndexEntry, originTS hlc.Timestamp,
+) (applied bool, _ error) {
+	b := &kv.Batch{}
+	b.Header.WriteOptions = &kvpb.WriteOptions{
+		OriginID:        logicalReplicationOriginID,
+		OriginTimestamp: originTS,
+	}
+	for i := range entries {
+		if entries[i].Value.IsPresent() {
+			b.Put(entries[i].Key, &entries[i].Value)
+		} else {
+			b.Del(entries[i].Key)
+		}
+	}
+	if err := r.db.Run(ctx, b); err != nil {
+		if cfe := (*kvpb.ConditionFailedError)(nil); errors.As(err, &cfe) &&
+			!cfe.OriginTimestampOlderThan.IsEmpty() {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// familyEntry returns the entry of the column family with the given ID among
+// the primary index entries of a row, or nil if there is none.
+func familyEntry(entries []rowenc.IndexEntry, familyID descpb.FamilyID) *rowenc.IndexEntry {
+	for i := range entries {
+		if entries[i].Family == familyID {
+			return &entries[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// validateDestinationTable checks that the replicated columns of a source
+// table can be applied to the destination table.
+func validateDestinationTable(td catalog.TableDescriptor, columns []string) error {
+	for _, name := range columns {
+		col := catalog.FindColumnByName(td, name)
+		if col == nil || !col.Public() {
+			return errors.Newf("table %q has no column %q", td.GetName(), name)
+		}
+		if col.IsComputed() {
+			return errors.Newf("column %q of table %q is computed", name, td.GetName())
+		}
+		if col.GetType().UserDefined() {
+			return errors.Newf("column %q of table %q has a user-defined type, which is not supported",
+				name, td.GetName())
+		}
+	}
+	for i := 0; i < td.GetPrimaryIndex().NumKeyColumns(); i++ {
+		name := td.GetPrimaryIndex().GetKeyColumnName(i)
+		found := false
+		for _, c := range columns {
+			found = found || c == name
+		}
+		if !found {
+			return errors.Newf("primary key column %q of table %q is not replicated", name, td.GetName())
+		}
+	}
+	return nil
+}
+
+// relation is a table described by the server, which the applier maps to its
+// destination table.
+type relation struct {
+	msg *relationMessage
+	// tableID is the ID of the destination table.
+	tableID descpb.ID
+	// types are the types of the destination columns, in the order of the
+	// columns of the relation.
+	types []*types.T
+	// pkOrdinals are the ordinals, in the columns of the relation, of the
+	// primary key columns of the destination table.
+	pkOrdinals []int
+}
+
+// change is a change to a row, or a truncation of tables, that the applier
+// applies in the transaction it is part of.
+type change struct {
+	op       byte
+	rel      *relation
+	oldTuple tuple
+	newTuple tuple
+	// truncated are the relations of a truncate change.
+	truncated []*relation
+}
+
+// applier applies the transactions replicated from the server to the
+// destination tables, with SQL statements run as the user of the job. Each
+// replicated transaction is applied in a transaction.
+type applier struct {
+	db      isql.DB
+	user    username.SQLUsername
+	evalCtx *eval.Context
+	// tables are the destination tables, by the source table they replicate.
+	tables map[publishedTable]catalog.TableDescriptor
+	// relations are the relations last described by the server, by ID.
+	relations map[uint32]*relation
+}
+
+func newApplier(
+	db isql.DB,
+	user username.SQLUsername,
+	evalCtx *eval.Context,
+	tables map[publishedTable]catalog.TableDescriptor,
+) *applier {
+	return &applier{
+		db:        db,
+		user:      user,
+		evalCtx:   evalCtx,
+		tables:    tables,
+		relations: make(map[uint32]*relation),
+	}
+}
+
+// addRelation maps a relation described by the server to its destination
+// table. Errors are permanent, since the server describes the relation again
+// when the job resumes.
+func (a *applier) addRelation(msg *relationMessage) error {
+	td, ok := a.tables[publishedTable{schema: msg.namespace, name: msg.name}]
+	if !ok {
+		return jobs.MarkAsPermanentJobError(errors.WithHint(
+			errors.Newf("table %s.%s is not replicated by the subscription", msg.namespace, msg.name),
+			"Tables added to the publications after the subscription was created are not replicated."))
+	}
+	rel := &relation{msg: msg, tableID: td.GetID(), types: make([]*types.T, len(msg.columns))}
+	for i, c := range msg.columns {
+		col := catalog.FindColumnByName(td, c.name)
+		if col == nil || !col.Public() || col.IsComputed() {
+			return jobs.MarkAsPermanentJobError(
+				errors.Newf("table %q has no column %q of table %s.%s", td.GetName(), c.name, msg.namespace, msg.name))
+		}
+		rel.types[i] = col.GetType()
+	}
+	for i := 0; i < td.GetPrimaryIndex().NumKeyColumns(); i++ {
+		name := td.GetPrimaryIndex().GetKeyColumnName(i)
+		ord := -1
+		for j, c := range msg.columns {
+			if c.name == name && c.key {
+				ord = j
+			}
+		}
+		if ord < 0 {
+			return jobs.MarkAsPermanentJobError(errors.WithHint(
+				errors.Newf("the replica identity of table %s.%s does not include primary key column %q of table %q",
+					msg.namespace, msg.name, name, td.GetName()),
+				"The source and destination tables must have the same primary key, or the source table "+
+					"must have REPLICA IDENTITY FULL."))
+		}
+		rel.pkOrdinals = append(rel.pkOrdinals, ord)
+	}
+	a.relations[msg.relationID] = rel
+	return nil
+}
+
+func (a *applier) relation(id uint32) (*relation, error) {
+	rel, ok := a.relations[id]
+	if !ok {
+		return nil, errors.Newf("change to relation %d, which the server has not described", id)
+	}
+	return rel, nil
+}
+
+// makeChange returns the change carried by a decoded insert, update, delete or
+// truncate message.
+func (a *applier) makeChange(msg interface{}) (change, error) {
+	var c change
+	var err error
+	switch msg := msg.(type) {
+	case *insertMessage:
+		c = change{op: insertByteID, newTuple: msg.newTuple}
+		c.rel, err = a.relation(msg.relationID)
+	case *updateMessage:
+		c = change{op: updateByteID, oldTuple: msg.oldTuple, newTuple: msg.newTuple}
+		c.rel, err = a.relation(msg.relationID)
+	case *deleteMessage:
+		c = change{op: deleteByteID, oldTuple: msg.oldTuple}
+		c.rel, err = a.relation(msg.relationID)
+	case *truncateMessage:
+		c = change{op: truncateByteID}
+		for _, id := range msg.relationIDs {
+			rel, err := a.relation(id)
+			if err != nil {
+				return change{}, err
+			}
+			c.truncated = append(c.truncated, rel)
+		}
+	default:
+		return change{}, errors.AssertionFailedf("unexpected message %T", msg)
+	}
+	if err == nil && c.rel != nil && (len(c.oldTuple) > len(c.rel.types) || len(c.newTuple) > len(c.rel.types)) {
+		err = errors.Newf("tuple of relation %d has more columns than the relation", c.rel.msg.relationID)
+	}
+	return c, err
+}
+
+// apply applies the changes of a replicated transaction in a transaction, and
+// returns the number of changes it applied.
+func (a *applier) apply(ctx context.Context, changes []change) (int, error) {
+	var rows int
+	err := a.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		rows = 0
+		for i := range changes {
+			if err := a.applyChange(ctx, txn, &changes[i]); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	return rows, err
+}
+
+func (a *applier) exec(ctx context.Context, txn isql.Txn, stmt string, args ...interface{}) error {
+	_, err := txn.ExecEx(ctx, "subscription-apply", txn.KV(),
+		sessiondata.InternalExecutorOverride{User: a.user}, stmt, args...)
+	return err
+}
+
+func (a *applier) applyChange(ctx context.Context, txn isql.Txn, c *change) error {
+	switch c.op {
+	case insertByteID:
+		return a.upsert(ctx, txn, c.rel, c.newTuple, nil /* oldTuple */)
+	case updateByteID:
+		if c.oldTuple != nil {
+			changed, err := a.primaryKeyChanged(c.rel, c.oldTuple, c.newTuple)
+			if err != nil {
+				return err
+			}
+			if changed {
+				if err := a.delete(ctx, txn, c.rel, c.oldTuple); err != nil {
+					return err
+				}
+			}
+		}
+		return a.upsert(ctx, txn, c.rel, c.newTuple, c.oldTuple)
+	case deleteByteID:
+		return a.delete(ctx, txn, c.rel, c.oldTuple)
+	case truncateByteID:
+		for _, rel := range c.truncated {
+			if err := a.exec(ctx, txn, fmt.Sprintf("DELETE FROM [%d AS t]", rel.tableID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.AssertionFailedf("unexpected change %q", c.op)
+	}
+}
+
+// upsert writes the values of a tuple. Unchanged TOASTed values, which the
+// server does not send, are taken from the old tuple if it holds them, and are
+// otherwise left as they are.
+func (a *applier) upsert(
+	ctx context.Context, txn isql.Txn, rel *relation, newTuple, oldTuple tuple,
+) error {
+	var names, placeholders []string
+	var args []interface{}
+	for i, col := range newTuple {
+		if col.kind == tupleUnchanged {
+			if i >= len(oldTuple) || oldTuple[i].kind == tupleUnchanged {
+				continue
+			}
+			col = oldTuple[i]
+		}
+		d, err := a.datum(ctx, rel, i, col)
+		if err != nil {
+			return err
+		}
+		args = append(args, d)
+		names = append(names, tree.NameString(rel.msg.columns[i].name))
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+	return a.exec(ctx, txn, fmt.Sprintf("UPSERT INTO [%d AS t] (%s) VALUES (%s)",
+		rel.tableID, strings.Join(names, ", "), strings.Join(placeholders, ", ")), args...)
+}
+
+// delete deletes the row with the primary key in a tuple.
+func (a *applier) delete(ctx context.Context, txn isql.Txn, rel *relation, oldTuple tuple) error {
+	var preds []string
+	var args []interface{}
+	for _, ord := range rel.pkOrdinals {
+		if ord >= len(oldTuple) || oldTuple[ord].kind != tupleText {
+			return errors.Newf("old tuple of relation %d has no value for primary key column %q",
+				rel.msg.relationID, rel.msg.columns[ord].name)
+		}
+		d, err := a.datum(ctx, rel, ord, oldTuple[ord])
+		if err != nil {
+			return err
+		}
+		args = append(args, d)
+		preds = append(preds, fmt.Sprintf("%s = $%d", tree.NameString(rel.msg.columns[ord].name), len(args)))
+	}
+	return a.exec(ctx, txn, fmt.Sprintf("DELETE FROM [%d AS t] WHERE %s",
+		rel.tableID, strings.Join(preds, " AND ")), args...)
+}
+
+func (a *applier) primaryKeyChanged(rel *relation, oldTuple, newTuple tuple) (bool, error) {
+	for _, ord := range rel.pkOrdinals {
+		if ord >= len(oldTuple) || ord >= len(newTuple) {
+			return false, errors.Newf("tuple of relation %d has no value for primary key column %q",
+				rel.msg.relationID, rel.msg.columns[ord].name)
+		}
+		if oldTuple[ord].kind != newTuple[ord].kind || string(oldTuple[ord].value) != string(newTuple[ord].value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// datum parses the text representation of a value of a column.
+func (a *applier) datum(
+	ctx context.Context, rel *relation, ord int, col tupleColumn,
+) (tree.Datum, error) {
+	if col.kind == tupleNull {
+		return tree.DNull, nil
+	}
+	d, err := rowenc.ParseDatumStringAs(ctx, rel.types[ord], string(col.value), a.evalCtx)
+	return d, errors.Wrapf(err, "parsing value of column %q", rel.msg.columns[ord].name)
+}
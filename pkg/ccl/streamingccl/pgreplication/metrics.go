@@ -0,0 +1,82 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+)
+
+const subscriptionCommitLatencyMaxValue = 10 * time.Minute
+
+var (
+	metaSubscriptionsRunning = metric.Metadata{
+		Name:        "subscription.running",
+		Help:        "Number of subscriptions currently replicating from PostgreSQL",
+		Measurement: "Subscriptions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaSubscriptionTransactionsApplied = metric.Metadata{
+		Name:        "subscription.transactions_applied",
+		Help:        "Transactions replicated from PostgreSQL and applied by all subscriptions",
+		Measurement: "Transactions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaSubscriptionRowsApplied = metric.Metadata{
+		Name:        "subscription.rows_applied",
+		Help:        "Row changes replicated from PostgreSQL and applied by all subscriptions",
+		Measurement: "Rows",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaSubscriptionCommitLatency = metric.Metadata{
+		Name: "subscription.commit_latency",
+		Help: "Time between the commit of a transaction on PostgreSQL and the commit " +
+			"of its changes by a subscription",
+		Measurement: "Nanoseconds",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaSubscriptionReplicationLagNanos = metric.Metadata{
+		Name: "subscription.replication_lag_nanos",
+		Help: "Time between the wall clock and the replicated time of a subscription, " +
+			"as of its last checkpoint. This metric tracks how far behind PostgreSQL the " +
+			"subscription is",
+		Measurement: "Nanoseconds",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+)
+
+// Metrics are for production monitoring of subscription jobs.
+type Metrics struct {
+	Running             *metric.Gauge
+	TransactionsApplied *metric.Counter
+	RowsApplied         *metric.Counter
+	CommitLatency       metric.IHistogram
+	ReplicationLagNanos *metric.Gauge
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (*Metrics) MetricStruct() {}
+
+func makeMetrics() *Metrics {
+	return &Metrics{
+		Running:             metric.NewGauge(metaSubscriptionsRunning),
+		TransactionsApplied: metric.NewCounter(metaSubscriptionTransactionsApplied),
+		RowsApplied:         metric.NewCounter(metaSubscriptionRowsApplied),
+		CommitLatency: metric.NewHistogram(metric.HistogramOptions{
+			Metadata: metaSubscriptionCommitLatency,
+			Duration: base.DefaultHistogramWindowInterval(),
+			Buckets:  metric.BatchProcessLatencyBuckets,
+			MaxVal:   subscriptionCommitLatencyMaxValue.Nanoseconds(),
+			SigFigs:  1,
+		}),
+		ReplicationLagNanos: metric.NewGauge(metaSubscriptionReplicationLagNanos),
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// This file decodes the messages of the streaming replication protocol of
+// PostgreSQL, and the messages of the pgoutput logical decoding plugin that
+// they carry. See
+// https://www.postgresql.org/docs/current/protocol-replication.html and
+// https://www.postgresql.org/docs/current/protocol-logicalrep-message-formats.html.
+// Only version 1 of the pgoutput protocol is supported, which sends values in
+// the text format and does not stream transactions that are in progress.
+
+// lsn is a position in the write-ahead log of a PostgreSQL server.
+type lsn uint64
+
+// String formats the LSN like PostgreSQL does.
+func (l lsn) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
+
+func parseLSN(s string) (lsn, error) {
+	var hi, lo uint32
+	if _, err := fmt.Sscanf(s, "%X/%X", &hi, &lo); err != nil {
+		return 0, errors.Wrapf(err, "parsing LSN %q", s)
+	}
+	return lsn(uint64(hi)<<32 | uint64(lo)), nil
+}
+
+// postgresEpoch is the epoch of the timestamps of the replication protocol,
+// which count microseconds.
+var postgresEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func timeFromPostgres(micros int64) time.Time {
+	return postgresEpoch.Add(time.Duration(micros) * time.Microsecond)
+}
+
+func timeToPostgres(t time.Time) int64 {
+	return t.Sub(postgresEpoch).Microseconds()
+}
+
+// The types of the messages of the streaming replication protocol, which are
+// the first byte of the CopyData messages exchanged once replication started.
+const (
+	xLogDataByteID            = 'w'
+	primaryKeepaliveByteID    = 'k'
+	standbyStatusUpdateByteID = 'r'
+)
+
+// xLogData is a message of the server that carries a logical decoding message.
+type xLogData struct {
+	walStart   lsn
+	walEnd     lsn
+	serverTime time.Time
+	data       []byte
+}
+
+// primaryKeepalive is a message of the server that reports the end of its
+// write-ahead log, and may request a standby status update.
+type primaryKeepalive struct {
+	walEnd         lsn
+	serverTime     time.Time
+	replyRequested bool
+}
+
+func parseXLogData(buf []byte) (xLogData, error) {
+	r := messageReader{buf: buf}
+	m := xLogData{
+		walStart:   lsn(r.uint64()),
+		walEnd:     lsn(r.uint64()),
+		serverTime: timeFromPostgres(int64(r.uint64())),
+	}
+	m.data = r.bytes(len(r.buf))
+	return m, errors.Wrap(r.err, "decoding XLogData")
+}
+
+func parsePrimaryKeepalive(buf []byte) (primaryKeepalive, error) {
+	r := messageReader{buf: buf}
+	m := primaryKeepalive{
+		walEnd:         lsn(r.uint64()),
+		serverTime:     timeFromPostgres(int64(r.uint64())),
+		replyRequested: r.byte() == 1,
+	}
+	return m, errors.Wrap(r.err, "decoding primary keepalive")
+}
+
+// encodeStandbyStatusUpdate encodes the message with which the client reports
+// the position up to which it has written, flushed and applied the changes of
+// the server. The server may discard the write-ahead log before the flushed
+// position.
+func encodeStandbyStatusUpdate(flushed lsn, now time.Time) []byte {
+	buf := make([]byte, 0, 34)
+	buf = append(buf, standbyStatusUpdateByteID)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(flushed)) // written
+	buf = binary.BigEndian.AppendUint64(buf, uint64(flushed)) // flushed
+	buf = binary.BigEndian.AppendUint64(buf, uint64(flushed)) // applied
+	buf = binary.BigEndian.AppendUint64(buf, uint64(timeToPostgres(now)))
+	buf = append(buf, 0) // do not request a reply
+	return buf
+}
+
+// The types of the pgoutput messages that the subscription handles. Origin,
+// type and generic logical decoding messages are ignored.
+const (
+	beginByteID    = 'B'
+	commitByteID   = 'C'
+	relationByteID = 'R'
+	insertByteID   = 'I'
+	updateByteID   = 'U'
+	deleteByteID   = 'D'
+	truncateByteID = 'T'
+)
+
+// beginMessage starts a transaction, which is only sent once it committed.
+type beginMessage struct {
+	finalLSN   lsn
+	commitTime time.Time
+	xid        uint32
+}
+
+// commitMessage ends a transaction. The subscription has consumed the
+// transaction once it reaches endLSN.
+type commitMessage struct {
+	commitLSN  lsn
+	endLSN     lsn
+	commitTime time.Time
+}
+
+// relationMessage describes a table, and is sent before the first change to
+// the table in each session, and after the table changes.
+type relationMessage struct {
+	relationID      uint32
+	namespace       string
+	name            string
+	replicaIdentity byte
+	columns         []relationColumn
+}
+
+type relationColumn struct {
+	// key is set if the column is part of the replica identity of the table,
+	// usually its primary key.
+	key          bool
+	name         string
+	typeOID      uint32
+	typeModifier int32
+}
+
+// The kinds of the columns of a tuple.
+const (
+	tupleNull      = 'n'
+	tupleUnchanged = 'u' // an unchanged TOASTed value, which is not sent
+	tupleText      = 't'
+)
+
+type tupleColumn struct {
+	kind  byte
+	value []byte
+}
+
+// A tuple holds the values of the columns of a row, in the order of the
+// columns of its relation.
+type tuple []tupleColumn
+
+type insertMessage struct {
+	relationID uint32
+	newTuple   tuple
+}
+
+// updateMessage holds the new values of a row. It also holds the old values
+// of the replica identity columns if they changed, or of all columns if the
+// replica identity of the table is FULL.
+type updateMessage struct {
+	relationID uint32
+	oldTuple   tuple
+	newTuple   tuple
+}
+
+// deleteMessage holds the old values of the replica identity columns of a row,
+// or of all columns if the replica identity of the table is FULL.
+type deleteMessage struct {
+	relationID uint32
+	oldTuple   tuple
+}
+
+type truncateMessage struct {
+	relationIDs []uint32
+}
+
+// parseLogicalMessage decodes a pgoutput message. It returns nil for the
+// messages that the subscription ignores.
+func parseLogicalMessage(buf []byte) (interface{}, error) {
+	if len(buf) == 0 {
+		return nil, errors.New("empty logical replication message")
+	}
+	r := messageReader{buf: buf[1:]}
+	var m interface{}
+	switch buf[0] {
+	case beginByteID:
+		m = &beginMessage{
+			finalLSN:   lsn(r.uint64()),
+			commitTime: timeFromPostgres(int64(r.uint64())),
+			xid:        r.uint32(),
+		}
+	case commitByteID:
+		_ = r.byte() // flags, which are unused
+		m = &commitMessage{
+			commitLSN:  lsn(r.uint64()),
+			endLSN:     lsn(r.uint64()),
+			commitTime: timeFromPostgres(int64(r.uint64())),
+		}
+	case relationByteID:
+		rel := &relationMessage{
+			relationID:      r.uint32(),
+			namespace:       r.string(),
+			name:            r.string(),
+			replicaIdentity: r.byte(),
+		}
+		n := int(r.uint16())
+		for i := 0; i < n && r.err == nil; i++ {
+			rel.columns = append(rel.columns, relationColumn{
+				key:          r.byte()&1 != 0,
+				name:         r.string(),
+				typeOID:      r.uint32(),
+				typeModifier: int32(r.uint32()),
+			})
+		}
+		m = rel
+	case insertByteID:
+		ins := &insertMessage{relationID: r.uint32()}
+		if kind := r.byte(); kind != 'N' && r.err == nil {
+			return nil, errors.Newf("unexpected tuple type %q in insert message", kind)
+		}
+		ins.newTuple = r.tuple()
+		m = ins
+	case updateByteID:
+		upd := &updateMessage{relationID: r.uint32()}
+		kind := r.byte()
+		if kind == 'K' || kind == 'O' {
+			upd.oldTuple = r.tuple()
+			kind = r.byte()
+		}
+		if kind != 'N' && r.err == nil {
+			return nil, errors.Newf("unexpected tuple type %q in update message", kind)
+		}
+		upd.newTuple = r.tuple()
+		m = upd
+	case deleteByteID:
+		del := &deleteMessage{relationID: r.uint32()}
+		if kind := r.byte(); kind != 'K' && kind != 'O' && r.err == nil {
+			return nil, errors.Newf("unexpected tuple type %q in delete message", kind)
+		}
+		del.oldTuple = r.tuple()
+		m = del
+	case truncateByteID:
+		n := int(r.uint32())
+		_ = r.byte() // options: CASCADE and RESTART IDENTITY
+		trunc := &truncateMessage{}
+		for i := 0; i < n && r.err == nil; i++ {
+			trunc.relationIDs = append(trunc.relationIDs, r.uint32())
+		}
+		m = trunc
+	default:
+		return nil, nil
+	}
+	if r.err != nil {
+		return nil, errors.Wrapf(r.err, "decoding logical replication message %q", buf[0])
+	}
+	return m, nil
+}
+
+// messageReader reads the big-endian fields of a message. Reading past the end
+// of the message sets err, after which all reads return zero values.
+type messageReader struct {
+	buf []byte
+	err error
+}
+
+func (r *messageReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = errors.Newf("message truncated: need %d more bytes, have %d", n, len(r.buf))
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *messageReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *messageReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *messageReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *messageReader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// bytes returns a copy of the next n bytes, since the buffers of the messages
+// received from the server are reused.
+func (r *messageReader) bytes(n int) []byte {
+	if b := r.next(n); b != nil {
+		return append([]byte(nil), b...)
+	}
+	return nil
+}
+
+// string reads a null-terminated string.
+func (r *messageReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.buf {
+		if c == 0 {
+			s := string(r.buf[:i])
+			r.buf = r.buf[i+1:]
+			return s
+		}
+	}
+	r.err = errors.New("message truncated: unterminated string")
+	return ""
+}
+
+func (r *messageReader) tuple() tuple {
+	n := int(r.uint16())
+	var t tuple
+	for i := 0; i < n && r.err == nil; i++ {
+		col := tupleColumn{kind: r.byte()}
+		switch col.kind {
+		case tupleNull, tupleUnchanged:
+		case tupleText:
+			col.value = r.bytes(int(r.uint32()))
+		default:
+			if r.err == nil {
+				r.err = errors.Newf("unsupported tuple column kind %q", col.kind)
+			}
+		}
+		t = append(t, col)
+	}
+	return t
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+// The encode functions below encode the messages that a PostgreSQL server
+// sends, for the tests.
+
+func encodeXLogData(walStart lsn, data []byte) []byte {
+	buf := []byte{xLogDataByteID}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(walStart))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(walStart))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(timeToPostgres(time.Now())))
+	return append(buf, data...)
+}
+
+func encodePrimaryKeepalive(walEnd lsn, serverTime time.Time, replyRequested bool) []byte {
+	buf := []byte{primaryKeepaliveByteID}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(walEnd))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(timeToPostgres(serverTime)))
+	if replyRequested {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+func appendString(buf []byte, s string) []byte {
+	return append(append(buf, s...), 0)
+}
+
+func appendTuple(buf []byte, kind byte, t tuple) []byte {
+	buf = append(buf, kind)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(t)))
+	for _, col := range t {
+		buf = append(buf, col.kind)
+		if col.kind == tupleText {
+			buf = binary.BigEndian.AppendUint32(buf, uint32(len(col.value)))
+			buf = append(buf, col.value...)
+		}
+	}
+	return buf
+}
+
+func encodeBegin(m beginMessage) []byte {
+	buf := []byte{beginByteID}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(m.finalLSN))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(timeToPostgres(m.commitTime)))
+	return binary.BigEndian.AppendUint32(buf, m.xid)
+}
+
+func encodeCommit(m commitMessage) []byte {
+	buf := []byte{commitByteID, 0}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(m.commitLSN))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(m.endLSN))
+	return binary.BigEndian.AppendUint64(buf, uint64(timeToPostgres(m.commitTime)))
+}
+
+func encodeRelation(m relationMessage) []byte {
+	buf := []byte{relationByteID}
+	buf = binary.BigEndian.AppendUint32(buf, m.relationID)
+	buf = appendString(buf, m.namespace)
+	buf = appendString(buf, m.name)
+	buf = append(buf, m.replicaIdentity)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(m.columns)))
+	for _, c := range m.columns {
+		if c.key {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+		buf = appendString(buf, c.name)
+		buf = binary.BigEndian.AppendUint32(buf, c.typeOID)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(c.typeModifier))
+	}
+	return buf
+}
+
+func encodeInsert(m insertMessage) []byte {
+	buf := binary.BigEndian.AppendUint32([]byte{insertByteID}, m.relationID)
+	return appendTuple(buf, 'N', m.newTuple)
+}
+
+func encodeUpdate(m updateMessage) []byte {
+	buf := binary.BigEndian.AppendUint32([]byte{updateByteID}, m.relationID)
+	if m.oldTuple != nil {
+		buf = appendTuple(buf, 'K', m.oldTuple)
+	}
+	return appendTuple(buf, 'N', m.newTuple)
+}
+
+func encodeDelete(m deleteMessage) []byte {
+	buf := binary.BigEndian.AppendUint32([]byte{deleteByteID}, m.relationID)
+	return appendTuple(buf, 'K', m.oldTuple)
+}
+
+func encodeTruncate(m truncateMessage) []byte {
+	buf := binary.BigEndian.AppendUint32([]byte{truncateByteID}, uint32(len(m.relationIDs)))
+	buf = append(buf, 0)
+	for _, id := range m.relationIDs {
+		buf = binary.BigEndian.AppendUint32(buf, id)
+	}
+	return buf
+}
+
+func text(s string) tupleColumn {
+	return tupleColumn{kind: tupleText, value: []byte(s)}
+}
+
+func TestLSN(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	l, err := parseLSN("16/B374D848")
+	require.NoError(t, err)
+	require.Equal(t, lsn(0x16B374D848), l)
+	require.Equal(t, "16/B374D848", l.String())
+	require.Equal(t, "0/0", lsn(0).String())
+
+	_, err = parseLSN("16B374D848")
+	require.Error(t, err)
+}
+
+func TestParseReplicationMessages(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	now := timeFromPostgres(timeToPostgres(time.Now()))
+	ka, err := parsePrimaryKeepalive(encodePrimaryKeepalive(0x1234, now, true)[1:])
+	require.NoError(t, err)
+	require.Equal(t, primaryKeepalive{walEnd: 0x1234, serverTime: now, replyRequested: true}, ka)
+
+	xld, err := parseXLogData(encodeXLogData(0x5678, []byte("data"))[1:])
+	require.NoError(t, err)
+	require.Equal(t, lsn(0x5678), xld.walStart)
+	require.Equal(t, []byte("data"), xld.data)
+
+	_, err = parsePrimaryKeepalive([]byte{1, 2, 3})
+	require.Error(t, err)
+
+	status := encodeStandbyStatusUpdate(0x9abc, now)
+	require.Len(t, status, 34)
+	require.Equal(t, byte(standbyStatusUpdateByteID), status[0])
+	require.Equal(t, uint64(0x9abc), binary.BigEndian.Uint64(status[9:]))
+	require.Equal(t, uint64(timeToPostgres(now)), binary.BigEndian.Uint64(status[25:]))
+}
+
+func TestParseLogicalMessage(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	now := timeFromPostgres(timeToPostgres(time.Now()))
+	for _, tc := range []struct {
+		name string
+		buf  []byte
+		exp  interface{}
+	}{
+		{
+			name: "begin",
+			buf:  encodeBegin(beginMessage{finalLSN: 10, commitTime: now, xid: 7}),
+			exp:  &beginMessage{finalLSN: 10, commitTime: now, xid: 7},
+		},
+		{
+			name: "commit",
+			buf:  encodeCommit(commitMessage{commitLSN: 10, endLSN: 11, commitTime: now}),
+			exp:  &commitMessage{commitLSN: 10, endLSN: 11, commitTime: now},
+		},
+		{
+			name: "relation",
+			buf: encodeRelation(relationMessage{
+				relationID: 16384, namespace: "public", name: "t", replicaIdentity: 'd',
+				columns: []relationColumn{
+					{key: true, name: "id", typeOID: 20, typeModifier: -1},
+					{name: "v", typeOID: 25, typeModifier: -1},
+				},
+			}),
+			exp: &relationMessage{
+				relationID: 16384, namespace: "public", name: "t", replicaIdentity: 'd',
+				columns: []relationColumn{
+					{key: true, name: "id", typeOID: 20, typeModifier: -1},
+					{name: "v", typeOID: 25, typeModifier: -1},
+				},
+			},
+		},
+		{
+			name: "insert",
+			buf:  encodeInsert(insertMessage{relationID: 1, newTuple: tuple{text("1"), {kind: tupleNull}}}),
+			exp:  &insertMessage{relationID: 1, newTuple: tuple{text("1"), {kind: tupleNull}}},
+		},
+		{
+			name: "update",
+			buf: encodeUpdate(updateMessage{
+				relationID: 1,
+				oldTuple:   tuple{text("1"), {kind: tupleNull}},
+				newTuple:   tuple{text("2"), {kind: tupleUnchanged}},
+			}),
+			exp: &updateMessage{
+				relationID: 1,
+				oldTuple:   tuple{text("1"), {kind: tupleNull}},
+				newTuple:   tuple{text("2"), {kind: tupleUnchanged}},
+			},
+		},
+		{
+			name: "update without old tuple",
+			buf:  encodeUpdate(updateMessage{relationID: 1, newTuple: tuple{text("2"), text("two")}}),
+			exp:  &updateMessage{relationID: 1, newTuple: tuple{text("2"), text("two")}},
+		},
+		{
+			name: "delete",
+			buf:  encodeDelete(deleteMessage{relationID: 1, oldTuple: tuple{text("1"), {kind: tupleNull}}}),
+			exp:  &deleteMessage{relationID: 1, oldTuple: tuple{text("1"), {kind: tupleNull}}},
+		},
+		{
+			name: "truncate",
+			buf:  encodeTruncate(truncateMessage{relationIDs: []uint32{1, 2}}),
+			exp:  &truncateMessage{relationIDs: []uint32{1, 2}},
+		},
+		{
+			name: "origin",
+			buf:  appendString(binary.BigEndian.AppendUint64([]byte{'O'}, 10), "origin"),
+			exp:  nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := parseLogicalMessage(tc.buf)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, m)
+
+			// Truncated messages fail to decode.
+			if tc.exp != nil {
+				_, err = parseLogicalMessage(tc.buf[:len(tc.buf)-1])
+				require.Error(t, err)
+			}
+		})
+	}
+}
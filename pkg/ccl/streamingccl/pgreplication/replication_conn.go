@@ -0,0 +1,238 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+)
+
+// replicationConn is a connection to a PostgreSQL server in logical
+// replication mode, which accepts both the replication commands and SQL
+// statements. It is not safe for concurrent use.
+type replicationConn struct {
+	conn *pgconn.PgConn
+}
+
+func connectForReplication(ctx context.Context, connStr string) (*replicationConn, error) {
+	cfg, err := pgconn.ParseConfig(connStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing PostgreSQL connection string")
+	}
+	cfg.RuntimeParams["replication"] = "database"
+	conn, err := pgconn.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "connecting to PostgreSQL")
+	}
+	return &replicationConn{conn: conn}, nil
+}
+
+func (c *replicationConn) Close(ctx context.Context) error {
+	return c.conn.Close(ctx)
+}
+
+// query runs statements with the simple query protocol and returns the rows of
+// their results as strings. NULL values are returned as empty strings.
+func (c *replicationConn) query(ctx context.Context, sql string) ([][]string, error) {
+	results, err := c.conn.Exec(ctx, sql).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	for _, res := range results {
+		for _, r := range res.Rows {
+			row := make([]string, len(r))
+			for i := range r {
+				row[i] = string(r[i])
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// publishedTable is a table of a publication.
+type publishedTable struct {
+	schema, name string
+}
+
+// publishedTables returns the tables of the publications, and checks that the
+// publications exist.
+func (c *replicationConn) publishedTables(
+	ctx context.Context, publications []string,
+) ([]publishedTable, error) {
+	names := make([]string, len(publications))
+	for i, p := range publications {
+		names[i] = quoteLiteral(p)
+	}
+	rows, err := c.query(ctx, fmt.Sprintf(
+		`SELECT pubname FROM pg_catalog.pg_publication WHERE pubname IN (%s)`, strings.Join(names, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) != len(publications) {
+		found := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			found[row[0]] = true
+		}
+		for _, p := range publications {
+			if !found[p] {
+				return nil, errors.Newf("publication %q does not exist", p)
+			}
+		}
+	}
+
+	rows, err = c.query(ctx, fmt.Sprintf(
+		`SELECT DISTINCT schemaname, tablename FROM pg_catalog.pg_publication_tables
+WHERE pubname IN (%s) ORDER BY 1, 2`, strings.Join(names, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]publishedTable, len(rows))
+	for i, row := range rows {
+		tables[i] = publishedTable{schema: row[0], name: row[1]}
+	}
+	return tables, nil
+}
+
+// tableColumns returns the names of the columns of a table that logical
+// replication publishes, which excludes the generated columns.
+func (c *replicationConn) tableColumns(ctx context.Context, t publishedTable) ([]string, error) {
+	rows, err := c.query(ctx, fmt.Sprintf(
+		`SELECT attname FROM pg_catalog.pg_attribute
+WHERE attrelid = %s::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+ORDER BY attnum`, quoteLiteral(quoteIdent(t.schema)+"."+quoteIdent(t.name))))
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, len(rows))
+	for i, row := range rows {
+		columns[i] = row[0]
+	}
+	return columns, nil
+}
+
+// createSlot creates a logical replication slot that decodes changes with the
+// pgoutput plugin, and returns the position as of which it decodes them. If
+// useSnapshot is set, the slot is created in a new transaction that reads the
+// snapshot as of that position, in which the published tables can then be
+// copied, until the transaction is committed.
+func (c *replicationConn) createSlot(
+	ctx context.Context, slot string, useSnapshot bool,
+) (lsn, error) {
+	snapshotAction := "NOEXPORT_SNAPSHOT"
+	if useSnapshot {
+		if _, err := c.query(ctx, `BEGIN READ ONLY ISOLATION LEVEL REPEATABLE READ`); err != nil {
+			return 0, err
+		}
+		snapshotAction = "USE_SNAPSHOT"
+	}
+	rows, err := c.query(ctx, fmt.Sprintf(
+		"CREATE_REPLICATION_SLOT %s LOGICAL pgoutput %s", quoteIdent(slot), snapshotAction))
+	if err != nil {
+		return 0, errors.Wrapf(err, "creating replication slot %q", slot)
+	}
+	if len(rows) != 1 || len(rows[0]) < 2 {
+		return 0, errors.Newf("unexpected result creating replication slot %q: %v", slot, rows)
+	}
+	return parseLSN(rows[0][1])
+}
+
+// commit commits the transaction started by createSlot.
+func (c *replicationConn) commit(ctx context.Context) error {
+	_, err := c.query(ctx, `COMMIT`)
+	return err
+}
+
+// dropSlotIfExists drops a replication slot, if it exists.
+func (c *replicationConn) dropSlotIfExists(ctx context.Context, slot string) error {
+	_, err := c.query(ctx, fmt.Sprintf(
+		`SELECT pg_catalog.pg_drop_replication_slot(slot_name) FROM pg_catalog.pg_replication_slots
+WHERE slot_name = %s`, quoteLiteral(slot)))
+	return errors.Wrapf(err, "dropping replication slot %q", slot)
+}
+
+// copyTable writes the rows of a table to w in the text format of COPY.
+func (c *replicationConn) copyTable(
+	ctx context.Context, w io.Writer, t publishedTable, columns []string,
+) error {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(col)
+	}
+	_, err := c.conn.CopyTo(ctx, w, fmt.Sprintf("COPY %s.%s (%s) TO STDOUT",
+		quoteIdent(t.schema), quoteIdent(t.name), strings.Join(quoted, ", ")))
+	return errors.Wrapf(err, "copying table %s.%s", t.schema, t.name)
+}
+
+// startReplication starts streaming the changes to the tables of the
+// publications from a slot, from the given position or from the position the
+// slot was last confirmed to have been flushed to, whichever is later. Once it
+// returns, the connection only accepts receive and sendStandbyStatus.
+func (c *replicationConn) startReplication(
+	ctx context.Context, slot string, start lsn, publications []string,
+) error {
+	names := make([]string, len(publications))
+	for i, p := range publications {
+		names[i] = quoteIdent(p)
+	}
+	sql := fmt.Sprintf("START_REPLICATION SLOT %s LOGICAL %s (proto_version '1', publication_names %s)",
+		quoteIdent(slot), start, quoteLiteral(strings.Join(names, ",")))
+	if err := c.conn.SendBytes(ctx, (&pgproto3.Query{String: sql}).Encode(nil)); err != nil {
+		return err
+	}
+	for {
+		msg, err := c.conn.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			return nil
+		case *pgproto3.NoticeResponse:
+		case *pgproto3.ErrorResponse:
+			return errors.Wrapf(pgconn.ErrorResponseToPgError(msg),
+				"starting replication from slot %q", slot)
+		default:
+			return errors.Newf("unexpected message %T starting replication", msg)
+		}
+	}
+}
+
+// receive returns the next message of the replication stream. The returned
+// message is only valid until the next call.
+func (c *replicationConn) receive(ctx context.Context) (pgproto3.BackendMessage, error) {
+	return c.conn.ReceiveMessage(ctx)
+}
+
+// sendStandbyStatus reports the position up to which the changes of the
+// stream have been durably applied, before which the server may discard its
+// write-ahead log.
+func (c *replicationConn) sendStandbyStatus(ctx context.Context, flushed lsn) error {
+	msg := &pgproto3.CopyData{Data: encodeStandbyStatusUpdate(flushed, timeutil.Now())}
+	return c.conn.SendBytes(ctx, msg.Encode(nil))
+}
+
+// quoteIdent quotes a PostgreSQL identifier.
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a PostgreSQL string literal, assuming
+// standard_conforming_strings, which is on by default.
+func quoteLiteral(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
@@ -0,0 +1,565 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+)
+
+var subscriptionCheckpointFrequency = settings.RegisterDurationSetting(
+	settings.TenantWritable,
+	"subscription.checkpoint_frequency",
+	"the frequency at which a subscription persists its progress and reports it to the "+
+		"PostgreSQL server, which may then discard the write-ahead log before it",
+	10*time.Second,
+	settings.PositiveDuration,
+)
+
+const (
+	// subscriptionSlotInfoKey is the job_info key that records that the job
+	// attempted to create its replication slot, which a later attempt then
+	// drops and recreates if the initial copy did not complete.
+	subscriptionSlotInfoKey = "subscription-slot"
+	// subscriptionImportInfoKeyPrefix is the prefix of the job_info keys under
+	// which the job records the ID of the job importing the initial copy of
+	// each table.
+	subscriptionImportInfoKeyPrefix = "subscription-import/"
+)
+
+func subscriptionImportInfoKey(t jobspb.SubscriptionDetails_Table) string {
+	return fmt.Sprintf("%s%d", subscriptionImportInfoKeyPrefix, t.DescriptorID)
+}
+
+// stagingURI returns the URI of the directory, in the user-scoped file storage
+// of the user of the job, where the initial copy of the tables is staged for
+// import.
+func stagingURI(jobID jobspb.JobID) string {
+	return fmt.Sprintf("userfile:///subscription-%d", jobID)
+}
+
+func stagingFileName(t jobspb.SubscriptionDetails_Table) string {
+	return fmt.Sprintf("%d.copy", t.DescriptorID)
+}
+
+// subscriptionResumer replicates the tables of PostgreSQL publications into
+// tables of this cluster.
+//
+// The job first creates a logical replication slot on the server. Unless the
+// subscription does not copy data, it copies the published tables, as of the
+// snapshot of the slot, to files that it then imports with IMPORT INTO. It
+// then streams the changes decoded by the slot from that snapshot on, and
+// applies each replicated transaction in a transaction. The job runs on a
+// single node.
+type subscriptionResumer struct {
+	job *jobs.Job
+}
+
+var _ jobs.Resumer = (*subscriptionResumer)(nil)
+
+// Resume is part of the jobs.Resumer interface.
+func (r *subscriptionResumer) Resume(ctx context.Context, execCtx interface{}) error {
+	p := execCtx.(sql.JobExecContext)
+	metrics := p.ExecCfg().JobRegistry.MetricsStruct().JobSpecificMetrics[jobspb.TypeSubscription].(*Metrics)
+	metrics.Running.Inc(1)
+	defer metrics.Running.Dec(1)
+
+	if err := r.replicateWithRetries(ctx, p, metrics); err != nil {
+		// Like a PostgreSQL subscription, which is disabled when it fails, the
+		// job pauses rather than fail and drop its replication slot.
+		const errorFmt = "subscription job failed (%s) but is being paused"
+		log.Warningf(ctx, errorFmt, err)
+		return jobs.MarkPauseRequestError(err)
+	}
+	return nil
+}
+
+func (r *subscriptionResumer) replicateWithRetries(
+	ctx context.Context, p sql.JobExecContext, metrics *Metrics,
+) error {
+	ro := retry.Options{
+		InitialBackoff: 100 * time.Millisecond,
+		Multiplier:     2,
+		MaxBackoff:     10 * time.Second,
+		MaxRetries:     20,
+	}
+	var err error
+	for rt := retry.StartWithCtx(ctx, ro); rt.Next(); {
+		err = r.replicate(ctx, p, metrics)
+		if jobs.IsPermanentJobError(err) || errors.Is(err, context.Canceled) {
+			break
+		}
+		log.Warningf(ctx, "waiting before retrying error: %s", err)
+	}
+	return err
+}
+
+func (r *subscriptionResumer) replicate(
+	ctx context.Context, p sql.JobExecContext, metrics *Metrics,
+) error {
+	details := r.job.Details().(jobspb.SubscriptionDetails)
+	if !r.job.Progress().GetSubscription().InitialCopyComplete {
+		if err := r.initialCopy(ctx, p, details); err != nil {
+			return err
+		}
+	}
+	return r.stream(ctx, p, details, metrics)
+}
+
+// initialCopy creates the replication slot and, if the subscription copies
+// data, imports the rows of the published tables as of the snapshot of the
+// slot.
+func (r *subscriptionResumer) initialCopy(
+	ctx context.Context, p sql.JobExecContext, details jobspb.SubscriptionDetails,
+) error {
+	if r.job.Progress().GetSubscription().ConsistentLSN == 0 {
+		consistentLSN, err := r.createSlot(ctx, p, details)
+		if err != nil {
+			return err
+		}
+		if err := r.job.NoTxn().Update(ctx, func(_ isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+			prog := md.Progress.GetSubscription()
+			prog.ConsistentLSN = uint64(consistentLSN)
+			prog.FlushedLSN = uint64(consistentLSN)
+			prog.InitialCopyComplete = !details.CopyData
+			if details.CopyData {
+				md.Progress.RunningStatus = "importing the initial copy of the published tables"
+			}
+			ju.UpdateProgress(md.Progress)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if !details.CopyData {
+			return nil
+		}
+	}
+
+	importJobIDs := make([]jobspb.JobID, len(details.Tables))
+	for i, t := range details.Tables {
+		var err error
+		if importJobIDs[i], err = r.startImport(ctx, p, t); err != nil {
+			return errors.Wrapf(err, "importing table %s", t.TableName)
+		}
+	}
+	if err := p.ExecCfg().JobRegistry.WaitForJobs(ctx, importJobIDs); err != nil {
+		return errors.Wrap(err, "importing the initial copy of the published tables")
+	}
+	if err := r.job.NoTxn().Update(ctx, func(_ isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+		md.Progress.GetSubscription().InitialCopyComplete = true
+		ju.UpdateProgress(md.Progress)
+		return nil
+	}); err != nil {
+		return err
+	}
+	r.deleteStagedFiles(ctx, p, details)
+	return nil
+}
+
+// createSlot creates the replication slot and, if the subscription copies
+// data, stages the rows of the published tables as of its snapshot. It returns
+// the position as of which the slot decodes changes.
+func (r *subscriptionResumer) createSlot(
+	ctx context.Context, p sql.JobExecContext, details jobspb.SubscriptionDetails,
+) (lsn, error) {
+	var attempted bool
+	if err := p.ExecCfg().InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		info := jobs.InfoStorageForJob(txn, r.job.ID())
+		var err error
+		if _, attempted, err = info.Get(ctx, subscriptionSlotInfoKey); err != nil || attempted {
+			return err
+		}
+		return info.Write(ctx, subscriptionSlotInfoKey, []byte(details.SlotName))
+	}); err != nil {
+		return 0, err
+	}
+
+	conn, err := connectForReplication(ctx, details.ConnStr)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			log.Warningf(ctx, "failed to close connection to PostgreSQL: %v", err)
+		}
+	}()
+	if attempted {
+		// A previous attempt may have created the slot, but the snapshot of the
+		// slot only lasts as long as the connection that created it, so the
+		// tables cannot be copied as of it anymore.
+		if err := conn.dropSlotIfExists(ctx, details.SlotName); err != nil {
+			return 0, err
+		}
+	}
+	consistentLSN, err := conn.createSlot(ctx, details.SlotName, details.CopyData)
+	if err != nil || !details.CopyData {
+		return consistentLSN, err
+	}
+
+	store, err := p.ExecCfg().DistSQLSrv.ExternalStorageFromURI(ctx, stagingURI(r.job.ID()), p.User())
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+	for _, t := range details.Tables {
+		w, err := store.Writer(ctx, stagingFileName(t))
+		if err != nil {
+			return 0, err
+		}
+		if err := conn.copyTable(ctx, w, publishedTable{schema: t.SourceSchema, name: t.SourceName}, t.Columns); err != nil {
+			_ = w.Close()
+			return 0, err
+		}
+		if err := w.Close(); err != nil {
+			return 0, err
+		}
+	}
+	return consistentLSN, conn.commit(ctx)
+}
+
+// startImport starts, unless a previous attempt already did, a job that
+// imports the staged rows of a table, and returns its ID.
+func (r *subscriptionResumer) startImport(
+	ctx context.Context, p sql.JobExecContext, t jobspb.SubscriptionDetails_Table,
+) (jobspb.JobID, error) {
+	var importJobID jobspb.JobID
+	err := p.ExecCfg().InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		info := jobs.InfoStorageForJob(txn, r.job.ID())
+		v, found, err := info.Get(ctx, subscriptionImportInfoKey(t))
+		if err != nil {
+			return err
+		}
+		if found {
+			id, err := strconv.ParseInt(string(v), 10, 64)
+			importJobID = jobspb.JobID(id)
+			return err
+		}
+
+		columns := make([]string, len(t.Columns))
+		for i, c := range t.Columns {
+			columns[i] = tree.NameString(c)
+		}
+		row, err := txn.QueryRowEx(ctx, "subscription-import", txn.KV(),
+			sessiondata.InternalExecutorOverride{User: p.User()},
+			fmt.Sprintf("IMPORT INTO %s (%s) PGCOPY DATA ($1) WITH detached",
+				t.TableName, strings.Join(columns, ", ")),
+			stagingURI(r.job.ID())+"/"+stagingFileName(t))
+		if err != nil {
+			return err
+		}
+		importJobID = jobspb.JobID(tree.MustBeDInt(row[0]))
+		return info.Write(ctx, subscriptionImportInfoKey(t),
+			[]byte(strconv.FormatInt(int64(importJobID), 10)))
+	})
+	return importJobID, err
+}
+
+// deleteStagedFiles deletes the staged initial copy of the tables, on a best
+// effort basis.
+func (r *subscriptionResumer) deleteStagedFiles(
+	ctx context.Context, p sql.JobExecContext, details jobspb.SubscriptionDetails,
+) {
+	store, err := p.ExecCfg().DistSQLSrv.ExternalStorageFromURI(ctx, stagingURI(r.job.ID()), p.User())
+	if err != nil {
+		log.Warningf(ctx, "failed to open the staged initial copy: %v", err)
+		return
+	}
+	defer store.Close()
+	for _, t := range details.Tables {
+		if err := store.Delete(ctx, stagingFileName(t)); err != nil && !errors.Is(err, cloud.ErrFileDoesNotExist) {
+			log.Warningf(ctx, "failed to delete the staged initial copy of table %s: %v", t.TableName, err)
+		}
+	}
+}
+
+// stream applies the changes streamed from the replication slot, from the
+// position up to which they were last checkpointed.
+func (r *subscriptionResumer) stream(
+	ctx context.Context, p sql.JobExecContext, details jobspb.SubscriptionDetails, metrics *Metrics,
+) error {
+	execCfg := p.ExecCfg()
+	tables := make(map[publishedTable]catalog.TableDescriptor, len(details.Tables))
+	if err := sql.DescsTxn(ctx, execCfg, func(ctx context.Context, txn isql.Txn, col *descs.Collection) error {
+		for _, t := range details.Tables {
+			td, err := col.ByIDWithLeased(txn.KV()).WithoutNonPublic().Get().Table(ctx, t.DescriptorID)
+			if err != nil {
+				return err
+			}
+			if err := validateDestinationTable(td, t.Columns); err != nil {
+				return jobs.MarkAsPermanentJobError(err)
+			}
+			tables[publishedTable{schema: t.SourceSchema, name: t.SourceName}] = td
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	progress := r.job.Progress().GetSubscription()
+	conn, err := connectForReplication(ctx, details.ConnStr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			log.Warningf(ctx, "failed to close connection to PostgreSQL: %v", err)
+		}
+	}()
+	if err := conn.startReplication(ctx, details.SlotName, lsn(progress.FlushedLSN), details.Publications); err != nil {
+		return err
+	}
+
+	c := &subscriptionConsumer{
+		job:            r.job,
+		conn:           conn,
+		applier:        newApplier(execCfg.InternalDB, p.User(), &p.ExtendedEvalContext().Context, tables),
+		metrics:        metrics,
+		sv:             &execCfg.Settings.SV,
+		applied:        lsn(progress.FlushedLSN),
+		flushed:        lsn(progress.FlushedLSN),
+		replicatedTime: progress.ReplicatedTime,
+	}
+	return c.consume(ctx)
+}
+
+// subscriptionConsumer consumes the replication stream of a slot.
+type subscriptionConsumer struct {
+	job     *jobs.Job
+	conn    *replicationConn
+	applier *applier
+	metrics *Metrics
+	sv      *settings.Values
+
+	// txn holds the changes of the transaction being received, if any.
+	txn []change
+	// inTxn is set between the begin and commit messages of a transaction.
+	inTxn bool
+	// applied is the position up to which all changes have been applied, and
+	// replicatedTime is the corresponding time on the server.
+	applied        lsn
+	replicatedTime hlc.Timestamp
+	// flushed is the position last persisted in the progress of the job, which
+	// is the position reported to the server.
+	flushed lsn
+}
+
+func (c *subscriptionConsumer) consume(ctx context.Context) error {
+	nextCheckpoint := timeutil.Now()
+	for {
+		if now := timeutil.Now(); !now.Before(nextCheckpoint) {
+			if err := c.checkpoint(ctx); err != nil {
+				return err
+			}
+			nextCheckpoint = now.Add(subscriptionCheckpointFrequency.Get(c.sv))
+		}
+
+		recvCtx, cancel := context.WithDeadline(ctx, nextCheckpoint)
+		msg, err := c.conn.receive(recvCtx)
+		cancel()
+		if err != nil {
+			if pgconn.Timeout(err) && ctx.Err() == nil {
+				continue
+			}
+			return err
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.CopyData:
+			if err := c.handle(ctx, msg.Data); err != nil {
+				return err
+			}
+		case *pgproto3.NoticeResponse:
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(msg)
+		case *pgproto3.CopyDone:
+			return errors.New("the PostgreSQL server ended the replication stream")
+		default:
+			return errors.Newf("unexpected message %T in the replication stream", msg)
+		}
+	}
+}
+
+func (c *subscriptionConsumer) handle(ctx context.Context, data []byte) error {
+	if len(data) == 0 {
+		return errors.New("empty replication message")
+	}
+	switch data[0] {
+	case primaryKeepaliveByteID:
+		ka, err := parsePrimaryKeepalive(data[1:])
+		if err != nil {
+			return err
+		}
+		// Transactions are only sent once they committed, so if none is being
+		// received, all the changes to the published tables before the position
+		// the server reports have been applied.
+		if !c.inTxn && ka.walEnd >= c.applied {
+			c.applied = ka.walEnd
+			c.replicatedTime.Forward(hlc.Timestamp{WallTime: ka.serverTime.UnixNano()})
+		}
+		if ka.replyRequested {
+			return c.conn.sendStandbyStatus(ctx, c.flushed)
+		}
+		return nil
+	case xLogDataByteID:
+		xld, err := parseXLogData(data[1:])
+		if err != nil {
+			return err
+		}
+		msg, err := parseLogicalMessage(xld.data)
+		if err != nil {
+			return err
+		}
+		return c.handleLogicalMessage(ctx, msg)
+	default:
+		return errors.Newf("unexpected replication message %q", data[0])
+	}
+}
+
+func (c *subscriptionConsumer) handleLogicalMessage(ctx context.Context, msg interface{}) error {
+	switch msg := msg.(type) {
+	case nil:
+		return nil
+	case *beginMessage:
+		if c.inTxn {
+			return errors.New("transaction began before the previous one committed")
+		}
+		c.inTxn = true
+		c.txn = c.txn[:0]
+		return nil
+	case *relationMessage:
+		return c.applier.addRelation(msg)
+	case *commitMessage:
+		if !c.inTxn {
+			return errors.New("commit outside of a transaction")
+		}
+		c.inTxn = false
+		// The slot resends the transactions that committed after the position
+		// it was last confirmed to have been flushed to, some of which may have
+		// been applied before the job last checkpointed.
+		if msg.endLSN <= c.applied {
+			return nil
+		}
+		rows, err := c.applier.apply(ctx, c.txn)
+		if err != nil {
+			return err
+		}
+		c.metrics.TransactionsApplied.Inc(1)
+		c.metrics.RowsApplied.Inc(int64(rows))
+		c.metrics.CommitLatency.RecordValue(timeutil.Since(msg.commitTime).Nanoseconds())
+		c.applied = msg.endLSN
+		c.replicatedTime.Forward(hlc.Timestamp{WallTime: msg.commitTime.UnixNano()})
+		return nil
+	default:
+		if !c.inTxn {
+			return errors.Newf("change %T outside of a transaction", msg)
+		}
+		ch, err := c.applier.makeChange(msg)
+		if err != nil {
+			return err
+		}
+		c.txn = append(c.txn, ch)
+		return nil
+	}
+}
+
+// checkpoint persists the position up to which the changes have been applied
+// in the progress of the job, and reports it to the server.
+func (c *subscriptionConsumer) checkpoint(ctx context.Context) error {
+	applied, replicatedTime := c.applied, c.replicatedTime
+	if err := c.job.NoTxn().Update(ctx, func(_ isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+		if err := md.CheckRunningOrReverting(); err != nil {
+			return err
+		}
+		prog := md.Progress.GetSubscription()
+		prog.FlushedLSN = uint64(applied)
+		prog.ReplicatedTime = replicatedTime
+		md.Progress.RunningStatus = fmt.Sprintf("replicating from PostgreSQL: applied changes up to LSN %s", applied)
+		if !replicatedTime.IsEmpty() {
+			hw := replicatedTime
+			md.Progress.Progress = &jobspb.Progress_HighWater{HighWater: &hw}
+		}
+		ju.UpdateProgress(md.Progress)
+		return nil
+	}); err != nil {
+		return err
+	}
+	c.flushed = applied
+	if !replicatedTime.IsEmpty() {
+		c.metrics.ReplicationLagNanos.Update(timeutil.Since(replicatedTime.GoTime()).Nanoseconds())
+	}
+	return c.conn.sendStandbyStatus(ctx, c.flushed)
+}
+
+// OnFailOrCancel is part of the jobs.Resumer interface. It cancels the imports
+// of the initial copy that are still running, and drops the replication slot
+// and the staged initial copy, on a best effort basis.
+func (r *subscriptionResumer) OnFailOrCancel(
+	ctx context.Context, execCtx interface{}, _ error,
+) error {
+	p := execCtx.(sql.JobExecContext)
+	execCfg := p.ExecCfg()
+	details := r.job.Details().(jobspb.SubscriptionDetails)
+
+	var importJobIDs []jobspb.JobID
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		importJobIDs = importJobIDs[:0]
+		return jobs.InfoStorageForJob(txn, r.job.ID()).Iterate(ctx, subscriptionImportInfoKeyPrefix,
+			func(_ string, value []byte) error {
+				id, err := strconv.ParseInt(string(value), 10, 64)
+				importJobIDs = append(importJobIDs, jobspb.JobID(id))
+				return err
+			})
+	}); err != nil {
+		return err
+	}
+	for _, id := range importJobIDs {
+		if _, err := execCfg.InternalDB.Executor().ExecEx(ctx, "subscription-cancel-import", nil, /* txn */
+			sessiondata.NodeUserSessionDataOverride, `CANCEL JOB $1`, id); err != nil {
+			// The import has most likely already finished.
+			log.Infof(ctx, "could not cancel import job %d: %v", id, err)
+		}
+	}
+	if !r.job.Progress().GetSubscription().InitialCopyComplete {
+		r.deleteStagedFiles(ctx, p, details)
+	}
+
+	if err := timeutil.RunWithTimeout(ctx, "drop replication slot", 30*time.Second,
+		func(ctx context.Context) error {
+			conn, err := connectForReplication(ctx, details.ConnStr)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close(ctx) }()
+			return conn.dropSlotIfExists(ctx, details.SlotName)
+		},
+	); err != nil {
+		log.Warningf(ctx, "failed to drop replication slot %q on PostgreSQL: %v", details.SlotName, err)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fakeConsistentLSN lsn = 0x1000000
+	fakeCommitLSN     lsn = 0x2000000
+	fakeCommitEndLSN  lsn = 0x2000010
+	fakeWALEnd        lsn = 0x2000020
+)
+
+// fakePostgres implements enough of a PostgreSQL server, in logical
+// replication mode, for a subscription to replicate publication "pub" of
+// table public.t (id INT8 PRIMARY KEY, v TEXT). The table holds rows (1, one)
+// and (2, two) as of the snapshot of the slot, and a transaction then inserts
+// (3, three), updates row 1 to (1, uno) and deletes row 2.
+type fakePostgres struct {
+	t  *testing.T
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu struct {
+		syncutil.Mutex
+		conns       []net.Conn
+		slotCreated bool
+		slotDropped bool
+		flushed     lsn
+	}
+}
+
+func startFakePostgres(t *testing.T) *fakePostgres {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakePostgres{t: t, ln: ln}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.mu.conns = append(f.mu.conns, conn)
+			f.mu.Unlock()
+			f.wg.Add(1)
+			go func() {
+				defer f.wg.Done()
+				defer conn.Close()
+				f.serve(conn)
+			}()
+		}
+	}()
+	return f
+}
+
+func (f *fakePostgres) connStr() string {
+	return fmt.Sprintf("postgres://replicator:secret@%s/src?sslmode=disable", f.ln.Addr())
+}
+
+func (f *fakePostgres) stop() {
+	_ = f.ln.Close()
+	f.mu.Lock()
+	for _, conn := range f.mu.conns {
+		_ = conn.Close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+func (f *fakePostgres) serve(conn net.Conn) {
+	b := pgproto3.NewBackend(pgproto3.NewChunkReader(conn), conn)
+	if _, err := b.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	if !f.send(b, &pgproto3.AuthenticationOk{}, &pgproto3.ReadyForQuery{TxStatus: 'I'}) {
+		return
+	}
+	for {
+		msg, err := b.Receive()
+		if err != nil {
+			return
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			if !f.query(b, msg.String) {
+				return
+			}
+		case *pgproto3.CopyData:
+			if len(msg.Data) == 34 && msg.Data[0] == standbyStatusUpdateByteID {
+				f.mu.Lock()
+				f.mu.flushed = lsn(binary.BigEndian.Uint64(msg.Data[9:]))
+				f.mu.Unlock()
+			}
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+func (f *fakePostgres) send(b *pgproto3.Backend, msgs ...pgproto3.BackendMessage) bool {
+	for _, msg := range msgs {
+		if err := b.Send(msg); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakePostgres) sendRows(b *pgproto3.Backend, columns []string, rows [][]string) bool {
+	desc := &pgproto3.RowDescription{}
+	for _, c := range columns {
+		desc.Fields = append(desc.Fields, pgproto3.FieldDescription{
+			Name: []byte(c), DataTypeOID: 25, DataTypeSize: -1, TypeModifier: -1,
+		})
+	}
+	msgs := []pgproto3.BackendMessage{desc}
+	for _, row := range rows {
+		values := make([][]byte, len(row))
+		for i := range row {
+			values[i] = []byte(row[i])
+		}
+		msgs = append(msgs, &pgproto3.DataRow{Values: values})
+	}
+	msgs = append(msgs,
+		&pgproto3.CommandComplete{CommandTag: []byte(fmt.Sprintf("SELECT %d", len(rows)))},
+		&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	return f.send(b, msgs...)
+}
+
+func (f *fakePostgres) query(b *pgproto3.Backend, q string) bool {
+	switch {
+	case strings.HasPrefix(q, "SELECT pubname "):
+		return f.sendRows(b, []string{"pubname"}, [][]string{{"pub"}})
+	case strings.HasPrefix(q, "SELECT DISTINCT schemaname, tablename "):
+		return f.sendRows(b, []string{"schemaname", "tablename"}, [][]string{{"public", "t"}})
+	case strings.HasPrefix(q, "SELECT attname "):
+		return f.sendRows(b, []string{"attname"}, [][]string{{"id"}, {"v"}})
+	case strings.HasPrefix(q, "SELECT pg_catalog.pg_drop_replication_slot"):
+		f.mu.Lock()
+		created := f.mu.slotCreated
+		f.mu.slotDropped = f.mu.slotDropped || created
+		f.mu.Unlock()
+		if created {
+			return f.sendRows(b, []string{"pg_drop_replication_slot"}, [][]string{{""}})
+		}
+		return f.sendRows(b, []string{"pg_drop_replication_slot"}, nil)
+	case strings.HasPrefix(q, "BEGIN"), q == "COMMIT":
+		return f.send(b, &pgproto3.CommandComplete{CommandTag: []byte(q)}, &pgproto3.ReadyForQuery{TxStatus: 'I'})
+	case q == `CREATE_REPLICATION_SLOT "sub" LOGICAL pgoutput USE_SNAPSHOT`:
+		f.mu.Lock()
+		f.mu.slotCreated = true
+		f.mu.Unlock()
+		return f.sendRows(b, []string{"slot_name", "consistent_point", "snapshot_name", "output_plugin"},
+			[][]string{{"sub", fakeConsistentLSN.String(), "", "pgoutput"}})
+	case q == `COPY "public"."t" ("id", "v") TO STDOUT`:
+		return f.send(b,
+			&pgproto3.CopyOutResponse{ColumnFormatCodes: []uint16{0, 0}},
+			&pgproto3.CopyData{Data: []byte("1\tone\n")},
+			&pgproto3.CopyData{Data: []byte("2\ttwo\n")},
+			&pgproto3.CopyDone{},
+			&pgproto3.CommandComplete{CommandTag: []byte("COPY 2")},
+			&pgproto3.ReadyForQuery{TxStatus: 'T'})
+	case strings.HasPrefix(q, `START_REPLICATION SLOT "sub" LOGICAL 0/1000000 `):
+		return f.stream(b)
+	default:
+		return f.send(b,
+			&pgproto3.ErrorResponse{Severity: "ERROR", Code: "42601", Message: "unexpected query: " + q},
+			&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	}
+}
+
+func (f *fakePostgres) stream(b *pgproto3.Backend) bool {
+	commitTime := time.Now()
+	msgs := []pgproto3.BackendMessage{&pgproto3.CopyBothResponse{}}
+	for _, data := range [][]byte{
+		encodeBegin(beginMessage{finalLSN: fakeCommitLSN, commitTime: commitTime, xid: 1}),
+		encodeRelation(relationMessage{
+			relationID: 16384, namespace: "public", name: "t", replicaIdentity: 'd',
+			columns: []relationColumn{
+				{key: true, name: "id", typeOID: 20, typeModifier: -1},
+				{name: "v", typeOID: 25, typeModifier: -1},
+			},
+		}),
+		encodeInsert(insertMessage{relationID: 16384, newTuple: tuple{text("3"), text("three")}}),
+		encodeUpdate(updateMessage{relationID: 16384, newTuple: tuple{text("1"), text("uno")}}),
+		encodeDelete(deleteMessage{relationID: 16384, oldTuple: tuple{text("2"), {kind: tupleNull}}}),
+		encodeCommit(commitMessage{commitLSN: fakeCommitLSN, endLSN: fakeCommitEndLSN, commitTime: commitTime}),
+	} {
+		msgs = append(msgs, &pgproto3.CopyData{Data: encodeXLogData(fakeCommitLSN, data)})
+	}
+	msgs = append(msgs, &pgproto3.CopyData{Data: encodePrimaryKeepalive(fakeWALEnd, time.Now(), true)})
+	return f.send(b, msgs...)
+}
+
+func (f *fakePostgres) flushed() lsn {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mu.flushed
+}
+
+func (f *fakePostgres) slotDropped() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mu.slotDropped
+}
+
+func TestSubscription(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{
+		DefaultTestTenant: base.TODOTestTenantDisabled,
+		Knobs: base.TestingKnobs{
+			JobsTestingKnobs: jobs.NewTestingKnobsWithShortIntervals(),
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+
+	pg := startFakePostgres(t)
+	defer pg.stop()
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `SET CLUSTER SETTING subscription.checkpoint_frequency = '100ms'`)
+	sqlDB.Exec(t, `CREATE TABLE t (id INT PRIMARY KEY, v STRING)`)
+
+	sqlDB.ExpectErr(t, `publication "nope" does not exist`,
+		`CREATE SUBSCRIPTION sub CONNECTION $1 PUBLICATION pub, nope`, pg.connStr())
+
+	var jobID jobspb.JobID
+	sqlDB.QueryRow(t, `CREATE SUBSCRIPTION sub CONNECTION $1 PUBLICATION pub`, pg.connStr()).Scan(&jobID)
+	jobutils.WaitForJobToRun(t, sqlDB, jobID)
+
+	// The rows copied as of the snapshot of the slot are imported, and the
+	// transaction streamed after it is applied.
+	sqlDB.CheckQueryResultsRetry(t, `SELECT * FROM t ORDER BY id`, [][]string{{"1", "uno"}, {"3", "three"}})
+
+	// Once it checkpoints, the subscription reports the position of the server
+	// as flushed, since it applied every transaction before it.
+	testutils.SucceedsSoon(t, func() error {
+		if flushed := pg.flushed(); flushed != fakeWALEnd {
+			return errors.Newf("flushed LSN %s, expected %s", flushed, fakeWALEnd)
+		}
+		return nil
+	})
+
+	var description string
+	sqlDB.QueryRow(t, `SELECT description FROM [SHOW JOBS] WHERE job_id = $1`, jobID).Scan(&description)
+	require.NotContains(t, description, "secret")
+
+	sqlDB.Exec(t, `CANCEL JOB $1`, jobID)
+	jobutils.WaitForJobToCancel(t, sqlDB, jobID)
+	require.True(t, pg.slotDropped())
+}
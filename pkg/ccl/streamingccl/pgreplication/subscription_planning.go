@@ -0,0 +1,229 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package pgreplication
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+const subscriptionFeatureName = "CREATE SUBSCRIPTION"
+
+const (
+	optCopyData = "copy_data"
+	optSlotName = "slot_name"
+)
+
+var createSubscriptionOptionValidations = exprutil.KVOptionValidationMap{
+	optCopyData: exprutil.KVStringOptRequireValue,
+	optSlotName: exprutil.KVStringOptRequireValue,
+}
+
+var createSubscriptionHeader = colinfo.ResultColumns{
+	{Name: "job_id", Typ: types.Int},
+}
+
+func createSubscriptionTypeCheck(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (matched bool, _ colinfo.ResultColumns, _ error) {
+	createStmt, ok := stmt.(*tree.CreateSubscription)
+	if !ok {
+		return false, nil, nil
+	}
+	if err := exprutil.TypeCheck(
+		ctx, subscriptionFeatureName, p.SemaCtx(),
+		exprutil.Strings{createStmt.ConnInfo},
+		&exprutil.KVOptions{
+			KVOptions:  createStmt.Options,
+			Validation: createSubscriptionOptionValidations,
+		},
+	); err != nil {
+		return false, nil, err
+	}
+	return true, createSubscriptionHeader, nil
+}
+
+// createSubscriptionPlanHook implements sql.PlanHookFn for CREATE
+// SUBSCRIPTION. It resolves the tables of the publications on the PostgreSQL
+// server to the tables of the same schema and name in the current database,
+// and starts a job that replicates them.
+func createSubscriptionPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	createStmt, ok := stmt.(*tree.CreateSubscription)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	exprEval := p.ExprEvaluator(subscriptionFeatureName)
+	connStr, err := exprEval.String(ctx, createStmt.ConnInfo)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	opts, err := exprEval.KVOptions(ctx, createStmt.Options, createSubscriptionOptionValidations)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	copyData := true
+	if v, ok := opts[optCopyData]; ok {
+		copyData, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, nil, nil, false, pgerror.Wrapf(err, pgcode.InvalidParameterValue,
+				"invalid value for %s", optCopyData)
+		}
+	}
+	slotName := string(createStmt.Name)
+	if v, ok := opts[optSlotName]; ok {
+		slotName = v
+	}
+	publications := make([]string, len(createStmt.Publications))
+	for i := range createStmt.Publications {
+		publications[i] = string(createStmt.Publications[i])
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer span.Finish()
+
+		if err := utilccl.CheckEnterpriseEnabled(
+			p.ExecCfg().Settings, p.ExecCfg().NodeInfo.LogicalClusterID(), subscriptionFeatureName,
+		); err != nil {
+			return err
+		}
+		database := p.SessionData().Database
+		if database == "" {
+			return pgerror.New(pgcode.UndefinedDatabase,
+				"CREATE SUBSCRIPTION requires a current database")
+		}
+
+		conn, err := connectForReplication(ctx, connStr)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := conn.Close(ctx); err != nil {
+				log.Warningf(ctx, "failed to close connection to PostgreSQL: %v", err)
+			}
+		}()
+		srcTables, err := conn.publishedTables(ctx, publications)
+		if err != nil {
+			return err
+		}
+		if len(srcTables) == 0 {
+			return pgerror.Newf(pgcode.InvalidParameterValue, "the publications have no tables")
+		}
+
+		tables := make([]jobspb.SubscriptionDetails_Table, len(srcTables))
+		for i, src := range srcTables {
+			columns, err := conn.tableColumns(ctx, src)
+			if err != nil {
+				return err
+			}
+			tn := tree.MakeTableNameWithSchema(tree.Name(database), tree.Name(src.schema), tree.Name(src.name))
+			_, td, err := p.ResolveMutableTableDescriptor(ctx, &tn, true /* required */, tree.ResolveRequireTableDesc)
+			if err != nil {
+				return errors.Wrapf(err, "resolving the destination of table %s.%s", src.schema, src.name)
+			}
+			for _, priv := range []privilege.Kind{privilege.INSERT, privilege.UPDATE, privilege.DELETE} {
+				if err := p.CheckPrivilege(ctx, td, priv); err != nil {
+					return err
+				}
+			}
+			if err := validateDestinationTable(td, columns); err != nil {
+				return err
+			}
+			tables[i] = jobspb.SubscriptionDetails_Table{
+				SourceSchema: src.schema,
+				SourceName:   src.name,
+				DescriptorID: td.GetID(),
+				TableName:    tn.FQString(),
+				Columns:      columns,
+			}
+		}
+
+		redactedStmt := &tree.CreateSubscription{
+			Name:         createStmt.Name,
+			ConnInfo:     tree.NewDString(redactConnStr(connStr)),
+			Publications: createStmt.Publications,
+			Options:      createStmt.Options,
+		}
+		jr := jobs.Record{
+			JobID:       p.ExecCfg().JobRegistry.MakeJobID(),
+			Description: tree.AsString(redactedStmt),
+			Username:    p.User(),
+			Details: jobspb.SubscriptionDetails{
+				Name:         string(createStmt.Name),
+				ConnStr:      connStr,
+				Publications: publications,
+				SlotName:     slotName,
+				CopyData:     copyData,
+				Tables:       tables,
+			},
+			Progress: jobspb.SubscriptionProgress{},
+		}
+		if _, err := p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(
+			ctx, jr, jr.JobID, p.InternalSQLTxn(),
+		); err != nil {
+			return err
+		}
+		resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(jr.JobID))}
+		return nil
+	}
+	return fn, createSubscriptionHeader, nil, false, nil
+}
+
+var connStrPasswordRE = regexp.MustCompile(`password\s*=\s*('(\\.|[^'])*'|\S+)`)
+
+// redactConnStr redacts the password of a connection string, which may be a
+// URI or a list of keyword/value pairs.
+func redactConnStr(connStr string) string {
+	if u, err := url.Parse(connStr); err == nil && u.Scheme != "" {
+		if u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), "redacted")
+			}
+		}
+		if q := u.Query(); q.Get("password") != "" {
+			q.Set("password", "redacted")
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return connStrPasswordRE.ReplaceAllString(connStr, "password=redacted")
+}
+
+func init() {
+	sql.AddPlanHook("subscription", createSubscriptionPlanHook, createSubscriptionTypeCheck)
+	jobs.RegisterConstructor(
+		jobspb.TypeSubscription,
+		func(job *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return &subscriptionResumer{job: job}
+		},
+		jobs.UsesTenantCostControl,
+		jobs.WithJobMetrics(makeMetrics()),
+	)
+}
@@ -48,8 +48,12 @@ type CheckpointToken []byte
 type Client interface {
 	// Create initializes a stream with the source, potentially reserving any
 	// required resources, such as protected timestamps, and returns an ID which
-	// can be used to interact with this stream in the future.
-	Create(ctx context.Context, tenant roachpb.TenantName) (streampb.ReplicationProducerSpec, error)
+	// can be used to interact with this stream in the future. The request
+	// identifies the consumer tenant if the stream reverses a previous
+	// replication, and is otherwise empty.
+	Create(
+		ctx context.Context, tenant roachpb.TenantName, req streampb.ReplicationProducerRequest,
+	) (streampb.ReplicationProducerSpec, error)
 
 	// Dial checks if the source is able to be connected to for queries
 	Dial(ctx context.Context) error
@@ -86,6 +90,18 @@ type Client interface {
 	Complete(ctx context.Context, streamID streampb.StreamID, successfulIngestion bool) error
 }
 
+// LogicalReplicationClient is a Client that can also replicate tables, rather
+// than tenants, for logical replication.
+type LogicalReplicationClient interface {
+	Client
+
+	// CreateForTables initializes a stream of the tables of the request with the
+	// source. The returned spec includes the descriptors of the tables.
+	CreateForTables(
+		ctx context.Context, req *streampb.ReplicationProducerRequest,
+	) (streampb.ReplicationProducerSpec, error)
+}
+
 // Topology is a configuration of stream partitions. These are particular to a
 // stream. It specifies the number and addresses of partitions of the stream.
 //
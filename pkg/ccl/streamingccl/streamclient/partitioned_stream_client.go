@@ -15,6 +15,7 @@ import (
 	"encoding/pem"
 	"net"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl"
@@ -25,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 	"github.com/jackc/pgx/v4"
 )
@@ -77,10 +79,11 @@ func NewPartitionedStreamClient(
 }
 
 var _ Client = &partitionedStreamClient{}
+var _ LogicalReplicationClient = &partitionedStreamClient{}
 
 // Create implements Client interface.
 func (p *partitionedStreamClient) Create(
-	ctx context.Context, tenantName roachpb.TenantName,
+	ctx context.Context, tenantName roachpb.TenantName, req streampb.ReplicationProducerRequest,
 ) (streampb.ReplicationProducerSpec, error) {
 	ctx, sp := tracing.ChildSpan(ctx, "streamclient.Client.Create")
 	defer sp.Finish()
@@ -88,7 +91,16 @@ func (p *partitionedStreamClient) Create(
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	var rawReplicationProducerSpec []byte
-	row := p.mu.srcConn.QueryRow(ctx, `SELECT crdb_internal.start_replication_stream($1)`, tenantName)
+	var row pgx.Row
+	if req.ClusterID.Equal(uuid.Nil) {
+		row = p.mu.srcConn.QueryRow(ctx, `SELECT crdb_internal.start_replication_stream($1)`, tenantName)
+	} else {
+		reqBytes, err := protoutil.Marshal(&req)
+		if err != nil {
+			return streampb.ReplicationProducerSpec{}, err
+		}
+		row = p.mu.srcConn.QueryRow(ctx, `SELECT crdb_internal.start_replication_stream($1, $2)`, tenantName, reqBytes)
+	}
 	err := row.Scan(&rawReplicationProducerSpec)
 	if err != nil {
 		return streampb.ReplicationProducerSpec{}, errors.Wrapf(err, "error creating replication stream for tenant %s", tenantName)
@@ -101,6 +113,33 @@ func (p *partitionedStreamClient) Create(
 	return replicationProducerSpec, err
 }
 
+// CreateForTables implements the LogicalReplicationClient interface.
+func (p *partitionedStreamClient) CreateForTables(
+	ctx context.Context, req *streampb.ReplicationProducerRequest,
+) (streampb.ReplicationProducerSpec, error) {
+	ctx, sp := tracing.ChildSpan(ctx, "streamclient.Client.CreateForTables")
+	defer sp.Finish()
+
+	reqBytes, err := protoutil.Marshal(req)
+	if err != nil {
+		return streampb.ReplicationProducerSpec{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var rawReplicationProducerSpec []byte
+	row := p.mu.srcConn.QueryRow(ctx, `SELECT crdb_internal.start_replication_stream_for_tables($1)`, reqBytes)
+	if err := row.Scan(&rawReplicationProducerSpec); err != nil {
+		return streampb.ReplicationProducerSpec{}, errors.Wrapf(err,
+			"error creating replication stream for tables %s", strings.Join(req.TableNames, ", "))
+	}
+	var replicationProducerSpec streampb.ReplicationProducerSpec
+	if err := protoutil.Unmarshal(rawReplicationProducerSpec, &replicationProducerSpec); err != nil {
+		return streampb.ReplicationProducerSpec{}, err
+	}
+	return replicationProducerSpec, nil
+}
+
 // Dial implements Client interface.
 func (p *partitionedStreamClient) Dial(ctx context.Context) error {
 	p.mu.Lock()
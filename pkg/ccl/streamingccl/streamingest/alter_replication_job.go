@@ -14,11 +14,15 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl"
 	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl/replicationutils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl/streamclient"
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/multitenant/mtinfopb"
+	"github.com/cockroachdb/cockroach/pkg/repstream/streampb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
@@ -82,11 +86,19 @@ func alterReplicationJobTypeCheck(
 	if !ok {
 		return false, nil, nil
 	}
-	if err := exprutil.TypeCheck(
-		ctx, alterReplicationJobOp, p.SemaCtx(),
+	toTypeCheck := []exprutil.ToTypeCheck{
 		exprutil.TenantSpec{TenantSpec: alterStmt.TenantSpec},
-		exprutil.Strings{alterStmt.Options.Retention},
-	); err != nil {
+		exprutil.Strings{
+			alterStmt.Options.Retention,
+			alterStmt.ReplicationSourceAddress,
+		},
+	}
+	if alterStmt.ReplicationSourceTenantName != nil {
+		toTypeCheck = append(toTypeCheck,
+			exprutil.TenantSpec{TenantSpec: alterStmt.ReplicationSourceTenantName},
+		)
+	}
+	if err := exprutil.TypeCheck(ctx, alterReplicationJobOp, p.SemaCtx(), toTypeCheck...); err != nil {
 		return false, nil, err
 	}
 
@@ -152,6 +164,18 @@ func alterReplicationJobHook(
 		return nil, nil, nil, false, err
 	}
 
+	var srcAddr, srcTenant string
+	if alterTenantStmt.ReplicationSourceAddress != nil {
+		srcAddr, err = exprEval.String(ctx, alterTenantStmt.ReplicationSourceAddress)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		_, _, srcTenant, err = exprEval.TenantSpec(ctx, alterTenantStmt.ReplicationSourceTenantName)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+
 	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
 		if err := utilccl.CheckEnterpriseEnabled(
 			p.ExecCfg().Settings, p.ExecCfg().NodeInfo.LogicalClusterID(),
@@ -168,6 +192,9 @@ func alterReplicationJobHook(
 		if err != nil {
 			return err
 		}
+		if alterTenantStmt.ReplicationSourceAddress != nil {
+			return alterTenantStartReplication(ctx, p, alterTenantStmt, tenInfo, srcAddr, srcTenant, options)
+		}
 		if tenInfo.TenantReplicationJobID == 0 {
 			return errors.Newf("tenant %q (%d) does not have an active replication job",
 				tenInfo.Name, tenInfo.ID)
@@ -272,6 +299,115 @@ func alterTenantJobCutover(
 	return cutoverTime, nil
 }
 
+// alterTenantStartReplication starts a replication job into the tenant from
+// the source tenant, which must be the tenant that this tenant was previously
+// replicated into, such as after a failover. Since both tenants had the same
+// data as of the time that replication cut over, the new job reverts this
+// tenant to that time and then ingests the changes since, rather than starting
+// with an initial scan of the source tenant.
+func alterTenantStartReplication(
+	ctx context.Context,
+	p sql.PlanHookState,
+	alterTenantStmt *tree.AlterTenantReplication,
+	tenInfo *mtinfopb.TenantInfo,
+	srcAddr string,
+	srcTenant string,
+	options *resolvedTenantReplicationOptions,
+) error {
+	if tenInfo.TenantReplicationJobID != 0 {
+		return errors.Newf("tenant %q (%d) already has an active replication job",
+			tenInfo.Name, tenInfo.ID)
+	}
+	if tenInfo.ServiceMode != mtinfopb.ServiceModeNone {
+		return errors.WithHint(
+			errors.Newf("tenant %q (%d) must be stopped before replicating into it", tenInfo.Name, tenInfo.ID),
+			"Run ALTER VIRTUAL CLUSTER ... STOP SERVICE first.")
+	}
+	if roachpb.IsSystemTenantName(roachpb.TenantName(srcTenant)) {
+		return errors.Newf("the source tenant %q cannot be the system tenant", srcTenant)
+	}
+	tenantID, err := roachpb.MakeTenantID(tenInfo.ID)
+	if err != nil {
+		return err
+	}
+
+	streamAddress := streamingccl.StreamAddress(srcAddr)
+	streamURL, err := streamAddress.URL()
+	if err != nil {
+		return err
+	}
+	streamAddress = streamingccl.StreamAddress(streamURL.String())
+
+	client, err := streamclient.NewStreamClient(ctx, streamAddress, p.ExecCfg().InternalDB)
+	if err != nil {
+		return err
+	}
+	// The producer checks that the source tenant was replicated from this
+	// tenant and starts the stream at the time that replication cut over.
+	replicationProducerSpec, err := client.Create(ctx, roachpb.TenantName(srcTenant),
+		streampb.ReplicationProducerRequest{
+			ClusterID: p.ExecCfg().NodeInfo.LogicalClusterID(),
+			TenantID:  tenantID,
+		})
+	if err != nil {
+		return err
+	}
+	if err := client.Close(ctx); err != nil {
+		return err
+	}
+
+	retentionTTLSeconds := defaultRetentionTTLSeconds
+	if ret, ok := options.GetRetention(); ok {
+		retentionTTLSeconds = ret
+	}
+	prefix := keys.MakeTenantPrefix(tenantID)
+	streamIngestionDetails := jobspb.StreamIngestionDetails{
+		StreamAddress:         string(streamAddress),
+		StreamID:              uint64(replicationProducerSpec.StreamID),
+		Span:                  roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()},
+		DestinationTenantID:   tenantID,
+		SourceTenantName:      roachpb.TenantName(srcTenant),
+		DestinationTenantName: tenInfo.Name,
+		ReplicationTTLSeconds: retentionTTLSeconds,
+		ReplicationStartTime:  replicationProducerSpec.ReplicationStartTime,
+		SourceClusterID:       replicationProducerSpec.SourceClusterID,
+		SourceTenantID:        replicationProducerSpec.SourceTenantID,
+	}
+
+	redactedSourceAddr, err := redactSourceURI(srcAddr)
+	if err != nil {
+		return err
+	}
+	redactedStmt := &tree.AlterTenantReplication{
+		TenantSpec:                  alterTenantStmt.TenantSpec,
+		Options:                     alterTenantStmt.Options,
+		ReplicationSourceTenantName: alterTenantStmt.ReplicationSourceTenantName,
+		ReplicationSourceAddress:    tree.NewDString(redactedSourceAddr),
+	}
+
+	jobID := p.ExecCfg().JobRegistry.MakeJobID()
+	jr := jobs.Record{
+		Description: tree.AsStringWithFQNames(redactedStmt, p.ExtendedEvalContext().Annotations),
+		Username:    p.User(),
+		// The job starts out as if it had already replicated up to the start
+		// time, so that it does not run an initial scan.
+		Progress: jobspb.StreamIngestionProgress{
+			ReplicatedTime:        replicationProducerSpec.ReplicationStartTime,
+			InitialRevertRequired: true,
+		},
+		Details: streamIngestionDetails,
+	}
+	if _, err := p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(
+		ctx, jr, jobID, p.InternalSQLTxn(),
+	); err != nil {
+		return err
+	}
+
+	tenInfo.TenantReplicationJobID = jobID
+	tenInfo.DataState = mtinfopb.DataStateAdd
+	return sql.UpdateTenantRecord(ctx, p.ExecCfg().Settings, p.InternalSQLTxn(), tenInfo)
+}
+
 func alterTenantOptions(
 	ctx context.Context,
 	txn isql.Txn,
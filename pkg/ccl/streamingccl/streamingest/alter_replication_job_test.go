@@ -11,18 +11,25 @@ package streamingest
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl/replicationtestutils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl/replicationutils"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/multitenant/mtinfopb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -80,6 +87,81 @@ func TestAlterTenantCompleteToLatest(t *testing.T) {
 	jobutils.WaitForJobToSucceed(c.T, c.DestSysSQL, jobspb.JobID(ingestionJobID))
 }
 
+// TestAlterTenantStartReplicationFromPreviousDestination checks that after a
+// cutover the replication can be reversed into the former source tenant, which
+// is rewound to the cutover time rather than being replaced by an initial scan.
+func TestAlterTenantStartReplicationFromPreviousDestination(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	args := replicationtestutils.DefaultTenantStreamingClustersArgs
+
+	c, cleanup := replicationtestutils.CreateTenantStreamingClusters(ctx, t, args)
+	defer cleanup()
+	producerJobID, ingestionJobID := c.StartStreamReplication(ctx)
+
+	jobutils.WaitForJobToRun(t, c.SrcSysSQL, jobspb.JobID(producerJobID))
+	jobutils.WaitForJobToRun(t, c.DestSysSQL, jobspb.JobID(ingestionJobID))
+	c.WaitUntilStartTimeReached(jobspb.JobID(ingestionJobID))
+	c.Cutover(producerJobID, ingestionJobID, time.Time{}, false)
+
+	// Write to both tenants after the cutover. The writes to the former source
+	// tenant must be undone when the replication is reversed into it.
+	c.SrcTenantSQL.Exec(t, `CREATE TABLE lost (x INT)`)
+	cleanupDestTenant := c.StartDestTenant(ctx)
+	c.DestTenantSQL.Exec(t, `CREATE TABLE kept (x INT)`)
+	c.DestTenantSQL.Exec(t, `INSERT INTO kept VALUES (1)`)
+	require.NoError(t, cleanupDestTenant())
+
+	c.SrcSysSQL.Exec(t, `ALTER TENANT $1 STOP SERVICE`, args.SrcTenantName)
+	c.SrcSysSQL.Exec(t, `SET CLUSTER SETTING cross_cluster_replication.enabled = true`)
+	destURL, cleanupURL := sqlutils.PGUrl(t, c.DestSysServer.AdvSQLAddr(), t.Name(), url.User(username.RootUser))
+	defer cleanupURL()
+
+	// Only the tenant that the destination tenant was replicated from can be
+	// replicated into.
+	c.SrcSysSQL.Exec(t, `CREATE TENANT other`)
+	c.SrcSysSQL.ExpectErr(t, "was not replicated from tenant",
+		`ALTER TENANT other START REPLICATION OF $1 ON $2`, args.DestTenantName, destURL.String())
+
+	c.SrcSysSQL.Exec(t, `ALTER TENANT $1 START REPLICATION OF $2 ON $3`,
+		args.SrcTenantName, args.DestTenantName, destURL.String())
+	_, reverseJobID := replicationtestutils.GetStreamJobIds(t, ctx, c.SrcSysSQL, args.SrcTenantName)
+	jobutils.WaitForJobToRun(t, c.SrcSysSQL, jobspb.JobID(reverseJobID))
+
+	srcDB := c.SrcSysServer.InternalDB().(isql.DB)
+	testutils.SucceedsSoon(t, func() error {
+		progress, err := replicationutils.LoadIngestionProgress(ctx, srcDB, jobspb.JobID(reverseJobID))
+		if err != nil {
+			return err
+		}
+		if progress.InitialRevertRequired {
+			return errors.New("waiting for the initial revert")
+		}
+		return nil
+	})
+	target := c.DestSysServer.Clock().Now()
+	replicationtestutils.WaitUntilReplicatedTime(t, target, c.SrcSysSQL, jobspb.JobID(reverseJobID))
+
+	expected := replicationtestutils.FingerprintTenantAtTimestampNoHistory(t, c.DestSysSQL,
+		args.DestTenantID.ToUint64(), target.AsOfSystemTime())
+	actual := replicationtestutils.FingerprintTenantAtTimestampNoHistory(t, c.SrcSysSQL,
+		args.SrcTenantID.ToUint64(), target.AsOfSystemTime())
+	require.Equal(t, expected, actual)
+
+	c.SrcSysSQL.Exec(t, `ALTER TENANT $1 COMPLETE REPLICATION TO LATEST`, args.SrcTenantName)
+	jobutils.WaitForJobToSucceed(t, c.SrcSysSQL, jobspb.JobID(reverseJobID))
+
+	var infoBytes []byte
+	var info mtinfopb.ProtoInfo
+	c.SrcSysSQL.QueryRow(t, `SELECT info FROM system.tenants WHERE name = $1`,
+		args.SrcTenantName).Scan(&infoBytes)
+	require.NoError(t, protoutil.Unmarshal(infoBytes, &info))
+	require.NotNil(t, info.PreviousSourceTenant)
+	require.Equal(t, args.DestTenantID, info.PreviousSourceTenant.TenantID)
+}
+
 func TestAlterTenantPauseResume(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -194,8 +194,22 @@ func completeIngestion(
 	ctx context.Context, execCtx sql.JobExecContext, ingestionJob *jobs.Job,
 ) error {
 	details := ingestionJob.Details().(jobspb.StreamIngestionDetails)
+	progress, err := replicationutils.LoadIngestionProgress(ctx, execCtx.ExecCfg().InternalDB, ingestionJob.ID())
+	if err != nil {
+		return err
+	}
+	// Jobs created before the source tenant was recorded in the job details
+	// cannot be reversed without an initial scan.
+	var prevSource *mtinfopb.PreviousSourceTenant
+	if !details.SourceClusterID.Equal(uuid.Nil) {
+		prevSource = &mtinfopb.PreviousSourceTenant{
+			ClusterID:        details.SourceClusterID,
+			TenantID:         details.SourceTenantID,
+			CutoverTimestamp: progress.CutoverTime,
+		}
+	}
 	log.Infof(ctx, "activating destination tenant %d", details.DestinationTenantID)
-	if err := activateTenant(ctx, execCtx, details.DestinationTenantID); err != nil {
+	if err := activateTenant(ctx, execCtx, details.DestinationTenantID, prevSource); err != nil {
 		return err
 	}
 
@@ -265,6 +279,9 @@ func ingest(ctx context.Context, execCtx sql.JobExecContext, ingestionJob *jobs.
 			return err
 		}
 	}
+	if err := maybeRevertToReplicationStartTime(ctx, execCtx, ingestionJob); err != nil {
+		return err
+	}
 	// A nil error is only possible if the job was signaled to cutover and the
 	// processors shut down gracefully, i.e stopped ingesting any additional
 	// events from the replication stream. At this point it is safe to revert to
@@ -557,7 +574,63 @@ func maybeRevertToCutoverTimestamp(
 	return true, nil
 }
 
-func activateTenant(ctx context.Context, execCtx interface{}, newTenantID roachpb.TenantID) error {
+// maybeRevertToReplicationStartTime reverts the destination tenant to the
+// replication start time if the job ingests into a tenant with existing data,
+// which is the case when the job reverses a previous replication out of the
+// destination tenant. The stream only contains the changes since the
+// replication start time, so any later writes to the destination tenant must
+// be undone before it is ingested.
+func maybeRevertToReplicationStartTime(
+	ctx context.Context, p sql.JobExecContext, ingestionJob *jobs.Job,
+) error {
+	progress, err := replicationutils.LoadIngestionProgress(ctx, p.ExecCfg().InternalDB, ingestionJob.ID())
+	if err != nil {
+		return err
+	}
+	if !progress.InitialRevertRequired {
+		return nil
+	}
+
+	ctx, span := tracing.ChildSpan(ctx, "streamingest.revertToReplicationStartTime")
+	defer span.Finish()
+
+	details := ingestionJob.Details().(jobspb.StreamIngestionDetails)
+	log.Infof(ctx, "reverting destination tenant %s to replication start time %s",
+		details.DestinationTenantID, details.ReplicationStartTime)
+	updateRunningStatus(ctx, ingestionJob, jobspb.InitializingReplication,
+		fmt.Sprintf("reverting the destination tenant to %s", details.ReplicationStartTime))
+
+	batchSize := int64(sql.RevertTableDefaultBatchSize)
+	if p.ExecCfg().StreamingTestingKnobs != nil && p.ExecCfg().StreamingTestingKnobs.OverrideRevertRangeBatchSize != 0 {
+		batchSize = p.ExecCfg().StreamingTestingKnobs.OverrideRevertRangeBatchSize
+	}
+	if err := sql.RevertSpansFanout(ctx,
+		p.ExecCfg().DB,
+		p,
+		roachpb.Spans{details.Span},
+		details.ReplicationStartTime,
+		false, /* ignoreGCThreshold */
+		batchSize,
+		nil, /* onCompletedCallback */
+	); err != nil {
+		return err
+	}
+
+	return ingestionJob.NoTxn().Update(ctx, func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+		md.Progress.GetStreamIngest().InitialRevertRequired = false
+		ju.UpdateProgress(md.Progress)
+		return nil
+	})
+}
+
+// activateTenant makes the destination tenant ready for service. prevSource,
+// if set, records the tenant that was replicated into it.
+func activateTenant(
+	ctx context.Context,
+	execCtx interface{},
+	newTenantID roachpb.TenantID,
+	prevSource *mtinfopb.PreviousSourceTenant,
+) error {
 	p := execCtx.(sql.JobExecContext)
 	execCfg := p.ExecCfg()
 	return execCfg.InternalDB.Txn(ctx, func(
@@ -570,6 +643,7 @@ func activateTenant(ctx context.Context, execCtx interface{}, newTenantID roachp
 
 		info.DataState = mtinfopb.DataStateReady
 		info.TenantReplicationJobID = 0
+		info.PreviousSourceTenant = prevSource
 		return sql.UpdateTenantRecord(ctx, p.ExecCfg().Settings, txn, info)
 	})
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/multitenant/mtinfopb"
+	"github.com/cockroachdb/cockroach/pkg/repstream/streampb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
@@ -219,7 +220,8 @@ func ingestionPlanHook(
 		// Create the producer job first for the purpose of observability, user is
 		// able to know the producer job id immediately after executing
 		// CREATE VIRTUAL CLUSTER ... FROM REPLICATION.
-		replicationProducerSpec, err := client.Create(ctx, roachpb.TenantName(sourceTenant))
+		replicationProducerSpec, err := client.Create(
+			ctx, roachpb.TenantName(sourceTenant), streampb.ReplicationProducerRequest{})
 		if err != nil {
 			return err
 		}
@@ -237,6 +239,8 @@ func ingestionPlanHook(
 			DestinationTenantName: roachpb.TenantName(dstTenantName),
 			ReplicationTTLSeconds: retentionTTLSeconds,
 			ReplicationStartTime:  replicationProducerSpec.ReplicationStartTime,
+			SourceClusterID:       replicationProducerSpec.SourceClusterID,
+			SourceTenantID:        replicationProducerSpec.SourceTenantID,
 		}
 
 		jobDescription, err := streamIngestionJobDescription(p, from, ingestionStmt)
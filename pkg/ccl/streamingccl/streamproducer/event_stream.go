@@ -232,6 +232,14 @@ func (s *eventStream) Close(ctx context.Context) {
 }
 
 func (s *eventStream) onValue(ctx context.Context, value *kvpb.RangeFeedValue) {
+	// Skip values that were themselves replicated from another cluster, if
+	// requested. Note that values emitted by the initial scan carry no origin
+	// ID, so they are never skipped.
+	if s.spec.WithFiltering && value.OriginID != 0 {
+		log.VInfof(ctx, 1, "onValue: skipping %s@%s with origin %d",
+			value.Key, value.Value.Timestamp, value.OriginID)
+		return
+	}
 	select {
 	case <-ctx.Done():
 	case s.eventsCh <- kvcoord.RangeFeedMessage{RangeFeedEvent: &kvpb.RangeFeedEvent{Val: value}}:
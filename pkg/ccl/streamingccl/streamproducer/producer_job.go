@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/streamingccl"
@@ -56,6 +57,32 @@ func makeProducerJobRecord(
 	}
 }
 
+// makeProducerJobRecordForTables makes the record of a producer job that
+// replicates the given spans of tables of the tenant.
+func makeProducerJobRecordForTables(
+	registry *jobs.Registry,
+	tenantID roachpb.TenantID,
+	tableNames []string,
+	spans []*roachpb.Span,
+	timeout time.Duration,
+	user username.SQLUsername,
+	ptsID uuid.UUID,
+) jobs.Record {
+	return jobs.Record{
+		JobID:       registry.MakeJobID(),
+		Description: fmt.Sprintf("stream replication for tables %s", strings.Join(tableNames, ", ")),
+		Username:    user,
+		Details: jobspb.StreamReplicationDetails{
+			ProtectedTimestampRecordID: ptsID,
+			Spans:                      spans,
+			TenantID:                   tenantID,
+		},
+		Progress: jobspb.StreamReplicationProgress{
+			Expiration: timeutil.Now().Add(timeout),
+		},
+	}
+}
+
 type producerJobResumer struct {
 	job *jobs.Job
 
@@ -16,13 +16,19 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprotectedts"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts/ptpb"
 	"github.com/cockroachdb/cockroach/pkg/repstream/streampb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -37,7 +43,11 @@ import (
 // 1. Tracks the liveness of the replication stream consumption.
 // 2. Updates the protected timestamp for spans being replicated.
 func startReplicationProducerJob(
-	ctx context.Context, evalCtx *eval.Context, txn isql.Txn, tenantName roachpb.TenantName,
+	ctx context.Context,
+	evalCtx *eval.Context,
+	txn isql.Txn,
+	tenantName roachpb.TenantName,
+	req streampb.ReplicationProducerRequest,
 ) (streampb.ReplicationProducerSpec, error) {
 	execConfig := evalCtx.Planner.ExecutorConfig().(*sql.ExecutorConfig)
 
@@ -51,6 +61,29 @@ func startReplicationProducerJob(
 	}
 	tenantID := tenantRecord.ID
 
+	startTime := hlc.Timestamp{
+		WallTime: evalCtx.GetStmtTimestamp().UnixNano(),
+	}
+	// If the consumer identifies its tenant, the stream reverses a replication
+	// from that tenant into this one. The consumer rewinds its tenant to the
+	// time that replication cut over, so the stream starts at that time rather
+	// than with an initial scan.
+	if !req.ClusterID.Equal(uuid.Nil) {
+		prev := tenantRecord.PreviousSourceTenant
+		if prev == nil || !prev.ClusterID.Equal(req.ClusterID) || prev.TenantID != req.TenantID {
+			return streampb.ReplicationProducerSpec{}, errors.Newf(
+				"tenant %q was not replicated from tenant %s of cluster %s",
+				tenantName, req.TenantID, req.ClusterID)
+		}
+		startTime = prev.CutoverTimestamp
+		// The stream can only start at the cutover timestamp if the history of
+		// the tenant since then is still around, which the protected timestamp
+		// laid below can't bring back.
+		if err := checkTenantAboveGCThreshold(ctx, execConfig.DB, tenantID, startTime); err != nil {
+			return streampb.ReplicationProducerSpec{}, err
+		}
+	}
+
 	registry := execConfig.JobRegistry
 	timeout := streamingccl.StreamReplicationJobLivenessTimeout.Get(&evalCtx.Settings.SV)
 	ptsID := uuid.MakeV4()
@@ -61,20 +94,122 @@ func startReplicationProducerJob(
 	}
 
 	ptp := execConfig.ProtectedTimestampProvider.WithTxn(txn)
-	statementTime := hlc.Timestamp{
-		WallTime: evalCtx.GetStmtTimestamp().UnixNano(),
-	}
 	deprecatedSpansToProtect := roachpb.Spans{*makeTenantSpan(tenantID)}
 	targetToProtect := ptpb.MakeTenantsTarget([]roachpb.TenantID{roachpb.MustMakeTenantID(tenantID)})
-	pts := jobsprotectedts.MakeRecord(ptsID, int64(jr.JobID), statementTime,
+	pts := jobsprotectedts.MakeRecord(ptsID, int64(jr.JobID), startTime,
 		deprecatedSpansToProtect, jobsprotectedts.Jobs, targetToProtect)
 
 	if err := ptp.Protect(ctx, pts); err != nil {
 		return streampb.ReplicationProducerSpec{}, err
 	}
+	return streampb.ReplicationProducerSpec{
+		StreamID:             streampb.StreamID(jr.JobID),
+		ReplicationStartTime: startTime,
+		SourceClusterID:      execConfig.NodeInfo.LogicalClusterID(),
+		SourceTenantID:       roachpb.MustMakeTenantID(tenantID),
+	}, nil
+}
+
+// checkTenantAboveGCThreshold returns an error if the data of the tenant was
+// garbage collected above the given timestamp on any of its ranges.
+func checkTenantAboveGCThreshold(
+	ctx context.Context, db *kv.DB, tenantID uint64, ts hlc.Timestamp,
+) error {
+	// A QueryResolvedTimestamp request is evaluated on every range of the span
+	// without reading any data, and it's rejected by the ranges whose GC
+	// threshold is above its timestamp.
+	span := makeTenantSpan(tenantID)
+	b := &kv.Batch{}
+	b.Header.Timestamp = ts
+	b.AddRawRequest(&kvpb.QueryResolvedTimestampRequest{
+		RequestHeader: kvpb.RequestHeader{Key: span.Key, EndKey: span.EndKey},
+	})
+	if err := db.Run(ctx, b); err != nil {
+		if errors.HasType(err, (*kvpb.BatchTimestampBeforeGCError)(nil)) {
+			return errors.Wrapf(err, "cannot replicate tenant %d from the cutover timestamp %s "+
+				"since its data was garbage collected", tenantID, ts)
+		}
+		return err
+	}
+	return nil
+}
+
+// startReplicationProducerJobForTables initializes a replication stream
+// producer job on the source cluster for the tables of the request, rather than
+// for a tenant. The returned spec includes the descriptors of the tables as of
+// the replication start time, which consumers need to decode their rows.
+func startReplicationProducerJobForTables(
+	ctx context.Context,
+	evalCtx *eval.Context,
+	txn isql.Txn,
+	req streampb.ReplicationProducerRequest,
+) (streampb.ReplicationProducerSpec, error) {
+	execConfig := evalCtx.Planner.ExecutorConfig().(*sql.ExecutorConfig)
+
+	if !kvserver.RangefeedEnabled.Get(&evalCtx.Settings.SV) {
+		return streampb.ReplicationProducerSpec{}, errors.Errorf("kv.rangefeed.enabled must be true to start a replication job")
+	}
+	if len(req.TableNames) == 0 {
+		return streampb.ReplicationProducerSpec{}, errors.New("no tables to replicate")
+	}
+	dTxn, ok := txn.(descs.Txn)
+	if !ok {
+		return streampb.ReplicationProducerSpec{}, errors.AssertionFailedf("unexpected txn type %T", txn)
+	}
+
+	var tableDescs []descpb.TableDescriptor
+	var spans []*roachpb.Span
+	var ids descpb.IDs
+	for _, name := range req.TableNames {
+		tn, err := parser.ParseQualifiedTableName(name)
+		if err != nil {
+			return streampb.ReplicationProducerSpec{}, err
+		}
+		id, err := evalCtx.Planner.ResolveTableName(ctx, tn)
+		if err != nil {
+			return streampb.ReplicationProducerSpec{}, err
+		}
+		td, err := dTxn.Descriptors().ByIDWithLeased(txn.KV()).WithoutNonPublic().Get().Table(ctx, descpb.ID(id))
+		if err != nil {
+			return streampb.ReplicationProducerSpec{}, err
+		}
+		tableDescs = append(tableDescs, *td.TableDesc())
+		sp := td.PrimaryIndexSpan(execConfig.Codec)
+		spans = append(spans, &sp)
+		ids = append(ids, td.GetID())
+	}
+
+	_, tenantID, err := keys.DecodeTenantPrefix(execConfig.Codec.TenantPrefix())
+	if err != nil {
+		return streampb.ReplicationProducerSpec{}, err
+	}
+	registry := execConfig.JobRegistry
+	timeout := streamingccl.StreamReplicationJobLivenessTimeout.Get(&evalCtx.Settings.SV)
+	ptsID := uuid.MakeV4()
+
+	jr := makeProducerJobRecordForTables(registry, tenantID, req.TableNames, spans, timeout,
+		evalCtx.SessionData().User(), ptsID)
+	if _, err := registry.CreateAdoptableJobWithTxn(ctx, jr, jr.JobID, txn); err != nil {
+		return streampb.ReplicationProducerSpec{}, err
+	}
+
+	ptp := execConfig.ProtectedTimestampProvider.WithTxn(txn)
+	statementTime := hlc.Timestamp{
+		WallTime: evalCtx.GetStmtTimestamp().UnixNano(),
+	}
+	deprecatedSpansToProtect := make(roachpb.Spans, 0, len(spans))
+	for _, sp := range spans {
+		deprecatedSpansToProtect = append(deprecatedSpansToProtect, *sp)
+	}
+	pts := jobsprotectedts.MakeRecord(ptsID, int64(jr.JobID), statementTime,
+		deprecatedSpansToProtect, jobsprotectedts.Jobs, ptpb.MakeSchemaObjectsTarget(ids))
+	if err := ptp.Protect(ctx, pts); err != nil {
+		return streampb.ReplicationProducerSpec{}, err
+	}
 	return streampb.ReplicationProducerSpec{
 		StreamID:             streampb.StreamID(jr.JobID),
 		ReplicationStartTime: statementTime,
+		TableDescriptors:     tableDescs,
 	}, nil
 }
 
@@ -1043,6 +1043,35 @@ initialized node is not supported.
 `, docs.URL("use-cloud-storage-for-bulk-operations")),
 	}
 
+	ColdStorage = FlagInfo{
+		Name: "cold-storage",
+		Description: fmt.Sprintf(`
+External storage URL (eg. s3://, gcs://, or nodelocal:// for a local filesystem
+directory) to use as the cold storage tier of all stores on this cockroach node.
+Ranges whose zone configuration sets storage_tier = 'cold', or which have not
+been written to for longer than their storage_tier_cold_after_seconds, are moved
+to this storage. Reads of moved data are served from it transparently. The
+format of this URL is the same as that specified for bulk operations, for more
+on that see:
+
+<PRE>
+%s
+</PRE>
+`, docs.URL("use-cloud-storage-for-bulk-operations")),
+	}
+
+	ColdStorageCacheSize = FlagInfo{
+		Name: "cold-storage-cache-size",
+		Description: `
+Size of each store's on-disk cache of data read from the cold storage tier.
+Only used together with --cold-storage.
+<PRE>
+
+  --cold-storage-cache-size=10GiB
+
+</PRE>`,
+	}
+
 	Size = FlagInfo{
 		Name:      "size",
 		Shorthand: "z",
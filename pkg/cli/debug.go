@@ -1341,6 +1341,7 @@ var debugCmds = []*cobra.Command{
 	debugResetQuorumCmd,
 	debugSendKVBatchCmd,
 	debugRecoverCmd,
+	debugAllocatorReplayCmd,
 }
 
 // DebugCmd is the root of all debug commands. Exported to allow modification by CCL code.
@@ -1432,6 +1433,7 @@ func init() {
 	DebugCmd.AddCommand(debugStatementBundleCmd)
 
 	DebugCmd.AddCommand(debugJobTraceFromClusterCmd)
+	DebugCmd.AddCommand(debugChangefeedCmd)
 
 	f = debugSyncBenchCmd.Flags()
 	f.IntVarP(&syncBenchOpts.Concurrency, "concurrency", "c", syncBenchOpts.Concurrency,
@@ -1445,6 +1447,16 @@ func init() {
 	f.IntVarP(&debugCompactOpts.maxConcurrency, "max-concurrency", "c", debugCompactOpts.maxConcurrency,
 		"maximum number of concurrent compactions")
 
+	f = debugAllocatorReplayCmd.Flags()
+	f.DurationVar(&debugAllocatorReplayOpts.duration, "duration", debugAllocatorReplayOpts.duration,
+		"simulated duration of each replay")
+	f.StringSliceVar(&debugAllocatorReplayOpts.objectives, "objectives", debugAllocatorReplayOpts.objectives,
+		"rebalance objectives to replay the capture with (qps, cpu)")
+	f.Float64Var(&debugAllocatorReplayOpts.threshold, "threshold", debugAllocatorReplayOpts.threshold,
+		"maximum over mean of a stat across stores, at or below which the stat is considered balanced")
+	f.StringVar(&debugAllocatorReplayOpts.saveCapture, "save-capture", "",
+		"file to save the capture to, which may be replayed later")
+
 	f = debugRecoverCollectInfoCmd.Flags()
 	f.VarP(&debugRecoverCollectInfoOpts.Stores, cliflags.RecoverStore.Name, cliflags.RecoverStore.Shorthand, cliflags.RecoverStore.Usage())
 
@@ -1522,6 +1534,17 @@ func init() {
 	f.Var(&debugTimeSeriesDumpOpts.from, "from", "oldest timestamp to include (inclusive)")
 	f.Var(&debugTimeSeriesDumpOpts.to, "to", "newest timestamp to include (inclusive)")
 
+	f = debugChangefeedCmd.Flags()
+	f.StringVar(&debugChangefeedContext.cursor, "cursor", debugChangefeedContext.cursor,
+		"timestamp after which changes are replayed, in any format accepted by the changefeed cursor option")
+	f.StringVar(&debugChangefeedContext.endTime, "end-time", debugChangefeedContext.endTime,
+		"timestamp up to which changes are replayed; defaults to the current time")
+	f.StringSliceVar(&debugChangefeedContext.options, "with", debugChangefeedContext.options,
+		"additional changefeed options, as key or key=value")
+	f.StringSliceVar(&debugChangefeedContext.backups, "backup", debugChangefeedContext.backups,
+		"URI of a backup taken with revision_history to read the changes from instead of the cluster; "+
+			"repeat for each backup of the chain, starting with the full backup")
+
 	f = debugSendKVBatchCmd.Flags()
 	f.StringVar(&debugSendKVBatchContext.traceFormat, "trace", debugSendKVBatchContext.traceFormat,
 		"which format to use for the trace output (off, text, jaeger)")
@@ -0,0 +1,140 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cli/clierrorplus"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/gen"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/replay"
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/cobra"
+)
+
+var debugAllocatorReplayOpts = struct {
+	duration    time.Duration
+	objectives  []string
+	threshold   float64
+	saveCapture string
+}{
+	duration:   30 * time.Minute,
+	objectives: []string{"qps", "cpu"},
+	threshold:  1.1,
+}
+
+var debugAllocatorReplayCmd = &cobra.Command{
+	Use:   "allocator-replay [<debug zip dir>|<capture file>]",
+	Short: "replay a cluster's ranges and load in the allocator simulator",
+	Long: `
+Captures the range layout, localities, span configs and per-range load of a
+cluster and replays it in the allocator simulator, once for each rebalance
+objective. Prints how the balance of each objective converged, along with the
+number of lease transfers and replica rebalances that it took.
+
+The cluster is captured from the unzipped debug directory of a debug zip
+collected with --include-range-info, or by connecting to a running cluster
+when no argument is given. Span configs are only captured from debug zips.
+
+The capture may be saved with --save-capture and later passed as the argument
+instead, e.g. to replay the same load against different versions of the
+allocator. The replay is deterministic.
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: clierrorplus.MaybeDecorateError(runDebugAllocatorReplay),
+}
+
+func runDebugAllocatorReplay(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	objectives := make([]kvserver.LBRebalancingObjective, len(debugAllocatorReplayOpts.objectives))
+	for i, name := range debugAllocatorReplayOpts.objectives {
+		found := false
+		for obj, objName := range kvserver.LoadBasedRebalancingObjectiveMap {
+			if name == objName {
+				objectives[i], found = kvserver.LBRebalancingObjective(obj), true
+			}
+		}
+		if !found {
+			return errors.Newf("unknown rebalance objective %q", name)
+		}
+	}
+
+	c, err := captureForAllocatorReplay(ctx, args)
+	if err != nil {
+		return err
+	}
+	if path := debugAllocatorReplayOpts.saveCapture; path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := errors.CombineErrors(c.Write(f), f.Close()); err != nil {
+			return errors.Wrapf(err, "saving capture to %s", path)
+		}
+	}
+	fmt.Printf("captured %d nodes, %d stores and %d ranges\n",
+		len(c.Nodes), c.StoreCount(), len(c.Ranges))
+
+	for _, obj := range objectives {
+		settings := config.DefaultSimulationSettings()
+		settings.LBRebalancingObjective = int64(obj)
+		sim := gen.GenerateSimulation(
+			debugAllocatorReplayOpts.duration,
+			replay.Cluster{Capture: c},
+			replay.Ranges{Capture: c},
+			replay.Workload{Capture: c},
+			gen.StaticSettings{Settings: settings},
+			gen.StaticEvents{},
+			settings.Seed,
+		)
+		sim.RunSim(ctx)
+		conv := replay.ComputeConvergence(
+			sim.History(), debugAllocatorReplayOpts.threshold, "qps", "cpu", "replicas", "leases")
+		fmt.Printf("\nobjective: %s\n%s", obj, conv)
+	}
+	return nil
+}
+
+// captureForAllocatorReplay returns a capture of the cluster from the debug zip
+// directory or capture file in args, or from the running cluster when args is
+// empty.
+func captureForAllocatorReplay(ctx context.Context, args []string) (*replay.Capture, error) {
+	if len(args) == 0 {
+		status, finish, err := getStatusClient(ctx, serverCfg)
+		if err != nil {
+			return nil, err
+		}
+		defer finish()
+		return replay.FromStatus(ctx, status)
+	}
+
+	path := args[0]
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return replay.FromDebugZip(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return replay.Read(f)
+}
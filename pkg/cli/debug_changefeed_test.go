@@ -0,0 +1,80 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeDebugChangefeedStmt(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		targets string
+		options []string
+		exp     string
+		expErr  string
+	}{
+		{
+			targets: `users, orders FAMILY f`,
+			exp: `EXPERIMENTAL CHANGEFEED FOR TABLE users, TABLE orders FAMILY f ` +
+				`WITH cursor = '10', end_time = '20'`,
+		},
+		{
+			targets: `users`,
+			options: []string{`diff`, `envelope = row`},
+			exp:     `EXPERIMENTAL CHANGEFEED FOR TABLE users WITH cursor = '10', end_time = '20', diff, envelope = 'row'`,
+		},
+		{
+			targets: `  select id FROM orders WHERE status = 'failed'`,
+			exp: `CREATE CHANGEFEED WITH cursor = '10', end_time = '20' ` +
+				`AS SELECT id FROM orders WHERE status = 'failed'`,
+		},
+		{
+			targets: `users INTO 'kafka://nope'`,
+			expErr:  `invalid changefeed targets`,
+		},
+		{
+			targets: `users WITH resolved`,
+			expErr:  `invalid changefeed targets`,
+		},
+		{
+			targets: `users; DROP TABLE users`,
+			expErr:  `invalid changefeed targets`,
+		},
+		{
+			targets: `users`,
+			options: []string{`cursor=5`},
+			expErr:  `option cursor is set by --cursor and --end-time`,
+		},
+	} {
+		t.Run(tc.targets, func(t *testing.T) {
+			stmt, err := makeDebugChangefeedStmt(tc.targets, `10`, `20`, tc.options)
+			if tc.expErr != "" {
+				require.Regexp(t, tc.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, tree.AsString(stmt))
+		})
+	}
+
+	// The end time is left to the backups when replaying from them.
+	stmt, err := makeDebugChangefeedStmt(`users`, `10`, ``, nil)
+	require.NoError(t, err)
+	require.Equal(t, `EXPERIMENTAL CHANGEFEED FOR TABLE users WITH cursor = '10'`, tree.AsString(stmt))
+}
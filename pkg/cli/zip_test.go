@@ -77,6 +77,7 @@ table_name NOT IN (
 	'databases',
 	'forward_dependencies',
 	'gossip_network',
+	'hot_keys',
 	'index_columns',
   'index_spans',
   'kv_builtin_function_comments',
@@ -90,6 +91,7 @@ table_name NOT IN (
 	'kv_flow_control_handles',
 	'kv_flow_controller',
 	'kv_flow_token_deductions',
+	'kv_store_reencryption',
 	'lost_descriptors_with_data',
 	'table_columns',
 	'table_row_statistics',
@@ -111,6 +113,7 @@ table_name NOT IN (
 	'transaction_statistics_persisted_v22_2',
 	'transaction_statistics',
 	'tenant_usage_details',
+	'verify_backup_results',
   'pg_catalog_table_is_implemented'
 )
 ORDER BY name ASC`)
@@ -557,6 +557,20 @@ const (
 	// FormatVirtualSSTables, allowing use of virtual sstables in Pebble.
 	V23_2_PebbleFormatVirtualSSTables
 
+	// V23_2_IncrementalConsistencyChecks is the version where all nodes support
+	// the CHECK_INCREMENTAL consistency check mode.
+	V23_2_IncrementalConsistencyChecks
+
+	// V23_2_ColumnarBlockCache is the version where tables can be configured with
+	// the columnar_block_cache storage parameter, which enables the in-memory
+	// columnar block cache of their ranges.
+	V23_2_ColumnarBlockCache
+
+	// V23_2_LogicalReplicationOriginHeaders is the version where writes can
+	// record the origin ID and origin timestamp of logical replication in the
+	// headers of their values.
+	V23_2_LogicalReplicationOriginHeaders
+
 	// *************************************************
 	// Step (1) Add new versions here.
 	// Do not add new versions to a patch release.
@@ -970,6 +984,18 @@ var rawVersionsSingleton = keyedVersions{
 		Key:     V23_2_PebbleFormatVirtualSSTables,
 		Version: roachpb.Version{Major: 23, Minor: 1, Internal: 16},
 	},
+	{
+		Key:     V23_2_IncrementalConsistencyChecks,
+		Version: roachpb.Version{Major: 23, Minor: 1, Internal: 18},
+	},
+	{
+		Key:     V23_2_ColumnarBlockCache,
+		Version: roachpb.Version{Major: 23, Minor: 1, Internal: 20},
+	},
+	{
+		Key:     V23_2_LogicalReplicationOriginHeaders,
+		Version: roachpb.Version{Major: 23, Minor: 1, Internal: 22},
+	},
 
 	// *************************************************
 	// Step (2): Add new versions here.
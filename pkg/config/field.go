@@ -25,16 +25,21 @@ var _ redact.SafeValue = Field(0)
 //go:generate stringer --type=Field --linecomment
 
 const (
-	_                Field = iota
-	RangeMinBytes          // range_min_bytes
-	RangeMaxBytes          // range_max_bytes
-	GlobalReads            // global_reads
-	NumReplicas            // num_replicas
-	NumVoters              // num_voters
-	GCTTL                  // gc.ttlseconds
-	Constraints            // constraints
-	VoterConstraints       // voter_constraints
-	LeasePreferences       // lease_preferences
+	_                  Field = iota
+	RangeMinBytes            // range_min_bytes
+	RangeMaxBytes            // range_max_bytes
+	GlobalReads              // global_reads
+	NumReplicas              // num_replicas
+	NumVoters                // num_voters
+	GCTTL                    // gc.ttlseconds
+	Constraints              // constraints
+	VoterConstraints         // voter_constraints
+	LeasePreferences         // lease_preferences
+	NumWitnesses             // num_witnesses
+	WitnessConstraints       // witness_constraints
+	StorageTTLSeconds        // storage_ttl_seconds
+	StorageTier              // storage_tier
+	ColdAfterSeconds         // storage_tier_cold_after_seconds
 
 	// NumFields is the number of fields in the config.
 	NumFields int = iota - 1
@@ -17,6 +17,11 @@ func _() {
 	_ = x[Constraints-7]
 	_ = x[VoterConstraints-8]
 	_ = x[LeasePreferences-9]
+	_ = x[NumWitnesses-10]
+	_ = x[WitnessConstraints-11]
+	_ = x[StorageTTLSeconds-12]
+	_ = x[StorageTier-13]
+	_ = x[ColdAfterSeconds-14]
 }
 
 func (i Field) String() string {
@@ -39,6 +44,16 @@ func (i Field) String() string {
 		return "voter_constraints"
 	case LeasePreferences:
 		return "lease_preferences"
+	case NumWitnesses:
+		return "num_witnesses"
+	case WitnessConstraints:
+		return "witness_constraints"
+	case StorageTTLSeconds:
+		return "storage_ttl_seconds"
+	case StorageTier:
+		return "storage_tier"
+	case ColdAfterSeconds:
+		return "storage_tier_cold_after_seconds"
 	default:
 		return "Field(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
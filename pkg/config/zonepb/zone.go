@@ -496,6 +496,39 @@ func (z *ZoneConfig) Validate() error {
 		return err
 	}
 
+	if z.NumWitnesses != nil && *z.NumWitnesses < 0 {
+		return fmt.Errorf("num_witnesses cannot be negative")
+	}
+	if z.StorageTTLSeconds != nil && *z.StorageTTLSeconds < 0 {
+		return fmt.Errorf("storage_ttl_seconds cannot be negative")
+	}
+	if z.StorageTier != nil {
+		if _, err := storageTierFromString(*z.StorageTier); err != nil {
+			return err
+		}
+	}
+	if z.StorageTierColdAfterSeconds != nil && *z.StorageTierColdAfterSeconds < 0 {
+		return fmt.Errorf("storage_tier_cold_after_seconds cannot be negative")
+	}
+	var numConstrainedWitnesses int64
+	for _, constraints := range z.WitnessConstraints {
+		numConstrainedWitnesses += int64(constraints.NumReplicas)
+		for _, constraint := range constraints.Constraints {
+			if constraint.Type == Constraint_DEPRECATED_POSITIVE {
+				return fmt.Errorf("witness_constraints must either be required (prefixed with a '+') " +
+					"or prohibited (prefixed with a '-')")
+			}
+		}
+	}
+	if len(z.WitnessConstraints) > 0 && (z.NumWitnesses == nil || *z.NumWitnesses == 0) {
+		return fmt.Errorf("witness_constraints cannot be set without num_witnesses")
+	}
+	if z.NumWitnesses != nil && numConstrainedWitnesses > int64(*z.NumWitnesses) {
+		return fmt.Errorf("the number of replicas specified in witness_constraints (%d) cannot be "+
+			"greater than the number of witnesses configured for the zone (%d)",
+			numConstrainedWitnesses, *z.NumWitnesses)
+	}
+
 	for _, leasePref := range z.LeasePreferences {
 		if len(leasePref.Constraints) == 0 {
 			return fmt.Errorf("every lease preference must include at least one constraint")
@@ -586,6 +619,21 @@ func (z *ZoneConfig) InheritFromParent(parent *ZoneConfig) {
 		z.LeasePreferences = parent.LeasePreferences
 		z.InheritedLeasePreferences = false
 	}
+	// The witness constraints only make sense together with the number of
+	// witnesses, so they are inherited together.
+	if z.NumWitnesses == nil && parent.NumWitnesses != nil {
+		z.NumWitnesses = proto.Int32(*parent.NumWitnesses)
+		z.WitnessConstraints = parent.WitnessConstraints
+	}
+	if z.StorageTTLSeconds == nil && parent.StorageTTLSeconds != nil {
+		z.StorageTTLSeconds = proto.Int32(*parent.StorageTTLSeconds)
+	}
+	if z.StorageTier == nil && parent.StorageTier != nil {
+		z.StorageTier = proto.String(*parent.StorageTier)
+	}
+	if z.StorageTierColdAfterSeconds == nil && parent.StorageTierColdAfterSeconds != nil {
+		z.StorageTierColdAfterSeconds = proto.Int32(*parent.StorageTierColdAfterSeconds)
+	}
 }
 
 // CopyFromZone copies over the specified fields from the other zone.
@@ -632,6 +680,28 @@ func (z *ZoneConfig) CopyFromZone(other ZoneConfig, fieldList []tree.Name) {
 		case "lease_preferences":
 			z.LeasePreferences = other.LeasePreferences
 			z.InheritedLeasePreferences = other.InheritedLeasePreferences
+		case "num_witnesses":
+			z.NumWitnesses = nil
+			if other.NumWitnesses != nil {
+				z.NumWitnesses = proto.Int32(*other.NumWitnesses)
+			}
+		case "witness_constraints":
+			z.WitnessConstraints = other.WitnessConstraints
+		case "storage_ttl_seconds":
+			z.StorageTTLSeconds = nil
+			if other.StorageTTLSeconds != nil {
+				z.StorageTTLSeconds = proto.Int32(*other.StorageTTLSeconds)
+			}
+		case "storage_tier":
+			z.StorageTier = nil
+			if other.StorageTier != nil {
+				z.StorageTier = proto.String(*other.StorageTier)
+			}
+		case "storage_tier_cold_after_seconds":
+			z.StorageTierColdAfterSeconds = nil
+			if other.StorageTierColdAfterSeconds != nil {
+				z.StorageTierColdAfterSeconds = proto.Int32(*other.StorageTierColdAfterSeconds)
+			}
 		}
 	}
 }
@@ -790,6 +860,59 @@ func (z *ZoneConfig) DiffWithZone(
 					}
 				}
 			}
+		case "num_witnesses":
+			if other.NumWitnesses == nil && z.NumWitnesses == nil {
+				continue
+			}
+			if z.NumWitnesses == nil || other.NumWitnesses == nil ||
+				*z.NumWitnesses != *other.NumWitnesses {
+				return false, DiffWithZoneMismatch{
+					Field: "num_witnesses",
+				}, nil
+			}
+		case "witness_constraints":
+			if len(z.WitnessConstraints) != len(other.WitnessConstraints) {
+				return false, DiffWithZoneMismatch{
+					Field: "witness_constraints",
+				}, nil
+			}
+			for i := range z.WitnessConstraints {
+				if !z.WitnessConstraints[i].Equal(&other.WitnessConstraints[i]) {
+					return false, DiffWithZoneMismatch{
+						Field: "witness_constraints",
+					}, nil
+				}
+			}
+		case "storage_ttl_seconds":
+			if other.StorageTTLSeconds == nil && z.StorageTTLSeconds == nil {
+				continue
+			}
+			if z.StorageTTLSeconds == nil || other.StorageTTLSeconds == nil ||
+				*z.StorageTTLSeconds != *other.StorageTTLSeconds {
+				return false, DiffWithZoneMismatch{
+					Field: "storage_ttl_seconds",
+				}, nil
+			}
+		case "storage_tier":
+			if other.StorageTier == nil && z.StorageTier == nil {
+				continue
+			}
+			if z.StorageTier == nil || other.StorageTier == nil ||
+				*z.StorageTier != *other.StorageTier {
+				return false, DiffWithZoneMismatch{
+					Field: "storage_tier",
+				}, nil
+			}
+		case "storage_tier_cold_after_seconds":
+			if other.StorageTierColdAfterSeconds == nil && z.StorageTierColdAfterSeconds == nil {
+				continue
+			}
+			if z.StorageTierColdAfterSeconds == nil || other.StorageTierColdAfterSeconds == nil ||
+				*z.StorageTierColdAfterSeconds != *other.StorageTierColdAfterSeconds {
+				return false, DiffWithZoneMismatch{
+					Field: "storage_tier_cold_after_seconds",
+				}, nil
+			}
 		default:
 			return false, DiffWithZoneMismatch{}, errors.AssertionFailedf("unknown zone configuration field %q", fieldName)
 		}
@@ -1176,6 +1299,28 @@ func (z *ZoneConfig) toSpanConfig() (roachpb.SpanConfig, error) {
 		}
 	}
 
+	if z.NumWitnesses != nil {
+		sc.NumWitnesses = *z.NumWitnesses
+	}
+	if len(z.WitnessConstraints) != 0 {
+		sc.WitnessConstraints, err = toSpanConfigConstraintsConjunction(z.WitnessConstraints)
+		if err != nil {
+			return roachpb.SpanConfig{}, err
+		}
+	}
+	if z.StorageTTLSeconds != nil {
+		sc.StorageTTLSeconds = *z.StorageTTLSeconds
+	}
+	if z.StorageTier != nil {
+		sc.StorageTier, err = storageTierFromString(*z.StorageTier)
+		if err != nil {
+			return roachpb.SpanConfig{}, err
+		}
+	}
+	if z.StorageTierColdAfterSeconds != nil {
+		sc.StorageTierColdAfterSeconds = *z.StorageTierColdAfterSeconds
+	}
+
 	if len(z.LeasePreferences) != 0 {
 		sc.LeasePreferences = make([]roachpb.LeasePreference, len(z.LeasePreferences))
 		for i, leasePreference := range z.LeasePreferences {
@@ -1188,6 +1333,19 @@ func (z *ZoneConfig) toSpanConfig() (roachpb.SpanConfig, error) {
 	return sc, nil
 }
 
+// storageTierFromString parses the value of the storage_tier zone config
+// field.
+func storageTierFromString(tier string) (roachpb.StorageTier, error) {
+	switch tier {
+	case "hot":
+		return roachpb.HOT, nil
+	case "cold":
+		return roachpb.COLD, nil
+	default:
+		return 0, fmt.Errorf("storage_tier must be either 'hot' or 'cold', found %q", tier)
+	}
+}
+
 func init() {
 	if len(NamedZonesList) != len(NamedZones) {
 		panic(fmt.Errorf(
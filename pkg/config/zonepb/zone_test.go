@@ -85,6 +85,33 @@ func TestZoneConfigValidate(t *testing.T) {
 			},
 			"",
 		},
+		{
+			ZoneConfig{
+				NumReplicas:       proto.Int32(1),
+				RangeMaxBytes:     DefaultZoneConfig().RangeMaxBytes,
+				GC:                &GCPolicy{TTLSeconds: 1},
+				StorageTTLSeconds: proto.Int32(-1),
+			},
+			"storage_ttl_seconds cannot be negative",
+		},
+		{
+			ZoneConfig{
+				NumReplicas:   proto.Int32(1),
+				RangeMaxBytes: DefaultZoneConfig().RangeMaxBytes,
+				GC:            &GCPolicy{TTLSeconds: 1},
+				StorageTier:   proto.String("lukewarm"),
+			},
+			`storage_tier must be either 'hot' or 'cold', found "lukewarm"`,
+		},
+		{
+			ZoneConfig{
+				NumReplicas:                 proto.Int32(1),
+				RangeMaxBytes:               DefaultZoneConfig().RangeMaxBytes,
+				GC:                          &GCPolicy{TTLSeconds: 1},
+				StorageTierColdAfterSeconds: proto.Int32(-1),
+			},
+			"storage_tier_cold_after_seconds cannot be negative",
+		},
 		{
 			ZoneConfig{
 				NumReplicas:   proto.Int32(1),
@@ -208,6 +208,11 @@ type marshalableZoneConfig struct {
 	Constraints                  ConstraintsList   `json:"constraints" yaml:"constraints,flow"`
 	VoterConstraints             ConstraintsList   `json:"voter_constraints" yaml:"voter_constraints,flow"`
 	LeasePreferences             []LeasePreference `json:"lease_preferences" yaml:"lease_preferences,flow"`
+	NumWitnesses                 *int32            `json:"num_witnesses" yaml:"num_witnesses,omitempty"`
+	WitnessConstraints           ConstraintsList   `json:"witness_constraints" yaml:"witness_constraints,flow,omitempty"`
+	StorageTTLSeconds            *int32            `json:"storage_ttl_seconds" yaml:"storage_ttl_seconds,omitempty"`
+	StorageTier                  *string           `json:"storage_tier" yaml:"storage_tier,omitempty"`
+	StorageTierColdAfterSeconds  *int32            `json:"storage_tier_cold_after_seconds" yaml:"storage_tier_cold_after_seconds,omitempty"`
 	ExperimentalLeasePreferences []LeasePreference `json:"experimental_lease_preferences" yaml:"experimental_lease_preferences,flow,omitempty"`
 	Subzones                     []Subzone         `json:"subzones" yaml:"-"`
 	SubzoneSpans                 []SubzoneSpan     `json:"subzone_spans" yaml:"-"`
@@ -243,6 +248,19 @@ func zoneConfigToMarshalable(c ZoneConfig) marshalableZoneConfig {
 	if !c.InheritedLeasePreferences {
 		m.LeasePreferences = c.LeasePreferences
 	}
+	if c.NumWitnesses != nil {
+		m.NumWitnesses = proto.Int32(*c.NumWitnesses)
+	}
+	m.WitnessConstraints = ConstraintsList{Constraints: c.WitnessConstraints}
+	if c.StorageTTLSeconds != nil {
+		m.StorageTTLSeconds = proto.Int32(*c.StorageTTLSeconds)
+	}
+	if c.StorageTier != nil {
+		m.StorageTier = proto.String(*c.StorageTier)
+	}
+	if c.StorageTierColdAfterSeconds != nil {
+		m.StorageTierColdAfterSeconds = proto.Int32(*c.StorageTierColdAfterSeconds)
+	}
 	// We intentionally do not round-trip ExperimentalLeasePreferences. We never
 	// want to return yaml containing it.
 	m.Subzones = c.Subzones
@@ -280,6 +298,19 @@ func zoneConfigFromMarshalable(m marshalableZoneConfig, c ZoneConfig) ZoneConfig
 	if m.LeasePreferences != nil {
 		c.LeasePreferences = m.LeasePreferences
 	}
+	if m.NumWitnesses != nil {
+		c.NumWitnesses = proto.Int32(*m.NumWitnesses)
+	}
+	c.WitnessConstraints = m.WitnessConstraints.Constraints
+	if m.StorageTTLSeconds != nil {
+		c.StorageTTLSeconds = proto.Int32(*m.StorageTTLSeconds)
+	}
+	if m.StorageTier != nil {
+		c.StorageTier = proto.String(*m.StorageTier)
+	}
+	if m.StorageTierColdAfterSeconds != nil {
+		c.StorageTierColdAfterSeconds = proto.Int32(*m.StorageTierColdAfterSeconds)
+	}
 
 	// Prefer a provided m.ExperimentalLeasePreferences value over whatever is in
 	// m.LeasePreferences, since we know that m.ExperimentalLeasePreferences can
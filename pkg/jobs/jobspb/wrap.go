@@ -52,6 +52,9 @@ var (
 	_ Details = AutoConfigEnvRunnerDetails{}
 	_ Details = AutoConfigTaskDetails{}
 	_ Details = AutoUpdateSQLActivityDetails{}
+	_ Details = LogicalReplicationDetails{}
+	_ Details = VerifyBackupDetails{}
+	_ Details = SubscriptionDetails{}
 )
 
 // ProgressDetails is a marker interface for job progress details proto structs.
@@ -76,6 +79,9 @@ var (
 	_ ProgressDetails = AutoConfigEnvRunnerProgress{}
 	_ ProgressDetails = AutoConfigTaskProgress{}
 	_ ProgressDetails = AutoUpdateSQLActivityProgress{}
+	_ ProgressDetails = LogicalReplicationProgress{}
+	_ ProgressDetails = VerifyBackupProgress{}
+	_ ProgressDetails = SubscriptionProgress{}
 )
 
 // Type returns the payload's job type and panics if the type is invalid.
@@ -147,6 +153,11 @@ const ForecastStatsName = "__forecast__"
 // table statistic.
 const MergedStatsName = "__merged__"
 
+// VerifyBackupResultInfoKeyPrefix is the prefix of the job_info keys under
+// which a VERIFY BACKUP job records the VerifyBackupResult of each virtual
+// cluster of the backup.
+const VerifyBackupResultInfoKeyPrefix = "verify-backup-result/"
+
 // AutomaticJobTypes is a list of automatic job types that currently exist.
 var AutomaticJobTypes = [...]Type{
 	TypeAutoCreateStats,
@@ -212,6 +223,12 @@ func DetailsType(d isPayload_Details) (Type, error) {
 		return TypeAutoConfigTask, nil
 	case *Payload_AutoUpdateSqlActivities:
 		return TypeAutoUpdateSQLActivity, nil
+	case *Payload_LogicalReplicationDetails:
+		return TypeLogicalReplication, nil
+	case *Payload_VerifyBackup:
+		return TypeVerifyBackup, nil
+	case *Payload_Subscription:
+		return TypeSubscription, nil
 	default:
 		return TypeUnspecified, errors.Newf("Payload.Type called on a payload with an unknown details type: %T", d)
 	}
@@ -256,6 +273,9 @@ var JobDetailsForEveryJobType = map[Type]Details{
 	TypeAutoConfigEnvRunner:          AutoConfigEnvRunnerDetails{},
 	TypeAutoConfigTask:               AutoConfigTaskDetails{},
 	TypeAutoUpdateSQLActivity:        AutoUpdateSQLActivityDetails{},
+	TypeLogicalReplication:           LogicalReplicationDetails{},
+	TypeVerifyBackup:                 VerifyBackupDetails{},
+	TypeSubscription:                 SubscriptionDetails{},
 }
 
 // WrapProgressDetails wraps a ProgressDetails object in the protobuf wrapper
@@ -311,6 +331,12 @@ func WrapProgressDetails(details ProgressDetails) interface {
 		return &Progress_AutoConfigTask{AutoConfigTask: &d}
 	case AutoUpdateSQLActivityProgress:
 		return &Progress_UpdateSqlActivity{UpdateSqlActivity: &d}
+	case LogicalReplicationProgress:
+		return &Progress_LogicalReplication{LogicalReplication: &d}
+	case VerifyBackupProgress:
+		return &Progress_VerifyBackup{VerifyBackup: &d}
+	case SubscriptionProgress:
+		return &Progress_Subscription{Subscription: &d}
 	default:
 		panic(errors.AssertionFailedf("WrapProgressDetails: unknown progress type %T", d))
 	}
@@ -364,6 +390,12 @@ func (p *Payload) UnwrapDetails() Details {
 		return *d.AutoConfigTask
 	case *Payload_AutoUpdateSqlActivities:
 		return *d.AutoUpdateSqlActivities
+	case *Payload_LogicalReplicationDetails:
+		return *d.LogicalReplicationDetails
+	case *Payload_VerifyBackup:
+		return *d.VerifyBackup
+	case *Payload_Subscription:
+		return *d.Subscription
 	default:
 		return nil
 	}
@@ -417,6 +449,12 @@ func (p *Progress) UnwrapDetails() ProgressDetails {
 		return *d.AutoConfigTask
 	case *Progress_UpdateSqlActivity:
 		return *d.UpdateSqlActivity
+	case *Progress_LogicalReplication:
+		return *d.LogicalReplication
+	case *Progress_VerifyBackup:
+		return *d.VerifyBackup
+	case *Progress_Subscription:
+		return *d.Subscription
 	default:
 		return nil
 	}
@@ -494,6 +532,12 @@ func WrapPayloadDetails(details Details) interface {
 		return &Payload_AutoConfigTask{AutoConfigTask: &d}
 	case AutoUpdateSQLActivityDetails:
 		return &Payload_AutoUpdateSqlActivities{AutoUpdateSqlActivities: &d}
+	case LogicalReplicationDetails:
+		return &Payload_LogicalReplicationDetails{LogicalReplicationDetails: &d}
+	case VerifyBackupDetails:
+		return &Payload_VerifyBackup{VerifyBackup: &d}
+	case SubscriptionDetails:
+		return &Payload_Subscription{Subscription: &d}
 	default:
 		panic(errors.AssertionFailedf("jobs.WrapPayloadDetails: unknown details type %T", d))
 	}
@@ -529,7 +573,7 @@ const (
 func (Type) SafeValue() {}
 
 // NumJobTypes is the number of jobs types.
-const NumJobTypes = 24
+const NumJobTypes = 27
 
 // ChangefeedDetailsMarshaler allows for dependency injection of
 // cloud.SanitizeExternalStorageURI to avoid the dependency from this
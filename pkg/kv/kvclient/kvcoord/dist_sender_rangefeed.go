@@ -92,6 +92,7 @@ type rangeFeedConfig struct {
 	useMuxRangeFeed bool
 	overSystemTable bool
 	withDiff        bool
+	valueFilter     *kvpb.RangeFeedValueFilter
 
 	knobs struct {
 		onMuxRangefeedEvent func(event *kvpb.MuxRangeFeedEvent)
@@ -129,6 +130,15 @@ func WithDiff() RangeFeedOption {
 	})
 }
 
+// WithValueFilter configures the rangefeed to filter and project its values on
+// the servers, which may ignore the filter, so the caller must still filter
+// the values it receives. See kvpb.RangeFeedValueFilter.
+func WithValueFilter(f *kvpb.RangeFeedValueFilter) RangeFeedOption {
+	return optionFunc(func(c *rangeFeedConfig) {
+		c.valueFilter = f
+	})
+}
+
 // A "kill switch" to disable multiplexing rangefeed if severe issues discovered with new implementation.
 var enableMuxRangeFeed = envutil.EnvOrDefaultBool("COCKROACH_ENABLE_MULTIPLEXING_RANGEFEED", true)
 
@@ -699,6 +709,7 @@ func (ds *DistSender) singleRangeFeed(
 	}()
 
 	args := makeRangeFeedRequest(span, desc.RangeID, cfg.overSystemTable, startAfter, cfg.withDiff)
+	args.ValueFilter = cfg.valueFilter
 	transport, err := newTransportForRange(ctx, desc, ds)
 	if err != nil {
 		return args.Timestamp, err
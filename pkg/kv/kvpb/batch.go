@@ -53,6 +53,25 @@ func (h Header) RequiredFrontier() hlc.Timestamp {
 	return h.Timestamp
 }
 
+// OriginID returns the origin ID that the writes of the batch should record in
+// the headers of their values, or zero if the writes were not replicated from
+// another cluster.
+func (h Header) OriginID() uint32 {
+	if h.WriteOptions == nil {
+		return 0
+	}
+	return h.WriteOptions.OriginID
+}
+
+// OriginTimestamp returns the origin timestamp of the writes of the batch, or
+// an empty timestamp if the writes were not replicated from another cluster.
+func (h Header) OriginTimestamp() hlc.Timestamp {
+	if h.WriteOptions == nil {
+		return hlc.Timestamp{}
+	}
+	return h.WriteOptions.OriginTimestamp
+}
+
 // ShallowCopy returns a shallow copy of the receiver.
 func (ba *BatchRequest) ShallowCopy() *BatchRequest {
 	shallowCopy := *ba
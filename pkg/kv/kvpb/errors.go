@@ -1094,6 +1094,10 @@ func (e *ConditionFailedError) Error() string {
 }
 
 func (e *ConditionFailedError) SafeFormatError(p errors.Printer) (next error) {
+	if !e.OriginTimestampOlderThan.IsEmpty() {
+		p.Printf("origin timestamp older than existing value at %s", e.OriginTimestampOlderThan)
+		return nil
+	}
 	p.Printf("unexpected value: %s", e.ActualValue)
 	return nil
 }
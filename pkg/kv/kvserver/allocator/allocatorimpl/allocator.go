@@ -140,11 +140,15 @@ const (
 	AllocatorConsiderRebalance
 	AllocatorRangeUnavailable
 	AllocatorFinalizeAtomicReplicationChange
+	AllocatorAddWitness
+	AllocatorRemoveWitness
+	AllocatorRemoveDeadWitness
+	AllocatorRemoveDecommissioningWitness
 )
 
 // Add indicates an action adding a replica.
 func (a AllocatorAction) Add() bool {
-	return a == AllocatorAddVoter || a == AllocatorAddNonVoter
+	return a == AllocatorAddVoter || a == AllocatorAddNonVoter || a == AllocatorAddWitness
 }
 
 // Replace indicates an action replacing a dead or decommissioning replica.
@@ -162,10 +166,14 @@ func (a AllocatorAction) Remove() bool {
 		a == AllocatorRemoveDeadVoter ||
 		a == AllocatorRemoveDeadNonVoter ||
 		a == AllocatorRemoveDecommissioningVoter ||
-		a == AllocatorRemoveDecommissioningNonVoter
+		a == AllocatorRemoveDecommissioningNonVoter ||
+		a == AllocatorRemoveWitness ||
+		a == AllocatorRemoveDeadWitness ||
+		a == AllocatorRemoveDecommissioningWitness
 }
 
-// TargetReplicaType returns that the action is for a voter or non-voter replica.
+// TargetReplicaType returns that the action is for a voter, non-voter or
+// witness replica.
 func (a AllocatorAction) TargetReplicaType() TargetReplicaType {
 	var t TargetReplicaType
 	if a == AllocatorRemoveVoter ||
@@ -182,6 +190,11 @@ func (a AllocatorAction) TargetReplicaType() TargetReplicaType {
 		a == AllocatorReplaceDecommissioningNonVoter ||
 		a == AllocatorRemoveDecommissioningNonVoter {
 		t = NonVoterTarget
+	} else if a == AllocatorAddWitness ||
+		a == AllocatorRemoveWitness ||
+		a == AllocatorRemoveDeadWitness ||
+		a == AllocatorRemoveDecommissioningWitness {
+		t = WitnessTarget
 	}
 	return t
 }
@@ -193,17 +206,21 @@ func (a AllocatorAction) ReplicaStatus() ReplicaStatus {
 	if a == AllocatorRemoveVoter ||
 		a == AllocatorRemoveNonVoter ||
 		a == AllocatorAddVoter ||
-		a == AllocatorAddNonVoter {
+		a == AllocatorAddNonVoter ||
+		a == AllocatorAddWitness ||
+		a == AllocatorRemoveWitness {
 		s = Alive
 	} else if a == AllocatorReplaceDeadVoter ||
 		a == AllocatorReplaceDeadNonVoter ||
 		a == AllocatorRemoveDeadVoter ||
-		a == AllocatorRemoveDeadNonVoter {
+		a == AllocatorRemoveDeadNonVoter ||
+		a == AllocatorRemoveDeadWitness {
 		s = Dead
 	} else if a == AllocatorReplaceDecommissioningVoter ||
 		a == AllocatorReplaceDecommissioningNonVoter ||
 		a == AllocatorRemoveDecommissioningVoter ||
-		a == AllocatorRemoveDecommissioningNonVoter {
+		a == AllocatorRemoveDecommissioningNonVoter ||
+		a == AllocatorRemoveDecommissioningWitness {
 		s = Decommissioning
 	}
 	return s
@@ -227,6 +244,10 @@ var allocatorActionNames = map[AllocatorAction]string{
 	AllocatorConsiderRebalance:               "consider rebalance",
 	AllocatorRangeUnavailable:                "range unavailable",
 	AllocatorFinalizeAtomicReplicationChange: "finalize conf change",
+	AllocatorAddWitness:                      "add witness",
+	AllocatorRemoveWitness:                   "remove witness",
+	AllocatorRemoveDeadWitness:               "remove dead witness",
+	AllocatorRemoveDecommissioningWitness:    "remove decommissioning witness",
 }
 
 func (a AllocatorAction) String() string {
@@ -259,6 +280,14 @@ func (a AllocatorAction) Priority() float64 {
 		return 900
 	case AllocatorRemoveVoter:
 		return 800
+	case AllocatorAddWitness:
+		return 790
+	case AllocatorRemoveDeadWitness:
+		return 780
+	case AllocatorRemoveDecommissioningWitness:
+		return 770
+	case AllocatorRemoveWitness:
+		return 760
 	case AllocatorReplaceDeadNonVoter:
 		return 700
 	case AllocatorAddNonVoter:
@@ -278,8 +307,8 @@ func (a AllocatorAction) Priority() float64 {
 	}
 }
 
-// TargetReplicaType indicates whether the target replica is a voter or
-// non-voter.
+// TargetReplicaType indicates whether the target replica is a voter, non-voter
+// or witness.
 type TargetReplicaType int
 
 const (
@@ -288,6 +317,8 @@ const (
 	VoterTarget
 	// NonVoterTarget represents a non-voting target replica.
 	NonVoterTarget
+	// WitnessTarget represents a witness target replica.
+	WitnessTarget
 )
 
 // ReplicaStatus represents whether a replica is currently alive,
@@ -315,6 +346,8 @@ func (t TargetReplicaType) AddChangeType() roachpb.ReplicaChangeType {
 		return roachpb.ADD_VOTER
 	case NonVoterTarget:
 		return roachpb.ADD_NON_VOTER
+	case WitnessTarget:
+		return roachpb.ADD_WITNESS
 	default:
 		panic(fmt.Sprintf("unknown targetReplicaType %d", t))
 	}
@@ -328,6 +361,8 @@ func (t TargetReplicaType) RemoveChangeType() roachpb.ReplicaChangeType {
 		return roachpb.REMOVE_VOTER
 	case NonVoterTarget:
 		return roachpb.REMOVE_NON_VOTER
+	case WitnessTarget:
+		return roachpb.REMOVE_WITNESS
 	default:
 		panic(fmt.Sprintf("unknown targetReplicaType %d", t))
 	}
@@ -339,6 +374,8 @@ func (t TargetReplicaType) String() string {
 		return "voter"
 	case NonVoterTarget:
 		return "non-voter"
+	case WitnessTarget:
+		return "witness"
 	default:
 		panic(fmt.Sprintf("unknown targetReplicaType %d", t))
 	}
@@ -373,6 +410,7 @@ const (
 type allocatorError struct {
 	constraints           []roachpb.ConstraintsConjunction
 	voterConstraints      []roachpb.ConstraintsConjunction
+	witnessConstraints    []roachpb.ConstraintsConjunction
 	existingVoterCount    int
 	existingNonVoterCount int
 	aliveStores           int
@@ -405,7 +443,8 @@ func (ae *allocatorError) Error() string {
 			ae.aliveStores, existingVoterStr, existingNonVoterStr)
 	}
 
-	if len(ae.constraints) == 0 && len(ae.voterConstraints) == 0 {
+	if len(ae.constraints) == 0 && len(ae.voterConstraints) == 0 &&
+		len(ae.witnessConstraints) == 0 {
 		if ae.throttledStores > 0 {
 			return baseMsg
 		}
@@ -436,6 +475,19 @@ func (ae *allocatorError) Error() string {
 	}
 	b.WriteString("]")
 
+	if len(ae.witnessConstraints) > 0 {
+		b.WriteString("; witnesses must match witness_constraints [")
+		for i := range ae.witnessConstraints {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteByte('{')
+			b.WriteString(ae.witnessConstraints[i].String())
+			b.WriteByte('}')
+		}
+		b.WriteString("]")
+	}
+
 	return b.String()
 }
 
@@ -685,6 +737,22 @@ func GetNeededNonVoters(numVoters, zoneConfigNonVoterCount, clusterNodes int) in
 	return need
 }
 
+// GetNeededWitnesses calculates the number of witnesses a range should have
+// given its zone config and the number of nodes available for up-replication
+// (i.e. live and not decommissioning).
+func GetNeededWitnesses(numVoters, zoneConfigWitnessCount, clusterNodes int) int {
+	need := zoneConfigWitnessCount
+	if clusterNodes-numVoters < need {
+		// Witnesses can only be placed on nodes that do not have a voting
+		// replica.
+		need = clusterNodes - numVoters
+	}
+	if need < 0 {
+		need = 0 // Must be non-negative.
+	}
+	return need
+}
+
 // WillHaveFragileQuorum determines, based on the number of existing voters,
 // incoming voters, and needed voters, if we will be upreplicating to a state
 // in which we don't have enough needed voters and yet will have a fragile quorum
@@ -925,7 +993,7 @@ func (a *Allocator) ComputeAction(
 	}
 
 	return a.computeAction(ctx, storePool, conf, desc.Replicas().VoterDescriptors(),
-		desc.Replicas().NonVoterDescriptors())
+		desc.Replicas().NonVoterDescriptors(), desc.Replicas().WitnessDescriptors())
 }
 
 func (a *Allocator) computeAction(
@@ -934,6 +1002,7 @@ func (a *Allocator) computeAction(
 	conf roachpb.SpanConfig,
 	voterReplicas []roachpb.ReplicaDescriptor,
 	nonVoterReplicas []roachpb.ReplicaDescriptor,
+	witnessReplicas []roachpb.ReplicaDescriptor,
 ) (action AllocatorAction, adjustedPriority float64) {
 	// NB: The ordering of the checks in this method is intentional. The order in
 	// which these actions are returned by this method determines the relative
@@ -942,8 +1011,9 @@ func (a *Allocator) computeAction(
 	// (which influence the replicateQueue's decision of which range it'll pick to
 	// repair/rebalance before the others).
 	//
-	// In broad strokes, we first handle all voting replica-based actions and then
-	// the actions pertaining to non-voting replicas. Within each replica set, we
+	// In broad strokes, we first handle all voting replica-based actions, then
+	// the actions pertaining to witnesses (which also vote), and then the
+	// actions pertaining to non-voting replicas. Within each replica set, we
 	// first handle operations that correspond to repairing/recovering the range.
 	// After that we handle rebalancing related actions, followed by removal
 	// actions.
@@ -955,7 +1025,8 @@ func (a *Allocator) computeAction(
 	clusterNodes := storePool.ClusterNodeCount()
 	neededVoters := GetNeededVoters(conf.GetNumVoters(), clusterNodes)
 	desiredQuorum := computeQuorum(neededVoters)
-	quorum := computeQuorum(haveVoters)
+	// Witnesses take part in the raft quorum alongside the voters.
+	quorum := computeQuorum(haveVoters + len(witnessReplicas))
 
 	// TODO(aayush): When haveVoters < neededVoters but we don't have quorum to
 	// actually execute the addition of a new replica, we should be returning a
@@ -979,15 +1050,19 @@ func (a *Allocator) computeAction(
 	// elsewhere (for a regular rebalance or for decommissioning).
 	const includeSuspectAndDrainingStores = true
 	liveVoters, deadVoters := storePool.LiveAndDeadReplicas(voterReplicas, includeSuspectAndDrainingStores)
+	liveWitnesses, deadWitnesses := storePool.LiveAndDeadReplicas(
+		witnessReplicas, includeSuspectAndDrainingStores,
+	)
 
-	if len(liveVoters) < quorum {
+	if len(liveVoters)+len(liveWitnesses) < quorum {
 		// Do not take any replacement/removal action if we do not have a quorum of
 		// live voters. If we're correctly assessing the unavailable state of the
 		// range, we also won't be able to add replicas as we try above, but hope
 		// springs eternal.
 		action = AllocatorRangeUnavailable
-		log.KvDistribution.VEventf(ctx, 1, "unable to take action - live voters %v don't meet quorum of %d",
-			liveVoters, quorum)
+		log.KvDistribution.VEventf(ctx, 1,
+			"unable to take action - live voters %v and witnesses %v don't meet quorum of %d",
+			liveVoters, liveWitnesses, quorum)
 		return action, action.Priority()
 	}
 
@@ -1018,7 +1093,7 @@ func (a *Allocator) computeAction(
 	if len(deadVoters) > 0 {
 		// The range has dead replicas, which should be removed immediately.
 		action = AllocatorRemoveDeadVoter
-		adjustedPriority = action.Priority() + float64(quorum-len(liveVoters))
+		adjustedPriority = action.Priority() + float64(quorum-len(liveVoters)-len(liveWitnesses))
 		log.KvDistribution.VEventf(ctx, 3, "%s - dead=%d, live=%d, quorum=%d, priority=%.2f",
 			action, len(deadVoters), len(liveVoters), quorum, adjustedPriority)
 		return action, adjustedPriority
@@ -1045,11 +1120,51 @@ func (a *Allocator) computeAction(
 		return action, adjustedPriority
 	}
 
+	// Witness actions follow.
+	//
+	// Witnesses hold no range data, so there is nothing to be gained from
+	// replacing a dead or decommissioning witness atomically. Instead, we remove
+	// it first and add a new one on a later pass, which also keeps every witness
+	// change a simple (non-joint) configuration change.
+	haveWitnesses := len(witnessReplicas)
+	neededWitnesses := GetNeededWitnesses(haveVoters, int(conf.NumWitnesses), clusterNodes)
+	if len(deadWitnesses) > 0 {
+		action = AllocatorRemoveDeadWitness
+		log.KvDistribution.VEventf(ctx, 3, "%s - dead=%d, live=%d, priority=%.2f",
+			action, len(deadWitnesses), len(liveWitnesses), action.Priority())
+		return action, action.Priority()
+	}
+
+	decommissioningWitnesses := storePool.DecommissioningReplicas(witnessReplicas)
+	if len(decommissioningWitnesses) > 0 {
+		action = AllocatorRemoveDecommissioningWitness
+		log.KvDistribution.VEventf(ctx, 3,
+			"%s - need=%d, have=%d, num_decommissioning=%d, priority=%.2f",
+			action, neededWitnesses, haveWitnesses, len(decommissioningWitnesses), action.Priority())
+		return action, action.Priority()
+	}
+
+	if haveWitnesses < neededWitnesses {
+		action = AllocatorAddWitness
+		log.KvDistribution.VEventf(ctx, 3, "%s - missing witness need=%d, have=%d, priority=%.2f",
+			action, neededWitnesses, haveWitnesses, action.Priority())
+		return action, action.Priority()
+	}
+
+	if haveWitnesses > neededWitnesses {
+		action = AllocatorRemoveWitness
+		log.KvDistribution.VEventf(ctx, 3, "%s - need=%d, have=%d, priority=%.2f", action,
+			neededWitnesses, haveWitnesses, action.Priority())
+		return action, action.Priority()
+	}
+
 	// Non-voting replica actions follow.
 	//
 	// Non-voting replica addition / replacement.
 	haveNonVoters := len(nonVoterReplicas)
-	neededNonVoters := GetNeededNonVoters(haveVoters, int(conf.GetNumNonVoters()), clusterNodes)
+	neededNonVoters := GetNeededNonVoters(
+		haveVoters+haveWitnesses, int(conf.GetNumNonVoters()), clusterNodes,
+	)
 	if haveNonVoters < neededNonVoters {
 		action = AllocatorAddNonVoter
 		log.KvDistribution.VEventf(ctx, 3, "%s - missing non-voter need=%d, have=%d, priority=%.2f",
@@ -1659,6 +1774,135 @@ func (a Allocator) RemoveNonVoter(
 	)
 }
 
+// AllocateWitness returns a suitable store for a new witness replica. Witnesses
+// hold no range data, so they are only subject to the range's
+// `witness_constraints` and are scored purely on diversity with respect to the
+// other members of the raft quorum (i.e. the voters and the existing
+// witnesses). Nodes already accommodating _any_ replica of the range are ruled
+// out as targets.
+func (a *Allocator) AllocateWitness(
+	ctx context.Context,
+	storePool storepool.AllocatorStorePool,
+	conf roachpb.SpanConfig,
+	existingVoters, existingNonVoters, existingWitnesses []roachpb.ReplicaDescriptor,
+) (roachpb.ReplicationTarget, string, error) {
+	candidateStoreList, aliveStoreCount, throttled := storePool.GetStoreList(storepool.StoreFilterThrottled)
+
+	analyzedWitnessConstraints := constraint.AnalyzeConstraints(
+		storePool,
+		existingWitnesses,
+		conf.NumWitnesses,
+		conf.WitnessConstraints,
+	)
+	// Diversity is computed against the members of the raft quorum, but nodes
+	// with any kind of replica are ruled out.
+	var quorumReplicas, existingReplicas []roachpb.ReplicaDescriptor
+	quorumReplicas = append(quorumReplicas, existingVoters...)
+	quorumReplicas = append(quorumReplicas, existingWitnesses...)
+	existingReplicas = append(existingReplicas, quorumReplicas...)
+	existingReplicas = append(existingReplicas, existingNonVoters...)
+
+	candidates := rankedCandidateListForAllocation(
+		ctx,
+		candidateStoreList,
+		witnessConstraintsCheckerForAllocation(analyzedWitnessConstraints),
+		existingReplicas,
+		existingNonVoters,
+		storePool.GetLocalitiesByStore(quorumReplicas),
+		storePool.IsStoreReadyForRoutineReplicaTransfer,
+		false, /* allowMultipleReplsPerNode */
+		a.ScorerOptions(ctx),
+		WitnessTarget,
+	)
+
+	log.KvDistribution.VEventf(ctx, 3, "allocate %s: %s", WitnessTarget, candidates)
+	if target := a.NewBestCandidateSelector().selectOne(candidates); target != nil {
+		log.KvDistribution.VEventf(ctx, 3, "add target: %s", target)
+		details := decisionDetails{Target: target.compactString()}
+		detailsBytes, err := json.Marshal(details)
+		if err != nil {
+			log.KvDistribution.Warningf(ctx, "failed to marshal details for choosing allocate target: %+v", err)
+		}
+		return roachpb.ReplicationTarget{
+			NodeID: target.store.Node.NodeID, StoreID: target.store.StoreID,
+		}, string(detailsBytes), nil
+	}
+
+	if len(throttled) > 0 {
+		return roachpb.ReplicationTarget{}, "", errors.Errorf(
+			"%d matching stores are currently throttled: %v", len(throttled), throttled,
+		)
+	}
+	return roachpb.ReplicationTarget{}, "", &allocatorError{
+		witnessConstraints:    conf.WitnessConstraints,
+		existingVoterCount:    len(existingVoters),
+		existingNonVoterCount: len(existingNonVoters),
+		aliveStores:           aliveStoreCount,
+		throttledStores:       len(throttled),
+	}
+}
+
+// RemoveWitness returns a suitable witness to remove from the provided set of
+// candidates, preferring witnesses whose removal keeps the range in
+// conformance with its `witness_constraints` and that contribute the least to
+// the diversity of the raft quorum.
+func (a Allocator) RemoveWitness(
+	ctx context.Context,
+	storePool storepool.AllocatorStorePool,
+	conf roachpb.SpanConfig,
+	witnessCandidates []roachpb.ReplicaDescriptor,
+	existingVoters []roachpb.ReplicaDescriptor,
+	existingWitnesses []roachpb.ReplicaDescriptor,
+	options ScorerOptions,
+) (roachpb.ReplicationTarget, string, error) {
+	candidateStoreIDs := make(roachpb.StoreIDSlice, len(witnessCandidates))
+	for i, exist := range witnessCandidates {
+		candidateStoreIDs[i] = exist.StoreID
+	}
+	candidateStoreList, _, _ := storePool.GetStoreListFromIDs(candidateStoreIDs, storepool.StoreFilterNone)
+	if len(candidateStoreList.Stores) == 0 {
+		return roachpb.ReplicationTarget{}, "", errors.Errorf(
+			"must supply at least one candidate replica to allocator.RemoveWitness()",
+		)
+	}
+
+	analyzedWitnessConstraints := constraint.AnalyzeConstraints(
+		storePool,
+		existingWitnesses,
+		conf.NumWitnesses,
+		conf.WitnessConstraints,
+	)
+	var quorumReplicas []roachpb.ReplicaDescriptor
+	quorumReplicas = append(quorumReplicas, existingVoters...)
+	quorumReplicas = append(quorumReplicas, existingWitnesses...)
+	rankedCandidates := candidateListForRemoval(
+		ctx,
+		candidateStoreList,
+		witnessConstraintsCheckerForRemoval(analyzedWitnessConstraints),
+		storePool.GetLocalitiesByStore(quorumReplicas),
+		options,
+	)
+
+	log.KvDistribution.VEventf(ctx, 3, "remove %s: %s", WitnessTarget, rankedCandidates)
+	if bad := rankedCandidates.selectWorst(a.randGen); bad != nil {
+		for _, exist := range existingWitnesses {
+			if exist.StoreID == bad.store.StoreID {
+				log.KvDistribution.VEventf(ctx, 3, "remove target: %s", bad)
+				details := decisionDetails{Target: bad.compactString()}
+				detailsBytes, err := json.Marshal(details)
+				if err != nil {
+					log.KvDistribution.Warningf(ctx, "failed to marshal details for choosing remove target: %+v", err)
+				}
+				return roachpb.ReplicationTarget{
+					StoreID: exist.StoreID, NodeID: exist.NodeID,
+				}, string(detailsBytes), nil
+			}
+		}
+	}
+
+	return roachpb.ReplicationTarget{}, "", errors.New("could not select an appropriate witness to be removed")
+}
+
 // RebalanceTarget returns a suitable store for a rebalance target (of the given
 // type) with required attributes.
 func (a Allocator) RebalanceTarget(
@@ -1906,6 +1906,31 @@ func nonVoterConstraintsCheckerForAllocation(
 	}
 }
 
+// witnessConstraintsCheckerForAllocation returns a constraintsCheckFn that
+// determines whether a candidate for a new witness is valid and/or necessary as
+// per the `witness_constraints` on the range.
+//
+// NB: Witnesses don't hold any range data, so neither the overall
+// `constraints` nor the `voter_constraints` apply to them.
+func witnessConstraintsCheckerForAllocation(
+	witnessConstraints constraint.AnalyzedConstraints,
+) constraintsCheckFn {
+	return func(s roachpb.StoreDescriptor) (valid, necessary bool) {
+		return allocateConstraintsCheck(s, witnessConstraints)
+	}
+}
+
+// witnessConstraintsCheckerForRemoval returns a constraintsCheckFn that
+// determines whether an existing witness is valid and/or necessary with
+// respect to the `witness_constraints` on the range.
+func witnessConstraintsCheckerForRemoval(
+	witnessConstraints constraint.AnalyzedConstraints,
+) constraintsCheckFn {
+	return func(s roachpb.StoreDescriptor) (valid, necessary bool) {
+		return removeConstraintsCheck(s, witnessConstraints)
+	}
+}
+
 // voterConstraintsCheckerForRemoval returns a constraintsCheckFn that
 // determines whether an existing voting replica is valid and/or necessary with
 // respect to the `constraints` and `voter_constraints` on the range.
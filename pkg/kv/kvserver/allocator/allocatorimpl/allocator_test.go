@@ -6740,6 +6740,93 @@ func TestAllocatorComputeActionRemoveDead(t *testing.T) {
 	}
 }
 
+func TestAllocatorComputeActionWitnesses(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	conf := roachpb.SpanConfig{NumReplicas: 2, NumWitnesses: 1}
+	twoVoterDesc := roachpb.RangeDescriptor{
+		InternalReplicas: []roachpb.ReplicaDescriptor{
+			{StoreID: 1, NodeID: 1, ReplicaID: 1},
+			{StoreID: 2, NodeID: 2, ReplicaID: 2},
+		},
+	}
+	oneWitnessDesc := twoVoterDesc
+	oneWitnessDesc.InternalReplicas = append(oneWitnessDesc.InternalReplicas[:2:2],
+		roachpb.ReplicaDescriptor{StoreID: 3, NodeID: 3, ReplicaID: 3, Type: roachpb.WITNESS})
+	twoWitnessDesc := oneWitnessDesc
+	twoWitnessDesc.InternalReplicas = append(twoWitnessDesc.InternalReplicas[:3:3],
+		roachpb.ReplicaDescriptor{StoreID: 4, NodeID: 4, ReplicaID: 4, Type: roachpb.WITNESS})
+
+	testCases := []struct {
+		desc            roachpb.RangeDescriptor
+		live            []roachpb.StoreID
+		dead            []roachpb.StoreID
+		decommissioning []roachpb.StoreID
+		expectedAction  AllocatorAction
+	}{
+		// Missing the witness.
+		{
+			desc:           twoVoterDesc,
+			live:           []roachpb.StoreID{1, 2, 3},
+			expectedAction: AllocatorAddWitness,
+		},
+		// Fully replicated.
+		{
+			desc:           oneWitnessDesc,
+			live:           []roachpb.StoreID{1, 2, 3, 4},
+			expectedAction: AllocatorConsiderRebalance,
+		},
+		// One voter is dead, but the witness maintains quorum so the voter can be
+		// replaced.
+		{
+			desc:           oneWitnessDesc,
+			live:           []roachpb.StoreID{1, 3, 4},
+			dead:           []roachpb.StoreID{2},
+			expectedAction: AllocatorReplaceDeadVoter,
+		},
+		// One voter and the witness are dead (i.e. the range lacks a quorum).
+		{
+			desc:           oneWitnessDesc,
+			live:           []roachpb.StoreID{1, 4},
+			dead:           []roachpb.StoreID{2, 3},
+			expectedAction: AllocatorRangeUnavailable,
+		},
+		// The witness is dead.
+		{
+			desc:           oneWitnessDesc,
+			live:           []roachpb.StoreID{1, 2, 4},
+			dead:           []roachpb.StoreID{3},
+			expectedAction: AllocatorRemoveDeadWitness,
+		},
+		// The witness is decommissioning.
+		{
+			desc:            oneWitnessDesc,
+			live:            []roachpb.StoreID{1, 2, 4},
+			decommissioning: []roachpb.StoreID{3},
+			expectedAction:  AllocatorRemoveDecommissioningWitness,
+		},
+		// One witness too many.
+		{
+			desc:           twoWitnessDesc,
+			live:           []roachpb.StoreID{1, 2, 3, 4},
+			expectedAction: AllocatorRemoveWitness,
+		},
+	}
+
+	ctx := context.Background()
+	stopper, _, sp, a, _ := CreateTestAllocator(ctx, 10, false /* deterministic */)
+	defer stopper.Stop(ctx)
+
+	for i, tcase := range testCases {
+		mockStorePool(sp, tcase.live, nil, tcase.dead, tcase.decommissioning, nil, nil)
+		action, _ := a.ComputeAction(ctx, sp, conf, &tcase.desc)
+		if tcase.expectedAction != action {
+			t.Errorf("Test case %d expected action %s, got action %s", i, tcase.expectedAction, action)
+		}
+	}
+}
+
 func TestAllocatorComputeActionWithStorePoolRemoveDead(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
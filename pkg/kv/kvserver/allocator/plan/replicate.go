@@ -336,6 +336,23 @@ func (rp ReplicaPlanner) PlanOneChange(
 		op, stats, err = rp.removeDead(ctx, repl, deadVoterReplicas, allocatorimpl.VoterTarget)
 	case allocatorimpl.AllocatorRemoveDeadNonVoter:
 		op, stats, err = rp.removeDead(ctx, repl, deadNonVoterReplicas, allocatorimpl.NonVoterTarget)
+
+	// Witness actions.
+	//
+	// NB: Witnesses are never replaced atomically; dead and decommissioning
+	// witnesses are removed first and re-added by a later pass, see
+	// `Allocator.computeAction`.
+	case allocatorimpl.AllocatorAddWitness:
+		op, stats, err = rp.addWitness(ctx, repl, voterReplicas, nonVoterReplicas, allocatorPrio)
+	case allocatorimpl.AllocatorRemoveWitness:
+		op, stats, err = rp.removeWitness(ctx, repl, voterReplicas)
+	case allocatorimpl.AllocatorRemoveDecommissioningWitness:
+		op, stats, err = rp.removeDecommissioning(ctx, repl, allocatorimpl.WitnessTarget)
+	case allocatorimpl.AllocatorRemoveDeadWitness:
+		_, deadWitnessReplicas := rp.storePool.LiveAndDeadReplicas(
+			desc.Replicas().WitnessDescriptors(), true, /* includeSuspectAndDrainingStores */
+		)
+		op, stats, err = rp.removeDead(ctx, repl, deadWitnessReplicas, allocatorimpl.WitnessTarget)
 	// Rebalance replicas.
 	//
 	// NB: Rebalacing attempts to balance replica counts among stores of
@@ -527,6 +544,71 @@ func (rp ReplicaPlanner) addOrReplaceNonVoters(
 	return op, stats, nil
 }
 
+// addWitness adds a witness to `repl`s range.
+func (rp ReplicaPlanner) addWitness(
+	ctx context.Context,
+	repl AllocatorReplica,
+	existingVoters, existingNonVoters []roachpb.ReplicaDescriptor,
+	allocatorPrio float64,
+) (op AllocationOp, stats ReplicateStats, _ error) {
+	desc, conf := repl.DescAndSpanConfig()
+	existingWitnesses := desc.Replicas().WitnessDescriptors()
+	newWitness, details, err := rp.allocator.AllocateWitness(
+		ctx, rp.storePool, conf, existingVoters, existingNonVoters, existingWitnesses,
+	)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	stats = stats.trackAddReplicaCount(allocatorimpl.WitnessTarget)
+	log.KvDistribution.Infof(ctx, "adding witness %+v: %s",
+		newWitness, rangeRaftProgress(repl.RaftStatus(), existingVoters))
+	op = AllocationChangeReplicasOp{
+		lhStore:           repl.StoreID(),
+		Usage:             repl.RangeUsageInfo(),
+		Chgs:              kvpb.MakeReplicationChanges(roachpb.ADD_WITNESS, newWitness),
+		Priority:          kvserverpb.SnapshotRequest_RECOVERY,
+		AllocatorPriority: allocatorPrio,
+		Reason:            kvserverpb.ReasonRangeUnderReplicated,
+		Details:           details,
+	}
+	return op, stats, nil
+}
+
+// removeWitness removes a witness from `repl`s range due to over-replication.
+func (rp ReplicaPlanner) removeWitness(
+	ctx context.Context, repl AllocatorReplica, existingVoters []roachpb.ReplicaDescriptor,
+) (op AllocationOp, stats ReplicateStats, _ error) {
+	desc, conf := repl.DescAndSpanConfig()
+	existingWitnesses := desc.Replicas().WitnessDescriptors()
+	removeWitness, details, err := rp.allocator.RemoveWitness(
+		ctx,
+		rp.storePool,
+		conf,
+		existingWitnesses,
+		existingVoters,
+		existingWitnesses,
+		rp.allocator.ScorerOptions(ctx),
+	)
+	if err != nil {
+		return nil, stats, err
+	}
+	stats = stats.trackRemoveMetric(allocatorimpl.WitnessTarget, allocatorimpl.Alive)
+
+	log.KvDistribution.Infof(ctx, "removing witness %+v due to over-replication: %s",
+		removeWitness, rangeRaftProgress(repl.RaftStatus(), existingVoters))
+	op = AllocationChangeReplicasOp{
+		lhStore:           repl.StoreID(),
+		Usage:             repl.RangeUsageInfo(),
+		Chgs:              kvpb.MakeReplicationChanges(roachpb.REMOVE_WITNESS, removeWitness),
+		Priority:          kvserverpb.SnapshotRequest_UNKNOWN, // unused
+		AllocatorPriority: 0.0,                                // unused
+		Reason:            kvserverpb.ReasonRangeOverReplicated,
+		Details:           details,
+	}
+	return op, stats, nil
+}
+
 // findRemoveVoter takes a list of voting replicas and picks one to remove,
 // making sure to not remove a newly added voter or to violate the zone configs
 // in the process.
@@ -714,6 +796,10 @@ func (rp ReplicaPlanner) removeDecommissioning(
 		decommissioningReplicas = rp.storePool.DecommissioningReplicas(
 			desc.Replicas().NonVoterDescriptors(),
 		)
+	case allocatorimpl.WitnessTarget:
+		decommissioningReplicas = rp.storePool.DecommissioningReplicas(
+			desc.Replicas().WitnessDescriptors(),
+		)
 	default:
 		panic(fmt.Sprintf("unknown targetReplicaType: %s", targetType))
 	}
@@ -512,6 +512,12 @@ func (sp *StorePool) UpdateLocalStoreAfterRebalance(
 				detail.Desc.Capacity.CPUPerSecond -= rangeUsageInfo.RaftCPUNanosPerSecond
 			}
 		}
+	case roachpb.ADD_WITNESS:
+		// Witnesses hold no range data and don't serve any requests, so they only
+		// count towards the store's range count.
+		detail.Desc.Capacity.RangeCount++
+	case roachpb.REMOVE_WITNESS:
+		detail.Desc.Capacity.RangeCount--
 	default:
 		return
 	}
@@ -130,9 +130,10 @@ func (b *appBatch) runPreAddTriggers(ctx context.Context, cmd *raftlog.Replicate
 	return nil
 }
 
-// addWriteBatch adds the command's writes to the batch.
+// addWriteBatch adds the command's writes to the batch. If the replica is a
+// witness, the writes to the range's user data are omitted.
 func (b *appBatch) addWriteBatch(
-	ctx context.Context, batch storage.Batch, cmd *replicatedCmd,
+	ctx context.Context, batch storage.Batch, cmd *replicatedCmd, witness bool,
 ) error {
 	wb := cmd.Cmd.WriteBatch
 	if wb == nil {
@@ -143,6 +144,12 @@ func (b *appBatch) addWriteBatch(
 	} else {
 		b.numMutations += mutations
 	}
+	if witness {
+		if err := applyWitnessWriteBatch(batch, wb.Data); err != nil {
+			return errors.Wrapf(err, "unable to apply WriteBatch to witness")
+		}
+		return nil
+	}
 	if err := batch.ApplyBatchRepr(wb.Data, false); err != nil {
 		return errors.Wrapf(err, "unable to apply WriteBatch")
 	}
@@ -154,6 +161,9 @@ type postAddEnv struct {
 	eng         storage.Engine
 	sideloaded  logstore.SideloadStorage
 	bulkLimiter *rate.Limiter
+	// witness is set if the replica is a witness, which doesn't ingest the
+	// user data of AddSSTable commands.
+	witness bool
 }
 
 func (b *appBatch) runPostAddTriggers(
@@ -174,7 +184,7 @@ func (b *appBatch) runPostAddTriggers(
 	// NB: any command which has an AddSSTable is non-trivial and will be
 	// applied in its own batch so it's not possible that any other commands
 	// which precede this command can shadow writes from this SSTable.
-	if res.AddSSTable != nil {
+	if res.AddSSTable != nil && !env.witness {
 		copied := addSSTablePreApply(
 			ctx,
 			env,
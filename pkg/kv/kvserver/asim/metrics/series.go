@@ -26,6 +26,7 @@ func MakeTS(metrics [][]StoreMetrics) map[string][][]float64 {
 	// custom scraper or provide definitions for each metric available. These
 	// are partially duplicated with the cluster tracker.
 	ret["qps"] = make([][]float64, stores)
+	ret["cpu"] = make([][]float64, stores)
 	ret["write"] = make([][]float64, stores)
 	ret["write_b"] = make([][]float64, stores)
 	ret["read"] = make([][]float64, stores)
@@ -42,6 +43,7 @@ func MakeTS(metrics [][]StoreMetrics) map[string][][]float64 {
 	for _, sms := range metrics {
 		for i, sm := range sms {
 			ret["qps"][i] = append(ret["qps"][i], float64(sm.QPS))
+			ret["cpu"][i] = append(ret["cpu"][i], float64(sm.CPU))
 			ret["write"][i] = append(ret["write"][i], float64(sm.WriteKeys))
 			ret["write_b"][i] = append(ret["write_b"][i], float64(sm.WriteBytes))
 			ret["read"][i] = append(ret["read"][i], float64(sm.ReadKeys))
@@ -0,0 +1,146 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package replay
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/errors"
+)
+
+// Capture is a snapshot of a cluster's nodes, range layout, span configs and
+// per-range load. A capture is taken from a debug zip or the status API of a
+// running cluster and may be written to a file, so that the same production
+// load can be replayed deterministically in the allocator simulator e.g. to
+// compare allocator versions or rebalance objectives.
+type Capture struct {
+	Nodes  []Node
+	Ranges []Range
+}
+
+// Node is a captured node, along with its stores.
+type Node struct {
+	NodeID   roachpb.NodeID
+	Locality roachpb.Locality
+	Stores   []Store
+}
+
+// Store is a captured store.
+type Store struct {
+	StoreID roachpb.StoreID
+	// Capacity is the disk capacity of the store in bytes.
+	Capacity int64
+}
+
+// Range is a captured range.
+type Range struct {
+	RangeID     roachpb.RangeID
+	StartKey    roachpb.RKey
+	Voters      []roachpb.StoreID
+	NonVoters   []roachpb.StoreID `json:",omitempty"`
+	Leaseholder roachpb.StoreID
+	// Size is the logical size of the range in bytes.
+	Size int64
+	// Config is the span config that applies to the range. When nil, the
+	// simulator's default span config is used, with the replication factor of
+	// the captured range.
+	Config *roachpb.SpanConfig `json:",omitempty"`
+	Load   RangeLoad
+}
+
+// RangeLoad is the load on the leaseholder replica of a range, as rates per
+// second.
+type RangeLoad struct {
+	QueriesPerSecond   float64
+	WritesPerSecond    float64
+	ReadBytesPerSecond float64
+	// CPUNanosPerSecond is the CPU time spent serving requests to the range.
+	CPUNanosPerSecond float64
+}
+
+// Read reads a capture written by Capture.Write.
+func Read(r io.Reader) (*Capture, error) {
+	var c Capture
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, errors.Wrap(err, "decoding capture")
+	}
+	c.normalize()
+	return &c, nil
+}
+
+// Write writes the capture as JSON.
+func (c *Capture) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+// normalize sorts the nodes, stores and ranges of the capture by ID and start
+// key respectively, so that replaying the capture doesn't depend on the order
+// in which it was collected. Replicas on stores that are not part of the
+// capture are removed, as are ranges left without a voter. When the captured
+// leaseholder isn't a voter, the lease is placed on the first voter instead.
+func (c *Capture) normalize() {
+	stores := make(map[roachpb.StoreID]struct{})
+	sort.Slice(c.Nodes, func(i, j int) bool {
+		return c.Nodes[i].NodeID < c.Nodes[j].NodeID
+	})
+	for _, n := range c.Nodes {
+		sort.Slice(n.Stores, func(i, j int) bool {
+			return n.Stores[i].StoreID < n.Stores[j].StoreID
+		})
+		for _, s := range n.Stores {
+			stores[s.StoreID] = struct{}{}
+		}
+	}
+
+	filter := func(storeIDs []roachpb.StoreID) []roachpb.StoreID {
+		var ret []roachpb.StoreID
+		for _, storeID := range storeIDs {
+			if _, ok := stores[storeID]; ok {
+				ret = append(ret, storeID)
+			}
+		}
+		return ret
+	}
+	ranges := c.Ranges[:0]
+	for _, r := range c.Ranges {
+		r.Voters = filter(r.Voters)
+		r.NonVoters = filter(r.NonVoters)
+		if len(r.Voters) == 0 {
+			continue
+		}
+		leaseholderIsVoter := false
+		for _, storeID := range r.Voters {
+			leaseholderIsVoter = leaseholderIsVoter || storeID == r.Leaseholder
+		}
+		if !leaseholderIsVoter {
+			r.Leaseholder = r.Voters[0]
+		}
+		ranges = append(ranges, r)
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].StartKey.Less(ranges[j].StartKey)
+	})
+	c.Ranges = ranges
+}
+
+// StoreCount returns the number of captured stores.
+func (c *Capture) StoreCount() int {
+	n := 0
+	for _, node := range c.Nodes {
+		n += len(node.Stores)
+	}
+	return n
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package replay
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/server/status/statuspb"
+	"github.com/cockroachdb/errors"
+)
+
+// FromStatus returns a capture of a running cluster, collected using its
+// status API. The status API doesn't expose span configs, so the ranges of the
+// capture use the simulator's default span config.
+func FromStatus(ctx context.Context, status serverpb.StatusClient) (*Capture, error) {
+	nodes, err := status.Nodes(ctx, &serverpb.NodesRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "requesting nodes")
+	}
+	c := &Capture{}
+	rc := rangeCollector{}
+	for _, ns := range nodes.Nodes {
+		c.Nodes = append(c.Nodes, nodeFromStatus(ns))
+		ranges, err := status.Ranges(ctx, &serverpb.RangesRequest{NodeId: ns.Desc.NodeID.String()})
+		if err != nil {
+			return nil, errors.Wrapf(err, "requesting ranges of n%d", ns.Desc.NodeID)
+		}
+		rc.add(ranges.Ranges...)
+	}
+	c.Ranges = rc.ranges(nil /* spanConfigs */)
+	c.normalize()
+	return c, nil
+}
+
+// nodeFromStatus returns the captured node for the node status.
+func nodeFromStatus(ns statuspb.NodeStatus) Node {
+	n := Node{NodeID: ns.Desc.NodeID, Locality: ns.Desc.Locality}
+	for _, ss := range ns.StoreStatuses {
+		n.Stores = append(n.Stores, Store{
+			StoreID:  ss.Desc.StoreID,
+			Capacity: ss.Desc.Capacity.Capacity,
+		})
+	}
+	return n
+}
+
+// rangeCollector collects the range info reported by each replica of a range.
+// Only the leaseholder's range info is kept, since it is the only replica that
+// reports the load of the range.
+type rangeCollector map[roachpb.RangeID]serverpb.RangeInfo
+
+func (rc rangeCollector) add(infos ...serverpb.RangeInfo) {
+	for _, info := range infos {
+		if info.State.Desc == nil || info.ErrorMessage != "" {
+			continue
+		}
+		rangeID := info.State.Desc.RangeID
+		existing, ok := rc[rangeID]
+		switch {
+		case !ok:
+		case info.IsLeaseholder && !existing.IsLeaseholder:
+		case info.IsLeaseholder == existing.IsLeaseholder &&
+			info.State.Desc.Generation > existing.State.Desc.Generation:
+		default:
+			continue
+		}
+		rc[rangeID] = info
+	}
+}
+
+// ranges returns the captured ranges. The span config of each range is looked
+// up in spanConfigs, when given.
+func (rc rangeCollector) ranges(spanConfigs spanConfigs) []Range {
+	ranges := make([]Range, 0, len(rc))
+	for _, info := range rc {
+		desc := info.State.Desc
+		r := Range{
+			RangeID:  desc.RangeID,
+			StartKey: desc.StartKey,
+			Config:   spanConfigs.lookup(desc.StartKey),
+			Load: RangeLoad{
+				QueriesPerSecond:   info.Stats.QueriesPerSecond,
+				WritesPerSecond:    info.Stats.WritesPerSecond,
+				ReadBytesPerSecond: info.Stats.ReadBytesPerSecond,
+				CPUNanosPerSecond:  info.Stats.CPUTimePerSecond,
+			},
+		}
+		for _, repl := range desc.Replicas().VoterDescriptors() {
+			r.Voters = append(r.Voters, repl.StoreID)
+		}
+		for _, repl := range desc.Replicas().NonVoterDescriptors() {
+			r.NonVoters = append(r.NonVoters, repl.StoreID)
+		}
+		if lease := info.State.Lease; lease != nil {
+			r.Leaseholder = lease.Replica.StoreID
+		}
+		if stats := info.State.Stats; stats != nil {
+			r.Size = stats.Total()
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// spanConfigEntry is the span config that applies to a span.
+type spanConfigEntry struct {
+	span   roachpb.Span
+	config roachpb.SpanConfig
+}
+
+// spanConfigs is a list of span configs, sorted by start key.
+type spanConfigs []spanConfigEntry
+
+// lookup returns the span config that applies to the given key, or nil if
+// there is none.
+func (sc spanConfigs) lookup(key roachpb.RKey) *roachpb.SpanConfig {
+	// Find the last entry that starts at or before the key.
+	i := sort.Search(len(sc), func(i int) bool {
+		return key.AsRawKey().Compare(sc[i].span.Key) < 0
+	}) - 1
+	if i < 0 || !sc[i].span.ContainsKey(key.AsRawKey()) {
+		return nil
+	}
+	conf := sc[i].config
+	return &conf
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package replay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/montanaflynn/stats"
+)
+
+// Convergence summarizes how the load and replicas of a simulated cluster
+// converged to a balanced state.
+type Convergence struct {
+	// Stats is the convergence of each stat, in the order requested.
+	Stats []StatConvergence
+	// LeaseTransfers and Rebalances are the total number of lease transfers
+	// and replica rebalances over the simulation run.
+	LeaseTransfers int64
+	Rebalances     int64
+	// RebalancedBytes is the total size of the rebalanced replicas.
+	RebalancedBytes int64
+}
+
+// StatConvergence summarizes the balance of a store stat over the simulation
+// run. The balance of a stat is the maximum over the mean of the stat across
+// stores, a perfectly balanced stat has a balance of 1.
+type StatConvergence struct {
+	Stat           string
+	InitialBalance float64
+	FinalBalance   float64
+	// Converged is true if the balance of the stat is within the threshold at
+	// the end of the simulation run. ConvergedAfter is the duration after
+	// which the balance remained within the threshold.
+	Converged      bool
+	ConvergedAfter time.Duration
+}
+
+// ComputeConvergence returns the convergence of the given stats (e.g. qps,
+// cpu, replicas, leases) over the simulation run history, where a stat is
+// considered converged once its balance is no greater than threshold.
+func ComputeConvergence(h asim.History, threshold float64, statNames ...string) Convergence {
+	var c Convergence
+	if len(h.Recorded) == 0 || len(h.Recorded[0]) == 0 {
+		return c
+	}
+	start := h.Recorded[0][0].Tick
+	ts := metrics.MakeTS(h.Recorded)
+	for _, stat := range statNames {
+		sc := StatConvergence{Stat: stat}
+		for i, tickStats := range metrics.Transpose(ts[stat]) {
+			b := balance(tickStats)
+			if i == 0 {
+				sc.InitialBalance = b
+			}
+			sc.FinalBalance = b
+			if b > threshold {
+				sc.Converged = false
+			} else if !sc.Converged {
+				sc.Converged = true
+				sc.ConvergedAfter = h.Recorded[i][0].Tick.Sub(start)
+			}
+		}
+		c.Stats = append(c.Stats, sc)
+	}
+	for _, sm := range h.Recorded[len(h.Recorded)-1] {
+		c.LeaseTransfers += sm.LeaseTransfers
+		c.Rebalances += sm.Rebalances
+		c.RebalancedBytes += sm.RebalanceSentBytes
+	}
+	return c
+}
+
+// balance returns the maximum over the mean of the given store values. When
+// the mean is zero, the values are balanced.
+func balance(values []float64) float64 {
+	mean, _ := stats.Mean(values)
+	max, _ := stats.Max(values)
+	if mean == 0 {
+		return 1
+	}
+	return max / mean
+}
+
+// String returns a table of the convergence of each stat, followed by the
+// rebalancing activity.
+func (c Convergence) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%-10s %8s %8s %s\n", "stat", "initial", "final", "converged after")
+	for _, sc := range c.Stats {
+		convergedAfter := "never"
+		if sc.Converged {
+			convergedAfter = sc.ConvergedAfter.String()
+		}
+		fmt.Fprintf(&buf, "%-10s %8.2f %8.2f %s\n",
+			sc.Stat, sc.InitialBalance, sc.FinalBalance, convergedAfter)
+	}
+	fmt.Fprintf(&buf, "lease transfers: %d, replica rebalances: %d (%s)\n",
+		c.LeaseTransfers, c.Rebalances, humanizeutil.IBytes(c.RebalancedBytes))
+	return buf.String()
+}
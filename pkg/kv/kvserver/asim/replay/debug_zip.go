@@ -0,0 +1,151 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package replay
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/server/status/statuspb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+)
+
+const (
+	// debugZipNodesDir is the directory of a debug zip that contains a
+	// directory per node.
+	debugZipNodesDir = "nodes"
+	// debugZipNodeStatusFile and debugZipRangesFile are the files of a node's
+	// directory that contain the node's status and the range info of each of
+	// its replicas respectively.
+	debugZipNodeStatusFile = "status.json"
+	debugZipRangesFile     = "ranges.json"
+	// debugZipSpanConfigsFile is the dump of system.span_configurations.
+	debugZipSpanConfigsFile = "system.span_configurations.txt"
+)
+
+// FromDebugZip returns a capture of a cluster from an unzipped debug zip, given
+// the path of its debug directory. The debug zip must include range info. When
+// the debug zip includes a dump of system.span_configurations, the span config
+// of each captured range is set accordingly.
+func FromDebugZip(dir string) (*Capture, error) {
+	nodeDirs, err := filepath.Glob(filepath.Join(dir, debugZipNodesDir, "*"))
+	if err != nil {
+		return nil, err
+	}
+	if len(nodeDirs) == 0 {
+		return nil, errors.Newf("no nodes found in debug zip %s", dir)
+	}
+	sort.Strings(nodeDirs)
+
+	c := &Capture{}
+	rc := rangeCollector{}
+	for _, nodeDir := range nodeDirs {
+		var ns statuspb.NodeStatus
+		if err := readJSONFile(filepath.Join(nodeDir, debugZipNodeStatusFile), &ns); err != nil {
+			if oserror.IsNotExist(err) {
+				// The node could not be reached when collecting the debug zip.
+				continue
+			}
+			return nil, err
+		}
+		if ns.Desc.NodeID == 0 {
+			// The node isn't a KV node.
+			continue
+		}
+		c.Nodes = append(c.Nodes, nodeFromStatus(ns))
+
+		var infos []serverpb.RangeInfo
+		if err := readJSONFile(filepath.Join(nodeDir, debugZipRangesFile), &infos); err != nil {
+			if oserror.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		rc.add(infos...)
+	}
+
+	var sc spanConfigs
+	if f, err := os.Open(filepath.Join(dir, debugZipSpanConfigsFile)); err == nil {
+		defer f.Close()
+		if sc, err = readSpanConfigs(f); err != nil {
+			return nil, errors.Wrapf(err, "reading %s", debugZipSpanConfigsFile)
+		}
+	} else if !oserror.IsNotExist(err) {
+		return nil, err
+	}
+
+	c.Ranges = rc.ranges(sc)
+	if len(c.Ranges) == 0 {
+		return nil, errors.Newf("no ranges found in debug zip %s; was it collected with --include-range-info?", dir)
+	}
+	c.normalize()
+	return c, nil
+}
+
+// readJSONFile decodes the JSON contents of the file into v.
+func readJSONFile(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return errors.Wrapf(json.NewDecoder(f).Decode(v), "decoding %s", path)
+}
+
+// readSpanConfigs reads a TSV dump of system.span_configurations, where the
+// start_key, end_key and config columns are hex encoded bytes.
+func readSpanConfigs(r io.Reader) (spanConfigs, error) {
+	var sc spanConfigs
+	s := bufio.NewScanner(r)
+	// The size of a span config is unbounded, allow large rows.
+	s.Buffer(make([]byte, 64<<10), 50<<20)
+	header := true
+	for s.Scan() {
+		if header {
+			header = false
+			continue
+		}
+		fields := strings.Split(s.Text(), "\t")
+		if len(fields) != 3 {
+			return nil, errors.Newf("expected 3 columns, found %d: %q", len(fields), s.Text())
+		}
+		var cols [3][]byte
+		for i, field := range fields {
+			b, err := hex.DecodeString(strings.TrimPrefix(field, `\x`))
+			if err != nil {
+				return nil, errors.Wrapf(err, "decoding %q", field)
+			}
+			cols[i] = b
+		}
+		entry := spanConfigEntry{span: roachpb.Span{Key: cols[0], EndKey: cols[1]}}
+		if err := protoutil.Unmarshal(cols[2], &entry.config); err != nil {
+			return nil, errors.Wrapf(err, "decoding span config of %s", entry.span)
+		}
+		sc = append(sc, entry)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(sc, func(i, j int) bool {
+		return sc[i].span.Key.Compare(sc[j].span.Key) < 0
+	})
+	return sc, nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package replay
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/gen"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+)
+
+// KeysPerRange is the number of simulated keys spanned by each captured range.
+// The simulated keyspace is made up of integer keys, so the captured ranges
+// are laid out in order, each spanning KeysPerRange keys.
+const KeysPerRange = 1000
+
+// rangeKey returns the simulated start key of the i'th captured range.
+func rangeKey(i int) state.Key {
+	return state.Key(i * KeysPerRange)
+}
+
+// storeIDs maps the captured store IDs to simulated store IDs. The simulator
+// assigns store IDs sequentially as stores are added, in the order of the
+// captured nodes and stores.
+func (c *Capture) storeIDs() map[roachpb.StoreID]state.StoreID {
+	ids := make(map[roachpb.StoreID]state.StoreID)
+	for _, n := range c.Nodes {
+		for _, s := range n.Stores {
+			ids[s.StoreID] = state.StoreID(len(ids) + 1)
+		}
+	}
+	return ids
+}
+
+// Cluster implements the gen.ClusterGen interface.
+type Cluster struct {
+	Capture *Capture
+}
+
+var _ gen.ClusterGen = Cluster{}
+
+// Generate returns a new simulator state, with a node for each captured node
+// and a store for each captured store. The nodes have the captured localities
+// and the stores the captured capacities. There is no randomness in this
+// cluster generation.
+func (cl Cluster) Generate(seed int64, settings *config.SimulationSettings) state.State {
+	s := state.NewState(settings)
+	for _, n := range cl.Capture.Nodes {
+		node := s.AddNode()
+		s.SetNodeLocality(node.NodeID(), n.Locality)
+		for _, captured := range n.Stores {
+			store, ok := s.AddStore(node.NodeID())
+			if !ok {
+				panic(fmt.Sprintf("unable to add store to node %d", node.NodeID()))
+			}
+			s.SetStoreCapacity(store.StoreID(), captured.Capacity)
+		}
+	}
+	return s
+}
+
+// Ranges implements the gen.RangeGen interface.
+type Ranges struct {
+	Capture *Capture
+}
+
+var _ gen.RangeGen = Ranges{}
+
+// Generate returns an updated simulator state, where the captured ranges are
+// loaded with their replica and lease placement, size and span config. There
+// is no randomness in this range generation.
+func (r Ranges) Generate(
+	seed int64, settings *config.SimulationSettings, s state.State,
+) state.State {
+	ids := r.Capture.storeIDs()
+	toSim := func(storeIDs []roachpb.StoreID) []state.StoreID {
+		ret := make([]state.StoreID, len(storeIDs))
+		for i, storeID := range storeIDs {
+			ret[i] = ids[storeID]
+		}
+		return ret
+	}
+
+	rangesInfo := make(state.RangesInfo, len(r.Capture.Ranges))
+	for i, rng := range r.Capture.Ranges {
+		conf := rng.Config
+		if conf == nil {
+			// Retain the captured replication factor, otherwise the simulated
+			// cluster begins by up or down replicating the range.
+			conf = &roachpb.SpanConfig{
+				RangeMinBytes: 128 << 20, // 128 MB
+				RangeMaxBytes: 512 << 20, // 512 MB
+				NumReplicas:   int32(len(rng.Voters) + len(rng.NonVoters)),
+				NumVoters:     int32(len(rng.Voters)),
+			}
+		}
+		rangesInfo[i] = state.RangeInfoWithReplicas(
+			rangeKey(i), toSim(rng.Voters), toSim(rng.NonVoters), ids[rng.Leaseholder], conf)
+		rangesInfo[i].Size = rng.Size
+	}
+	state.LoadRangeInfo(s, rangesInfo...)
+	return s
+}
+
+// Workload implements the gen.LoadGen interface.
+type Workload struct {
+	Capture *Capture
+}
+
+var _ gen.LoadGen = Workload{}
+
+// Generate returns a workload generator that replays the captured load of
+// each range. There is no randomness in the generated workload.
+func (w Workload) Generate(seed int64, settings *config.SimulationSettings) []workload.Generator {
+	g := &generator{
+		lastRun: settings.StartTime,
+		ranges:  make([]rangeGenerator, len(w.Capture.Ranges)),
+	}
+	for i, rng := range w.Capture.Ranges {
+		g.ranges[i] = rangeGenerator{key: int64(rangeKey(i)), load: rng.Load}
+	}
+	return []workload.Generator{g}
+}
+
+// generator replays the captured load of each range at a constant rate.
+type generator struct {
+	lastRun time.Time
+	ranges  []rangeGenerator
+}
+
+var _ workload.Generator = &generator{}
+
+// Tick returns a load event for each range that received at least one request
+// since the last tick.
+func (g *generator) Tick(tick time.Time) workload.LoadBatch {
+	elapsed := tick.Sub(g.lastRun).Seconds()
+	if elapsed <= 0 {
+		return nil
+	}
+	g.lastRun = tick
+
+	var batch workload.LoadBatch
+	for i := range g.ranges {
+		if le, ok := g.ranges[i].tick(elapsed); ok {
+			batch = append(batch, le)
+		}
+	}
+	// NB: the ranges are in key order and each event's key is within its
+	// range, so the batch is sorted.
+	return batch
+}
+
+// rangeGenerator replays the captured load of a range. The captured load is
+// applied to a different key of the range on each tick, cycling through the
+// range's keys, so that the simulator may find load based split points.
+type rangeGenerator struct {
+	key  int64
+	load RangeLoad
+	// offset is the offset of the next load event's key from the start key.
+	offset int64
+	// reads, writes, readBytes and cpu accumulate the load that is yet to be
+	// applied. Only whole units are applied, the remainder carries over to
+	// the next tick.
+	reads, writes, readBytes, cpu float64
+}
+
+func (r *rangeGenerator) tick(elapsed float64) (workload.LoadEvent, bool) {
+	// The simulator counts each read and write as a query. Replay reads such
+	// that the simulated QPS of the range matches the captured QPS.
+	r.reads += math.Max(r.load.QueriesPerSecond-r.load.WritesPerSecond, 0) * elapsed
+	r.writes += r.load.WritesPerSecond * elapsed
+	r.readBytes += r.load.ReadBytesPerSecond * elapsed
+	r.cpu += r.load.CPUNanosPerSecond * elapsed
+	if r.reads < 1 && r.writes < 1 {
+		return workload.LoadEvent{}, false
+	}
+
+	// NB: write bytes are not replayed. The simulator grows a range by the
+	// size of each write, whereas the size of a production range is stable
+	// over time as overwritten data is garbage collected.
+	le := workload.LoadEvent{
+		Key:        r.key + r.offset,
+		Reads:      take(&r.reads),
+		Writes:     take(&r.writes),
+		ReadSize:   take(&r.readBytes),
+		RequestCPU: take(&r.cpu),
+	}
+	r.offset = (r.offset + 1) % KeysPerRange
+	return le, true
+}
+
+// take removes and returns the whole part of the accumulated value.
+func take(v *float64) int64 {
+	whole := math.Floor(*v)
+	*v -= whole
+	return int64(whole)
+}
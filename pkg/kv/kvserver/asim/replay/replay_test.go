@@ -0,0 +1,166 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/gen"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testingCapture returns a capture of a 3 node cluster, with two stores per
+// node, where all of the load is on the leaseholders of the first node.
+func testingCapture() *Capture {
+	c := &Capture{}
+	for n := 3; n >= 1; n-- {
+		c.Nodes = append(c.Nodes, Node{
+			NodeID: roachpb.NodeID(n),
+			Locality: roachpb.Locality{Tiers: []roachpb.Tier{
+				{Key: "region", Value: "us-east1"},
+				{Key: "zone", Value: fmt.Sprintf("us-east1-%c", 'a'+n-1)},
+			}},
+			Stores: []Store{
+				{StoreID: roachpb.StoreID(2*n + 10), Capacity: 1 << 40},
+				{StoreID: roachpb.StoreID(2*n + 9), Capacity: 1 << 40},
+			},
+		})
+	}
+	for i := 0; i < 20; i++ {
+		c.Ranges = append(c.Ranges, Range{
+			RangeID:     roachpb.RangeID(i + 1),
+			StartKey:    roachpb.RKey(fmt.Sprintf("%03d", 20-i)),
+			Voters:      []roachpb.StoreID{11 + roachpb.StoreID(i%2), 13, 15},
+			Leaseholder: 11 + roachpb.StoreID(i%2),
+			Size:        64 << 20,
+			Load: RangeLoad{
+				QueriesPerSecond:   100,
+				WritesPerSecond:    10.5,
+				ReadBytesPerSecond: 4 << 10,
+				CPUNanosPerSecond:  float64(10 * time.Millisecond),
+			},
+		})
+	}
+	c.normalize()
+	return c
+}
+
+func TestCaptureRoundTrip(t *testing.T) {
+	c := testingCapture()
+	var buf bytes.Buffer
+	require.NoError(t, c.Write(&buf))
+	read, err := Read(&buf)
+	require.NoError(t, err)
+	require.Equal(t, c, read)
+}
+
+func TestNormalize(t *testing.T) {
+	c := &Capture{
+		Nodes: []Node{{NodeID: 1, Stores: []Store{{StoreID: 2}, {StoreID: 1}}}},
+		Ranges: []Range{
+			{StartKey: roachpb.RKey("b"), Voters: []roachpb.StoreID{1, 3}, Leaseholder: 3},
+			{StartKey: roachpb.RKey("c"), Voters: []roachpb.StoreID{3}, Leaseholder: 3},
+			{StartKey: roachpb.RKeyMin, Voters: []roachpb.StoreID{2}, NonVoters: []roachpb.StoreID{4}, Leaseholder: 2},
+		},
+	}
+	c.normalize()
+	require.Equal(t, []Store{{StoreID: 1}, {StoreID: 2}}, c.Nodes[0].Stores)
+	require.Equal(t, []Range{
+		{StartKey: roachpb.RKeyMin, Voters: []roachpb.StoreID{2}, Leaseholder: 2},
+		{StartKey: roachpb.RKey("b"), Voters: []roachpb.StoreID{1}, Leaseholder: 1},
+	}, c.Ranges)
+}
+
+func TestReadSpanConfigs(t *testing.T) {
+	conf := roachpb.SpanConfig{NumReplicas: 5, NumVoters: 5}
+	confBytes, err := protoutil.Marshal(&conf)
+	require.NoError(t, err)
+	row := func(start, end string) string {
+		return fmt.Sprintf(`\x%x`+"\t"+`\x%x`+"\t"+`\x%x`, start, end, confBytes)
+	}
+	tsv := strings.Join([]string{
+		"start_key\tend_key\tconfig",
+		row("m", "z"),
+		row("a", "c"),
+	}, "\n")
+	sc, err := readSpanConfigs(strings.NewReader(tsv))
+	require.NoError(t, err)
+	require.Len(t, sc, 2)
+
+	require.Equal(t, &conf, sc.lookup(roachpb.RKey("a")))
+	require.Equal(t, &conf, sc.lookup(roachpb.RKey("b")))
+	require.Nil(t, sc.lookup(roachpb.RKey("c")))
+	require.Nil(t, sc.lookup(roachpb.RKeyMin))
+	require.Equal(t, &conf, sc.lookup(roachpb.RKey("p")))
+}
+
+// TestReplay asserts that replaying a capture loads the captured cluster and
+// ranges into the simulator and that the replay is deterministic.
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	c := testingCapture()
+	settings := config.DefaultSimulationSettings()
+	settings.LBRebalancingObjective = 1 // CPU
+
+	run := func() asim.History {
+		sim := gen.GenerateSimulation(
+			5*time.Minute,
+			Cluster{Capture: c},
+			Ranges{Capture: c},
+			Workload{Capture: c},
+			gen.StaticSettings{Settings: settings},
+			gen.StaticEvents{},
+			settings.Seed,
+		)
+		s := sim.History().S
+		require.Len(t, s.Nodes(), 3)
+		require.Len(t, s.Stores(), 6)
+		// The captured store 11 is the first store of node 1.
+		store, ok := s.Store(1)
+		require.True(t, ok)
+		require.Equal(t, state.NodeID(1), store.NodeID())
+		require.Len(t, s.Ranges(), len(c.Ranges))
+		for i, r := range c.Ranges {
+			rng := s.RangeFor(rangeKey(i))
+			leaseholder, ok := s.LeaseholderStore(rng.RangeID())
+			require.True(t, ok)
+			require.Equal(t, state.StoreID(r.Leaseholder-10), leaseholder.StoreID())
+			require.Len(t, rng.Replicas(), len(r.Voters))
+		}
+
+		sim.RunSim(ctx)
+		return sim.History()
+	}
+
+	first := run()
+	require.Equal(t, first.Recorded, run().Recorded)
+
+	// The captured CPU is replayed, totalling 10ms/s per range.
+	var cpu int64
+	for _, sm := range first.Recorded[len(first.Recorded)-1] {
+		cpu += sm.CPU
+	}
+	require.InEpsilon(t, float64(len(c.Ranges))*float64(10*time.Millisecond), float64(cpu), 0.25)
+
+	conv := ComputeConvergence(first, 1.1, "qps", "cpu")
+	require.Len(t, conv.Stats, 2)
+	require.Equal(t, "cpu", conv.Stats[1].Stat)
+}
@@ -225,6 +225,7 @@ func (s *state) capacity(storeID StoreID) roachpb.StoreCapacity {
 	capacity := store.desc.Capacity
 	capacity.QueriesPerSecond = 0
 	capacity.WritesPerSecond = 0
+	capacity.CPUPerSecond = 0
 	capacity.LogicalBytes = 0
 	capacity.LeaseCount = 0
 	capacity.RangeCount = 0
@@ -243,6 +244,7 @@ func (s *state) capacity(storeID StoreID) roachpb.StoreCapacity {
 			usage := s.RangeUsageInfo(rng.RangeID(), storeID)
 			capacity.QueriesPerSecond += usage.QueriesPerSecond
 			capacity.WritesPerSecond += usage.WritesPerSecond
+			capacity.CPUPerSecond += usage.RequestCPUNanosPerSecond
 			capacity.LogicalBytes += usage.LogicalBytes
 			capacity.LeaseCount++
 		}
@@ -26,6 +26,9 @@ type LoadEvent struct {
 	WriteSize int64
 	Reads     int64
 	ReadSize  int64
+	// RequestCPU is the CPU time in nanoseconds spent serving the requests of
+	// the load event. It is zero unless the workload models request CPU.
+	RequestCPU int64
 }
 
 // LoadBatch is a sorted list of load events.
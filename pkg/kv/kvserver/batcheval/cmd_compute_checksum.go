@@ -66,11 +66,12 @@ func ComputeChecksum(
 
 	var pd result.Result
 	pd.Replicated.ComputeChecksum = &kvserverpb.ComputeChecksum{
-		Version:    args.Version,
-		ChecksumID: reply.ChecksumID,
-		Mode:       args.Mode,
-		Checkpoint: args.Checkpoint,
-		Terminate:  args.Terminate,
+		Version:          args.Version,
+		ChecksumID:       reply.ChecksumID,
+		Mode:             args.Mode,
+		Checkpoint:       args.Checkpoint,
+		Terminate:        args.Terminate,
+		IncrementalStart: args.IncrementalStart,
 	}
 	return pd, nil
 }
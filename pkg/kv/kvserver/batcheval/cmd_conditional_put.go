@@ -59,9 +59,11 @@ func ConditionalPut(
 	handleMissing := storage.CPutMissingBehavior(args.AllowIfDoesNotExist)
 
 	opts := storage.MVCCWriteOptions{
-		Txn:            h.Txn,
-		LocalTimestamp: cArgs.Now,
-		Stats:          cArgs.Stats,
+		Txn:             h.Txn,
+		LocalTimestamp:  cArgs.Now,
+		Stats:           cArgs.Stats,
+		OriginID:        h.OriginID(),
+		OriginTimestamp: h.OriginTimestamp(),
 	}
 
 	var err error
@@ -32,9 +32,11 @@ func Delete(
 	reply := resp.(*kvpb.DeleteResponse)
 
 	opts := storage.MVCCWriteOptions{
-		Txn:            h.Txn,
-		LocalTimestamp: cArgs.Now,
-		Stats:          cArgs.Stats,
+		Txn:             h.Txn,
+		LocalTimestamp:  cArgs.Now,
+		Stats:           cArgs.Stats,
+		OriginID:        h.OriginID(),
+		OriginTimestamp: h.OriginTimestamp(),
 	}
 
 	var err error
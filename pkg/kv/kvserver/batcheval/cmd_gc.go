@@ -82,6 +82,19 @@ func declareKeysGC(
 				hlc.MaxTimestamp)
 		}
 	}
+	// Expired keys are removed along with their live, newest versions, so we
+	// must serialize with writers to those keys. Otherwise, a concurrent write
+	// could be removed with the expired versions, or invalidate the stats delta
+	// computed while removing them. Readers need not be blocked: they either
+	// see the key before or after its removal, both of which are valid under
+	// the storage-level TTL.
+	for _, k := range gcr.ExpiredKeys {
+		latchSpans.AddMVCC(spanset.SpanReadWrite, roachpb.Span{Key: k.Key}, header.Timestamp)
+	}
+	if rk := gcr.ExpiredClearRange; rk != nil {
+		latchSpans.AddMVCC(spanset.SpanReadWrite, roachpb.Span{Key: rk.StartKey, EndKey: rk.EndKey},
+			header.Timestamp)
+	}
 	// The RangeGCThresholdKey is only written to if the
 	// req.(*GCRequest).Threshold is set. However, we always declare an exclusive
 	// access over this key in order to serialize with other GC requests.
@@ -165,7 +178,8 @@ func GC(
 	//    GC request's effect from the raft log. Latches held on the leaseholder
 	//    would have no impact on a follower read.
 	if !args.Threshold.IsEmpty() &&
-		(len(args.Keys) != 0 || len(args.RangeKeys) != 0 || args.ClearRange != nil) &&
+		(len(args.Keys) != 0 || len(args.RangeKeys) != 0 || args.ClearRange != nil ||
+			len(args.ExpiredKeys) != 0 || args.ExpiredClearRange != nil) &&
 		!cArgs.EvalCtx.EvalKnobs().AllowGCWithNewThresholdAndKeys {
 		return result.Result{}, errors.AssertionFailedf(
 			"GC request can set threshold or it can GC keys, but it is unsafe for it to do both")
@@ -173,8 +187,8 @@ func GC(
 
 	// We do not allow removal of point or range keys combined with clear range
 	// operation as they could cover the same set of keys.
-	if (len(args.Keys) != 0 || len(args.RangeKeys) != 0) &&
-		args.ClearRange != nil {
+	if (len(args.Keys) != 0 || len(args.RangeKeys) != 0 || len(args.ExpiredKeys) != 0 ||
+		args.ExpiredClearRange != nil) && args.ClearRange != nil {
 		return result.Result{}, errors.AssertionFailedf(
 			"GC request can remove point and range keys or clear range, but it is unsafe for it to do both")
 	}
@@ -207,6 +221,34 @@ func GC(
 		}
 	}
 
+	// Remove keys that have expired under the storage-level TTL. Only global
+	// keys are subject to expiration. Their newest versions must be below the
+	// GC threshold, which the MVCC GC queue advances to the expiration
+	// threshold first, so that reads can't observe the removal of versions
+	// other than the newest.
+	expiredKeys := make([]kvpb.GCRequest_GCKey, 0, len(args.ExpiredKeys))
+	for _, k := range args.ExpiredKeys {
+		if cArgs.EvalCtx.ContainsKey(k.Key) && !keys.IsLocal(k.Key) {
+			expiredKeys = append(expiredKeys, k)
+		}
+	}
+	if _, err := storage.MVCCGarbageCollectExpired(
+		ctx, readWriter, cArgs.Stats, expiredKeys, cArgs.EvalCtx.GetGCThreshold(), h.Timestamp,
+	); err != nil {
+		return result.Result{}, err
+	}
+	if cr := args.ExpiredClearRange; cr != nil {
+		userSpan := cArgs.EvalCtx.Desc().KeySpan().AsRawSpanWithNoLocals()
+		if !userSpan.Contains(roachpb.Span{Key: cr.StartKey, EndKey: cr.EndKey}) {
+			return result.Result{}, errors.Errorf("expired clear range %s-%s is not within %s",
+				cr.StartKey, cr.EndKey, userSpan)
+		}
+		if err := storage.MVCCGarbageCollectExpiredRange(ctx, readWriter, cArgs.Stats,
+			cr.StartKey, cr.EndKey, cArgs.EvalCtx.GetGCThreshold(), h.Timestamp); err != nil {
+			return result.Result{}, err
+		}
+	}
+
 	desc := cArgs.EvalCtx.Desc()
 
 	if cr := args.ClearRange; cr != nil {
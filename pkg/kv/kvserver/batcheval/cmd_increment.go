@@ -33,9 +33,11 @@ func Increment(
 	reply := resp.(*kvpb.IncrementResponse)
 
 	opts := storage.MVCCWriteOptions{
-		Txn:            h.Txn,
-		LocalTimestamp: cArgs.Now,
-		Stats:          cArgs.Stats,
+		Txn:             h.Txn,
+		LocalTimestamp:  cArgs.Now,
+		Stats:           cArgs.Stats,
+		OriginID:        h.OriginID(),
+		OriginTimestamp: h.OriginTimestamp(),
 	}
 
 	var err error
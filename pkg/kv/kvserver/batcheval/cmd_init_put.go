@@ -37,9 +37,11 @@ func InitPut(
 	}
 
 	opts := storage.MVCCWriteOptions{
-		Txn:            h.Txn,
-		LocalTimestamp: cArgs.Now,
-		Stats:          cArgs.Stats,
+		Txn:             h.Txn,
+		LocalTimestamp:  cArgs.Now,
+		Stats:           cArgs.Stats,
+		OriginID:        h.OriginID(),
+		OriginTimestamp: h.OriginTimestamp(),
 	}
 
 	var err error
@@ -55,9 +55,11 @@ func Put(
 	}
 
 	opts := storage.MVCCWriteOptions{
-		Txn:            h.Txn,
-		LocalTimestamp: cArgs.Now,
-		Stats:          cArgs.Stats,
+		Txn:             h.Txn,
+		LocalTimestamp:  cArgs.Now,
+		Stats:           cArgs.Stats,
+		OriginID:        h.OriginID(),
+		OriginTimestamp: h.OriginTimestamp(),
 	}
 
 	var err error
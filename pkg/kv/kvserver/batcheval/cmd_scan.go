@@ -66,12 +66,26 @@ func Scan(
 		}
 		reply.BatchResponses = scanRes.KVData
 	case kvpb.COL_BATCH_RESPONSE:
-		scanRes, err = storage.MVCCScanToCols(
-			ctx, reader, cArgs.Header.IndexFetchSpec, args.Key, args.EndKey,
-			h.Timestamp, opts, cArgs.EvalCtx.ClusterSettings(),
-		)
-		if err != nil {
-			return result.Result{}, err
+		// Read the rows from the columnar blocks of the range, if it has any that
+		// can serve the scan, and fall back to decoding the KVs otherwise.
+		var scannedBlocks bool
+		if blocks := cArgs.EvalCtx.GetColumnarBlocks(ctx, cArgs.Header.IndexFetchSpec); blocks != nil {
+			scanRes, scannedBlocks, err = storage.MVCCScanColumnarBlocks(
+				ctx, reader, blocks, cArgs.Header.IndexFetchSpec, args.Key, args.EndKey,
+				h.Timestamp, opts, cArgs.EvalCtx.ClusterSettings(),
+			)
+			if err != nil {
+				return result.Result{}, err
+			}
+		}
+		if !scannedBlocks {
+			scanRes, err = storage.MVCCScanToCols(
+				ctx, reader, cArgs.Header.IndexFetchSpec, args.Key, args.EndKey,
+				h.Timestamp, opts, cArgs.EvalCtx.ClusterSettings(),
+			)
+			if err != nil {
+				return result.Result{}, err
+			}
 		}
 		if len(scanRes.ColBatches) > 0 {
 			reply.ColBatches.ColBatches = scanRes.ColBatches
@@ -22,6 +22,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/readsummary/rspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/limit"
@@ -130,6 +132,11 @@ type EvalContext interface {
 	// as an unlimited account).
 	GetResponseMemoryAccount() *mon.BoundAccount
 
+	// GetColumnarBlocks returns the columnar blocks of the range for the index
+	// of the given spec, or nil if there are none which contain its fetched
+	// columns. See storage.ColumnarBlocks.
+	GetColumnarBlocks(ctx context.Context, spec *fetchpb.IndexFetchSpec) *storage.ColumnarBlocks
+
 	GetMaxBytes() int64
 
 	// GetEngineCapacity returns the store's underlying engine capacity; other
@@ -305,6 +312,11 @@ func (m *mockEvalCtxImpl) GetResponseMemoryAccount() *mon.BoundAccount {
 	// No limits.
 	return nil
 }
+func (m *mockEvalCtxImpl) GetColumnarBlocks(
+	context.Context, *fetchpb.IndexFetchSpec,
+) *storage.ColumnarBlocks {
+	return nil
+}
 func (m *mockEvalCtxImpl) GetMaxBytes() int64 {
 	if m.MaxBytes != 0 {
 		return m.MaxBytes
@@ -0,0 +1,225 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/spanconfig"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// coldTierQueueTimerDuration is the duration between moves of queued
+	// replicas to the cold storage tier.
+	coldTierQueueTimerDuration = time.Second
+
+	// coldTierMinLocalBytes is the amount of replica data that must be stored
+	// on local disk before a cold replica is moved to the cold storage tier.
+	// Writes to a moved replica are stored locally, so this avoids repeatedly
+	// moving a cold replica that receives the occasional write.
+	coldTierMinLocalBytes = 1 << 20 // 1 MiB
+)
+
+// coldTierQueue manages a queue of replicas whose span config places them on
+// the cold storage tier, either explicitly or because they have not been
+// written to for long enough. Processing a replica moves its data from the
+// local disk to the store's cold storage tier (see storage.WriteColdSST).
+//
+// Every replica of a range is moved independently by its own store, so the
+// queue does not require the lease. Replicas at the same applied index have
+// the same data, so they usually end up sharing a single sstable on the cold
+// storage tier.
+type coldTierQueue struct {
+	*baseQueue
+}
+
+var _ queueImpl = &coldTierQueue{}
+
+// newColdTierQueue returns a new instance of coldTierQueue.
+func newColdTierQueue(store *Store) *coldTierQueue {
+	cq := &coldTierQueue{}
+	cq.baseQueue = newBaseQueue(
+		"coldtier", cq, store,
+		queueConfig{
+			maxSize:              defaultQueueMaxSize,
+			needsLease:           false,
+			needsSpanConfigs:     true,
+			acceptsUnsplitRanges: false,
+			successes:            store.metrics.ColdTierQueueSuccesses,
+			failures:             store.metrics.ColdTierQueueFailures,
+			pending:              store.metrics.ColdTierQueuePending,
+			processingNanos:      store.metrics.ColdTierQueueProcessingNanos,
+			disabledConfig:       kvserverbase.ColdTierQueueEnabled,
+		},
+	)
+	return cq
+}
+
+// isCold returns whether the span config places a range that was last written
+// at lastUpdate on the cold storage tier.
+func isCold(conf roachpb.SpanConfig, now hlc.ClockTimestamp, lastUpdate int64) bool {
+	switch conf.StorageTier {
+	case roachpb.StorageTier_COLD:
+		return true
+	case roachpb.StorageTier_HOT:
+		return false
+	}
+	coldAfter := conf.ColdAfter()
+	return coldAfter > 0 && now.WallTime-lastUpdate >= coldAfter.Nanoseconds()
+}
+
+// localBytes returns the number of bytes of the replica's data that are stored
+// on local disk rather than on remote storage.
+func (cq *coldTierQueue) localBytes(repl *Replica) (uint64, error) {
+	span := repl.Desc().KeySpan().AsRawSpanWithNoLocals()
+	total, remote, _, err := repl.store.TODOEngine().ApproximateDiskBytes(span.Key, span.EndKey)
+	if err != nil {
+		return 0, err
+	}
+	if remote > total {
+		return 0, nil
+	}
+	return total - remote, nil
+}
+
+// raftAppliedIndex returns the replica's applied index. The caller must hold
+// raftMu for the result to remain valid.
+func raftAppliedIndex(repl *Replica) kvpb.RaftIndex {
+	repl.mu.RLock()
+	defer repl.mu.RUnlock()
+	return repl.mu.state.RaftAppliedIndex
+}
+
+func (cq *coldTierQueue) shouldQueue(
+	ctx context.Context, now hlc.ClockTimestamp, repl *Replica, _ spanconfig.StoreReader,
+) (shouldQueue bool, priority float64) {
+	if !repl.store.TODOEngine().ColdStorageEnabled() {
+		return false, 0
+	}
+	if !isCold(repl.SpanConfig(), now, repl.GetMVCCStats().LastUpdateNanos) {
+		return false, 0
+	}
+	local, err := cq.localBytes(repl)
+	if err != nil {
+		log.VErrEventf(ctx, 2, "failed to compute local bytes: %v", err)
+		return false, 0
+	}
+	if local < coldTierMinLocalBytes {
+		return false, 0
+	}
+	// Move the replicas holding the most local data first.
+	return true, float64(local)
+}
+
+func (cq *coldTierQueue) process(
+	ctx context.Context, repl *Replica, _ spanconfig.StoreReader,
+) (processed bool, _ error) {
+	eng := repl.store.TODOEngine()
+	if !eng.ColdStorageEnabled() {
+		return false, nil
+	}
+	now := repl.store.Clock().NowAsClockTimestamp()
+	if !isCold(repl.SpanConfig(), now, repl.GetMVCCStats().LastUpdateNanos) {
+		return false, nil
+	}
+
+	// Capture the replica's data at its current applied index. The sstable is
+	// written to the cold storage tier without holding raftMu, and is only
+	// ingested if nothing was applied to the replica in the meantime, since
+	// ingesting it excises all local data in the replica's span.
+	repl.raftMu.Lock()
+	desc := repl.Desc()
+	appliedIndex := raftAppliedIndex(repl)
+	snap := eng.NewSnapshot()
+	repl.raftMu.Unlock()
+
+	span := desc.KeySpan().AsRawSpanWithNoLocals()
+	sst, err := eng.WriteColdSST(ctx, snap, span)
+	snap.Close()
+	if err != nil {
+		return false, errors.Wrapf(err, "writing %s to cold storage", span)
+	}
+	if sst.Empty() {
+		return false, nil
+	}
+
+	ingested, err := func() (bool, error) {
+		repl.raftMu.Lock()
+		defer repl.raftMu.Unlock()
+		if _, err := repl.IsDestroyed(); err != nil {
+			return false, nil //nolint:returnerrcheck
+		}
+		if raftAppliedIndex(repl) != appliedIndex {
+			return false, nil
+		}
+		return true, eng.IngestColdSST(ctx, sst, span)
+	}()
+	if !ingested || err != nil {
+		if relErr := sst.Release(ctx); relErr != nil {
+			log.Warningf(ctx, "failed to release %s on cold storage: %v", sst.ObjName, relErr)
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "ingesting %s from cold storage", span)
+		}
+		log.VEventf(ctx, 2, "replica changed while writing %s to cold storage; retrying later", span)
+		return false, nil
+	}
+	if sst.Reused {
+		log.VEventf(ctx, 1, "moved %s to cold storage, reusing %s", span, sst.ObjName)
+		return true, nil
+	}
+	repl.store.metrics.ColdTierQueueMovedBytes.Inc(sst.Size())
+	log.VEventf(ctx, 1, "moved %s to cold storage (%d bytes)", span, sst.Size())
+	return true, nil
+}
+
+// releaseColdStorage asynchronously drops the store's references to the
+// sstables on the cold storage tier that backed the data of a removed replica,
+// which are otherwise only dropped once compactions reach the replica's span.
+func (s *Store) releaseColdStorage(desc *roachpb.RangeDescriptor) {
+	eng := s.TODOEngine()
+	if !eng.ColdStorageEnabled() {
+		return
+	}
+	span := desc.KeySpan().AsRawSpanWithNoLocals()
+	ctx := s.AnnotateCtx(context.Background())
+	if err := s.stopper.RunAsyncTask(ctx, "release-cold-storage", func(ctx context.Context) {
+		if err := eng.ReleaseColdStorage(ctx, span); err != nil {
+			log.Warningf(ctx, "failed to release cold storage of %s: %v", span, err)
+		}
+	}); err != nil {
+		log.VEventf(ctx, 2, "not releasing cold storage of %s: %v", span, err)
+	}
+}
+
+func (*coldTierQueue) postProcessScheduled(
+	ctx context.Context, replica replicaInQueue, priority float64,
+) {
+}
+
+func (*coldTierQueue) timer(_ time.Duration) time.Duration {
+	return coldTierQueueTimerDuration
+}
+
+func (*coldTierQueue) purgatoryChan() <-chan time.Time {
+	return nil
+}
+
+func (*coldTierQueue) updateChan() <-chan time.Time {
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCold(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	now := hlc.ClockTimestamp{WallTime: (10 * time.Hour).Nanoseconds()}
+	hourAgo := now.WallTime - time.Hour.Nanoseconds()
+	for _, tc := range []struct {
+		name       string
+		tier       roachpb.StorageTier
+		coldAfter  time.Duration
+		lastUpdate int64
+		expected   bool
+	}{
+		{name: "default", tier: roachpb.StorageTier_DEFAULT_TIER, lastUpdate: 0, expected: false},
+		{name: "cold", tier: roachpb.StorageTier_COLD, lastUpdate: now.WallTime, expected: true},
+		{name: "hot", tier: roachpb.StorageTier_HOT, coldAfter: time.Minute, lastUpdate: 0, expected: false},
+		{name: "recently written", coldAfter: 2 * time.Hour, lastUpdate: hourAgo, expected: false},
+		{name: "not recently written", coldAfter: time.Hour, lastUpdate: hourAgo, expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := roachpb.SpanConfig{
+				StorageTier:                 tc.tier,
+				StorageTierColdAfterSeconds: int32(tc.coldAfter.Seconds()),
+			}
+			require.Equal(t, tc.expected, isCold(conf, now, tc.lastUpdate))
+		})
+	}
+}
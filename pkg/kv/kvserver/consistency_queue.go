@@ -14,6 +14,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
@@ -35,6 +36,25 @@ var consistencyCheckInterval = settings.RegisterDurationSetting(
 	settings.NonNegativeDuration,
 )
 
+var consistencyCheckIncrementalInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"server.consistency_check.incremental.interval",
+	"the time between incremental range consistency checks, which only check the data"+
+		" written since the previous incremental check of the range, in between the"+
+		" full checks; set to 0 to disable incremental consistency checking",
+	0,
+	settings.NonNegativeDuration,
+)
+
+var consistencyCheckIncrementalQuarantine = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"server.consistency_check.incremental.quarantine.enabled",
+	"if enabled, an inconsistency found by an incremental consistency check"+
+		" immediately triggers a full consistency check, which checkpoints the"+
+		" replicas and terminates the nodes with the diverged replicas",
+	false,
+)
+
 var consistencyCheckRate = settings.RegisterByteSizeSetting(
 	settings.SystemOnly,
 	"server.consistency_check.max_rate",
@@ -63,12 +83,20 @@ const consistencyCheckRateMinWait = 100 * time.Millisecond
 // before giving up.
 const consistencyCheckSyncTimeout = 5 * time.Second
 
+// consistencyQueueIncrementalName is the name under which the last processed
+// timestamp of the incremental consistency checks is stored for each range.
+// Unlike that of the full checks, it's the closed timestamp of the range at the
+// time of the last check, full or incremental, and the start timestamp of the
+// next incremental check.
+const consistencyQueueIncrementalName = "consistencyChecker-incremental"
+
 var testingAggressiveConsistencyChecks = envutil.EnvOrDefaultBool("COCKROACH_CONSISTENCY_AGGRESSIVE", false)
 
 type consistencyQueue struct {
 	*baseQueue
-	interval       func() time.Duration
-	replicaCountFn func() int
+	interval            func() time.Duration
+	incrementalInterval func() time.Duration
+	replicaCountFn      func() int
 }
 
 var _ queueImpl = &consistencyQueue{}
@@ -80,6 +108,10 @@ type consistencyShouldQueueData struct {
 	isNodeAvailable           func(nodeID roachpb.NodeID) bool
 	disableLastProcessedCheck bool
 	interval                  time.Duration
+	// getIncrementalLastProcessed and incrementalInterval are used to queue
+	// incremental checks. They're ignored if incrementalInterval is 0.
+	getIncrementalLastProcessed func(ctx context.Context) (hlc.Timestamp, error)
+	incrementalInterval         time.Duration
 }
 
 // newConsistencyQueue returns a new instance of consistencyQueue.
@@ -88,6 +120,13 @@ func newConsistencyQueue(store *Store) *consistencyQueue {
 		interval: func() time.Duration {
 			return consistencyCheckInterval.Get(&store.ClusterSettings().SV)
 		},
+		incrementalInterval: func() time.Duration {
+			st := store.ClusterSettings()
+			if !st.Version.IsActive(context.TODO(), clusterversion.V23_2_IncrementalConsistencyChecks) {
+				return 0
+			}
+			return consistencyCheckIncrementalInterval.Get(&st.SV)
+		},
 		replicaCountFn: store.ReplicaCount,
 	}
 	q.baseQueue = newBaseQueue(
@@ -126,6 +165,10 @@ func (q *consistencyQueue) shouldQueue(
 			},
 			disableLastProcessedCheck: repl.store.cfg.TestingKnobs.DisableLastProcessedCheck,
 			interval:                  q.interval(),
+			getIncrementalLastProcessed: func(ctx context.Context) (hlc.Timestamp, error) {
+				return repl.getQueueLastProcessed(ctx, consistencyQueueIncrementalName)
+			},
+			incrementalInterval: q.incrementalInterval(),
 		})
 }
 
@@ -134,17 +177,28 @@ func (q *consistencyQueue) shouldQueue(
 func consistencyQueueShouldQueueImpl(
 	ctx context.Context, now hlc.ClockTimestamp, data consistencyShouldQueueData,
 ) (bool, float64) {
-	if data.interval <= 0 {
+	if data.interval <= 0 && data.incrementalInterval <= 0 {
 		return false, 0
 	}
 
 	shouldQ, priority := true, float64(0)
 	if !data.disableLastProcessedCheck {
-		lpTS, err := data.getQueueLastProcessed(ctx)
-		if err != nil {
-			return false, 0
+		shouldQ = false
+		if data.interval > 0 {
+			lpTS, err := data.getQueueLastProcessed(ctx)
+			if err != nil {
+				return false, 0
+			}
+			shouldQ, priority = shouldQueueAgain(now.ToTimestamp(), lpTS, data.interval)
+		}
+		if !shouldQ && data.incrementalInterval > 0 {
+			lpTS, err := data.getIncrementalLastProcessed(ctx)
+			if err != nil {
+				return false, 0
+			}
+			shouldQ, priority = shouldQueueAgain(now.ToTimestamp(), lpTS, data.incrementalInterval)
 		}
-		if shouldQ, priority = shouldQueueAgain(now.ToTimestamp(), lpTS, data.interval); !shouldQ {
+		if !shouldQ {
 			return false, 0
 		}
 	}
@@ -161,9 +215,13 @@ func consistencyQueueShouldQueueImpl(
 func (q *consistencyQueue) process(
 	ctx context.Context, repl *Replica, _ spanconfig.StoreReader,
 ) (bool, error) {
-	if q.interval() <= 0 {
+	interval := q.interval()
+	if interval <= 0 && q.incrementalInterval() <= 0 {
 		return false, nil
 	}
+	if q.incrementalInterval() > 0 && !q.fullCheckDue(ctx, repl, interval) {
+		return q.processIncremental(ctx, repl)
+	}
 
 	// Call setQueueLastProcessed because the consistency checker targets a much
 	// longer cycle time than other queues. That it ignores errors is likely a
@@ -171,6 +229,14 @@ func (q *consistencyQueue) process(
 	if err := repl.setQueueLastProcessed(ctx, q.name, repl.store.Clock().Now()); err != nil {
 		log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
 	}
+	// The full check covers all the data at or below the current closed
+	// timestamp, so the next incremental check can start there. It's only
+	// recorded once the check succeeded, so that a failed check doesn't cause
+	// incremental checks to skip data.
+	var closedTS hlc.Timestamp
+	if q.incrementalInterval() > 0 {
+		closedTS = repl.GetCurrentClosedTimestamp(ctx)
+	}
 
 	req := kvpb.CheckConsistencyRequest{
 		// Tell CheckConsistency that the caller is the queue. This triggers
@@ -184,28 +250,121 @@ func (q *consistencyQueue) process(
 	}
 	resp, pErr := repl.CheckConsistency(ctx, req)
 	if pErr != nil {
-		var shouldQuiesce bool
-		select {
-		case <-repl.store.Stopper().ShouldQuiesce():
-			shouldQuiesce = true
-		default:
+		return false, q.checkError(ctx, repl, pErr)
+	}
+	if !closedTS.IsEmpty() {
+		if err := repl.setQueueLastProcessed(ctx, consistencyQueueIncrementalName, closedTS); err != nil {
+			log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
 		}
+	}
+	if fn := repl.store.cfg.TestingKnobs.ConsistencyTestingKnobs.ConsistencyQueueResultHook; fn != nil {
+		fn(resp)
+	}
+	return true, nil
+}
 
-		if shouldQuiesce && grpcutil.IsClosedConnection(pErr.GoError()) {
-			// Suppress noisy errors about closed GRPC connections when the
-			// server is quiescing.
-			return false, nil
-		}
-		err := pErr.GoError()
-		log.Errorf(ctx, "%v", err)
+// fullCheckDue returns whether a full consistency check of the replica is due,
+// i.e. whether it should be processed with a full check rather than an
+// incremental one.
+func (q *consistencyQueue) fullCheckDue(
+	ctx context.Context, repl *Replica, interval time.Duration,
+) bool {
+	if interval <= 0 {
+		return false
+	}
+	lpTS, err := repl.getQueueLastProcessed(ctx, q.name)
+	if err != nil {
+		// Fall back to a full check, like the queue always did.
+		return true
+	}
+	due, _ := shouldQueueAgain(repl.store.Clock().Now(), lpTS, interval)
+	return due
+}
+
+// processIncremental runs an incremental consistency check on the replica,
+// which checks only the data written since the previous check, full or
+// incremental.
+func (q *consistencyQueue) processIncremental(
+	ctx context.Context, repl *Replica,
+) (bool, error) {
+	start, err := repl.getQueueLastProcessed(ctx, consistencyQueueIncrementalName)
+	if err != nil {
 		return false, err
 	}
+	// All the data at or below the closed timestamp has been applied, and no
+	// more data can be written below it. So the next check can start there, and
+	// still cover all the data written after this one. Note that this doesn't
+	// hold for the data ingested by non-MVCC operations at historical timestamps
+	// (e.g. AddSSTable for IMPORT). That data is only covered by full checks.
+	next := repl.GetCurrentClosedTimestamp(ctx)
+	if start.IsEmpty() {
+		// No check recorded the closed timestamp it covered, e.g. because the
+		// last full check of the range ran before incremental checks were
+		// enabled. There is no previous check to start at, so only establish the
+		// start of the next check. Note that the wall time of the last full check
+		// can't be used instead: data can still be written below it, above the
+		// closed timestamp at the time, after the check ran.
+		start = next
+	}
+
+	resp, pErr := repl.CheckConsistency(ctx, kvpb.CheckConsistencyRequest{
+		Mode:             kvpb.ChecksumMode_CHECK_INCREMENTAL,
+		IncrementalStart: start,
+	})
+	if pErr != nil {
+		return false, q.checkError(ctx, repl, pErr)
+	}
+	if err := repl.setQueueLastProcessed(ctx, consistencyQueueIncrementalName, next); err != nil {
+		log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
+	}
 	if fn := repl.store.cfg.TestingKnobs.ConsistencyTestingKnobs.ConsistencyQueueResultHook; fn != nil {
 		fn(resp)
 	}
+
+	for _, res := range resp.Result {
+		if res.Status != kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT {
+			continue
+		}
+		log.Errorf(ctx, "incremental consistency check found an inconsistency above %s in %v:\n%s",
+			start, res.DivergingSpans, res.Detail)
+		if !consistencyCheckIncrementalQuarantine.Get(&repl.ClusterSettings().SV) {
+			continue
+		}
+		// Quarantine the diverged replicas. The full check run via the queue
+		// confirms the inconsistency, checkpoints the replicas, and terminates the
+		// nodes of the replicas in the minority.
+		if err := repl.setQueueLastProcessed(ctx, q.name, repl.store.Clock().Now()); err != nil {
+			log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
+		}
+		if _, pErr := repl.CheckConsistency(ctx, kvpb.CheckConsistencyRequest{
+			Mode: kvpb.ChecksumMode_CHECK_VIA_QUEUE,
+		}); pErr != nil {
+			return false, q.checkError(ctx, repl, pErr)
+		}
+	}
 	return true, nil
 }
 
+// checkError returns the error to fail the processing of the replica with,
+// given the error of its consistency check.
+func (q *consistencyQueue) checkError(ctx context.Context, repl *Replica, pErr *kvpb.Error) error {
+	var shouldQuiesce bool
+	select {
+	case <-repl.store.Stopper().ShouldQuiesce():
+		shouldQuiesce = true
+	default:
+	}
+
+	if shouldQuiesce && grpcutil.IsClosedConnection(pErr.GoError()) {
+		// Suppress noisy errors about closed GRPC connections when the
+		// server is quiescing.
+		return nil
+	}
+	err := pErr.GoError()
+	log.Errorf(ctx, "%v", err)
+	return err
+}
+
 func (*consistencyQueue) postProcessScheduled(
 	ctx context.Context, replica replicaInQueue, priority float64,
 ) {
@@ -218,7 +377,11 @@ func (q *consistencyQueue) timer(duration time.Duration) time.Duration {
 	if replicaCount == 0 {
 		return 0
 	}
-	replInterval := q.interval() / time.Duration(replicaCount)
+	interval := q.interval()
+	if incremental := q.incrementalInterval(); incremental > 0 && (interval <= 0 || incremental < interval) {
+		interval = incremental
+	}
+	replInterval := interval / time.Duration(replicaCount)
 	if replInterval < duration {
 		return 0
 	}
@@ -95,6 +95,58 @@ func TestConsistencyQueueRequiresLive(t *testing.T) {
 	}
 }
 
+// TestConsistencyQueueShouldQueueIncremental verifies that the queue processes
+// ranges whose incremental check is due, even if their full check isn't.
+func TestConsistencyQueueShouldQueueIncremental(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	manualClock := timeutil.NewManualTime(timeutil.Unix(0, 123))
+	clock := hlc.NewClockForTesting(manualClock)
+	const interval, incrementalInterval = 24 * time.Hour, time.Minute
+
+	desc := &roachpb.RangeDescriptor{
+		InternalReplicas: []roachpb.ReplicaDescriptor{
+			{NodeID: 1, StoreID: 1, ReplicaID: 1},
+			{NodeID: 2, StoreID: 2, ReplicaID: 2},
+			{NodeID: 3, StoreID: 3, ReplicaID: 3},
+		},
+	}
+	lastFull, lastIncremental := clock.Now(), hlc.Timestamp{}
+	getLastFull := func(ctx context.Context) (hlc.Timestamp, error) {
+		return lastFull, nil
+	}
+	getLastIncremental := func(ctx context.Context) (hlc.Timestamp, error) {
+		return lastIncremental, nil
+	}
+	isNodeAvailable := func(nodeID roachpb.NodeID) bool {
+		return true
+	}
+	shouldQueue := func(incrementalInterval time.Duration) bool {
+		shouldQ, _ := kvserver.ConsistencyQueueShouldQueueIncremental(ctx, clock.NowAsClockTimestamp(),
+			desc, getLastFull, getLastIncremental, isNodeAvailable, interval, incrementalInterval)
+		return shouldQ
+	}
+
+	// The full check isn't due, but the range has never been checked
+	// incrementally.
+	manualClock.Advance(time.Second)
+	require.True(t, shouldQueue(incrementalInterval))
+	require.False(t, shouldQueue(0))
+
+	// The incremental check isn't due until its interval elapses.
+	lastIncremental = clock.Now()
+	require.False(t, shouldQueue(incrementalInterval))
+	manualClock.Advance(incrementalInterval)
+	require.True(t, shouldQueue(incrementalInterval))
+
+	// The full check is due regardless of the incremental checks.
+	manualClock.Advance(interval)
+	lastIncremental = clock.Now()
+	require.True(t, shouldQueue(incrementalInterval))
+}
+
 // TestCheckConsistencyMultiStore creates a node with three stores
 // with three way replication. A consistency check is run on r1,
 // which always has some data, and on a newly split off range
@@ -425,6 +477,109 @@ func TestCheckConsistencyInconsistent(t *testing.T) {
 	require.NotEmpty(t, b)
 }
 
+// TestCheckConsistencyIncremental verifies that an incremental consistency
+// check only covers the data written above its start timestamp, and reports
+// the key spans in which the replicas diverge.
+func TestCheckConsistencyIncremental(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := testcluster.StartTestCluster(t, 3,
+		base.TestClusterArgs{
+			ReplicationMode: base.ReplicationManual,
+			ServerArgs: base.TestServerArgs{
+				Knobs: base.TestingKnobs{
+					Store: &kvserver.StoreTestingKnobs{
+						DisableConsistencyQueue: true,
+					},
+				},
+			},
+		},
+	)
+	defer tc.Stopper().Stop(ctx)
+
+	key := tc.ScratchRange(t)
+	tc.AddVotersOrFatal(t, key, tc.Targets(1, 2)...)
+	store := tc.GetFirstStoreFromServer(t, 0)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, store.DB().Put(ctx, append(key.Clone(), byte('a'+i)), "v"))
+	}
+
+	runCheck := func(start hlc.Timestamp) kvpb.CheckConsistencyResponse_Result {
+		req := kvpb.CheckConsistencyRequest{
+			RequestHeader:    kvpb.RequestHeader{Key: key, EndKey: key.Next()},
+			Mode:             kvpb.ChecksumMode_CHECK_INCREMENTAL,
+			IncrementalStart: start,
+		}
+		resp, pErr := kv.SendWrapped(ctx, store.DB().NonTransactionalSender(), &req)
+		require.NoError(t, pErr.GoError())
+		results := resp.(*kvpb.CheckConsistencyResponse).Result
+		require.Len(t, results, 1)
+		return results[0]
+	}
+	res := runCheck(hlc.Timestamp{})
+	require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_CONSISTENT, res.Status, res.Detail)
+
+	// Write a key only to s2, below the start timestamp of the next check. The
+	// incremental check doesn't detect the divergence.
+	s2 := tc.GetFirstStoreFromServer(t, 1)
+	var val roachpb.Value
+	val.SetInt(42)
+	require.NoError(t, storage.MVCCPut(ctx, s2.TODOEngine(), append(key.Clone(), "old"...),
+		tc.Server(0).Clock().Now(), val, storage.MVCCWriteOptions{}))
+	start := tc.Server(0).Clock().Now()
+	res = runCheck(start)
+	require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_CONSISTENT, res.Status, res.Detail)
+	require.Empty(t, res.DivergingSpans)
+
+	// Write a key only to s2 above the start timestamp. The incremental check
+	// detects the divergence, and pins it down to the key.
+	diverged := append(key.Clone(), "new"...)
+	require.NoError(t, storage.MVCCPut(ctx, s2.TODOEngine(), diverged,
+		tc.Server(0).Clock().Now(), val, storage.MVCCWriteOptions{}))
+	res = runCheck(start)
+	require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT, res.Status, res.Detail)
+	require.Contains(t, res.Detail, "[minority]")
+	require.Equal(t, []roachpb.Span{{Key: diverged, EndKey: diverged.Next()}}, res.DivergingSpans)
+}
+
+// TestConsistencyQueueRecordsIncrementalStart verifies that a full consistency
+// check run by the queue records the closed timestamp it covered, at which the
+// first incremental check of the range starts.
+func TestConsistencyQueueRecordsIncrementalStart(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := testcluster.StartTestCluster(t, 1, base.TestClusterArgs{
+		ReplicationMode: base.ReplicationManual,
+	})
+	defer tc.Stopper().Stop(ctx)
+	_, err := tc.ServerConn(0).Exec(`SET CLUSTER SETTING server.consistency_check.incremental.interval = '1h'`)
+	require.NoError(t, err)
+
+	key := tc.ScratchRange(t)
+	store := tc.GetFirstStoreFromServer(t, 0)
+	repl := store.LookupReplica(roachpb.RKey(key))
+	testutils.SucceedsSoon(t, func() error {
+		if repl.GetCurrentClosedTimestamp(ctx).IsEmpty() {
+			return errors.New("range has no closed timestamp yet")
+		}
+		return nil
+	})
+	require.NoError(t, store.ForceConsistencyQueueProcess())
+
+	lastFull, err := repl.GetQueueLastProcessed(ctx, "consistencyChecker")
+	require.NoError(t, err)
+	require.False(t, lastFull.IsEmpty())
+	start, err := repl.GetQueueLastProcessed(ctx, "consistencyChecker-incremental")
+	require.NoError(t, err)
+	require.False(t, start.IsEmpty())
+	// The closed timestamp lags behind the time of the full check.
+	require.True(t, start.Less(lastFull), "%s not below %s", start, lastFull)
+}
+
 // TestConsistencyQueueRecomputeStats is an end-to-end test of the mechanism CockroachDB
 // employs to adjust incorrect MVCCStats ("incorrect" meaning not an inconsistency of
 // these stats between replicas, but a delta between persisted stats and those one
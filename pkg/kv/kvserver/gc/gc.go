@@ -180,6 +180,9 @@ type PureGCer interface {
 	GC(context.Context, []kvpb.GCRequest_GCKey, []kvpb.GCRequest_GCRangeKey,
 		*kvpb.GCRequest_GCClearRange,
 	) error
+	// GCExpired removes all versions of keys which expired under the range's
+	// storage-level TTL, either the given keys or all keys in the given span.
+	GCExpired(context.Context, []kvpb.GCRequest_GCKey, *kvpb.GCRequest_GCClearRange) error
 }
 
 // A GCer is an abstraction used by the MVCC GC queue to carry out chunked deletions.
@@ -206,6 +209,13 @@ func (NoopGCer) GC(
 	return nil
 }
 
+// GCExpired implements storage.GCer.
+func (NoopGCer) GCExpired(
+	context.Context, []kvpb.GCRequest_GCKey, *kvpb.GCRequest_GCClearRange,
+) error {
+	return nil
+}
+
 // Threshold holds the key and txn span GC thresholds, respectively.
 type Threshold struct {
 	Key hlc.Timestamp
@@ -268,6 +278,12 @@ type Info struct {
 	ClearRangeSpanOperations int
 	// ClearRangeSpanFailures number of ClearRange requests GC failed to perform.
 	ClearRangeSpanFailures int
+	// ExpirationThreshold is the storage-level expiration timestamp, if any.
+	// Keys whose newest version is below it are removed entirely.
+	ExpirationThreshold hlc.Timestamp
+	// NumKeysExpired is the number of keys found to have expired under the
+	// storage-level TTL. All of their versions are removed.
+	NumKeysExpired int
 }
 
 // RunOptions contains collection of limits that GC run applies when performing operations
@@ -307,6 +323,12 @@ type RunOptions struct {
 	// to issuing point delete requests for the oldest batch to free up memory
 	// before resuming further iteration.
 	MaxPendingKeysSize int64
+	// ExpirationThreshold, if set, is the storage-level expiration timestamp:
+	// keys whose newest version is a committed value or tombstone below it
+	// are removed in their entirety, regardless of the GC threshold. The
+	// caller is responsible for ensuring that no protected timestamp record
+	// applies to the range, since expiration changes reads at all timestamps.
+	ExpirationThreshold hlc.Timestamp
 }
 
 // CleanupIntentsFunc synchronously resolves the supplied intents
@@ -347,9 +369,10 @@ func Run(
 	}
 
 	info := Info{
-		GCTTL:     gcTTL,
-		Now:       now,
-		Threshold: newThreshold,
+		GCTTL:               gcTTL,
+		Now:                 now,
+		Threshold:           newThreshold,
+		ExpirationThreshold: options.ExpirationThreshold,
 	}
 
 	fastPath, err := processReplicatedKeyRange(ctx, desc, snap, now, newThreshold, options.IntentAgeThreshold,
@@ -368,6 +391,14 @@ func Run(
 	if err != nil {
 		return Info{}, err
 	}
+	if !options.ExpirationThreshold.IsEmpty() && !fastPath {
+		batcherOptions := populateBatcherOptions(options)
+		err = processExpiredKeys(ctx, desc, snap, options.ExpirationThreshold,
+			batcherOptions.batchGCKeysBytesThreshold, batcherOptions.clearRangeMinKeys, gcer, &info)
+		if err != nil {
+			return Info{}, err
+		}
+	}
 
 	// From now on, all keys processed are range-local and inline (zero timestamp).
 
@@ -1376,6 +1407,143 @@ func processReplicatedRangeTombstones(
 	return b.flushPendingFragments(ctx)
 }
 
+// processExpiredKeys removes keys that have expired under the storage-level
+// TTL, i.e. user keys whose newest version is a committed value or tombstone
+// with a timestamp below expirationThreshold. Keys with intents or covered by
+// MVCC range keys are skipped; they will be reconsidered once the intent is
+// resolved or the range key is GC'ed.
+//
+// Expiration is performed in a separate pass after regular GC. The requests
+// are independent: regular GC never removes the newest version of a live key,
+// and expiration of a key that was since GC'ed or written to is a no-op.
+//
+// Runs of at least clearRangeMinKeys consecutive expired keys are removed
+// with a single Pebble range tombstone rather than a point tombstone per
+// version, so that compactions drop the expired data without the GC queue
+// having to write it all out again. Shorter runs are removed key by key.
+// A value of 0 disables the use of range tombstones.
+func processExpiredKeys(
+	ctx context.Context,
+	desc *roachpb.RangeDescriptor,
+	snap storage.Reader,
+	expirationThreshold hlc.Timestamp,
+	batchBytesThreshold int64,
+	clearRangeMinKeys int,
+	gcer PureGCer,
+	info *Info,
+) error {
+	userSpan := desc.KeySpan().AsRawSpanWithNoLocals()
+	iter := snap.NewMVCCIterator(storage.MVCCKeyAndIntentsIterKind, storage.IterOptions{
+		LowerBound: userSpan.Key,
+		UpperBound: userSpan.EndKey,
+		KeyTypes:   storage.IterKeyTypePointsAndRanges,
+	})
+	defer iter.Close()
+
+	var alloc bufalloc.ByteAllocator
+	var batch []kvpb.GCRequest_GCKey
+	var batchBytes int64
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := gcer.GCExpired(ctx, batch, nil)
+		batch, batchBytes, alloc = nil, 0, nil
+		return err
+	}
+	addKey := func(k kvpb.GCRequest_GCKey) error {
+		batch = append(batch, k)
+		batchBytes += int64(len(k.Key)) + storage.MVCCVersionTimestampSize
+		if batchBytes >= batchBytesThreshold {
+			return flush()
+		}
+		return nil
+	}
+
+	// run is the current run of consecutive expired keys. Its keys are only
+	// buffered until it's long enough to be removed with a range tombstone.
+	var run []kvpb.GCRequest_GCKey
+	var runLen int
+	var runStart, runLast roachpb.Key
+	endRun := func() error {
+		defer func() {
+			run, runLen, runStart, runLast = run[:0], 0, nil, nil
+		}()
+		if clearRangeMinKeys == 0 || runLen < clearRangeMinKeys {
+			for _, k := range run {
+				if err := addKey(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if err := gcer.GCExpired(ctx, nil, &kvpb.GCRequest_GCClearRange{
+			StartKey: runStart,
+			EndKey:   runLast.Next(),
+		}); err != nil {
+			if errors.Is(err, ctx.Err()) {
+				return err
+			}
+			// The keys may have been written to since the snapshot was taken. They
+			// will be reconsidered by the next GC run.
+			log.Warningf(ctx, "failed to expire keys with clear range: %v", err)
+			info.ClearRangeSpanFailures++
+			return nil
+		}
+		info.ClearRangeSpanOperations++
+		return nil
+	}
+
+	// The first point key of each key is either the metadata of an intent or
+	// inline value, which makes the key ineligible, or its newest version.
+	for iter.SeekGE(storage.MVCCKey{Key: userSpan.Key}); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		hasPoint, hasRange := iter.HasPointAndRange()
+		if !hasPoint {
+			// A bare range key start; the point keys it covers are visited next.
+			continue
+		}
+		newest := iter.UnsafeKey()
+		if hasRange || !newest.IsValue() || expirationThreshold.LessEq(newest.Timestamp) {
+			if err := endRun(); err != nil {
+				return err
+			}
+			continue
+		}
+		var key roachpb.Key
+		alloc, key = alloc.Copy(newest.Key, 0)
+		if runLen == 0 {
+			runStart = key
+		}
+		runLast = key
+		runLen++
+		info.NumKeysExpired++
+		k := kvpb.GCRequest_GCKey{Key: key, Timestamp: newest.Timestamp}
+		switch {
+		case clearRangeMinKeys == 0:
+			if err := addKey(k); err != nil {
+				return err
+			}
+		case runLen < clearRangeMinKeys:
+			run = append(run, k)
+		default:
+			// The run will be removed with a range tombstone.
+			run = run[:0]
+		}
+	}
+	if err := endRun(); err != nil {
+		return err
+	}
+	return flush()
+}
+
 // batchingInlineGCer is a helper to paginate the GC of inline (i.e. zero
 // timestamp keys). After creation, keys are added via FlushingAdd(). A
 // final call to Flush() empties out the buffer when all keys were added.
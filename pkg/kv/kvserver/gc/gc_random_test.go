@@ -811,6 +811,8 @@ type fakeGCer struct {
 	// non-overlapping.
 	gcRangeKeyBatches [][]kvpb.GCRequest_GCRangeKey
 	gcClearRanges     []kvpb.GCRequest_GCClearRange
+	gcExpiredKeys     []kvpb.GCRequest_GCKey
+	gcExpiredRanges   []kvpb.GCRequest_GCClearRange
 	threshold         Threshold
 	intents           []roachpb.Intent
 	batches           [][]roachpb.Intent
@@ -855,6 +857,19 @@ func (f *fakeGCer) GC(
 	return nil
 }
 
+func (f *fakeGCer) GCExpired(
+	ctx context.Context, keys []kvpb.GCRequest_GCKey, clearRange *kvpb.GCRequest_GCClearRange,
+) error {
+	f.gcExpiredKeys = append(f.gcExpiredKeys, keys...)
+	if clearRange != nil {
+		f.gcExpiredRanges = append(f.gcExpiredRanges, kvpb.GCRequest_GCClearRange{
+			StartKey: clearRange.StartKey.Clone(),
+			EndKey:   clearRange.EndKey.Clone(),
+		})
+	}
+	return nil
+}
+
 func (f *fakeGCer) resolveIntentsAsync(_ context.Context, txn *roachpb.Transaction) error {
 	f.txnIntents = append(f.txnIntents, txnIntents{txn: txn, intents: txn.LocksAsLockUpdates()})
 	return nil
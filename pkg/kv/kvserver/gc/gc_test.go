@@ -69,6 +69,12 @@ func (c *collectingGCer) GC(
 	return nil
 }
 
+func (c *collectingGCer) GCExpired(
+	context.Context, []kvpb.GCRequest_GCKey, *kvpb.GCRequest_GCClearRange,
+) error {
+	return nil
+}
+
 func TestBatchingInlineGCer(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -174,6 +180,74 @@ func TestIntentAgeThresholdSetting(t *testing.T) {
 		"Expected 1 intents considered by GC with short threshold")
 }
 
+// TestStorageTTLExpiration verifies that GC removes keys whose newest version
+// is below the storage-level expiration threshold, and only those, clearing
+// long enough runs of them with range tombstones.
+func TestStorageTTLExpiration(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	hlcAt := func(d time.Duration) hlc.Timestamp {
+		return hlc.Timestamp{WallTime: d.Nanoseconds()}
+	}
+	value := roachpb.Value{RawBytes: []byte("0123456789")}
+	put := func(key string, d time.Duration, txn *roachpb.Transaction) {
+		require.NoError(t, storage.MVCCPut(ctx, eng, roachpb.Key(key), hlcAt(d), value,
+			storage.MVCCWriteOptions{Txn: txn}))
+	}
+	// Keys whose newest version is older than 90m have expired.
+	now := 3 * time.Hour
+	storageTTL := 90 * time.Minute
+	put("a", 30*time.Minute, nil)
+	put("a", time.Hour, nil)
+	put("b", time.Hour, nil)
+	put("b", 150*time.Minute, nil)
+	txn := roachpb.MakeTransaction("txn", roachpb.Key("c"), isolation.Serializable,
+		roachpb.NormalUserPriority, hlcAt(time.Hour), 1000, 0)
+	put("c", time.Hour, &txn)
+	put("d", 30*time.Minute, nil)
+	_, err := storage.MVCCDelete(ctx, eng, roachpb.Key("d"), hlcAt(time.Hour), storage.MVCCWriteOptions{})
+	require.NoError(t, err)
+	put("e", time.Hour, nil)
+	put("f", 30*time.Minute, nil)
+	put("g", time.Hour, nil)
+	// Keys covered by MVCC range keys are skipped.
+	put("h", 30*time.Minute, nil)
+	require.NoError(t, storage.MVCCDeleteRangeUsingTombstone(ctx, eng, nil, roachpb.Key("h"),
+		roachpb.Key("i"), hlcAt(time.Hour), hlc.ClockTimestamp{}, nil, nil, false, 0, nil))
+	put("j", time.Hour, nil)
+
+	desc := roachpb.RangeDescriptor{
+		StartKey: roachpb.RKey("a"),
+		EndKey:   roachpb.RKey("z"),
+	}
+	snap := eng.NewSnapshot()
+	defer snap.Close()
+	nowTs := hlcAt(now)
+	gcTTL := now
+	gcer := makeFakeGCer()
+	info, err := Run(ctx, &desc, snap, nowTs, CalculateThreshold(nowTs, gcTTL),
+		RunOptions{
+			IntentAgeThreshold:  now,
+			TxnCleanupThreshold: txnCleanupThreshold,
+			ExpirationThreshold: CalculateThreshold(nowTs, storageTTL),
+			ClearRangeMinKeys:   3,
+		}, gcTTL, &gcer, gcer.resolveIntents, gcer.resolveIntentsAsync)
+	require.NoError(t, err)
+	require.Equal(t, 6, info.NumKeysExpired)
+	require.Equal(t, []kvpb.GCRequest_GCKey{
+		{Key: roachpb.Key("a"), Timestamp: hlcAt(time.Hour)},
+		{Key: roachpb.Key("j"), Timestamp: hlcAt(time.Hour)},
+	}, gcer.gcExpiredKeys)
+	require.Equal(t, []kvpb.GCRequest_GCClearRange{
+		{StartKey: roachpb.Key("d"), EndKey: roachpb.Key("g").Next()},
+	}, gcer.gcExpiredRanges)
+	require.Empty(t, gcer.gcPointsBatches)
+}
+
 func TestIntentCleanupBatching(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1866,6 +1940,13 @@ func (c *capturingGCer) GC(
 	return nil
 }
 
+func (c *capturingGCer) GCExpired(
+	context.Context, []kvpb.GCRequest_GCKey, *kvpb.GCRequest_GCClearRange,
+) error {
+	c.t.Fatal("unexpected expiration GC request")
+	return nil
+}
+
 type gcData struct {
 	kv      mvccKeyValue
 	garbage bool
@@ -114,8 +114,29 @@ func ConsistencyQueueShouldQueue(
 	interval time.Duration,
 ) (bool, float64) {
 	return consistencyQueueShouldQueueImpl(ctx, now, consistencyShouldQueueData{
-		desc, getQueueLastProcessed, isNodeAvailable,
-		disableLastProcessedCheck, interval})
+		desc: desc, getQueueLastProcessed: getQueueLastProcessed, isNodeAvailable: isNodeAvailable,
+		disableLastProcessedCheck: disableLastProcessedCheck, interval: interval})
+}
+
+// ConsistencyQueueShouldQueueIncremental is like ConsistencyQueueShouldQueue,
+// but with incremental consistency checks enabled.
+func ConsistencyQueueShouldQueueIncremental(
+	ctx context.Context,
+	now hlc.ClockTimestamp,
+	desc *roachpb.RangeDescriptor,
+	getQueueLastProcessed func(ctx context.Context) (hlc.Timestamp, error),
+	getIncrementalLastProcessed func(ctx context.Context) (hlc.Timestamp, error),
+	isNodeAvailable func(nodeID roachpb.NodeID) bool,
+	interval, incrementalInterval time.Duration,
+) (bool, float64) {
+	return consistencyQueueShouldQueueImpl(ctx, now, consistencyShouldQueueData{
+		desc:                        desc,
+		getQueueLastProcessed:       getQueueLastProcessed,
+		isNodeAvailable:             isNodeAvailable,
+		interval:                    interval,
+		getIncrementalLastProcessed: getIncrementalLastProcessed,
+		incrementalInterval:         incrementalInterval,
+	})
 }
 
 // LogReplicaChangeTest adds a fake replica change event to the log for the
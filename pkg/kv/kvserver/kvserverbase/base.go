@@ -72,6 +72,15 @@ var RaftSnapshotQueueEnabled = settings.RegisterBoolSetting(
 	true,
 )
 
+// ColdTierQueueEnabled is a setting that controls whether the cold tier queue
+// is enabled.
+var ColdTierQueueEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.cold_tier_queue.enabled",
+	"whether the cold tier queue is enabled",
+	true,
+)
+
 // ConsistencyQueueEnabled is a setting that controls whether the consistency
 // queue is enabled.
 var ConsistencyQueueEnabled = settings.RegisterBoolSetting(
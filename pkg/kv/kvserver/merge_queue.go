@@ -311,10 +311,11 @@ func (mq *mergeQueue) process(
 	}
 	leftRepls, rightRepls := lhsDesc.Replicas().Descriptors(), rhsDesc.Replicas().Descriptors()
 
-	// Defensive sanity check that the ranges involved only have either VOTER_FULL
-	// and NON_VOTER replicas.
+	// Defensive sanity check that the ranges involved only have either VOTER_FULL,
+	// NON_VOTER and WITNESS replicas.
 	for i := range leftRepls {
-		if typ := leftRepls[i].Type; !(typ == roachpb.VOTER_FULL || typ == roachpb.NON_VOTER) {
+		if typ := leftRepls[i].Type; !(typ == roachpb.VOTER_FULL || typ == roachpb.NON_VOTER ||
+			typ == roachpb.WITNESS) {
 			return false,
 				errors.AssertionFailedf(
 					`cannot merge because lhs is either in a joint state or has learner replicas: %v`,
@@ -326,11 +327,18 @@ func (mq *mergeQueue) process(
 	// Range merges require that the set of stores that contain a replica for the
 	// RHS range be equal to the set of stores that contain a replica for the LHS
 	// range. The LHS and RHS ranges' leaseholders do not need to be co-located
-	// and types of the replicas (voting or non-voting) do not matter. Even if
-	// replicas are collocated, the RHS might still be in a joint config, and
-	// calling AdminRelocateRange will fix this.
-	if !replicasCollocated(leftRepls, rightRepls) ||
+	// and types of the replicas (voting or non-voting) do not matter, except
+	// that witnesses must be collocated with witnesses (see
+	// witnessesCollocated). Even if replicas are collocated, the RHS might still
+	// be in a joint config, and calling AdminRelocateRange will fix this.
+	if !replicasCollocated(leftRepls, rightRepls) || !witnessesCollocated(leftRepls, rightRepls) ||
 		rhsDesc.Replicas().InAtomicReplicationChange() {
+		// AdminRelocateRange only relocates voters and non-voters, so move the
+		// witnesses of the RHS out of its way first, and add them back on the
+		// stores of the LHS witnesses afterwards.
+		if rhsDesc, err = mq.collocateWitnesses(ctx, lhsDesc, rhsDesc, roachpb.REMOVE_WITNESS); err != nil {
+			return false, err
+		}
 		// TODO(aayush): We enable merges to proceed even when LHS and/or RHS are in
 		// violation of their constraints (by adding or removing replicas on the RHS
 		// as needed). We could instead choose to check constraints conformance of
@@ -372,10 +380,14 @@ func (mq *mergeQueue) process(
 		if err != nil {
 			return false, err
 		}
+		if rhsDesc, err = mq.collocateWitnesses(ctx, lhsDesc, rhsDesc, roachpb.ADD_WITNESS); err != nil {
+			return false, err
+		}
 		rightRepls = rhsDesc.Replicas().Descriptors()
 	}
 	for i := range rightRepls {
-		if typ := rightRepls[i].Type; !(typ == roachpb.VOTER_FULL || typ == roachpb.NON_VOTER) {
+		if typ := rightRepls[i].Type; !(typ == roachpb.VOTER_FULL || typ == roachpb.NON_VOTER ||
+			typ == roachpb.WITNESS) {
 			log.Infof(ctx, "RHS Type: %s", typ)
 			return false,
 				errors.AssertionFailedf(
@@ -428,6 +440,38 @@ func (mq *mergeQueue) process(
 	return true, nil
 }
 
+// collocateWitnesses moves the witnesses of the RHS towards the stores of the
+// witnesses of the LHS. With REMOVE_WITNESS, it removes the RHS witnesses that
+// aren't on a store holding an LHS witness. With ADD_WITNESS, it adds RHS
+// witnesses on the stores holding LHS witnesses that don't have one yet. Like
+// all witness changes, each of these is carried out on its own (see
+// validateWitnessChanges). Returns the updated RHS descriptor.
+func (mq *mergeQueue) collocateWitnesses(
+	ctx context.Context,
+	lhsDesc, rhsDesc *roachpb.RangeDescriptor,
+	changeType roachpb.ReplicaChangeType,
+) (*roachpb.RangeDescriptor, error) {
+	from, to := lhsDesc, rhsDesc
+	if changeType == roachpb.REMOVE_WITNESS {
+		from, to = rhsDesc, lhsDesc
+	}
+	for _, witness := range from.Replicas().WitnessDescriptors() {
+		if repDesc, ok := to.GetReplicaDescriptor(witness.StoreID); ok && repDesc.IsWitness() {
+			continue
+		}
+		target := roachpb.ReplicationTarget{NodeID: witness.NodeID, StoreID: witness.StoreID}
+		log.VEventf(ctx, 2, "collocating witnesses of %s with %s: %s %s", rhsDesc, lhsDesc, changeType, target)
+		var err error
+		rhsDesc, err = mq.db.AdminChangeReplicas(
+			ctx, rhsDesc.StartKey, *rhsDesc, kvpb.MakeReplicationChanges(changeType, target),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rhsDesc, nil
+}
+
 func (*mergeQueue) postProcessScheduled(
 	ctx context.Context, replica replicaInQueue, priority float64,
 ) {
@@ -768,6 +768,18 @@ bytes preserved during flushes and compactions over the lifetime of the process.
 		Measurement: "Bytes",
 		Unit:        metric.Unit_BYTES,
 	}
+	metaColdStorageBytesWritten = metric.Metadata{
+		Name:        "storage.cold-tier.write",
+		Help:        "Bytes written to the cold storage tier",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaColdStorageBytesRead = metric.Metadata{
+		Name:        "storage.cold-tier.read",
+		Help:        "Bytes read from the cold storage tier",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
 	metaFlushableIngestCount = metric.Metadata{
 		Name:        "storage.flush.ingest.count",
 		Help:        "Flushes performing an ingest (flushable ingestions)",
@@ -1632,6 +1644,36 @@ The messages are dropped to help these replicas to recover from I/O overload.`,
 		Measurement: "Processing Time",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaColdTierQueueSuccesses = metric.Metadata{
+		Name:        "queue.coldtier.process.success",
+		Help:        "Number of replicas successfully processed by the cold tier queue",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaColdTierQueueFailures = metric.Metadata{
+		Name:        "queue.coldtier.process.failure",
+		Help:        "Number of replicas which failed processing in the cold tier queue",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaColdTierQueuePending = metric.Metadata{
+		Name:        "queue.coldtier.pending",
+		Help:        "Number of pending replicas in the cold tier queue",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaColdTierQueueProcessingNanos = metric.Metadata{
+		Name:        "queue.coldtier.processingnanos",
+		Help:        "Nanoseconds spent processing replicas in the cold tier queue",
+		Measurement: "Processing Time",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaColdTierQueueMovedBytes = metric.Metadata{
+		Name:        "queue.coldtier.moved.bytes",
+		Help:        "Bytes of replica data moved to the cold storage tier by the cold tier queue",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
 	metaConsistencyQueueSuccesses = metric.Metadata{
 		Name:        "queue.consistency.process.success",
 		Help:        "Number of replicas successfully processed by the consistency checker queue",
@@ -1778,6 +1820,12 @@ The messages are dropped to help these replicas to recover from I/O overload.`,
 		Measurement: "Range Keys",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaGCNumKeysExpired = metric.Metadata{
+		Name:        "queue.gc.info.numkeysexpired",
+		Help:        "Number of keys removed by storage-level TTL expiration",
+		Measurement: "Keys",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaGCIntentsConsidered = metric.Metadata{
 		Name:        "queue.gc.info.intentsconsidered",
 		Help:        "Number of 'old' intents",
@@ -2006,6 +2054,32 @@ throttled they do count towards 'delay.total' and 'delay.enginebackpressure'.
 		Measurement: "Encryption At Rest",
 		Unit:        metric.Unit_CONST,
 	}
+	metaEncryptionRetiredKeyFiles = metric.Metadata{
+		Name:        "storage.encryption.retired-key.files",
+		Help:        "Number of files (sstables, WALs, MANIFEST, OPTIONS and auxiliary files) encrypted with a data key of a retired store key, which are yet to be re-encrypted",
+		Measurement: "Files",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaEncryptionRetiredKeyBytes = metric.Metadata{
+		Name:        "storage.encryption.retired-key.bytes",
+		Help:        "Size of files (sstables, WALs, MANIFEST, OPTIONS and auxiliary files) encrypted with a data key of a retired store key, which are yet to be re-encrypted",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaEncryptionReencryptedBytes = metric.Metadata{
+		Name:        "storage.encryption.reencrypted.bytes",
+		Help:        "Bytes written by compactions and flushes that re-encrypted sstables and WALs with the active data key after a store key rotation",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+
+	// Columnar block cache metrics.
+	metaColumnarBlockCacheBytes = metric.Metadata{
+		Name:        "kv.columnar_block_cache.bytes",
+		Help:        "Memory footprint of the in-memory columnar block caches of the ranges of tables with the columnar_block_cache storage parameter",
+		Measurement: "Memory",
+		Unit:        metric.Unit_BYTES,
+	}
 
 	// Concurrency control metrics.
 	metaConcurrencyLocks = metric.Metadata{
@@ -2285,6 +2359,8 @@ type StoreMetrics struct {
 	RdbWriteStallNanos            *metric.Gauge
 	SharedStorageBytesRead        *metric.Gauge
 	SharedStorageBytesWritten     *metric.Gauge
+	ColdStorageBytesRead          *metric.Gauge
+	ColdStorageBytesWritten       *metric.Gauge
 	StorageCompactionsPinnedKeys  *metric.Gauge
 	StorageCompactionsPinnedBytes *metric.Gauge
 	StorageCompactionsDuration    *metric.Gauge
@@ -2425,6 +2501,11 @@ type StoreMetrics struct {
 	RaftSnapshotQueueFailures                 *metric.Counter
 	RaftSnapshotQueuePending                  *metric.Gauge
 	RaftSnapshotQueueProcessingNanos          *metric.Counter
+	ColdTierQueueSuccesses                    *metric.Counter
+	ColdTierQueueFailures                     *metric.Counter
+	ColdTierQueuePending                      *metric.Gauge
+	ColdTierQueueProcessingNanos              *metric.Counter
+	ColdTierQueueMovedBytes                   *metric.Counter
 	ConsistencyQueueSuccesses                 *metric.Counter
 	ConsistencyQueueFailures                  *metric.Counter
 	ConsistencyQueuePending                   *metric.Gauge
@@ -2451,6 +2532,7 @@ type StoreMetrics struct {
 	// GCInfo cumulative totals.
 	GCNumKeysAffected            *metric.Counter
 	GCNumRangeKeysAffected       *metric.Counter
+	GCNumKeysExpired             *metric.Counter
 	GCIntentsConsidered          *metric.Counter
 	GCIntentTxns                 *metric.Counter
 	GCTransactionSpanScanned     *metric.Counter
@@ -2495,6 +2577,16 @@ type StoreMetrics struct {
 	// Encryption-at-rest stats.
 	// EncryptionAlgorithm is an enum representing the cipher in use, so we use a gauge.
 	EncryptionAlgorithm *metric.Gauge
+	// EncryptionRetiredKeyFiles and EncryptionRetiredKeyBytes track the
+	// progress of re-encryption after a store key rotation. The store is fully
+	// rotated once they drop to zero.
+	EncryptionRetiredKeyFiles  *metric.Gauge
+	EncryptionRetiredKeyBytes  *metric.Gauge
+	EncryptionReencryptedBytes *metric.Counter
+
+	// ColumnarBlockCacheBytes is the memory accounted for by the columnar
+	// block caches of the replicas of the store.
+	ColumnarBlockCacheBytes *metric.Gauge
 
 	// RangeFeed counts.
 	RangeFeedMetrics *rangefeed.Metrics
@@ -2938,6 +3030,8 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		IterInternalSteps:             metric.NewGauge(metaIterInternalSteps),
 		SharedStorageBytesRead:        metric.NewGauge(metaSharedStorageBytesRead),
 		SharedStorageBytesWritten:     metric.NewGauge(metaSharedStorageBytesWritten),
+		ColdStorageBytesRead:          metric.NewGauge(metaColdStorageBytesRead),
+		ColdStorageBytesWritten:       metric.NewGauge(metaColdStorageBytesWritten),
 		StorageCompactionsPinnedKeys:  metric.NewGauge(metaStorageCompactionsKeysPinnedCount),
 		StorageCompactionsPinnedBytes: metric.NewGauge(metaStorageCompactionsKeysPinnedBytes),
 		StorageCompactionsDuration:    metric.NewGauge(metaStorageCompactionsDuration),
@@ -3112,6 +3206,11 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RaftSnapshotQueueFailures:                 metric.NewCounter(metaRaftSnapshotQueueFailures),
 		RaftSnapshotQueuePending:                  metric.NewGauge(metaRaftSnapshotQueuePending),
 		RaftSnapshotQueueProcessingNanos:          metric.NewCounter(metaRaftSnapshotQueueProcessingNanos),
+		ColdTierQueueSuccesses:                    metric.NewCounter(metaColdTierQueueSuccesses),
+		ColdTierQueueFailures:                     metric.NewCounter(metaColdTierQueueFailures),
+		ColdTierQueuePending:                      metric.NewGauge(metaColdTierQueuePending),
+		ColdTierQueueProcessingNanos:              metric.NewCounter(metaColdTierQueueProcessingNanos),
+		ColdTierQueueMovedBytes:                   metric.NewCounter(metaColdTierQueueMovedBytes),
 		ConsistencyQueueSuccesses:                 metric.NewCounter(metaConsistencyQueueSuccesses),
 		ConsistencyQueueFailures:                  metric.NewCounter(metaConsistencyQueueFailures),
 		ConsistencyQueuePending:                   metric.NewGauge(metaConsistencyQueuePending),
@@ -3138,6 +3237,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		// GCInfo cumulative totals.
 		GCNumKeysAffected:            metric.NewCounter(metaGCNumKeysAffected),
 		GCNumRangeKeysAffected:       metric.NewCounter(metaGCNumRangeKeysAffected),
+		GCNumKeysExpired:             metric.NewCounter(metaGCNumKeysExpired),
 		GCIntentsConsidered:          metric.NewCounter(metaGCIntentsConsidered),
 		GCIntentTxns:                 metric.NewCounter(metaGCIntentTxns),
 		GCTransactionSpanScanned:     metric.NewCounter(metaGCTransactionSpanScanned),
@@ -3177,7 +3277,13 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		ExportRequestProposalTotalDelay: metric.NewCounter(metaExportEvalTotalDelay),
 
 		// Encryption-at-rest.
-		EncryptionAlgorithm: metric.NewGauge(metaEncryptionAlgorithm),
+		EncryptionAlgorithm:        metric.NewGauge(metaEncryptionAlgorithm),
+		EncryptionRetiredKeyFiles:  metric.NewGauge(metaEncryptionRetiredKeyFiles),
+		EncryptionRetiredKeyBytes:  metric.NewGauge(metaEncryptionRetiredKeyBytes),
+		EncryptionReencryptedBytes: metric.NewCounter(metaEncryptionReencryptedBytes),
+
+		// Columnar block cache.
+		ColumnarBlockCacheBytes: metric.NewGauge(metaColumnarBlockCacheBytes),
 
 		// RangeFeed counters.
 		RangeFeedMetrics: rangefeed.NewMetrics(),
@@ -3321,6 +3427,8 @@ func (sm *StoreMetrics) updateEngineMetrics(m storage.Metrics) {
 	sm.StorageCompactionsDuration.Update(int64(m.Compact.Duration))
 	sm.SharedStorageBytesRead.Update(m.SharedStorageReadBytes)
 	sm.SharedStorageBytesWritten.Update(m.SharedStorageWriteBytes)
+	sm.ColdStorageBytesRead.Update(m.ColdStorageReadBytes)
+	sm.ColdStorageBytesWritten.Update(m.ColdStorageWriteBytes)
 	sm.RdbL0Sublevels.Update(int64(m.Levels[0].Sublevels))
 	sm.RdbL0NumFiles.Update(m.Levels[0].NumFiles)
 	sm.RdbL0BytesFlushed.Update(int64(m.Levels[0].BytesFlushed))
@@ -3430,6 +3538,8 @@ func (sm *StoreMetrics) updateCrossLocalityMetricsOnOutgoingRaftMsg(
 
 func (sm *StoreMetrics) updateEnvStats(stats storage.EnvStats) {
 	sm.EncryptionAlgorithm.Update(int64(stats.EncryptionType))
+	sm.EncryptionRetiredKeyFiles.Update(int64(stats.RetiredKeyFiles))
+	sm.EncryptionRetiredKeyBytes.Update(int64(stats.RetiredKeyBytes))
 }
 
 func (sm *StoreMetrics) handleMetricsResult(ctx context.Context, metric result.Metrics) {
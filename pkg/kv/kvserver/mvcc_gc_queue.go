@@ -259,9 +259,26 @@ func (mgcq *mvccGCQueue) shouldQueue(
 	}
 
 	r := makeMVCCGCQueueScore(ctx, repl, gcTimestamp, lastGC, conf.TTL(), canAdvanceGCThreshold)
+	// Ranges with a storage-level TTL need to be scanned periodically to remove
+	// expired keys, which don't contribute to the score.
+	if storageTTL := conf.StorageTTL(); !r.ShouldQueue && storageTTL > 0 &&
+		(r.LastGC == 0 || r.LastGC >= storageTTLGCInterval(storageTTL)) {
+		return true, r.FinalScore + 1
+	}
 	return r.ShouldQueue, r.FinalScore
 }
 
+// storageTTLGCInterval returns how often ranges with the given storage-level
+// TTL are processed to remove expired keys. Keys are thus removed within
+// roughly 1.5x the TTL, or the TTL plus mvccGCQueueCooldownDuration for short
+// TTLs, whose ranges would otherwise be scanned too often.
+func storageTTLGCInterval(storageTTL time.Duration) time.Duration {
+	if interval := storageTTL / 2; interval > mvccGCQueueCooldownDuration {
+		return interval
+	}
+	return mvccGCQueueCooldownDuration
+}
+
 func makeMVCCGCQueueScore(
 	ctx context.Context,
 	repl *Replica,
@@ -641,6 +658,20 @@ func (r *replicaGCer) GC(
 	return r.send(ctx, req)
 }
 
+func (r *replicaGCer) GCExpired(
+	ctx context.Context,
+	expiredKeys []kvpb.GCRequest_GCKey,
+	clearRange *kvpb.GCRequest_GCClearRange,
+) error {
+	if len(expiredKeys) == 0 && clearRange == nil {
+		return nil
+	}
+	req := r.template()
+	req.ExpiredKeys = expiredKeys
+	req.ExpiredClearRange = clearRange
+	return r.send(ctx, req)
+}
+
 // process first determines whether the replica can run MVCC GC given its view
 // of the protected timestamp subsystem and its current state. This check also
 // determines the most recent time which can be used for the purposes of
@@ -690,6 +721,16 @@ func (mgcq *mvccGCQueue) process(
 	if !canGC {
 		return false, nil
 	}
+	// Keys expire under the storage-level TTL only if no protected timestamp
+	// record applies to the range. Expiration removes all versions of a key, so
+	// the GC threshold is advanced to the expiration threshold first: a storage
+	// TTL below the GC TTL lowers the effective GC TTL of the range.
+	expirationThreshold, err := repl.storageExpirationThreshold(ctx, conf.StorageTTL())
+	if err != nil {
+		log.VErrEventf(ctx, 2, "not expiring keys: failed to check protected timestamps: %v", err)
+		expirationThreshold = hlc.Timestamp{}
+	}
+	newThreshold.Forward(expirationThreshold)
 	canAdvanceGCThreshold := !newThreshold.Equal(oldThreshold)
 	// We don't recheck ShouldQueue here, since the range may have been enqueued
 	// manually e.g. via the admin server.
@@ -732,6 +773,7 @@ func (mgcq *mvccGCQueue) process(
 			MaxTxnsPerIntentCleanupBatch:           intentresolver.MaxTxnsPerIntentCleanupBatch,
 			IntentCleanupBatchTimeout:              mvccGCQueueIntentBatchTimeout,
 			ClearRangeMinKeys:                      clearRangeMinKeys,
+			ExpirationThreshold:                    expirationThreshold,
 		},
 		conf.TTL(),
 		&replicaGCer{
@@ -811,6 +853,7 @@ func (mgcq *mvccGCQueue) process(
 func updateStoreMetricsWithGCInfo(metrics *StoreMetrics, info gc.Info) {
 	metrics.GCNumKeysAffected.Inc(int64(info.NumKeysAffected))
 	metrics.GCNumRangeKeysAffected.Inc(int64(info.NumRangeKeysAffected))
+	metrics.GCNumKeysExpired.Inc(int64(info.NumKeysExpired))
 	metrics.GCIntentsConsidered.Inc(int64(info.IntentsConsidered))
 	metrics.GCIntentTxns.Inc(int64(info.IntentTxns))
 	metrics.GCTransactionSpanScanned.Inc(int64(info.TransactionSpanTotal))
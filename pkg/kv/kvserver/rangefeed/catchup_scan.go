@@ -336,6 +336,7 @@ func (i *CatchUpIterator) CatchUpScan(
 						RawBytes:  val,
 						Timestamp: ts,
 					},
+					OriginID: mvccVal.OriginID,
 				})
 				reorderBuf = append(reorderBuf, event)
 				if i.OnEmit != nil {
@@ -36,6 +36,12 @@ var (
 		Measurement: "Events",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRangeFeedValuesFiltered = metric.Metadata{
+		Name:        "kv.rangefeed.filtered_values",
+		Help:        "Number of RangeFeed values not sent because the value filter of their registration dropped them",
+		Measurement: "Events",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRangeFeedRegistrations = metric.Metadata{
 		Name:        "kv.rangefeed.registrations",
 		Help:        "Number of active rangefeed registrations",
@@ -50,6 +56,7 @@ type Metrics struct {
 	RangeFeedBudgetExhausted         *metric.Counter
 	RangeFeedBudgetBlocked           *metric.Counter
 	RangeFeedRegistrations           *metric.Gauge
+	RangeFeedValuesFiltered          *metric.Counter
 	RangeFeedSlowClosedTimestampLogN log.EveryN
 	// RangeFeedSlowClosedTimestampNudgeSem bounds the amount of work that can be
 	// spun up on behalf of the RangeFeed nudger. We don't expect to hit this
@@ -68,6 +75,7 @@ func NewMetrics() *Metrics {
 		RangeFeedBudgetExhausted:             metric.NewCounter(metaRangeFeedExhausted),
 		RangeFeedBudgetBlocked:               metric.NewCounter(metaRangeFeedBudgetBlocked),
 		RangeFeedRegistrations:               metric.NewGauge(metaRangeFeedRegistrations),
+		RangeFeedValuesFiltered:              metric.NewCounter(metaRangeFeedValuesFiltered),
 		RangeFeedSlowClosedTimestampLogN:     log.Every(5 * time.Second),
 		RangeFeedSlowClosedTimestampNudgeSem: make(chan struct{}, 1024),
 	}
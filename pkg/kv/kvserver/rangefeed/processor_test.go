@@ -220,6 +220,7 @@ func TestProcessorBasic(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -342,6 +343,7 @@ func TestProcessorBasic(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,  /* catchUpIter */
 		true, /* withDiff */
+		nil,  /* valueFilter */
 		r2Stream,
 		func() {},
 		&r2Done,
@@ -426,6 +428,7 @@ func TestProcessorBasic(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r3Stream,
 		func() {},
 		&r3Done,
@@ -454,7 +457,7 @@ func TestNilProcessor(t *testing.T) {
 	require.Panics(t, func() { _ = p.Start(stopper, nil) })
 	require.Panics(t, func() {
 		var done future.ErrorFuture
-		p.Register(roachpb.RSpan{}, hlc.Timestamp{}, nil, false, nil,
+		p.Register(roachpb.RSpan{}, hlc.Timestamp{}, nil, false, nil /* valueFilter */, nil,
 			func() {}, &done,
 		)
 	})
@@ -474,6 +477,7 @@ func TestProcessorSlowConsumer(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -485,6 +489,7 @@ func TestProcessorSlowConsumer(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r2Stream,
 		func() {},
 		&r2Done,
@@ -587,6 +592,7 @@ func TestProcessorMemoryBudgetExceeded(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -656,6 +662,7 @@ func TestProcessorMemoryBudgetReleased(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -731,6 +738,7 @@ func TestProcessorInitializeResolvedTimestamp(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -975,7 +983,7 @@ func TestProcessorConcurrentStop(t *testing.T) {
 			runtime.Gosched()
 			s := newTestStream()
 			var done future.ErrorFuture
-			p.Register(p.Span, hlc.Timestamp{}, nil, false, s,
+			p.Register(p.Span, hlc.Timestamp{}, nil, false, nil /* valueFilter */, s,
 				func() {}, &done)
 		}()
 		go func() {
@@ -1045,7 +1053,7 @@ func TestProcessorRegistrationObservesOnlyNewEvents(t *testing.T) {
 			s := newTestStream()
 			regs[s] = firstIdx
 			var done future.ErrorFuture
-			p.Register(p.Span, hlc.Timestamp{}, nil, false,
+			p.Register(p.Span, hlc.Timestamp{}, nil, false, nil, /* valueFilter */
 				s, func() {}, &done)
 			regDone <- struct{}{}
 		}
@@ -1144,6 +1152,7 @@ func TestBudgetReleaseOnProcessorStop(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		rStream,
 		func() {},
 		&done,
@@ -1234,6 +1243,7 @@ func TestBudgetReleaseOnLastStreamError(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		rStream,
 		func() {},
 		&done,
@@ -1313,6 +1323,7 @@ func TestBudgetReleaseOnOneStreamError(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -1327,6 +1338,7 @@ func TestBudgetReleaseOnOneStreamError(t *testing.T) {
 		hlc.Timestamp{WallTime: 1},
 		nil,   /* catchUpIter */
 		false, /* withDiff */
+		nil,   /* valueFilter */
 		r2Stream,
 		func() {},
 		&r2Done,
@@ -79,7 +79,9 @@ type registration struct {
 	span             roachpb.Span
 	catchUpTimestamp hlc.Timestamp // exclusive
 	withDiff         bool
-	metrics          *Metrics
+	// valueFilter, if set, filters and projects the values sent to the stream.
+	valueFilter ValueFilter
+	metrics     *Metrics
 
 	// catchUpIterConstructor is used to construct the catchUpIter if necessary.
 	// The reason this constructor is plumbed down is to make sure that the
@@ -124,6 +126,7 @@ func newRegistration(
 	startTS hlc.Timestamp,
 	catchUpIterConstructor CatchUpIteratorConstructor,
 	withDiff bool,
+	valueFilter ValueFilter,
 	bufferSz int,
 	metrics *Metrics,
 	stream Stream,
@@ -135,6 +138,7 @@ func newRegistration(
 		catchUpTimestamp:       startTS,
 		catchUpIterConstructor: catchUpIterConstructor,
 		withDiff:               withDiff,
+		valueFilter:            valueFilter,
 		metrics:                metrics,
 		stream:                 stream,
 		done:                   done,
@@ -155,7 +159,12 @@ func (r *registration) publish(
 	ctx context.Context, event *kvpb.RangeFeedEvent, alloc *SharedBudgetAllocation,
 ) {
 	r.validateEvent(event)
-	e := getPooledSharedEvent(sharedEvent{event: r.maybeStripEvent(event), alloc: alloc})
+	event = r.maybeStripEvent(event)
+	if event == nil {
+		// The value filter of the registration dropped the event.
+		return
+	}
+	e := getPooledSharedEvent(sharedEvent{event: event, alloc: alloc})
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -217,7 +226,8 @@ func (r *registration) validateEvent(event *kvpb.RangeFeedEvent) {
 // maybeStripEvent determines whether the event contains excess information not
 // applicable to the current registration. If so, it makes a copy of the event
 // and strips the incompatible information to match only what the registration
-// requested.
+// requested. It returns nil if the value filter of the registration drops the
+// event.
 func (r *registration) maybeStripEvent(event *kvpb.RangeFeedEvent) *kvpb.RangeFeedEvent {
 	ret := event
 	copyOnWrite := func() interface{} {
@@ -267,7 +277,25 @@ func (r *registration) maybeStripEvent(event *kvpb.RangeFeedEvent) *kvpb.RangeFe
 	default:
 		panic(fmt.Sprintf("unexpected RangeFeedEvent variant: %v", t))
 	}
-	return ret
+	return r.maybeFilterValue(ret)
+}
+
+// maybeFilterValue applies the value filter of the registration, if any, to a
+// value event. It returns nil if the value need not be sent, and otherwise the
+// event to send in its place.
+func (r *registration) maybeFilterValue(event *kvpb.RangeFeedEvent) *kvpb.RangeFeedEvent {
+	if r.valueFilter == nil || event.Val == nil || !event.Val.Value.IsPresent() {
+		return event
+	}
+	filtered := r.valueFilter.FilterValue(event.Val)
+	if filtered == nil {
+		r.metrics.RangeFeedValuesFiltered.Inc(1)
+		return nil
+	}
+	if filtered == event.Val {
+		return event
+	}
+	return &kvpb.RangeFeedEvent{Val: filtered}
 }
 
 // disconnect cancels the output loop context for the registration and passes an
@@ -387,7 +415,16 @@ func (r *registration) maybeRunCatchUpScan(ctx context.Context) error {
 		r.metrics.RangeFeedCatchUpScanNanos.Inc(timeutil.Since(start).Nanoseconds())
 	}()
 
-	return catchUpIter.CatchUpScan(ctx, r.stream.Send, r.withDiff)
+	outputFn := r.stream.Send
+	if r.valueFilter != nil {
+		outputFn = func(e *kvpb.RangeFeedEvent) error {
+			if e = r.maybeFilterValue(e); e == nil {
+				return nil
+			}
+			return r.stream.Send(e)
+		}
+	}
+	return catchUpIter.CatchUpScan(ctx, outputFn, r.withDiff)
 }
 
 // ID implements interval.Interface.
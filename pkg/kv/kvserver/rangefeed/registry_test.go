@@ -118,6 +118,7 @@ func newTestRegistration(
 		ts,
 		makeCatchUpIteratorConstructor(catchup),
 		withDiff,
+		nil, /* valueFilter */
 		5,
 		NewMetrics(),
 		s,
@@ -323,6 +324,72 @@ func TestRegistrationCatchUpScan(t *testing.T) {
 	require.Equal(t, expEvents, r.Events())
 }
 
+// testValueFilter drops the values of keyB, and replaces those of keyC.
+type testValueFilter struct{}
+
+func (testValueFilter) FilterValue(v *kvpb.RangeFeedValue) *kvpb.RangeFeedValue {
+	switch {
+	case v.Key.Equal(keyB):
+		return nil
+	case v.Key.Equal(keyC):
+		return &kvpb.RangeFeedValue{Key: v.Key, Value: makeVal("replaced")}
+	default:
+		return v
+	}
+}
+
+func TestRegistrationValueFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	val := makeVal("val")
+	val.Timestamp = hlc.Timestamp{WallTime: 20}
+	var evs []*kvpb.RangeFeedEvent
+	for _, key := range []roachpb.Key{keyA, keyB, keyC} {
+		ev := new(kvpb.RangeFeedEvent)
+		ev.MustSetValue(&kvpb.RangeFeedValue{Key: key, Value: val})
+		evs = append(evs, ev)
+	}
+	del := new(kvpb.RangeFeedEvent)
+	del.MustSetValue(&kvpb.RangeFeedValue{
+		Key: keyB, Value: roachpb.Value{Timestamp: hlc.Timestamp{WallTime: 21}},
+	})
+
+	reg := newTestRegistration(roachpb.Span{Key: keyA, EndKey: keyD}, hlc.Timestamp{WallTime: 1},
+		newTestIterator([]storage.MVCCKeyValue{
+			makeKV("a", "valA", 10),
+			makeKV("b", "valB", 10),
+			makeKV("c", "valC", 10),
+		}, nil), false)
+	reg.valueFilter = testValueFilter{}
+	for _, ev := range append(evs, del) {
+		reg.publish(ctx, ev, nil /* alloc */)
+	}
+	// The live value of keyB is dropped before it is buffered, but its
+	// deletion is not.
+	require.Equal(t, 3, len(reg.buf))
+	go reg.runOutputLoop(ctx, 0)
+	require.NoError(t, reg.waitForCaughtUp())
+
+	var sent []string
+	for _, ev := range reg.Events() {
+		require.NotNil(t, ev.Val)
+		if !ev.Val.Value.IsPresent() {
+			sent = append(sent, fmt.Sprintf("%s deleted", ev.Val.Key))
+			continue
+		}
+		v, err := ev.Val.Value.GetBytes()
+		require.NoError(t, err)
+		sent = append(sent, fmt.Sprintf("%s=%s", ev.Val.Key, v))
+	}
+	require.Equal(t, []string{
+		`"a"=valA`, `"c"=replaced`, // catch-up scan
+		`"a"=val`, `"c"=replaced`, `"b" deleted`, // live
+	}, sent)
+	require.Equal(t, int64(2), reg.metrics.RangeFeedValuesFiltered.Count())
+	reg.disconnect(nil)
+}
+
 func TestRegistryBasic(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
@@ -0,0 +1,73 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangefeed
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// ValueFilter filters and projects the values published to a registration,
+// both live and during its catch-up scan. It is constructed from the
+// kvpb.RangeFeedValueFilter of the RangeFeedRequest.
+//
+// Filters are defined above KV, which only knows them by the type under which
+// they are registered with RegisterValueFilter. A ValueFilter must be safe for
+// concurrent use, since the catch-up scan of a registration may run
+// concurrently with the publication of its live values.
+type ValueFilter interface {
+	// FilterValue returns the value to send in place of v, or nil if v need not
+	// be sent. It must not modify v, which may be shared with other
+	// registrations, and must return v itself if it cannot interpret it, so
+	// that filters can only drop the values that the client would drop.
+	//
+	// Deletion tombstones are not passed to the filter.
+	FilterValue(v *kvpb.RangeFeedValue) *kvpb.RangeFeedValue
+}
+
+// ValueFilterConstructor constructs a ValueFilter from its serialized spec.
+type ValueFilterConstructor func(spec []byte) (ValueFilter, error)
+
+var valueFilters struct {
+	syncutil.Mutex
+	constructors map[string]ValueFilterConstructor
+}
+
+// RegisterValueFilter registers the constructor of the value filters of a
+// type. It is meant to be called from init functions.
+func RegisterValueFilter(typ string, fn ValueFilterConstructor) {
+	valueFilters.Lock()
+	defer valueFilters.Unlock()
+	if valueFilters.constructors == nil {
+		valueFilters.constructors = make(map[string]ValueFilterConstructor)
+	}
+	if _, ok := valueFilters.constructors[typ]; ok {
+		panic(errors.AssertionFailedf("value filter %q registered twice", typ))
+	}
+	valueFilters.constructors[typ] = fn
+}
+
+// NewValueFilter constructs the ValueFilter of a rangefeed request. It returns
+// nil if the request has no filter, or if no filter of its type is registered.
+func NewValueFilter(f *kvpb.RangeFeedValueFilter) (ValueFilter, error) {
+	if f == nil {
+		return nil, nil
+	}
+	valueFilters.Lock()
+	fn, ok := valueFilters.constructors[f.Type]
+	valueFilters.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	vf, err := fn(f.Spec)
+	return vf, errors.Wrapf(err, "constructing rangefeed value filter %q", f.Type)
+}
@@ -886,6 +886,13 @@ type Replica struct {
 
 	// loadBasedSplitter keeps information about load-based splitting.
 	loadBasedSplitter split.Decider
+	// hotKeys samples the keys accessed by requests to find the range's hottest
+	// keys.
+	hotKeys split.HotKeyFinder
+
+	// columnar is the in-memory columnar block cache of the range, if its span
+	// config has ColumnarBlockCache set. See replica_columnar.go.
+	columnar replicaColumnar
 
 	// unreachablesMu contains a set of remote ReplicaIDs that are to be reported
 	// as unreachable on the next raft tick.
@@ -2259,6 +2266,39 @@ func (r *Replica) maybeTransferRaftLeadershipToLeaseholderLocked(
 	}
 }
 
+// maybeTransferRaftLeadershipFromWitnessLocked attempts to transfer the
+// leadership away from this node if it is a witness and the current raft
+// leader. Witnesses campaign like voters, so that a range can elect a leader
+// even if its surviving voters are behind on the log. The witness then catches
+// them up, but it can't hold the lease, so the leadership is transferred to a
+// voter once one has caught up with the log. The leaseholder is preferred if
+// there is a valid lease, see maybeTransferRaftLeadershipToLeaseholderLocked.
+func (r *Replica) maybeTransferRaftLeadershipFromWitnessLocked(
+	ctx context.Context, status kvserverpb.LeaseStatus,
+) {
+	if !r.isRaftLeaderRLocked() || !r.isWitnessRLocked() { // fast path
+		return
+	}
+	if status.IsValid() && !status.OwnedBy(r.StoreID()) {
+		return
+	}
+	raftStatus := r.raftSparseStatusRLocked()
+	if raftStatus == nil || raftStatus.RaftState != raft.StateLeader ||
+		raftStatus.LeadTransferee != raft.None {
+		return
+	}
+	for _, repDesc := range r.mu.state.Desc.Replicas().VoterDescriptors() {
+		progress, ok := raftStatus.Progress[uint64(repDesc.ReplicaID)]
+		if ok && progress.Match >= raftStatus.Commit {
+			log.VEventf(ctx, 1, "transferring raft leadership from witness to replica ID %v",
+				repDesc.ReplicaID)
+			r.store.metrics.RangeRaftLeaderTransfers.Inc(1)
+			r.mu.internalRaftGroup.TransferLeader(uint64(repDesc.ReplicaID))
+			return
+		}
+	}
+}
+
 func (r *Replica) getReplicaDescriptorByIDRLocked(
 	replicaID roachpb.ReplicaID, fallback roachpb.ReplicaDescriptor,
 ) (roachpb.ReplicaDescriptor, error) {
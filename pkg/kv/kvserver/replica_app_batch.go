@@ -59,6 +59,9 @@ type replicaAppBatch struct {
 	// changeRemovesReplica tracks whether the command in the batch (there must
 	// be only one) removes this replica from the range.
 	changeRemovesReplica bool
+	// mutatesMVCCHistory tracks whether any command in the batch mutates the
+	// MVCC history.
+	mutatesMVCCHistory bool
 
 	start                   time.Time // time at NewBatch()
 	followerStoreWriteBytes kvadmission.FollowerStoreWriteBytes
@@ -136,7 +139,8 @@ func (b *replicaAppBatch) Stage(
 	}
 
 	// Stage the command's write batch in the application batch.
-	if err := b.ab.addWriteBatch(ctx, b.batch, cmd); err != nil {
+	witness := b.isWitness()
+	if err := b.ab.addWriteBatch(ctx, b.batch, cmd, witness); err != nil {
 		return nil, err
 	}
 
@@ -149,6 +153,7 @@ func (b *replicaAppBatch) Stage(
 		eng:         b.r.store.TODOEngine(),
 		sideloaded:  b.r.raftMu.sideloaded,
 		bulkLimiter: b.r.store.limiters.BulkIOWriteRate,
+		witness:     witness,
 	}); err != nil {
 		return nil, err
 	}
@@ -186,6 +191,25 @@ func changeRemovesStore(
 	return !existsInChange
 }
 
+// changePromotesToWitness returns true if the store was not a witness in the
+// given descriptor but will be one after the change is applied.
+func changePromotesToWitness(
+	desc *roachpb.RangeDescriptor, change *kvserverpb.ChangeReplicas, storeID roachpb.StoreID,
+) bool {
+	if repDesc, ok := desc.GetReplicaDescriptor(storeID); !ok || repDesc.IsWitness() {
+		return false
+	}
+	repDesc, ok := change.Desc.GetReplicaDescriptor(storeID)
+	return ok && repDesc.IsWitness()
+}
+
+// isWitness returns whether the replica is a witness according to the range
+// descriptor in the batch's staged state.
+func (b *replicaAppBatch) isWitness() bool {
+	repDesc, ok := b.state.Desc.GetReplicaDescriptorByID(b.r.replicaID)
+	return ok && repDesc.IsWitness()
+}
+
 // runPreAddTriggersReplicaOnly is like (appBatch).runPreAddTriggers (and is
 // called right after it), except that it must only contain ephemeral side
 // effects that have no influence on durable state. It is not invoked during
@@ -271,6 +295,7 @@ func (b *replicaAppBatch) runPostAddTriggersReplicaOnly(
 	// The are no rangefeeds in standalone mode, so we don't have to do anything
 	// for this on appBatch.
 	if res.MVCCHistoryMutation != nil {
+		b.mutatesMVCCHistory = true
 		for _, span := range res.MVCCHistoryMutation.Spans {
 			b.r.disconnectRangefeedSpanWithErr(span, kvpb.NewError(&kvpb.MVCCHistoryMutationError{
 				Span: span,
@@ -489,6 +514,20 @@ func (b *replicaAppBatch) runPostAddTriggersReplicaOnly(
 		}
 	}
 
+	// If this command promotes us from a learner to a witness, drop the user
+	// data that we received in the learner snapshot. From here on, the writes
+	// to it are filtered out in addWriteBatch.
+	if change := res.ChangeReplicas; change != nil && !b.changeRemovesReplica &&
+		changePromotesToWitness(b.state.Desc, change, b.r.store.StoreID()) {
+		for _, span := range witnessExcludedSpans(b.state.Desc) {
+			if err := b.batch.ClearRawRange(
+				span.Key, span.EndKey, true /* pointKeys */, true, /* rangeKeys */
+			); err != nil {
+				return errors.Wrapf(err, "unable to clear data of witness")
+			}
+		}
+	}
+
 	// Provide the command's corresponding logical operations to the Replica's
 	// rangefeed. Only do so if the WriteBatch is non-nil, in which case the
 	// rangefeed requires there to be a corresponding logical operation log or
@@ -614,6 +653,12 @@ func (b *replicaAppBatch) ApplyToStateMachine(ctx context.Context) error {
 	// Record the number of keys written to the replica.
 	b.r.loadStats.RecordWriteKeys(float64(b.ab.numMutations))
 
+	// The columnar blocks don't reflect MVCC history mutations below their
+	// timestamp, so they have to be rebuilt.
+	if b.mutatesMVCCHistory {
+		r.clearColumnarBlocks()
+	}
+
 	now := timeutil.Now()
 	if needsSplitBySize && r.splitQueueThrottle.ShouldProcess(now) {
 		r.store.splitQueue.MaybeAddAsync(ctx, r, r.store.Clock().NowAsClockTimestamp())
@@ -512,6 +512,10 @@ func (r *Replica) handleVersionResult(ctx context.Context, version *roachpb.Vers
 }
 
 func (r *Replica) handleComputeChecksumResult(ctx context.Context, cc *kvserverpb.ComputeChecksum) {
+	if r.isWitness() {
+		// Witnesses hold no user data and don't take part in consistency checks.
+		return
+	}
 	err := r.computeChecksumPostApply(ctx, *cc)
 	// Don't log errors caused by the store quiescing, they are expected.
 	if err != nil && !errors.Is(err, stop.ErrUnavailable) {
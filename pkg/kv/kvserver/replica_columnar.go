@@ -0,0 +1,289 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catid"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// columnarBlockCacheMaxSizePerRangeIndex limits the memory footprint of the
+// columnar blocks cached by a range for one of the indexes of its table.
+var columnarBlockCacheMaxSizePerRangeIndex = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.columnar_block_cache.max_size_per_range_index",
+	"the maximum memory footprint of the in-memory columnar block cache of each "+
+		"index of each range of a table with the columnar_block_cache storage parameter; "+
+		"the indexes of ranges whose blocks don't fit aren't cached",
+	64<<20, // 64 MiB
+	settings.PositiveInt,
+)
+
+// columnarBlockCacheMaxSizePerStore limits the memory footprint of the columnar
+// blocks cached by all the ranges of a store. The memory is also accounted for
+// in the KV memory monitor of the node.
+var columnarBlockCacheMaxSizePerStore = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.columnar_block_cache.max_size_per_store",
+	"the maximum memory footprint of the in-memory columnar block caches of all "+
+		"the ranges of a store; blocks which don't fit aren't cached",
+	512<<20, // 512 MiB
+	settings.PositiveInt,
+)
+
+// columnarBlockCacheRebuildInterval limits how often the columnar blocks of an
+// index are rebuilt after they've gone stale due to writes to the range.
+var columnarBlockCacheRebuildInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.columnar_block_cache.rebuild_interval",
+	"the minimum interval between rebuilds of the in-memory columnar block cache of "+
+		"an index of a range of a table with the columnar_block_cache storage parameter",
+	10*time.Second,
+	settings.NonNegativeDuration,
+)
+
+// columnarIndexID identifies an index whose rows are kept in columnar blocks.
+type columnarIndexID struct {
+	tableID catid.DescID
+	indexID catid.IndexID
+}
+
+// replicaColumnar is the in-memory cache of the columnar blocks of the indexes
+// of a replica whose span config has ColumnarBlockCache set. The blocks are built
+// lazily, for the indexes and columns requested by the direct columnar scans,
+// as of the closed timestamp of the range. They're not persisted, and their
+// memory is accounted for in the columnar monitor of the store, which bounds
+// the memory of the caches of all its replicas. See storage.ColumnarBlocks for
+// more details.
+type replicaColumnar struct {
+	syncutil.Mutex
+	// gen is incremented whenever the blocks are invalidated, so that the
+	// builds which started before don't install stale blocks.
+	gen     int64
+	indexes map[columnarIndexID]*columnarIndex
+}
+
+// columnarIndex holds the columnar blocks of an index.
+type columnarIndex struct {
+	// blocks are the latest blocks of the index, or nil if none were built yet.
+	blocks *storage.ColumnarBlocks
+	// acc accounts for the memory of the blocks.
+	acc mon.BoundAccount
+	// building is set while the blocks are being rebuilt.
+	building bool
+	// lastBuild is the time the last rebuild started.
+	lastBuild time.Time
+}
+
+// GetColumnarBlocks returns the columnar blocks of the range for the index of
+// the given spec, or nil if there are none which contain its fetched columns.
+// It kicks off a rebuild of the blocks in the background if they're missing,
+// don't contain the fetched columns, or have gone stale due to writes to the
+// range.
+func (r *Replica) GetColumnarBlocks(
+	ctx context.Context, spec *fetchpb.IndexFetchSpec,
+) *storage.ColumnarBlocks {
+	r.mu.RLock()
+	enabled := r.mu.conf.ColumnarBlockCache
+	desc := r.mu.state.Desc
+	lastUpdateNanos := r.mu.state.Stats.LastUpdateNanos
+	r.mu.RUnlock()
+	if !enabled || !r.ClusterSettings().Version.IsActive(ctx, clusterversion.V23_2_ColumnarBlockCache) {
+		// Release the blocks in case the setting was just turned off.
+		r.clearColumnarBlocks()
+		return nil
+	}
+	span, ok := columnarIndexSpan(desc, spec)
+	if !ok {
+		return nil
+	}
+
+	id := columnarIndexID{tableID: spec.TableID, indexID: spec.IndexID}
+	r.columnar.Lock()
+	defer r.columnar.Unlock()
+	idx, ok := r.columnar.indexes[id]
+	if !ok {
+		if r.columnar.indexes == nil {
+			r.columnar.indexes = make(map[columnarIndexID]*columnarIndex)
+		}
+		idx = &columnarIndex{}
+		r.columnar.indexes[id] = idx
+	}
+	blocks := idx.blocks
+	usable := blocks != nil && blocks.Span.Equal(span) && blocks.Covers(spec)
+	// The stats of the range are aged to the timestamps of the writes, so
+	// this detects the writes above the timestamp of the blocks.
+	if usable && lastUpdateNanos <= blocks.Timestamp.WallTime {
+		return blocks
+	}
+	interval := columnarBlockCacheRebuildInterval.Get(&r.ClusterSettings().SV)
+	if !idx.building && timeutil.Since(idx.lastBuild) >= interval {
+		idx.building = true
+		idx.lastBuild = timeutil.Now()
+		buildSpec := columnarBuildSpec(spec, blocks)
+		if err := r.buildColumnarBlocksAsync(id, buildSpec, span, r.columnar.gen); err != nil {
+			idx.building = false
+		}
+	}
+	if usable {
+		// The blocks may still be usable by scans over the parts of the range
+		// which weren't written to.
+		return blocks
+	}
+	return nil
+}
+
+// columnarIndexSpan returns the part of the span of the range which contains
+// the rows of the index of the given spec.
+func columnarIndexSpan(
+	desc *roachpb.RangeDescriptor, spec *fetchpb.IndexFetchSpec,
+) (roachpb.Span, bool) {
+	_, tenantID, err := keys.DecodeTenantPrefixE(desc.StartKey.AsRawKey())
+	if err != nil {
+		return roachpb.Span{}, false
+	}
+	prefix := keys.MakeSQLCodec(tenantID).IndexPrefix(uint32(spec.TableID), uint32(spec.IndexID))
+	indexSpan := roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()}
+	span := indexSpan.Intersect(desc.RSpan().AsRawSpanWithNoLocals())
+	return span, span.Valid()
+}
+
+// columnarBuildSpec returns the spec to rebuild the columnar blocks with, in
+// order to serve the scans with the given spec. The rebuilt blocks also keep
+// the columns of the current blocks, so that the scans fetching different
+// columns of the index don't keep on replacing each other's blocks.
+func columnarBuildSpec(
+	spec *fetchpb.IndexFetchSpec, blocks *storage.ColumnarBlocks,
+) *fetchpb.IndexFetchSpec {
+	buildSpec := *spec
+	buildSpec.ColumnBounds = nil
+	buildSpec.FetchedColumns = append([]fetchpb.IndexFetchSpec_Column(nil), spec.FetchedColumns...)
+	if blocks == nil || blocks.Spec.MaxKeysPerRow != spec.MaxKeysPerRow ||
+		blocks.Spec.MaxFamilyID != spec.MaxFamilyID {
+		// The schema of the index changed.
+		return &buildSpec
+	}
+	for _, col := range blocks.Spec.FetchedColumns {
+		found := false
+		for i := range spec.FetchedColumns {
+			if spec.FetchedColumns[i].ColumnID == col.ColumnID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			buildSpec.FetchedColumns = append(buildSpec.FetchedColumns, col)
+		}
+	}
+	return &buildSpec
+}
+
+// buildColumnarBlocksAsync rebuilds the columnar blocks of an index in the
+// background. The blocks are only installed if they weren't invalidated since
+// the given generation.
+func (r *Replica) buildColumnarBlocksAsync(
+	id columnarIndexID, spec *fetchpb.IndexFetchSpec, span roachpb.Span, gen int64,
+) error {
+	ctx := r.AnnotateCtx(context.Background())
+	return r.store.stopper.RunAsyncTask(ctx, "columnar-blocks-build", func(ctx context.Context) {
+		// The memory of the blocks being replaced is released once the new
+		// blocks are installed, so it's available to the new blocks.
+		var replaced int64
+		r.columnar.Lock()
+		if idx, ok := r.columnar.indexes[id]; ok {
+			replaced = idx.acc.Used()
+		}
+		r.columnar.Unlock()
+		acc := r.store.columnarMon.MakeBoundAccount()
+		blocks, err := r.buildColumnarBlocks(ctx, spec, span, replaced, &acc)
+		if err != nil {
+			log.VEventf(ctx, 2, "failed to build columnar blocks of index %s: %v", spec.IndexName, err)
+		}
+		r.columnar.Lock()
+		defer r.columnar.Unlock()
+		idx, ok := r.columnar.indexes[id]
+		if !ok || r.columnar.gen != gen {
+			acc.Close(ctx)
+			return
+		}
+		idx.building = false
+		if err != nil {
+			acc.Close(ctx)
+			return
+		}
+		idx.acc.Close(ctx)
+		idx.blocks, idx.acc = blocks, acc
+	})
+}
+
+// buildColumnarBlocks builds the columnar blocks of an index as of the closed
+// timestamp of the range, accounting for their memory in acc. Building fails
+// if the blocks don't fit in the remaining memory budget of the store, not
+// counting the replaced bytes of the blocks that the new blocks replace.
+func (r *Replica) buildColumnarBlocks(
+	ctx context.Context,
+	spec *fetchpb.IndexFetchSpec,
+	span roachpb.Span,
+	replaced int64,
+	acc *mon.BoundAccount,
+) (*storage.ColumnarBlocks, error) {
+	st := r.ClusterSettings()
+	maxSize := columnarBlockCacheMaxSizePerRangeIndex.Get(&st.SV)
+	// NB: concurrent builds may each use the remaining budget, so the budget
+	// can be exceeded by the builds in flight.
+	storeBudget := columnarBlockCacheMaxSizePerStore.Get(&st.SV)
+	if remaining := storeBudget - r.store.columnarMon.AllocBytes() + replaced; remaining < maxSize {
+		if remaining <= 0 {
+			return nil, errors.Newf("columnar block caches of the store exceed the budget of %s",
+				humanizeutil.IBytes(storeBudget))
+		}
+		maxSize = remaining
+	}
+	// The closed timestamp is read before the engine snapshot is taken, so the
+	// snapshot contains all the writes at or below it.
+	ts := r.GetCurrentClosedTimestamp(ctx)
+	if ts.IsEmpty() {
+		return nil, errors.New("no closed timestamp")
+	}
+	snap := r.store.TODOEngine().NewSnapshot()
+	defer snap.Close()
+	return storage.BuildColumnarBlocks(ctx, snap, spec, span, ts, maxSize, acc, st)
+}
+
+// clearColumnarBlocks drops the columnar blocks of the replica. It's called
+// when the data of the replica may have changed below the timestamp of the
+// blocks, or when the blocks are no longer needed.
+func (r *Replica) clearColumnarBlocks() {
+	r.columnar.Lock()
+	defer r.columnar.Unlock()
+	if r.columnar.indexes != nil {
+		ctx := r.AnnotateCtx(context.Background())
+		for _, idx := range r.columnar.indexes {
+			idx.acc.Close(ctx)
+		}
+		r.columnar.gen++
+		r.columnar.indexes = nil
+	}
+}
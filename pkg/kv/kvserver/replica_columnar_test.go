@@ -0,0 +1,93 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catid"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnarIndexSpan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	codec := keys.SystemSQLCodec
+	spec := &fetchpb.IndexFetchSpec{TableID: 106, IndexID: 2}
+	indexPrefix := codec.IndexPrefix(106, 2)
+	desc := func(startKey, endKey roachpb.Key) *roachpb.RangeDescriptor {
+		return &roachpb.RangeDescriptor{
+			StartKey: roachpb.RKey(startKey),
+			EndKey:   roachpb.RKey(endKey),
+		}
+	}
+
+	// The range contains the whole table.
+	span, ok := columnarIndexSpan(desc(codec.TablePrefix(106), codec.TablePrefix(107)), spec)
+	require.True(t, ok)
+	require.Equal(t, roachpb.Span{Key: indexPrefix, EndKey: indexPrefix.PrefixEnd()}, span)
+
+	// The range contains part of the index.
+	midKey := append(indexPrefix.Clone(), 0x90)
+	span, ok = columnarIndexSpan(desc(midKey, codec.TablePrefix(107)), spec)
+	require.True(t, ok)
+	require.Equal(t, roachpb.Span{Key: midKey, EndKey: indexPrefix.PrefixEnd()}, span)
+
+	// The range doesn't contain the index.
+	_, ok = columnarIndexSpan(desc(codec.TablePrefix(107), codec.TablePrefix(108)), spec)
+	require.False(t, ok)
+}
+
+func TestColumnarBuildSpec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	makeSpec := func(maxKeysPerRow uint32, colIDs ...catid.ColumnID) *fetchpb.IndexFetchSpec {
+		spec := &fetchpb.IndexFetchSpec{TableID: 106, IndexID: 1, MaxKeysPerRow: maxKeysPerRow}
+		for _, id := range colIDs {
+			spec.FetchedColumns = append(spec.FetchedColumns, fetchpb.IndexFetchSpec_Column{ColumnID: id})
+		}
+		spec.ColumnBounds = []fetchpb.IndexFetchSpec_ColumnBound{{ColumnID: colIDs[0]}}
+		return spec
+	}
+	colIDs := func(spec *fetchpb.IndexFetchSpec) []catid.ColumnID {
+		var ids []catid.ColumnID
+		for _, col := range spec.FetchedColumns {
+			ids = append(ids, col.ColumnID)
+		}
+		return ids
+	}
+
+	// Without blocks, the requested columns are built.
+	spec := makeSpec(1, 2, 1)
+	buildSpec := columnarBuildSpec(spec, nil /* blocks */)
+	require.Equal(t, []catid.ColumnID{2, 1}, colIDs(buildSpec))
+	require.Nil(t, buildSpec.ColumnBounds)
+	require.NotNil(t, spec.ColumnBounds)
+
+	// The columns of the current blocks are kept.
+	blocks := &storage.ColumnarBlocks{Spec: makeSpec(1, 1, 3)}
+	buildSpec = columnarBuildSpec(spec, blocks)
+	require.Equal(t, []catid.ColumnID{2, 1, 3}, colIDs(buildSpec))
+	require.Equal(t, []catid.ColumnID{2, 1}, colIDs(spec))
+
+	// Unless the schema of the index changed.
+	blocks = &storage.ColumnarBlocks{Spec: makeSpec(2, 1, 3)}
+	buildSpec = columnarBuildSpec(spec, blocks)
+	require.Equal(t, []catid.ColumnID{2, 1}, colIDs(buildSpec))
+}
@@ -666,17 +666,22 @@ func (r *Replica) AdminMerge(
 		// queues should fix things up quickly).
 		lReplicas, rReplicas := origLeftDesc.Replicas(), rightDesc.Replicas()
 
-		if len(lReplicas.VoterFullAndNonVoterDescriptors()) != len(lReplicas.Descriptors()) {
+		if len(lReplicas.VoterFullAndNonVoterDescriptors())+len(lReplicas.WitnessDescriptors()) !=
+			len(lReplicas.Descriptors()) {
 			return errors.Errorf("cannot merge ranges when lhs is in a joint state or has learners: %s",
 				lReplicas)
 		}
-		if len(rReplicas.VoterFullAndNonVoterDescriptors()) != len(rReplicas.Descriptors()) {
+		if len(rReplicas.VoterFullAndNonVoterDescriptors())+len(rReplicas.WitnessDescriptors()) !=
+			len(rReplicas.Descriptors()) {
 			return errors.Errorf("cannot merge ranges when rhs is in a joint state or has learners: %s",
 				rReplicas)
 		}
 		if !replicasCollocated(lReplicas.Descriptors(), rReplicas.Descriptors()) {
 			return errors.Errorf("ranges not collocated; %s != %s", lReplicas, rReplicas)
 		}
+		if !witnessesCollocated(lReplicas.Descriptors(), rReplicas.Descriptors()) {
+			return errors.Errorf("witnesses not collocated; %s != %s", lReplicas, rReplicas)
+		}
 
 		disableWaitForReplicasInTesting := r.store.TestingKnobs() != nil &&
 			r.store.TestingKnobs().DisableMergeWaitForReplicasInit
@@ -1044,6 +1049,8 @@ func (r *Replica) changeReplicasImpl(
 	// 3. Voter removals
 	// 4. Non-voter additions
 	// 5. Non-voter removals
+	// 6. Witness additions and removals (which are never combined with other
+	//    changes; see validateWitnessChanges)
 	//
 	// This order is meant to be symmetric with how the allocator prioritizes
 	// these actions. Broadly speaking, we first want to add a missing voter (and
@@ -1155,6 +1162,54 @@ func (r *Replica) changeReplicasImpl(
 		}
 	}
 
+	if adds := targets.WitnessAdditions; len(adds) > 0 {
+		// Witnesses are added like voters, as a LEARNER that receives an initial
+		// snapshot, except that the snapshot omits the range's user data and
+		// that they're then promoted to a WITNESS in a simple config change. The
+		// learner applies the writes to the user data until then, which it drops
+		// when it applies its promotion.
+		desc, err = r.initializeRaftLearners(
+			ctx, desc, priority, senderName, senderQueuePriority, reason, details, adds, roachpb.WITNESS,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range adds {
+			iChgs := []internalReplicationChange{{target: target, typ: internalChangeTypePromoteLearnerToWitness}}
+			desc, err = execChangeReplicasTxn(ctx, r.store.cfg.Tracer(), desc, reason, details, iChgs,
+				changeReplicasTxnArgs{
+					db:                                   r.store.DB(),
+					liveAndDeadReplicas:                  r.store.cfg.StorePool.LiveAndDeadReplicas,
+					logChange:                            r.store.logChange,
+					testForceJointConfig:                 r.store.TestingKnobs().ReplicationAlwaysUseJointConfig,
+					testAllowDangerousReplicationChanges: r.store.TestingKnobs().AllowDangerousReplicationChanges,
+				})
+			if err != nil {
+				log.Infof(ctx, "could not promote %v to witness, rolling back: %v", target, err)
+				r.tryRollbackRaftLearner(ctx, r.Desc(), target, reason, details)
+				return nil, err
+			}
+		}
+	}
+
+	if removals := targets.WitnessRemovals; len(removals) > 0 {
+		for _, rem := range removals {
+			iChgs := []internalReplicationChange{{target: rem, typ: internalChangeTypeRemoveWitness}}
+			var err error
+			desc, err = execChangeReplicasTxn(ctx, r.store.cfg.Tracer(), desc, reason, details, iChgs,
+				changeReplicasTxnArgs{
+					db:                                   r.store.DB(),
+					liveAndDeadReplicas:                  r.store.cfg.StorePool.LiveAndDeadReplicas,
+					logChange:                            r.store.logChange,
+					testForceJointConfig:                 r.store.TestingKnobs().ReplicationAlwaysUseJointConfig,
+					testAllowDangerousReplicationChanges: r.store.TestingKnobs().AllowDangerousReplicationChanges,
+				})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	if len(targets.VoterDemotions) > 0 {
 		// If we demoted or swapped any voters with non-voters, we likely are in a
 		// joint config or have learners on the range. Let's exit the joint config
@@ -1171,6 +1226,7 @@ type TargetsForReplicationChanges struct {
 	VoterDemotions, NonVoterPromotions  []roachpb.ReplicationTarget
 	VoterAdditions, VoterRemovals       []roachpb.ReplicationTarget
 	NonVoterAdditions, NonVoterRemovals []roachpb.ReplicationTarget
+	WitnessAdditions, WitnessRemovals   []roachpb.ReplicationTarget
 }
 
 // SynthesizeTargetsByChangeType groups replication changes in the
@@ -1197,6 +1253,10 @@ func SynthesizeTargetsByChangeType(
 	result.NonVoterAdditions = subtractTargets(chgs.NonVoterAdditions(), chgs.VoterRemovals())
 	result.NonVoterRemovals = subtractTargets(chgs.NonVoterRemovals(), chgs.VoterAdditions())
 
+	// Witnesses are never promoted or demoted.
+	result.WitnessAdditions = chgs.WitnessAdditions()
+	result.WitnessRemovals = chgs.WitnessRemovals()
+
 	return result
 }
 
@@ -1455,6 +1515,10 @@ func validateAdditionsPerStore(
 					return errors.AssertionFailedf(
 						"trying to add a non-voter to a store that already has a %s", t)
 				}
+			case roachpb.WITNESS:
+				// Witnesses can't be swapped with any other type of replica.
+				return errors.AssertionFailedf(
+					"trying to add(%+v) to a store that already has a %s", chg, t)
 			default:
 				return errors.AssertionFailedf("store(%d) being added to already contains a"+
 					" replica of an unexpected type: %s", storeID, t)
@@ -1489,6 +1553,11 @@ func validateRemovals(desc *roachpb.RangeDescriptor, chgsByStoreID changesByStor
 					return errors.AssertionFailedf("type of replica being removed (%s) does not match"+
 						" expectation for change: %+v", t, chg)
 				}
+			case roachpb.WITNESS:
+				if chg.ChangeType != roachpb.REMOVE_WITNESS {
+					return errors.AssertionFailedf("type of replica being removed (%s) does not match"+
+						" expectation for change: %+v", t, chg)
+				}
 			default:
 				return errors.AssertionFailedf("unexpected replica type for removal %+v: %s", chg, t)
 			}
@@ -1554,6 +1623,22 @@ func validatePromotionsAndDemotions(
 	return nil
 }
 
+// validateWitnessChanges ensures that witnesses are added or removed on their
+// own. Witness changes are executed as simple config changes, one at a time, and
+// are never part of a rebalance or of an atomic replication change.
+func validateWitnessChanges(chgs kvpb.ReplicationChanges) error {
+	if len(chgs) <= 1 {
+		return nil
+	}
+	for _, chg := range chgs {
+		if chg.ChangeType == roachpb.ADD_WITNESS || chg.ChangeType == roachpb.REMOVE_WITNESS {
+			return errors.AssertionFailedf("witness changes can't be combined with other"+
+				" changes: %+v", chgs)
+		}
+	}
+	return nil
+}
+
 // validateOneReplicaPerNode ensures that there are no more than 2 changes for
 // any given node and if a node already has a replica, then adding a second
 // replica is prohibited unless the existing replica is being removed with it.
@@ -1634,10 +1719,15 @@ func validateOneReplicaPerNode(desc *roachpb.RangeDescriptor, chgsByNodeID chang
 // 5. We're not removing a replica that doesn't exist.
 // 6. Additions to stores that already contain a replica are strictly the ones
 // that correspond to a voter demotion and/or a non-voter promotion
+// 7. Witnesses are added or removed one at a time, without any other changes.
 func validateReplicationChanges(desc *roachpb.RangeDescriptor, chgs kvpb.ReplicationChanges) error {
 	chgsByStoreID := getChangesByStoreID(chgs)
 	chgsByNodeID := getChangesByNodeID(chgs)
 
+	if err := validateWitnessChanges(chgs); err != nil {
+		return err
+	}
+
 	if err := validateAdditionsPerStore(desc, chgsByStoreID); err != nil {
 		return err
 	}
@@ -1691,6 +1781,9 @@ func getChangesByNodeID(chgs kvpb.ReplicationChanges) changesByNodeID {
 // that snapshot. Otherwise, if we get any errors trying to add or upreplicate
 // any of these learners, this function will clean up after itself by rolling all
 // of them back.
+//
+// A replicaType of WITNESS adds LEARNERs too, but sends them a snapshot without
+// the range's user data, since they're about to be promoted to witnesses.
 func (r *Replica) initializeRaftLearners(
 	ctx context.Context,
 	desc *roachpb.RangeDescriptor,
@@ -1703,9 +1796,13 @@ func (r *Replica) initializeRaftLearners(
 	replicaType roachpb.ReplicaType,
 ) (afterDesc *roachpb.RangeDescriptor, err error) {
 	var iChangeType internalChangeType
+	addedType := replicaType
 	switch replicaType {
 	case roachpb.LEARNER:
 		iChangeType = internalChangeTypeAddLearner
+	case roachpb.WITNESS:
+		iChangeType = internalChangeTypeAddLearner
+		addedType = roachpb.LEARNER
 	case roachpb.NON_VOTER:
 		iChangeType = internalChangeTypeAddNonVoter
 	default:
@@ -1819,7 +1916,7 @@ func (r *Replica) initializeRaftLearners(
 			return nil, errors.Errorf("programming error: replica %v not found in %v", target, desc)
 		}
 
-		if rDesc.Type != replicaType {
+		if rDesc.Type != addedType {
 			return nil, errors.Errorf("programming error: cannot promote replica of type %s", rDesc.Type)
 		}
 
@@ -1845,6 +1942,7 @@ func (r *Replica) initializeRaftLearners(
 		// these, it would be susceptible to future similar issues.
 		if err := r.sendSnapshotUsingDelegate(
 			ctx, rDesc, kvserverpb.SnapshotRequest_INITIAL, priority, senderName, senderQueuePriority,
+			replicaType == roachpb.WITNESS, /* witness */
 		); err != nil {
 			return nil, err
 		}
@@ -2036,6 +2134,11 @@ const (
 	// https://github.com/cockroachdb/cockroach/pull/40268
 	internalChangeTypeRemoveLearner
 	internalChangeTypeRemoveNonVoter
+	// internalChangeTypePromoteLearnerToWitness turns a learner, which has been
+	// sent its initial snapshot, into a witness. Like the removal of a witness,
+	// this is always a simple config change.
+	internalChangeTypePromoteLearnerToWitness
+	internalChangeTypeRemoveWitness
 )
 
 // internalReplicationChange is a replication target together with an internal
@@ -2057,6 +2160,10 @@ func (c internalReplicationChanges) useJoint() bool {
 		c[0].typ == internalChangeTypeDemoteVoterToLearner
 	return len(c) > 1 || isDemotion
 }
+func (c internalReplicationChanges) isWitnessChange() bool {
+	return len(c) == 1 && (c[0].typ == internalChangeTypePromoteLearnerToWitness ||
+		c[0].typ == internalChangeTypeRemoveWitness)
+}
 func (c internalReplicationChanges) isSingleLearnerRemoval() bool {
 	return len(c) == 1 && c[0].typ == internalChangeTypeRemoveLearner
 }
@@ -2078,7 +2185,8 @@ func prepareChangeReplicasTrigger(
 		}
 
 		useJoint := chgs.useJoint()
-		if fn := testingForceJointConfig; fn != nil && fn() {
+		// NB: witnesses are never part of an atomic replication change.
+		if fn := testingForceJointConfig; fn != nil && fn() && !chgs.isWitnessChange() {
 			useJoint = true
 		}
 		for _, chg := range chgs {
@@ -2129,6 +2237,21 @@ func prepareChangeReplicasTrigger(
 					rDesc, _, _ = updatedDesc.SetReplicaType(chg.target.NodeID, chg.target.StoreID, roachpb.VOTER_OUTGOING)
 				}
 				removed = append(removed, rDesc)
+			case internalChangeTypePromoteLearnerToWitness:
+				rDesc, prevTyp, ok := updatedDesc.SetReplicaType(chg.target.NodeID, chg.target.StoreID, roachpb.WITNESS)
+				if !ok || prevTyp != roachpb.LEARNER {
+					return nil, errors.Errorf("cannot promote target %v which is missing as LEARNER",
+						chg.target)
+				}
+				added = append(added, rDesc)
+			case internalChangeTypeRemoveWitness:
+				rDesc, ok := updatedDesc.GetReplicaDescriptor(chg.target.StoreID)
+				if !ok || rDesc.Type != roachpb.WITNESS {
+					return nil, errors.Errorf("cannot remove target %v which is missing as WITNESS",
+						chg.target)
+				}
+				rDesc, _ = updatedDesc.RemoveReplica(chg.target.NodeID, chg.target.StoreID)
+				removed = append(removed, rDesc)
 			case internalChangeTypeDemoteVoterToLearner:
 				// Demotion is similar to removal, except that a demotion
 				// cannot apply to a learner, and that the resulting type is
@@ -2516,18 +2639,20 @@ func recordRangeEventsInLog(
 	logChange logChangeFn,
 ) error {
 	for _, repDesc := range repDescs {
-		isNonVoter := repDesc.Type == roachpb.NON_VOTER
 		var typ roachpb.ReplicaChangeType
-		if added {
+		switch {
+		case added && repDesc.Type == roachpb.NON_VOTER:
+			typ = roachpb.ADD_NON_VOTER
+		case added && repDesc.Type == roachpb.WITNESS:
+			typ = roachpb.ADD_WITNESS
+		case added:
 			typ = roachpb.ADD_VOTER
-			if isNonVoter {
-				typ = roachpb.ADD_NON_VOTER
-			}
-		} else {
+		case repDesc.Type == roachpb.NON_VOTER:
+			typ = roachpb.REMOVE_NON_VOTER
+		case repDesc.Type == roachpb.WITNESS:
+			typ = roachpb.REMOVE_WITNESS
+		default:
 			typ = roachpb.REMOVE_VOTER
-			if isNonVoter {
-				typ = roachpb.REMOVE_NON_VOTER
-			}
 		}
 		if err := logChange(
 			ctx, txn, typ, repDesc, *rangeDesc, reason, details, logAsync,
@@ -2651,7 +2776,8 @@ func (r *Replica) getSenderReplicas(
 // sendSnapshotUsingDelegate sends a snapshot of the replica state to the specified
 // replica through a delegate. Currently, only invoked from replicateQueue and
 // raftSnapshotQueue. Be careful about adding additional calls as generating a
-// snapshot is moderately expensive.
+// snapshot is moderately expensive. If witness is set, or if the recipient is a
+// witness, the snapshot omits the range's user data.
 //
 // A snapshot is a bulk transfer of all data in a range. It consists of a
 // consistent view of all the state needed to run some replica of a range as of
@@ -2777,6 +2903,7 @@ func (r *Replica) sendSnapshotUsingDelegate(
 	priority kvserverpb.SnapshotRequest_Priority,
 	senderQueueName kvserverpb.SnapshotRequest_QueueName,
 	senderQueuePriority float64,
+	witness bool,
 ) (retErr error) {
 
 	defer func() {
@@ -2841,6 +2968,7 @@ func (r *Replica) sendSnapshotUsingDelegate(
 		DescriptorGeneration: r.Desc().Generation,
 		QueueOnDelegateLen:   MaxQueueOnDelegateLimit.Get(&r.ClusterSettings().SV),
 		SnapId:               snapUUID,
+		Witness:              witness || recipient.IsWitness(),
 	}
 
 	// Get the list of senders in order.
@@ -2848,6 +2976,19 @@ func (r *Replica) sendSnapshotUsingDelegate(
 	if err != nil {
 		return err
 	}
+	if !delegateRequest.Witness {
+		// Witnesses don't hold the range's user data, so they can't send snapshots
+		// to replicas that do. A witness coordinates snapshots only while it's the
+		// raft leader, until it hands the leadership to a voter (see
+		// maybeTransferRaftLeadershipFromWitnessLocked).
+		dataSenders := senders[:0]
+		for _, sender := range senders {
+			if !sender.IsWitness() {
+				dataSenders = append(dataSenders, sender)
+			}
+		}
+		senders = dataSenders
+	}
 
 	if len(senders) == 0 {
 		return errors.Errorf("no sender found to send a snapshot from for %v", r)
@@ -3160,6 +3301,10 @@ func (r *Replica) followerSendSnapshot(
 		SenderQueuePriority: req.SenderQueuePriority,
 		Strategy:            kvserverpb.SnapshotRequest_KV_BATCH,
 		Type:                req.Type,
+		Witness:             req.Witness || req.RecipientReplica.IsWitness(),
+	}
+	if !header.Witness && r.isWitness() {
+		return nil, errors.Errorf("%s: witness can't send a snapshot to non-witness %s", r, req.RecipientReplica)
 	}
 	newBatchFn := func() storage.WriteBatch {
 		return r.store.TODOEngine().NewWriteBatch()
@@ -3239,6 +3384,17 @@ func replicasCollocated(a, b []roachpb.ReplicaDescriptor) bool {
 	return true
 }
 
+// witnessesCollocated returns whether the witnesses of the two sets of replicas
+// are on the same stores. Together with replicasCollocated, this ensures that
+// every store holds either data-bearing replicas of both ranges or witnesses of
+// both ranges, as is required to merge them: a witness can't subsume the data
+// of the RHS, and a data-bearing replica can't subsume a witness.
+func witnessesCollocated(a, b []roachpb.ReplicaDescriptor) bool {
+	return replicasCollocated(
+		roachpb.MakeReplicaSet(a).WitnessDescriptors(), roachpb.MakeReplicaSet(b).WitnessDescriptors(),
+	)
+}
+
 func checkDescsEqual(
 	desc *roachpb.RangeDescriptor,
 ) func(*roachpb.RangeDescriptor) (matched bool, skip bool) {
@@ -15,6 +15,7 @@ import (
 	"crypto/sha512"
 	"encoding/binary"
 	"fmt"
+	"hash"
 	"os"
 	"sync"
 	"time"
@@ -82,9 +83,10 @@ func (r *Replica) CheckConsistency(
 	ctx context.Context, req kvpb.CheckConsistencyRequest,
 ) (kvpb.CheckConsistencyResponse, *kvpb.Error) {
 	return r.checkConsistencyImpl(ctx, kvpb.ComputeChecksumRequest{
-		RequestHeader: kvpb.RequestHeader{Key: r.Desc().StartKey.AsRawKey()},
-		Version:       batcheval.ReplicaChecksumVersion,
-		Mode:          req.Mode,
+		RequestHeader:    kvpb.RequestHeader{Key: r.Desc().StartKey.AsRawKey()},
+		Version:          batcheval.ReplicaChecksumVersion,
+		Mode:             req.Mode,
+		IncrementalStart: req.IncrementalStart,
 	})
 }
 
@@ -92,6 +94,10 @@ func (r *Replica) checkConsistencyImpl(
 	ctx context.Context, args kvpb.ComputeChecksumRequest,
 ) (kvpb.CheckConsistencyResponse, *kvpb.Error) {
 	isQueue := args.Mode == kvpb.ChecksumMode_CHECK_VIA_QUEUE
+	// Incremental and stats-only checks don't recompute the stats, so there is
+	// no delta to examine.
+	recomputesStats := args.Mode != kvpb.ChecksumMode_CHECK_STATS &&
+		args.Mode != kvpb.ChecksumMode_CHECK_INCREMENTAL
 
 	results, err := r.runConsistencyCheck(ctx, args)
 	if err != nil {
@@ -147,6 +153,15 @@ func (r *Replica) checkConsistencyImpl(
 			}
 		}
 
+		if args.Mode == kvpb.ChecksumMode_CHECK_INCREMENTAL {
+			res.DivergingSpans = divergingSpans(results)
+			if len(res.DivergingSpans) > 0 {
+				buf.Printf("diverging spans: %v\n", res.DivergingSpans)
+			} else {
+				buf.Printf("diverging spans: none, only the range applied state diverged\n")
+			}
+		}
+
 		if isQueue {
 			log.Errorf(ctx, "%v", &buf)
 		}
@@ -181,7 +196,7 @@ func (r *Replica) checkConsistencyImpl(
 	res.Status = kvpb.CheckConsistencyResponse_RANGE_CONSISTENT
 	if minoritySHA != "" {
 		res.Status = kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT
-	} else if args.Mode != kvpb.ChecksumMode_CHECK_STATS && haveDelta {
+	} else if recomputesStats && haveDelta {
 		if delta.ContainsEstimates > 0 {
 			// When ContainsEstimates is set, it's generally expected that we'll get a different
 			// result when we recompute from scratch.
@@ -325,7 +340,14 @@ func (r *Replica) runConsistencyCheck(
 	}
 	ccRes := res.(*kvpb.ComputeChecksumResponse)
 
-	replicas := r.Desc().Replicas().Descriptors()
+	// Witnesses hold no user data, so there is nothing to compare them on.
+	// Learners that are about to be promoted to witnesses only hold the user
+	// data written since their snapshot, and can't be told apart from other
+	// learners, so skip all learners. Those promoted to voters are checked once
+	// they are.
+	replicas := r.Desc().Replicas().Filter(func(rDesc roachpb.ReplicaDescriptor) bool {
+		return !rDesc.IsWitness() && rDesc.Type != roachpb.LEARNER
+	}).Descriptors()
 	resultCh := make(chan ConsistencyCheckResult, len(replicas))
 	results := make([]ConsistencyCheckResult, 0, len(replicas))
 
@@ -473,6 +495,7 @@ func (*Replica) computeChecksumDone(rc *replicaChecksum, result *ReplicaDigest)
 		delta.Subtract(result.RecomputedMS)
 		c.Delta = enginepb.MVCCStatsDelta(delta)
 		c.Persisted = result.PersistedMS
+		c.Chunks = result.Chunks
 	}
 
 	// Sending succeeds because the channel is buffered, and there is at most one
@@ -487,6 +510,115 @@ type ReplicaDigest struct {
 	SHA512       [sha512.Size]byte
 	PersistedMS  enginepb.MVCCStats
 	RecomputedMS enginepb.MVCCStats
+	// Chunks contains the checksums of the chunks of keys hashed in the
+	// CHECK_INCREMENTAL mode.
+	Chunks []ChecksumChunk
+}
+
+// checksumLimiterWait requests quota from the consistency check rate limiter
+// in chunks of at least targetBatchSize, to amortize the overhead of the
+// limiter when reading many small KVs.
+type checksumLimiterWait struct {
+	ctx       context.Context
+	limiter   *quotapool.RateLimiter
+	batchSize int64
+}
+
+func makeChecksumLimiterWait(
+	ctx context.Context, limiter *quotapool.RateLimiter,
+) checksumLimiterWait {
+	return checksumLimiterWait{ctx: ctx, limiter: limiter}
+}
+
+// add accounts for size bytes read, and waits for the quota if the batch is
+// full.
+func (w *checksumLimiterWait) add(size int64) error {
+	const targetBatchSize = int64(256 << 10) // 256 KiB
+	if w.batchSize += size; w.batchSize < targetBatchSize {
+		return nil
+	}
+	return w.flush()
+}
+
+// flush waits for the quota borrowed by the current batch.
+func (w *checksumLimiterWait) flush() error {
+	tokens := w.batchSize
+	w.batchSize = 0
+	return w.limiter.WaitN(w.ctx, tokens)
+}
+
+// kvHasher hashes MVCC point and range keys, and their values.
+type kvHasher struct {
+	hash.Hash
+	intBuf          [8]byte
+	legacyTimestamp hlc.LegacyTimestamp
+	timestampBuf    []byte
+}
+
+// writeLen hashes the given length.
+func (h *kvHasher) writeLen(n int) error {
+	binary.LittleEndian.PutUint64(h.intBuf[:], uint64(n))
+	_, err := h.Write(h.intBuf[:])
+	return err
+}
+
+// writeTimestamp hashes the given timestamp.
+func (h *kvHasher) writeTimestamp(ts hlc.Timestamp) error {
+	h.legacyTimestamp = ts.ToLegacyTimestamp()
+	if size := h.legacyTimestamp.Size(); size > cap(h.timestampBuf) {
+		h.timestampBuf = make([]byte, size)
+	} else {
+		h.timestampBuf = h.timestampBuf[:size]
+	}
+	if _, err := protoutil.MarshalToSizedBuffer(&h.legacyTimestamp, h.timestampBuf); err != nil {
+		return err
+	}
+	_, err := h.Write(h.timestampBuf)
+	return err
+}
+
+// hashPointKey hashes the given MVCC point key and value.
+func (h *kvHasher) hashPointKey(unsafeKey storage.MVCCKey, unsafeValue []byte) error {
+	// Encode the length of the key and value.
+	if err := h.writeLen(len(unsafeKey.Key)); err != nil {
+		return err
+	}
+	if err := h.writeLen(len(unsafeValue)); err != nil {
+		return err
+	}
+	if _, err := h.Write(unsafeKey.Key); err != nil {
+		return err
+	}
+	if err := h.writeTimestamp(unsafeKey.Timestamp); err != nil {
+		return err
+	}
+	_, err := h.Write(unsafeValue)
+	return err
+}
+
+// hashRangeKey hashes the given MVCC range key and value.
+func (h *kvHasher) hashRangeKey(rangeKV storage.MVCCRangeKeyValue) error {
+	// Encode the length of the start key and end key.
+	if err := h.writeLen(len(rangeKV.RangeKey.StartKey)); err != nil {
+		return err
+	}
+	if err := h.writeLen(len(rangeKV.RangeKey.EndKey)); err != nil {
+		return err
+	}
+	if err := h.writeLen(len(rangeKV.Value)); err != nil {
+		return err
+	}
+	if _, err := h.Write(rangeKV.RangeKey.StartKey); err != nil {
+		return err
+	}
+	if _, err := h.Write(rangeKV.RangeKey.EndKey); err != nil {
+		return err
+	}
+	if err := h.writeTimestamp(rangeKV.RangeKey.Timestamp); err != nil {
+		return err
+	}
+	_, err := h.Write(rangeKV.Value)
+	return err
 }
 
 // CalcReplicaDigest computes the SHA512 hash and MVCC stats of the replica data
@@ -502,97 +634,25 @@ func CalcReplicaDigest(
 	statsOnly := mode == kvpb.ChecksumMode_CHECK_STATS
 
 	// Iterate over all the data in the range.
-	var intBuf [8]byte
-	var legacyTimestamp hlc.LegacyTimestamp
-	var timestampBuf []byte
-	hasher := sha512.New()
-
-	// Request quota from the limiter in chunks of at least targetBatchSize, to
-	// amortize the overhead of the limiter when reading many small KVs.
-	var batchSize int64
-	const targetBatchSize = int64(256 << 10) // 256 KiB
-	wait := func(size int64) error {
-		if batchSize += size; batchSize < targetBatchSize {
-			return nil
-		}
-		tokens := batchSize
-		batchSize = 0
-		return limiter.WaitN(ctx, tokens)
-	}
+	hasher := kvHasher{Hash: sha512.New()}
+	wait := makeChecksumLimiterWait(ctx, limiter)
 
 	pointKeyVisitor := func(unsafeKey storage.MVCCKey, unsafeValue []byte) error {
 		// Rate limit the scan through the range.
-		if err := wait(int64(len(unsafeKey.Key) + len(unsafeValue))); err != nil {
-			return err
-		}
-		// Encode the length of the key and value.
-		binary.LittleEndian.PutUint64(intBuf[:], uint64(len(unsafeKey.Key)))
-		if _, err := hasher.Write(intBuf[:]); err != nil {
-			return err
-		}
-		binary.LittleEndian.PutUint64(intBuf[:], uint64(len(unsafeValue)))
-		if _, err := hasher.Write(intBuf[:]); err != nil {
-			return err
-		}
-		if _, err := hasher.Write(unsafeKey.Key); err != nil {
-			return err
-		}
-		legacyTimestamp = unsafeKey.Timestamp.ToLegacyTimestamp()
-		if size := legacyTimestamp.Size(); size > cap(timestampBuf) {
-			timestampBuf = make([]byte, size)
-		} else {
-			timestampBuf = timestampBuf[:size]
-		}
-		if _, err := protoutil.MarshalToSizedBuffer(&legacyTimestamp, timestampBuf); err != nil {
-			return err
-		}
-		if _, err := hasher.Write(timestampBuf); err != nil {
+		if err := wait.add(int64(len(unsafeKey.Key) + len(unsafeValue))); err != nil {
 			return err
 		}
-		_, err := hasher.Write(unsafeValue)
-		return err
+		return hasher.hashPointKey(unsafeKey, unsafeValue)
 	}
 
 	rangeKeyVisitor := func(rangeKV storage.MVCCRangeKeyValue) error {
 		// Rate limit the scan through the range.
-		err := wait(
+		err := wait.add(
 			int64(len(rangeKV.RangeKey.StartKey) + len(rangeKV.RangeKey.EndKey) + len(rangeKV.Value)))
 		if err != nil {
 			return err
 		}
-		// Encode the length of the start key and end key.
-		binary.LittleEndian.PutUint64(intBuf[:], uint64(len(rangeKV.RangeKey.StartKey)))
-		if _, err := hasher.Write(intBuf[:]); err != nil {
-			return err
-		}
-		binary.LittleEndian.PutUint64(intBuf[:], uint64(len(rangeKV.RangeKey.EndKey)))
-		if _, err := hasher.Write(intBuf[:]); err != nil {
-			return err
-		}
-		binary.LittleEndian.PutUint64(intBuf[:], uint64(len(rangeKV.Value)))
-		if _, err := hasher.Write(intBuf[:]); err != nil {
-			return err
-		}
-		if _, err := hasher.Write(rangeKV.RangeKey.StartKey); err != nil {
-			return err
-		}
-		if _, err := hasher.Write(rangeKV.RangeKey.EndKey); err != nil {
-			return err
-		}
-		legacyTimestamp = rangeKV.RangeKey.Timestamp.ToLegacyTimestamp()
-		if size := legacyTimestamp.Size(); size > cap(timestampBuf) {
-			timestampBuf = make([]byte, size)
-		} else {
-			timestampBuf = timestampBuf[:size]
-		}
-		if _, err := protoutil.MarshalToSizedBuffer(&legacyTimestamp, timestampBuf); err != nil {
-			return err
-		}
-		if _, err := hasher.Write(timestampBuf); err != nil {
-			return err
-		}
-		_, err = hasher.Write(rangeKV.Value)
-		return err
+		return hasher.hashRangeKey(rangeKV)
 	}
 
 	// In statsOnly mode, we hash only the RangeAppliedState. In regular mode, hash
@@ -603,7 +663,7 @@ func CalcReplicaDigest(
 			pointKeyVisitor, rangeKeyVisitor)
 		// Consume the remaining quota borrowed in the visitors. Do it even on
 		// iteration error, but prioritize returning the latter if it occurs.
-		if wErr := limiter.WaitN(ctx, batchSize); wErr != nil && err == nil {
+		if wErr := wait.flush(); wErr != nil && err == nil {
 			err = wErr
 		}
 		if err != nil {
@@ -717,7 +777,14 @@ func (r *Replica) computeChecksumPostApply(
 		); err != nil {
 			log.Errorf(ctx, "checksum collection did not join: %v", err)
 		} else {
-			result, err := CalcReplicaDigest(ctx, desc, snap, cc.Mode, r.store.consistencyLimiter)
+			var result *ReplicaDigest
+			var err error
+			if cc.Mode == kvpb.ChecksumMode_CHECK_INCREMENTAL {
+				result, err = CalcIncrementalReplicaDigest(ctx, desc, snap, cc.IncrementalStart,
+					r.store.consistencyLimiter)
+			} else {
+				result, err = CalcReplicaDigest(ctx, desc, snap, cc.Mode, r.store.consistencyLimiter)
+			}
 			if err != nil {
 				log.Errorf(ctx, "checksum computation failed: %v", err)
 				result = nil
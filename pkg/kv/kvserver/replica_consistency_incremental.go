@@ -0,0 +1,272 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"crypto/sha512"
+	"hash/crc32"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/stateloader"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+)
+
+// checksumChunkMask determines the average number of distinct keys in a chunk
+// hashed by an incremental consistency check. A key starts a new chunk iff the
+// masked bits of its hash are all zero.
+const checksumChunkMask = 1<<8 - 1
+
+// isChunkBoundary returns whether the given key starts a new chunk. The chunk
+// boundaries depend only on the keys themselves, so a key which is present on
+// some replicas and missing on others only affects the chunk it belongs to,
+// and the rest of the chunks line up across replicas.
+func isChunkBoundary(key roachpb.Key) bool {
+	return crc32.ChecksumIEEE(key)&checksumChunkMask == 0
+}
+
+// checksumChunker hashes the keys of an incremental consistency check in
+// chunks of contiguous keys. The keys must be added in key order, with all the
+// versions of a key added consecutively.
+type checksumChunker struct {
+	kvHasher
+	chunks []ChecksumChunk
+	// startKey is the first key of the current chunk, or nil if the current
+	// chunk is empty.
+	startKey roachpb.Key
+	// lastKey is the last key added.
+	lastKey roachpb.Key
+	// rangeEnd is the largest end key of the range keys in the current chunk.
+	rangeEnd roachpb.Key
+}
+
+// add starts a new chunk if necessary before the given key is hashed. For a
+// range key, key is its start key and rangeEnd its end key.
+func (c *checksumChunker) add(key, rangeEnd roachpb.Key) {
+	if !key.Equal(c.lastKey) {
+		if c.startKey != nil && isChunkBoundary(key) {
+			c.finish()
+		}
+		if c.startKey == nil {
+			c.startKey = key.Clone()
+		}
+		c.lastKey = append(c.lastKey[:0], key...)
+	}
+	if rangeEnd.Compare(c.rangeEnd) > 0 {
+		c.rangeEnd = append(c.rangeEnd[:0], rangeEnd...)
+	}
+}
+
+// finish completes the current chunk, if it is not empty.
+func (c *checksumChunker) finish() {
+	if c.startKey == nil {
+		return
+	}
+	endKey := c.lastKey.Next()
+	if c.rangeEnd.Compare(endKey) > 0 {
+		endKey = c.rangeEnd.Clone()
+	}
+	c.chunks = append(c.chunks, ChecksumChunk{
+		Span:     roachpb.Span{Key: c.startKey, EndKey: endKey},
+		Checksum: c.Sum(nil),
+	})
+	c.Reset()
+	c.startKey, c.rangeEnd = nil, c.rangeEnd[:0]
+}
+
+// incrementalChecksumSpans returns the key spans checked by an incremental
+// consistency check of the given range: its user key span, except for the
+// timeseries keys. The timeseries are stored as inline values which don't
+// carry timestamps, so they can't be checked incrementally.
+func incrementalChecksumSpans(desc *roachpb.RangeDescriptor) []roachpb.Span {
+	span := desc.KeySpan().AsRawSpanWithNoLocals()
+	tsSpan := roachpb.Span{Key: keys.TimeseriesPrefix, EndKey: keys.TimeseriesKeyMax}
+	if !span.Overlaps(tsSpan) {
+		return []roachpb.Span{span}
+	}
+	var spans []roachpb.Span
+	if span.Key.Compare(tsSpan.Key) < 0 {
+		spans = append(spans, roachpb.Span{Key: span.Key, EndKey: tsSpan.Key})
+	}
+	if span.EndKey.Compare(tsSpan.EndKey) > 0 {
+		spans = append(spans, roachpb.Span{Key: tsSpan.EndKey, EndKey: span.EndKey})
+	}
+	return spans
+}
+
+// CalcIncrementalReplicaDigest computes the digest of the replica data at the
+// given snapshot in the CHECK_INCREMENTAL mode. It hashes the RangeAppliedState
+// (including MVCC stats), and the MVCC point and range keys in the user key
+// span of the range which were written above the given start timestamp. The
+// keys are hashed in chunks, whose checksums are returned in the digest.
+//
+// Intents are covered by their provisional values, but the intent metadata is
+// not hashed. Whether the incremental iterator emits the metadata of intents
+// outside the time bounds depends on the layout of the storage engine, which
+// differs across replicas.
+func CalcIncrementalReplicaDigest(
+	ctx context.Context,
+	desc roachpb.RangeDescriptor,
+	snap storage.Reader,
+	start hlc.Timestamp,
+	limiter *quotapool.RateLimiter,
+) (*ReplicaDigest, error) {
+	chunker := checksumChunker{kvHasher: kvHasher{Hash: sha512.New()}}
+	wait := makeChecksumLimiterWait(ctx, limiter)
+
+	iterate := func(span roachpb.Span) error {
+		iter := storage.NewMVCCIncrementalIterator(snap, storage.MVCCIncrementalIterOptions{
+			KeyTypes:     storage.IterKeyTypePointsAndRanges,
+			StartKey:     span.Key,
+			EndKey:       span.EndKey,
+			StartTime:    start, // exclusive
+			IntentPolicy: storage.MVCCIncrementalIterIntentPolicyEmit,
+		})
+		defer iter.Close()
+
+		// The iterator may surface the same range key fragment again after
+		// skipping keys outside the time bounds, which depends on the layout of
+		// the storage engine. Only hash each fragment once.
+		var lastRangeBounds roachpb.Span
+		for iter.SeekGE(storage.MVCCKey{Key: span.Key}); ; iter.Next() {
+			if ok, err := iter.Valid(); err != nil {
+				return err
+			} else if !ok {
+				return nil
+			}
+			hasPoint, hasRange := iter.HasPointAndRange()
+			if hasRange && iter.RangeKeyChanged() {
+				if bounds := iter.RangeBounds(); !bounds.Equal(lastRangeBounds) {
+					lastRangeBounds = bounds.Clone()
+					chunker.add(bounds.Key, bounds.EndKey)
+					for _, rangeKV := range iter.RangeKeys().AsRangeKeyValues() {
+						err := wait.add(int64(
+							len(rangeKV.RangeKey.StartKey) + len(rangeKV.RangeKey.EndKey) + len(rangeKV.Value)))
+						if err != nil {
+							return err
+						}
+						if err := chunker.hashRangeKey(rangeKV); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			if !hasPoint {
+				continue
+			}
+			unsafeKey := iter.UnsafeKey()
+			if !unsafeKey.IsValue() {
+				continue // intent metadata
+			}
+			unsafeValue, err := iter.UnsafeValue()
+			if err != nil {
+				return err
+			}
+			if err := wait.add(int64(len(unsafeKey.Key) + len(unsafeValue))); err != nil {
+				return err
+			}
+			chunker.add(unsafeKey.Key, nil /* rangeEnd */)
+			if err := chunker.hashPointKey(unsafeKey, unsafeValue); err != nil {
+				return err
+			}
+		}
+	}
+	var err error
+	for _, span := range incrementalChecksumSpans(&desc) {
+		if err = iterate(span); err != nil {
+			break
+		}
+	}
+	// Consume the remaining quota borrowed during the iteration. Do it even on
+	// iteration error, but prioritize returning the latter if it occurs.
+	if wErr := wait.flush(); wErr != nil && err == nil {
+		err = wErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	chunker.finish()
+
+	rangeAppliedState, err := stateloader.Make(desc.RangeID).LoadRangeAppliedState(ctx, snap)
+	if err != nil {
+		return nil, err
+	}
+	result := ReplicaDigest{
+		PersistedMS: rangeAppliedState.RangeStats.ToStats(),
+		Chunks:      chunker.chunks,
+	}
+
+	// The digest of the replica covers the RangeAppliedState and the chunks.
+	hasher := kvHasher{Hash: sha512.New()}
+	b, err := protoutil.Marshal(rangeAppliedState)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := hasher.Write(b); err != nil {
+		return nil, err
+	}
+	for _, chunk := range result.Chunks {
+		if err := hasher.writeLen(len(chunk.Span.Key)); err != nil {
+			return nil, err
+		}
+		if err := hasher.writeLen(len(chunk.Span.EndKey)); err != nil {
+			return nil, err
+		}
+		if _, err := hasher.Write(chunk.Span.Key); err != nil {
+			return nil, err
+		}
+		if _, err := hasher.Write(chunk.Span.EndKey); err != nil {
+			return nil, err
+		}
+		if _, err := hasher.Write(chunk.Checksum); err != nil {
+			return nil, err
+		}
+	}
+	hasher.Sum(result.SHA512[:0])
+	return &result, nil
+}
+
+// divergingSpans returns the key spans in which the replicas diverge, judging
+// by the chunks of keys hashed by an incremental consistency check. A chunk
+// that isn't identical on all the replicas that returned a checksum indicates
+// a divergence in its span. Overlapping and adjacent spans are merged.
+func divergingSpans(results []ConsistencyCheckResult) []roachpb.Span {
+	type chunkKey struct {
+		key, endKey, checksum string
+	}
+	var replicas int
+	counts := map[chunkKey]int{}
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		replicas++
+		for _, chunk := range result.Response.Chunks {
+			counts[chunkKey{
+				key:      string(chunk.Span.Key),
+				endKey:   string(chunk.Span.EndKey),
+				checksum: string(chunk.Checksum),
+			}]++
+		}
+	}
+	var spans []roachpb.Span
+	for ck, count := range counts {
+		if count < replicas {
+			spans = append(spans, roachpb.Span{Key: roachpb.Key(ck.key), EndKey: roachpb.Key(ck.endKey)})
+		}
+	}
+	spans, _ = roachpb.MergeSpans(&spans)
+	return spans
+}
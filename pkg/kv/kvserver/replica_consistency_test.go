@@ -324,3 +324,86 @@ func TestReplicaChecksumSHA512(t *testing.T) {
 
 	echotest.Require(t, sb.String(), datapathutils.TestDataPath(t, "replica_consistency_sha512"))
 }
+
+// TestCalcIncrementalReplicaDigest checks that an incremental digest covers
+// only the data written above its start timestamp, and that the chunks of the
+// digests localize the divergences between replicas.
+func TestCalcIncrementalReplicaDigest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	unlim := quotapool.NewRateLimiter("test", quotapool.Inf(), 0)
+	desc := roachpb.RangeDescriptor{
+		RangeID:  1,
+		StartKey: roachpb.RKey("a"),
+		EndKey:   roachpb.RKey("z"),
+	}
+
+	// Write the same data to both engines, at timestamp 10.
+	const numKeys = 2000
+	eng1, eng2 := storage.NewDefaultInMemForTesting(), storage.NewDefaultInMemForTesting()
+	defer eng1.Close()
+	defer eng2.Close()
+	key := func(i int) roachpb.Key {
+		return roachpb.Key(fmt.Sprintf("k%04d", i))
+	}
+	ts := hlc.Timestamp{WallTime: 10}
+	for _, eng := range []storage.Engine{eng1, eng2} {
+		for i := 0; i < numKeys; i++ {
+			require.NoError(t, storage.MVCCPut(ctx, eng, key(i), ts,
+				roachpb.MakeValueFromString("v"), storage.MVCCWriteOptions{}))
+		}
+		require.NoError(t, storage.MVCCDeleteRangeUsingTombstone(
+			ctx, eng, nil, roachpb.Key("p"), roachpb.Key("q"), ts, hlc.ClockTimestamp{},
+			nil, nil, false, 0, nil))
+	}
+
+	digests := func(start hlc.Timestamp) (*ReplicaDigest, *ReplicaDigest) {
+		rd1, err := CalcIncrementalReplicaDigest(ctx, desc, eng1, start, unlim)
+		require.NoError(t, err)
+		rd2, err := CalcIncrementalReplicaDigest(ctx, desc, eng2, start, unlim)
+		require.NoError(t, err)
+		return rd1, rd2
+	}
+	results := func(rds ...*ReplicaDigest) []ConsistencyCheckResult {
+		var res []ConsistencyCheckResult
+		for _, rd := range rds {
+			res = append(res, ConsistencyCheckResult{Response: CollectChecksumResponse{
+				Checksum: rd.SHA512[:],
+				Chunks:   rd.Chunks,
+			}})
+		}
+		return res
+	}
+
+	// The data is covered iff it was written above the start timestamp.
+	rd1, rd2 := digests(hlc.Timestamp{WallTime: 5})
+	require.Equal(t, rd1.SHA512, rd2.SHA512)
+	require.Equal(t, rd1.Chunks, rd2.Chunks)
+	require.Greater(t, len(rd1.Chunks), 1)
+	require.Equal(t, roachpb.Key("k0000"), rd1.Chunks[0].Span.Key)
+	require.Equal(t, roachpb.Key("q"), rd1.Chunks[len(rd1.Chunks)-1].Span.EndKey)
+	rd1, rd2 = digests(ts)
+	require.Equal(t, rd1.SHA512, rd2.SHA512)
+	require.Empty(t, rd1.Chunks)
+
+	// Diverge below the start timestamp, which isn't detected.
+	require.NoError(t, storage.MVCCPut(ctx, eng2, roachpb.Key("k0500a"), hlc.Timestamp{WallTime: 1},
+		roachpb.MakeValueFromString("v"), storage.MVCCWriteOptions{}))
+	rd1, rd2 = digests(hlc.Timestamp{WallTime: 5})
+	require.Equal(t, rd1.SHA512, rd2.SHA512)
+	require.Empty(t, divergingSpans(results(rd1, rd2, rd1)))
+
+	// Diverge above the start timestamp, which is detected and localized.
+	diverged := roachpb.Key("k1000a")
+	require.NoError(t, storage.MVCCPut(ctx, eng2, diverged, hlc.Timestamp{WallTime: 7},
+		roachpb.MakeValueFromString("v"), storage.MVCCWriteOptions{}))
+	rd1, rd2 = digests(hlc.Timestamp{WallTime: 5})
+	require.NotEqual(t, rd1.SHA512, rd2.SHA512)
+	spans := divergingSpans(results(rd1, rd2, rd1))
+	require.Len(t, spans, 1)
+	require.True(t, spans[0].ContainsKey(diverged), "%s", spans[0])
+	require.True(t, spans[0].Key.Compare(key(0)) > 0 && spans[0].EndKey.Compare(key(numKeys-1)) < 0,
+		"diverging span %s not narrowed down", spans[0])
+}
@@ -101,6 +101,8 @@ func (r *Replica) postDestroyRaftMuLocked(ctx context.Context, ms enginepb.MVCCS
 		r.store.tenantRateLimiters.Release(r.tenantLimiter)
 	}
 
+	r.clearColumnarBlocks()
+
 	return nil
 }
 
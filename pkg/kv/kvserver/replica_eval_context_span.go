@@ -23,6 +23,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
@@ -260,6 +262,13 @@ func (rec *SpanSetReplicaEvalContext) GetResponseMemoryAccount() *mon.BoundAccou
 	return rec.i.GetResponseMemoryAccount()
 }
 
+// GetColumnarBlocks implements the batcheval.EvalContext interface.
+func (rec *SpanSetReplicaEvalContext) GetColumnarBlocks(
+	ctx context.Context, spec *fetchpb.IndexFetchSpec,
+) *storage.ColumnarBlocks {
+	return rec.i.GetColumnarBlocks(ctx, spec)
+}
+
 // GetMaxBytes implements the batcheval.EvalContext interface.
 func (rec *SpanSetReplicaEvalContext) GetMaxBytes() int64 {
 	return rec.i.GetMaxBytes()
@@ -0,0 +1,79 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/split"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+// HotKeySampleRate wraps "kv.hot_keys.sample_rate".
+var HotKeySampleRate = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"kv.hot_keys.sample_rate",
+	"the fraction of batches whose keys are sampled to find the hottest keys "+
+		"of each range, or 0 to disable hot key sampling",
+	0.1,
+	settings.NonNegativeFloatWithMaximum(1),
+)
+
+// recordBatchForHotKeys samples the keys accessed by the batch to find the
+// hottest keys of the range. Each request is attributed to its start key, and
+// counts the bytes it wrote or, for reads, the bytes it returned.
+func (r *Replica) recordBatchForHotKeys(ba *kvpb.BatchRequest, br *kvpb.BatchResponse) {
+	rate := HotKeySampleRate.Get(&r.store.cfg.Settings.SV)
+	if rate <= 0 || ba == nil || br == nil || len(ba.Requests) != len(br.Responses) {
+		return
+	}
+	if rate < 1 && rand.Float64() >= rate {
+		return
+	}
+	weight := 1 / rate
+	now := r.Clock().PhysicalTime()
+	for i := range ba.Requests {
+		req := ba.Requests[i].GetInner()
+		var bytes int
+		if kvpb.IsReadOnly(req) {
+			bytes = br.Responses[i].GetInner().Size()
+		} else {
+			bytes = req.Size()
+		}
+		r.hotKeys.Record(now, req.Header().Key, int64(bytes), weight)
+	}
+}
+
+// HotKeys returns the hottest keys of the range observed by this replica by
+// request rate, followed by the remaining hottest keys by byte rate.
+func (r *Replica) HotKeys(now time.Time) []split.HotKey {
+	hotKeys := r.hotKeys.HotKeys(now)
+	byBytes := r.hotKeys.HotKeysByBytes(now)
+	res := make([]split.HotKey, 0, len(hotKeys)+len(byBytes))
+	res = append(res, hotKeys...)
+	for _, hk := range byBytes {
+		found := false
+		for i := range hotKeys {
+			if hotKeys[i].Key.Equal(hk.Key) {
+				// The byte ranking estimates the key's byte rate more accurately.
+				res[i].BytesPerSecond, res[i].BytesErrorPerSecond = hk.BytesPerSecond, hk.BytesErrorPerSecond
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, hk)
+		}
+	}
+	return res
+}
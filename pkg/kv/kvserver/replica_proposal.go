@@ -415,6 +415,7 @@ func (r *Replica) leasePostApplyLocked(
 			r.loadStats.Reset()
 		}
 		r.loadBasedSplitter.Reset(r.Clock().PhysicalTime())
+		r.hotKeys.Reset(r.Clock().PhysicalTime())
 	}
 
 	// Inform the concurrency manager that the lease holder has been updated.
@@ -168,6 +168,54 @@ func (r *Replica) checkProtectedTimestampsForGC(
 	return true, read.readAt, gcTimestamp, oldThreshold, newThreshold, nil
 }
 
+// storageExpirationThreshold returns the timestamp below which keys, whose
+// newest version is older, have expired under the given storage-level TTL. An
+// empty timestamp is returned if the TTL is unset or expiration can't proceed.
+//
+// Expiration removes live data and thus changes the result of reads at all
+// timestamps, so unlike regular GC, which can always proceed up to the earliest
+// protection timestamp, it is disabled entirely while any protected timestamp
+// record applies to the range. Records that are written concurrently with a GC
+// run may observe expirations performed by that run.
+//
+// Expired keys are removed by GC requests, which aren't emitted on rangefeeds,
+// so expiration is also disabled while the range has rangefeed registrations,
+// lest they miss the deletion of live data. Paused changefeeds are covered by
+// the protected timestamp records they hold.
+func (r *Replica) storageExpirationThreshold(
+	ctx context.Context, storageTTL time.Duration,
+) (hlc.Timestamp, error) {
+	if storageTTL <= 0 {
+		return hlc.Timestamp{}, nil
+	}
+	if n := r.numRangefeedRegistrations(); n > 0 {
+		log.VEventf(ctx, 1, "not expiring keys in replica %v due to %d rangefeed registrations",
+			r, n)
+		return hlc.Timestamp{}, nil
+	}
+	var read cachedProtectedTimestampState
+	defer r.maybeUpdateCachedProtectedTS(&read)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defer read.clearIfNotNewer(r.mu.cachedProtectedTS)
+
+	lease := *r.mu.state.Lease
+	var err error
+	read, err = r.readProtectedTimestampsRLocked(ctx)
+	if err != nil {
+		return hlc.Timestamp{}, err
+	}
+	if read.readAt.IsEmpty() || read.readAt.Less(lease.Start.ToTimestamp()) {
+		return hlc.Timestamp{}, nil
+	}
+	if !read.earliestProtectionTimestamp.IsEmpty() {
+		log.VEventf(ctx, 1, "not expiring keys in replica %v due to protection at %v",
+			r, read.earliestProtectionTimestamp)
+		return hlc.Timestamp{}, nil
+	}
+	return gc.CalculateThreshold(read.readAt, storageTTL), nil
+}
+
 // markPendingGC is called just prior to sending the GC request to increase the
 // GC threshold during MVCC GC queue processing. This method synchronizes such
 // requests with the processing of AdminVerifyProtectedTimestamp requests. Such
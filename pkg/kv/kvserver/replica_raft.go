@@ -1257,6 +1257,7 @@ func (r *Replica) tick(
 	}
 
 	r.maybeTransferRaftLeadershipToLeaseholderLocked(ctx, leaseStatus)
+	r.maybeTransferRaftLeadershipFromWitnessLocked(ctx, leaseStatus)
 
 	// Eagerly acquire or extend leases. This only works for unquiesced ranges. We
 	// never quiesce expiration leases, but for epoch leases we fall back to the
@@ -2664,7 +2665,7 @@ func shouldCampaignAfterConfChange(
 		return false
 	}
 	if replDesc, ok := desc.GetReplicaDescriptorByID(roachpb.ReplicaID(raftStatus.Lead)); ok {
-		if replDesc.IsAnyVoter() {
+		if replDesc.IsAnyVoterOrWitness() {
 			// The leader is still a voter in the descriptor.
 			return false
 		}
@@ -475,9 +475,11 @@ func (r *Replica) applySnapshot(
 				// the snapshot queue) if we end up truncating the raft log before it
 				// gets promoted to a voter. We count such snapshot applications as
 				// "applied by voters" here, since the LEARNER will soon be promoted to
-				// a voting replica.
+				// a voting replica. Witnesses vote too, so their snapshots are counted
+				// here as well.
 				case roachpb.VOTER_FULL, roachpb.VOTER_INCOMING, roachpb.VOTER_DEMOTING_LEARNER,
-					roachpb.VOTER_OUTGOING, roachpb.LEARNER, roachpb.VOTER_DEMOTING_NON_VOTER:
+					roachpb.VOTER_OUTGOING, roachpb.LEARNER, roachpb.VOTER_DEMOTING_NON_VOTER,
+					roachpb.WITNESS:
 					r.store.metrics.RangeSnapshotsAppliedByVoters.Inc(1)
 				case roachpb.NON_VOTER:
 					r.store.metrics.RangeSnapshotsAppliedByNonVoters.Inc(1)
@@ -719,6 +721,10 @@ func (r *Replica) applySnapshot(
 		kvpb.RangeFeedRetryError_REASON_RAFT_SNAPSHOT,
 	)
 
+	// The snapshot may include MVCC history mutations that weren't applied to
+	// the columnar blocks, so drop them.
+	r.clearColumnarBlocks()
+
 	// Update the replica's cached byte thresholds. This is a no-op if the system
 	// config is not available, in which case we rely on the next gossip update
 	// to perform the update.
@@ -1477,8 +1477,10 @@ func (r *Replica) shouldRequestLeaseRLocked(
 		// We want the lease and leader to be colocated, and a non-leader lease
 		// proposal would be rejected by the Raft proposal buffer anyway. This also
 		// reduces aggregate work across ranges, since only 1 replica will attempt
-		// to acquire the lease, and only if there is a leader.
-		return r.isRaftLeaderRLocked(), false
+		// to acquire the lease, and only if there is a leader. Witnesses can't
+		// hold the lease, and hand the leadership to a voter instead (see
+		// maybeTransferRaftLeadershipFromWitnessLocked).
+		return r.isRaftLeaderRLocked() && !r.isWitnessRLocked(), false
 
 	case kvserverpb.LeaseState_PROSCRIBED:
 		// Reacquire leases after a restart, if they're still ours. We could also
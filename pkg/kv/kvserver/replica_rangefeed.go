@@ -176,6 +176,14 @@ func (r *Replica) RangeFeed(
 		checkTS = r.Clock().Now()
 	}
 
+	// The value filter is only an optimization, so the rangefeed proceeds
+	// without it if it cannot be constructed.
+	valueFilter, err := rangefeed.NewValueFilter(args.ValueFilter)
+	if err != nil {
+		log.Warningf(ctx, "ignoring rangefeed value filter: %v", err)
+		valueFilter = nil
+	}
+
 	lockedStream := &lockedRangefeedStream{wrapped: stream}
 
 	// If we will be using a catch-up iterator, wait for the limiter here before
@@ -229,7 +237,7 @@ func (r *Replica) RangeFeed(
 	}
 	var done future.ErrorFuture
 	p := r.registerWithRangefeedRaftMuLocked(
-		ctx, rSpan, args.Timestamp, catchUpIterFunc, args.WithDiff, lockedStream, &done,
+		ctx, rSpan, args.Timestamp, catchUpIterFunc, args.WithDiff, valueFilter, lockedStream, &done,
 	)
 	r.raftMu.Unlock()
 
@@ -330,6 +338,7 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	startTS hlc.Timestamp, // exclusive
 	catchUpIter rangefeed.CatchUpIteratorConstructor,
 	withDiff bool,
+	valueFilter rangefeed.ValueFilter,
 	stream rangefeed.Stream,
 	done *future.ErrorFuture,
 ) *rangefeed.Processor {
@@ -341,7 +350,7 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	r.rangefeedMu.Lock()
 	p := r.rangefeedMu.proc
 	if p != nil {
-		reg, filter := p.Register(span, startTS, catchUpIter, withDiff, stream, func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
+		reg, filter := p.Register(span, startTS, catchUpIter, withDiff, valueFilter, stream, func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
 		if reg {
 			// Registered successfully with an existing processor.
 			// Update the rangefeed filter to avoid filtering ops
@@ -410,7 +419,7 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	// any other goroutines are able to stop the processor. In other words,
 	// this ensures that the only time the registration fails is during
 	// server shutdown.
-	reg, filter := p.Register(span, startTS, catchUpIter, withDiff, stream, func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
+	reg, filter := p.Register(span, startTS, catchUpIter, withDiff, valueFilter, stream, func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
 	if !reg {
 		select {
 		case <-r.store.Stopper().ShouldQuiesce():
@@ -581,11 +590,12 @@ func (r *Replica) handleLogicalOpLogRaftMuLocked(
 		var key []byte
 		var ts hlc.Timestamp
 		var valPtr *[]byte
+		var originIDPtr *uint32
 		switch t := op.GetValue().(type) {
 		case *enginepb.MVCCWriteValueOp:
-			key, ts, valPtr = t.Key, t.Timestamp, &t.Value
+			key, ts, valPtr, originIDPtr = t.Key, t.Timestamp, &t.Value, &t.OriginID
 		case *enginepb.MVCCCommitIntentOp:
-			key, ts, valPtr = t.Key, t.Timestamp, &t.Value
+			key, ts, valPtr, originIDPtr = t.Key, t.Timestamp, &t.Value, &t.OriginID
 		case *enginepb.MVCCWriteIntentOp,
 			*enginepb.MVCCUpdateIntentOp,
 			*enginepb.MVCCAbortIntentOp,
@@ -641,6 +651,7 @@ func (r *Replica) handleLogicalOpLogRaftMuLocked(
 			vhf(key, nil, ts, vh)
 		}
 		*valPtr = valRes.Value.RawBytes
+		*originIDPtr = vh.OriginID
 	}
 
 	// Pass the ops to the rangefeed processor.
@@ -149,7 +149,7 @@ func (r *Replica) SendWithWriteBytes(
 	r.maybeUnquiesce(true /* wakeLeader */, true /* mayCampaign */)
 
 	isReadOnly := ba.IsReadOnly()
-	if err := r.checkBatchRequest(ba, isReadOnly); err != nil {
+	if err := r.checkBatchRequest(ctx, ba, isReadOnly); err != nil {
 		return nil, nil, kvpb.NewError(err)
 	}
 
@@ -214,6 +214,7 @@ func (r *Replica) SendWithWriteBytes(
 		r.maybeAddRangeInfoToResponse(ctx, ba, br)
 		// Handle load-based splitting, if necessary.
 		r.recordBatchForLoadBasedSplitting(ctx, ba, br, int(grunning.Difference(startCPU, grunning.Time())))
+		r.recordBatchForHotKeys(ba, br)
 	}
 
 	r.recordRequestWriteBytes(writeBytes)
@@ -1080,12 +1081,15 @@ func (r *Replica) recordRequestWriteBytes(writeBytes *kvadmission.StoreWriteByte
 }
 
 // checkBatchRequest verifies BatchRequest validity requirements. In particular,
-// the batch must have an assigned timestamp, and either all requests must be
-// read-only, or none.
+// the batch must have an assigned timestamp, either all requests must be
+// read-only, or none, and write options may only be set once all nodes can
+// decode the value headers they result in.
 //
 // TODO(tschottdorf): should check that request is contained in range and that
 // EndTxn only occurs at the very end.
-func (r *Replica) checkBatchRequest(ba *kvpb.BatchRequest, isReadOnly bool) error {
+func (r *Replica) checkBatchRequest(
+	ctx context.Context, ba *kvpb.BatchRequest, isReadOnly bool,
+) error {
 	if ba.Timestamp.IsEmpty() {
 		// For transactional requests, Store.Send sets the timestamp. For non-
 		// transactional requests, the client sets the timestamp. Either way, we
@@ -1101,6 +1105,12 @@ func (r *Replica) checkBatchRequest(ba *kvpb.BatchRequest, isReadOnly bool) erro
 	} else if !consistent {
 		return errors.Errorf("%v mode is only available to reads", ba.ReadConsistency)
 	}
+	if ba.WriteOptions != nil &&
+		!r.ClusterSettings().Version.IsActive(ctx, clusterversion.V23_2_LogicalReplicationOriginHeaders) {
+		// Nodes running older binaries can't decode the origin fields of the
+		// headers of the values written with them.
+		return errors.New("write options cannot be used until the cluster version is finalized")
+	}
 
 	return nil
 }
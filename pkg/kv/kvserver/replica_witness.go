@@ -0,0 +1,158 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+// A witness replica (roachpb.WITNESS) takes part in raft like a voter but
+// doesn't hold the range's data. Concretely, a witness:
+//
+//   - receives, persists and acknowledges log entries and votes and campaigns
+//     in elections like a voter. This lets a witness become the leader and
+//     catch up the surviving voters after the failure of a voter, but since it
+//     can't hold the lease, it hands the leadership to a voter once one has
+//     caught up (see maybeTransferRaftLeadershipFromWitnessLocked);
+//   - applies committed commands, but drops all the writes to the user keyspace
+//     of the range (and to the corresponding lock table keys) when doing so.
+//     Everything keyed by the RangeID or range-local keys (the applied state,
+//     the lease, the range descriptor, transaction records, etc.) is applied
+//     as usual, so the witness keeps track of the range's configuration and
+//     can be part of splits, merges and replication changes;
+//   - is sent snapshots without user data (see kvBatchSnapshotStrategy.Send),
+//     and never sends snapshots to replicas that hold user data;
+//   - can't hold the lease, serve follower reads, or take part in consistency
+//     checks;
+//   - is merged with a witness of the adjacent range on the same store (see
+//     witnessesCollocated and mergeQueue.collocateWitnesses).
+//
+// Witnesses are added as a LEARNER which receives a snapshot without user data
+// and which is then promoted to a WITNESS. Until then, the learner applies the
+// writes to the user data, and it drops them when it applies its promotion
+// (see replicaAppBatch.runPostAddTriggersReplicaOnly).
+
+// isWitnessRLocked returns whether the replica is a witness according to its
+// current range descriptor.
+func (r *Replica) isWitnessRLocked() bool {
+	repDesc, ok := r.mu.state.Desc.GetReplicaDescriptorByID(r.replicaID)
+	return ok && repDesc.IsWitness()
+}
+
+// isWitness is like isWitnessRLocked, but acquires r.mu.
+func (r *Replica) isWitness() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.isWitnessRLocked()
+}
+
+// witnessExcludedSpans returns the spans of the range that witnesses don't
+// hold any data for: the user keyspace and the lock table spans that
+// correspond to it. Range-local keys, and the locks on them, are retained.
+func witnessExcludedSpans(desc *roachpb.RangeDescriptor) []roachpb.Span {
+	// Like rditer.Select, use the adjusted span because r1's user keyspace only
+	// starts at LocalMax.
+	userSpan := desc.RSpan().KeySpan().AsRawSpanWithNoLocals()
+	lockStart, _ := keys.LockTableSingleKey(userSpan.Key, nil)
+	lockEnd, _ := keys.LockTableSingleKey(userSpan.EndKey, nil)
+	return []roachpb.Span{{Key: lockStart, EndKey: lockEnd}, userSpan}
+}
+
+// isWitnessExcludedKey returns whether the given (raw) key is one that
+// witnesses don't store, i.e. a global key or a lock table key for one.
+func isWitnessExcludedKey(key roachpb.Key) bool {
+	if !keys.IsLocal(key) {
+		return true
+	}
+	if lockedKey, err := keys.DecodeLockTableSingleKey(key); err == nil {
+		return !keys.IsLocal(lockedKey)
+	}
+	return false
+}
+
+// applyWitnessWriteBatch applies the parts of the given WriteBatch repr that a
+// witness retains to the writer. See isWitnessExcludedKey.
+func applyWitnessWriteBatch(w storage.Writer, repr []byte) error {
+	r, err := storage.NewBatchReader(repr)
+	if err != nil {
+		return err
+	}
+	for r.Next() {
+		start, err := r.EngineKey()
+		if err != nil {
+			return err
+		}
+		if isWitnessExcludedKey(start.Key) {
+			continue
+		}
+		switch r.KeyKind() {
+		case pebble.InternalKeyKindSet, pebble.InternalKeyKindSetWithDelete:
+			err = w.PutEngineKey(start, r.Value())
+		case pebble.InternalKeyKindDelete:
+			err = w.ClearEngineKey(start, storage.ClearOptions{})
+		case pebble.InternalKeyKindSingleDelete:
+			err = w.SingleClearEngineKey(start)
+		case pebble.InternalKeyKindMerge:
+			var key storage.MVCCKey
+			if key, err = r.MVCCKey(); err == nil {
+				err = w.Merge(key, r.Value())
+			}
+		case pebble.InternalKeyKindRangeDelete, pebble.InternalKeyKindRangeKeySet,
+			pebble.InternalKeyKindRangeKeyUnset, pebble.InternalKeyKindRangeKeyDelete:
+			err = applyWitnessRangedEntry(w, r, start)
+		default:
+			err = errors.AssertionFailedf("unexpected batch entry key kind %d", r.KeyKind())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return r.Error()
+}
+
+// applyWitnessRangedEntry applies the current ranged entry of the BatchReader,
+// which starts at a key retained by witnesses, to the writer.
+func applyWitnessRangedEntry(
+	w storage.Writer, r *storage.BatchReader, start storage.EngineKey,
+) error {
+	end, err := r.EngineEndKey()
+	if err != nil {
+		return err
+	}
+	// NB: commands don't write ranged entries that straddle the range-local
+	// and the user keyspace, so an entry that starts at a retained key only
+	// covers retained keys.
+	switch r.KeyKind() {
+	case pebble.InternalKeyKindRangeDelete:
+		return w.ClearRawRange(start.Key, end.Key, true /* pointKeys */, false /* rangeKeys */)
+	case pebble.InternalKeyKindRangeKeyDelete:
+		return w.ClearRawRange(start.Key, end.Key, false /* pointKeys */, true /* rangeKeys */)
+	}
+	rangeKeys, err := r.EngineRangeKeys()
+	if err != nil {
+		return err
+	}
+	for _, rkv := range rangeKeys {
+		if r.KeyKind() == pebble.InternalKeyKindRangeKeySet {
+			err = w.PutEngineRangeKey(start.Key, end.Key, rkv.Version, rkv.Value)
+		} else {
+			err = w.ClearEngineRangeKey(start.Key, end.Key, rkv.Version)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
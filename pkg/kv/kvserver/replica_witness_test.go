@@ -0,0 +1,91 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyWitnessWriteBatch verifies that witnesses only apply the writes to
+// range-local and RangeID-local keys of a command's WriteBatch.
+func TestApplyWitnessWriteBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	userKey := roachpb.Key("a")
+	lockKey, _ := keys.LockTableSingleKey(userKey, nil)
+	rangeLocalKey := keys.RangeDescriptorKey(roachpb.RKey("a"))
+	rangeLocalLockKey, _ := keys.LockTableSingleKey(rangeLocalKey, nil)
+	rangeIDLocalKey := keys.RangeLeaseKey(1)
+
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	b := eng.NewBatch()
+	defer b.Close()
+	for _, k := range []roachpb.Key{userKey, lockKey, rangeLocalKey, rangeLocalLockKey, rangeIDLocalKey} {
+		require.NoError(t, b.PutUnversioned(k, []byte("v")))
+	}
+
+	require.NoError(t, applyWitnessWriteBatch(eng, b.Repr()))
+
+	for _, tc := range []struct {
+		key    roachpb.Key
+		exists bool
+	}{
+		{userKey, false},
+		{lockKey, false},
+		{rangeLocalKey, true},
+		{rangeLocalLockKey, true},
+		{rangeIDLocalKey, true},
+	} {
+		require.Equal(t, !tc.exists, isWitnessExcludedKey(tc.key), "%s", tc.key)
+		iter := eng.NewEngineIterator(storage.IterOptions{Prefix: true})
+		ok, err := iter.SeekEngineKeyGE(storage.EngineKey{Key: tc.key})
+		iter.Close()
+		require.NoError(t, err)
+		require.Equal(t, tc.exists, ok, "%s", tc.key)
+	}
+}
+
+// TestWitnessesCollocated verifies that ranges can only be merged if their
+// witnesses are on the same stores.
+func TestWitnessesCollocated(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	repl := func(storeID roachpb.StoreID, typ roachpb.ReplicaType) roachpb.ReplicaDescriptor {
+		return roachpb.ReplicaDescriptor{
+			NodeID: roachpb.NodeID(storeID), StoreID: storeID, ReplicaID: roachpb.ReplicaID(storeID), Type: typ,
+		}
+	}
+	voters := []roachpb.ReplicaDescriptor{repl(1, roachpb.VOTER_FULL), repl(2, roachpb.VOTER_FULL)}
+	withWitness := func(storeID roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		return append(append([]roachpb.ReplicaDescriptor(nil), voters...), repl(storeID, roachpb.WITNESS))
+	}
+
+	require.True(t, witnessesCollocated(voters, voters))
+	require.True(t, witnessesCollocated(withWitness(3), withWitness(3)))
+	require.False(t, witnessesCollocated(withWitness(3), withWitness(4)))
+	require.False(t, witnessesCollocated(withWitness(3), voters))
+	// The replicas are collocated, but the witness of one range is on the store
+	// of a voter of the other.
+	other := []roachpb.ReplicaDescriptor{repl(1, roachpb.VOTER_FULL), repl(2, roachpb.WITNESS), repl(3, roachpb.VOTER_FULL)}
+	require.True(t, replicasCollocated(withWitness(3), other))
+	require.False(t, witnessesCollocated(withWitness(3), other))
+}
@@ -175,6 +175,30 @@ var (
 		Measurement: "Replica Removals",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaReplicateQueueAddWitnessReplicaCount = metric.Metadata{
+		Name:        "queue.replicate.addwitnessreplica",
+		Help:        "Number of witness replica additions attempted by the replicate queue",
+		Measurement: "Replica Additions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReplicateQueueRemoveWitnessReplicaCount = metric.Metadata{
+		Name:        "queue.replicate.removewitnessreplica",
+		Help:        "Number of witness replica removals attempted by the replicate queue",
+		Measurement: "Replica Removals",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReplicateQueueRemoveDeadWitnessReplicaCount = metric.Metadata{
+		Name:        "queue.replicate.removedeadwitnessreplica",
+		Help:        "Number of dead witness replica removals attempted by the replicate queue (typically in response to a node outage)",
+		Measurement: "Replica Removals",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReplicateQueueRemoveDecommissioningWitnessReplicaCount = metric.Metadata{
+		Name:        "queue.replicate.removedecommissioningwitnessreplica",
+		Help:        "Number of decommissioning witness replica removals attempted by the replicate queue",
+		Measurement: "Replica Removals",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaReplicateQueueRemoveLearnerReplicaCount = metric.Metadata{
 		Name:        "queue.replicate.removelearnerreplica",
 		Help:        "Number of learner replica removals attempted by the replicate queue (typically due to internal race conditions)",
@@ -324,6 +348,10 @@ type ReplicateQueueMetrics struct {
 	RemoveDecommissioningReplicaCount         *metric.Counter
 	RemoveDecommissioningVoterReplicaCount    *metric.Counter
 	RemoveDecommissioningNonVoterReplicaCount *metric.Counter
+	AddWitnessReplicaCount                    *metric.Counter
+	RemoveWitnessReplicaCount                 *metric.Counter
+	RemoveDeadWitnessReplicaCount             *metric.Counter
+	RemoveDecommissioningWitnessReplicaCount  *metric.Counter
 	RemoveLearnerReplicaCount                 *metric.Counter
 	RebalanceReplicaCount                     *metric.Counter
 	RebalanceVoterReplicaCount                *metric.Counter
@@ -365,6 +393,10 @@ func makeReplicateQueueMetrics() ReplicateQueueMetrics {
 		RemoveDecommissioningReplicaCount:         metric.NewCounter(metaReplicateQueueRemoveDecommissioningReplicaCount),
 		RemoveDecommissioningVoterReplicaCount:    metric.NewCounter(metaReplicateQueueRemoveDecommissioningVoterReplicaCount),
 		RemoveDecommissioningNonVoterReplicaCount: metric.NewCounter(metaReplicateQueueRemoveDecommissioningNonVoterReplicaCount),
+		AddWitnessReplicaCount:                    metric.NewCounter(metaReplicateQueueAddWitnessReplicaCount),
+		RemoveWitnessReplicaCount:                 metric.NewCounter(metaReplicateQueueRemoveWitnessReplicaCount),
+		RemoveDeadWitnessReplicaCount:             metric.NewCounter(metaReplicateQueueRemoveDeadWitnessReplicaCount),
+		RemoveDecommissioningWitnessReplicaCount:  metric.NewCounter(metaReplicateQueueRemoveDecommissioningWitnessReplicaCount),
 		RebalanceReplicaCount:                     metric.NewCounter(metaReplicateQueueRebalanceReplicaCount),
 		RebalanceVoterReplicaCount:                metric.NewCounter(metaReplicateQueueRebalanceVoterReplicaCount),
 		RebalanceNonVoterReplicaCount:             metric.NewCounter(metaReplicateQueueRebalanceNonVoterReplicaCount),
@@ -430,6 +462,18 @@ func (metrics *ReplicateQueueMetrics) trackPlanningStats(
 	if stats.RemoveDecommissioningNonVoterReplicaCount > 0 {
 		metrics.RemoveDecommissioningNonVoterReplicaCount.Inc(stats.RemoveDecommissioningNonVoterReplicaCount)
 	}
+	if stats.AddWitnessReplicaCount > 0 {
+		metrics.AddWitnessReplicaCount.Inc(stats.AddWitnessReplicaCount)
+	}
+	if stats.RemoveWitnessReplicaCount > 0 {
+		metrics.RemoveWitnessReplicaCount.Inc(stats.RemoveWitnessReplicaCount)
+	}
+	if stats.RemoveDeadWitnessReplicaCount > 0 {
+		metrics.RemoveDeadWitnessReplicaCount.Inc(stats.RemoveDeadWitnessReplicaCount)
+	}
+	if stats.RemoveDecommissioningWitnessReplicaCount > 0 {
+		metrics.RemoveDecommissioningWitnessReplicaCount.Inc(stats.RemoveDecommissioningWitnessReplicaCount)
+	}
 	if stats.RemoveLearnerReplicaCount > 0 {
 		metrics.RemoveLearnerReplicaCount.Inc(stats.RemoveLearnerReplicaCount)
 	}
@@ -457,17 +501,21 @@ func (metrics *ReplicateQueueMetrics) trackSuccessByAllocatorAction(
 	ctx context.Context, action allocatorimpl.AllocatorAction,
 ) {
 	switch action {
-	case allocatorimpl.AllocatorRemoveVoter, allocatorimpl.AllocatorRemoveNonVoter:
+	case allocatorimpl.AllocatorRemoveVoter, allocatorimpl.AllocatorRemoveNonVoter,
+		allocatorimpl.AllocatorRemoveWitness:
 		metrics.RemoveReplicaSuccessCount.Inc(1)
-	case allocatorimpl.AllocatorAddVoter, allocatorimpl.AllocatorAddNonVoter:
+	case allocatorimpl.AllocatorAddVoter, allocatorimpl.AllocatorAddNonVoter,
+		allocatorimpl.AllocatorAddWitness:
 		metrics.AddReplicaSuccessCount.Inc(1)
 	case allocatorimpl.AllocatorReplaceDeadVoter, allocatorimpl.AllocatorReplaceDeadNonVoter:
 		metrics.ReplaceDeadReplicaSuccessCount.Inc(1)
-	case allocatorimpl.AllocatorRemoveDeadVoter, allocatorimpl.AllocatorRemoveDeadNonVoter:
+	case allocatorimpl.AllocatorRemoveDeadVoter, allocatorimpl.AllocatorRemoveDeadNonVoter,
+		allocatorimpl.AllocatorRemoveDeadWitness:
 		metrics.RemoveDeadReplicaSuccessCount.Inc(1)
 	case allocatorimpl.AllocatorReplaceDecommissioningVoter, allocatorimpl.AllocatorReplaceDecommissioningNonVoter:
 		metrics.ReplaceDecommissioningReplicaSuccessCount.Inc(1)
-	case allocatorimpl.AllocatorRemoveDecommissioningVoter, allocatorimpl.AllocatorRemoveDecommissioningNonVoter:
+	case allocatorimpl.AllocatorRemoveDecommissioningVoter, allocatorimpl.AllocatorRemoveDecommissioningNonVoter,
+		allocatorimpl.AllocatorRemoveDecommissioningWitness:
 		metrics.RemoveDecommissioningReplicaSuccessCount.Inc(1)
 	case allocatorimpl.AllocatorConsiderRebalance, allocatorimpl.AllocatorNoop,
 		allocatorimpl.AllocatorRangeUnavailable, allocatorimpl.AllocatorRemoveLearner,
@@ -485,17 +533,21 @@ func (metrics *ReplicateQueueMetrics) trackErrorByAllocatorAction(
 	ctx context.Context, action allocatorimpl.AllocatorAction,
 ) {
 	switch action {
-	case allocatorimpl.AllocatorRemoveVoter, allocatorimpl.AllocatorRemoveNonVoter:
+	case allocatorimpl.AllocatorRemoveVoter, allocatorimpl.AllocatorRemoveNonVoter,
+		allocatorimpl.AllocatorRemoveWitness:
 		metrics.RemoveReplicaErrorCount.Inc(1)
-	case allocatorimpl.AllocatorAddVoter, allocatorimpl.AllocatorAddNonVoter:
+	case allocatorimpl.AllocatorAddVoter, allocatorimpl.AllocatorAddNonVoter,
+		allocatorimpl.AllocatorAddWitness:
 		metrics.AddReplicaErrorCount.Inc(1)
 	case allocatorimpl.AllocatorReplaceDeadVoter, allocatorimpl.AllocatorReplaceDeadNonVoter:
 		metrics.ReplaceDeadReplicaErrorCount.Inc(1)
-	case allocatorimpl.AllocatorRemoveDeadVoter, allocatorimpl.AllocatorRemoveDeadNonVoter:
+	case allocatorimpl.AllocatorRemoveDeadVoter, allocatorimpl.AllocatorRemoveDeadNonVoter,
+		allocatorimpl.AllocatorRemoveDeadWitness:
 		metrics.RemoveDeadReplicaErrorCount.Inc(1)
 	case allocatorimpl.AllocatorReplaceDecommissioningVoter, allocatorimpl.AllocatorReplaceDecommissioningNonVoter:
 		metrics.ReplaceDecommissioningReplicaErrorCount.Inc(1)
-	case allocatorimpl.AllocatorRemoveDecommissioningVoter, allocatorimpl.AllocatorRemoveDecommissioningNonVoter:
+	case allocatorimpl.AllocatorRemoveDecommissioningVoter, allocatorimpl.AllocatorRemoveDecommissioningNonVoter,
+		allocatorimpl.AllocatorRemoveDecommissioningWitness:
 		metrics.RemoveDecommissioningReplicaErrorCount.Inc(1)
 	case allocatorimpl.AllocatorConsiderRebalance, allocatorimpl.AllocatorNoop,
 		allocatorimpl.AllocatorRangeUnavailable, allocatorimpl.AllocatorRemoveLearner,
@@ -950,6 +1002,7 @@ func maybeAnnotateDecommissionErr(err error, action allocatorimpl.AllocatorActio
 func isDecommissionAction(action allocatorimpl.AllocatorAction) bool {
 	return action == allocatorimpl.AllocatorRemoveDecommissioningVoter ||
 		action == allocatorimpl.AllocatorRemoveDecommissioningNonVoter ||
+		action == allocatorimpl.AllocatorRemoveDecommissioningWitness ||
 		action == allocatorimpl.AllocatorReplaceDecommissioningVoter ||
 		action == allocatorimpl.AllocatorReplaceDecommissioningNonVoter
 }
@@ -0,0 +1,211 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package split
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+const (
+	// hotKeyCapacity is the number of keys for which each ranking of a
+	// HotKeyFinder keeps counters. Keys beyond the hottest few are only
+	// tracked to absorb the error of the Space-Saving algorithm.
+	hotKeyCapacity = 32
+	// HotKeysReported is the maximum number of keys returned by
+	// HotKeyFinder.HotKeys and HotKeyFinder.HotKeysByBytes.
+	HotKeysReported = 8
+	// hotKeyWindow is the duration over which load is accumulated before it is
+	// reported.
+	hotKeyWindow = time.Minute
+)
+
+// HotKey is a key that received a large share of a range's recent load.
+type HotKey struct {
+	Key roachpb.Key
+	// RequestsPerSecond and BytesPerSecond are the estimated request and byte
+	// rates of the key. Keys that were tracked for only part of the window
+	// may have their load overestimated by up to ErrorPerSecond requests and
+	// BytesErrorPerSecond bytes per second.
+	RequestsPerSecond   float64
+	BytesPerSecond      float64
+	ErrorPerSecond      float64
+	BytesErrorPerSecond float64
+}
+
+type hotKeyCounter struct {
+	key             roachpb.Key
+	requests, bytes float64
+	// reqErr and bytesErr are the number of requests and bytes that were
+	// attributed to the key when it replaced a previously tracked key, which
+	// bounds the overestimation.
+	reqErr, bytesErr float64
+}
+
+// hotKeyRank returns the load by which a hotKeySketch ranks its keys.
+type hotKeyRank func(*hotKeyCounter) float64
+
+func rankByRequests(c *hotKeyCounter) float64 { return c.requests }
+func rankByBytes(c *hotKeyCounter) float64    { return c.bytes }
+
+// hotKeySketch is a bounded set of Space-Saving counters: a key without a
+// counter replaces the key ranked lowest, inheriting its counts.
+type hotKeySketch struct {
+	counters map[string]*hotKeyCounter
+}
+
+func (s *hotKeySketch) record(key roachpb.Key, requests, bytes float64, rank hotKeyRank) {
+	if c, ok := s.counters[string(key)]; ok {
+		c.requests += requests
+		c.bytes += bytes
+		return
+	}
+	if s.counters == nil {
+		s.counters = make(map[string]*hotKeyCounter, hotKeyCapacity)
+	}
+	if len(s.counters) < hotKeyCapacity {
+		s.counters[string(key)] = &hotKeyCounter{
+			key:      key.Clone(),
+			requests: requests,
+			bytes:    bytes,
+		}
+		return
+	}
+	var min *hotKeyCounter
+	for _, c := range s.counters {
+		if min == nil || rank(c) < rank(min) {
+			min = c
+		}
+	}
+	delete(s.counters, string(min.key))
+	min.key = key.Clone()
+	min.reqErr, min.bytesErr = min.requests, min.bytes
+	min.requests += requests
+	min.bytes += bytes
+	s.counters[string(min.key)] = min
+}
+
+// summarize returns up to HotKeysReported of the keys ranked highest, given
+// the number of seconds over which their load was recorded.
+func (s *hotKeySketch) summarize(secs float64, rank hotKeyRank) []HotKey {
+	if secs <= 0 || len(s.counters) == 0 {
+		return []HotKey{}
+	}
+	counters := make([]*hotKeyCounter, 0, len(s.counters))
+	for _, c := range s.counters {
+		counters = append(counters, c)
+	}
+	sort.Slice(counters, func(i, j int) bool {
+		if ri, rj := rank(counters[i]), rank(counters[j]); ri != rj {
+			return ri > rj
+		}
+		return counters[i].key.Compare(counters[j].key) < 0
+	})
+	if len(counters) > HotKeysReported {
+		counters = counters[:HotKeysReported]
+	}
+	hotKeys := make([]HotKey, 0, len(counters))
+	for _, c := range counters {
+		hotKeys = append(hotKeys, HotKey{
+			Key:                 c.key,
+			RequestsPerSecond:   c.requests / secs,
+			BytesPerSecond:      c.bytes / secs,
+			ErrorPerSecond:      c.reqErr / secs,
+			BytesErrorPerSecond: c.bytesErr / secs,
+		})
+	}
+	return hotKeys
+}
+
+// HotKeyFinder tracks the hottest keys of a range by number of requests and
+// by number of bytes, using the Space-Saving algorithm for each ranking. This
+// guarantees that any key with more than 1/hotKeyCapacity of the requests, or
+// of the bytes, is tracked. Keys that receive few but large requests are thus
+// found as well as keys that receive many small ones. Load is accumulated over
+// a window, and the hottest keys of the last complete window are reported.
+//
+// The zero value is ready to use. HotKeyFinder is safe for concurrent use.
+type HotKeyFinder struct {
+	mu struct {
+		syncutil.Mutex
+		windowStart time.Time
+		byRequests  hotKeySketch
+		byBytes     hotKeySketch
+		// last and lastByBytes contain the hottest keys of the last complete
+		// window.
+		last, lastByBytes []HotKey
+	}
+}
+
+// Record records a request to key that read or wrote the given number of
+// bytes. The request is counted with the given weight, which is the inverse of
+// the rate at which requests are sampled.
+func (f *HotKeyFinder) Record(now time.Time, key roachpb.Key, bytes int64, weight float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maybeRotateLocked(now)
+	f.mu.byRequests.record(key, weight, float64(bytes)*weight, rankByRequests)
+	f.mu.byBytes.record(key, weight, float64(bytes)*weight, rankByBytes)
+}
+
+// HotKeys returns up to HotKeysReported of the hottest keys, ordered by
+// decreasing number of requests. The keys of the last complete window are
+// returned; if there is none yet, the load recorded so far is used.
+func (f *HotKeyFinder) HotKeys(now time.Time) []HotKey {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maybeRotateLocked(now)
+	if f.mu.last != nil {
+		return f.mu.last
+	}
+	return f.mu.byRequests.summarize(now.Sub(f.mu.windowStart).Seconds(), rankByRequests)
+}
+
+// HotKeysByBytes is like HotKeys, but returns the keys that read or wrote the
+// most bytes, ordered by decreasing number of bytes.
+func (f *HotKeyFinder) HotKeysByBytes(now time.Time) []HotKey {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maybeRotateLocked(now)
+	if f.mu.lastByBytes != nil {
+		return f.mu.lastByBytes
+	}
+	return f.mu.byBytes.summarize(now.Sub(f.mu.windowStart).Seconds(), rankByBytes)
+}
+
+// Reset discards all recorded load, e.g. after the range's bounds changed.
+func (f *HotKeyFinder) Reset(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mu.windowStart = now
+	f.mu.byRequests.counters, f.mu.byBytes.counters = nil, nil
+	f.mu.last, f.mu.lastByBytes = nil, nil
+}
+
+func (f *HotKeyFinder) maybeRotateLocked(now time.Time) {
+	if f.mu.windowStart.IsZero() {
+		f.mu.windowStart = now
+		return
+	}
+	if now.Sub(f.mu.windowStart) < hotKeyWindow {
+		return
+	}
+	// If no load was recorded for a while, the rates computed over the elapsed
+	// time are diluted accordingly.
+	secs := now.Sub(f.mu.windowStart).Seconds()
+	f.mu.last = f.mu.byRequests.summarize(secs, rankByRequests)
+	f.mu.lastByBytes = f.mu.byBytes.summarize(secs, rankByBytes)
+	f.mu.windowStart = now
+	f.mu.byRequests.counters, f.mu.byBytes.counters = nil, nil
+}
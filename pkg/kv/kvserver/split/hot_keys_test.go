@@ -0,0 +1,140 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package split
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/rand"
+)
+
+// TestHotKeyFinder verifies that the hottest keys are found among many cold
+// keys, and that their rates are reported for the last complete window.
+func TestHotKeyFinder(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	key := func(i int) roachpb.Key {
+		return keys.SystemSQLCodec.TablePrefix(uint32(100 + i))
+	}
+	start := time.Unix(0, 0)
+	var f HotKeyFinder
+	require.Empty(t, f.HotKeys(start))
+
+	// Keys 0, 1 and 2 receive 1000, 500 and 250 requests respectively, and are
+	// interleaved with single requests to 1000 distinct cold keys. Each of the
+	// hot keys receives more than 1/hotKeyCapacity of the requests, so it is
+	// guaranteed to be tracked.
+	rng := rand.New(rand.NewSource(1))
+	hot := []int{1000, 500, 250}
+	var reqs []int
+	for i, n := range hot {
+		for j := 0; j < n; j++ {
+			reqs = append(reqs, i)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		reqs = append(reqs, len(hot)+i)
+	}
+	rng.Shuffle(len(reqs), func(i, j int) { reqs[i], reqs[j] = reqs[j], reqs[i] })
+	var hotKeys []HotKey
+	for i, k := range reqs {
+		now := start.Add(time.Duration(i) * hotKeyWindow / time.Duration(len(reqs)))
+		if i == len(reqs)/2 {
+			// Before the window is complete, the load recorded so far is reported.
+			hotKeys = f.HotKeys(now)
+			require.Len(t, hotKeys, HotKeysReported)
+			for j := range hot {
+				require.Equal(t, key(j), hotKeys[j].Key)
+			}
+		}
+		f.Record(now, key(k), 10, 1)
+	}
+
+	// Once the window is complete, its rates are reported until the next one
+	// completes.
+	end := start.Add(hotKeyWindow)
+	for _, now := range []time.Time{end, end.Add(hotKeyWindow / 2)} {
+		hotKeys = f.HotKeys(now)
+		require.Len(t, hotKeys, HotKeysReported)
+		for i, n := range hot {
+			require.Equal(t, key(i), hotKeys[i].Key)
+			secs := hotKeyWindow.Seconds()
+			require.GreaterOrEqual(t, hotKeys[i].RequestsPerSecond, float64(n)/secs)
+			require.LessOrEqual(t,
+				hotKeys[i].RequestsPerSecond-hotKeys[i].ErrorPerSecond, float64(n)/secs)
+			require.InDelta(t, 10*hotKeys[i].RequestsPerSecond, hotKeys[i].BytesPerSecond, 1e-9)
+		}
+	}
+
+	// A window without load reports no hot keys.
+	require.Empty(t, f.HotKeys(end.Add(hotKeyWindow)))
+
+	// Weighted samples are scaled up, and a reset discards all load.
+	f.Record(end.Add(hotKeyWindow), key(0), 100, 10)
+	hotKeys = f.HotKeys(end.Add(2 * hotKeyWindow))
+	require.Len(t, hotKeys, 1)
+	require.InDelta(t, 10/hotKeyWindow.Seconds(), hotKeys[0].RequestsPerSecond, 1e-9)
+	require.InDelta(t, 1000/hotKeyWindow.Seconds(), hotKeys[0].BytesPerSecond, 1e-9)
+	f.Reset(end.Add(2 * hotKeyWindow))
+	require.Empty(t, f.HotKeys(end.Add(2*hotKeyWindow)))
+}
+
+// TestHotKeyFinderByBytes verifies that a key receiving few but large requests
+// is found by the byte ranking, even though it is cold by number of requests.
+func TestHotKeyFinderByBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	key := func(i int) roachpb.Key {
+		return keys.SystemSQLCodec.TablePrefix(uint32(100 + i))
+	}
+	start := time.Unix(0, 0)
+	var f HotKeyFinder
+	require.Empty(t, f.HotKeysByBytes(start))
+
+	// Key 0 receives 10 requests of 10KiB each, interleaved with 100 requests
+	// of 10 bytes to each of 10 other keys.
+	var reqs []int
+	for i := 0; i < 10; i++ {
+		reqs = append(reqs, 0)
+		for j := 0; j < 100; j++ {
+			reqs = append(reqs, 1+(i*100+j)%10)
+		}
+	}
+	for i, k := range reqs {
+		now := start.Add(time.Duration(i) * hotKeyWindow / time.Duration(len(reqs)))
+		bytes := int64(10)
+		if k == 0 {
+			bytes = 10 << 10
+		}
+		f.Record(now, key(k), bytes, 1)
+	}
+
+	end := start.Add(hotKeyWindow)
+	secs := hotKeyWindow.Seconds()
+	byBytes := f.HotKeysByBytes(end)
+	require.Len(t, byBytes, HotKeysReported)
+	require.Equal(t, key(0), byBytes[0].Key)
+	require.InDelta(t, 100<<10/secs, byBytes[0].BytesPerSecond, 1e-9)
+	require.InDelta(t, 10/secs, byBytes[0].RequestsPerSecond, 1e-9)
+	for i := 1; i < len(byBytes); i++ {
+		require.GreaterOrEqual(t, byBytes[i-1].BytesPerSecond, byBytes[i].BytesPerSecond)
+	}
+	byRequests := f.HotKeys(end)
+	require.Len(t, byRequests, HotKeysReported)
+	for _, hk := range byRequests {
+		require.NotEqual(t, key(0), hk.Key)
+	}
+}
@@ -327,6 +327,7 @@ func (sq *splitQueue) processAttempt(
 
 		// Reset the splitter now that the bounds of the range changed.
 		r.loadBasedSplitter.Reset(sq.store.Clock().PhysicalTime())
+		r.hotKeys.Reset(sq.store.Clock().PhysicalTime())
 		return true, nil
 	}
 
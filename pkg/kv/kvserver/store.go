@@ -837,6 +837,7 @@ type Store struct {
 	// via raft, when they are safe. Created in Store.Start.
 	raftTruncator       *raftLogTruncator
 	raftSnapshotQueue   *raftSnapshotQueue          // Raft repair queue
+	coldTierQueue       *coldTierQueue              // Cold storage tier queue
 	tsMaintenanceQueue  *timeSeriesMaintenanceQueue // Time series maintenance queue
 	scanner             *replicaScanner             // Replica scanner
 	consistencyQueue    *consistencyQueue           // Replica consistency check queue
@@ -1025,6 +1026,10 @@ type Store struct {
 	// Store.mu.replicas.
 	raftRecvQueues raftReceiveQueues
 
+	// columnarMon accounts for the memory of the columnar block caches of the
+	// replicas. See replica_columnar.go.
+	columnarMon *mon.BytesMonitor
+
 	scheduler *raftScheduler
 
 	// livenessMap is a map from nodeID to a bool indicating
@@ -1410,6 +1415,23 @@ func NewStore(
 		cfg.Settings,
 	)
 
+	if cfg.KVMemoryMonitor != nil {
+		s.columnarMon = mon.NewMonitorInheritWithLimit(
+			"columnar-blocks", 0 /* limit */, cfg.KVMemoryMonitor)
+		s.columnarMon.StartNoReserved(ctx, cfg.KVMemoryMonitor)
+		s.columnarMon.SetMetrics(s.metrics.ColumnarBlockCacheBytes, nil /* maxHist */)
+	} else {
+		s.columnarMon = mon.NewUnlimitedMonitor(
+			ctx,
+			"columnar-blocks",
+			mon.MemoryResource,
+			s.metrics.ColumnarBlockCacheBytes,
+			nil,
+			math.MaxInt64,
+			cfg.Settings,
+		)
+	}
+
 	s.cfg.RangeLogWriter = newWrappedRangeLogWriter(
 		s.metrics.getCounterForRangeLogEventType,
 		func() bool {
@@ -1572,12 +1594,13 @@ func NewStore(
 		s.raftLogQueue = newRaftLogQueue(s, s.db)
 		s.raftSnapshotQueue = newRaftSnapshotQueue(s)
 		s.consistencyQueue = newConsistencyQueue(s)
+		s.coldTierQueue = newColdTierQueue(s)
 		// NOTE: If more queue types are added, please also add them to the list of
 		// queues on the EnqueueRange debug page as defined in
 		// pkg/ui/src/views/reports/containers/enqueueRange/index.tsx
 		s.scanner.AddQueues(
 			s.mvccGCQueue, s.mergeQueue, s.splitQueue, s.replicateQueue, s.replicaGCQueue,
-			s.raftLogQueue, s.raftSnapshotQueue, s.consistencyQueue)
+			s.raftLogQueue, s.raftSnapshotQueue, s.consistencyQueue, s.coldTierQueue)
 		tsDS := s.cfg.TimeSeriesDataStore
 		if s.cfg.TestingKnobs.TimeSeriesDataStore != nil {
 			tsDS = s.cfg.TestingKnobs.TimeSeriesDataStore
@@ -2181,6 +2204,9 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 	// Connect rangefeeds to closed timestamp updates.
 	s.startRangefeedUpdater(ctx)
 
+	// Re-encrypt sstables after encryption-at-rest store key rotations.
+	s.startReencryption(ctx)
+
 	if s.replicateQueue != nil {
 		s.storeRebalancer = NewStoreRebalancer(
 			s.cfg.AmbientCtx, s.cfg.Settings, s.replicateQueue, s.replRankings, s.rebalanceObjManager)
@@ -0,0 +1,79 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// reencryptionRate is the rate at which sstables and WALs encrypted with a data
+// key of a retired store key are rewritten with the active data key.
+var reencryptionRate = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"storage.encryption.reencryption.max_rate",
+	"the rate limit (bytes/sec) at which each store rewrites sstables and WALs that are "+
+		"still encrypted with data keys of a retired encryption-at-rest store key; "+
+		"the limit applies to all bytes written by the compactions and flushes that rewrite them; "+
+		"progress is reported by crdb_internal.kv_store_reencryption and the "+
+		"storage.encryption.retired-key.bytes metric, which drops to zero once the "+
+		"store is fully rotated (0 disables re-encryption)",
+	8<<20, // 8 MiB
+	settings.NonNegativeInt,
+)
+
+// reencryptionIdleInterval is the interval at which a store checks for
+// sstables to re-encrypt when there are none, or re-encryption is disabled.
+const reencryptionIdleInterval = time.Minute
+
+// startReencryption starts a task that re-encrypts the store's sstables after
+// a rotation of the encryption-at-rest store key. Without it, sstables written
+// before the rotation would remain encrypted with data keys of the retired
+// store key until they happen to be compacted.
+func (s *Store) startReencryption(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "storage-reencryption",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		for {
+			wait := reencryptionIdleInterval
+			if rate := reencryptionRate.Get(&s.cfg.Settings.SV); rate > 0 {
+				n, err := s.TODOEngine().ReencryptNextSSTable(ctx)
+				if err != nil {
+					log.Warningf(ctx, "failed to re-encrypt sstable: %v", err)
+				} else if n > 0 {
+					s.metrics.EncryptionReencryptedBytes.Inc(int64(n))
+					// Pace re-encryption by the bytes written by its compaction,
+					// which rewrites all sstables overlapping the re-encrypted one,
+					// so that it proceeds at the configured rate.
+					wait = time.Duration(float64(n) / float64(rate) * float64(time.Second))
+				}
+			}
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+				timer.Read = true
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
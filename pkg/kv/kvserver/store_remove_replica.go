@@ -186,6 +186,7 @@ func (s *Store) removeInitializedReplicaRaftMuLocked(
 		if err := rep.destroyRaftMuLocked(ctx, nextReplicaID); err != nil {
 			return nil, err
 		}
+		s.releaseColdStorage(desc)
 	}
 
 	ph := func() *ReplicaPlaceholder {
@@ -579,8 +579,19 @@ func (kvSS *kvBatchSnapshotStrategy) Send(
 		return nil
 	}
 
+	// Witnesses don't hold the range's user data, so don't send it to them or
+	// to learners that are about to be promoted to witnesses.
+	var skipSpans []roachpb.Span
+	if header.Witness {
+		skipSpans = witnessExcludedSpans(snap.State.Desc)
+	}
 	err := rditer.IterateReplicaKeySpans(snap.State.Desc, snap.EngineSnap, true, /* replicatedOnly */
-		func(iter storage.EngineIterator, _ roachpb.Span, keyType storage.IterKeyType) error {
+		func(iter storage.EngineIterator, span roachpb.Span, keyType storage.IterKeyType) error {
+			for _, sp := range skipSpans {
+				if sp.Equal(span) {
+					return nil
+				}
+			}
 			timingTag.start("iter")
 			defer timingTag.stop("iter")
 
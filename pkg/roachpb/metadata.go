@@ -476,6 +476,20 @@ func (r ReplicaDescriptor) IsNonVoter() bool {
 	}
 }
 
+// IsWitness returns true if the replica is a witness. Witnesses take part in
+// raft quorums but hold no range data, so they are deliberately not considered
+// by the IsVoter* predicates above. Can be used as a filter for
+// ReplicaDescriptors.Filter.
+func (r ReplicaDescriptor) IsWitness() bool {
+	return r.Type == WITNESS
+}
+
+// IsAnyVoterOrWitness returns true if the replica counts towards any of the
+// raft quorums of the range.
+func (r ReplicaDescriptor) IsAnyVoterOrWitness() bool {
+	return r.IsAnyVoter() || r.IsWitness()
+}
+
 // PercentilesFromData derives percentiles from a slice of data points.
 // Sorts the input data if it isn't already sorted.
 func PercentilesFromData(data []float64) Percentiles {
@@ -89,6 +89,10 @@ func predNonVoter(rDesc ReplicaDescriptor) bool {
 	return rDesc.Type == NON_VOTER
 }
 
+func predWitness(rDesc ReplicaDescriptor) bool {
+	return rDesc.Type == WITNESS
+}
+
 func predVoterOrNonVoter(rDesc ReplicaDescriptor) bool {
 	return predVoterFullOrIncoming(rDesc) || predNonVoter(rDesc)
 }
@@ -226,6 +230,21 @@ func (d ReplicaSet) NonVoterDescriptors() []ReplicaDescriptor {
 	return d.FilterToDescriptors(predNonVoter)
 }
 
+// Witnesses returns a ReplicaSet containing only the witnesses in `d`.
+// Witnesses vote in raft like voters but don't apply commands and hold no
+// range data. They are not included in Voters() (or any of the other voter
+// accessors), since most callers interested in voters want replicas that can
+// serve reads or hold the lease; the raft configuration and the availability
+// computations in this file account for them explicitly.
+func (d ReplicaSet) Witnesses() ReplicaSet {
+	return d.Filter(predWitness)
+}
+
+// WitnessDescriptors returns the witness replica descriptors in the set.
+func (d ReplicaSet) WitnessDescriptors() []ReplicaDescriptor {
+	return d.FilterToDescriptors(predWitness)
+}
+
 // VoterFullAndNonVoterDescriptors returns the descriptors of
 // VOTER_FULL/NON_VOTER replicas in the set. This set will not contain learners
 // or, during an atomic replication change, incoming or outgoing voters.
@@ -322,7 +341,7 @@ func (d ReplicaSet) InAtomicReplicationChange() bool {
 		case VOTER_INCOMING, VOTER_OUTGOING, VOTER_DEMOTING_LEARNER,
 			VOTER_DEMOTING_NON_VOTER:
 			return true
-		case VOTER_FULL, LEARNER, NON_VOTER:
+		case VOTER_FULL, LEARNER, NON_VOTER, WITNESS:
 		default:
 			panic(fmt.Sprintf("unknown replica type %d", rDesc.Type))
 		}
@@ -356,6 +375,13 @@ func (d ReplicaSet) ConfState() raftpb.ConfState {
 			cs.Learners = append(cs.Learners, id)
 		case NON_VOTER:
 			cs.Learners = append(cs.Learners, id)
+		case WITNESS:
+			// Witnesses are never added or removed as part of a joint config, so
+			// they're voters on both sides of one.
+			cs.Voters = append(cs.Voters, id)
+			if joint {
+				cs.VotersOutgoing = append(cs.VotersOutgoing, id)
+			}
 		default:
 			panic(fmt.Sprintf("unknown ReplicaType %d", rep.Type))
 		}
@@ -435,15 +461,21 @@ func (d ReplicaSet) ReplicationStatus(
 	votersOldGroup := d.FilterToDescriptors(ReplicaDescriptor.IsVoterOldConfig)
 	liveVotersOldGroup := d.FilterToDescriptors(isBoth(ReplicaDescriptor.IsVoterOldConfig, liveFunc))
 
+	// Witnesses are part of both groups for the purposes of availability, but
+	// they don't count towards the replication factor of voters.
+	witnesses := d.FilterToDescriptors(ReplicaDescriptor.IsWitness)
+	liveWitnesses := d.FilterToDescriptors(isBoth(ReplicaDescriptor.IsWitness, liveFunc))
+
 	n := len(votersOldGroup)
 	// Empty groups succeed by default, to match the Raft implementation.
-	availableOutgoingGroup := (n == 0) || (len(liveVotersOldGroup) >= n/2+1)
+	availableOutgoingGroup := (n == 0) ||
+		(len(liveVotersOldGroup)+len(liveWitnesses) >= (n+len(witnesses))/2+1)
 
 	votersNewGroup := d.FilterToDescriptors(ReplicaDescriptor.IsVoterNewConfig)
 	liveVotersNewGroup := d.FilterToDescriptors(isBoth(ReplicaDescriptor.IsVoterNewConfig, liveFunc))
 
-	n = len(votersNewGroup)
-	availableIncomingGroup := len(liveVotersNewGroup) >= n/2+1
+	n = len(votersNewGroup) + len(witnesses)
+	availableIncomingGroup := len(liveVotersNewGroup)+len(liveWitnesses) >= n/2+1
 
 	res.Available = availableIncomingGroup && availableOutgoingGroup
 
@@ -506,9 +538,9 @@ func (d ReplicaSet) Subtract(o ReplicaSet) []ReplicaDescriptor {
 // IsAddition returns true if `c` refers to a replica addition operation.
 func (c ReplicaChangeType) IsAddition() bool {
 	switch c {
-	case ADD_NON_VOTER, ADD_VOTER:
+	case ADD_NON_VOTER, ADD_VOTER, ADD_WITNESS:
 		return true
-	case REMOVE_NON_VOTER, REMOVE_VOTER:
+	case REMOVE_NON_VOTER, REMOVE_VOTER, REMOVE_WITNESS:
 		return false
 	default:
 		panic(fmt.Sprintf("unexpected ReplicaChangeType %s", c))
@@ -518,9 +550,9 @@ func (c ReplicaChangeType) IsAddition() bool {
 // IsRemoval returns true if `c` refers a replica removal operation.
 func (c ReplicaChangeType) IsRemoval() bool {
 	switch c {
-	case ADD_NON_VOTER, ADD_VOTER:
+	case ADD_NON_VOTER, ADD_VOTER, ADD_WITNESS:
 		return false
-	case REMOVE_NON_VOTER, REMOVE_VOTER:
+	case REMOVE_NON_VOTER, REMOVE_VOTER, REMOVE_WITNESS:
 		return true
 	default:
 		panic(fmt.Sprintf("unexpected ReplicaChangeType %s", c))
@@ -557,6 +589,10 @@ var ErrReplicaCannotHoldLease = errors.New(`lease target replica cannot hold lea
 // aren't, the CAS call for extending the lease will fail (see
 // wasLastLeaseholder := isExtension in cmd_lease_request.go).
 //
+// Witnesses never receive the lease since they hold no data to serve it from;
+// they are not voters as far as IsVoter{Old,New}Config are concerned, so the
+// checks below reject them.
+//
 // An error is also returned is the replica is not part of `replDescs`.
 // NB: This logic should be in sync with constraint_stats_report as report
 // will check voter constraint violations. When changing this method, you need
@@ -182,6 +182,17 @@ func TestReplicaDescriptorsConfState(t *testing.T) {
 			[]ReplicaDescriptor{rd(VOTER_OUTGOING, 1), rd(VOTER_DEMOTING_LEARNER, 2), rd(VOTER_INCOMING, 3), rd(VOTER_INCOMING, 4), rd(LEARNER, 5)},
 			"Voters:[3 4] VotersOutgoing:[1 2] Learners:[5] LearnersNext:[2] AutoLeave:false",
 		},
+		// Witnesses vote like regular voters.
+		{
+			[]ReplicaDescriptor{rd(VOTER_FULL, 1), rd(VOTER_FULL, 2), rd(WITNESS, 3)},
+			"Voters:[1 2 3] VotersOutgoing:[] Learners:[] LearnersNext:[] AutoLeave:false",
+		},
+		// Witnesses are never part of a joint change, so they're voters on both
+		// sides of one.
+		{
+			[]ReplicaDescriptor{rd(VOTER_FULL, 1), rd(VOTER_OUTGOING, 2), rd(VOTER_INCOMING, 3), rd(WITNESS, 4)},
+			"Voters:[1 3 4] VotersOutgoing:[1 2 4] Learners:[] LearnersNext:[] AutoLeave:false",
+		},
 	}
 
 	for _, test := range tests {
@@ -225,6 +236,18 @@ func TestReplicaDescriptorsCanMakeProgress(t *testing.T) {
 			{false, rd(VOTER_FULL, 2)},
 			{true, rd(VOTER_FULL, 3)},
 		}, true},
+		// One out of two voters dead, but the witness breaks the tie.
+		{[]descWithLiveness{
+			{true, rd(VOTER_FULL, 1)},
+			{false, rd(VOTER_FULL, 2)},
+			{true, rd(WITNESS, 3)},
+		}, true},
+		// One out of two voters alive, and the witness is dead too.
+		{[]descWithLiveness{
+			{true, rd(VOTER_FULL, 1)},
+			{false, rd(VOTER_FULL, 2)},
+			{false, rd(WITNESS, 3)},
+		}, false},
 		// Two out of three voters alive, but one is an incoming voter. The outgoing
 		// group doesn't have quorum.
 		{[]descWithLiveness{
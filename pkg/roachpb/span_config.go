@@ -52,6 +52,19 @@ func (s *SpanConfig) TTL() time.Duration {
 	return time.Duration(s.GCPolicy.TTLSeconds) * time.Second
 }
 
+// StorageTTL returns the storage-level expiration age as a time.Duration. A
+// zero duration means that storage-level expiration is disabled.
+func (s *SpanConfig) StorageTTL() time.Duration {
+	return time.Duration(s.StorageTTLSeconds) * time.Second
+}
+
+// ColdAfter returns the age after which ranges are moved to the cold storage
+// tier as a time.Duration. A zero duration means that ranges are never moved
+// based on their age.
+func (s *SpanConfig) ColdAfter() time.Duration {
+	return time.Duration(s.StorageTierColdAfterSeconds) * time.Second
+}
+
 // ValidateSystemTargetSpanConfig ensures that only protection policies
 // (GCPolicy.ProtectionPolicies) field is set on the underlying
 // roachpb.SpanConfig.
@@ -92,6 +105,24 @@ func (s *SpanConfig) ValidateSystemTargetSpanConfig() error {
 	if s.ExcludeDataFromBackup {
 		return errors.AssertionFailedf("ExcludeDataFromBackup set on system span config")
 	}
+	if s.ColumnarBlockCache {
+		return errors.AssertionFailedf("ColumnarBlockCache set on system span config")
+	}
+	if s.NumWitnesses != 0 {
+		return errors.AssertionFailedf("NumWitnesses set on system span config")
+	}
+	if len(s.WitnessConstraints) != 0 {
+		return errors.AssertionFailedf("WitnessConstraints set on system span config")
+	}
+	if s.StorageTTLSeconds != 0 {
+		return errors.AssertionFailedf("StorageTTLSeconds set on system span config")
+	}
+	if s.StorageTier != DEFAULT_TIER {
+		return errors.AssertionFailedf("StorageTier set on system span config")
+	}
+	if s.StorageTierColdAfterSeconds != 0 {
+		return errors.AssertionFailedf("StorageTierColdAfterSeconds set on system span config")
+	}
 	return nil
 }
 
@@ -92,6 +92,10 @@ const (
 
 	// This comes out to 1024 cache entries.
 	defaultSQLQueryCacheSize = 8 * 1024 * 1024
+
+	// defaultColdStorageCacheSize is the default size of each store's on-disk
+	// cache of data read from the cold storage tier.
+	defaultColdStorageCacheSize = 1 << 30 // 1 GiB
 )
 
 var productionSettingsWebpage = fmt.Sprintf(
@@ -236,6 +240,14 @@ type BaseConfig struct {
 
 	// SharedStorage is specified to enable disaggregated shared storage.
 	SharedStorage string
+
+	// ColdStorage is specified to enable a cold storage tier, to which stores
+	// move the data of cold ranges.
+	ColdStorage string
+
+	// ColdStorageCacheSize is the size of each store's on-disk cache of data
+	// read from the cold storage tier.
+	ColdStorageCacheSize int64
 	*cloud.ExternalStorageAccessor
 
 	// StartDiagnosticsReporting starts the asynchronous goroutine that
@@ -299,6 +311,7 @@ func (cfg *BaseConfig) SetDefaults(
 	cfg.DisableMaxOffsetCheck = false
 	cfg.DefaultZoneConfig = zonepb.DefaultZoneConfig()
 	cfg.StorageEngine = storage.DefaultStorageEngine
+	cfg.ColdStorageCacheSize = defaultColdStorageCacheSize
 	cfg.TestingInsecureWebAccess = disableWebLogin
 	cfg.Stores = base.StoreSpecList{
 		Specs: []base.StoreSpec{storeSpec},
@@ -829,6 +842,9 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 			if sharedStorage != nil {
 				addCfgOpt(storage.SharedStorage(sharedStorage))
 			}
+			if cfg.ColdStorage != "" {
+				addCfgOpt(storage.ColdStorage(cfg.ColdStorage, cfg.ColdStorageCacheSize))
+			}
 			// If the spec contains Pebble options, set those too.
 			if spec.PebbleOptions != "" {
 				addCfgOpt(storage.PebbleOptions(spec.PebbleOptions, &pebble.ParseHooks{
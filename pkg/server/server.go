@@ -104,6 +104,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/ts"
+	"github.com/cockroachdb/cockroach/pkg/ts/tsprom"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/admission"
 	"github.com/cockroachdb/cockroach/pkg/util/goschedstats"
@@ -1888,6 +1889,21 @@ func (s *Server) PreStart(ctx context.Context) error {
 		}
 	})
 
+	// Export time series to a Prometheus remote-write endpoint, if enabled by
+	// configuration.
+	var remoteWriteOnce sync.Once
+	maybeStartRemoteWriter := func(context.Context) {
+		if tsprom.RemoteWriteURL.Get(&s.st.SV) != "" {
+			remoteWriteOnce.Do(func() {
+				tsprom.StartRemoteWriter(workersCtx, s.stopper, s.recorder, s.recorder.GetTimeSeriesMetadata, s.st)
+			})
+		}
+	}
+	tsprom.RemoteWriteURL.SetOnChange(&s.st.SV, maybeStartRemoteWriter)
+	// The setting was already loaded from the persisted cluster settings, so
+	// the callback won't fire for it after a restart.
+	maybeStartRemoteWriter(workersCtx)
+
 	// Start the protected timestamp subsystem. Note that this needs to happen
 	// before the modeOperational switch below, as the protected timestamps
 	// subsystem will crash if accessed before being Started (and serving general
@@ -2001,6 +2017,7 @@ func (s *Server) PreStart(ctx context.Context) error {
 			sqlServer:        s.sqlServer,
 			db:               s.db,
 		}), /* apiServer */
+		tsprom.NewHandler(s.tsServer, s.recorder.GetTimeSeriesMetadata), /* tsPromServer */
 		serverpb.FeatureFlags{
 			CanViewKvMetricDashboards:   s.rpcContext.TenantID.Equal(roachpb.SystemTenantID),
 			DisableKvLevelAdvancedDebug: false,
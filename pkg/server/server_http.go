@@ -28,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/server/status"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/ts"
+	"github.com/cockroachdb/cockroach/pkg/ts/tsprom"
 	"github.com/cockroachdb/cockroach/pkg/ui"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/log/logcrash"
@@ -101,6 +102,7 @@ func (s *httpServer) setupRoutes(
 	handleDebugUnauthenticated http.Handler,
 	handleInspectzUnauthenticated http.Handler,
 	apiServer http.Handler,
+	tsPromServer http.Handler,
 	flags serverpb.FeatureFlags,
 ) error {
 	// OIDC Configuration must happen prior to the UI Handler being defined below so that we have
@@ -159,6 +161,14 @@ func (s *httpServer) setupRoutes(
 
 	// The timeseries endpoint, used to produce graphs.
 	s.mux.Handle(ts.URLPrefix, authenticatedHandler)
+	if tsPromServer != nil {
+		// The Prometheus HTTP API of the timeseries, used by e.g. Grafana.
+		handleTSPromAuthenticated := tsPromServer
+		if !s.cfg.InsecureWebAccess() {
+			handleTSPromAuthenticated = authserver.NewMux(authnServer, handleTSPromAuthenticated, false /* allowAnonymous */)
+		}
+		s.mux.Handle(tsprom.URLPrefix, handleTSPromAuthenticated)
+	}
 
 	// Exempt the 2nd health check endpoint from authentication.
 	// (This simply mirrors /health and exists for backward compatibility.)
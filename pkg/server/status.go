@@ -69,7 +69,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/flowinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlinstance"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlstats/insights"
 	"github.com/cockroachdb/cockroach/pkg/util/grpcutil"
@@ -2647,7 +2649,35 @@ func (t *statusServer) HotRangesV2(
 		return nil, err
 	}
 
-	return t.sqlServer.tenantConnect.HotRangesV2(ctx, req)
+	resp, err := t.sqlServer.tenantConnect.HotRangesV2(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// The system tenant can't check the privileges of the users of the virtual
+	// cluster, so its hot keys are redacted here.
+	if err := t.redactHotKeys(ctx, resp.Ranges); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// redactHotKeys redacts the hot keys of the given hot ranges that belong to
+// tables on which the current user does not have the SELECT privilege, like
+// crdb_internal.hot_keys does.
+func (t *statusServer) redactHotKeys(
+	ctx context.Context, ranges []*serverpb.HotRangesResponseV2_HotRange,
+) error {
+	userName, isAdmin, err := t.privilegeChecker.GetUserAndRole(ctx)
+	if err != nil {
+		return srverrors.ServerError(ctx, err)
+	}
+	if isAdmin {
+		return nil
+	}
+	if err := sql.RedactHotKeys(ctx, t.sqlServer.execCfg, userName, ranges); err != nil {
+		return srverrors.ServerError(ctx, err)
+	}
+	return nil
 }
 
 // HotRangesV2 returns hot ranges from all stores on requested node or all nodes for specified tenant
@@ -2775,9 +2805,15 @@ func (s *systemStatusServer) HotRangesV2(
 						ReplicaNodeIds:      replicaNodeIDs,
 						LeaseholderNodeID:   r.LeaseholderNodeID,
 						StoreID:             store.StoreID,
+						HotKeys:             s.decodeHotKeys(ctx, r.HotKeys),
 					})
 				}
 			}
+			// The hot keys are redacted on the node that sampled them, with the
+			// identity of the user that requested them.
+			if err := s.redactHotKeys(ctx, ranges); err != nil {
+				return nil, err
+			}
 			response.Ranges = ranges
 			response.ErrorsByNodeID[requestedNodeID] = resp.ErrorMessage
 			return response, nil
@@ -2824,6 +2860,67 @@ func (s *systemStatusServer) HotRangesV2(
 	return response, nil
 }
 
+// decodeHotKeys resolves the SQL table and index of each of the given hot
+// keys, and decodes the values of the index columns present in the key.
+// Keys which do not belong to a SQL table are only pretty-printed.
+func (s *systemStatusServer) decodeHotKeys(
+	ctx context.Context, hotKeys []serverpb.HotRangesResponse_HotKey,
+) []serverpb.HotRangesResponseV2_HotKey {
+	if len(hotKeys) == 0 {
+		return nil
+	}
+	res := make([]serverpb.HotRangesResponseV2_HotKey, len(hotKeys))
+	for i, hk := range hotKeys {
+		res[i] = serverpb.HotRangesResponseV2_HotKey{
+			Key:               hk.Key,
+			PrettyKey:         hk.Key.String(),
+			RequestsPerSecond: hk.RequestsPerSecond,
+			BytesPerSecond:    hk.BytesPerSecond,
+		}
+	}
+	codec := s.sqlServer.execCfg.Codec
+	if err := s.sqlServer.distSQLServer.DB.DescsTxn(
+		ctx, func(ctx context.Context, txn descs.Txn) error {
+			var alloc tree.DatumAlloc
+			for i := range res {
+				hk := &res[i]
+				if _, _, ok := decodeTableID(codec, hk.Key); !ok {
+					continue
+				}
+				_, tableID, idxID, err := codec.DecodeIndexPrefix(hk.Key)
+				if err != nil {
+					continue
+				}
+				desc, err := txn.Descriptors().ByID(txn.KV()).WithoutNonPublic().Get().Table(ctx, descpb.ID(tableID))
+				if err != nil {
+					return errors.Wrapf(err, "cannot get table descriptor with tableID: %d", tableID)
+				}
+				hk.TableName = desc.GetName()
+				index := catalog.FindIndexByID(desc, descpb.IndexID(idxID))
+				if index == nil {
+					continue
+				}
+				hk.IndexName = index.GetName()
+				if index.GetType() == descpb.IndexDescriptor_INVERTED {
+					continue
+				}
+				cols := desc.IndexFullColumns(index)
+				datums, err := rowenc.DecodeIndexKeyToDatums(
+					codec, catalog.ColumnTypes(cols), desc.IndexFullColumnDirections(index), hk.Key, &alloc,
+				)
+				if err != nil {
+					log.Warningf(ctx, "cannot decode hot key %s: %v", hk.PrettyKey, err)
+					continue
+				}
+				hk.IndexValues = datums.String()
+			}
+			return nil
+		}); err != nil {
+		log.Warningf(ctx, "failed to decode hot keys: %v", err)
+	}
+	return res
+}
+
 func decodeTableID(codec keys.SQLCodec, key roachpb.Key) (roachpb.Key, uint32, bool) {
 	remaining, tableID, err := codec.DecodeTablePrefix(key)
 	if err != nil {
@@ -2860,6 +2957,14 @@ func (s *systemStatusServer) localHotRanges(
 			replica, err := store.GetReplica(r.Desc.GetRangeID())
 			if err == nil {
 				storeResp.HotRanges[i].LeaseholderNodeID = replica.State(ctx).Lease.Replica.NodeID
+				for _, hk := range replica.HotKeys(store.Clock().PhysicalTime()) {
+					storeResp.HotRanges[i].HotKeys = append(storeResp.HotRanges[i].HotKeys,
+						serverpb.HotRangesResponse_HotKey{
+							Key:               hk.Key,
+							RequestsPerSecond: hk.RequestsPerSecond,
+							BytesPerSecond:    hk.BytesPerSecond,
+						})
+				}
 			}
 			storeResp.HotRanges[i].Desc = *r.Desc
 			storeResp.HotRanges[i].QueriesPerSecond = r.QPS
@@ -3669,6 +3774,8 @@ func (s *systemStatusServer) Stores(
 		storeDetails.TotalBytes = envStats.TotalBytes
 		storeDetails.ActiveKeyFiles = envStats.ActiveKeyFiles
 		storeDetails.ActiveKeyBytes = envStats.ActiveKeyBytes
+		storeDetails.RetiredKeyFiles = envStats.RetiredKeyFiles
+		storeDetails.RetiredKeyBytes = envStats.RetiredKeyBytes
 
 		resp.Stores = append(resp.Stores, storeDetails)
 
@@ -453,6 +453,40 @@ func (mr *MetricsRecorder) GetMetricsMetadata() map[string]metric.Metadata {
 	return metrics
 }
 
+// GetTimeSeriesMetadata returns the metadata of the metrics recorded by
+// GetTimeSeriesData, keyed by the name of their time series (e.g.
+// cr.node.sql.conns or cr.store.capacity). Unlike GetMetricsMetadata, this
+// allows node metrics to be told apart from store metrics.
+func (mr *MetricsRecorder) GetTimeSeriesMetadata() map[string]metric.Metadata {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	if mr.mu.nodeRegistry == nil {
+		// We haven't yet processed initialization information; do nothing.
+		if log.V(1) {
+			log.Warning(context.TODO(), "MetricsRecorder.GetTimeSeriesMetadata() called before NodeID allocation")
+		}
+		return nil
+	}
+
+	metrics := make(map[string]metric.Metadata)
+	writeMetadata := func(format string, reg *metric.Registry) {
+		md := make(map[string]metric.Metadata)
+		reg.WriteMetricsMetadata(md)
+		for name, m := range md {
+			metrics[fmt.Sprintf(format, name)] = m
+		}
+	}
+	writeMetadata(nodeTimeSeriesPrefix, mr.mu.nodeRegistry)
+	writeMetadata(nodeTimeSeriesPrefix, mr.mu.logRegistry)
+	// All stores have the same metadata.
+	for _, r := range mr.mu.storeRegistries {
+		writeMetadata(storeTimeSeriesPrefix, r)
+		break
+	}
+	return metrics
+}
+
 // getNetworkActivity produces a map of network activity from this node to all
 // other nodes. Latencies are stored as nanos.
 func (mr *MetricsRecorder) getNetworkActivity(
@@ -45,7 +45,7 @@ func (f constraintsConjunctionField) FieldBound(b *Bounds) ValueBounds {
 		return unbounded{}
 	}
 	switch f {
-	case constraints, voterConstraints:
+	case constraints, voterConstraints, witnessConstraints:
 		return (*constraintsConjunctionBounds)(b.ConstraintBounds)
 	default:
 		// This is safe because we test that all the fields in the proto have
@@ -63,6 +63,8 @@ func (f constraintsConjunctionField) fieldValue(
 		return &c.VoterConstraints
 	case constraints:
 		return &c.Constraints
+	case witnessConstraints:
+		return &c.WitnessConstraints
 	default:
 		// This is safe because we test that all the fields in the proto have
 		// a corresponding field, and we call this for each of them, and the user
@@ -213,6 +215,11 @@ func (c *constraintsConjunctionBounds) clamp(t *roachpb.SpanConfig, f Field) (ch
 		// replicas in regions outside the fallback. That's not okay.
 		t.Constraints = distributeFallbackConstraints(t.NumReplicas)
 		return true
+	case witnessConstraints:
+		// Witnesses are typically placed in a tiebreaker location, so spread them
+		// over the fallback regions like the other replicas.
+		t.WitnessConstraints = distributeFallbackConstraints(t.NumWitnesses)
+		return true
 	default:
 		panic(errors.AssertionFailedf("failed to clamp constraints in unknown field %v", f))
 	}
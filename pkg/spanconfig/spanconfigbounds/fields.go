@@ -53,6 +53,11 @@ var fields = [config.NumFields]Field{
 	constraints,
 	voterConstraints,
 	leasePreferences,
+	numWitnesses,
+	witnessConstraints,
+	storageTTLSeconds,
+	storageTier,
+	coldAfterSeconds,
 }
 
 const (
@@ -65,4 +70,10 @@ const (
 	constraints      = constraintsConjunctionField(config.Constraints)
 	voterConstraints = constraintsConjunctionField(config.VoterConstraints)
 	leasePreferences = leasePreferencesField(config.LeasePreferences)
+
+	numWitnesses       = int32Field(config.NumWitnesses)
+	witnessConstraints = constraintsConjunctionField(config.WitnessConstraints)
+	storageTTLSeconds  = int32Field(config.StorageTTLSeconds)
+	storageTier        = storageTierField(config.StorageTier)
+	coldAfterSeconds   = int32Field(config.ColdAfterSeconds)
 )
@@ -662,6 +662,24 @@ func PrintSpanConfigDiffedAgainstDefaults(conf roachpb.SpanConfig) string {
 	if conf.ExcludeDataFromBackup != defaultConf.ExcludeDataFromBackup {
 		diffs = append(diffs, fmt.Sprintf("exclude_data_from_backup=%v", conf.ExcludeDataFromBackup))
 	}
+	if conf.ColumnarBlockCache != defaultConf.ColumnarBlockCache {
+		diffs = append(diffs, fmt.Sprintf("columnar_block_cache=%v", conf.ColumnarBlockCache))
+	}
+	if conf.NumWitnesses != defaultConf.NumWitnesses {
+		diffs = append(diffs, fmt.Sprintf("num_witnesses=%d", conf.NumWitnesses))
+	}
+	if !reflect.DeepEqual(conf.WitnessConstraints, defaultConf.WitnessConstraints) {
+		diffs = append(diffs, fmt.Sprintf("witness_constraints=%v", conf.WitnessConstraints))
+	}
+	if conf.StorageTTLSeconds != defaultConf.StorageTTLSeconds {
+		diffs = append(diffs, fmt.Sprintf("storage_ttl_seconds=%d", conf.StorageTTLSeconds))
+	}
+	if conf.StorageTier != defaultConf.StorageTier {
+		diffs = append(diffs, fmt.Sprintf("storage_tier=%s", conf.StorageTier))
+	}
+	if conf.StorageTierColdAfterSeconds != defaultConf.StorageTierColdAfterSeconds {
+		diffs = append(diffs, fmt.Sprintf("storage_tier_cold_after_seconds=%d", conf.StorageTierColdAfterSeconds))
+	}
 
 	return strings.Join(diffs, " ")
 }
@@ -716,6 +716,9 @@ type TableDescriptor interface {
 	// GetExcludeDataFromBackup returns true if the table's row data is configured
 	// to be excluded during backup.
 	GetExcludeDataFromBackup() bool
+	// HasColumnarBlockCache returns true if the table's ranges are configured to
+	// keep an in-memory cache of column-oriented blocks of its rows.
+	HasColumnarBlockCache() bool
 	// GetStorageParams returns a list of storage parameters for the table.
 	GetStorageParams(spaceBetweenEqual bool) []string
 	// NoAutoStatsSettingsOverrides is true if no auto stats related settings are
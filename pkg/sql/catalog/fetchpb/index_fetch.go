@@ -11,6 +11,8 @@
 package fetchpb
 
 import (
+	"bytes"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
@@ -73,3 +75,10 @@ func (c *IndexFetchSpec_KeyColumn) EncodingDirection() encoding.Direction {
 	}
 	return encoding.Ascending
 }
+
+// Excludes returns whether none of the values in the given inclusive range,
+// key-encoded in ascending order, are within the bound.
+func (b *IndexFetchSpec_ColumnBound) Excludes(min, max []byte) bool {
+	return (len(b.Start) > 0 && bytes.Compare(max, b.Start) < 0) ||
+		(len(b.End) > 0 && bytes.Compare(min, b.End) > 0)
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colfetcher
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc/keyside"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+// columnarBlockCodec implements the storage.ColumnarBlockCodec interface. See
+// a large comment in storage/col_blocks.go for more details.
+type columnarBlockCodec struct {
+	typs      []*types.T
+	acc       *mon.BoundAccount
+	allocator *colmem.Allocator
+
+	// serialize indicates whether the decoded batches must be serialized.
+	serialize bool
+
+	// Fields below are only used when serializing the batches.
+	converter  *colserde.ArrowBatchConverter
+	serializer *colserde.RecordBatchSerializer
+	buf        bytes.Buffer
+}
+
+var _ storage.ColumnarBlockCodec = &columnarBlockCodec{}
+
+func init() {
+	storage.GetColumnarBlockCodec = newColumnarBlockCodec
+}
+
+// EncodeBlock implements the storage.ColumnarBlockCodec interface.
+func (c *columnarBlockCodec) EncodeBlock(
+	ctx context.Context, batches []coldata.Batch,
+) (_ []coldata.Vec, length int, _ []storage.ColumnZoneMap, size int64, _ error) {
+	for _, b := range batches {
+		length += b.Length()
+	}
+	block := c.allocator.NewMemBatchWithFixedCapacity(c.typs, length)
+	if err := colexecerror.CatchVectorizedRuntimeError(func() {
+		c.allocator.PerformOperation(block.ColVecs(), func() {
+			destIdx := 0
+			for _, b := range batches {
+				for i, vec := range block.ColVecs() {
+					vec.Copy(coldata.SliceArgs{
+						Src:         b.ColVec(i),
+						Sel:         b.Selection(),
+						DestIdx:     destIdx,
+						SrcStartIdx: 0,
+						SrcEndIdx:   b.Length(),
+					})
+				}
+				destIdx += b.Length()
+			}
+		})
+	}); err != nil {
+		return nil, 0, nil, 0, err
+	}
+	block.SetLength(length)
+
+	zoneMaps := make([]storage.ColumnZoneMap, len(c.typs))
+	converter := colconv.NewAllVecToDatumConverter(len(c.typs))
+	defer converter.Release()
+	if err := colexecerror.CatchVectorizedRuntimeError(func() {
+		converter.ConvertVecs(block.ColVecs(), length, nil /* sel */)
+	}); err != nil {
+		return nil, 0, nil, 0, err
+	}
+	for i := range zoneMaps {
+		zoneMaps[i] = computeZoneMap(converter.GetDatumColumn(i)[:length])
+	}
+	return block.ColVecs(), length, zoneMaps, colmem.GetBatchMemSize(block), nil
+}
+
+// computeZoneMap returns the zone map of the given column values.
+func computeZoneMap(datums tree.Datums) storage.ColumnZoneMap {
+	var z storage.ColumnZoneMap
+	for _, d := range datums {
+		if d == tree.DNull {
+			continue
+		}
+		key, err := keyside.Encode(nil /* b */, d, encoding.Ascending)
+		if err != nil {
+			// The type of the column has no key encoding.
+			return storage.ColumnZoneMap{Unknown: true}
+		}
+		if z.Min == nil || bytes.Compare(key, z.Min) < 0 {
+			z.Min = key
+		}
+		if z.Max == nil || bytes.Compare(key, z.Max) > 0 {
+			z.Max = key
+		}
+	}
+	return z
+}
+
+// DecodeBlock implements the storage.ColumnarBlockCodec interface.
+func (c *columnarBlockCodec) DecodeBlock(
+	ctx context.Context, cols []coldata.Vec, length int,
+) ([]byte, coldata.Batch, error) {
+	// The columns of the block are shared by all the scans, so we always copy
+	// them into a new batch; note that the batch could be modified later (for
+	// example, the ArrowBatchConverter truncates the nulls).
+	batch := c.allocator.NewMemBatchWithFixedCapacity(c.typs, length)
+	if err := colexecerror.CatchVectorizedRuntimeError(func() {
+		c.allocator.PerformOperation(batch.ColVecs(), func() {
+			for i, vec := range batch.ColVecs() {
+				vec.Copy(coldata.SliceArgs{
+					Src:         cols[i],
+					SrcStartIdx: 0,
+					SrcEndIdx:   length,
+				})
+			}
+		})
+	}); err != nil {
+		return nil, nil, err
+	}
+	batch.SetLength(length)
+	if !c.serialize {
+		return nil, batch, nil
+	}
+	// The batch is only needed until it's serialized.
+	defer c.allocator.ReleaseMemory(colmem.GetBatchMemSize(batch))
+	data, err := c.converter.BatchToArrow(ctx, batch)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.buf.Reset()
+	if _, _, err = c.serializer.Serialize(&c.buf, data, length); err != nil {
+		return nil, nil, err
+	}
+	serializedBatch := c.buf.Bytes()
+	if err = c.acc.Grow(ctx, int64(len(serializedBatch))); err != nil {
+		return nil, nil, err
+	}
+	b := make([]byte, len(serializedBatch))
+	copy(b, serializedBatch)
+	return b, nil, nil
+}
+
+// Close implements the storage.ColumnarBlockCodec interface.
+func (c *columnarBlockCodec) Close(ctx context.Context) {
+	if c.converter != nil {
+		c.converter.Release(ctx)
+		c.converter = nil
+	}
+	c.buf = bytes.Buffer{}
+}
+
+func newColumnarBlockCodec(
+	ctx context.Context,
+	st *cluster.Settings,
+	acc *mon.BoundAccount,
+	fetchSpec *fetchpb.IndexFetchSpec,
+	mustSerialize bool,
+) (storage.ColumnarBlockCodec, error) {
+	// Enums are never kept in the blocks (the cFetcherWrapper always serializes
+	// them), but this allows for a uniform handling of the fetch spec.
+	const allowUnhydratedEnums = true
+	tableArgs, err := populateTableArgs(ctx, fetchSpec, nil /* typeResolver */, allowUnhydratedEnums)
+	if err != nil {
+		return nil, err
+	}
+	defer tableArgs.Release()
+	typs := make([]*types.T, len(tableArgs.typs))
+	copy(typs, tableArgs.typs)
+	codec := columnarBlockCodec{
+		typs: typs,
+		acc:  acc,
+		// We don't need to provide the eval context here since the vectors are
+		// only copied, converted to datums for encoding, and serialized.
+		allocator: colmem.NewAllocator(ctx, acc, coldataext.NewExtendedColumnFactoryNoEvalCtx()),
+		serialize: mustSerialize,
+	}
+	if mustSerialize {
+		codec.converter, err = colserde.NewArrowBatchConverter(typs, colserde.BatchToArrowOnly, acc)
+		if err != nil {
+			return nil, err
+		}
+		codec.serializer, err = colserde.NewRecordBatchSerializer(typs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &codec, nil
+}
@@ -215,6 +215,9 @@ var crdbInternal = virtualSchema{
 		catconstants.CrdbInternalKVFlowControllerID:                 crdbInternalKVFlowController,
 		catconstants.CrdbInternalKVFlowTokenDeductions:              crdbInternalKVFlowTokenDeductions,
 		catconstants.CrdbInternalRepairableCatalogCorruptionsViewID: crdbInternalRepairableCatalogCorruptions,
+		catconstants.CrdbInternalHotKeysTableID:                     crdbInternalHotKeysTable,
+		catconstants.CrdbInternalKVStoreReencryptionTableID:         crdbInternalKVStoreReencryptionTable,
+		catconstants.CrdbInternalVerifyBackupResultsTableID:         crdbInternalVerifyBackupResultsTable,
 	},
 	validWithNoDatabaseContext: true,
 }
@@ -5393,6 +5396,108 @@ CREATE TABLE crdb_internal.kv_store_status (
 	},
 }
 
+// crdbInternalKVStoreReencryptionTable exposes the progress of re-encrypting
+// the files of the cluster stores after a rotation of the encryption-at-rest
+// store key.
+var crdbInternalKVStoreReencryptionTable = virtualSchemaTable{
+	comment: "store re-encryption progress after encryption-at-rest store key rotations (cluster RPC; expensive!)",
+	schema: `
+CREATE TABLE crdb_internal.kv_store_reencryption (
+  node_id           INT NOT NULL,
+  store_id          INT NOT NULL,
+  encrypted         BOOL NOT NULL,
+  retired_key_files INT NOT NULL,
+  retired_key_bytes INT NOT NULL,
+  reencrypted_bytes INT NOT NULL,
+  fully_rotated     BOOL NOT NULL
+)
+	`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.RequireAdminRole(ctx, "read crdb_internal.kv_store_reencryption"); err != nil {
+			return err
+		}
+		ss, err := p.ExecCfg().NodesStatusServer.OptionalNodesStatusServer(
+			errorutil.FeatureNotAvailableToNonSystemTenantsIssue)
+		if err != nil {
+			return err
+		}
+		response, err := ss.ListNodesInternal(ctx, &serverpb.NodesRequest{})
+		if err != nil {
+			return err
+		}
+
+		for _, n := range response.Nodes {
+			for _, s := range n.StoreStatuses {
+				// The re-encryption progress is recorded in the store metrics,
+				// which are refreshed periodically by the status recorder.
+				retiredKeyFiles := int64(s.Metrics["storage.encryption.retired-key.files"])
+				if err := addRow(
+					tree.NewDInt(tree.DInt(s.Desc.Node.NodeID)),
+					tree.NewDInt(tree.DInt(s.Desc.StoreID)),
+					tree.MakeDBool(tree.DBool(s.Desc.Properties.Encrypted)),
+					tree.NewDInt(tree.DInt(retiredKeyFiles)),
+					tree.NewDInt(tree.DInt(s.Metrics["storage.encryption.retired-key.bytes"])),
+					tree.NewDInt(tree.DInt(s.Metrics["storage.encryption.reencrypted.bytes"])),
+					tree.MakeDBool(retiredKeyFiles == 0),
+				); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// crdbInternalVerifyBackupResultsTable exposes the results that VERIFY BACKUP
+// jobs record in system.job_info for each virtual cluster of a backup.
+var crdbInternalVerifyBackupResultsTable = virtualSchemaTable{
+	comment: "fingerprints of the virtual clusters restored by VERIFY BACKUP jobs",
+	schema: `
+CREATE TABLE crdb_internal.verify_backup_results (
+  job_id               INT NOT NULL,
+  virtual_cluster_id   INT NOT NULL,
+  backup_fingerprint   INT NOT NULL,
+  restored_fingerprint INT NOT NULL,
+  restore_job_id       INT NOT NULL,
+  matched              BOOL NOT NULL
+)
+	`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		// VERIFY BACKUP can only be run by admins.
+		if err := p.RequireAdminRole(ctx, "read crdb_internal.verify_backup_results"); err != nil {
+			return err
+		}
+		rows, err := p.InternalSQLTxn().QueryBufferedEx(
+			ctx, "crdb-internal-verify-backup-results", p.txn, sessiondata.NodeUserSessionDataOverride,
+			`SELECT i.job_id, i.value
+FROM system.jobs AS j JOIN system.job_info AS i ON i.job_id = j.id
+WHERE j.job_type = $1 AND starts_with(i.info_key, $2)
+ORDER BY i.job_id, i.info_key`,
+			jobspb.TypeVerifyBackup.String(), jobspb.VerifyBackupResultInfoKeyPrefix,
+		)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			var res jobspb.VerifyBackupResult
+			if err := protoutil.Unmarshal([]byte(tree.MustBeDBytes(r[1])), &res); err != nil {
+				return err
+			}
+			if err := addRow(
+				r[0],
+				tree.NewDInt(tree.DInt(res.TenantID)),
+				tree.NewDInt(tree.DInt(res.BackupFingerprint)),
+				tree.NewDInt(tree.DInt(res.RestoredFingerprint)),
+				tree.NewDInt(tree.DInt(res.RestoreJobID)),
+				tree.MakeDBool(res.BackupFingerprint == res.RestoredFingerprint),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 var crdbInternalCatalogDescriptorTable = virtualSchemaTable{
 	comment: `like system.descriptor but overlaid with in-txn in-memory changes and including virtual objects`,
 	schema: `
@@ -8505,3 +8610,130 @@ func populateFlowTokensResponse(
 	}
 	return nil
 }
+
+var crdbInternalHotKeysTable = virtualSchemaTable{
+	comment: `sampled hottest keys of the hottest ranges in the cluster (KV layer, cluster RPC; expensive!)`,
+	schema: `
+CREATE TABLE crdb_internal.hot_keys (
+  range_id            INT NOT NULL,
+  node_id             INT NOT NULL,
+  store_id            INT NOT NULL,
+  database_name       STRING,
+  schema_name         STRING,
+  table_name          STRING,
+  index_name          STRING,
+  key                 BYTES NOT NULL,
+  pretty_key          STRING NOT NULL,
+  index_values        STRING,
+  requests_per_second FLOAT NOT NULL,
+  bytes_per_second    FLOAT NOT NULL
+);`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		// Hot keys are subject to the same privilege check as the hot ranges
+		// status endpoint.
+		if err := p.CheckPrivilege(
+			ctx, syntheticprivilege.GlobalPrivilegeObject, privilege.VIEWCLUSTERMETADATA,
+		); err != nil {
+			return err
+		}
+		resp, err := p.ExecCfg().TenantStatusServer.HotRangesV2(ctx, &serverpb.HotRangesRequest{})
+		if err != nil {
+			return err
+		}
+		if err := p.redactHotKeys(ctx, resp.Ranges); err != nil {
+			return err
+		}
+		stringOrNull := func(s string) tree.Datum {
+			if s == "" {
+				return tree.DNull
+			}
+			return tree.NewDString(s)
+		}
+		for _, r := range resp.Ranges {
+			for _, hk := range r.HotKeys {
+				tableName, indexName := r.TableName, r.IndexName
+				if hk.TableName != "" {
+					tableName, indexName = hk.TableName, hk.IndexName
+				}
+				if err := addRow(
+					tree.NewDInt(tree.DInt(r.RangeID)),
+					tree.NewDInt(tree.DInt(r.NodeID)),
+					tree.NewDInt(tree.DInt(r.StoreID)),
+					stringOrNull(r.DatabaseName),
+					stringOrNull(r.SchemaName),
+					stringOrNull(tableName),
+					stringOrNull(indexName),
+					tree.NewDBytes(tree.DBytes(hk.Key)),
+					tree.NewDString(hk.PrettyKey),
+					stringOrNull(hk.IndexValues),
+					tree.NewDFloat(tree.DFloat(hk.RequestsPerSecond)),
+					tree.NewDFloat(tree.DFloat(hk.BytesPerSecond)),
+				); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// redactHotKeys redacts, in place, the hot keys of the given hot ranges that
+// belong to tables on which the user does not have the SELECT privilege, as
+// they contain the values of the indexed columns: they are truncated to the
+// prefix of their index. The keys of tables that were dropped since they were
+// sampled are redacted as well.
+func (p *planner) redactHotKeys(
+	ctx context.Context, ranges []*serverpb.HotRangesResponseV2_HotRange,
+) error {
+	codec := p.ExecCfg().Codec
+	canSelect := make(map[uint32]bool)
+	for _, r := range ranges {
+		for i := range r.HotKeys {
+			hk := &r.HotKeys[i]
+			_, tableID, indexID, err := codec.DecodeIndexPrefix(hk.Key)
+			if err != nil || keys.IsPseudoTableID(tableID) {
+				continue
+			}
+			ok, found := canSelect[tableID]
+			if !found {
+				desc, err := p.Descriptors().ByIDWithLeased(p.txn).WithoutNonPublic().Get().Table(
+					ctx, descpb.ID(tableID))
+				if err != nil {
+					// The table was dropped since; its keys are redacted.
+					ok = false
+				} else if ok, err = p.HasPrivilege(ctx, desc, privilege.SELECT, p.User()); err != nil {
+					return err
+				}
+				canSelect[tableID] = ok
+			}
+			if ok {
+				continue
+			}
+			hk.Key = codec.IndexPrefix(tableID, indexID)
+			hk.PrettyKey = hk.Key.String() + "/" + string(redact.RedactedMarker())
+			hk.IndexValues = ""
+		}
+	}
+	return nil
+}
+
+// RedactHotKeys redacts, in place, the hot keys of the given hot ranges that
+// belong to tables on which the given user does not have the SELECT privilege.
+// It is used by the hot ranges status endpoint, and applies the same check as
+// crdb_internal.hot_keys.
+func RedactHotKeys(
+	ctx context.Context,
+	execCfg *ExecutorConfig,
+	user username.SQLUsername,
+	ranges []*serverpb.HotRangesResponseV2_HotRange,
+) error {
+	const opName = "redact-hot-keys"
+	return execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		p, cleanup := newInternalPlanner(
+			opName, txn.KV(), user, &MemoryMetrics{}, execCfg,
+			NewInternalSessionData(ctx, execCfg.Settings, opName),
+		)
+		defer cleanup()
+		return p.redactHotKeys(ctx, ranges)
+	})
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/server"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/status/statuspb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/spanconfig"
@@ -65,6 +66,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/upgrade/upgradebase"
 	"github.com/cockroachdb/cockroach/pkg/util/cancelchecker"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -1632,3 +1634,47 @@ func TestVirtualTableDoesntHangOnQueryCanceledError(t *testing.T) {
 	// Sanity check that the callback was added at least once.
 	require.Greater(t, numCallbacksAdded.Load(), int32(0))
 }
+
+// TestRedactHotKeys checks that the hot keys of tables on which a user does not
+// have the SELECT privilege are truncated to the prefix of their index.
+func TestRedactHotKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, db, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer srv.Stopper().Stop(ctx)
+	s := srv.ApplicationLayer()
+	execCfg := s.ExecutorConfig().(sql.ExecutorConfig)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `CREATE TABLE t (k INT PRIMARY KEY)`)
+	sqlDB.Exec(t, `CREATE USER testuser`)
+
+	desc := desctestutils.TestingGetPublicTableDescriptor(kvDB, s.Codec(), "defaultdb", "t")
+	prefix := s.Codec().IndexPrefix(uint32(desc.GetID()), uint32(desc.GetPrimaryIndexID()))
+	key := roachpb.Key(encoding.EncodeVarintAscending(prefix.Clone(), 42))
+	redactHotKeys := func(user username.SQLUsername) serverpb.HotRangesResponseV2_HotKey {
+		ranges := []*serverpb.HotRangesResponseV2_HotRange{{
+			HotKeys: []serverpb.HotRangesResponseV2_HotKey{{
+				Key: key, PrettyKey: key.String(), IndexValues: "(42)",
+			}},
+		}}
+		require.NoError(t, sql.RedactHotKeys(ctx, &execCfg, user, ranges))
+		return ranges[0].HotKeys[0]
+	}
+
+	hk := redactHotKeys(username.RootUserName())
+	require.Equal(t, key, hk.Key)
+	require.Equal(t, "(42)", hk.IndexValues)
+
+	testUser := username.TestUserName()
+	hk = redactHotKeys(testUser)
+	require.Equal(t, prefix, hk.Key)
+	require.Equal(t, prefix.String()+"/‹×›", hk.PrettyKey)
+	require.Empty(t, hk.IndexValues)
+
+	sqlDB.Exec(t, `GRANT SELECT ON t TO testuser`)
+	hk = redactHotKeys(testUser)
+	require.Equal(t, key, hk.Key)
+	require.Equal(t, "(42)", hk.IndexValues)
+}
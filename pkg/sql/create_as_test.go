@@ -84,11 +84,13 @@ func TestCreateAsVTable(t *testing.T) {
 					`"".crdb_internal.gossip_alerts`:                  {},
 					`"".crdb_internal.gossip_liveness`:                {},
 					`"".crdb_internal.gossip_nodes`:                   {},
+					`"".crdb_internal.hot_keys`:                       {},
 					`"".crdb_internal.kv_flow_controller`:             {},
 					`"".crdb_internal.kv_flow_control_handles`:        {},
 					`"".crdb_internal.kv_flow_token_deductions`:       {},
 					`"".crdb_internal.kv_node_status`:                 {},
 					`"".crdb_internal.kv_node_liveness`:               {},
+					`"".crdb_internal.kv_store_reencryption`:          {},
 					`"".crdb_internal.kv_store_status`:                {},
 					`"".crdb_internal.node_tenant_capabilities_cache`: {},
 					`"".crdb_internal.tenant_usage_details`:           {},
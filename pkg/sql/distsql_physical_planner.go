@@ -34,6 +34,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/colflow"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsql"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra/execagg"
@@ -1684,6 +1685,32 @@ func (dsp *DistSQLPlanner) createTableReaders(
 	return p, err
 }
 
+// addColumnBoundsToTableReaders adds the bounds on the values of the scanned
+// columns which are implied by the filter on the scan to the fetch specs of the
+// TableReaders of the plan. This allows the KV layer to skip the blocks of rows
+// of tables with a columnar block cache which can't pass the filter. The filter still
+// needs to be applied to the rows of the remaining blocks.
+func addColumnBoundsToTableReaders(plan *PhysicalPlan, n *scanNode, filter tree.TypedExpr) {
+	if !n.desc.HasColumnarBlockCache() || n.hardLimit != 0 {
+		// The hard limit applies to the scanned rows before the filter, so none
+		// of the rows can be skipped.
+		return
+	}
+	var bounds []fetchpb.IndexFetchSpec_ColumnBound
+	for i, idx := range plan.ResultRouters {
+		tr := plan.Processors[idx].Spec.Core.TableReader
+		if tr == nil {
+			return
+		}
+		if i == 0 {
+			if bounds = rowenc.ColumnBoundsFromFilter(&tr.FetchSpec, filter); len(bounds) == 0 {
+				return
+			}
+		}
+		tr.FetchSpec.ColumnBounds = bounds
+	}
+}
+
 // tableReaderPlanningInfo is a utility struct that contains the information
 // needed to perform the physical planning of table readers once the specs have
 // been created. See scanNode to get more context on some of the fields.
@@ -3403,6 +3430,9 @@ func (dsp *DistSQLPlanner) createPhysPlanForPlanNode(
 			return nil, err
 		}
 
+		if scan, ok := n.source.plan.(*scanNode); ok {
+			addColumnBoundsToTableReaders(plan, scan, n.filter)
+		}
 		if err := plan.AddFilter(ctx, n.filter, planCtx, plan.PlanToStreamColMap); err != nil {
 			return nil, err
 		}
@@ -82,6 +82,8 @@ const (
 	exportSnappyCodec     = "snappy"
 	csvSuffix             = "csv"
 	parquetSuffix         = "parquet"
+	jsonlSuffix           = "jsonl"
+	avroSuffix            = "avro"
 )
 
 var exportOptionExpectValues = map[string]exprutil.KVStringOptValidate{
@@ -131,7 +133,9 @@ func (ef *execFactory) ConstructExport(
 		return nil, errors.Errorf("EXPORT cannot be used inside a multi-statement transaction")
 	}
 
-	if fileSuffix != csvSuffix && fileSuffix != parquetSuffix {
+	switch fileSuffix {
+	case csvSuffix, parquetSuffix, jsonlSuffix, avroSuffix:
+	default:
 		return nil, errors.Errorf("unsupported export format: %q", fileSuffix)
 	}
 
@@ -208,6 +212,13 @@ func (ef *execFactory) ConstructExport(
 		}
 		format.Format = roachpb.IOFileFormat_Parquet
 		format.Parquet = parquetOpts
+	case jsonlSuffix:
+		format.Format = roachpb.IOFileFormat_JSONL
+	case avroSuffix:
+		// Avro files are written as object container files, which embed the
+		// schema of their records.
+		format.Format = roachpb.IOFileFormat_Avro
+		format.Avro = roachpb.AvroOptions{Format: roachpb.AvroOptions_OCF}
 	}
 
 	chunkRows := exportChunkRowsDefault
@@ -239,7 +250,7 @@ func (ef *execFactory) ConstructExport(
 		switch {
 		case strings.EqualFold(name, exportGzipCodec):
 			codec = roachpb.IOFileFormat_Gzip
-		case strings.EqualFold(name, exportSnappyCodec) && fileSuffix == parquetSuffix:
+		case strings.EqualFold(name, exportSnappyCodec) && (fileSuffix == parquetSuffix || fileSuffix == avroSuffix):
 			codec = roachpb.IOFileFormat_Snappy
 		default:
 			return nil, pgerror.Newf(pgcode.InvalidParameterValue,
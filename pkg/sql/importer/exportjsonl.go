@@ -0,0 +1,283 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondatapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+const exportJSONLFilePatternDefault = exportFilePatternPart + ".jsonl"
+
+// jsonlExporter writes rows as JSON objects, one per line, whose keys are the
+// names of the exported columns.
+type jsonlExporter struct {
+	colNames   []string
+	compressor *gzip.Writer
+	buf        *bytes.Buffer
+	w          io.Writer
+	// scratch holds the encoding of the row being written.
+	scratch bytes.Buffer
+}
+
+func newJSONLExporter(sp execinfrapb.ExportSpec) (*jsonlExporter, error) {
+	e := &jsonlExporter{
+		colNames: sp.ColNames,
+		buf:      bytes.NewBuffer([]byte{}),
+	}
+	switch sp.Format.Compression {
+	case roachpb.IOFileFormat_Gzip:
+		e.compressor = gzip.NewWriter(e.buf)
+		e.w = e.compressor
+	case roachpb.IOFileFormat_Auto, roachpb.IOFileFormat_None:
+		e.w = e.buf
+	default:
+		return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+			"jsonl writer does not support compression format %s", sp.Format.Compression)
+	}
+	return e, nil
+}
+
+// Write appends the row to the file as a JSON object. The keys of the object
+// are in the order of the columns, and NULLs are written as JSON nulls.
+func (e *jsonlExporter) Write(row tree.Datums) error {
+	e.scratch.Reset()
+	e.scratch.WriteByte('{')
+	for i, d := range row {
+		if i > 0 {
+			e.scratch.WriteString(", ")
+		}
+		json.FromString(e.colNames[i]).Format(&e.scratch)
+		e.scratch.WriteString(": ")
+		j, err := tree.AsJSON(d, sessiondatapb.DataConversionConfig{}, time.UTC)
+		if err != nil {
+			return errors.Wrapf(err, "column %s", e.colNames[i])
+		}
+		j.Format(&e.scratch)
+	}
+	e.scratch.WriteString("}\n")
+	_, err := e.w.Write(e.scratch.Bytes())
+	return err
+}
+
+// Close closes the compressor, which appends its footer.
+func (e *jsonlExporter) Close() error {
+	if e.compressor != nil {
+		return e.compressor.Close()
+	}
+	return nil
+}
+
+// ResetBuffer resets the buffer and compressor state.
+func (e *jsonlExporter) ResetBuffer() {
+	e.buf.Reset()
+	if e.compressor != nil {
+		e.compressor.Reset(e.buf)
+	}
+}
+
+func (e *jsonlExporter) FileName(spec execinfrapb.ExportSpec, part string) string {
+	pattern := exportJSONLFilePatternDefault
+	if spec.NamePattern != "" {
+		pattern = spec.NamePattern
+	}
+	fileName := strings.Replace(pattern, exportFilePatternPart, part, -1)
+	if e.compressor != nil {
+		fileName += ".gz"
+	}
+	return fileName
+}
+
+func newJSONLWriterProcessor(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	processorID int32,
+	spec execinfrapb.ExportSpec,
+	post *execinfrapb.PostProcessSpec,
+	input execinfra.RowSource,
+) (execinfra.Processor, error) {
+	c := &jsonlWriter{
+		flowCtx:     flowCtx,
+		processorID: processorID,
+		spec:        spec,
+		input:       input,
+	}
+	semaCtx := tree.MakeSemaContext()
+	if err := c.out.Init(ctx, post, colinfo.ExportColumnTypes, &semaCtx, flowCtx.NewEvalCtx()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type jsonlWriter struct {
+	flowCtx     *execinfra.FlowCtx
+	processorID int32
+	spec        execinfrapb.ExportSpec
+	input       execinfra.RowSource
+	out         execinfra.ProcOutputHelper
+}
+
+var _ execinfra.Processor = &jsonlWriter{}
+
+func (sp *jsonlWriter) OutputTypes() []*types.T {
+	return sp.out.OutputTypes
+}
+
+func (sp *jsonlWriter) MustBeStreaming() bool {
+	return false
+}
+
+func (sp *jsonlWriter) Run(ctx context.Context, output execinfra.RowReceiver) {
+	ctx, span := tracing.ChildSpan(ctx, "jsonlWriter")
+	defer span.Finish()
+
+	instanceID := sp.flowCtx.EvalCtx.NodeID.SQLInstanceID()
+	uniqueID := builtins.GenerateUniqueInt(builtins.ProcessUniqueID(instanceID))
+
+	err := func() error {
+		typs := sp.input.OutputTypes()
+		sp.input.Start(ctx)
+		input := execinfra.MakeNoMetadataRowSource(sp.input, output)
+
+		alloc := &tree.DatumAlloc{}
+
+		writer, err := newJSONLExporter(sp.spec)
+		if err != nil {
+			return err
+		}
+		datums := make(tree.Datums, len(typs))
+
+		chunk := 0
+		done := false
+		for {
+			var rows int64
+			writer.ResetBuffer()
+			for {
+				// If the bytes.Buffer sink exceeds the target size of a file, we flush
+				// before exporting any additional rows.
+				if int64(writer.buf.Len()) >= sp.spec.ChunkSize {
+					break
+				}
+				if sp.spec.ChunkRows > 0 && rows >= sp.spec.ChunkRows {
+					break
+				}
+				row, err := input.NextRow()
+				if err != nil {
+					return err
+				}
+				if row == nil {
+					done = true
+					break
+				}
+				rows++
+
+				for i, ed := range row {
+					if err := ed.EnsureDecoded(typs[i], alloc); err != nil {
+						return err
+					}
+					datums[i] = tree.UnwrapDOidWrapper(ed.Datum)
+				}
+				if err := writer.Write(datums); err != nil {
+					return err
+				}
+			}
+			if rows < 1 {
+				break
+			}
+			// Close writer to ensure buffer and any compression footer is flushed.
+			if err := writer.Close(); err != nil {
+				return errors.Wrapf(err, "failed to close exporting writer")
+			}
+
+			conf, err := cloud.ExternalStorageConfFromURI(sp.spec.Destination, sp.spec.User())
+			if err != nil {
+				return err
+			}
+			es, err := sp.flowCtx.Cfg.ExternalStorage(ctx, conf)
+			if err != nil {
+				return err
+			}
+			defer es.Close()
+
+			part := fmt.Sprintf("n%d.%d", uniqueID, chunk)
+			chunk++
+			filename := writer.FileName(sp.spec, part)
+			size := writer.buf.Len()
+
+			if err := cloud.WriteFile(ctx, es, filename, bytes.NewReader(writer.buf.Bytes())); err != nil {
+				return err
+			}
+			res := rowenc.EncDatumRow{
+				rowenc.DatumToEncDatum(
+					types.String,
+					tree.NewDString(filename),
+				),
+				rowenc.DatumToEncDatum(
+					types.Int,
+					tree.NewDInt(tree.DInt(rows)),
+				),
+				rowenc.DatumToEncDatum(
+					types.Int,
+					tree.NewDInt(tree.DInt(size)),
+				),
+			}
+
+			cs, err := sp.out.EmitRow(ctx, res, output)
+			if err != nil {
+				return err
+			}
+			if cs != execinfra.NeedMoreRows {
+				// We don't return an error here because we want the error (if any) that
+				// actually caused the consumer to enter a closed/draining state to take precendence.
+				return nil
+			}
+			if done {
+				break
+			}
+		}
+
+		return nil
+	}()
+
+	execinfra.DrainAndClose(
+		ctx, output, err, func(context.Context, execinfra.RowReceiver) {} /* pushTrailingMeta */, sp.input)
+}
+
+// Resume is part of the execinfra.Processor interface.
+func (sp *jsonlWriter) Resume(output execinfra.RowReceiver) {
+	panic("not implemented")
+}
+
+func init() {
+	rowexec.NewJSONLWriterProcessor = newJSONLWriterProcessor
+}
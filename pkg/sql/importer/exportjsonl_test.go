@@ -0,0 +1,83 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLExporter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	arr := tree.NewDArray(types.Int)
+	require.NoError(t, arr.Append(tree.NewDInt(1)))
+	require.NoError(t, arr.Append(tree.DNull))
+	j, err := tree.ParseDJSON(`{"k": [true]}`)
+	require.NoError(t, err)
+	rows := []tree.Datums{
+		{tree.NewDInt(1), tree.NewDString("a \"quoted\"\nline"), arr, j},
+		{tree.NewDInt(2), tree.DNull, tree.DNull, tree.DNull},
+	}
+	const expected = `{"z": 1, "a b": "a \"quoted\"\nline", "arr": [1, null], "j": {"k": [true]}}
+{"z": 2, "a b": null, "arr": null, "j": null}
+`
+
+	spec := execinfrapb.ExportSpec{
+		NamePattern: "export-%part%.jsonl",
+		ColNames:    []string{"z", "a b", "arr", "j"},
+	}
+	t.Run("uncompressed", func(t *testing.T) {
+		e, err := newJSONLExporter(spec)
+		require.NoError(t, err)
+		e.ResetBuffer()
+		for _, r := range rows {
+			require.NoError(t, e.Write(r))
+		}
+		require.NoError(t, e.Close())
+		require.Equal(t, expected, e.buf.String())
+		require.Equal(t, "export-n1.0.jsonl", e.FileName(spec, "n1.0"))
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		gzipSpec := spec
+		gzipSpec.Format.Compression = roachpb.IOFileFormat_Gzip
+		e, err := newJSONLExporter(gzipSpec)
+		require.NoError(t, err)
+		e.ResetBuffer()
+		for _, r := range rows {
+			require.NoError(t, e.Write(r))
+		}
+		require.NoError(t, e.Close())
+		require.Equal(t, "export-n1.0.jsonl.gz", e.FileName(gzipSpec, "n1.0"))
+
+		r, err := gzip.NewReader(e.buf)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(decompressed))
+	})
+
+	snappySpec := spec
+	snappySpec.Format.Compression = roachpb.IOFileFormat_Snappy
+	_, err = newJSONLExporter(snappySpec)
+	require.ErrorContains(t, err, "jsonl writer does not support compression format Snappy")
+}
@@ -338,6 +338,14 @@ func makeInputConverter(
 		return newAvroInputReader(
 			semaCtx, kvCh, singleTable, spec.Format.Avro, spec.WalltimeNanos,
 			readerParallelism, evalCtx, db)
+	case roachpb.IOFileFormat_Parquet:
+		return newParquetInputReader(
+			semaCtx, kvCh, spec.Format.Parquet, spec.WalltimeNanos, readerParallelism,
+			singleTable, singleTableTargetCols, evalCtx, seqChunkProvider, db), nil
+	case roachpb.IOFileFormat_JSONL:
+		return newJSONLInputReader(
+			semaCtx, kvCh, spec.Format.Jsonl, spec.WalltimeNanos, readerParallelism,
+			singleTable, singleTableTargetCols, evalCtx, seqChunkProvider, db), nil
 	default:
 		return nil, errors.Errorf(
 			"Requested IMPORT format (%d) not supported by this node", spec.Format.Format)
@@ -33,6 +33,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/typedesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
@@ -245,8 +246,7 @@ func readInputFiles(
 			src.Reader = decompressed
 
 			var rejected chan string
-			if (format.Format == roachpb.IOFileFormat_CSV && format.SaveRejected) ||
-				(format.Format == roachpb.IOFileFormat_MysqlOutfile && format.SaveRejected) {
+			if format.SaveRejected && formatSavesRejectedRows(format.Format) {
 				rejected = make(chan string)
 			}
 			dataFile := dataFile // copy for safe reference in Go routine
@@ -392,7 +392,22 @@ func formatHasNamedColumns(format roachpb.IOFileFormat_FileFormat) bool {
 	switch format {
 	case roachpb.IOFileFormat_Avro,
 		roachpb.IOFileFormat_Mysqldump,
-		roachpb.IOFileFormat_PgDump:
+		roachpb.IOFileFormat_PgDump,
+		roachpb.IOFileFormat_Parquet,
+		roachpb.IOFileFormat_JSONL:
+		return true
+	}
+	return false
+}
+
+// formatSavesRejectedRows returns true if rows of the input files which fail
+// to parse can be saved to a side file instead of failing the import.
+func formatSavesRejectedRows(format roachpb.IOFileFormat_FileFormat) bool {
+	switch format {
+	case roachpb.IOFileFormat_CSV,
+		roachpb.IOFileFormat_MysqlOutfile,
+		roachpb.IOFileFormat_Parquet,
+		roachpb.IOFileFormat_JSONL:
 		return true
 	}
 	return false
@@ -503,6 +518,60 @@ func makeDatumConverter(
 	return conv, err
 }
 
+// namedColumns maps the names of the fields of records, in formats whose
+// records name their fields, to the columns filled by the datum converter.
+type namedColumns struct {
+	idxByName map[string]int
+	// strict requires each record to have a field for every column, and every
+	// field of a record to have a column.
+	strict bool
+}
+
+func makeNamedColumns(importCtx *parallelImportContext, strict bool) namedColumns {
+	idxByName := make(map[string]int)
+	if len(importCtx.targetCols) > 0 {
+		for i, name := range importCtx.targetCols {
+			idxByName[string(name)] = i
+		}
+	} else {
+		for i, col := range importCtx.tableDesc.VisibleColumns() {
+			idxByName[col.GetName()] = i
+		}
+	}
+	return namedColumns{idxByName: idxByName, strict: strict}
+}
+
+// lookup returns the index of the column for the named field, if any.
+func (n namedColumns) lookup(field string) (int, bool, error) {
+	idx, ok := n.idxByName[lexbase.NormalizeName(field)]
+	if !ok && n.strict {
+		return 0, false, errors.Newf("could not find column for record field %s", field)
+	}
+	return idx, ok, nil
+}
+
+// reset clears the datums of the converter before filling in a record.
+func (n namedColumns) reset(conv *row.DatumRowConverter) {
+	for i := range conv.Datums {
+		if conv.TargetColOrds.Contains(i) {
+			conv.Datums[i] = nil
+		}
+	}
+}
+
+// finish sets the columns that the record had no field for to NULL.
+func (n namedColumns) finish(conv *row.DatumRowConverter) error {
+	for i := range conv.Datums {
+		if conv.TargetColOrds.Contains(i) && conv.Datums[i] == nil {
+			if n.strict {
+				return errors.Newf("field %s was not set in the record", conv.VisibleCols[i].GetName())
+			}
+			conv.Datums[i] = tree.DNull
+		}
+	}
+	return nil
+}
+
 // importRowProducer is producer of "rows" that must be imported.
 // Row is an opaque interface{} object which will be passed onto
 // the consumer implementation.
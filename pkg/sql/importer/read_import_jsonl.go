@@ -0,0 +1,245 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// jsonlInputReader reads newline-delimited JSON files, in which each line
+// holds a JSON object whose keys name the columns of the row.
+type jsonlInputReader struct {
+	importCtx *parallelImportContext
+	opts      roachpb.JSONLOptions
+}
+
+var _ inputConverter = &jsonlInputReader{}
+
+func newJSONLInputReader(
+	semaCtx *tree.SemaContext,
+	kvCh chan row.KVBatch,
+	opts roachpb.JSONLOptions,
+	walltime int64,
+	parallelism int,
+	tableDesc catalog.TableDescriptor,
+	targetCols tree.NameList,
+	evalCtx *eval.Context,
+	seqChunkProvider *row.SeqChunkProvider,
+	db *kv.DB,
+) *jsonlInputReader {
+	return &jsonlInputReader{
+		importCtx: &parallelImportContext{
+			semaCtx:          semaCtx,
+			walltime:         walltime,
+			numWorkers:       parallelism,
+			evalCtx:          evalCtx,
+			tableDesc:        tableDesc,
+			targetCols:       targetCols,
+			kvCh:             kvCh,
+			seqChunkProvider: seqChunkProvider,
+			db:               db,
+		},
+		opts: opts,
+	}
+}
+
+func (j *jsonlInputReader) start(group ctxgroup.Group) {}
+
+func (j *jsonlInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	resumePos map[int32]int64,
+	format roachpb.IOFileFormat,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+) error {
+	return readInputFiles(ctx, dataFiles, resumePos, format, j.readFile, makeExternalStorage, user)
+}
+
+func (j *jsonlInputReader) readFile(
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+) error {
+	producer, consumer := newJSONLPipeline(j, input)
+	fileCtx := &importFileContext{
+		source:   inputIdx,
+		skip:     resumePos,
+		rejected: rejected,
+		rowLimit: j.opts.RowLimit,
+	}
+	return runParallelImport(ctx, j.importCtx, fileCtx, producer, consumer)
+}
+
+func newJSONLPipeline(j *jsonlInputReader, input *fileReader) (*jsonlRowProducer, *jsonlRowConsumer) {
+	maxRowSize := int(j.opts.MaxRowSize)
+	if maxRowSize <= 0 {
+		maxRowSize = defaultScanBuffer
+	}
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRowSize)
+
+	producer := &jsonlRowProducer{
+		scanner:  scanner,
+		progress: func() float32 { return input.ReadFraction() },
+	}
+	consumer := &jsonlRowConsumer{
+		cols: makeNamedColumns(j.importCtx, j.opts.StrictMode),
+	}
+	return producer, consumer
+}
+
+// jsonlRowProducer produces the non-blank lines of the input.
+type jsonlRowProducer struct {
+	scanner  *bufio.Scanner
+	line     []byte
+	progress func() float32
+}
+
+var _ importRowProducer = &jsonlRowProducer{}
+
+// Scan implements the importRowProducer interface.
+func (p *jsonlRowProducer) Scan() bool {
+	for p.scanner.Scan() {
+		if p.line = bytes.TrimSpace(p.scanner.Bytes()); len(p.line) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Err implements the importRowProducer interface.
+func (p *jsonlRowProducer) Err() error {
+	return p.scanner.Err()
+}
+
+// Skip implements the importRowProducer interface.
+func (p *jsonlRowProducer) Skip() error {
+	return nil
+}
+
+// Row implements the importRowProducer interface.
+func (p *jsonlRowProducer) Row() (interface{}, error) {
+	// The scanner reuses its buffer, so the line is copied before it is handed
+	// to the consumers.
+	return string(p.line), nil
+}
+
+// Progress implements the importRowProducer interface.
+func (p *jsonlRowProducer) Progress() float32 {
+	return p.progress()
+}
+
+// jsonlRowConsumer converts the JSON object on a line to the datums of a row.
+type jsonlRowConsumer struct {
+	cols namedColumns
+}
+
+var _ importRowConsumer = &jsonlRowConsumer{}
+
+// FillDatums implements the importRowConsumer interface.
+func (c *jsonlRowConsumer) FillDatums(
+	ctx context.Context, row interface{}, rowNum int64, conv *row.DatumRowConverter,
+) error {
+	line := row.(string)
+	obj, err := json.ParseJSON(line)
+	if err != nil {
+		return newImportRowError(errors.Wrap(err, "parse JSON object"), line, rowNum)
+	}
+	if obj.Type() != json.ObjectJSONType {
+		return newImportRowError(
+			errors.Newf("expected a JSON object, found %s", obj.Type()), line, rowNum)
+	}
+
+	c.cols.reset(conv)
+	it, err := obj.ObjectIter()
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		idx, ok, err := c.cols.lookup(it.Key())
+		if err != nil {
+			return newImportRowError(err, line, rowNum)
+		}
+		if !ok {
+			continue
+		}
+		conv.Datums[idx], err = jsonToDatum(ctx, it.Value(), conv.VisibleColTypes[idx], conv.EvalCtx)
+		if err != nil {
+			col := conv.VisibleCols[idx]
+			return newImportRowError(
+				errors.Wrapf(err, "parse %q as %s", col.GetName(), col.GetType().SQLString()),
+				line, rowNum)
+		}
+	}
+	if err := c.cols.finish(conv); err != nil {
+		return newImportRowError(err, line, rowNum)
+	}
+	return nil
+}
+
+// jsonToDatum converts a JSON value to a datum of the target type. Any JSON
+// value, including nested objects and arrays, can be imported into a JSONB
+// column. Otherwise, JSON arrays are imported into array columns, and JSON
+// scalars are parsed as the target type from their text, just like the fields
+// of a CSV file.
+func jsonToDatum(
+	ctx context.Context, j json.JSON, targetT *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	if j.Type() == json.NullJSONType {
+		return tree.DNull, nil
+	}
+	if targetT.Family() == types.JsonFamily {
+		return tree.NewDJSON(j), nil
+	}
+
+	switch j.Type() {
+	case json.StringJSONType:
+		s, err := j.AsText()
+		if err != nil {
+			return nil, err
+		}
+		return rowenc.ParseDatumStringAs(ctx, targetT, *s, evalCtx)
+	case json.NumberJSONType, json.TrueJSONType, json.FalseJSONType:
+		return rowenc.ParseDatumStringAs(ctx, targetT, j.String(), evalCtx)
+	case json.ArrayJSONType:
+		if targetT.Family() != types.ArrayFamily {
+			break
+		}
+		elems, _ := j.AsArray()
+		arr := tree.NewDArray(targetT.ArrayContents())
+		for _, elem := range elems {
+			d, err := jsonToDatum(ctx, elem, targetT.ArrayContents(), evalCtx)
+			if err != nil {
+				return nil, err
+			}
+			if err := arr.Append(d); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, errors.Newf("cannot convert JSON %s to %s", j.Type(), targetT.SQLString())
+}
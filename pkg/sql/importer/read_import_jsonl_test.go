@@ -0,0 +1,151 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// readJSONLRows reads the JSONL data into the table, returning the datums of
+// each row, or the error of the first row that could not be converted.
+func readJSONLRows(
+	t *testing.T, create string, targetCols tree.NameList, opts roachpb.JSONLOptions, data string,
+) ([]tree.Datums, error) {
+	ctx := context.Background()
+	defer row.TestingSetDatumRowConverterBatchSize(100)()
+
+	tableDesc := descForTable(ctx, t, create, 100, 150, 200, NoFKs).
+		ImmutableCopy().(catalog.TableDescriptor)
+	semaCtx := tree.MakeSemaContext()
+	evalCtx := testEvalCtx.Copy()
+	reader := newJSONLInputReader(&semaCtx, nil, opts, 0, 1, tableDesc, targetCols, evalCtx,
+		nil /* seqChunkProvider */, nil /* db */)
+	producer, consumer := newJSONLPipeline(reader, &fileReader{Reader: strings.NewReader(data)})
+
+	conv, err := row.NewDatumRowConverter(ctx, &semaCtx, tableDesc, targetCols, evalCtx, nil,
+		nil /* seqChunkProvider */, nil /* metrics */, nil /* db */)
+	require.NoError(t, err)
+
+	var rows []tree.Datums
+	for rowNum := int64(1); producer.Scan(); rowNum++ {
+		r, err := producer.Row()
+		require.NoError(t, err)
+		if err := consumer.FillDatums(ctx, r, rowNum, conv); err != nil {
+			return rows, err
+		}
+		rows = append(rows, append(tree.Datums(nil), conv.Datums[:len(conv.VisibleCols)]...))
+	}
+	require.NoError(t, producer.Err())
+	return rows, nil
+}
+
+func TestReadsJSONL(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const create = `CREATE TABLE t (a INT8, b STRING, c JSONB, d INT8[], e TIMESTAMP)`
+	rows, err := readJSONLRows(t, create, nil, roachpb.JSONLOptions{}, `
+{"a": 1, "b": "one", "c": {"x": [1, 2]}, "d": [1, 2, 3], "e": "2023-01-02 03:04:05"}
+
+{"A": 2, "b": null, "c": "str", "unknown": true}
+`)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	require.Equal(t, "1", rows[0][0].String())
+	require.Equal(t, "'one'", rows[0][1].String())
+	require.Equal(t, `'{"x": [1, 2]}'`, rows[0][2].String())
+	require.Equal(t, "ARRAY[1,2,3]", rows[0][3].String())
+	require.Equal(t, "'2023-01-02 03:04:05'", rows[0][4].String())
+
+	// Field names are normalized like identifiers, missing fields are NULL, and
+	// unknown fields are ignored.
+	require.Equal(t, "2", rows[1][0].String())
+	require.Equal(t, tree.DNull, rows[1][1])
+	require.Equal(t, `'"str"'`, rows[1][2].String())
+	require.Equal(t, tree.DNull, rows[1][3])
+	require.Equal(t, tree.DNull, rows[1][4])
+}
+
+func TestReadsJSONLTargetColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const create = `CREATE TABLE t (a INT8, b STRING, c INT8)`
+	rows, err := readJSONLRows(t, create, tree.NameList{"c", "a"}, roachpb.JSONLOptions{StrictMode: true},
+		`{"a": 1, "c": 3}`)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "3", rows[0][0].String())
+	require.Equal(t, "1", rows[0][1].String())
+}
+
+func TestJSONLRowErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const create = `CREATE TABLE t (a INT8, b STRING)`
+	for _, tc := range []struct {
+		name   string
+		strict bool
+		data   string
+		err    string
+	}{
+		{name: "invalid JSON", data: `{"a": 1`, err: "parse JSON object"},
+		{name: "not an object", data: `[1, 2]`, err: "expected a JSON object, found array"},
+		{name: "invalid value", data: `{"a": "one"}`, err: `parse "a" as INT8`},
+		{name: "object for scalar", data: `{"b": {"x": 1}}`, err: "cannot convert JSON object to STRING"},
+		{name: "strict unknown field", strict: true, data: `{"a": 1, "b": "x", "c": 2}`,
+			err: "could not find column for record field c"},
+		{name: "strict missing field", strict: true, data: `{"a": 1}`,
+			err: "field b was not set in the record"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readJSONLRows(t, create, nil, roachpb.JSONLOptions{StrictMode: tc.strict}, tc.data)
+			require.ErrorContains(t, err, tc.err)
+			// Errors converting rows can be saved as rejected rows.
+			var rowErr *importRowError
+			require.True(t, errors.As(err, &rowErr))
+			require.Equal(t, tc.data, rowErr.row)
+		})
+	}
+}
+
+func TestJSONLMaxRowSize(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const create = `CREATE TABLE t (a INT8, b STRING)`
+	data := `{"a": 1, "b": "` + strings.Repeat("x", 100) + `"}`
+	_, err := readJSONLRows(t, create, nil, roachpb.JSONLOptions{MaxRowSize: 200}, data)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tableDesc := descForTable(ctx, t, create, 100, 150, 200, NoFKs).
+		ImmutableCopy().(catalog.TableDescriptor)
+	semaCtx := tree.MakeSemaContext()
+	reader := newJSONLInputReader(&semaCtx, nil, roachpb.JSONLOptions{MaxRowSize: 50}, 0, 1,
+		tableDesc, nil, testEvalCtx.Copy(), nil /* seqChunkProvider */, nil /* db */)
+	producer, _ := newJSONLPipeline(reader, &fileReader{Reader: strings.NewReader(data)})
+	require.False(t, producer.Scan())
+	require.ErrorContains(t, producer.Err(), "token too long")
+}
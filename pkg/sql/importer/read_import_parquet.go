@@ -0,0 +1,575 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// parquetInputReader reads Parquet files, mapping their top-level columns to
+// the columns of the table by name.
+type parquetInputReader struct {
+	importCtx *parallelImportContext
+	opts      roachpb.ParquetOptions
+}
+
+var _ inputConverter = &parquetInputReader{}
+
+func newParquetInputReader(
+	semaCtx *tree.SemaContext,
+	kvCh chan row.KVBatch,
+	opts roachpb.ParquetOptions,
+	walltime int64,
+	parallelism int,
+	tableDesc catalog.TableDescriptor,
+	targetCols tree.NameList,
+	evalCtx *eval.Context,
+	seqChunkProvider *row.SeqChunkProvider,
+	db *kv.DB,
+) *parquetInputReader {
+	return &parquetInputReader{
+		importCtx: &parallelImportContext{
+			semaCtx:          semaCtx,
+			walltime:         walltime,
+			numWorkers:       parallelism,
+			evalCtx:          evalCtx,
+			tableDesc:        tableDesc,
+			targetCols:       targetCols,
+			kvCh:             kvCh,
+			seqChunkProvider: seqChunkProvider,
+			db:               db,
+		},
+		opts: opts,
+	}
+}
+
+func (p *parquetInputReader) start(group ctxgroup.Group) {}
+
+func (p *parquetInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	resumePos map[int32]int64,
+	format roachpb.IOFileFormat,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+) error {
+	return readInputFiles(ctx, dataFiles, resumePos, format, p.readFile, makeExternalStorage, user)
+}
+
+func (p *parquetInputReader) readFile(
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+) error {
+	producer, consumer, err := newParquetPipeline(p, input)
+	if err != nil {
+		return err
+	}
+	fileCtx := &importFileContext{
+		source:   inputIdx,
+		skip:     resumePos,
+		rejected: rejected,
+		rowLimit: p.opts.RowLimit,
+	}
+	return runParallelImport(ctx, p.importCtx, fileCtx, producer, consumer)
+}
+
+func newParquetPipeline(
+	p *parquetInputReader, input io.Reader,
+) (*parquetRowProducer, *parquetRowConsumer, error) {
+	// The footer of a Parquet file describes where its column chunks are, so
+	// the reader needs to seek, which the import input streams do not support.
+	// The file is buffered in memory instead.
+	buf, err := io.ReadAll(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := goparquet.NewFileReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading parquet file")
+	}
+
+	columns := make(map[string]*parquetschema.ColumnDefinition)
+	if root := reader.GetSchemaDefinition().RootColumn; root != nil {
+		for _, col := range root.Children {
+			columns[col.SchemaElement.Name] = col
+		}
+	}
+	producer := &parquetRowProducer{
+		reader:  reader,
+		numRows: reader.NumRows(),
+	}
+	consumer := &parquetRowConsumer{
+		cols:    makeNamedColumns(p.importCtx, p.opts.StrictMode),
+		columns: columns,
+	}
+	return producer, consumer, nil
+}
+
+// parquetRowProducer produces the rows of a Parquet file, as maps from the
+// names of its top-level columns to their values.
+type parquetRowProducer struct {
+	reader   *goparquet.FileReader
+	numRows  int64
+	rowsRead int64
+	row      map[string]interface{}
+	err      error
+}
+
+var _ importRowProducer = &parquetRowProducer{}
+
+// Scan implements the importRowProducer interface.
+func (p *parquetRowProducer) Scan() bool {
+	p.row, p.err = p.reader.NextRow()
+	if p.err == io.EOF {
+		p.err = nil
+		return false
+	}
+	if p.err != nil {
+		return false
+	}
+	p.rowsRead++
+	return true
+}
+
+// Err implements the importRowProducer interface.
+func (p *parquetRowProducer) Err() error {
+	return p.err
+}
+
+// Skip implements the importRowProducer interface.
+func (p *parquetRowProducer) Skip() error {
+	return nil
+}
+
+// Row implements the importRowProducer interface.
+func (p *parquetRowProducer) Row() (interface{}, error) {
+	return p.row, nil
+}
+
+// Progress implements the importRowProducer interface.
+func (p *parquetRowProducer) Progress() float32 {
+	if p.numRows <= 0 {
+		return 0
+	}
+	return float32(p.rowsRead) / float32(p.numRows)
+}
+
+// parquetRowConsumer converts the values of a Parquet row to the datums of a
+// row.
+type parquetRowConsumer struct {
+	cols namedColumns
+	// columns are the definitions of the top-level columns of the file.
+	columns map[string]*parquetschema.ColumnDefinition
+}
+
+var _ importRowConsumer = &parquetRowConsumer{}
+
+// FillDatums implements the importRowConsumer interface.
+func (c *parquetRowConsumer) FillDatums(
+	ctx context.Context, row interface{}, rowNum int64, conv *row.DatumRowConverter,
+) error {
+	record := row.(map[string]interface{})
+	c.cols.reset(conv)
+	for field, v := range record {
+		idx, ok, err := c.cols.lookup(field)
+		if err != nil {
+			return newImportRowError(err, parquetRowString(record), rowNum)
+		}
+		if !ok {
+			continue
+		}
+		conv.Datums[idx], err = parquetToDatum(ctx, v, c.columns[field], conv.VisibleColTypes[idx], conv.EvalCtx)
+		if err != nil {
+			col := conv.VisibleCols[idx]
+			return newImportRowError(
+				errors.Wrapf(err, "parse %q as %s", col.GetName(), col.GetType().SQLString()),
+				parquetRowString(record), rowNum)
+		}
+	}
+	if err := c.cols.finish(conv); err != nil {
+		return newImportRowError(err, parquetRowString(record), rowNum)
+	}
+	return nil
+}
+
+// parquetRowString renders a Parquet row as a JSON object for error messages
+// and rejected rows.
+func parquetRowString(record map[string]interface{}) string {
+	native := make(map[string]interface{}, len(record))
+	for k, v := range record {
+		native[k] = parquetToNative(v, nil /* col */)
+	}
+	b, err := gojson.Marshal(native)
+	if err != nil {
+		return fmt.Sprintf("%v", record)
+	}
+	return string(b)
+}
+
+// parquetToDatum converts the value of a Parquet column to a datum of the
+// target type. Any value, including nested groups and lists, can be imported
+// into a JSONB column. Otherwise, lists are imported into array columns, and
+// values of the primitive Parquet types are converted according to the logical
+// type of the column, falling back to parsing the target type from their text.
+func parquetToDatum(
+	ctx context.Context,
+	v interface{},
+	col *parquetschema.ColumnDefinition,
+	targetT *types.T,
+	evalCtx *eval.Context,
+) (tree.Datum, error) {
+	if v == nil {
+		return tree.DNull, nil
+	}
+	var el *parquet.SchemaElement
+	if col != nil {
+		el = col.SchemaElement
+	}
+
+	if targetT.Family() == types.JsonFamily {
+		if b, ok := v.([]byte); ok && parquetIsString(el) {
+			// Strings hold JSON documents in files written by EXPORT, or by any
+			// writer which annotates the column as JSON.
+			if d, err := tree.ParseDJSON(string(b)); err == nil || parquetIsJSON(el) {
+				return d, err
+			}
+		}
+		j, err := json.MakeJSON(parquetToNative(v, col))
+		if err != nil {
+			return nil, err
+		}
+		return tree.NewDJSON(j), nil
+	}
+
+	if elems, ok := parquetListElements(v, col); ok {
+		if targetT.Family() != types.ArrayFamily {
+			return nil, errors.Newf("cannot convert parquet list to %s", targetT.SQLString())
+		}
+		var elemCol *parquetschema.ColumnDefinition
+		if col != nil && len(col.Children) == 1 && len(col.Children[0].Children) == 1 {
+			elemCol = col.Children[0].Children[0]
+		}
+		arr := tree.NewDArray(targetT.ArrayContents())
+		for _, elem := range elems {
+			d, err := parquetToDatum(ctx, elem, elemCol, targetT.ArrayContents(), evalCtx)
+			if err != nil {
+				return nil, err
+			}
+			if err := arr.Append(d); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+
+	switch v := v.(type) {
+	case bool:
+		if targetT.Family() == types.BoolFamily {
+			return tree.MakeDBool(tree.DBool(v)), nil
+		}
+		return rowenc.ParseDatumStringAs(ctx, targetT, strconv.FormatBool(v), evalCtx)
+	case int32:
+		return parquetIntToDatum(ctx, int64(v), el, targetT, evalCtx)
+	case int64:
+		return parquetIntToDatum(ctx, v, el, targetT, evalCtx)
+	case float32:
+		if targetT.Family() == types.FloatFamily {
+			return tree.NewDFloat(tree.DFloat(v)), nil
+		}
+		return rowenc.ParseDatumStringAs(ctx, targetT, strconv.FormatFloat(float64(v), 'g', -1, 32), evalCtx)
+	case float64:
+		if targetT.Family() == types.FloatFamily {
+			return tree.NewDFloat(tree.DFloat(v)), nil
+		}
+		return rowenc.ParseDatumStringAs(ctx, targetT, strconv.FormatFloat(v, 'g', -1, 64), evalCtx)
+	case [12]byte:
+		// INT96 timestamps, as written by Impala and Spark, hold the nanoseconds
+		// within the day followed by the Julian day.
+		nanos := int64(binary.LittleEndian.Uint64(v[:8]))
+		days := int64(binary.LittleEndian.Uint32(v[8:])) - julianDayOfUnixEpoch
+		return parquetTimeToDatum(ctx, time.Unix(days*secondsPerDay, nanos).UTC(), targetT, evalCtx)
+	case []byte:
+		return parquetBytesToDatum(ctx, v, el, targetT, evalCtx)
+	case map[string]interface{}:
+		return nil, errors.Newf("cannot convert parquet group to %s", targetT.SQLString())
+	}
+	return nil, errors.Newf("cannot convert parquet value of type %T to %s", v, targetT.SQLString())
+}
+
+const (
+	julianDayOfUnixEpoch = 2440588
+	secondsPerDay        = 24 * 60 * 60
+)
+
+func parquetIntToDatum(
+	ctx context.Context, v int64, el *parquet.SchemaElement, targetT *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	switch {
+	case parquetHasConvertedType(el, parquet.ConvertedType_DATE) ||
+		(el != nil && el.LogicalType != nil && el.LogicalType.DATE != nil):
+		d, err := pgdate.MakeDateFromUnixEpoch(v)
+		if err != nil {
+			return nil, err
+		}
+		if targetT.Family() == types.DateFamily {
+			return tree.NewDDate(d), nil
+		}
+		return rowenc.ParseDatumStringAs(ctx, targetT, d.String(), evalCtx)
+	case parquetHasConvertedType(el, parquet.ConvertedType_TIMESTAMP_MILLIS):
+		return parquetTimeToDatum(ctx, time.UnixMilli(v).UTC(), targetT, evalCtx)
+	case parquetHasConvertedType(el, parquet.ConvertedType_TIMESTAMP_MICROS):
+		return parquetTimeToDatum(ctx, time.UnixMicro(v).UTC(), targetT, evalCtx)
+	case el != nil && el.LogicalType != nil && el.LogicalType.TIMESTAMP != nil:
+		return parquetTimeToDatum(ctx, parquetUnitTime(v, el.LogicalType.TIMESTAMP.Unit), targetT, evalCtx)
+	case parquetHasConvertedType(el, parquet.ConvertedType_TIME_MILLIS):
+		return parquetTimeOfDayToDatum(ctx, v*1000, targetT, evalCtx)
+	case parquetHasConvertedType(el, parquet.ConvertedType_TIME_MICROS):
+		return parquetTimeOfDayToDatum(ctx, v, targetT, evalCtx)
+	case el != nil && el.LogicalType != nil && el.LogicalType.TIME != nil:
+		micros := parquetUnitTime(v, el.LogicalType.TIME.Unit).Sub(time.Unix(0, 0)).Microseconds()
+		return parquetTimeOfDayToDatum(ctx, micros, targetT, evalCtx)
+	case parquetDecimalScale(el) != 0:
+		return rowenc.ParseDatumStringAs(ctx, targetT,
+			fmt.Sprintf("%dE-%d", v, parquetDecimalScale(el)), evalCtx)
+	}
+	if targetT.Family() == types.IntFamily {
+		return tree.NewDInt(tree.DInt(v)), nil
+	}
+	return rowenc.ParseDatumStringAs(ctx, targetT, strconv.FormatInt(v, 10), evalCtx)
+}
+
+// parquetUnitTime returns the time that is v units of the TIMESTAMP or TIME
+// logical types after the Unix epoch.
+func parquetUnitTime(v int64, unit *parquet.TimeUnit) time.Time {
+	switch {
+	case unit != nil && unit.MILLIS != nil:
+		return time.UnixMilli(v).UTC()
+	case unit != nil && unit.NANOS != nil:
+		return time.Unix(0, v).UTC()
+	default:
+		return time.UnixMicro(v).UTC()
+	}
+}
+
+func parquetTimeToDatum(
+	ctx context.Context, t time.Time, targetT *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	round := tree.TimeFamilyPrecisionToRoundDuration(targetT.Precision())
+	switch targetT.Family() {
+	case types.TimestampFamily:
+		return tree.MakeDTimestamp(t, round)
+	case types.TimestampTZFamily:
+		return tree.MakeDTimestampTZ(t, round)
+	case types.DateFamily:
+		return tree.NewDDateFromTime(t)
+	}
+	return rowenc.ParseDatumStringAs(ctx, targetT, t.Format(time.RFC3339Nano), evalCtx)
+}
+
+func parquetTimeOfDayToDatum(
+	ctx context.Context, micros int64, targetT *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	tod := timeofday.TimeOfDay(micros)
+	if targetT.Family() == types.TimeFamily {
+		return tree.MakeDTime(tod.Round(tree.TimeFamilyPrecisionToRoundDuration(targetT.Precision()))), nil
+	}
+	return rowenc.ParseDatumStringAs(ctx, targetT, tod.String(), evalCtx)
+}
+
+func parquetBytesToDatum(
+	ctx context.Context, v []byte, el *parquet.SchemaElement, targetT *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	if scale := parquetDecimalScale(el); scale != 0 || parquetHasConvertedType(el, parquet.ConvertedType_DECIMAL) {
+		// EXPORT writes decimals as their text; other writers use the big-endian
+		// two's complement of the unscaled value.
+		if el.GetType() == parquet.Type_BYTE_ARRAY {
+			if _, err := tree.ParseDDecimal(string(v)); err == nil {
+				return rowenc.ParseDatumStringAs(ctx, targetT, string(v), evalCtx)
+			}
+		}
+		unscaled := new(big.Int).SetBytes(v)
+		if len(v) > 0 && v[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(v)*8)))
+		}
+		return rowenc.ParseDatumStringAs(ctx, targetT, fmt.Sprintf("%sE-%d", unscaled, scale), evalCtx)
+	}
+	if el != nil && el.LogicalType != nil && el.LogicalType.UUID != nil && len(v) == uuid.Size {
+		u, err := uuid.FromBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if targetT.Family() == types.UuidFamily {
+			return tree.NewDUuid(tree.DUuid{UUID: u}), nil
+		}
+		return rowenc.ParseDatumStringAs(ctx, targetT, u.String(), evalCtx)
+	}
+	if targetT.Family() == types.BytesFamily && !parquetIsString(el) {
+		return tree.NewDBytes(tree.DBytes(v)), nil
+	}
+	return rowenc.ParseDatumStringAs(ctx, targetT, string(v), evalCtx)
+}
+
+func parquetHasConvertedType(el *parquet.SchemaElement, typ parquet.ConvertedType) bool {
+	return el != nil && el.ConvertedType != nil && *el.ConvertedType == typ
+}
+
+// parquetIsString returns whether the byte array column holds text.
+func parquetIsString(el *parquet.SchemaElement) bool {
+	if el == nil {
+		return true
+	}
+	if el.LogicalType != nil &&
+		(el.LogicalType.STRING != nil || el.LogicalType.JSON != nil || el.LogicalType.ENUM != nil) {
+		return true
+	}
+	return parquetHasConvertedType(el, parquet.ConvertedType_UTF8) ||
+		parquetHasConvertedType(el, parquet.ConvertedType_JSON) ||
+		parquetHasConvertedType(el, parquet.ConvertedType_ENUM)
+}
+
+func parquetIsJSON(el *parquet.SchemaElement) bool {
+	return (el != nil && el.LogicalType != nil && el.LogicalType.JSON != nil) ||
+		parquetHasConvertedType(el, parquet.ConvertedType_JSON)
+}
+
+func parquetDecimalScale(el *parquet.SchemaElement) int32 {
+	switch {
+	case el == nil:
+		return 0
+	case el.LogicalType != nil && el.LogicalType.DECIMAL != nil:
+		return el.LogicalType.DECIMAL.Scale
+	case parquetHasConvertedType(el, parquet.ConvertedType_DECIMAL) && el.Scale != nil:
+		return *el.Scale
+	}
+	return 0
+}
+
+// parquetListElements returns the elements of a value of a column with the LIST
+// logical type. Lists are read as a group holding a repeated "list" group, each
+// of which holds an "element", and an empty list is read as a single group
+// without an element.
+func parquetListElements(v interface{}, col *parquetschema.ColumnDefinition) ([]interface{}, bool) {
+	group, ok := v.(map[string]interface{})
+	if !ok || len(group) != 1 {
+		return nil, false
+	}
+	if col != nil && col.SchemaElement != nil && !parquetHasConvertedType(col.SchemaElement, parquet.ConvertedType_LIST) &&
+		(col.SchemaElement.LogicalType == nil || col.SchemaElement.LogicalType.LIST == nil) {
+		return nil, false
+	}
+	var elems []interface{}
+	switch list := group["list"].(type) {
+	case []map[string]interface{}:
+		for _, m := range list {
+			if elem, ok := m["element"]; ok {
+				elems = append(elems, elem)
+			}
+		}
+	case []interface{}:
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				if elem, ok := m["element"]; ok {
+					elems = append(elems, elem)
+				}
+			}
+		}
+	default:
+		return nil, false
+	}
+	return elems, true
+}
+
+// parquetToNative converts a Parquet value to the values that json.MakeJSON
+// accepts, unwrapping lists and converting byte arrays to strings.
+func parquetToNative(v interface{}, col *parquetschema.ColumnDefinition) interface{} {
+	childCol := func(name string) *parquetschema.ColumnDefinition {
+		if col == nil {
+			return nil
+		}
+		for _, child := range col.Children {
+			if child.SchemaElement != nil && child.SchemaElement.Name == name {
+				return child
+			}
+		}
+		return nil
+	}
+
+	if elems, ok := parquetListElements(v, col); ok {
+		var elemCol *parquetschema.ColumnDefinition
+		if col != nil && len(col.Children) == 1 && len(col.Children[0].Children) == 1 {
+			elemCol = col.Children[0].Children[0]
+		}
+		res := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			res[i] = parquetToNative(elem, elemCol)
+		}
+		return res
+	}
+
+	switch v := v.(type) {
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	case [12]byte:
+		nanos := int64(binary.LittleEndian.Uint64(v[:8]))
+		days := int64(binary.LittleEndian.Uint32(v[8:])) - julianDayOfUnixEpoch
+		return time.Unix(days*secondsPerDay, nanos).UTC().Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			res[k] = parquetToNative(child, childCol(k))
+		}
+		return res
+	case []map[string]interface{}:
+		res := make([]interface{}, len(v))
+		for i, child := range v {
+			res[i] = parquetToNative(child, col)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, child := range v {
+			res[i] = parquetToNative(child, col)
+		}
+		return res
+	}
+	return v
+}
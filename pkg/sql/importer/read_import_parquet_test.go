@@ -0,0 +1,225 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	crlparquet "github.com/cockroachdb/cockroach/pkg/util/parquet"
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+// readParquetRows reads the Parquet file into the table, returning the datums
+// of each row, or the error of the first row that could not be converted.
+func readParquetRows(
+	t *testing.T, create string, opts roachpb.ParquetOptions, data []byte,
+) ([]tree.Datums, error) {
+	ctx := context.Background()
+	defer row.TestingSetDatumRowConverterBatchSize(100)()
+
+	tableDesc := descForTable(ctx, t, create, 100, 150, 200, NoFKs).
+		ImmutableCopy().(catalog.TableDescriptor)
+	semaCtx := tree.MakeSemaContext()
+	evalCtx := testEvalCtx.Copy()
+	reader := newParquetInputReader(&semaCtx, nil, opts, 0, 1, tableDesc, nil, evalCtx,
+		nil /* seqChunkProvider */, nil /* db */)
+	producer, consumer, err := newParquetPipeline(reader, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	conv, err := row.NewDatumRowConverter(ctx, &semaCtx, tableDesc, nil, evalCtx, nil,
+		nil /* seqChunkProvider */, nil /* metrics */, nil /* db */)
+	require.NoError(t, err)
+
+	var rows []tree.Datums
+	for rowNum := int64(1); producer.Scan(); rowNum++ {
+		r, err := producer.Row()
+		require.NoError(t, err)
+		if err := consumer.FillDatums(ctx, r, rowNum, conv); err != nil {
+			return rows, err
+		}
+		rows = append(rows, append(tree.Datums(nil), conv.Datums[:len(conv.VisibleCols)]...))
+	}
+	require.NoError(t, producer.Err())
+	require.Equal(t, float32(1), producer.Progress())
+	return rows, nil
+}
+
+// writeParquet writes the rows with the writer used by EXPORT.
+func writeParquet(t *testing.T, names []string, typs []*types.T, rows []tree.Datums) []byte {
+	sch, err := crlparquet.NewSchema(names, typs)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	w, err := crlparquet.NewWriter(sch, &buf)
+	require.NoError(t, err)
+	for _, r := range rows {
+		require.NoError(t, w.AddRow(r))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestReadsExportedParquet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	names := []string{"i", "s", "d", "ts", "tstz", "dt", "tm", "j", "arr", "u", "b", "f"}
+	typs := []*types.T{
+		types.Int, types.String, types.Decimal, types.Timestamp, types.TimestampTZ, types.Date,
+		types.Time, types.Jsonb, types.IntArray, types.Uuid, types.Bytes, types.Float,
+	}
+	const create = `CREATE TABLE t (
+	i INT8, s STRING, d DECIMAL, ts TIMESTAMP, tstz TIMESTAMPTZ, dt DATE,
+	tm TIME, j JSONB, arr INT8[], u UUID, b BYTES, f FLOAT8
+)`
+
+	rng, _ := randgen.NewTestRand()
+	var rows []tree.Datums
+	for i := 0; i < 20; i++ {
+		r := make(tree.Datums, len(typs))
+		for j, typ := range typs {
+			r[j] = randgen.RandDatum(rng, typ, true /* nullOk */)
+		}
+		rows = append(rows, r)
+	}
+	// Include an empty array, which is written differently from other arrays.
+	emptyArr := make(tree.Datums, len(typs))
+	for j, typ := range typs {
+		emptyArr[j] = randgen.RandDatum(rng, typ, true /* nullOk */)
+	}
+	emptyArr[8] = tree.NewDArray(types.Int)
+	rows = append(rows, emptyArr)
+
+	got, err := readParquetRows(t, create, roachpb.ParquetOptions{}, writeParquet(t, names, typs, rows))
+	require.NoError(t, err)
+	require.Len(t, got, len(rows))
+	for i := range rows {
+		for j := range rows[i] {
+			cmp, err := rows[i][j].CompareError(testEvalCtx, got[i][j])
+			require.NoError(t, err)
+			require.Zerof(t, cmp, "row %d column %s: expected %s, found %s", i, names[j], rows[i][j], got[i][j])
+		}
+	}
+}
+
+func TestParquetRelaxedAndStrictImport(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	data := writeParquet(t, []string{"a", "c"}, []*types.T{types.Int, types.Int},
+		[]tree.Datums{{tree.NewDInt(1), tree.NewDInt(3)}})
+
+	got, err := readParquetRows(t, `CREATE TABLE t (a INT8, b INT8)`, roachpb.ParquetOptions{}, data)
+	require.NoError(t, err)
+	require.Equal(t, []tree.Datums{{tree.NewDInt(1), tree.DNull}}, got)
+
+	_, err = readParquetRows(t, `CREATE TABLE t (a INT8, b INT8)`,
+		roachpb.ParquetOptions{StrictMode: true}, data)
+	require.ErrorContains(t, err, "could not find column for record field c")
+
+	_, err = readParquetRows(t, `CREATE TABLE t (a INT8, b INT8, c INT8)`,
+		roachpb.ParquetOptions{StrictMode: true}, data)
+	require.NoError(t, err)
+
+	_, err = readParquetRows(t, `CREATE TABLE t (a INT8, c DATE)`, roachpb.ParquetOptions{}, data)
+	require.ErrorContains(t, err, `parse "c" as DATE`)
+	require.ErrorContains(t, err, `(row: {"a":1,"c":3})`)
+}
+
+func TestParquetToDatum(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	column := func(typ parquet.Type, configure func(el *parquet.SchemaElement)) *parquetschema.ColumnDefinition {
+		el := &parquet.SchemaElement{Name: "c", Type: &typ}
+		configure(el)
+		return &parquetschema.ColumnDefinition{SchemaElement: el}
+	}
+	decimal := func(scale int32) func(el *parquet.SchemaElement) {
+		return func(el *parquet.SchemaElement) {
+			el.LogicalType = &parquet.LogicalType{DECIMAL: &parquet.DecimalType{Scale: scale, Precision: 10}}
+		}
+	}
+	timestamp := func(unit *parquet.TimeUnit) func(el *parquet.SchemaElement) {
+		return func(el *parquet.SchemaElement) {
+			el.LogicalType = &parquet.LogicalType{TIMESTAMP: &parquet.TimestampType{IsAdjustedToUTC: true, Unit: unit}}
+		}
+	}
+	none := func(el *parquet.SchemaElement) {}
+
+	ts := time.Date(2023, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	var int96 [12]byte
+	midnight := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	binary.LittleEndian.PutUint64(int96[:8], uint64(ts.Sub(midnight)))
+	binary.LittleEndian.PutUint32(int96[8:], uint32(midnight.Unix()/secondsPerDay+julianDayOfUnixEpoch))
+
+	for _, tc := range []struct {
+		name     string
+		v        interface{}
+		col      *parquetschema.ColumnDefinition
+		typ      *types.T
+		expected string
+	}{
+		{name: "int32", v: int32(7), col: column(parquet.Type_INT32, none), typ: types.Int, expected: "7"},
+		{name: "int as string", v: int64(7), col: column(parquet.Type_INT64, none), typ: types.String,
+			expected: "'7'"},
+		{name: "float32", v: float32(1.5), col: column(parquet.Type_FLOAT, none), typ: types.Float,
+			expected: "1.5"},
+		{name: "decimal int32", v: int32(-12345), col: column(parquet.Type_INT32, decimal(2)),
+			typ: types.Decimal, expected: "-123.45"},
+		{name: "decimal twos complement", v: []byte{0xcf, 0xc7}, col: column(parquet.Type_BYTE_ARRAY, decimal(2)),
+			typ: types.Decimal, expected: "-123.45"},
+		{name: "decimal text", v: []byte("-123.45"), col: column(parquet.Type_BYTE_ARRAY, decimal(2)),
+			typ: types.Decimal, expected: "-123.45"},
+		{name: "date", v: int32(19359), col: column(parquet.Type_INT32, func(el *parquet.SchemaElement) {
+			el.LogicalType = &parquet.LogicalType{DATE: &parquet.DateType{}}
+		}), typ: types.Date, expected: "'2023-01-02'"},
+		{name: "timestamp micros", v: ts.UnixMicro(),
+			col: column(parquet.Type_INT64, timestamp(&parquet.TimeUnit{MICROS: &parquet.MicroSeconds{}})),
+			typ: types.Timestamp, expected: "'2023-01-02 03:04:05.123456'"},
+		{name: "timestamp millis", v: ts.UnixMilli(),
+			col: column(parquet.Type_INT64, timestamp(&parquet.TimeUnit{MILLIS: &parquet.MilliSeconds{}})),
+			typ: types.TimestampTZ, expected: "'2023-01-02 03:04:05.123+00'"},
+		{name: "timestamp int96", v: int96, col: column(parquet.Type_INT96, none), typ: types.Timestamp,
+			expected: "'2023-01-02 03:04:05.123456'"},
+		{name: "time micros", v: int64(3723000001), col: column(parquet.Type_INT64, func(el *parquet.SchemaElement) {
+			el.LogicalType = &parquet.LogicalType{TIME: &parquet.TimeType{Unit: &parquet.TimeUnit{MICROS: &parquet.MicroSeconds{}}}}
+		}), typ: types.Time, expected: "'01:02:03.000001'"},
+		{name: "bytes", v: []byte{0xff}, col: column(parquet.Type_BYTE_ARRAY, none), typ: types.Bytes,
+			expected: `'\xff'`},
+		{name: "group as json", v: map[string]interface{}{"x": int32(1), "y": []byte("s")}, typ: types.Jsonb,
+			expected: `'{"x": 1, "y": "s"}'`},
+		{name: "null", v: nil, col: column(parquet.Type_INT64, none), typ: types.Int, expected: "NULL"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := parquetToDatum(ctx, tc.v, tc.col, tc.typ, testEvalCtx)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, d.String())
+		})
+	}
+
+	_, err := parquetToDatum(ctx, map[string]interface{}{"x": int32(1)}, nil, types.Int, testEvalCtx)
+	require.ErrorContains(t, err, "cannot convert parquet group to INT8")
+}
@@ -403,12 +403,17 @@ func init() {
 		&tree.AlterTenantReplication{},
 		&tree.Backup{},
 		&tree.ShowBackup{},
+		&tree.ShowBackupDiff{},
 		&tree.Restore{},
+		&tree.RestoreRows{},
+		&tree.VerifyBackup{},
 		&tree.CreateChangefeed{},
 		&tree.ScheduledChangefeed{},
 		&tree.Import{},
 		&tree.ScheduledBackup{},
 		&tree.CreateTenantFromReplication{},
+		&tree.CreateLogicalReplicationStream{},
+		&tree.CreateSubscription{},
 	} {
 		typ := optbuilder.OpaqueReadOnly
 		if tree.CanModifySchema(stmt) {
@@ -11,10 +11,15 @@
 package rowenc
 
 import (
+	"bytes"
+
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc/keyside"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treecmp"
 	"github.com/cockroachdb/cockroach/pkg/util/buildutil"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/errors"
@@ -107,3 +112,118 @@ func InitIndexFetchSpec(
 
 	return nil
 }
+
+// ColumnBoundsFromFilter returns the bounds on the values of the fetched
+// columns of the spec which are implied by the given filter. The indexed vars
+// in the filter must refer to the fetched columns. Only the conjuncts which
+// compare a fetched column to constants of an equivalent type are taken into
+// account; strict inequalities result in inclusive bounds.
+func ColumnBoundsFromFilter(
+	s *fetchpb.IndexFetchSpec, filter tree.TypedExpr,
+) []fetchpb.IndexFetchSpec_ColumnBound {
+	var bounds []fetchpb.IndexFetchSpec_ColumnBound
+	// intersect narrows down the bound of the column with the given ordinal in
+	// the fetched columns.
+	intersect := func(colOrdinal int, start, end []byte) {
+		colID := s.FetchedColumns[colOrdinal].ColumnID
+		for i := range bounds {
+			if b := &bounds[i]; b.ColumnID == colID {
+				if len(b.Start) == 0 || bytes.Compare(start, b.Start) > 0 {
+					b.Start = start
+				}
+				if len(b.End) == 0 || (len(end) > 0 && bytes.Compare(end, b.End) < 0) {
+					b.End = end
+				}
+				return
+			}
+		}
+		bounds = append(bounds, fetchpb.IndexFetchSpec_ColumnBound{
+			ColumnID: colID, Start: start, End: end,
+		})
+	}
+	// encode returns the ascending key encoding of the datum, if it is a
+	// non-NULL constant that can be compared to the values of the column with
+	// the given ordinal.
+	encode := func(colOrdinal int, d tree.Datum) []byte {
+		if d == tree.DNull || !d.ResolvedType().Equivalent(s.FetchedColumns[colOrdinal].Type) {
+			return nil
+		}
+		key, err := keyside.Encode(nil, d, encoding.Ascending)
+		if err != nil {
+			return nil
+		}
+		return key
+	}
+	var visit func(expr tree.Expr)
+	visit = func(expr tree.Expr) {
+		switch t := expr.(type) {
+		case *tree.AndExpr:
+			visit(t.Left)
+			visit(t.Right)
+		case *tree.ComparisonExpr:
+			op := t.Operator.Symbol
+			v, ok := t.Left.(*tree.IndexedVar)
+			d, isConst := t.Right.(tree.Datum)
+			if !ok || !isConst {
+				// Try the commuted comparison.
+				v, ok = t.Right.(*tree.IndexedVar)
+				d, isConst = t.Left.(tree.Datum)
+				switch op {
+				case treecmp.LT:
+					op = treecmp.GT
+				case treecmp.LE:
+					op = treecmp.GE
+				case treecmp.GT:
+					op = treecmp.LT
+				case treecmp.GE:
+					op = treecmp.LE
+				case treecmp.In:
+					return
+				}
+			}
+			if !ok || !isConst || v.Idx >= len(s.FetchedColumns) {
+				return
+			}
+			switch op {
+			case treecmp.EQ:
+				if key := encode(v.Idx, d); key != nil {
+					intersect(v.Idx, key, key)
+				}
+			case treecmp.LT, treecmp.LE:
+				if key := encode(v.Idx, d); key != nil {
+					intersect(v.Idx, nil /* start */, key)
+				}
+			case treecmp.GT, treecmp.GE:
+				if key := encode(v.Idx, d); key != nil {
+					intersect(v.Idx, key, nil /* end */)
+				}
+			case treecmp.In:
+				tuple, ok := d.(*tree.DTuple)
+				if !ok {
+					return
+				}
+				var min, max []byte
+				for _, elem := range tuple.D {
+					if elem == tree.DNull {
+						continue
+					}
+					key := encode(v.Idx, elem)
+					if key == nil {
+						return
+					}
+					if min == nil || bytes.Compare(key, min) < 0 {
+						min = key
+					}
+					if max == nil || bytes.Compare(key, max) > 0 {
+						max = key
+					}
+				}
+				if min != nil {
+					intersect(v.Idx, min, max)
+				}
+			}
+		}
+	}
+	visit(filter)
+	return bounds
+}
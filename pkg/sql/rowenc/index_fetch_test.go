@@ -22,10 +22,16 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/desctestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc/keyside"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treecmp"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils/datapathutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/datadriven"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
 )
 
@@ -90,3 +96,62 @@ func TestInitIndexFetchSpec(t *testing.T) {
 		},
 	)
 }
+
+func TestColumnBoundsFromFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	spec := fetchpb.IndexFetchSpec{
+		FetchedColumns: []fetchpb.IndexFetchSpec_Column{
+			{ColumnID: 1, Name: "a", Type: types.Int},
+			{ColumnID: 2, Name: "b", Type: types.String},
+			{ColumnID: 3, Name: "c", Type: types.Float},
+		},
+	}
+	encode := func(d tree.Datum) []byte {
+		key, err := keyside.Encode(nil, d, encoding.Ascending)
+		require.NoError(t, err)
+		return key
+	}
+	cmp := func(op treecmp.ComparisonOperatorSymbol, left, right tree.Expr) tree.Expr {
+		return &tree.ComparisonExpr{Operator: treecmp.MakeComparisonOperator(op), Left: left, Right: right}
+	}
+	and := func(exprs ...tree.Expr) tree.TypedExpr {
+		res := exprs[0]
+		for _, expr := range exprs[1:] {
+			res = &tree.AndExpr{Left: res, Right: expr}
+		}
+		return res.(tree.TypedExpr)
+	}
+	a, b, c := tree.NewOrdinalReference(0), tree.NewOrdinalReference(1), tree.NewOrdinalReference(2)
+
+	// a > 1 AND a <= 10 AND 3 < a AND 'x' = b AND c < 5 AND c IN (1.5, NULL, -2.5)
+	filter := and(
+		cmp(treecmp.GT, a, tree.NewDInt(1)),
+		cmp(treecmp.LE, a, tree.NewDInt(10)),
+		cmp(treecmp.LT, tree.NewDInt(3), a),
+		cmp(treecmp.EQ, tree.NewDString("x"), b),
+		// The constant isn't of an equivalent type, so the conjunct is ignored.
+		cmp(treecmp.LT, c, tree.NewDInt(5)),
+		cmp(treecmp.In, c, tree.NewDTuple(
+			types.MakeTuple([]*types.T{types.Float, types.Unknown, types.Float}),
+			tree.NewDFloat(1.5), tree.DNull, tree.NewDFloat(-2.5),
+		)),
+	)
+	require.Equal(t, []fetchpb.IndexFetchSpec_ColumnBound{
+		{ColumnID: 1, Start: encode(tree.NewDInt(3)), End: encode(tree.NewDInt(10))},
+		{ColumnID: 2, Start: encode(tree.NewDString("x")), End: encode(tree.NewDString("x"))},
+		{ColumnID: 3, Start: encode(tree.NewDFloat(-2.5)), End: encode(tree.NewDFloat(1.5))},
+	}, rowenc.ColumnBoundsFromFilter(&spec, filter))
+
+	// Disjunctions and comparisons between columns don't imply bounds.
+	filter = and(
+		&tree.OrExpr{Left: cmp(treecmp.EQ, a, tree.NewDInt(1)), Right: cmp(treecmp.EQ, a, tree.NewDInt(2))},
+		cmp(treecmp.LT, a, c),
+	)
+	require.Nil(t, rowenc.ColumnBoundsFromFilter(&spec, filter))
+
+	bound := fetchpb.IndexFetchSpec_ColumnBound{ColumnID: 1, Start: encode(tree.NewDInt(3))}
+	require.True(t, bound.Excludes(encode(tree.NewDInt(0)), encode(tree.NewDInt(2))))
+	require.False(t, bound.Excludes(encode(tree.NewDInt(0)), encode(tree.NewDInt(3))))
+	require.False(t, bound.Excludes(encode(tree.NewDInt(5)), encode(tree.NewDInt(7))))
+}
@@ -302,8 +302,16 @@ func NewProcessor(
 			return nil, err
 		}
 
-		if core.Exporter.Format.Format == roachpb.IOFileFormat_Parquet {
+		switch core.Exporter.Format.Format {
+		case roachpb.IOFileFormat_Parquet:
 			return NewParquetWriterProcessor(ctx, flowCtx, processorID, *core.Exporter, post, inputs[0])
+		case roachpb.IOFileFormat_JSONL:
+			return NewJSONLWriterProcessor(ctx, flowCtx, processorID, *core.Exporter, post, inputs[0])
+		case roachpb.IOFileFormat_Avro:
+			if NewAvroWriterProcessor == nil {
+				return nil, errors.New("AvroWriter processor unimplemented")
+			}
+			return NewAvroWriterProcessor(ctx, flowCtx, processorID, *core.Exporter, post, inputs[0])
 		}
 		return NewCSVWriterProcessor(ctx, flowCtx, processorID, *core.Exporter, post, inputs[0])
 	}
@@ -434,6 +442,12 @@ var NewCSVWriterProcessor func(context.Context, *execinfra.FlowCtx, int32, execi
 // NewParquetWriterProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewParquetWriterProcessor func(context.Context, *execinfra.FlowCtx, int32, execinfrapb.ExportSpec, *execinfrapb.PostProcessSpec, execinfra.RowSource) (execinfra.Processor, error)
 
+// NewJSONLWriterProcessor is implemented in the importer package and then injected here via runtime initialization.
+var NewJSONLWriterProcessor func(context.Context, *execinfra.FlowCtx, int32, execinfrapb.ExportSpec, *execinfrapb.PostProcessSpec, execinfra.RowSource) (execinfra.Processor, error)
+
+// NewAvroWriterProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
+var NewAvroWriterProcessor func(context.Context, *execinfra.FlowCtx, int32, execinfrapb.ExportSpec, *execinfrapb.PostProcessSpec, execinfra.RowSource) (execinfra.Processor, error)
+
 // NewChangeAggregatorProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewChangeAggregatorProcessor func(context.Context, *execinfra.FlowCtx, int32, execinfrapb.ChangeAggregatorSpec, *execinfrapb.PostProcessSpec) (execinfra.Processor, error)
 
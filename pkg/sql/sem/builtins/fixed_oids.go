@@ -2436,6 +2436,10 @@ var builtinOidsArray = []string{
 	2463: `workload_index_recs(timestamptz: timestamptz) -> string`,
 	2464: `workload_index_recs(budget: string) -> string`,
 	2465: `workload_index_recs(timestamptz: timestamptz, budget: string) -> string`,
+	2466: `crdb_internal.backup_compaction(collection_uri: string, subdir: string, start_time: timestamptz, end_time: timestamptz) -> int`,
+	2467: `crdb_internal.start_replication_stream_for_tables(req: bytes) -> bytes`,
+	2468: `crdb_internal.start_replication_stream(tenant_name: string, req: bytes) -> bytes`,
+	2469: `crdb_internal.backup_collection_gc(collection_uri: string) -> int`,
 }
 
 var builtinOidsBySignature map[string]oid.Oid
@@ -198,6 +198,9 @@ const (
 	CrdbInternalKVFlowHandlesID
 	CrdbInternalKVFlowTokenDeductions
 	CrdbInternalRepairableCatalogCorruptionsViewID
+	CrdbInternalHotKeysTableID
+	CrdbInternalKVStoreReencryptionTableID
+	CrdbInternalVerifyBackupResultsTableID
 	InformationSchemaID
 	InformationSchemaAdministrableRoleAuthorizationsID
 	InformationSchemaApplicableRolesID
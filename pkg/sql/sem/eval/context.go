@@ -804,8 +804,17 @@ type StreamManagerFactory interface {
 // on the production side.
 type ReplicationStreamManager interface {
 	// StartReplicationStream starts a stream replication job for the specified
-	// tenant on the producer side.
-	StartReplicationStream(ctx context.Context, tenantName roachpb.TenantName) (streampb.ReplicationProducerSpec, error)
+	// tenant on the producer side. The request optionally identifies the
+	// consumer tenant, if the stream reverses a previous replication.
+	StartReplicationStream(
+		ctx context.Context, tenantName roachpb.TenantName, req streampb.ReplicationProducerRequest,
+	) (streampb.ReplicationProducerSpec, error)
+
+	// StartReplicationStreamForTables starts a stream replication job for the
+	// tables of the request on the producer side.
+	StartReplicationStreamForTables(
+		ctx context.Context, req streampb.ReplicationProducerRequest,
+	) (streampb.ReplicationProducerSpec, error)
 
 	// HeartbeatReplicationStream sends a heartbeat to the replication stream producer, indicating
 	// consumer has consumed until the given 'frontier' timestamp. This updates the producer job
@@ -22,6 +22,12 @@ type AlterTenantReplication struct {
 	Command    JobCommand
 	Cutover    *ReplicationCutoverTime
 	Options    TenantReplicationOptions
+
+	// ReplicationSourceTenantName and ReplicationSourceAddress are set when
+	// the statement starts a replication into the tenant from the tenant it
+	// was previously replicated into.
+	ReplicationSourceTenantName *TenantSpec
+	ReplicationSourceAddress    Expr
 }
 
 var _ Statement = &AlterTenantReplication{}
@@ -31,7 +37,16 @@ func (n *AlterTenantReplication) Format(ctx *FmtCtx) {
 	ctx.WriteString("ALTER VIRTUAL CLUSTER ")
 	ctx.FormatNode(n.TenantSpec)
 	ctx.WriteByte(' ')
-	if n.Cutover != nil {
+	if n.ReplicationSourceAddress != nil {
+		ctx.WriteString("START REPLICATION OF ")
+		ctx.FormatNode(n.ReplicationSourceTenantName)
+		ctx.WriteString(" ON ")
+		ctx.FormatNode(n.ReplicationSourceAddress)
+		if !n.Options.IsDefault() {
+			ctx.WriteString(" WITH ")
+			ctx.FormatNode(&n.Options)
+		}
+	} else if n.Cutover != nil {
 		ctx.WriteString("COMPLETE REPLICATION TO ")
 		if n.Cutover.Latest {
 			ctx.WriteString("LATEST")
@@ -47,6 +47,7 @@ type BackupOptions struct {
 	EncryptionKMSURI           StringOrPlaceholderOptList
 	IncrementalStorage         StringOrPlaceholderOptList
 	ExecutionLocality          Expr
+	ContentAddressed           Expr
 }
 
 var _ NodeFormatter = &BackupOptions{}
@@ -203,6 +204,66 @@ func (node *Restore) Format(ctx *FmtCtx) {
 	}
 }
 
+// RestoreRows represents a RESTORE ROWS statement, which writes the rows of
+// a table in a backup that match a filter back into the live table.
+type RestoreRows struct {
+	Table        TableName
+	Subdir       Expr
+	InCollection StringOrPlaceholderOptList
+	AsOf         AsOfClause
+	Where        *Where
+	Options      RestoreOptions
+}
+
+var _ Statement = &RestoreRows{}
+
+// Format implements the NodeFormatter interface.
+func (node *RestoreRows) Format(ctx *FmtCtx) {
+	ctx.WriteString("RESTORE ROWS OF TABLE ")
+	ctx.FormatNode(&node.Table)
+	ctx.WriteString(" FROM ")
+	ctx.FormatNode(node.Subdir)
+	ctx.WriteString(" IN ")
+	ctx.FormatNode(&node.InCollection)
+	if node.AsOf.Expr != nil {
+		ctx.WriteString(" ")
+		ctx.FormatNode(&node.AsOf)
+	}
+	ctx.WriteString(" ")
+	ctx.FormatNode(node.Where)
+	if !node.Options.IsDefault() {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
+	}
+}
+
+// VerifyBackup represents a VERIFY BACKUP statement, which starts a job that
+// checks that the virtual clusters in a backup can be restored.
+type VerifyBackup struct {
+	Subdir       Expr
+	InCollection StringOrPlaceholderOptList
+	AsOf         AsOfClause
+	Options      ShowBackupOptions
+}
+
+var _ Statement = &VerifyBackup{}
+
+// Format implements the NodeFormatter interface.
+func (node *VerifyBackup) Format(ctx *FmtCtx) {
+	ctx.WriteString("VERIFY BACKUP ")
+	ctx.FormatNode(node.Subdir)
+	ctx.WriteString(" IN ")
+	ctx.FormatNode(&node.InCollection)
+	if node.AsOf.Expr != nil {
+		ctx.WriteString(" ")
+		ctx.FormatNode(&node.AsOf)
+	}
+	if !node.Options.IsDefault() {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
+	}
+}
+
 // KVOption is a key-value option.
 type KVOption struct {
 	Key   Name
@@ -311,6 +372,12 @@ func (o *BackupOptions) Format(ctx *FmtCtx) {
 		ctx.WriteString("include_all_virtual_clusters = ")
 		ctx.FormatNode(o.IncludeAllSecondaryTenants)
 	}
+
+	if o.ContentAddressed != nil {
+		maybeAddSep()
+		ctx.WriteString("content_addressed = ")
+		ctx.FormatNode(o.ContentAddressed)
+	}
 }
 
 // CombineWith merges other backup options into this backup options struct.
@@ -364,6 +431,14 @@ func (o *BackupOptions) CombineWith(other *BackupOptions) error {
 		o.IncludeAllSecondaryTenants = other.IncludeAllSecondaryTenants
 	}
 
+	if o.ContentAddressed != nil {
+		if other.ContentAddressed != nil {
+			return errors.New("content_addressed option specified multiple times")
+		}
+	} else {
+		o.ContentAddressed = other.ContentAddressed
+	}
+
 	return nil
 }
 
@@ -376,7 +451,8 @@ func (o BackupOptions) IsDefault() bool {
 		o.EncryptionPassphrase == options.EncryptionPassphrase &&
 		cmp.Equal(o.IncrementalStorage, options.IncrementalStorage) &&
 		o.ExecutionLocality == options.ExecutionLocality &&
-		o.IncludeAllSecondaryTenants == options.IncludeAllSecondaryTenants
+		o.IncludeAllSecondaryTenants == options.IncludeAllSecondaryTenants &&
+		o.ContentAddressed == options.ContentAddressed
 }
 
 // Format implements the NodeFormatter interface.
@@ -2290,6 +2290,74 @@ func (o TenantReplicationOptions) IsDefault() bool {
 	return o.Retention == options.Retention
 }
 
+// CreateLogicalReplicationStream represents a CREATE LOGICAL REPLICATION
+// STREAM statement.
+type CreateLogicalReplicationStream struct {
+	// From are the tables on the source cluster that are replicated.
+	From LogicalReplicationResources
+	// PGURL is the address of the source cluster.
+	PGURL Expr
+	// Into are the tables on this cluster that the source tables are
+	// replicated into. They are paired positionally with From.
+	Into LogicalReplicationResources
+}
+
+// LogicalReplicationResources are the tables on one side of a logical
+// replication stream.
+type LogicalReplicationResources struct {
+	Tables TableNames
+}
+
+var _ Statement = &CreateLogicalReplicationStream{}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateLogicalReplicationStream) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE LOGICAL REPLICATION STREAM FROM ")
+	ctx.FormatNode(&node.From)
+	ctx.WriteString(" ON ")
+	ctx.FormatNode(node.PGURL)
+	ctx.WriteString(" INTO ")
+	ctx.FormatNode(&node.Into)
+}
+
+// Format implements the NodeFormatter interface.
+func (node *LogicalReplicationResources) Format(ctx *FmtCtx) {
+	if len(node.Tables) == 1 {
+		ctx.WriteString("TABLE ")
+		ctx.FormatNode(&node.Tables[0])
+		return
+	}
+	ctx.WriteString("TABLES (")
+	ctx.FormatNode(&node.Tables)
+	ctx.WriteString(")")
+}
+
+// CreateSubscription represents a CREATE SUBSCRIPTION statement.
+type CreateSubscription struct {
+	Name Name
+	// ConnInfo is the connection string of the PostgreSQL server.
+	ConnInfo     Expr
+	Publications NameList
+	Options      KVOptions
+}
+
+var _ Statement = &CreateSubscription{}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateSubscription) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE SUBSCRIPTION ")
+	ctx.FormatNode(&node.Name)
+	ctx.WriteString(" CONNECTION ")
+	ctx.FormatNode(node.ConnInfo)
+	ctx.WriteString(" PUBLICATION ")
+	ctx.FormatNode(&node.Publications)
+	if len(node.Options) > 0 {
+		ctx.WriteString(" WITH (")
+		ctx.FormatNode(&node.Options)
+		ctx.WriteString(")")
+	}
+}
+
 type SuperRegion struct {
 	Name    Name
 	Regions NameList
@@ -144,6 +144,49 @@ func (node *ShowBackup) Format(ctx *FmtCtx) {
 	}
 }
 
+// BackupDiffSource is one side of a SHOW BACKUP DIFF statement: a backup in
+// the collection, and optionally the revision-history time to read it at.
+type BackupDiffSource struct {
+	Path Expr
+	AsOf AsOfClause
+}
+
+// Format implements the NodeFormatter interface.
+func (node *BackupDiffSource) Format(ctx *FmtCtx) {
+	ctx.FormatNode(node.Path)
+	if node.AsOf.Expr != nil {
+		ctx.WriteString(" ")
+		ctx.FormatNode(&node.AsOf)
+	}
+}
+
+// ShowBackupDiff represents a SHOW BACKUP DIFF statement, which compares the
+// rows of a table between two backups, or two times of a revision-history
+// backup, in a collection.
+type ShowBackupDiff struct {
+	Table        TableName
+	InCollection StringOrPlaceholderOptList
+	From         BackupDiffSource
+	To           BackupDiffSource
+	Options      ShowBackupOptions
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowBackupDiff) Format(ctx *FmtCtx) {
+	ctx.WriteString("SHOW BACKUP DIFF TABLE ")
+	ctx.FormatNode(&node.Table)
+	ctx.WriteString(" IN ")
+	ctx.FormatNode(&node.InCollection)
+	ctx.WriteString(" FROM ")
+	ctx.FormatNode(&node.From)
+	ctx.WriteString(" TO ")
+	ctx.FormatNode(&node.To)
+	if !node.Options.IsDefault() {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
+	}
+}
+
 type ShowBackupOptions struct {
 	AsJson               bool
 	CheckFiles           bool
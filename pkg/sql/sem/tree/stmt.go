@@ -168,7 +168,7 @@ func CanWriteData(stmt Statement) bool {
 	case *Insert, *Delete, *Update, *Truncate:
 		return true
 	// Import operations.
-	case *CopyFrom, *Import, *Restore:
+	case *CopyFrom, *Import, *Restore, *RestoreRows:
 		return true
 	// Backup creates a job and allows you to write into userfiles.
 	case *Backup:
@@ -177,7 +177,8 @@ func CanWriteData(stmt Statement) bool {
 	case *Split, *Unsplit, *Relocate, *RelocateRange, *Scatter:
 		return true
 	// Replication operations.
-	case *CreateTenantFromReplication, *AlterTenantReplication:
+	case *CreateTenantFromReplication, *AlterTenantReplication, *CreateLogicalReplicationStream,
+		*CreateSubscription:
 		return true
 	}
 	return false
@@ -218,13 +219,18 @@ var _ CCLOnlyStatement = &AlterBackup{}
 var _ CCLOnlyStatement = &AlterBackupSchedule{}
 var _ CCLOnlyStatement = &Backup{}
 var _ CCLOnlyStatement = &ShowBackup{}
+var _ CCLOnlyStatement = &ShowBackupDiff{}
 var _ CCLOnlyStatement = &Restore{}
+var _ CCLOnlyStatement = &RestoreRows{}
+var _ CCLOnlyStatement = &VerifyBackup{}
 var _ CCLOnlyStatement = &CreateChangefeed{}
 var _ CCLOnlyStatement = &AlterChangefeed{}
 var _ CCLOnlyStatement = &Import{}
 var _ CCLOnlyStatement = &Export{}
 var _ CCLOnlyStatement = &ScheduledBackup{}
 var _ CCLOnlyStatement = &CreateTenantFromReplication{}
+var _ CCLOnlyStatement = &CreateLogicalReplicationStream{}
+var _ CCLOnlyStatement = &CreateSubscription{}
 
 // StatementReturnType implements the Statement interface.
 func (*AlterChangefeed) StatementReturnType() StatementReturnType { return Rows }
@@ -871,6 +877,30 @@ func (*CreateTenantFromReplication) StatementTag() string {
 
 func (*CreateTenantFromReplication) cclOnlyStatement() {}
 
+// StatementReturnType implements the Statement interface.
+func (*CreateLogicalReplicationStream) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*CreateLogicalReplicationStream) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CreateLogicalReplicationStream) StatementTag() string {
+	return "CREATE LOGICAL REPLICATION STREAM"
+}
+
+func (*CreateLogicalReplicationStream) cclOnlyStatement() {}
+
+// StatementReturnType implements the Statement interface.
+func (*CreateSubscription) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*CreateSubscription) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CreateSubscription) StatementTag() string { return "CREATE SUBSCRIPTION" }
+
+func (*CreateSubscription) cclOnlyStatement() {}
+
 // StatementReturnType implements the Statement interface.
 func (*DropExternalConnection) StatementReturnType() StatementReturnType { return Ack }
 
@@ -1351,6 +1381,17 @@ func (*Restore) cclOnlyStatement() {}
 
 func (*Restore) hiddenFromShowQueries() {}
 
+// StatementReturnType implements the Statement interface.
+func (*RestoreRows) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*RestoreRows) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*RestoreRows) StatementTag() string { return "RESTORE ROWS" }
+
+func (*RestoreRows) cclOnlyStatement() {}
+
 // StatementReturnType implements the Statement interface.
 func (*Revoke) StatementReturnType() StatementReturnType { return DDL }
 
@@ -1613,6 +1654,17 @@ func (*ShowBackup) StatementTag() string { return "SHOW BACKUP" }
 
 func (*ShowBackup) cclOnlyStatement() {}
 
+// StatementReturnType implements the Statement interface.
+func (*ShowBackupDiff) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*ShowBackupDiff) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*ShowBackupDiff) StatementTag() string { return "SHOW BACKUP DIFF" }
+
+func (*ShowBackupDiff) cclOnlyStatement() {}
+
 // StatementReturnType implements the Statement interface.
 func (*ShowDatabases) StatementReturnType() StatementReturnType { return Rows }
 
@@ -2076,6 +2128,17 @@ func (*ValuesClause) StatementType() StatementType { return TypeDML }
 // StatementTag returns a short string identifying the type of statement.
 func (*ValuesClause) StatementTag() string { return "VALUES" }
 
+// StatementReturnType implements the Statement interface.
+func (*VerifyBackup) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*VerifyBackup) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*VerifyBackup) StatementTag() string { return "VERIFY BACKUP" }
+
+func (*VerifyBackup) cclOnlyStatement() {}
+
 // StatementReturnType implements the Statement interface.
 func (*CreateRoutine) StatementReturnType() StatementReturnType { return DDL }
 
@@ -2227,6 +2290,7 @@ func (n *CreateRole) String() string                          { return AsString(
 func (n *CreateTable) String() string                         { return AsString(n) }
 func (n *CreateTenant) String() string                        { return AsString(n) }
 func (n *CreateTenantFromReplication) String() string         { return AsString(n) }
+func (n *CreateLogicalReplicationStream) String() string      { return AsString(n) }
 func (n *CreateSchema) String() string                        { return AsString(n) }
 func (n *CreateSequence) String() string                      { return AsString(n) }
 func (n *CreateStats) String() string                         { return AsString(n) }
@@ -2271,6 +2335,7 @@ func (n *ReparentDatabase) String() string                    { return AsString(
 func (n *RenameIndex) String() string                         { return AsString(n) }
 func (n *RenameTable) String() string                         { return AsString(n) }
 func (n *Restore) String() string                             { return AsString(n) }
+func (n *RestoreRows) String() string                         { return AsString(n) }
 func (n *RoutineReturn) String() string                       { return AsString(n) }
 func (n *Revoke) String() string                              { return AsString(n) }
 func (n *RevokeRole) String() string                          { return AsString(n) }
@@ -2290,6 +2355,7 @@ func (n *SetTransaction) String() string                      { return AsString(
 func (n *SetTracing) String() string                          { return AsString(n) }
 func (n *SetVar) String() string                              { return AsString(n) }
 func (n *ShowBackup) String() string                          { return AsString(n) }
+func (n *ShowBackupDiff) String() string                      { return AsString(n) }
 func (n *ShowClusterSetting) String() string                  { return AsString(n) }
 func (n *ShowClusterSettingList) String() string              { return AsString(n) }
 func (n *ShowTenantClusterSetting) String() string            { return AsString(n) }
@@ -2350,3 +2416,4 @@ func (n *UnionClause) String() string                         { return AsString(
 func (n *Unsplit) String() string                             { return AsString(n) }
 func (n *Update) String() string                              { return AsString(n) }
 func (n *ValuesClause) String() string                        { return AsString(n) }
+func (n *VerifyBackup) String() string                        { return AsString(n) }
@@ -1032,6 +1032,24 @@ func (n *AlterTenantReplication) walkStmt(v Visitor) Statement {
 			ret.Cutover.Timestamp = e
 		}
 	}
+	if n.ReplicationSourceTenantName != nil {
+		e, changed := WalkExpr(v, n.ReplicationSourceTenantName.Expr)
+		if changed {
+			if ret == n {
+				ret = n.copyNode()
+			}
+			ret.ReplicationSourceTenantName = &TenantSpec{IsName: true, Expr: e}
+		}
+	}
+	if n.ReplicationSourceAddress != nil {
+		e, changed := WalkExpr(v, n.ReplicationSourceAddress)
+		if changed {
+			if ret == n {
+				ret = n.copyNode()
+			}
+			ret.ReplicationSourceAddress = e
+		}
+	}
 	if n.Options.Retention != nil {
 		e, changed := WalkExpr(v, n.Options.Retention)
 		if changed {
@@ -1072,6 +1090,51 @@ func (n *CreateTenant) walkStmt(v Visitor) Statement {
 	return ret
 }
 
+// copyNode makes a copy of this Statement without recursing in any child Statements.
+func (n *CreateLogicalReplicationStream) copyNode() *CreateLogicalReplicationStream {
+	stmtCopy := *n
+	return &stmtCopy
+}
+
+// walkStmt is part of the walkableStmt interface.
+func (n *CreateLogicalReplicationStream) walkStmt(v Visitor) Statement {
+	ret := n
+	if n.PGURL != nil {
+		e, changed := WalkExpr(v, n.PGURL)
+		if changed {
+			ret = n.copyNode()
+			ret.PGURL = e
+		}
+	}
+	return ret
+}
+
+// copyNode makes a copy of this Statement without recursing in any child Statements.
+func (n *CreateSubscription) copyNode() *CreateSubscription {
+	stmtCopy := *n
+	stmtCopy.Options = append(KVOptions(nil), n.Options...)
+	return &stmtCopy
+}
+
+// walkStmt is part of the walkableStmt interface.
+func (n *CreateSubscription) walkStmt(v Visitor) Statement {
+	ret := n
+	if n.ConnInfo != nil {
+		e, changed := WalkExpr(v, n.ConnInfo)
+		if changed {
+			ret = n.copyNode()
+			ret.ConnInfo = e
+		}
+	}
+	if opts, changed := walkKVOptions(v, n.Options); changed {
+		if ret == n {
+			ret = n.copyNode()
+		}
+		ret.Options = opts
+	}
+	return ret
+}
+
 // copyNode makes a copy of this Statement without recursing in any child Statements.
 func (n *CreateTenantFromReplication) copyNode() *CreateTenantFromReplication {
 	stmtCopy := *n
@@ -1266,6 +1329,16 @@ func (stmt *Backup) walkStmt(v Visitor) Statement {
 		}
 	}
 
+	if stmt.Options.ContentAddressed != nil {
+		ca, changed := WalkExpr(v, stmt.Options.ContentAddressed)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.Options.ContentAddressed = ca
+		}
+	}
+
 	if stmt.Options.ExecutionLocality != nil {
 		rh, changed := WalkExpr(v, stmt.Options.ExecutionLocality)
 		if changed {
@@ -1567,6 +1640,50 @@ func (stmt *Restore) walkStmt(v Visitor) Statement {
 	return ret
 }
 
+// copyNode makes a copy of this Statement without recursing in any child Statements.
+func (stmt *RestoreRows) copyNode() *RestoreRows {
+	stmtCopy := *stmt
+	stmtCopy.InCollection = append(StringOrPlaceholderOptList(nil), stmt.InCollection...)
+	if stmt.Where != nil {
+		wCopy := *stmt.Where
+		stmtCopy.Where = &wCopy
+	}
+	return &stmtCopy
+}
+
+// walkStmt is part of the walkableStmt interface.
+func (stmt *RestoreRows) walkStmt(v Visitor) Statement {
+	ret := stmt
+	if stmt.AsOf.Expr != nil {
+		e, changed := WalkExpr(v, stmt.AsOf.Expr)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.AsOf.Expr = e
+		}
+	}
+	if stmt.Where != nil {
+		e, changed := WalkExpr(v, stmt.Where.Expr)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.Where.Expr = e
+		}
+	}
+	if stmt.Options.EncryptionPassphrase != nil {
+		pw, changed := WalkExpr(v, stmt.Options.EncryptionPassphrase)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.Options.EncryptionPassphrase = pw
+		}
+	}
+	return ret
+}
+
 // copyNode makes a copy of this Statement without recursing in any child Statements.
 func (stmt *ReturningExprs) copyNode() *ReturningExprs {
 	stmtCopy := append(ReturningExprs(nil), *stmt...)
@@ -1966,6 +2083,26 @@ func (stmt *BeginTransaction) walkStmt(v Visitor) Statement {
 	return ret
 }
 
+// copyNode makes a copy of this Statement without recursing in any child Statements.
+func (stmt *VerifyBackup) copyNode() *VerifyBackup {
+	stmtCopy := *stmt
+	stmtCopy.InCollection = append(StringOrPlaceholderOptList(nil), stmt.InCollection...)
+	return &stmtCopy
+}
+
+// walkStmt is part of the walkableStmt interface.
+func (stmt *VerifyBackup) walkStmt(v Visitor) Statement {
+	ret := stmt
+	if stmt.AsOf.Expr != nil {
+		e, changed := WalkExpr(v, stmt.AsOf.Expr)
+		if changed {
+			ret = stmt.copyNode()
+			ret.AsOf.Expr = e
+		}
+	}
+	return ret
+}
+
 var _ walkableStmt = &AlterTenantCapability{}
 var _ walkableStmt = &AlterTenantRename{}
 var _ walkableStmt = &AlterTenantReplication{}
@@ -1980,6 +2117,8 @@ var _ walkableStmt = &ControlSchedules{}
 var _ walkableStmt = &CreateTable{}
 var _ walkableStmt = &CreateTenant{}
 var _ walkableStmt = &CreateTenantFromReplication{}
+var _ walkableStmt = &CreateLogicalReplicationStream{}
+var _ walkableStmt = &CreateSubscription{}
 var _ walkableStmt = &Delete{}
 var _ walkableStmt = &DropTenant{}
 var _ walkableStmt = &Explain{}
@@ -1987,6 +2126,7 @@ var _ walkableStmt = &Import{}
 var _ walkableStmt = &Insert{}
 var _ walkableStmt = &ParenSelect{}
 var _ walkableStmt = &Restore{}
+var _ walkableStmt = &RestoreRows{}
 var _ walkableStmt = &SelectClause{}
 var _ walkableStmt = &Select{}
 var _ walkableStmt = &SetClusterSetting{}
@@ -1996,6 +2136,7 @@ var _ walkableStmt = &ShowTenant{}
 var _ walkableStmt = &UnionClause{}
 var _ walkableStmt = &Update{}
 var _ walkableStmt = &ValuesClause{}
+var _ walkableStmt = &VerifyBackup{}
 
 // walkStmt walks the entire parsed stmt calling WalkExpr on each
 // expression, and replacing each expression with the one returned
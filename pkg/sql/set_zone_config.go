@@ -155,6 +155,43 @@ func init() {
 				c.InheritedLeasePreferences = false
 			},
 		},
+		{
+			field:        config.NumWitnesses,
+			requiredType: types.Int,
+			setter:       func(c *zonepb.ZoneConfig, d tree.Datum) { c.NumWitnesses = proto.Int32(int32(tree.MustBeDInt(d))) },
+		},
+		{
+			field:        config.WitnessConstraints,
+			requiredType: types.String,
+			setter: func(c *zonepb.ZoneConfig, d tree.Datum) {
+				witnessConstraintsList := zonepb.ConstraintsList{
+					Constraints: c.WitnessConstraints,
+				}
+				loadYAML(&witnessConstraintsList, string(tree.MustBeDString(d)))
+				c.WitnessConstraints = witnessConstraintsList.Constraints
+			},
+		},
+		{
+			field:        config.StorageTTLSeconds,
+			requiredType: types.Int,
+			setter: func(c *zonepb.ZoneConfig, d tree.Datum) {
+				c.StorageTTLSeconds = proto.Int32(int32(tree.MustBeDInt(d)))
+			},
+		},
+		{
+			field:        config.StorageTier,
+			requiredType: types.String,
+			setter: func(c *zonepb.ZoneConfig, d tree.Datum) {
+				c.StorageTier = proto.String(string(tree.MustBeDString(d)))
+			},
+		},
+		{
+			field:        config.ColdAfterSeconds,
+			requiredType: types.Int,
+			setter: func(c *zonepb.ZoneConfig, d tree.Datum) {
+				c.StorageTierColdAfterSeconds = proto.Int32(int32(tree.MustBeDInt(d)))
+			},
+		},
 	}
 	supportedZoneConfigOptions = make(map[tree.Name]zoneConfigOption, len(opts))
 	zoneOptionKeys = make([]string, len(opts))
@@ -916,6 +953,9 @@ func validateNoRepeatKeysInZone(zone *zonepb.ZoneConfig) error {
 	if err := validateNoRepeatKeysInConjunction(zone.Constraints); err != nil {
 		return err
 	}
+	if err := validateNoRepeatKeysInConjunction(zone.WitnessConstraints); err != nil {
+		return err
+	}
 	return validateNoRepeatKeysInConjunction(zone.VoterConstraints)
 }
 
@@ -987,6 +1027,11 @@ func accumulateUniqueConstraints(zone *zonepb.ZoneConfig) []zonepb.Constraint {
 			addToValidate(constraint)
 		}
 	}
+	for _, constraints := range zone.WitnessConstraints {
+		for _, constraint := range constraints.Constraints {
+			addToValidate(constraint)
+		}
+	}
 	for _, leasePreferences := range zone.LeasePreferences {
 		for _, constraint := range leasePreferences.Constraints {
 			addToValidate(constraint)
@@ -1011,7 +1056,8 @@ func validateZoneAttrsAndLocalities(
 	zone *zonepb.ZoneConfig,
 ) error {
 	// Avoid RPCs to the Node/Region server if we don't have anything to validate.
-	if len(zone.Constraints) == 0 && len(zone.VoterConstraints) == 0 && len(zone.LeasePreferences) == 0 {
+	if len(zone.Constraints) == 0 && len(zone.VoterConstraints) == 0 &&
+		len(zone.WitnessConstraints) == 0 && len(zone.LeasePreferences) == 0 {
 		return nil
 	}
 	if execCfg.Codec.ForSystemTenant() {
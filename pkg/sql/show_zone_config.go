@@ -189,6 +189,13 @@ func zoneConfigToSQL(zs *tree.ZoneSpecifier, zone *zonepb.ZoneConfig) (string, e
 		return "", err
 	}
 	prefs = strings.TrimSpace(prefs)
+	witnessConstraints, err := yamlMarshalFlow(zonepb.ConstraintsList{
+		Constraints: zone.WitnessConstraints,
+	})
+	if err != nil {
+		return "", err
+	}
+	witnessConstraints = strings.TrimSpace(witnessConstraints)
 
 	useComma := false
 	maybeWriteComma := func(f *tree.FmtCtx) {
@@ -238,6 +245,26 @@ func zoneConfigToSQL(zs *tree.ZoneSpecifier, zone *zonepb.ZoneConfig) (string, e
 		maybeWriteComma(f)
 		f.Printf("\tlease_preferences = %s", lexbase.EscapeSQLString(prefs))
 	}
+	if zone.NumWitnesses != nil && *zone.NumWitnesses > 0 {
+		maybeWriteComma(f)
+		f.Printf("\tnum_witnesses = %d", *zone.NumWitnesses)
+		if len(zone.WitnessConstraints) > 0 {
+			maybeWriteComma(f)
+			f.Printf("\twitness_constraints = %s", lexbase.EscapeSQLString(witnessConstraints))
+		}
+	}
+	if zone.StorageTTLSeconds != nil && *zone.StorageTTLSeconds > 0 {
+		maybeWriteComma(f)
+		f.Printf("\tstorage_ttl_seconds = %d", *zone.StorageTTLSeconds)
+	}
+	if zone.StorageTier != nil {
+		maybeWriteComma(f)
+		f.Printf("\tstorage_tier = %s", lexbase.EscapeSQLString(*zone.StorageTier))
+	}
+	if zone.StorageTierColdAfterSeconds != nil && *zone.StorageTierColdAfterSeconds > 0 {
+		maybeWriteComma(f)
+		f.Printf("\tstorage_tier_cold_after_seconds = %d", *zone.StorageTierColdAfterSeconds)
+	}
 	return f.String(), nil
 }
 
@@ -17,6 +17,7 @@ import (
 	"math"
 	"strings"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
@@ -454,6 +455,25 @@ var tableParams = map[string]tableParam{
 			return nil
 		},
 	},
+	`columnar_block_cache`: {
+		onSet: func(ctx context.Context, po *Setter, semaCtx *tree.SemaContext,
+			evalCtx *eval.Context, key string, datum tree.Datum) error {
+			if !evalCtx.Settings.Version.IsActive(ctx, clusterversion.V23_2_ColumnarBlockCache) {
+				return pgerror.Newf(pgcode.FeatureNotSupported,
+					"cannot set storage parameter %q until the cluster version is finalized", key)
+			}
+			columnarBlockCache, err := boolFromDatum(ctx, evalCtx, key, datum)
+			if err != nil {
+				return err
+			}
+			po.TableDesc.ColumnarBlockCache = columnarBlockCache
+			return nil
+		},
+		onReset: func(_ context.Context, po *Setter, evalCtx *eval.Context, key string) error {
+			po.TableDesc.ColumnarBlockCache = false
+			return nil
+		},
+	},
 	catpb.AutoStatsEnabledTableSettingName: {
 		onSet:   autoStatsEnabledSettingFunc,
 		onReset: autoStatsTableSettingResetFunc,
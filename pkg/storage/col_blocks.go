@@ -0,0 +1,470 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catid"
+	"github.com/cockroachdb/cockroach/pkg/util/grpcutil"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+)
+
+// This file implements the in-memory columnar block cache of the ranges of
+// tables with the columnar_block_cache storage parameter. Such ranges cache,
+// next to the row-oriented KV data, a column-oriented copy of the rows of an
+// index as of some timestamp: the ColumnarBlocks. The blocks are not persisted,
+// so they are rebuilt from the KV data when needed, e.g. after a restart or a
+// lease transfer, and they are bounded by the memory budget of the store. This
+// is a cache rather than a storage layout: the data of the table stays
+// row-oriented, and the indexes of the ranges which don't fit in the budgets
+// aren't cached. The rows are split into blocks of contiguous keys, and each block holds the values of each column in a
+// separate vector along with a zone map of the column, i.e. its smallest and
+// largest value in the block.
+//
+// The blocks are built by the same decoding logic that powers the direct
+// columnar scans (see col_mvcc.go), and they are used by the direct columnar
+// scans which would observe exactly the rows in the blocks, i.e. which read at
+// or above the timestamp of the blocks when nothing was written to the scanned
+// span after it. Such scans:
+// - skip the blocks whose zone maps show that none of their rows are within
+//   the column bounds of the fetchpb.IndexFetchSpec (which are implied by the
+//   filters on the scan),
+// - only copy (and possibly serialize) the vectors of the fetched columns of
+//   the other blocks, without decoding any KVs.
+// The parts of the scanned span which aren't covered by whole blocks are
+// scanned as usual.
+
+// ColumnarBlocks is a column-oriented copy of the rows of an index in a key
+// span, as of a timestamp.
+type ColumnarBlocks struct {
+	// Spec is the IndexFetchSpec that the blocks were built with. The blocks
+	// contain the values of its fetched columns. It must not be modified.
+	Spec *fetchpb.IndexFetchSpec
+	// Span is the key span whose rows are in the blocks.
+	Span roachpb.Span
+	// Timestamp is the MVCC timestamp as of which the rows are in the blocks.
+	Timestamp hlc.Timestamp
+	// Blocks are the blocks, in key order. Their spans are adjacent.
+	Blocks []ColumnarBlock
+	// Size is the approximate memory footprint of the blocks.
+	Size int64
+}
+
+// ColumnarBlock holds a block of rows with contiguous keys.
+type ColumnarBlock struct {
+	// Span is the key span of the rows in the block.
+	Span roachpb.Span
+	// Length is the number of rows in the block.
+	Length int
+	// Columns contains the values of the fetched columns of the spec of the
+	// blocks, in the same order. The vectors are shared by all the scans using
+	// the block, so they must not be modified.
+	Columns []coldata.Vec
+	// ZoneMaps contains the zone maps of the columns, in the same order.
+	ZoneMaps []ColumnZoneMap
+	// NumKeys and NumBytes are the number of keys and bytes of the rows in the
+	// block, in terms of the MVCCScanOptions.MaxKeys and TargetBytes limits.
+	NumKeys, NumBytes int64
+}
+
+// ColumnZoneMap summarizes the values of a column in a block.
+type ColumnZoneMap struct {
+	// Min and Max are the smallest and largest non-NULL values of the column,
+	// key-encoded in ascending order. They are nil if all the values are NULL.
+	Min, Max []byte
+	// Unknown is set if the values couldn't be summarized, e.g. because the
+	// type of the column has no key encoding. The zone map then doesn't allow
+	// skipping the block.
+	Unknown bool
+}
+
+// excludes returns whether none of the values summarized by the zone map are
+// within the bound.
+func (z *ColumnZoneMap) excludes(bound *fetchpb.IndexFetchSpec_ColumnBound) bool {
+	if z.Unknown {
+		return false
+	}
+	if z.Min == nil {
+		// All the values are NULL, and NULLs are never within the bounds.
+		return true
+	}
+	return bound.Excludes(z.Min, z.Max)
+}
+
+// ColumnarBlockCodec converts between the coldata.Batch'es produced by a
+// CFetcherWrapper and the columns of ColumnarBlocks.
+type ColumnarBlockCodec interface {
+	// EncodeBlock returns the columns of a block with the rows of the given
+	// batches, the zone maps of the columns, and the memory footprint of the
+	// columns. The batches must have been produced for the spec of the codec.
+	EncodeBlock(
+		ctx context.Context, batches []coldata.Batch,
+	) (_ []coldata.Vec, length int, _ []ColumnZoneMap, size int64, _ error)
+
+	// DecodeBlock gives back a batch with the given columns of a block, in the
+	// order of the fetched columns of the spec of the codec, possibly
+	// serialized in Arrow batch format. All calls to DecodeBlock will use the
+	// same format, and the memory accounting for all returned batches is done
+	// against the memory account provided in GetColumnarBlockCodec().
+	DecodeBlock(ctx context.Context, cols []coldata.Vec, length int) ([]byte, coldata.Batch, error)
+
+	// Close releases the resources held by this ColumnarBlockCodec. It *must*
+	// be called after use of the codec.
+	Close(ctx context.Context)
+}
+
+// GetColumnarBlockCodec returns a ColumnarBlockCodec. It's injected from
+// pkg/sql/colfetcher to avoid circular dependencies since storage can't depend
+// on higher levels of the system.
+var GetColumnarBlockCodec func(
+	ctx context.Context,
+	st *cluster.Settings,
+	acc *mon.BoundAccount,
+	indexFetchSpec *fetchpb.IndexFetchSpec,
+	mustSerialize bool,
+) (ColumnarBlockCodec, error)
+
+// columnarBlockMaxKeys returns the maximum number of keys of the rows in a
+// block. It keeps the number of rows in a block within coldata.BatchSize().
+func columnarBlockMaxKeys(spec *fetchpb.IndexFetchSpec) int64 {
+	maxKeys := int64(coldata.BatchSize())
+	if maxKeysPerRow := int64(spec.MaxKeysPerRow); maxKeys < maxKeysPerRow {
+		maxKeys = maxKeysPerRow
+	}
+	return maxKeys
+}
+
+// BuildColumnarBlocks builds the columnar blocks with the rows of the index of
+// the given spec in the span, as of the timestamp. The reader must observe all
+// the writes at or below the timestamp, i.e. the timestamp must be closed.
+// Building fails if there are intents at or below the timestamp, if the
+// memory footprint of the blocks would exceed maxSize, or if it can't be
+// accounted for in acc. The caller owns the memory registered in acc, and is
+// responsible for releasing it along with the blocks.
+func BuildColumnarBlocks(
+	ctx context.Context,
+	reader Reader,
+	spec *fetchpb.IndexFetchSpec,
+	span roachpb.Span,
+	timestamp hlc.Timestamp,
+	maxSize int64,
+	acc *mon.BoundAccount,
+	st *cluster.Settings,
+) (*ColumnarBlocks, error) {
+	codec, err := GetColumnarBlockCodec(ctx, st, nil /* acc */, spec, false /* mustSerialize */)
+	if err != nil {
+		return nil, err
+	}
+	defer codec.Close(ctx)
+
+	blocks := &ColumnarBlocks{Spec: spec, Span: span, Timestamp: timestamp}
+	opts := MVCCScanOptions{
+		MaxKeys:         columnarBlockMaxKeys(spec),
+		WholeRowsOfSize: int32(spec.MaxKeysPerRow),
+	}
+	for key := span.Key; ; {
+		iter := newMVCCIterator(
+			reader, timestamp, true /* rangeKeyMasking */, false /* noInterleavedIntents */, IterOptions{
+				KeyTypes:   IterKeyTypePointsAndRanges,
+				LowerBound: key,
+				UpperBound: span.EndKey,
+			},
+		)
+		res, err := mvccScanToCols(
+			ctx, iter, spec, key, span.EndKey, timestamp, opts, st, false, /* mustSerialize */
+		)
+		iter.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(res.KVData) > 0 {
+			// The CFetcherWrapper serializes the batches if they contain enums,
+			// which can't be kept unhydrated in the blocks.
+			return nil, errors.Newf("columnar blocks are unsupported for index %s of table %s",
+				spec.IndexName, spec.TableName)
+		}
+		endKey := span.EndKey
+		if res.ResumeSpan != nil {
+			endKey = res.ResumeSpan.Key
+		}
+		if len(res.ColBatches) > 0 {
+			cols, length, zoneMaps, size, err := codec.EncodeBlock(ctx, res.ColBatches)
+			if err != nil {
+				return nil, err
+			}
+			blocks.Blocks = append(blocks.Blocks, ColumnarBlock{
+				Span:     roachpb.Span{Key: key, EndKey: endKey},
+				Length:   length,
+				Columns:  cols,
+				ZoneMaps: zoneMaps,
+				NumKeys:  res.NumKeys,
+				NumBytes: res.NumBytes,
+			})
+			size += int64(len(key) + len(endKey))
+			blocks.Size += size
+			if blocks.Size > maxSize {
+				return nil, errors.Newf("columnar blocks exceed the maximum size of %s",
+					humanizeutil.IBytes(maxSize))
+			}
+			if err := acc.Grow(ctx, size); err != nil {
+				return nil, err
+			}
+		}
+		if res.ResumeSpan == nil {
+			break
+		}
+		key = endKey
+	}
+	if n := len(blocks.Blocks); n > 0 {
+		// There are no rows between the last block and the end of the span.
+		blocks.Blocks[n-1].Span.EndKey = span.EndKey
+	}
+	return blocks, nil
+}
+
+// columnOrdinals returns, for each fetched column of the spec, the ordinal of
+// the column in the blocks. ok=false is returned if the spec isn't for the
+// index of the blocks (or its schema changed), or if some column isn't in the
+// blocks.
+func (b *ColumnarBlocks) columnOrdinals(spec *fetchpb.IndexFetchSpec) (_ []int, ok bool) {
+	if spec.TableID != b.Spec.TableID || spec.IndexID != b.Spec.IndexID ||
+		spec.MaxKeysPerRow != b.Spec.MaxKeysPerRow || spec.MaxFamilyID != b.Spec.MaxFamilyID {
+		return nil, false
+	}
+	ordinals := make([]int, len(spec.FetchedColumns))
+	for i := range spec.FetchedColumns {
+		col := &spec.FetchedColumns[i]
+		ord := b.columnOrdinal(col.ColumnID)
+		if ord < 0 || !col.Type.Identical(b.Spec.FetchedColumns[ord].Type) {
+			return nil, false
+		}
+		ordinals[i] = ord
+	}
+	return ordinals, true
+}
+
+// columnOrdinal returns the ordinal of the column with the given ID in the
+// blocks, or -1 if it isn't in the blocks.
+func (b *ColumnarBlocks) columnOrdinal(colID catid.ColumnID) int {
+	for i := range b.Spec.FetchedColumns {
+		if b.Spec.FetchedColumns[i].ColumnID == colID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Covers returns whether the blocks contain all the fetched columns of the
+// given spec.
+func (b *ColumnarBlocks) Covers(spec *fetchpb.IndexFetchSpec) bool {
+	_, ok := b.columnOrdinals(spec)
+	return ok
+}
+
+// excludes returns whether the zone maps of the block show that none of its
+// rows are within the given column bounds.
+func (b *ColumnarBlocks) excludes(
+	block *ColumnarBlock, bounds []fetchpb.IndexFetchSpec_ColumnBound,
+) bool {
+	for i := range bounds {
+		if ord := b.columnOrdinal(bounds[i].ColumnID); ord >= 0 && block.ZoneMaps[ord].excludes(&bounds[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWritesAbove returns whether any keys in the span were written above the
+// given timestamp, including intents and MVCC range tombstones.
+func hasWritesAbove(reader Reader, span roachpb.Span, timestamp hlc.Timestamp) (bool, error) {
+	iter := NewMVCCIncrementalIterator(reader, MVCCIncrementalIterOptions{
+		KeyTypes:     IterKeyTypePointsAndRanges,
+		StartKey:     span.Key,
+		EndKey:       span.EndKey,
+		StartTime:    timestamp, // exclusive
+		IntentPolicy: MVCCIncrementalIterIntentPolicyEmit,
+	})
+	defer iter.Close()
+	iter.SeekGE(MVCCKey{Key: span.Key})
+	return iter.Valid()
+}
+
+// MVCCScanColumnarBlocks is like MVCCScanToCols, but it reads the rows from the
+// columnar blocks where possible. It skips the blocks whose zone maps show that
+// none of their rows are within the column bounds of the spec, and only copies
+// the fetched columns of the others. The parts of the span which aren't covered
+// by whole blocks are scanned by MVCCScanToCols.
+//
+// ok=false is returned if the blocks can't be used for the scan, in which case
+// the caller is expected to fall back to MVCCScanToCols. This is the case if
+// the scan could observe different rows than the blocks, e.g. because it reads
+// below the timestamp of the blocks or because something was written to the
+// span after it.
+func MVCCScanColumnarBlocks(
+	ctx context.Context,
+	reader Reader,
+	blocks *ColumnarBlocks,
+	indexFetchSpec *fetchpb.IndexFetchSpec,
+	key, endKey roachpb.Key,
+	timestamp hlc.Timestamp,
+	opts MVCCScanOptions,
+	st *cluster.Settings,
+) (_ MVCCScanResult, ok bool, _ error) {
+	if timestamp.Less(blocks.Timestamp) || opts.Inconsistent || opts.SkipLocked ||
+		opts.FailOnMoreRecent || opts.Reverse || opts.Tombstones ||
+		opts.MaxKeys < 0 || opts.TargetBytes < 0 {
+		return MVCCScanResult{}, false, nil
+	}
+	ordinals, ok := blocks.columnOrdinals(indexFetchSpec)
+	if !ok {
+		return MVCCScanResult{}, false, nil
+	}
+	// Find the blocks which are fully contained in the scanned span.
+	first := sort.Search(len(blocks.Blocks), func(i int) bool {
+		return blocks.Blocks[i].Span.Key.Compare(key) >= 0
+	})
+	last := sort.Search(len(blocks.Blocks), func(i int) bool {
+		return blocks.Blocks[i].Span.EndKey.Compare(endKey) > 0
+	})
+	if first >= last {
+		return MVCCScanResult{}, false, nil
+	}
+	// The blocks contain the rows of the scan if nothing was written to them
+	// after their timestamp. Note that this is conservative since it includes
+	// the writes above the timestamp of the scan; however, those could still
+	// be observed by the scan due to uncertainty or as writes of its own
+	// transaction.
+	blocksSpan := roachpb.Span{Key: blocks.Blocks[first].Span.Key, EndKey: blocks.Blocks[last-1].Span.EndKey}
+	if changed, err := hasWritesAbove(reader, blocksSpan, blocks.Timestamp); err != nil || changed {
+		return MVCCScanResult{}, false, err
+	}
+
+	// Try to use the same root monitor (from the store) if the account is
+	// provided, like mvccScanToCols.
+	monitor := opts.MemoryAccount.Monitor()
+	if monitor == nil {
+		monitor = mon.NewMonitor(
+			"mvcc-scan-columnar-blocks",
+			mon.MemoryResource,
+			nil,           /* curCount */
+			nil,           /* maxHist */
+			-1,            /* increment */
+			math.MaxInt64, /* noteworthy */
+			st,
+		)
+		monitor.Start(ctx, nil /* pool */, mon.NewStandaloneBudget(math.MaxInt64))
+		defer monitor.Stop(ctx)
+	}
+	acc := monitor.MakeBoundAccount()
+	defer acc.Close(ctx)
+	_, isLocal := grpcutil.IsLocalRequestContext(ctx)
+	codec, err := GetColumnarBlockCodec(ctx, st, &acc, indexFetchSpec, !isLocal /* mustSerialize */)
+	if err != nil {
+		return MVCCScanResult{}, false, err
+	}
+	defer codec.Close(ctx)
+
+	var res MVCCScanResult
+	// exhausted returns whether the limits of the scan have been reached.
+	exhausted := func() (kvpb.ResumeReason, bool) {
+		if opts.MaxKeys > 0 && res.NumKeys >= opts.MaxKeys {
+			return kvpb.RESUME_KEY_LIMIT, true
+		}
+		if opts.TargetBytes > 0 && res.NumBytes >= opts.TargetBytes {
+			return kvpb.RESUME_BYTE_LIMIT, true
+		}
+		return 0, false
+	}
+	// scan scans the given span from the KV data, within the remaining limits
+	// of the scan. It returns whether the scan is done.
+	scan := func(span roachpb.Span) (done bool, _ error) {
+		if reason, ok := exhausted(); ok {
+			res.ResumeSpan = &roachpb.Span{Key: span.Key, EndKey: endKey}
+			res.ResumeReason = reason
+			return true, nil
+		}
+		spanOpts := opts
+		if opts.MaxKeys > 0 {
+			spanOpts.MaxKeys -= res.NumKeys
+		}
+		if opts.TargetBytes > 0 {
+			spanOpts.TargetBytes -= res.NumBytes
+		}
+		spanOpts.AllowEmpty = opts.AllowEmpty || res.NumKeys > 0
+		spanRes, err := MVCCScanToCols(
+			ctx, reader, indexFetchSpec, span.Key, span.EndKey, timestamp, spanOpts, st,
+		)
+		if err != nil {
+			return true, err
+		}
+		res.KVData = append(res.KVData, spanRes.KVData...)
+		res.ColBatches = append(res.ColBatches, spanRes.ColBatches...)
+		res.NumKeys += spanRes.NumKeys
+		res.NumBytes += spanRes.NumBytes
+		if spanRes.ResumeSpan != nil {
+			res.ResumeSpan = &roachpb.Span{Key: spanRes.ResumeSpan.Key, EndKey: endKey}
+			res.ResumeReason = spanRes.ResumeReason
+			res.ResumeNextBytes = spanRes.ResumeNextBytes
+			return true, nil
+		}
+		return false, nil
+	}
+
+	if head := (roachpb.Span{Key: key, EndKey: blocksSpan.Key}); head.Valid() {
+		if done, err := scan(head); done || err != nil {
+			return res, true, err
+		}
+	}
+	cols := make([]coldata.Vec, len(ordinals))
+	for i := first; i < last; i++ {
+		block := &blocks.Blocks[i]
+		if blocks.excludes(block, indexFetchSpec.ColumnBounds) {
+			continue
+		}
+		if (opts.MaxKeys > 0 && res.NumKeys+block.NumKeys > opts.MaxKeys) ||
+			(opts.TargetBytes > 0 && res.NumBytes+block.NumBytes > opts.TargetBytes) {
+			// The block doesn't fit into the limits of the scan, so scan the rest
+			// of the span from the KV data, which stops at the limits.
+			_, err := scan(roachpb.Span{Key: block.Span.Key, EndKey: endKey})
+			return res, true, err
+		}
+		for j, ord := range ordinals {
+			cols[j] = block.Columns[ord]
+		}
+		serializedBatch, colBatch, err := codec.DecodeBlock(ctx, cols, block.Length)
+		if err != nil {
+			return MVCCScanResult{}, false, err
+		}
+		if len(serializedBatch) > 0 {
+			res.KVData = append(res.KVData, serializedBatch)
+		} else {
+			res.ColBatches = append(res.ColBatches, colBatch)
+		}
+		res.NumKeys += block.NumKeys
+		res.NumBytes += block.NumBytes
+	}
+	if tail := (roachpb.Span{Key: blocksSpan.EndKey, EndKey: endKey}); tail.Valid() {
+		_, err := scan(tail)
+		return res, true, err
+	}
+	return res, true, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnZoneMapExcludes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	bound := fetchpb.IndexFetchSpec_ColumnBound{Start: []byte("c"), End: []byte("f")}
+	for _, tc := range []struct {
+		name     string
+		zoneMap  ColumnZoneMap
+		excludes bool
+	}{
+		{"all null", ColumnZoneMap{}, true},
+		{"unknown", ColumnZoneMap{Unknown: true}, false},
+		{"below", ColumnZoneMap{Min: []byte("a"), Max: []byte("b")}, true},
+		{"above", ColumnZoneMap{Min: []byte("g"), Max: []byte("h")}, true},
+		{"overlaps start", ColumnZoneMap{Min: []byte("a"), Max: []byte("c")}, false},
+		{"overlaps end", ColumnZoneMap{Min: []byte("f"), Max: []byte("h")}, false},
+		{"contains", ColumnZoneMap{Min: []byte("a"), Max: []byte("h")}, false},
+		{"within", ColumnZoneMap{Min: []byte("d"), Max: []byte("e")}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.excludes, tc.zoneMap.excludes(&bound))
+		})
+	}
+}
+
+func TestHasWritesAbove(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := NewDefaultInMemForTesting()
+	defer engine.Close()
+
+	span := roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")}
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	requireWritesAbove := func(expected bool, timestamp hlc.Timestamp) {
+		t.Helper()
+		changed, err := hasWritesAbove(engine, span, timestamp)
+		require.NoError(t, err)
+		require.Equal(t, expected, changed)
+	}
+
+	requireWritesAbove(false, ts(1))
+	require.NoError(t, MVCCPut(ctx, engine, roachpb.Key("b"), ts(10), roachpb.MakeValueFromString("v"), MVCCWriteOptions{}))
+	requireWritesAbove(true, ts(5))
+	requireWritesAbove(false, ts(10))
+
+	// Intents count as writes.
+	txn := makeTxn(*txn1, ts(20))
+	require.NoError(t, MVCCPut(ctx, engine, roachpb.Key("c"), ts(20), roachpb.MakeValueFromString("v"), MVCCWriteOptions{Txn: txn}))
+	requireWritesAbove(true, ts(15))
+}
+
+func TestMVCCScanColumnarBlocksIneligible(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := NewDefaultInMemForTesting()
+	defer engine.Close()
+	st := cluster.MakeTestingClusterSettings()
+
+	spec := &fetchpb.IndexFetchSpec{
+		TableID: 106,
+		IndexID: 1,
+		FetchedColumns: []fetchpb.IndexFetchSpec_Column{
+			{ColumnID: 1, Type: types.Int}, {ColumnID: 2, Type: types.String},
+		},
+	}
+	blocks := &ColumnarBlocks{
+		Spec:      spec,
+		Span:      roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")},
+		Timestamp: hlc.Timestamp{WallTime: 10},
+		Blocks: []ColumnarBlock{
+			{Span: roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("m")}},
+			{Span: roachpb.Span{Key: roachpb.Key("m"), EndKey: roachpb.Key("z")}},
+		},
+	}
+	otherIndex := *spec
+	otherIndex.IndexID = 2
+	otherColumn := *spec
+	otherColumn.FetchedColumns = []fetchpb.IndexFetchSpec_Column{{ColumnID: 3, Type: types.Int}}
+
+	for _, tc := range []struct {
+		name      string
+		spec      *fetchpb.IndexFetchSpec
+		key       roachpb.Key
+		endKey    roachpb.Key
+		timestamp hlc.Timestamp
+		opts      MVCCScanOptions
+	}{
+		{"below blocks", spec, roachpb.Key("a"), roachpb.Key("z"), hlc.Timestamp{WallTime: 5}, MVCCScanOptions{}},
+		{"reverse", spec, roachpb.Key("a"), roachpb.Key("z"), hlc.Timestamp{WallTime: 20}, MVCCScanOptions{Reverse: true}},
+		{"inconsistent", spec, roachpb.Key("a"), roachpb.Key("z"), hlc.Timestamp{WallTime: 20}, MVCCScanOptions{Inconsistent: true}},
+		{"fail on more recent", spec, roachpb.Key("a"), roachpb.Key("z"), hlc.Timestamp{WallTime: 20}, MVCCScanOptions{FailOnMoreRecent: true}},
+		{"other index", &otherIndex, roachpb.Key("a"), roachpb.Key("z"), hlc.Timestamp{WallTime: 20}, MVCCScanOptions{}},
+		{"other column", &otherColumn, roachpb.Key("a"), roachpb.Key("z"), hlc.Timestamp{WallTime: 20}, MVCCScanOptions{}},
+		{"no whole block", spec, roachpb.Key("b"), roachpb.Key("n"), hlc.Timestamp{WallTime: 20}, MVCCScanOptions{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, ok, err := MVCCScanColumnarBlocks(
+				ctx, engine, blocks, tc.spec, tc.key, tc.endKey, tc.timestamp, tc.opts, st,
+			)
+			require.NoError(t, err)
+			require.False(t, ok)
+		})
+	}
+
+	// Writes to the blocks after their timestamp make them ineligible as well.
+	require.NoError(t, MVCCPut(ctx, engine, roachpb.Key("c"), hlc.Timestamp{WallTime: 15},
+		roachpb.MakeValueFromString("v"), MVCCWriteOptions{}))
+	_, ok, err := MVCCScanColumnarBlocks(
+		ctx, engine, blocks, spec, roachpb.Key("a"), roachpb.Key("z"), hlc.Timestamp{WallTime: 20},
+		MVCCScanOptions{}, st,
+	)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
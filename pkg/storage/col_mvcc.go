@@ -409,7 +409,11 @@ func MVCCScanToCols(
 		},
 	)
 	defer iter.Close()
-	return mvccScanToCols(ctx, iter, indexFetchSpec, key, endKey, timestamp, opts, st)
+	_, isLocal := grpcutil.IsLocalRequestContext(ctx)
+	// Note that the CFetcherWrapper might still serialize the batches even for
+	// local requests.
+	mustSerialize := !isLocal
+	return mvccScanToCols(ctx, iter, indexFetchSpec, key, endKey, timestamp, opts, st, mustSerialize)
 }
 
 func mvccScanToCols(
@@ -420,6 +424,7 @@ func mvccScanToCols(
 	timestamp hlc.Timestamp,
 	opts MVCCScanOptions,
 	st *cluster.Settings,
+	mustSerialize bool,
 ) (MVCCScanResult, error) {
 	mvccScanner := pebbleMVCCScannerPool.Get().(*pebbleMVCCScanner)
 	adapter := mvccScanFetchAdapter{machine: onNextKVSeek}
@@ -452,10 +457,6 @@ func mvccScanToCols(
 	}
 	acc := monitor.MakeBoundAccount()
 	defer acc.Close(ctx)
-	_, isLocal := grpcutil.IsLocalRequestContext(ctx)
-	// Note that the CFetcherWrapper might still serialize the batches even for
-	// local requests.
-	mustSerialize := !isLocal
 	wrapper, err := GetCFetcherWrapper(
 		ctx,
 		st,
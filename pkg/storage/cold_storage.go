@@ -0,0 +1,720 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/objstorage"
+	"github.com/cockroachdb/pebble/objstorage/remote"
+	"github.com/cockroachdb/pebble/sstable"
+)
+
+// coldStorage is the cold storage tier of a Pebble instance, an external
+// storage to which the data of cold spans is moved. A span is moved by
+// writing all of its keys to a single sstable on the cold storage tier, and
+// then ingesting that sstable as an external file in place of the span's
+// local data (see WriteColdSST and IngestColdSST). Pebble reads external files
+// through its remote storage, and caches the blocks it reads on local disk in
+// its secondary cache.
+//
+// Keys written to the span after it was moved are flushed to local sstables
+// as usual, and compactions involving the external file rewrite its keys
+// locally. A span that stays cold is thus moved again once enough of its data
+// is back on local disk.
+//
+// The cold storage tier may be shared by all stores of a cluster. Sstables are
+// content-addressed: they are named after the digest of their contents,
+// which is the same for all replicas of a range at a given applied index. A
+// store moving a span reuses an existing sstable with the same contents rather
+// than uploading its own copy, so that the replicas of a cold range usually
+// share a single sstable.
+//
+// Each store that references an sstable keeps a reference marker next to it.
+// A store removes its marker once none of its sstables is backed by the
+// sstable any more, i.e. once compactions, including those that drop the
+// data of removed replicas, have rewritten or deleted all of the sstable's
+// keys. An sstable without markers is deleted by the next store to observe it
+// unreferenced for coldStorageGCGracePeriod. A store only reuses an sstable
+// after adding its marker and observing that of another store, so that an
+// sstable can't be deleted while it's being reused.
+//
+// Markers of stores that are permanently removed from the cluster are never
+// removed, and neither are the sstables they reference.
+type coldStorage struct {
+	// locator identifies the cold storage tier to Pebble's remote storage. It
+	// is the URI of the external storage.
+	locator remote.Locator
+	factory *cloud.ExternalStorageAccessor
+	// storeID returns the ID of the store, which identifies its reference
+	// markers. It is 0 until the store is initialized.
+	storeID func() int32
+
+	mu struct {
+		syncutil.Mutex
+		// es is opened on first use, since the factory is only initialized after
+		// the engine is opened.
+		es cloud.ExternalStorage
+		// pinned counts, for each sstable, the ongoing moves which reference it
+		// but did not ingest it yet.
+		pinned map[string]int
+		// unreferencedSince is the time at which each sstable was first observed
+		// without any references.
+		unreferencedSince map[string]time.Time
+		// lastGC is the time at which garbage was last collected.
+		lastGC time.Time
+		// gcRunning is set while garbage is being collected.
+		gcRunning bool
+		// stopped is set once the Pebble instance is closing, after which no
+		// garbage is collected.
+		stopped bool
+	}
+}
+
+const (
+	// coldSSTSuffix is the suffix of the names of sstables on the cold storage
+	// tier.
+	coldSSTSuffix = ".sst"
+	// coldRefInfix separates the name of an sstable, without its suffix, from
+	// the ID of the store in the names of reference markers.
+	coldRefInfix = ".ref.s"
+	// coldStorageGCInterval is the minimum interval between collections of
+	// garbage on the cold storage tier.
+	coldStorageGCInterval = time.Minute
+	// coldStorageGCGracePeriod is the duration for which an sstable must be
+	// observed without references before it is deleted. It tolerates external
+	// storages whose listings are only eventually consistent.
+	coldStorageGCGracePeriod = 10 * time.Minute
+)
+
+// coldRefName returns the name of the given store's reference marker for an
+// sstable.
+func coldRefName(objName string, storeID int32) string {
+	return fmt.Sprintf("%s%s%d", strings.TrimSuffix(objName, coldSSTSuffix), coldRefInfix, storeID)
+}
+
+// parseColdRefName returns the sstable and store of a reference marker.
+func parseColdRefName(name string) (objName string, storeID int32, ok bool) {
+	i := strings.LastIndex(name, coldRefInfix)
+	if i < 0 {
+		return "", 0, false
+	}
+	id, err := strconv.ParseInt(name[i+len(coldRefInfix):], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:i] + coldSSTSuffix, int32(id), true
+}
+
+// externalStorage returns the external storage of the cold storage tier,
+// opening it if needed.
+func (c *coldStorage) externalStorage(ctx context.Context) (cloud.ExternalStorage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.es == nil {
+		es, err := c.factory.OpenURL(ctx, string(c.locator), username.SQLUsername{})
+		if err != nil {
+			return nil, errors.Wrap(err, "opening cold storage")
+		}
+		c.mu.es = es
+	}
+	return c.mu.es, nil
+}
+
+func (c *coldStorage) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.es == nil {
+		return nil
+	}
+	return c.mu.es.Close()
+}
+
+// coldRemoteStorage implements remote.Storage for the cold storage tier of a
+// Pebble instance. Pebble creates it when opened if the instance has
+// sstables on the cold storage tier, so the external storage is only opened
+// once Pebble accesses one of them.
+type coldRemoteStorage struct {
+	p   *Pebble
+	ctx context.Context
+}
+
+var _ remote.Storage = coldRemoteStorage{}
+
+func (c coldRemoteStorage) wrapper() (*externalStorageWrapper, error) {
+	es, err := c.p.coldStorage.externalStorage(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &externalStorageWrapper{p: c.p, es: es, ctx: c.ctx, cold: true}, nil
+}
+
+// Close implements the remote.Storage interface. The external storage is
+// closed along with the Pebble instance.
+func (c coldRemoteStorage) Close() error {
+	return nil
+}
+
+// ReadObject implements the remote.Storage interface.
+func (c coldRemoteStorage) ReadObject(
+	ctx context.Context, objName string,
+) (_ remote.ObjectReader, objSize int64, _ error) {
+	w, err := c.wrapper()
+	if err != nil {
+		return nil, 0, err
+	}
+	return w.ReadObject(ctx, objName)
+}
+
+// CreateObject implements the remote.Storage interface.
+func (c coldRemoteStorage) CreateObject(objName string) (io.WriteCloser, error) {
+	w, err := c.wrapper()
+	if err != nil {
+		return nil, err
+	}
+	return w.CreateObject(objName)
+}
+
+// List implements the remote.Storage interface.
+func (c coldRemoteStorage) List(prefix, delimiter string) ([]string, error) {
+	w, err := c.wrapper()
+	if err != nil {
+		return nil, err
+	}
+	return w.List(prefix, delimiter)
+}
+
+// Delete implements the remote.Storage interface.
+func (c coldRemoteStorage) Delete(objName string) error {
+	w, err := c.wrapper()
+	if err != nil {
+		return err
+	}
+	return w.Delete(objName)
+}
+
+// Size implements the remote.Storage interface.
+func (c coldRemoteStorage) Size(objName string) (int64, error) {
+	w, err := c.wrapper()
+	if err != nil {
+		return 0, err
+	}
+	return w.Size(objName)
+}
+
+// IsNotExistError implements the remote.Storage interface.
+func (c coldRemoteStorage) IsNotExistError(err error) bool {
+	return errors.Is(err, cloud.ErrFileDoesNotExist)
+}
+
+// pin records that an ongoing move references the sstable.
+func (c *coldStorage) pin(objName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.pinned == nil {
+		c.mu.pinned = make(map[string]int)
+	}
+	c.mu.pinned[objName]++
+}
+
+// unpin records that a move no longer references the sstable, either because
+// it ingested it or because it was aborted.
+func (c *coldStorage) unpin(objName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.pinned[objName]--; c.mu.pinned[objName] <= 0 {
+		delete(c.mu.pinned, objName)
+	}
+}
+
+// ColdSST is an sstable written to the cold storage tier by WriteColdSST.
+type ColdSST struct {
+	// ObjName is the name of the sstable on the cold storage tier.
+	ObjName string
+	// Reused is set if the sstable was uploaded by another store.
+	Reused bool
+
+	c       *coldStorage
+	es      cloud.ExternalStorage
+	storeID int32
+	meta    pebble.SharedSSTMeta
+}
+
+// Empty returns true if no sstable was written because the span was empty.
+func (s ColdSST) Empty() bool {
+	return s.ObjName == ""
+}
+
+// Size returns the size of the sstable in bytes.
+func (s ColdSST) Size() int64 {
+	return int64(s.meta.Size)
+}
+
+// Release drops the reference of an sstable that was not ingested. The
+// sstable itself is deleted once no store references it.
+func (s ColdSST) Release(ctx context.Context) error {
+	if s.Empty() {
+		return nil
+	}
+	defer s.c.unpin(s.ObjName)
+	return s.es.Delete(ctx, coldRefName(s.ObjName, s.storeID))
+}
+
+// coldSSTBacking implements objstorage.RemoteObjectBackingHandle for an
+// sstable on the cold storage tier. Pebble does not delete external objects;
+// the sstable is protected by the store's reference marker instead.
+type coldSSTBacking objstorage.RemoteObjectBacking
+
+var _ objstorage.RemoteObjectBackingHandle = coldSSTBacking(nil)
+
+// Get is part of the objstorage.RemoteObjectBackingHandle interface.
+func (b coldSSTBacking) Get() (objstorage.RemoteObjectBacking, error) {
+	return objstorage.RemoteObjectBacking(b), nil
+}
+
+// Close is part of the objstorage.RemoteObjectBackingHandle interface.
+func (b coldSSTBacking) Close() {}
+
+// ColdStorageEnabled implements the Engine interface.
+func (p *Pebble) ColdStorageEnabled() bool {
+	// Ingesting external files requires virtual sstables.
+	return p.coldStorage != nil &&
+		p.db.FormatMajorVersion() >= pebble.ExperimentalFormatVirtualSSTables
+}
+
+// hashWriter is an io.Writer that computes the digest of the written bytes.
+type hashWriter struct {
+	hash.Hash
+	size int64
+}
+
+func (h *hashWriter) Write(p []byte) (int, error) {
+	h.size += int64(len(p))
+	return h.Hash.Write(p)
+}
+
+func (h *hashWriter) digest() string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// WriteColdSST implements the Engine interface.
+//
+// The sstable is built twice: first to compute its digest, and then, unless
+// another store already uploaded an sstable with the same digest, to upload
+// it.
+func (p *Pebble) WriteColdSST(ctx context.Context, r Reader, span roachpb.Span) (ColdSST, error) {
+	if !p.ColdStorageEnabled() {
+		return ColdSST{}, errors.AssertionFailedf("engine has no cold storage tier")
+	}
+	if !r.ConsistentIterators() {
+		return ColdSST{}, errors.AssertionFailedf("reader must provide consistent iterators")
+	}
+	storeID := p.coldStorage.storeID()
+	if storeID == 0 {
+		return ColdSST{}, errors.AssertionFailedf("store is not initialized")
+	}
+	if empty, err := MVCCIsSpanEmpty(ctx, r, MVCCIsSpanEmptyOptions{
+		StartKey: span.Key,
+		EndKey:   span.EndKey,
+	}); err != nil || empty {
+		return ColdSST{}, err
+	}
+
+	hw := &hashWriter{Hash: sha256.New()}
+	sstWriter := MakeIngestionSSTWriter(ctx, p.settings, &noopFinishAbort{hw})
+	if err := writeColdSST(r, span, &sstWriter); err != nil {
+		sstWriter.Close()
+		return ColdSST{}, err
+	}
+	digest := hw.digest()
+
+	es, err := p.coldStorage.externalStorage(ctx)
+	if err != nil {
+		return ColdSST{}, err
+	}
+	sst := ColdSST{c: p.coldStorage, es: es, storeID: storeID, meta: makeColdSSTMeta(sstWriter.Meta)}
+	if objName, err := p.coldStorage.reuse(ctx, es, storeID, digest, hw.size); err != nil {
+		return ColdSST{}, err
+	} else if objName != "" {
+		sst.ObjName, sst.Reused = objName, true
+		return sst, nil
+	}
+
+	// Upload a new copy. Its reference marker is written first, so that the
+	// sstable is never observed without references.
+	sst.ObjName = fmt.Sprintf("%s/%s%s", digest, uuid.MakeV4(), coldSSTSuffix)
+	p.coldStorage.pin(sst.ObjName)
+	if err := p.uploadColdSST(ctx, es, r, span, sst, digest); err != nil {
+		if relErr := sst.Release(ctx); relErr != nil {
+			log.Warningf(ctx, "failed to release %s on cold storage: %v", sst.ObjName, relErr)
+		}
+		return ColdSST{}, err
+	}
+	return sst, nil
+}
+
+// reuse looks for an sstable uploaded by another store with the given digest
+// and size, and adds the store's reference to it. It returns an empty name if
+// there is none.
+func (c *coldStorage) reuse(
+	ctx context.Context, es cloud.ExternalStorage, storeID int32, digest string, size int64,
+) (string, error) {
+	// refs returns the stores referencing each sstable with the digest.
+	refs := func() (map[string][]int32, error) {
+		res := make(map[string][]int32)
+		err := es.List(ctx, digest+"/", "", func(name string) error {
+			name = digest + "/" + strings.TrimPrefix(name, "/")
+			if strings.HasSuffix(name, coldSSTSuffix) {
+				if _, ok := res[name]; !ok {
+					res[name] = nil
+				}
+			} else if objName, id, ok := parseColdRefName(name); ok {
+				res[objName] = append(res[objName], id)
+			}
+			return nil
+		})
+		return res, err
+	}
+	referencedByOthers := func(stores []int32) bool {
+		for _, id := range stores {
+			if id != storeID {
+				return true
+			}
+		}
+		return false
+	}
+	candidates, err := refs()
+	if err != nil {
+		return "", err
+	}
+	for objName, stores := range candidates {
+		if !referencedByOthers(stores) {
+			continue
+		}
+		if objSize, err := es.Size(ctx, objName); err != nil || objSize != size {
+			continue
+		}
+		c.pin(objName)
+		refName := coldRefName(objName, storeID)
+		if err := cloud.WriteFile(ctx, es, refName, bytes.NewReader(nil)); err != nil {
+			c.unpin(objName)
+			return "", err
+		}
+		// The sstable can't be deleted as long as another store references it
+		// after the marker was added.
+		if now, err := refs(); err == nil && referencedByOthers(now[objName]) {
+			return objName, nil
+		}
+		c.unpin(objName)
+		if err := es.Delete(ctx, refName); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// uploadColdSST writes the store's reference marker for the sstable, and then
+// the sstable itself, verifying that its contents have the expected digest.
+func (p *Pebble) uploadColdSST(
+	ctx context.Context,
+	es cloud.ExternalStorage,
+	r Reader,
+	span roachpb.Span,
+	sst ColdSST,
+	digest string,
+) error {
+	if err := cloud.WriteFile(ctx, es, coldRefName(sst.ObjName, sst.storeID), bytes.NewReader(nil)); err != nil {
+		return err
+	}
+	// Cancelling the context before the writer is closed aborts the write.
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := es.Writer(writeCtx, sst.ObjName)
+	if err != nil {
+		return err
+	}
+	hw := &hashWriter{Hash: sha256.New()}
+	ew := &externalStorageWriter{WriteCloser: w, p: p, cold: true}
+	sstWriter := MakeIngestionSSTWriter(ctx, p.settings, &noopFinishAbort{io.MultiWriter(ew, hw)})
+	if err := writeColdSST(r, span, &sstWriter); err != nil {
+		sstWriter.Close()
+		cancel()
+		_ = ew.Close()
+		return err
+	}
+	if hw.digest() != digest {
+		cancel()
+		_ = ew.Close()
+		return errors.AssertionFailedf("contents of cold sstable for %s changed while it was written", span)
+	}
+	return ew.Close()
+}
+
+// makeColdSSTMeta returns the metadata with which an sstable on the cold
+// storage tier is ingested.
+func makeColdSSTMeta(m *sstable.WriterMetadata) pebble.SharedSSTMeta {
+	meta := pebble.SharedSSTMeta{
+		SmallestPointKey: m.SmallestPoint,
+		LargestPointKey:  m.LargestPoint,
+		SmallestRangeKey: m.SmallestRangeKey,
+		LargestRangeKey:  m.LargestRangeKey,
+		// External files are ingested into the bottommost level, where they
+		// are shadowed by all keys subsequently written to the span.
+		Level: 6,
+		Size:  m.Size,
+	}
+	switch {
+	case !m.HasRangeKeys:
+		meta.Smallest, meta.Largest = m.SmallestPoint, m.LargestPoint
+	case !m.HasPointKeys:
+		meta.Smallest, meta.Largest = m.SmallestRangeKey, m.LargestRangeKey
+	default:
+		meta.Smallest, meta.Largest = m.SmallestPoint, m.LargestPoint
+		if EngineComparer.Compare(m.SmallestRangeKey.UserKey, m.SmallestPoint.UserKey) < 0 {
+			meta.Smallest = m.SmallestRangeKey
+		}
+		// NB: the largest range key is an exclusive sentinel, so it sorts after
+		// a point key with the same user key.
+		if EngineComparer.Compare(m.LargestRangeKey.UserKey, m.LargestPoint.UserKey) >= 0 {
+			meta.Largest = m.LargestRangeKey
+		}
+	}
+	return meta
+}
+
+// writeColdSST writes all point and range keys in the span to the sstable.
+func writeColdSST(r Reader, span roachpb.Span, w *SSTWriter) error {
+	for _, keyType := range []IterKeyType{IterKeyTypePointsOnly, IterKeyTypeRangesOnly} {
+		err := func() error {
+			iter := r.NewEngineIterator(IterOptions{
+				KeyTypes:   keyType,
+				LowerBound: span.Key,
+				UpperBound: span.EndKey,
+			})
+			defer iter.Close()
+			ok, err := iter.SeekEngineKeyGE(EngineKey{Key: span.Key})
+			for ; ok && err == nil; ok, err = iter.NextEngineKey() {
+				if keyType == IterKeyTypePointsOnly {
+					key, err := iter.UnsafeEngineKey()
+					if err != nil {
+						return err
+					}
+					v, err := iter.UnsafeValue()
+					if err != nil {
+						return err
+					}
+					if err := w.PutEngineKey(key, v); err != nil {
+						return err
+					}
+					continue
+				}
+				bounds, err := iter.EngineRangeBounds()
+				if err != nil {
+					return err
+				}
+				for _, rkv := range iter.EngineRangeKeys() {
+					if err := w.PutEngineRangeKey(bounds.Key, bounds.EndKey, rkv.Version, rkv.Value); err != nil {
+						return err
+					}
+				}
+			}
+			return err
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return w.Finish()
+}
+
+// IngestColdSST implements the Engine interface.
+func (p *Pebble) IngestColdSST(ctx context.Context, sst ColdSST, span roachpb.Span) error {
+	if sst.Empty() {
+		return nil
+	}
+	if !p.ColdStorageEnabled() {
+		return errors.AssertionFailedf("engine has no cold storage tier")
+	}
+	backing, err := p.db.ObjProvider().CreateExternalObjectBacking(p.coldStorage.locator, sst.ObjName)
+	if err != nil {
+		return err
+	}
+	meta := sst.meta
+	meta.Backing = coldSSTBacking(backing)
+	if _, err := p.db.IngestAndExcise(nil /* paths */, []pebble.SharedSSTMeta{meta}, pebble.KeyRange{
+		Start: EngineKey{Key: span.Key}.Encode(),
+		End:   EngineKey{Key: span.EndKey}.Encode(),
+	}); err != nil {
+		return err
+	}
+	// The sstable is now referenced by Pebble.
+	p.coldStorage.unpin(sst.ObjName)
+	return nil
+}
+
+// ReleaseColdStorage implements the Engine interface.
+func (p *Pebble) ReleaseColdStorage(ctx context.Context, span roachpb.Span) error {
+	if p.coldStorage == nil {
+		return nil
+	}
+	_, _, external, err := p.ApproximateDiskBytes(span.Key, span.EndKey)
+	if err != nil || external == 0 {
+		return err
+	}
+	// The span's keys were deleted, so compacting it drops the virtual sstables
+	// backed by the cold storage tier.
+	if err := p.CompactRange(span.Key, span.EndKey); err != nil {
+		return err
+	}
+	p.maybeCollectColdGarbage()
+	return nil
+}
+
+// maybeCollectColdGarbage asynchronously removes the store's references to
+// sstables on the cold storage tier that are no longer backing any of its
+// sstables, and deletes unreferenced sstables, unless garbage was collected
+// recently. It is called whenever Pebble deletes an sstable, which may have
+// been the last one backed by an sstable on the cold storage tier.
+func (p *Pebble) maybeCollectColdGarbage() {
+	c := p.coldStorage
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := timeutil.Now()
+	if c.mu.stopped || c.mu.gcRunning || now.Sub(c.mu.lastGC) < coldStorageGCInterval {
+		return
+	}
+	c.mu.gcRunning, c.mu.lastGC = true, now
+	// NB: p.async is called with c.mu held, so that it can't race with the
+	// Pebble instance waiting for asynchronous tasks after stopping c.
+	p.async(func() {
+		defer func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.mu.gcRunning = false
+		}()
+		if err := c.collectGarbage(p.logCtx, p.db.ObjProvider()); err != nil {
+			log.Warningf(p.logCtx, "failed to collect garbage on cold storage: %v", err)
+		}
+	})
+}
+
+// stop prevents further garbage collections.
+func (c *coldStorage) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mu.stopped = true
+}
+
+// collectGarbage removes the store's reference markers for the sstables on
+// the cold storage tier that are neither referenced by Pebble nor pinned by an
+// ongoing move, and deletes sstables that were observed without any
+// reference markers for at least coldStorageGCGracePeriod.
+func (c *coldStorage) collectGarbage(ctx context.Context, provider objstorage.Provider) error {
+	storeID := c.storeID()
+	if storeID == 0 {
+		return nil
+	}
+	es, err := c.externalStorage(ctx)
+	if err != nil {
+		return err
+	}
+	// The cold storage tier is listed before the sstables referenced by Pebble
+	// or pinned are, so that any listed sstable that is being moved is
+	// observed as either of them.
+	refs := make(map[string][]int32)
+	if err := es.List(ctx, "", "", func(name string) error {
+		name = strings.TrimPrefix(name, "/")
+		if strings.HasSuffix(name, coldSSTSuffix) {
+			if _, ok := refs[name]; !ok {
+				refs[name] = nil
+			}
+		} else if objName, id, ok := parseColdRefName(name); ok {
+			refs[objName] = append(refs[objName], id)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	inUse := make(map[string]bool)
+	c.mu.Lock()
+	for objName := range c.mu.pinned {
+		inUse[objName] = true
+	}
+	c.mu.Unlock()
+	for _, meta := range provider.List() {
+		if meta.IsExternal() && meta.Remote.Locator == c.locator {
+			inUse[meta.Remote.CustomObjectName] = true
+		}
+	}
+
+	now := timeutil.Now()
+	for objName, stores := range refs {
+		var remaining int
+		for _, id := range stores {
+			if id != storeID || inUse[objName] {
+				remaining++
+				continue
+			}
+			if err := es.Delete(ctx, coldRefName(objName, storeID)); err != nil {
+				return err
+			}
+		}
+		c.mu.Lock()
+		since, ok := c.mu.unreferencedSince[objName]
+		switch {
+		case remaining > 0 || inUse[objName]:
+			delete(c.mu.unreferencedSince, objName)
+		case !ok:
+			if c.mu.unreferencedSince == nil {
+				c.mu.unreferencedSince = make(map[string]time.Time)
+			}
+			c.mu.unreferencedSince[objName] = now
+		}
+		c.mu.Unlock()
+		if remaining > 0 || inUse[objName] || !ok || now.Sub(since) < coldStorageGCGracePeriod {
+			continue
+		}
+		if err := es.Delete(ctx, objName); err != nil && !errors.Is(err, cloud.ErrFileDoesNotExist) {
+			return err
+		}
+		c.mu.Lock()
+		delete(c.mu.unreferencedSince, objName)
+		c.mu.Unlock()
+		log.VEventf(ctx, 2, "deleted unreferenced %s from cold storage", objName)
+	}
+	// Forget about sstables that were deleted by other stores.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for objName := range c.mu.unreferencedSince {
+		if _, ok := refs[objName]; !ok {
+			delete(c.mu.unreferencedSince, objName)
+		}
+	}
+	return nil
+}
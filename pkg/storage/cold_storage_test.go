@@ -0,0 +1,71 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColdRefName(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	refName := coldRefName("abc/123.sst", 7)
+	require.Equal(t, "abc/123.ref.s7", refName)
+	objName, storeID, ok := parseColdRefName(refName)
+	require.True(t, ok)
+	require.Equal(t, "abc/123.sst", objName)
+	require.Equal(t, int32(7), storeID)
+
+	for _, name := range []string{"abc/123.sst", "abc/123.ref.sx", "abc/123.ref.s"} {
+		_, _, ok = parseColdRefName(name)
+		require.False(t, ok, name)
+	}
+}
+
+// TestColdSSTDeterministic verifies that the sstables written for the same
+// data by different engines have the same digest, which lets the replicas of a
+// range share an sstable on the cold storage tier.
+func TestColdSSTDeterministic(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	span := roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")}
+	digest := func(value string) string {
+		eng := NewDefaultInMemForTesting()
+		defer eng.Close()
+		for i, key := range []string{"a", "b", "c"} {
+			require.NoError(t, MVCCPut(ctx, eng, roachpb.Key(key), hlc.Timestamp{WallTime: int64(i + 1)},
+				roachpb.MakeValueFromString(value), MVCCWriteOptions{}))
+		}
+		require.NoError(t, eng.Flush())
+		snap := eng.NewSnapshot()
+		defer snap.Close()
+		hw := &hashWriter{Hash: sha256.New()}
+		w := MakeIngestionSSTWriter(ctx, st, &noopFinishAbort{hw})
+		require.NoError(t, writeColdSST(snap, span, &w))
+		require.Equal(t, int64(w.Meta.Size), hw.size)
+		return hw.digest()
+	}
+	require.Equal(t, digest("foo"), digest("foo"))
+	require.NotEqual(t, digest("foo"), digest("bar"))
+}
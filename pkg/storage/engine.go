@@ -923,6 +923,16 @@ type Engine interface {
 	// GetEnvStats retrieves stats about the engine's environment
 	// For RocksDB, this includes details of at-rest encryption.
 	GetEnvStats() (*EnvStats, error)
+	// GetReencryptionStatus returns the progress of re-encrypting the engine's
+	// sstables after a rotation of the encryption-at-rest store key.
+	GetReencryptionStatus() (ReencryptionStatus, error)
+	// ReencryptNextSSTable rewrites one of the sstables that are still
+	// encrypted with a data key of a retired store key, so that it is encrypted
+	// with the active data key, or replaces the WALs encrypted with one once no
+	// sstable needs to be re-encrypted. It returns the number of bytes written
+	// by the flushes and compactions that ran meanwhile, or zero if no file
+	// needs to be re-encrypted.
+	ReencryptNextSSTable(ctx context.Context) (uint64, error)
 	// GetAuxiliaryDir returns a path under which files can be stored
 	// persistently, and from which data can be ingested by the engine.
 	//
@@ -984,6 +994,28 @@ type Engine interface {
 	// additionally returns ingestion stats.
 	IngestExternalFilesWithStats(
 		ctx context.Context, paths []string) (pebble.IngestOperationStats, error)
+	// ColdStorageEnabled returns true if the engine has a cold storage tier to
+	// which data can be moved with WriteColdSST and IngestColdSST.
+	ColdStorageEnabled() bool
+	// WriteColdSST writes the point and range keys in the given span, as read
+	// from r, to a new sstable on the engine's cold storage tier, or reuses an
+	// sstable with the same contents that another store wrote. r must provide
+	// consistent iterators. If the span is empty, nothing is written and the
+	// returned ColdSST is empty.
+	WriteColdSST(ctx context.Context, r Reader, span roachpb.Span) (ColdSST, error)
+	// IngestColdSST atomically replaces the engine's keys in the given span
+	// with the contents of an sstable written by WriteColdSST for the same
+	// span. Reads of the span are subsequently served from the cold storage
+	// tier, through the engine's cold storage cache. The caller must ensure
+	// that the span was not written to since the Reader passed to WriteColdSST
+	// was created. If the sstable isn't ingested, the caller must release it
+	// with ColdSST.Release.
+	IngestColdSST(ctx context.Context, sst ColdSST, span roachpb.Span) error
+	// ReleaseColdStorage drops the engine's references to sstables on the cold
+	// storage tier that back keys in the given span, which must have been
+	// deleted, e.g. because the replica owning the span was removed. This
+	// compacts the span if any of its sstables are on the cold storage tier.
+	ReleaseColdStorage(ctx context.Context, span roachpb.Span) error
 	// PreIngestDelay offers an engine the chance to backpressure ingestions.
 	// When called, it may choose to block if the engine determines that it is in
 	// or approaching a state where further ingestions may risk its health.
@@ -1124,6 +1156,12 @@ type Metrics struct {
 	SharedStorageWriteBytes int64
 	// SharedStorageReadBytes counts the number of bytes read from shared storage.
 	SharedStorageReadBytes int64
+	// ColdStorageWriteBytes counts the number of bytes written to the cold
+	// storage tier.
+	ColdStorageWriteBytes int64
+	// ColdStorageReadBytes counts the number of bytes read from the cold
+	// storage tier.
+	ColdStorageReadBytes int64
 	// WriteStallCount counts the number of times Pebble intentionally delayed
 	// incoming writes. Currently, the only two reasons for this to happen are:
 	// - "memtable count limit reached"
@@ -1295,6 +1333,12 @@ type EnvStats struct {
 	ActiveKeyFiles uint64
 	// ActiveKeyBytes is the size of files using the active data key.
 	ActiveKeyBytes uint64
+	// RetiredKeyFiles is the number of files using a data key of a retired
+	// store key, which still need to be re-encrypted.
+	RetiredKeyFiles uint64
+	// RetiredKeyBytes is the size of files using a data key of a retired
+	// store key.
+	RetiredKeyBytes uint64
 	// EncryptionType is an enum describing the active encryption algorithm.
 	// See: ccl/storageccl/engineccl/enginepbccl/key_registry.proto
 	EncryptionType int32
@@ -29,7 +29,9 @@ func TestTxnMetaSizeOf(t *testing.T) {
 
 func populatedMVCCValueHeader() MVCCValueHeader {
 	allFieldsSet := MVCCValueHeader{
-		LocalTimestamp: hlc.ClockTimestamp{WallTime: 1, Logical: 1, Synthetic: true},
+		LocalTimestamp:  hlc.ClockTimestamp{WallTime: 1, Logical: 1, Synthetic: true},
+		OriginID:        2,
+		OriginTimestamp: hlc.Timestamp{WallTime: 3, Logical: 3, Synthetic: true},
 	}
 	allFieldsSet.KVNemesisSeq.Set(123)
 	return allFieldsSet
@@ -1799,6 +1799,12 @@ func replayTransactionalWrite(
 // assigned an explicit local timestamp. The effect of this is that
 // readers treat the local timestamp as being equal to the version
 // timestamp.
+//
+// The opts.OriginID and opts.OriginTimestamp parameters, if set, are recorded
+// in the header of the key-value. They identify the cluster that the write was
+// replicated from by logical replication, and the timestamp of the write there.
+// A write with an origin timestamp only replaces a committed value that is
+// less recent; see mvccCheckOriginTimestamp.
 func mvccPutInternal(
 	ctx context.Context,
 	writer Writer,
@@ -2128,6 +2134,11 @@ func mvccPutInternal(
 			writeTooOldErr := kvpb.NewWriteTooOldError(readTimestamp, writeTimestamp, key)
 			return false, writeTooOldErr
 		} else {
+			if !opts.OriginTimestamp.IsEmpty() {
+				if err := mvccCheckOriginTimestamp(ctx, iter, key, readTimestamp, opts.OriginTimestamp); err != nil {
+					return false, err
+				}
+			}
 			if value, err = maybeGetValue(ctx, iter, key, value, ok, readTimestamp, valueFn); err != nil {
 				return false, err
 			}
@@ -2149,6 +2160,8 @@ func mvccPutInternal(
 	versionValue := MVCCValue{}
 	versionValue.Value = value
 	versionValue.LocalTimestamp = opts.LocalTimestamp
+	versionValue.OriginID = opts.OriginID
+	versionValue.OriginTimestamp = opts.OriginTimestamp
 
 	if buildutil.CrdbTestBuild {
 		if seq, seqOK := kvnemesisutil.FromContext(ctx); seqOK {
@@ -3894,12 +3907,52 @@ func buildScanIntents(data []byte) ([]roachpb.Intent, error) {
 	return intents, nil
 }
 
+// mvccCheckOriginTimestamp returns a ConditionFailedError if the committed
+// value of the key is at least as recent as a logically replicated write with
+// the given origin timestamp, which therefore loses to it under last-writer-wins
+// conflict resolution. The recency of a value that was itself replicated is its
+// origin timestamp, rather than the timestamp at which it was applied locally.
+// Ties are resolved in favor of the existing value.
+func mvccCheckOriginTimestamp(
+	ctx context.Context,
+	iter MVCCIterator,
+	key roachpb.Key,
+	readTimestamp hlc.Timestamp,
+	originTimestamp hlc.Timestamp,
+) error {
+	exVal, _, vh, err := mvccGetWithValueHeader(ctx, iter, key, readTimestamp, MVCCGetOptions{
+		Tombstones: true,
+	})
+	if err != nil {
+		return err
+	}
+	if !exVal.exists {
+		return nil
+	}
+	exTimestamp := exVal.Value.Timestamp
+	if !vh.OriginTimestamp.IsEmpty() {
+		exTimestamp = vh.OriginTimestamp
+	}
+	if originTimestamp.LessEq(exTimestamp) {
+		return &kvpb.ConditionFailedError{OriginTimestampOlderThan: exTimestamp}
+	}
+	return nil
+}
+
 // MVCCWriteOptions bundles options for the MVCCPut and MVCCDelete families of functions.
 type MVCCWriteOptions struct {
 	// See the comment on mvccPutInternal for details on these parameters.
 	Txn            *roachpb.Transaction
 	LocalTimestamp hlc.ClockTimestamp
 	Stats          *enginepb.MVCCStats
+	// OriginID, if non-zero, is recorded in the header of the written value to
+	// identify the cluster that the write was logically replicated from.
+	OriginID uint32
+	// OriginTimestamp, if set, is the timestamp of the write on the cluster it
+	// was logically replicated from. It is recorded in the header of the written
+	// value, and the write fails with a ConditionFailedError if the existing
+	// value is at least as recent. See mvccCheckOriginTimestamp.
+	OriginTimestamp hlc.Timestamp
 }
 
 // MVCCScanOptions bundles options for the MVCCScan family of functions.
@@ -5498,6 +5551,152 @@ func MVCCGarbageCollect(
 	return nil
 }
 
+// MVCCGarbageCollectExpired removes all versions of each of the given keys,
+// which the caller has found to have expired under a storage-level TTL. Unlike
+// MVCCGarbageCollect, the newest version may be a live value, so removing it
+// changes the result of reads at every timestamp. Each GCKey's timestamp must
+// be that of the newest version of the key as observed by the caller; a key
+// whose newest version is no longer at that timestamp (because it was written
+// to since), which has an intent or inline value, or which is covered by an
+// MVCC range tombstone (and is thus not live anyway), is left untouched.
+//
+// To keep reads consistent, the newest versions must be below the GC
+// threshold, which rejects reads at the timestamps whose results would change
+// other than by the removal of the key. An error is returned otherwise.
+//
+// The caller must hold write latches on the keys so that the stats adjustment,
+// which is computed from the key's versions before their removal, is not
+// invalidated by concurrent writers.
+//
+// Returns the number of keys that were removed.
+func MVCCGarbageCollectExpired(
+	ctx context.Context,
+	rw ReadWriter,
+	ms *enginepb.MVCCStats,
+	keys []kvpb.GCRequest_GCKey,
+	gcThreshold hlc.Timestamp,
+	timestamp hlc.Timestamp,
+) (int64, error) {
+	var count int64
+	defer func(begin time.Time) {
+		log.Eventf(ctx, "done with expiration GC evaluation for %d keys at %.2f keys/sec. Expired %d keys",
+			len(keys), float64(len(keys))*1e9/float64(timeutil.Since(begin)), count)
+	}(timeutil.Now())
+
+	for _, gcKey := range keys {
+		if gcThreshold.LessEq(gcKey.Timestamp) {
+			return count, errors.Errorf("attempt to expire key %s at %s not below GC threshold %s",
+				gcKey.Key, gcKey.Timestamp, gcThreshold)
+		}
+		removed, err := mvccGarbageCollectExpiredKey(rw, ms, gcKey, timestamp)
+		if err != nil {
+			return count, err
+		}
+		if removed {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// errNotExpired is returned by the visitors of mvccGarbageCollectExpiredKey to
+// stop the scan of a key that mustn't be removed.
+var errNotExpired = errors.New("key has not expired")
+
+func mvccGarbageCollectExpiredKey(
+	rw ReadWriter, ms *enginepb.MVCCStats, gcKey kvpb.GCRequest_GCKey, timestamp hlc.Timestamp,
+) (bool, error) {
+	// The versions of the key are removed as they're visited by the stats
+	// computation, which thus yields the stats of everything that was removed.
+	// The first point key is either the intent (or inline) metadata, which
+	// makes the key ineligible, or the newest version, which must still be the
+	// one the caller observed. Any range key is visited before the point keys,
+	// so nothing has been removed when either of these checks fails.
+	first := true
+	removed, err := ComputeStatsWithVisitors(rw, gcKey.Key, gcKey.Key.Next(), timestamp.WallTime,
+		func(key MVCCKey, value []byte) error {
+			if first {
+				first = false
+				if !key.IsValue() || !key.Timestamp.Equal(gcKey.Timestamp) {
+					return errNotExpired
+				}
+			}
+			return rw.ClearMVCC(key, ClearOptions{
+				ValueSizeKnown: true,
+				ValueSize:      uint32(len(value)),
+			})
+		},
+		func(MVCCRangeKeyValue) error {
+			return errNotExpired
+		},
+	)
+	if errors.Is(err, errNotExpired) || (err == nil && first) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if ms != nil {
+		ms.Subtract(removed)
+	}
+	return true, nil
+}
+
+// MVCCGarbageCollectExpiredRange removes all versions of all keys in the span
+// [start, end), which the caller has found to have expired under a
+// storage-level TTL, using a Pebble range tombstone. This lets compactions drop
+// the expired data without a point tombstone having to be written for each of
+// its versions.
+//
+// The newest versions of all the keys must be committed values or tombstones
+// below the GC threshold, and the span must not contain MVCC range keys;
+// otherwise, an error is returned and nothing is removed. See
+// MVCCGarbageCollectExpired.
+func MVCCGarbageCollectExpiredRange(
+	ctx context.Context,
+	rw ReadWriter,
+	ms *enginepb.MVCCStats,
+	start, end roachpb.Key,
+	gcThreshold hlc.Timestamp,
+	timestamp hlc.Timestamp,
+) error {
+	var numKeys int64
+	defer func(begin time.Time) {
+		log.Eventf(ctx, "done with expiration GC evaluation for clear range of %d keys at %.2f keys/sec",
+			numKeys, float64(numKeys)*1e9/float64(timeutil.Since(begin)))
+	}(timeutil.Now())
+
+	var prevKey roachpb.Key
+	removed, err := ComputeStatsWithVisitors(rw, start, end, timestamp.WallTime,
+		func(key MVCCKey, _ []byte) error {
+			if key.Key.Equal(prevKey) {
+				return nil
+			}
+			prevKey = append(prevKey[:0], key.Key...)
+			numKeys++
+			if !key.IsValue() {
+				return errors.Errorf("attempt to expire key %s with intent or inline value", key.Key)
+			}
+			if gcThreshold.LessEq(key.Timestamp) {
+				return errors.Errorf("attempt to expire key %s not below GC threshold %s", key, gcThreshold)
+			}
+			return nil
+		},
+		func(rkv MVCCRangeKeyValue) error {
+			return errors.Errorf("attempt to expire keys covered by range key %s", rkv.RangeKey)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if err := rw.ClearMVCCVersions(MVCCKey{Key: start}, MVCCKey{Key: end}); err != nil {
+		return err
+	}
+	if ms != nil {
+		ms.Subtract(removed)
+	}
+	return nil
+}
+
 // CollectableGCRangeKey is a struct containing range key as well as span
 // boundaries locked for particular range key.
 // Range GC needs a latch span as it needs to expand iteration beyond the
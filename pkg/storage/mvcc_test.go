@@ -345,6 +345,59 @@ func TestMVCCGetWithValueHeader(t *testing.T) {
 	}
 }
 
+// TestMVCCPutWithOriginTimestamp tests that logically replicated writes record
+// their origin in the value header, and only replace less recent values.
+func TestMVCCPutWithOriginTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := NewDefaultInMemForTesting()
+	defer engine.Close()
+
+	// A local write at 5.
+	require.NoError(t, MVCCPut(ctx, engine, testKey1, hlc.Timestamp{WallTime: 5}, value1, MVCCWriteOptions{}))
+
+	// A replicated write that originated at 4 loses to it.
+	err := MVCCPut(ctx, engine, testKey1, hlc.Timestamp{WallTime: 6}, value2, MVCCWriteOptions{
+		OriginID: 1, OriginTimestamp: hlc.Timestamp{WallTime: 4},
+	})
+	var cErr *kvpb.ConditionFailedError
+	require.ErrorAs(t, err, &cErr)
+	require.Equal(t, hlc.Timestamp{WallTime: 5}, cErr.OriginTimestampOlderThan)
+
+	// A replicated write that originated at 7 replaces it, and records its
+	// origin.
+	require.NoError(t, MVCCPut(ctx, engine, testKey1, hlc.Timestamp{WallTime: 8}, value2, MVCCWriteOptions{
+		OriginID: 1, OriginTimestamp: hlc.Timestamp{WallTime: 7},
+	}))
+	valueRes, vh, err := MVCCGetWithValueHeader(ctx, engine, testKey1, hlc.Timestamp{WallTime: 9}, MVCCGetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, value2.RawBytes, valueRes.Value.RawBytes)
+	require.Equal(t, uint32(1), vh.OriginID)
+	require.Equal(t, hlc.Timestamp{WallTime: 7}, vh.OriginTimestamp)
+
+	// The recency of the replicated value is its origin timestamp rather than the
+	// timestamp it was written at, so a replicated write that originated between
+	// the two replaces it.
+	_, err = MVCCDelete(ctx, engine, testKey1, hlc.Timestamp{WallTime: 10}, MVCCWriteOptions{
+		OriginID: 1, OriginTimestamp: hlc.Timestamp{WallTime: 7, Logical: 1},
+	})
+	require.NoError(t, err)
+
+	// Tombstones take part in conflict resolution too.
+	err = MVCCPut(ctx, engine, testKey1, hlc.Timestamp{WallTime: 11}, value3, MVCCWriteOptions{
+		OriginID: 1, OriginTimestamp: hlc.Timestamp{WallTime: 7},
+	})
+	require.ErrorAs(t, err, &cErr)
+	require.Equal(t, hlc.Timestamp{WallTime: 7, Logical: 1}, cErr.OriginTimestampOlderThan)
+
+	// Writes to keys without values always succeed.
+	require.NoError(t, MVCCPut(ctx, engine, testKey2, hlc.Timestamp{WallTime: 12}, value3, MVCCWriteOptions{
+		OriginID: 1, OriginTimestamp: hlc.Timestamp{WallTime: 1},
+	}))
+}
+
 // TestMVCCWriteWithOlderTimestampAfterDeletionOfNonexistentKey tests a write
 // that comes after a delete on a nonexistent key, with the write holding a
 // timestamp earlier than the delete timestamp. The delete must write a
@@ -4876,6 +4929,128 @@ func TestMVCCGarbageCollect(t *testing.T) {
 	require.NoError(t, engine.Compact())
 }
 
+// TestMVCCGarbageCollectExpired verifies that MVCCGarbageCollectExpired
+// removes all versions of keys whose newest version is the expected one, and
+// leaves keys that were written to since, have intents or are covered by range
+// tombstones untouched. It also verifies that MVCCGarbageCollectExpiredRange
+// only clears spans in which all keys have expired.
+func TestMVCCGarbageCollectExpired(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := NewDefaultInMemForTesting()
+	defer engine.Close()
+
+	ms := &enginepb.MVCCStats{}
+	ts1 := hlc.Timestamp{WallTime: 1e9}
+	ts2 := hlc.Timestamp{WallTime: 2e9}
+	ts3 := hlc.Timestamp{WallTime: 3e9}
+	now := hlc.Timestamp{WallTime: 4e9}
+	val := roachpb.MakeValueFromString("value")
+	put := func(key string, ts hlc.Timestamp, txn *roachpb.Transaction) {
+		require.NoError(t, MVCCPut(ctx, engine, roachpb.Key(key), ts, val,
+			MVCCWriteOptions{Txn: txn, Stats: ms}))
+	}
+
+	put("a", ts1, nil)
+	put("a", ts2, nil)
+	put("b", ts1, nil)
+	put("b", ts3, nil)
+	put("c-del", ts1, nil)
+	_, err := MVCCDelete(ctx, engine, roachpb.Key("c-del"), ts2, MVCCWriteOptions{Stats: ms})
+	require.NoError(t, err)
+	put("d", ts1, nil)
+	put("d", ts2, &roachpb.Transaction{
+		TxnMeta:       enginepb.TxnMeta{ID: uuid.MakeV4(), WriteTimestamp: ts2},
+		ReadTimestamp: ts2,
+	})
+	put("f-1", ts1, nil)
+	put("f-1", ts2, nil)
+	put("f-2", ts1, nil)
+	put("r-1", ts1, nil)
+	require.NoError(t, MVCCDeleteRangeUsingTombstone(ctx, engine, ms, roachpb.Key("r"),
+		roachpb.Key("s"), ts2, hlc.ClockTimestamp{}, nil, nil, false, 0, nil))
+
+	// Keys at or above the GC threshold can't be expired.
+	_, err = MVCCGarbageCollectExpired(ctx, engine, ms, []kvpb.GCRequest_GCKey{
+		{Key: roachpb.Key("b"), Timestamp: ts3},
+	}, ts3, now)
+	require.ErrorContains(t, err, "not below GC threshold")
+
+	expired, err := MVCCGarbageCollectExpired(ctx, engine, ms, []kvpb.GCRequest_GCKey{
+		{Key: roachpb.Key("a"), Timestamp: ts2},
+		// The key was written to after it was found to have expired.
+		{Key: roachpb.Key("b"), Timestamp: ts1},
+		{Key: roachpb.Key("c-del"), Timestamp: ts2},
+		// The key has an intent.
+		{Key: roachpb.Key("d"), Timestamp: ts1},
+		// The key doesn't exist.
+		{Key: roachpb.Key("e"), Timestamp: ts1},
+		// The key is covered by an MVCC range tombstone.
+		{Key: roachpb.Key("r-1"), Timestamp: ts1},
+	}, ts3, now)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, expired)
+
+	for _, tc := range []struct {
+		start, end string
+		expErr     string
+	}{
+		{"b", "c", "not below GC threshold"},
+		{"d", "e", "with intent or inline value"},
+		{"r", "s", "covered by range key"},
+		{"f", "g", ""},
+	} {
+		err := MVCCGarbageCollectExpiredRange(ctx, engine, ms,
+			roachpb.Key(tc.start), roachpb.Key(tc.end), ts3, now)
+		if tc.expErr == "" {
+			require.NoError(t, err)
+		} else {
+			require.ErrorContains(t, err, tc.expErr)
+		}
+	}
+
+	numVersions := func(key string) int {
+		iter := engine.NewMVCCIterator(MVCCKeyAndIntentsIterKind, IterOptions{
+			LowerBound: roachpb.Key(key),
+			UpperBound: roachpb.Key(key).Next(),
+			KeyTypes:   IterKeyTypePointsOnly,
+		})
+		defer iter.Close()
+		var n int
+		for iter.SeekGE(MakeMVCCMetadataKey(roachpb.Key(key))); ; iter.Next() {
+			ok, err := iter.Valid()
+			require.NoError(t, err)
+			if !ok {
+				break
+			}
+			n++
+		}
+		return n
+	}
+	for key, exp := range map[string]int{
+		"a":     0,
+		"b":     2,
+		"c-del": 0,
+		"d":     3, // intent metadata and two versions
+		"f-1":   0,
+		"f-2":   0,
+		"r-1":   1,
+	} {
+		require.Equal(t, exp, numVersions(key), "key %s", key)
+	}
+
+	// Verify aggregated stats match computed stats after expiration.
+	expMS, err := ComputeStats(engine, localMax, roachpb.KeyMax, now.WallTime)
+	require.NoError(t, err)
+	assertEq(t, engine, "verification", ms, &expMS)
+
+	// Compact the engine; the ForTesting() config option will assert that all
+	// DELSIZED tombstones were appropriately sized.
+	require.NoError(t, engine.Compact())
+}
+
 // TestMVCCGarbageCollectNonDeleted verifies that the first value for
 // a key cannot be GC'd if it's not deleted.
 func TestMVCCGarbageCollectNonDeleted(t *testing.T) {
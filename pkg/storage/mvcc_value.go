@@ -131,8 +131,17 @@ func (v MVCCValue) String() string {
 func (v MVCCValue) SafeFormat(w redact.SafePrinter, _ rune) {
 	if v.MVCCValueHeader != (enginepb.MVCCValueHeader{}) {
 		w.Printf("{")
+		var sep redact.SafeString
 		if !v.LocalTimestamp.IsEmpty() {
 			w.Printf("localTs=%s", v.LocalTimestamp)
+			sep = " "
+		}
+		if v.OriginID != 0 {
+			w.Printf("%soriginID=%d", sep, v.OriginID)
+			sep = " "
+		}
+		if !v.OriginTimestamp.IsEmpty() {
+			w.Printf("%soriginTs=%s", sep, v.OriginTimestamp)
 		}
 		w.Printf("}")
 	}
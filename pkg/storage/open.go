@@ -185,6 +185,18 @@ func SharedStorage(sharedStorage cloud.ExternalStorage) ConfigOption {
 	}
 }
 
+// ColdStorage configures a cold storage tier, identified by the URI of an
+// external storage, to which cold spans can be moved. Blocks read from the
+// cold storage tier are cached in an on-disk cache of the given size. Requires
+// RemoteStorageFactory.
+func ColdStorage(uri string, cacheSize int64) ConfigOption {
+	return func(cfg *engineConfig) error {
+		cfg.ColdStorageURI = uri
+		cfg.ColdStorageCacheSize = cacheSize
+		return nil
+	}
+}
+
 // RemoteStorageFactory enables use of remote storage (experimental).
 func RemoteStorageFactory(accessor *cloud.ExternalStorageAccessor) ConfigOption {
 	return func(cfg *engineConfig) error {
@@ -720,6 +720,13 @@ type PebbleConfig struct {
 	// RemoteStorageFactory is used to pass the ExternalStorage factory.
 	RemoteStorageFactory *cloud.ExternalStorageAccessor
 
+	// ColdStorageURI, if set, is the URI of the external storage used as this
+	// store's cold storage tier. Requires RemoteStorageFactory.
+	ColdStorageURI string
+	// ColdStorageCacheSize is the size of the on-disk cache of blocks read from
+	// the cold storage tier.
+	ColdStorageCacheSize int64
+
 	// onClose is a slice of functions to be invoked before the engine is closed.
 	onClose []func(*Pebble)
 }
@@ -736,6 +743,10 @@ type EncryptionStatsHandler interface {
 	GetActiveStoreKeyType() int32
 	// Returns the KeyID embedded in the serialized EncryptionSettings.
 	GetKeyIDFromSettings(settings []byte) (string, error)
+	// Returns whether files encrypted with the given data key ID, as returned
+	// by GetKeyIDFromSettings, need to be re-encrypted because the data key was
+	// generated under a store key other than the active one.
+	IsDataKeyRetired(keyID string) bool
 }
 
 // Pebble is a wrapper around a Pebble database instance.
@@ -778,6 +789,8 @@ type Pebble struct {
 	diskStallCount       int64
 	sharedBytesRead      int64
 	sharedBytesWritten   int64
+	coldBytesRead        int64
+	coldBytesWritten     int64
 	iterStats            struct {
 		syncutil.Mutex
 		AggregatedIteratorStats
@@ -811,6 +824,9 @@ type Pebble struct {
 
 	storeIDPebbleLog *base.StoreIDContainer
 	replayer         *replay.WorkloadCollector
+
+	// coldStorage is set if the engine has a cold storage tier.
+	coldStorage *coldStorage
 }
 
 // WorkloadCollector implements an workloadCollectorGetter and returns the
@@ -892,6 +908,9 @@ type remoteStorageAdaptor struct {
 }
 
 func (r remoteStorageAdaptor) CreateStorage(locator remote.Locator) (remote.Storage, error) {
+	if r.p.coldStorage != nil && locator == r.p.coldStorage.locator {
+		return coldRemoteStorage{p: r.p, ctx: r.ctx}, nil
+	}
 	es, err := r.factory.OpenURL(r.ctx, string(locator), username.SQLUsername{})
 	return &externalStorageWrapper{p: r.p, ctx: r.ctx, es: es}, err
 }
@@ -1159,6 +1178,24 @@ func NewPebble(ctx context.Context, cfg PebbleConfig) (p *Pebble, err error) {
 		opts.Experimental.RemoteStorage = remoteStorageAdaptor{p: p, ctx: ctx, factory: cfg.RemoteStorageFactory}
 	}
 
+	if cfg.ColdStorageURI != "" {
+		if cfg.RemoteStorageFactory == nil {
+			return nil, errors.New("cold storage requires a remote storage factory")
+		}
+		p.coldStorage = &coldStorage{
+			locator: remote.Locator(cfg.ColdStorageURI),
+			factory: cfg.RemoteStorageFactory,
+			storeID: func() int32 {
+				storeID, err := p.GetStoreID()
+				if err != nil {
+					return 0
+				}
+				return storeID
+			},
+		}
+		opts.Experimental.SecondaryCacheSize = cfg.ColdStorageCacheSize
+	}
+
 	// Read the current store cluster version.
 	storeClusterVersion, minVerFileExists, err := getMinVersion(unencryptedFS, cfg.Dir)
 	if err != nil {
@@ -1335,6 +1372,13 @@ func (p *Pebble) makeMetricEtcEventListener(ctx context.Context) pebble.EventLis
 				cb()
 			}
 		},
+		TableDeleted: func(info pebble.TableDeleteInfo) {
+			// The table may have been the last one backed by an sstable on the
+			// cold storage tier.
+			if info.Err == nil && p.coldStorage != nil {
+				p.maybeCollectColdGarbage()
+			}
+		},
 	}
 }
 
@@ -1361,6 +1405,9 @@ func (p *Pebble) Close() {
 	}
 
 	p.closed = true
+	if p.coldStorage != nil {
+		p.coldStorage.stop()
+	}
 
 	// Wait for any asynchronous goroutines to exit.
 	p.asyncDone.Wait()
@@ -1385,6 +1432,9 @@ func (p *Pebble) Close() {
 	}
 
 	handleErr(p.db.Close())
+	if p.coldStorage != nil {
+		handleErr(p.coldStorage.close())
+	}
 	if p.fileRegistry != nil {
 		handleErr(p.fileRegistry.Close())
 	}
@@ -1867,6 +1917,8 @@ func (p *Pebble) GetMetrics() Metrics {
 		DiskStallCount:          atomic.LoadInt64(&p.diskStallCount),
 		SharedStorageReadBytes:  atomic.LoadInt64(&p.sharedBytesRead),
 		SharedStorageWriteBytes: atomic.LoadInt64(&p.sharedBytesWritten),
+		ColdStorageReadBytes:    atomic.LoadInt64(&p.coldBytesRead),
+		ColdStorageWriteBytes:   atomic.LoadInt64(&p.coldBytesWritten),
 	}
 	p.iterStats.Lock()
 	m.Iterator = p.iterStats.AggregatedIteratorStats
@@ -1961,6 +2013,13 @@ func (p *Pebble) GetEnvStats() (*EnvStats, error) {
 		stats.ActiveKeyBytes += sstSizes[pebble.FileNum(u)]
 	}
 
+	_, _, reencryptionStatus, err := p.retiredKeyFiles()
+	if err != nil {
+		return nil, err
+	}
+	stats.RetiredKeyFiles = reencryptionStatus.RetiredKeyFiles
+	stats.RetiredKeyBytes = reencryptionStatus.RetiredKeyBytes
+
 	// Ensure that encryption percentage does not exceed 100%.
 	frFileLen := uint64(len(fr.Files))
 	if stats.TotalFiles < frFileLen {
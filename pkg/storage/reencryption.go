@@ -0,0 +1,244 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/pebble"
+)
+
+// ReencryptionStatus describes the progress of re-encrypting the files of an
+// engine after a rotation of the encryption-at-rest store key. Rotating the
+// store key only affects newly written files, so files written before the
+// rotation remain encrypted with data keys generated under the retired store
+// key until they are rewritten.
+type ReencryptionStatus struct {
+	// TotalFiles and TotalBytes are the number and size of the files stored
+	// on local disk: the sstables, and the other files in the file registry,
+	// such as the WALs, the MANIFEST and OPTIONS files, and the files in the
+	// auxiliary directory, including sideloaded sstables.
+	TotalFiles uint64
+	TotalBytes uint64
+	// RetiredKeyFiles and RetiredKeyBytes are the number and size of the files
+	// encrypted with a data key of a retired store key.
+	RetiredKeyFiles uint64
+	RetiredKeyBytes uint64
+	// UnrewritableFiles are the paths, relative to the engine directory, of the
+	// files encrypted with a data key of a retired store key that the engine
+	// can't rewrite while it is running. The MANIFEST and OPTIONS files are
+	// rewritten when the engine is restarted, sideloaded sstables are removed
+	// when the raft log is truncated, and other auxiliary files, such as
+	// checkpoints, are only removed by their owner.
+	UnrewritableFiles []string
+}
+
+// FullyRotated returns true if no file is encrypted with a data key of a
+// retired store key.
+func (s ReencryptionStatus) FullyRotated() bool {
+	return s.RetiredKeyFiles == 0
+}
+
+// retiredKeyFiles returns the sstables encrypted with a data key of a retired
+// store key, whether any WAL is encrypted with one, and the re-encryption
+// status. Like GetEnvStats, it walks the whole file registry, so the status
+// also covers the files that aren't sstables. Sstables on remote storage are
+// not encrypted by the engine, and are ignored.
+func (p *Pebble) retiredKeyFiles() (
+	ssts []pebble.SSTableInfo,
+	retiredWAL bool,
+	status ReencryptionStatus,
+	err error,
+) {
+	if p.encryption == nil {
+		return nil, false, status, nil
+	}
+	isRetired := func(entry *enginepb.FileEntry) (bool, error) {
+		var keyID string
+		if entry != nil {
+			var err error
+			if keyID, err = p.encryption.StatsHandler.GetKeyIDFromSettings(entry.EncryptionSettings); err != nil {
+				return false, err
+			}
+		}
+		return p.encryption.StatsHandler.IsDataKeyRetired(keyID), nil
+	}
+
+	sstInfos, err := p.db.SSTables()
+	if err != nil {
+		return nil, false, status, err
+	}
+	// Virtual sstables share their backing sstable, which is the file that is
+	// encrypted. Sstables written before encryption was enabled are not in the
+	// file registry, so they are found through the LSM.
+	live := make(map[string]struct{})
+	for _, levelSSTs := range sstInfos {
+		for _, sst := range levelSSTs {
+			if sst.BackingType != pebble.BackingTypeLocal {
+				continue
+			}
+			name := sst.BackingSSTNum.String() + ".sst"
+			if _, ok := live[name]; ok {
+				continue
+			}
+			live[name] = struct{}{}
+			status.TotalFiles++
+			status.TotalBytes += sst.Size
+
+			retired, err := isRetired(p.fileRegistry.GetFileEntry(p.FS.PathJoin(p.path, name)))
+			if err != nil {
+				return nil, false, status, err
+			}
+			if !retired {
+				continue
+			}
+			status.RetiredKeyFiles++
+			status.RetiredKeyBytes += sst.Size
+			ssts = append(ssts, sst)
+		}
+	}
+
+	for filename, entry := range p.fileRegistry.getRegistryCopy().Files {
+		if _, ok := live[filename]; ok {
+			continue
+		}
+		path := filename
+		if !filepath.IsAbs(path) {
+			path = p.FS.PathJoin(p.path, filename)
+		}
+		info, err := p.FS.Stat(path)
+		if oserror.IsNotExist(err) {
+			// The registry may contain dangling entries until it is reloaded.
+			continue
+		} else if err != nil {
+			return nil, false, status, err
+		}
+		status.TotalFiles++
+		status.TotalBytes += uint64(info.Size())
+
+		if info.Size() == 0 {
+			// Empty files, such as Pebble's marker files, hold no data
+			// encrypted with their data key.
+			continue
+		}
+		retired, err := isRetired(entry)
+		if err != nil {
+			return nil, false, status, err
+		}
+		if !retired {
+			continue
+		}
+		status.RetiredKeyFiles++
+		status.RetiredKeyBytes += uint64(info.Size())
+		switch {
+		case strings.HasPrefix(filename, base.AuxiliaryDir):
+			status.UnrewritableFiles = append(status.UnrewritableFiles, filename)
+		case strings.HasSuffix(filename, ".log"):
+			// Live and recycled WALs are replaced by flushing the memtable.
+			retiredWAL = true
+		case strings.HasSuffix(filename, ".sst"):
+			// Obsolete sstables are removed once they are no longer
+			// referenced.
+		default:
+			status.UnrewritableFiles = append(status.UnrewritableFiles, filename)
+		}
+	}
+	sort.Strings(status.UnrewritableFiles)
+	return ssts, retiredWAL, status, nil
+}
+
+// GetReencryptionStatus implements the Engine interface.
+func (p *Pebble) GetReencryptionStatus() (ReencryptionStatus, error) {
+	_, _, status, err := p.retiredKeyFiles()
+	return status, err
+}
+
+// ReencryptNextSSTable implements the Engine interface.
+//
+// Pebble does not support rewriting a single sstable, so the key range of the
+// sstable is compacted instead. A manual compaction rewrites every sstable that
+// overlaps the key range, at all levels, so it can write considerably more
+// than the size of the retired sstable. Once no sstable needs to be
+// re-encrypted, WALs encrypted with a retired data key are replaced by
+// flushing the memtable, which switches to a new WAL. The returned byte count
+// is the number of bytes written by flushes and compactions while the manual
+// compaction or flush ran, which callers use to pace re-encryption. It may
+// include bytes written by concurrent background compactions, which only makes
+// the pacing more conservative.
+func (p *Pebble) ReencryptNextSSTable(ctx context.Context) (uint64, error) {
+	if p.readOnly {
+		return 0, nil
+	}
+	retired, retiredWAL, _, err := p.retiredKeyFiles()
+	if err != nil {
+		return 0, err
+	}
+	if len(retired) == 0 {
+		if !retiredWAL {
+			return 0, nil
+		}
+		log.VEventf(ctx, 2, "re-encrypting WALs")
+		before := p.bytesWrittenByCompactions()
+		if err := p.db.Flush(); err != nil {
+			return 0, err
+		}
+		return p.bytesWrittenByCompactions() - before, nil
+	}
+	// Rewrite the oldest sstable first.
+	sst := retired[0]
+	for _, s := range retired[1:] {
+		if s.LargestSeqNum < sst.LargestSeqNum {
+			sst = s
+		}
+	}
+	start, ok := DecodeEngineKey(sst.Smallest.UserKey)
+	if !ok {
+		return 0, errors.Errorf("invalid smallest key %x of sstable %s", sst.Smallest.UserKey, sst.FileNum)
+	}
+	end, ok := DecodeEngineKey(sst.Largest.UserKey)
+	if !ok {
+		return 0, errors.Errorf("invalid largest key %x of sstable %s", sst.Largest.UserKey, sst.FileNum)
+	}
+	log.VEventf(ctx, 2, "re-encrypting sstable %s (%s) in [%s, %s]",
+		sst.FileNum, humanizeutil.IBytes(int64(sst.Size)), start.Key, end.Key)
+	before := p.bytesWrittenByCompactions()
+	// NB: the sstable may contain versions of its largest key, which sort after
+	// the bare key, so the end of the compacted range is the next key.
+	if err := p.db.Compact(
+		EngineKey{Key: start.Key}.Encode(), EngineKey{Key: end.Key.Next()}.Encode(), false, /* parallelize */
+	); err != nil {
+		return 0, err
+	}
+	written := p.bytesWrittenByCompactions() - before
+	log.VEventf(ctx, 2, "re-encrypted sstable %s, compaction wrote %s",
+		sst.FileNum, humanizeutil.IBytes(int64(written)))
+	return written, nil
+}
+
+// bytesWrittenByCompactions returns the cumulative number of bytes written by
+// flushes and compactions across all levels.
+func (p *Pebble) bytesWrittenByCompactions() uint64 {
+	m := p.db.Metrics()
+	var n uint64
+	for i := range m.Levels {
+		n += m.Levels[i].BytesFlushed + m.Levels[i].BytesCompacted
+	}
+	return n
+}
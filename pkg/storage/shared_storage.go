@@ -31,6 +31,9 @@ type externalStorageReader struct {
 	p       *Pebble
 	es      cloud.ExternalStorage
 	objName string
+	// cold is set if the object lives on the Pebble instance's cold storage
+	// tier, whose reads are tracked separately.
+	cold bool
 }
 
 var _ remote.ObjectReader = (*externalStorageReader)(nil)
@@ -55,7 +58,11 @@ func (r *externalStorageReader) ReadAt(ctx context.Context, p []byte, offset int
 		}
 		n += nn
 	}
-	atomic.AddInt64(&r.p.sharedBytesRead, int64(len(p)))
+	if r.cold {
+		atomic.AddInt64(&r.p.coldBytesRead, int64(len(p)))
+	} else {
+		atomic.AddInt64(&r.p.sharedBytesRead, int64(len(p)))
+	}
 	return nil
 }
 
@@ -76,6 +83,9 @@ type externalStorageWriter struct {
 	// TODO(bilal): Refactor the metrics out of Pebble, and store a reference
 	// to just the Metrics struct.
 	p *Pebble
+	// cold is set if the object is written to the Pebble instance's cold
+	// storage tier, whose writes are tracked separately.
+	cold bool
 }
 
 var _ io.WriteCloser = &externalStorageWriter{}
@@ -83,7 +93,11 @@ var _ io.WriteCloser = &externalStorageWriter{}
 // Write implements the io.Writer interface.
 func (e *externalStorageWriter) Write(p []byte) (n int, err error) {
 	n, err = e.WriteCloser.Write(p)
-	atomic.AddInt64(&e.p.sharedBytesWritten, int64(n))
+	if e.cold {
+		atomic.AddInt64(&e.p.coldBytesWritten, int64(n))
+	} else {
+		atomic.AddInt64(&e.p.sharedBytesWritten, int64(n))
+	}
 	return n, err
 }
 
@@ -94,6 +108,8 @@ type externalStorageWrapper struct {
 	p   *Pebble
 	es  cloud.ExternalStorage
 	ctx context.Context
+	// cold is set if es is the Pebble instance's cold storage tier.
+	cold bool
 }
 
 // MakeExternalStorageWrapper returns a remote.Storage implementation that wraps
@@ -121,13 +137,14 @@ func (e *externalStorageWrapper) ReadObject(
 		p:       e.p,
 		es:      e.es,
 		objName: objName,
+		cold:    e.cold,
 	}, objSize, nil
 }
 
 // CreateObject implements the remote.Storage interface.
 func (e *externalStorageWrapper) CreateObject(objName string) (io.WriteCloser, error) {
 	writer, err := e.es.Writer(e.ctx, objName)
-	return &externalStorageWriter{WriteCloser: writer, p: e.p}, err
+	return &externalStorageWriter{WriteCloser: writer, p: e.p, cold: e.cold}, err
 }
 
 // List implements the remote.Storage interface.
@@ -0,0 +1,412 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tsprom
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/server/apiutil"
+	"github.com/cockroachdb/cockroach/pkg/ts"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// URLPrefix is the prefix of the Prometheus HTTP API endpoints. It is the URL
+// to configure a Prometheus data source in Grafana with.
+const URLPrefix = "/ts/prom/"
+
+// maxPoints is the maximum number of steps of a range query, as with
+// Prometheus.
+const maxPoints = 11000
+
+// Handler serves the query endpoints of the Prometheus HTTP API, evaluating a
+// subset of PromQL against the internal time series database. See evaluator
+// for the supported subset.
+type Handler struct {
+	querier  Querier
+	metadata MetadataFunc
+	mux      *http.ServeMux
+}
+
+// NewHandler returns a handler of the Prometheus HTTP API under URLPrefix,
+// querying the given querier for the metrics with the given metadata.
+func NewHandler(querier Querier, metadata MetadataFunc) *Handler {
+	h := &Handler{
+		querier:  querier,
+		metadata: metadata,
+		mux:      http.NewServeMux(),
+	}
+	h.mux.HandleFunc(URLPrefix+"api/v1/query", h.handleQuery)
+	h.mux.HandleFunc(URLPrefix+"api/v1/query_range", h.handleQueryRange)
+	h.mux.HandleFunc(URLPrefix+"api/v1/series", h.handleSeries)
+	h.mux.HandleFunc(URLPrefix+"api/v1/labels", h.handleLabels)
+	h.mux.HandleFunc(URLPrefix+"api/v1/label/", h.handleLabelValues)
+	return h
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The parameters may be given in the URL or, for POST requests, in the
+	// body.
+	if err := r.ParseForm(); err != nil {
+		writeError(r.Context(), w, errors.Mark(err, errBadData))
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// response is the envelope of the Prometheus HTTP API responses.
+type response struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type queryData struct {
+	ResultType string      `json:"resultType"`
+	Result     interface{} `json:"result"`
+}
+
+type vectorSample struct {
+	Metric labels.Labels `json:"metric"`
+	Value  point         `json:"value"`
+}
+
+type matrixSeries struct {
+	Metric labels.Labels `json:"metric"`
+	Values []point       `json:"values"`
+}
+
+// point is a value at a timestamp in nanos, encoded as a pair of the
+// timestamp in seconds and the formatted value.
+type point struct {
+	t int64
+	v float64
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p point) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{
+		float64(p.t) / float64(time.Second),
+		strconv.FormatFloat(p.v, 'f', -1, 64),
+	})
+}
+
+func writeData(ctx context.Context, w http.ResponseWriter, data interface{}) {
+	apiutil.WriteJSONResponse(ctx, w, http.StatusOK, response{Status: "success", Data: data})
+}
+
+func writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	code, errorType := http.StatusUnprocessableEntity, "execution"
+	if errors.Is(err, errBadData) {
+		code, errorType = http.StatusBadRequest, "bad_data"
+	}
+	apiutil.WriteJSONResponse(ctx, w, code, response{
+		Status:    "error",
+		ErrorType: errorType,
+		Error:     err.Error(),
+	})
+}
+
+func (h *Handler) handleQuery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	t, err := parseTime(r.FormValue("time"), timeutil.Now())
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	e := &evaluator{
+		querier: h.querier,
+		idx:     makeIndex(h.metadata()),
+		start:   t.UnixNano(),
+		end:     t.UnixNano(),
+		step:    ts.Resolution10s.SampleDuration(),
+	}
+	v, err := evalQuery(ctx, e, r.FormValue("query"))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	switch v := v.(type) {
+	case scalar:
+		writeData(ctx, w, queryData{ResultType: "scalar", Result: point{t: e.start, v: float64(v)}})
+	case vector:
+		result := make([]vectorSample, 0, len(v))
+		for _, s := range v {
+			if !math.IsNaN(s.values[0]) {
+				result = append(result, vectorSample{Metric: s.labels, Value: point{t: e.start, v: s.values[0]}})
+			}
+		}
+		writeData(ctx, w, queryData{ResultType: "vector", Result: result})
+	}
+}
+
+func (h *Handler) handleQueryRange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	e, err := h.rangeEvaluator(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	v, err := evalQuery(ctx, e, r.FormValue("query"))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	var vec vector
+	switch v := v.(type) {
+	case scalar:
+		values := make([]float64, e.numSteps())
+		for i := range values {
+			values[i] = float64(v)
+		}
+		vec = vector{{labels: labels.Labels{}, values: values}}
+	case vector:
+		vec = v
+	}
+	result := make([]matrixSeries, 0, len(vec))
+	for _, s := range vec {
+		var points []point
+		for i, v := range s.values {
+			if !math.IsNaN(v) {
+				points = append(points, point{t: e.start + int64(i)*e.step, v: v})
+			}
+		}
+		if len(points) > 0 {
+			result = append(result, matrixSeries{Metric: s.labels, Values: points})
+		}
+	}
+	writeData(ctx, w, queryData{ResultType: "matrix", Result: result})
+}
+
+func (h *Handler) rangeEvaluator(r *http.Request) (*evaluator, error) {
+	start, err := parseTime(r.FormValue("start"), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseTime(r.FormValue("end"), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	step, err := parseDuration(r.FormValue("step"))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case start.IsZero() || end.IsZero():
+		return nil, badDataf("start and end must be specified")
+	case end.Before(start):
+		return nil, badDataf("end timestamp must not be before start time")
+	case step <= 0:
+		return nil, badDataf("zero or negative query resolution step widths are not accepted")
+	case end.Sub(start)/step > maxPoints:
+		return nil, badDataf("exceeded maximum resolution of %d points per timeseries", maxPoints)
+	}
+	return &evaluator{
+		querier: h.querier,
+		idx:     makeIndex(h.metadata()),
+		start:   start.UnixNano(),
+		end:     end.UnixNano(),
+		step:    step.Nanoseconds(),
+	}, nil
+}
+
+func evalQuery(ctx context.Context, e *evaluator, query string) (value, error) {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return nil, errors.Mark(err, errBadData)
+	}
+	return e.eval(ctx, expr)
+}
+
+// handleSeries returns the labels of the series that match any of the
+// match[] selectors.
+func (h *Handler) handleSeries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	series, err := h.series(ctx, r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	writeData(ctx, w, series)
+}
+
+// handleLabels returns the names of the labels of the series that match any
+// of the match[] selectors, or the names of all labels when none are given.
+func (h *Handler) handleLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if len(r.Form["match[]"]) == 0 {
+		writeData(ctx, w, []string{labels.MetricName, nodeLabel, quantileLabel, storeLabel, tenantLabel})
+		return
+	}
+	series, err := h.series(ctx, r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	writeData(ctx, w, labelValues(series, func(l labels.Label) string { return l.Name }))
+}
+
+// handleLabelValues returns the values of the given label of the series that
+// match any of the match[] selectors. When no selectors are given, only the
+// values of the metric name and quantile labels are returned, as the sources
+// of all time series would otherwise need to be queried.
+func (h *Handler) handleLabelValues(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := strings.TrimPrefix(r.URL.Path, URLPrefix+"api/v1/label/")
+	if !strings.HasSuffix(name, "/values") {
+		http.NotFound(w, r)
+		return
+	}
+	name = strings.TrimSuffix(name, "/values")
+
+	var values []string
+	if len(r.Form["match[]"]) == 0 {
+		switch name {
+		case labels.MetricName:
+			for n := range makeIndex(h.metadata()).byName {
+				values = append(values, n)
+			}
+			sort.Strings(values)
+		case quantileLabel:
+			values = strings.Split(recordedQuantiles(), ", ")
+		default:
+			values = []string{}
+		}
+		writeData(ctx, w, values)
+		return
+	}
+	series, err := h.series(ctx, r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	writeData(ctx, w, labelValues(series, func(l labels.Label) string {
+		if l.Name != name {
+			return ""
+		}
+		return l.Value
+	}))
+}
+
+// series returns the labels of the series that match any of the match[]
+// selectors of the request, between its start and end.
+func (h *Handler) series(ctx context.Context, r *http.Request) ([]labels.Labels, error) {
+	now := timeutil.Now()
+	end, err := parseTime(r.FormValue("end"), now)
+	if err != nil {
+		return nil, err
+	}
+	start, err := parseTime(r.FormValue("start"), end.Add(-lookbackDelta))
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, badDataf("end timestamp must not be before start time")
+	}
+	e := &evaluator{
+		querier: h.querier,
+		idx:     makeIndex(h.metadata()),
+		start:   start.UnixNano(),
+		end:     end.UnixNano(),
+		step:    end.Sub(start).Nanoseconds(),
+	}
+	var ret []labels.Labels
+	seen := make(map[uint64]struct{})
+	for _, match := range r.Form["match[]"] {
+		matchers, err := parser.ParseMetricSelector(match)
+		if err != nil {
+			return nil, errors.Mark(err, errBadData)
+		}
+		var name string
+		for _, m := range matchers {
+			if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+				name = m.Value
+			}
+		}
+		if name == "" {
+			return nil, badDataf("selectors must specify a metric name: %s", match)
+		}
+		found, _, err := e.findSeries(ctx, e.idx.byName[name], matchers, e.start, e.sampleNanos(0))
+		if err != nil {
+			return nil, err
+		}
+		for _, lbls := range found {
+			if _, ok := seen[lbls.Hash()]; !ok {
+				seen[lbls.Hash()] = struct{}{}
+				ret = append(ret, lbls)
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return labels.Compare(ret[i], ret[j]) < 0 })
+	if ret == nil {
+		ret = []labels.Labels{}
+	}
+	return ret, nil
+}
+
+// labelValues returns the sorted, distinct, non-empty values of fn for each
+// label of the series.
+func labelValues(series []labels.Labels, fn func(labels.Label) string) []string {
+	set := make(map[string]struct{})
+	for _, lbls := range series {
+		for _, l := range lbls {
+			if v := fn(l); v != "" {
+				set[v] = struct{}{}
+			}
+		}
+	}
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// parseTime parses a timestamp given as either unix seconds or RFC 3339, as
+// with Prometheus. The default is returned when the timestamp is empty.
+func parseTime(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(f)
+		return timeutil.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, badDataf("cannot parse %q to a valid timestamp", s)
+}
+
+// parseDuration parses a duration given as either seconds or a Prometheus
+// duration, e.g. 5m.
+func parseDuration(s string) (time.Duration, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, badDataf("cannot parse %q to a valid duration", s)
+}
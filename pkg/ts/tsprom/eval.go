@@ -0,0 +1,596 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tsprom
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/ts"
+	"github.com/cockroachdb/cockroach/pkg/ts/tspb"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Querier queries the internal time series database. It is implemented by
+// *ts.Server.
+type Querier interface {
+	Query(context.Context, *tspb.TimeSeriesQueryRequest) (*tspb.TimeSeriesQueryResponse, error)
+}
+
+var _ Querier = (*ts.Server)(nil)
+
+// lookbackDelta is how far back a selector looks for the latest datapoint of
+// a time series at each evaluation step, as with Prometheus.
+const lookbackDelta = 5 * time.Minute
+
+// errBadData marks the errors due to queries that are invalid or that use
+// PromQL features that are not supported.
+var errBadData = errors.New("bad data")
+
+func badDataf(format string, args ...interface{}) error {
+	return errors.Mark(errors.Newf(format, args...), errBadData)
+}
+
+// value is the result of evaluating an expression, either a scalar or a
+// vector. Both are evaluated at each step of the query.
+type value interface {
+	isValue()
+}
+
+type scalar float64
+
+// vector is a set of series, sorted by their labels.
+type vector []series
+
+// series is a time series evaluated at each step of a query.
+type series struct {
+	labels labels.Labels
+	// values are the values of the series at each step, NaN where the series
+	// has no value.
+	values []float64
+}
+
+func (scalar) isValue() {}
+func (vector) isValue() {}
+
+// evaluator evaluates a subset of PromQL against the internal time series
+// database, at each step between start and end. The supported subset is:
+//
+//   - selectors of the recorded metrics, matching on their node_id, store and
+//     quantile labels. A tenant_id label may be matched for equality to select
+//     the time series of a secondary tenant.
+//   - rate() of a selector, computed from the non-negative derivative of the
+//     time series.
+//   - the sum, avg, min, max and count aggregations, optionally by labels.
+//   - histogram_quantile() of a histogram's (rate of) buckets, optionally
+//     summed by labels. As histograms are recorded as a fixed set of
+//     quantiles, only those quantiles are available, and the sum of the
+//     buckets of multiple sources is approximated by the maximum of their
+//     quantiles.
+//   - arithmetic between numbers and expressions.
+type evaluator struct {
+	querier Querier
+	idx     index
+	// start, end and step are the timestamps of the first and last
+	// evaluation steps, and the duration between steps, in nanos.
+	start, end, step int64
+}
+
+func (e *evaluator) numSteps() int {
+	return int((e.end-e.start)/e.step) + 1
+}
+
+func (e *evaluator) eval(ctx context.Context, expr parser.Expr) (value, error) {
+	switch expr := expr.(type) {
+	case *parser.NumberLiteral:
+		return scalar(expr.Val), nil
+	case *parser.ParenExpr:
+		return e.eval(ctx, expr.Expr)
+	case *parser.StepInvariantExpr:
+		return e.eval(ctx, expr.Expr)
+	case *parser.UnaryExpr:
+		v, err := e.eval(ctx, expr.Expr)
+		if err != nil || expr.Op != parser.SUB {
+			return v, err
+		}
+		return e.evalBinary(&parser.BinaryExpr{Op: parser.MUL}, scalar(-1), v)
+	case *parser.VectorSelector:
+		return e.selectVector(ctx, expr, 0 /* rateWindow */)
+	case *parser.Call:
+		return e.evalCall(ctx, expr)
+	case *parser.AggregateExpr:
+		if expr.Without || expr.Param != nil {
+			return nil, badDataf("unsupported aggregation: %s", expr)
+		}
+		v, err := e.eval(ctx, expr.Expr)
+		if err != nil {
+			return nil, err
+		}
+		vec, ok := v.(vector)
+		if !ok {
+			return nil, badDataf("expected a vector to aggregate: %s", expr)
+		}
+		return aggregate(vec, expr.Op, expr.Grouping)
+	case *parser.BinaryExpr:
+		lhs, err := e.eval(ctx, expr.LHS)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := e.eval(ctx, expr.RHS)
+		if err != nil {
+			return nil, err
+		}
+		return e.evalBinary(expr, lhs, rhs)
+	}
+	return nil, badDataf("unsupported expression: %s", expr)
+}
+
+func (e *evaluator) evalCall(ctx context.Context, call *parser.Call) (value, error) {
+	switch call.Func.Name {
+	case "rate":
+		vs, window, err := rateSelector(call)
+		if err != nil {
+			return nil, err
+		}
+		vec, err := e.selectVector(ctx, vs, window)
+		if err != nil {
+			return nil, err
+		}
+		return dropMetricName(vec), nil
+	case "histogram_quantile":
+		return e.histogramQuantile(ctx, call)
+	}
+	return nil, badDataf("unsupported function: %s", call.Func.Name)
+}
+
+// rateSelector returns the selector and window of the matrix selector that is
+// the argument of a rate() call.
+func rateSelector(call *parser.Call) (*parser.VectorSelector, time.Duration, error) {
+	if ms, ok := call.Args[0].(*parser.MatrixSelector); ok {
+		if vs, ok := ms.VectorSelector.(*parser.VectorSelector); ok {
+			return vs, ms.Range, nil
+		}
+	}
+	return nil, 0, badDataf("rate() is only supported of a range selector: %s", call)
+}
+
+// histogramQuantile evaluates histogram_quantile(φ, <buckets>), where the
+// buckets are one of x_bucket, rate(x_bucket[d]) or sum by (...) of either.
+// The φ quantile of each histogram is read from the time series that the
+// quantile was recorded under, which is computed over the histogram's own
+// window rather than the rate window.
+func (e *evaluator) histogramQuantile(ctx context.Context, call *parser.Call) (value, error) {
+	phi, ok := unwrapParens(call.Args[0]).(*parser.NumberLiteral)
+	if !ok {
+		return nil, badDataf("the quantile of histogram_quantile() must be a number: %s", call)
+	}
+	arg := unwrapParens(call.Args[1])
+	var agg *parser.AggregateExpr
+	if a, ok := arg.(*parser.AggregateExpr); ok {
+		if a.Op != parser.SUM || a.Without || a.Param != nil {
+			return nil, badDataf("histogram_quantile() only supports a sum by the buckets: %s", call)
+		}
+		agg = a
+		arg = unwrapParens(a.Expr)
+	}
+	if c, ok := arg.(*parser.Call); ok && c.Func.Name == "rate" {
+		vs, _, err := rateSelector(c)
+		if err != nil {
+			return nil, err
+		}
+		arg = vs
+	}
+	vs, ok := arg.(*parser.VectorSelector)
+	if !ok || !strings.HasSuffix(vs.Name, "_bucket") {
+		return nil, badDataf("histogram_quantile() is only supported of histogram buckets: %s", call)
+	}
+	hist, ok := e.idx.histograms[strings.TrimSuffix(vs.Name, "_bucket")]
+	if !ok {
+		return vector{}, nil
+	}
+	tsName, ok := hist.histogramQuantile(phi.Val)
+	if !ok {
+		return nil, badDataf("histogram_quantile() only supports the recorded quantiles: %s",
+			recordedQuantiles())
+	}
+	if err := checkModifiers(vs); err != nil {
+		return nil, err
+	}
+
+	// The le label of the buckets has no meaning here.
+	var matchers []*labels.Matcher
+	for _, m := range vs.LabelMatchers {
+		if m.Name != "le" {
+			matchers = append(matchers, m)
+		}
+	}
+	sel := selection{
+		tsName:      tsName,
+		sourceLabel: hist.sourceLabel,
+		labels:      labels.FromStrings(labels.MetricName, vs.Name),
+	}
+	vec, err := e.selectSeries(ctx, []selection{sel}, matchers, 0 /* rateWindow */)
+	if err != nil {
+		return nil, err
+	}
+	vec = dropMetricName(vec)
+	if agg == nil {
+		return vec, nil
+	}
+	var grouping []string
+	for _, l := range agg.Grouping {
+		if l != "le" {
+			grouping = append(grouping, l)
+		}
+	}
+	return aggregate(vec, parser.MAX, grouping)
+}
+
+func unwrapParens(expr parser.Expr) parser.Expr {
+	for {
+		p, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.Expr
+	}
+}
+
+func checkModifiers(vs *parser.VectorSelector) error {
+	if vs.OriginalOffset != 0 || vs.Timestamp != nil || vs.StartOrEnd != 0 {
+		return badDataf("offset and @ modifiers are not supported: %s", vs)
+	}
+	return nil
+}
+
+// selectVector evaluates the selector, or the rate of the selector over the
+// given window when it is non-zero.
+func (e *evaluator) selectVector(
+	ctx context.Context, vs *parser.VectorSelector, rateWindow time.Duration,
+) (vector, error) {
+	if err := checkModifiers(vs); err != nil {
+		return nil, err
+	}
+	if vs.Name == "" {
+		return nil, badDataf("selectors must specify a metric name: %s", vs)
+	}
+	if name := strings.TrimSuffix(vs.Name, "_bucket"); name != vs.Name {
+		if _, ok := e.idx.histograms[name]; ok {
+			return nil, badDataf("%s is only supported within histogram_quantile()", vs.Name)
+		}
+	}
+	return e.selectSeries(ctx, e.idx.byName[vs.Name], vs.LabelMatchers, rateWindow)
+}
+
+// selectSeries returns a series for each source of the given time series that
+// matches the matchers. When rateWindow is non-zero, the series are the
+// average per-second rate of the time series over the window at each step,
+// otherwise they are the latest value of the time series at each step.
+func (e *evaluator) selectSeries(
+	ctx context.Context, sels []selection, matchers []*labels.Matcher, rateWindow time.Duration,
+) (vector, error) {
+	lookback := lookbackDelta
+	if rateWindow > 0 {
+		lookback = rateWindow
+	}
+	sampleNanos := e.sampleNanos(rateWindow)
+	startNanos := e.start - lookback.Nanoseconds()
+
+	targets, queries, err := e.findSeries(ctx, sels, matchers, startNanos, sampleNanos)
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return vector{}, nil
+	}
+	for i := range queries {
+		queries[i].Downsampler = tspb.TimeSeriesQueryAggregator_AVG.Enum()
+		queries[i].SourceAggregator = tspb.TimeSeriesQueryAggregator_SUM.Enum()
+		if rateWindow > 0 {
+			queries[i].Derivative = tspb.TimeSeriesQueryDerivative_NON_NEGATIVE_DERIVATIVE.Enum()
+		}
+	}
+	resp, err := e.query(ctx, startNanos, sampleNanos, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	vec := make(vector, len(resp.Results))
+	for i, r := range resp.Results {
+		vec[i] = series{
+			labels: targets[i],
+			values: e.sample(r.Datapoints, lookback.Nanoseconds(), sampleNanos, rateWindow > 0),
+		}
+	}
+	sortVector(vec)
+	return vec, nil
+}
+
+// findSeries returns the labels of each source of the given time series that
+// matches the matchers, along with a query of the source's time series.
+func (e *evaluator) findSeries(
+	ctx context.Context,
+	sels []selection,
+	matchers []*labels.Matcher,
+	startNanos, sampleNanos int64,
+) ([]labels.Labels, []tspb.Query, error) {
+	tenantID, err := tenantFromMatchers(matchers)
+	if err != nil {
+		return nil, nil, err
+	}
+	if startNanos > timeutil.Now().UnixNano()-sampleNanos || len(sels) == 0 {
+		// There is no data to be found.
+		return nil, nil, nil
+	}
+
+	// The sources of a time series are returned when querying it without
+	// specifying any.
+	queries := make([]tspb.Query, len(sels))
+	for i, sel := range sels {
+		queries[i] = tspb.Query{Name: sel.tsName, TenantID: tenantID}
+	}
+	resp, err := e.query(ctx, startNanos, sampleNanos, queries)
+	if err != nil {
+		return nil, nil, err
+	}
+	queries = queries[:0]
+	var found []labels.Labels
+	for i, r := range resp.Results {
+		for _, source := range r.Sources {
+			b := labels.NewBuilder(sels[i].labels).Set(sels[i].sourceLabel, source)
+			if tenantID.IsSet() {
+				b.Set(tenantLabel, tenantID.String())
+			}
+			lbls := b.Labels()
+			if !matches(lbls, matchers) {
+				continue
+			}
+			found = append(found, lbls)
+			queries = append(queries, tspb.Query{
+				Name:     sels[i].tsName,
+				Sources:  []string{source},
+				TenantID: tenantID,
+			})
+		}
+	}
+	return found, queries, nil
+}
+
+func (e *evaluator) query(
+	ctx context.Context, startNanos, sampleNanos int64, queries []tspb.Query,
+) (*tspb.TimeSeriesQueryResponse, error) {
+	return e.querier.Query(ctx, &tspb.TimeSeriesQueryRequest{
+		StartNanos:  startNanos,
+		EndNanos:    e.end,
+		Queries:     queries,
+		SampleNanos: sampleNanos,
+	})
+}
+
+// sampleNanos returns the sample duration to query the time series at, which
+// is the step of the query, or the rate window if it's shorter, rounded down
+// to a multiple of the stored resolution.
+func (e *evaluator) sampleNanos(rateWindow time.Duration) int64 {
+	res := ts.Resolution10s.SampleDuration()
+	sample := e.step
+	if rateWindow > 0 && rateWindow.Nanoseconds() < sample {
+		sample = rateWindow.Nanoseconds()
+	}
+	sample -= sample % res
+	if sample < res {
+		sample = res
+	}
+	return sample
+}
+
+// sample returns the values of the datapoints at each step. When average is
+// false, the value at each step is that of the latest datapoint within the
+// window, otherwise it is the average of the datapoints within the window.
+// The window is widened to the sample duration, such that it always contains
+// a datapoint of a contiguous time series.
+func (e *evaluator) sample(
+	dps []tspb.TimeSeriesDatapoint, window, sampleNanos int64, average bool,
+) []float64 {
+	if window < sampleNanos {
+		window = sampleNanos
+	}
+	values := make([]float64, e.numSteps())
+	for i := range values {
+		t := e.start + int64(i)*e.step
+		// Find the datapoints in (t-window, t].
+		hi := sort.Search(len(dps), func(j int) bool { return dps[j].TimestampNanos > t })
+		lo := sort.Search(hi, func(j int) bool { return dps[j].TimestampNanos > t-window })
+		switch {
+		case lo == hi:
+			values[i] = math.NaN()
+		case !average:
+			values[i] = dps[hi-1].Value
+		default:
+			var sum float64
+			for _, dp := range dps[lo:hi] {
+				sum += dp.Value
+			}
+			values[i] = sum / float64(hi-lo)
+		}
+	}
+	return values
+}
+
+// tenantFromMatchers returns the tenant that the matchers select the time
+// series of, if any.
+func tenantFromMatchers(matchers []*labels.Matcher) (roachpb.TenantID, error) {
+	for _, m := range matchers {
+		if m.Name != tenantLabel {
+			continue
+		}
+		if m.Type != labels.MatchEqual {
+			return roachpb.TenantID{}, badDataf("%s may only be matched for equality", tenantLabel)
+		}
+		tenantID, err := roachpb.TenantIDFromString(m.Value)
+		return tenantID, errors.Mark(err, errBadData)
+	}
+	return roachpb.TenantID{}, nil
+}
+
+func matches(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortVector(vec vector) {
+	sort.Slice(vec, func(i, j int) bool {
+		return labels.Compare(vec[i].labels, vec[j].labels) < 0
+	})
+}
+
+// dropMetricName removes the metric name from the labels of the series, as
+// the result of a function or operator is no longer the named metric.
+func dropMetricName(vec vector) vector {
+	for i := range vec {
+		vec[i].labels = labels.NewBuilder(vec[i].labels).Del(labels.MetricName).Labels()
+	}
+	return vec
+}
+
+// aggregate aggregates the series of the vector that have the same values of
+// the grouping labels, at each step.
+func aggregate(vec vector, op parser.ItemType, grouping []string) (vector, error) {
+	var fn func(values []float64) float64
+	switch op {
+	case parser.SUM:
+		fn = func(values []float64) float64 {
+			var sum float64
+			for _, v := range values {
+				sum += v
+			}
+			return sum
+		}
+	case parser.AVG:
+		fn = func(values []float64) float64 {
+			var sum float64
+			for _, v := range values {
+				sum += v
+			}
+			return sum / float64(len(values))
+		}
+	case parser.MIN:
+		fn = func(values []float64) float64 {
+			min := values[0]
+			for _, v := range values[1:] {
+				min = math.Min(min, v)
+			}
+			return min
+		}
+	case parser.MAX:
+		fn = func(values []float64) float64 {
+			max := values[0]
+			for _, v := range values[1:] {
+				max = math.Max(max, v)
+			}
+			return max
+		}
+	case parser.COUNT:
+		fn = func(values []float64) float64 {
+			return float64(len(values))
+		}
+	default:
+		return nil, badDataf("unsupported aggregation: %s", op)
+	}
+
+	grouping = append([]string(nil), grouping...)
+	sort.Strings(grouping)
+	groups := make(map[uint64][]series)
+	var groupLabels []labels.Labels
+	for _, s := range vec {
+		lbls := s.labels.WithLabels(grouping...)
+		h := lbls.Hash()
+		if _, ok := groups[h]; !ok {
+			groupLabels = append(groupLabels, lbls)
+		}
+		groups[h] = append(groups[h], s)
+	}
+
+	ret := make(vector, 0, len(groupLabels))
+	for _, lbls := range groupLabels {
+		group := groups[lbls.Hash()]
+		values := make([]float64, len(group[0].values))
+		present := make([]float64, 0, len(group))
+		for i := range values {
+			present = present[:0]
+			for _, s := range group {
+				if !math.IsNaN(s.values[i]) {
+					present = append(present, s.values[i])
+				}
+			}
+			if len(present) == 0 {
+				values[i] = math.NaN()
+			} else {
+				values[i] = fn(present)
+			}
+		}
+		ret = append(ret, series{labels: lbls, values: values})
+	}
+	sortVector(ret)
+	return ret, nil
+}
+
+// evalBinary evaluates arithmetic between scalars, or between a scalar and
+// each value of a vector.
+func (e *evaluator) evalBinary(expr *parser.BinaryExpr, lhs, rhs value) (value, error) {
+	var fn func(l, r float64) float64
+	switch expr.Op {
+	case parser.ADD:
+		fn = func(l, r float64) float64 { return l + r }
+	case parser.SUB:
+		fn = func(l, r float64) float64 { return l - r }
+	case parser.MUL:
+		fn = func(l, r float64) float64 { return l * r }
+	case parser.DIV:
+		fn = func(l, r float64) float64 { return l / r }
+	default:
+		return nil, badDataf("unsupported operator: %s", expr.Op)
+	}
+
+	switch l := lhs.(type) {
+	case scalar:
+		switch r := rhs.(type) {
+		case scalar:
+			return scalar(fn(float64(l), float64(r))), nil
+		case vector:
+			return mapVector(r, func(v float64) float64 { return fn(float64(l), v) }), nil
+		}
+	case vector:
+		if r, ok := rhs.(scalar); ok {
+			return mapVector(l, func(v float64) float64 { return fn(v, float64(r)) }), nil
+		}
+	}
+	return nil, badDataf("operators are only supported between numbers and expressions: %s", expr)
+}
+
+func mapVector(vec vector, fn func(float64) float64) vector {
+	for _, s := range vec {
+		for i, v := range s.values {
+			s.values[i] = fn(v)
+		}
+	}
+	return dropMetricName(vec)
+}
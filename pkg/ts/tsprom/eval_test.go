@@ -0,0 +1,377 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tsprom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ts/tspb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	prometheusgo "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuerier serves queries of the time series in data, keyed by name and
+// source, which are recorded every 10s.
+type fakeQuerier struct {
+	data map[string]map[string][]tspb.TimeSeriesDatapoint
+}
+
+func (q fakeQuerier) Query(
+	_ context.Context, req *tspb.TimeSeriesQueryRequest,
+) (*tspb.TimeSeriesQueryResponse, error) {
+	resp := &tspb.TimeSeriesQueryResponse{}
+	for _, query := range req.Queries {
+		result := tspb.TimeSeriesQueryResponse_Result{Query: query}
+		if len(query.Sources) == 0 {
+			for source := range q.data[query.Name] {
+				result.Sources = append(result.Sources, source)
+			}
+			sort.Strings(result.Sources)
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+		if len(query.Sources) != 1 || query.GetSourceAggregator() != tspb.TimeSeriesQueryAggregator_SUM ||
+			query.GetDownsampler() != tspb.TimeSeriesQueryAggregator_AVG {
+			return nil, errors.Newf("unexpected query %+v", query)
+		}
+
+		// Downsample the datapoints to the sample duration.
+		var samples []tspb.TimeSeriesDatapoint
+		var count float64
+		for _, dp := range q.data[query.Name][query.Sources[0]] {
+			t := dp.TimestampNanos - dp.TimestampNanos%req.SampleNanos
+			if t < req.StartNanos || t > req.EndNanos {
+				continue
+			}
+			if n := len(samples); n == 0 || samples[n-1].TimestampNanos != t {
+				samples = append(samples, tspb.TimeSeriesDatapoint{TimestampNanos: t})
+				count = 0
+			}
+			last := &samples[len(samples)-1]
+			last.Value = (last.Value*count + dp.Value) / (count + 1)
+			count++
+		}
+		if query.GetDerivative() == tspb.TimeSeriesQueryDerivative_NON_NEGATIVE_DERIVATIVE {
+			var derivs []tspb.TimeSeriesDatapoint
+			for i := 1; i < len(samples); i++ {
+				seconds := float64(samples[i].TimestampNanos-samples[i-1].TimestampNanos) / 1e9
+				derivs = append(derivs, tspb.TimeSeriesDatapoint{
+					TimestampNanos: samples[i].TimestampNanos,
+					Value:          math.Max(0, (samples[i].Value-samples[i-1].Value)/seconds),
+				})
+			}
+			samples = derivs
+		}
+		result.Datapoints = samples
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+func testingMetadata() map[string]metric.Metadata {
+	return map[string]metric.Metadata{
+		"cr.node.sql.conns":           {MetricType: prometheusgo.MetricType_GAUGE},
+		"cr.node.sql.query.count":     {MetricType: prometheusgo.MetricType_COUNTER},
+		"cr.node.sql.service.latency": {MetricType: prometheusgo.MetricType_HISTOGRAM},
+		"cr.store.capacity":           {MetricType: prometheusgo.MetricType_GAUGE},
+	}
+}
+
+// testingQuerier returns a querier with an hour of data recorded by two nodes
+// and their stores, starting at the returned time.
+func testingQuerier() (fakeQuerier, time.Time) {
+	start := timeutil.Now().Add(-2 * time.Hour).Truncate(time.Minute)
+	q := fakeQuerier{data: make(map[string]map[string][]tspb.TimeSeriesDatapoint)}
+	record := func(name, source string, fn func(i int) float64) {
+		if q.data[name] == nil {
+			q.data[name] = make(map[string][]tspb.TimeSeriesDatapoint)
+		}
+		for i := 0; i < 360; i++ {
+			q.data[name][source] = append(q.data[name][source], tspb.TimeSeriesDatapoint{
+				TimestampNanos: start.Add(time.Duration(i) * 10 * time.Second).UnixNano(),
+				Value:          fn(i),
+			})
+		}
+	}
+	for n := 1; n <= 2; n++ {
+		n := n
+		source := fmt.Sprint(n)
+		record("cr.node.sql.conns", source, func(int) float64 { return float64(5 * n) })
+		record("cr.node.sql.query.count", source, func(i int) float64 { return float64(100 * n * i) })
+		record("cr.node.sql.service.latency-p99", source, func(int) float64 { return float64(100 * n) })
+		record("cr.node.sql.service.latency-p50", source, func(int) float64 { return float64(10 * n) })
+		record("cr.store.capacity", source, func(int) float64 { return float64(1000 * n) })
+	}
+	return q, start
+}
+
+// formatValue formats the value at each step, one series per line.
+func formatValue(v value) string {
+	switch v := v.(type) {
+	case scalar:
+		return fmt.Sprint(float64(v))
+	case vector:
+		var lines []string
+		for _, s := range v {
+			lines = append(lines, fmt.Sprintf("%s %v", s.labels, s.values))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+func TestEval(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	q, start := testingQuerier()
+	at := start.Add(30 * time.Minute).UnixNano()
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{
+			query: `sql_conns`,
+			expected: `{__name__="sql_conns", node_id="1"} [5]
+{__name__="sql_conns", node_id="2"} [10]`,
+		},
+		{
+			query:    `sql_conns{node_id!="1"}`,
+			expected: `{__name__="sql_conns", node_id="2"} [10]`,
+		},
+		{
+			query:    `capacity{store=~"1|3"}`,
+			expected: `{__name__="capacity", store="1"} [1000]`,
+		},
+		{
+			query:    `unknown_metric`,
+			expected: ``,
+		},
+		{
+			query:    `sum(sql_conns)`,
+			expected: `{} [15]`,
+		},
+		{
+			query: `max by (node_id) (sql_conns)`,
+			expected: `{node_id="1"} [5]
+{node_id="2"} [10]`,
+		},
+		{
+			query: `rate(sql_query_count[1m])`,
+			expected: `{node_id="1"} [10]
+{node_id="2"} [20]`,
+		},
+		{
+			query:    `sum(rate(sql_query_count[5m])) / 2`,
+			expected: `{} [15]`,
+		},
+		{
+			query: `-sql_conns + 1`,
+			expected: `{node_id="1"} [-4]
+{node_id="2"} [-9]`,
+		},
+		{
+			query:    `(1 + 2) * 3`,
+			expected: `9`,
+		},
+		{
+			query: `sql_service_latency{quantile=~"0.5|0.99", node_id="1"}`,
+			expected: `{__name__="sql_service_latency", node_id="1", quantile="0.5"} [10]
+{__name__="sql_service_latency", node_id="1", quantile="0.99"} [100]`,
+		},
+		{
+			query: `histogram_quantile(0.99, rate(sql_service_latency_bucket[5m]))`,
+			expected: `{node_id="1"} [100]
+{node_id="2"} [200]`,
+		},
+		{
+			query:    `histogram_quantile(0.5, sum by (le) (rate(sql_service_latency_bucket{node_id="1"}[5m])))`,
+			expected: `{} [10]`,
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			e := &evaluator{
+				querier: q,
+				idx:     makeIndex(testingMetadata()),
+				start:   at,
+				end:     at,
+				step:    int64(10 * time.Second),
+			}
+			v, err := evalQuery(context.Background(), e, tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, formatValue(v))
+		})
+	}
+}
+
+func TestEvalUnsupported(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	q, start := testingQuerier()
+	for _, query := range []string{
+		`sql_conns[`,
+		`sql_conns[1m]`,
+		`sql_service_latency_bucket`,
+		`histogram_quantile(0.42, rate(sql_service_latency_bucket[5m]))`,
+		`sql_conns offset 5m`,
+		`sql_conns and sql_conns`,
+		`sql_conns + sql_conns`,
+		`sql_conns > 1`,
+		`sum without (node_id) (sql_conns)`,
+		`topk(1, sql_conns)`,
+		`irate(sql_query_count[1m])`,
+		`sql_conns{tenant_id=~"2|3"}`,
+	} {
+		t.Run(query, func(t *testing.T) {
+			e := &evaluator{
+				querier: q,
+				idx:     makeIndex(testingMetadata()),
+				start:   start.UnixNano(),
+				end:     start.UnixNano(),
+				step:    int64(10 * time.Second),
+			}
+			_, err := evalQuery(context.Background(), e, query)
+			require.True(t, errors.Is(err, errBadData), "%v", err)
+		})
+	}
+}
+
+// TestSample verifies that a range query evaluates each step from the
+// datapoints preceding it.
+func TestSample(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s := int64(10 * time.Second)
+	e := &evaluator{start: 0, end: 4 * s, step: s}
+	dps := []tspb.TimeSeriesDatapoint{
+		{TimestampNanos: 0, Value: 1},
+		{TimestampNanos: s, Value: 2},
+		{TimestampNanos: 3 * s, Value: 4},
+	}
+	formatValues := func(values []float64) string {
+		return fmt.Sprint(values)
+	}
+	require.Equal(t, "[1 2 2 4 4]", formatValues(e.sample(dps, 5*s, s, false /* average */)))
+	require.Equal(t, "[1 2 NaN 4 NaN]", formatValues(e.sample(dps, 0, s, false /* average */)))
+	require.Equal(t, "[1 1.5 2 4 4]", formatValues(e.sample(dps, 2*s, s, true /* average */)))
+}
+
+func TestHandler(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	q, start := testingQuerier()
+	h := NewHandler(q, testingMetadata)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(path string, params url.Values, expectedCode int) response {
+		resp, err := http.Get(srv.URL + URLPrefix + path + "?" + params.Encode())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, expectedCode, resp.StatusCode)
+		var r response
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&r))
+		return r
+	}
+	unixSeconds := func(t time.Time) string {
+		return fmt.Sprint(t.Unix())
+	}
+
+	r := get("api/v1/query_range", url.Values{
+		"query": {`sum by (node_id) (rate(sql_query_count[1m]))`},
+		"start": {unixSeconds(start.Add(10 * time.Minute))},
+		"end":   {unixSeconds(start.Add(11 * time.Minute))},
+		"step":  {"30s"},
+	}, http.StatusOK)
+	require.Equal(t, "success", r.Status)
+	data, err := json.Marshal(r.Data)
+	require.NoError(t, err)
+	ts := func(d time.Duration) float64 {
+		return float64(start.Add(d).Unix())
+	}
+	expected, err := json.Marshal(map[string]interface{}{
+		"resultType": "matrix",
+		"result": []interface{}{
+			map[string]interface{}{
+				"metric": map[string]string{"node_id": "1"},
+				"values": [][]interface{}{
+					{ts(10 * time.Minute), "10"},
+					{ts(10*time.Minute + 30*time.Second), "10"},
+					{ts(11 * time.Minute), "10"},
+				},
+			},
+			map[string]interface{}{
+				"metric": map[string]string{"node_id": "2"},
+				"values": [][]interface{}{
+					{ts(10 * time.Minute), "20"},
+					{ts(10*time.Minute + 30*time.Second), "20"},
+					{ts(11 * time.Minute), "20"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(data))
+
+	r = get("api/v1/query", url.Values{
+		"query": {`sum(sql_conns)`},
+		"time":  {unixSeconds(start.Add(time.Minute))},
+	}, http.StatusOK)
+	require.Equal(t, map[string]interface{}{
+		"resultType": "vector",
+		"result": []interface{}{
+			map[string]interface{}{
+				"metric": map[string]interface{}{},
+				"value":  []interface{}{ts(time.Minute), "15"},
+			},
+		},
+	}, r.Data)
+
+	r = get("api/v1/query", url.Values{"query": {`sql_conns offset 1m`}}, http.StatusBadRequest)
+	require.Equal(t, "error", r.Status)
+	require.Equal(t, "bad_data", r.ErrorType)
+
+	r = get("api/v1/label/__name__/values", nil, http.StatusOK)
+	require.Equal(t, []interface{}{
+		"capacity", "sql_conns", "sql_query_count", "sql_service_latency",
+		"sql_service_latency_count", "sql_service_latency_sum",
+	}, r.Data)
+
+	r = get("api/v1/label/store/values", url.Values{
+		"match[]": {`capacity`},
+		"start":   {unixSeconds(start)},
+		"end":     {unixSeconds(start.Add(time.Hour))},
+	}, http.StatusOK)
+	require.Equal(t, []interface{}{"1", "2"}, r.Data)
+
+	r = get("api/v1/series", url.Values{
+		"match[]": {`sql_conns{node_id="2"}`, `capacity{store="1"}`},
+		"start":   {unixSeconds(start)},
+		"end":     {unixSeconds(start.Add(time.Hour))},
+	}, http.StatusOK)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"__name__": "capacity", "store": "1"},
+		map[string]interface{}{"__name__": "sql_conns", "node_id": "2"},
+	}, r.Data)
+}
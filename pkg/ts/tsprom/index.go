@@ -0,0 +1,149 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package tsprom exposes the internal time series database through the
+// Prometheus HTTP API, and exports the time series recorded by a node to a
+// Prometheus remote-write endpoint.
+//
+// Metrics are named as they are by the _status/vars endpoint, so that
+// dashboards and alerts written against a Prometheus server scraping the
+// cluster work unmodified against either. Each time series source is exposed
+// as a node_id or store label, and a histogram is exposed as the quantiles
+// recorded by the internal time series database, as a summary would be.
+package tsprom
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	prometheusgo "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+const (
+	// nodeLabel and storeLabel are the labels identifying the source of node
+	// and store metrics respectively. They match the labels of the metrics
+	// exported by the _status/vars endpoint.
+	nodeLabel  = "node_id"
+	storeLabel = "store"
+	// tenantLabel identifies the secondary tenant that a time series was
+	// recorded for.
+	tenantLabel = "tenant_id"
+	// quantileLabel identifies the quantile of a histogram.
+	quantileLabel = "quantile"
+
+	nodeTimeSeriesPrefix  = "cr.node."
+	storeTimeSeriesPrefix = "cr.store."
+)
+
+// MetadataFunc returns the metadata of the recorded metrics, keyed by the name
+// of their time series (e.g. cr.node.sql.conns).
+type MetadataFunc func() map[string]metric.Metadata
+
+// selection is a time series that makes up a Prometheus metric.
+type selection struct {
+	// tsName is the name of the time series, e.g. cr.node.sql.conns.
+	tsName string
+	// sourceLabel is the label that the source of the time series is exposed
+	// as, either nodeLabel or storeLabel.
+	sourceLabel string
+	// labels are the labels of the time series, other than its source. These
+	// always include the metric name.
+	labels labels.Labels
+}
+
+// index maps Prometheus metric names to the time series that make them up,
+// and vice versa.
+type index struct {
+	// byName maps the Prometheus name of each metric to its time series. A
+	// histogram is made up of the time series of each recorded quantile.
+	byName map[string][]selection
+	// byTSName maps the name of each time series to its Prometheus metric.
+	byTSName map[string]selection
+	// histograms maps the Prometheus name of each histogram to its time
+	// series, named without the suffix of the recorded quantiles.
+	histograms map[string]selection
+}
+
+// makeIndex returns an index of the metrics with the given metadata, keyed by
+// the name of their time series.
+func makeIndex(md map[string]metric.Metadata) index {
+	idx := index{
+		byName:     make(map[string][]selection),
+		byTSName:   make(map[string]selection),
+		histograms: make(map[string]selection),
+	}
+	add := func(name, tsName, sourceLabel string, lbls ...labels.Label) {
+		sel := selection{
+			tsName:      tsName,
+			sourceLabel: sourceLabel,
+			labels:      labels.New(append(lbls, labels.Label{Name: labels.MetricName, Value: name})...),
+		}
+		idx.byName[name] = append(idx.byName[name], sel)
+		idx.byTSName[tsName] = sel
+	}
+	for tsName, m := range md {
+		var sourceLabel, name string
+		if strings.HasPrefix(tsName, nodeTimeSeriesPrefix) {
+			sourceLabel, name = nodeLabel, strings.TrimPrefix(tsName, nodeTimeSeriesPrefix)
+		} else if strings.HasPrefix(tsName, storeTimeSeriesPrefix) {
+			sourceLabel, name = storeLabel, strings.TrimPrefix(tsName, storeTimeSeriesPrefix)
+		} else {
+			continue
+		}
+		name = metric.ExportedName(name)
+		if m.MetricType != prometheusgo.MetricType_HISTOGRAM {
+			add(name, tsName, sourceLabel)
+			continue
+		}
+		// See the recording of histograms in status.extractValue. The average is
+		// not exposed, as it is derived from the sum and count.
+		idx.histograms[name] = selection{
+			tsName:      tsName,
+			sourceLabel: sourceLabel,
+			labels:      labels.FromStrings(labels.MetricName, name),
+		}
+		add(name+"_count", tsName+"-count", sourceLabel)
+		add(name+"_sum", tsName+"-sum", sourceLabel)
+		for _, q := range metric.RecordHistogramQuantiles {
+			add(name, tsName+q.Suffix, sourceLabel,
+				labels.Label{Name: quantileLabel, Value: formatQuantile(q.Quantile)})
+		}
+	}
+	return idx
+}
+
+// formatQuantile formats the given percentile as a quantile label value, e.g.
+// 99.9 as 0.999.
+func formatQuantile(percentile float64) string {
+	return strconv.FormatFloat(percentile/100, 'g', 6, 64)
+}
+
+// histogramQuantile returns the time series that the given quantile of the
+// histogram is recorded under. Only the recorded quantiles are available.
+func (s selection) histogramQuantile(q float64) (string, bool) {
+	for _, rq := range metric.RecordHistogramQuantiles {
+		if formatQuantile(rq.Quantile) == strconv.FormatFloat(q, 'g', 6, 64) {
+			return s.tsName + rq.Suffix, true
+		}
+	}
+	return "", false
+}
+
+// recordedQuantiles returns the quantiles that histograms are recorded at, for
+// use in error messages.
+func recordedQuantiles() string {
+	qs := make([]string, len(metric.RecordHistogramQuantiles))
+	for i, q := range metric.RecordHistogramQuantiles {
+		qs[i] = formatQuantile(q.Quantile)
+	}
+	return strings.Join(qs, ", ")
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tsprom
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/ts"
+	"github.com/cockroachdb/cockroach/pkg/ts/tspb"
+	"github.com/cockroachdb/cockroach/pkg/ts/tsutil"
+	"github.com/cockroachdb/cockroach/pkg/util/httputil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/logtags"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// RemoteWriteURL is the URL, if any, of the Prometheus remote-write endpoint
+// that the time series recorded by each node are pushed to.
+var RemoteWriteURL = settings.RegisterStringSetting(
+	settings.SystemOnly,
+	"external.prometheus.remote_write.url",
+	"if nonempty, push the time series recorded by each node to the Prometheus "+
+		"remote-write endpoint at the specified URL",
+	"",
+)
+
+// remoteWriteInterval is how often time series are pushed to the remote-write
+// endpoint, if enabled.
+var remoteWriteInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"external.prometheus.remote_write.interval",
+	"the interval at which time series are pushed to the Prometheus remote-write endpoint (if enabled)",
+	10*time.Second,
+	settings.NonNegativeDurationWithMinimum(time.Second),
+)
+
+// remoteWriteTimeout is the timeout of each request to the remote-write
+// endpoint.
+const remoteWriteTimeout = 30 * time.Second
+
+// StartRemoteWriter starts periodically pushing the time series of the source
+// to the remote-write endpoint at RemoteWriteURL, while it's set. The time
+// series are named and labeled as by the query endpoints of the Handler.
+func StartRemoteWriter(
+	ctx context.Context,
+	stopper *stop.Stopper,
+	source ts.DataSource,
+	metadata MetadataFunc,
+	st *cluster.Settings,
+) {
+	ctx = logtags.AddTag(ctx, "prometheus remote writer", nil)
+	client := httputil.NewClientWithTimeout(remoteWriteTimeout)
+	every := log.Every(time.Minute)
+
+	_ = stopper.RunAsyncTask(ctx, "prometheus-remote-writer", func(ctx context.Context) {
+		var timer timeutil.Timer
+		defer timer.Stop()
+		for {
+			timer.Reset(remoteWriteInterval.Get(&st.SV))
+			select {
+			case <-stopper.ShouldQuiesce():
+				return
+			case <-timer.C:
+				timer.Read = true
+				url := RemoteWriteURL.Get(&st.SV)
+				if url == "" {
+					continue
+				}
+				req := makeWriteRequest(source.GetTimeSeriesData(), makeIndex(metadata()))
+				if err := remoteWrite(ctx, client, url, req); err != nil && every.ShouldLog() {
+					log.Warningf(ctx, "error pushing time series to the remote-write endpoint: %v", err)
+				}
+			}
+		}
+	})
+}
+
+// makeWriteRequest converts the time series data to a remote-write request,
+// dropping the time series that aren't exposed, e.g. histogram averages.
+func makeWriteRequest(data []tspb.TimeSeriesData, idx index) *prompb.WriteRequest {
+	req := &prompb.WriteRequest{Timeseries: make([]prompb.TimeSeries, 0, len(data))}
+	for _, d := range data {
+		sel, ok := idx.byTSName[d.Name]
+		if !ok {
+			continue
+		}
+		source, tenant := tsutil.DecodeSource(d.Source)
+		b := labels.NewBuilder(sel.labels).Set(sel.sourceLabel, source)
+		if tenant != "" {
+			b.Set(tenantLabel, tenant)
+		}
+		lbls := b.Labels()
+
+		pts := prompb.TimeSeries{
+			Labels:  make([]prompb.Label, len(lbls)),
+			Samples: make([]prompb.Sample, len(d.Datapoints)),
+		}
+		for i, l := range lbls {
+			pts.Labels[i] = prompb.Label{Name: l.Name, Value: l.Value}
+		}
+		for i, dp := range d.Datapoints {
+			pts.Samples[i] = prompb.Sample{
+				Value:     dp.Value,
+				Timestamp: dp.TimestampNanos / int64(time.Millisecond),
+			}
+		}
+		req.Timeseries = append(req.Timeseries, pts)
+	}
+	return req
+}
+
+// remoteWrite sends the request to the remote-write endpoint at url, encoded
+// as specified by the Prometheus remote-write protocol.
+func remoteWrite(
+	ctx context.Context, client *httputil.Client, url string, req *prompb.WriteRequest,
+) error {
+	if len(req.Timeseries) == 0 {
+		return nil
+	}
+	b, err := protoutil.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, b)))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return errors.Newf("server returned HTTP status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
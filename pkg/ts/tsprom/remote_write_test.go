@@ -0,0 +1,91 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tsprom
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ts/tspb"
+	"github.com/cockroachdb/cockroach/pkg/util/httputil"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteWrite(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := int64(1700000000 * time.Second)
+	datapoint := func(v float64) []tspb.TimeSeriesDatapoint {
+		return []tspb.TimeSeriesDatapoint{{TimestampNanos: ts, Value: v}}
+	}
+	data := []tspb.TimeSeriesData{
+		{Name: "cr.node.sql.conns", Source: "1", Datapoints: datapoint(5)},
+		{Name: "cr.node.sql.conns", Source: "1-2", Datapoints: datapoint(3)},
+		{Name: "cr.store.capacity", Source: "4", Datapoints: datapoint(1000)},
+		{Name: "cr.node.sql.service.latency-count", Source: "1", Datapoints: datapoint(10)},
+		{Name: "cr.node.sql.service.latency-avg", Source: "1", Datapoints: datapoint(50)},
+		{Name: "cr.node.sql.service.latency-p99.9", Source: "1", Datapoints: datapoint(100)},
+		{Name: "cr.node.unknown", Source: "1", Datapoints: datapoint(1)},
+	}
+	req := makeWriteRequest(data, makeIndex(testingMetadata()))
+
+	series := func(v float64, lbls ...string) prompb.TimeSeries {
+		s := prompb.TimeSeries{Samples: []prompb.Sample{{Value: v, Timestamp: ts / int64(time.Millisecond)}}}
+		for i := 0; i < len(lbls); i += 2 {
+			s.Labels = append(s.Labels, prompb.Label{Name: lbls[i], Value: lbls[i+1]})
+		}
+		return s
+	}
+	expected := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		series(5, "__name__", "sql_conns", "node_id", "1"),
+		series(3, "__name__", "sql_conns", "node_id", "1", "tenant_id", "2"),
+		series(1000, "__name__", "capacity", "store", "4"),
+		series(10, "__name__", "sql_service_latency_count", "node_id", "1"),
+		series(100, "__name__", "sql_service_latency", "node_id", "1", "quantile", "0.999"),
+	}}
+	require.Equal(t, expected, req)
+
+	var received prompb.WriteRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+		compressed, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		b, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		require.NoError(t, protoutil.Unmarshal(b, &received))
+		if len(received.Timeseries) > 1 {
+			http.Error(w, "too many series", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := httputil.NewClientWithTimeout(remoteWriteTimeout)
+	require.NoError(t, remoteWrite(ctx, client, srv.URL, &prompb.WriteRequest{
+		Timeseries: expected.Timeseries[:1],
+	}))
+	require.Len(t, received.Timeseries, 1)
+	require.Equal(t, expected.Timeseries[0].Labels, received.Timeseries[0].Labels)
+	require.Equal(t, expected.Timeseries[0].Samples, received.Timeseries[0].Samples)
+
+	err := remoteWrite(ctx, client, srv.URL, req)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "400 Bad Request: too many series")
+}
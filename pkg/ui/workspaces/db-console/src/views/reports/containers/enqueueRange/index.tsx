@@ -32,6 +32,7 @@ const QUEUES = [
   "raftlog",
   "raftsnapshot",
   "consistencyChecker",
+  "coldtier",
   "timeSeriesMaintenance",
 ];
 
@@ -35,6 +35,11 @@ function printLogEventType(
     case protos.cockroach.kv.kvserver.storagepb.RangeLogEventType
       .remove_non_voter:
       return "Remove Non-Voter";
+    case protos.cockroach.kv.kvserver.storagepb.RangeLogEventType.add_witness:
+      return "Add Witness";
+    case protos.cockroach.kv.kvserver.storagepb.RangeLogEventType
+      .remove_witness:
+      return "Remove Witness";
     case protos.cockroach.kv.kvserver.storagepb.RangeLogEventType.split:
       return "Split";
     case protos.cockroach.kv.kvserver.storagepb.RangeLogEventType.merge:
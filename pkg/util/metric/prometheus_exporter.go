@@ -58,7 +58,7 @@ func MakePrometheusExporterForSelectedMetrics(selection map[string]struct{}) Pro
 func (pm *PrometheusExporter) findOrCreateFamily(
 	prom PrometheusExportable,
 ) *prometheusgo.MetricFamily {
-	familyName := exportedName(prom.GetName())
+	familyName := ExportedName(prom.GetName())
 	if family, ok := pm.families[familyName]; ok {
 		return family
 	}
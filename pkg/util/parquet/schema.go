@@ -29,9 +29,10 @@ import (
 // null or not. See comments on nonNilDefLevel or nilDefLevel for more info.
 var defaultRepetitions = parquet.Repetitions.Optional
 
-// A schema field is an internal identifier for schema nodes used by the parquet
-// library. A value of -1 will let the library auto-assign values. This does not
-// affect reading or writing parquet files.
+// A schema field ID is an optional identifier stored with each schema node.
+// A value of -1 leaves the identifier unset. Unless a caller supplies field IDs
+// (see NewSchemaWithFieldIDs), this does not affect reading or writing parquet
+// files.
 const defaultSchemaFieldID = int32(-1)
 
 // The parquet library utilizes a type length of -1 for all types
@@ -81,6 +82,31 @@ func (sd *SchemaDefinition) NumColumns() int {
 // Columns in the returned SchemaDefinition will match the order they appear in
 // the supplied parameters.
 func NewSchema(columnNames []string, columnTypes []*types.T) (*SchemaDefinition, error) {
+	return newSchema(columnNames, columnTypes, nil /* fieldIDs */)
+}
+
+// NewSchemaWithFieldIDs generates a SchemaDefinition whose top level columns
+// carry the supplied field IDs. Field IDs are stored in the file footer and
+// allow readers, such as those of Iceberg tables, to resolve columns which
+// have been renamed since the file was written. Field IDs must be
+// non-negative.
+func NewSchemaWithFieldIDs(
+	columnNames []string, columnTypes []*types.T, fieldIDs []int32,
+) (*SchemaDefinition, error) {
+	if len(fieldIDs) != len(columnNames) {
+		return nil, errors.AssertionFailedf("the number of column names must match the number of field IDs")
+	}
+	for _, id := range fieldIDs {
+		if id < 0 {
+			return nil, errors.AssertionFailedf("field IDs must be non-negative, found %d", id)
+		}
+	}
+	return newSchema(columnNames, columnTypes, fieldIDs)
+}
+
+func newSchema(
+	columnNames []string, columnTypes []*types.T, fieldIDs []int32,
+) (*SchemaDefinition, error) {
 	if len(columnTypes) != len(columnNames) {
 		return nil, errors.AssertionFailedf("the number of column names must match the number of column types")
 	}
@@ -93,7 +119,11 @@ func NewSchema(columnNames []string, columnTypes []*types.T) (*SchemaDefinition,
 		if columnTypes[i] == nil {
 			return nil, errors.AssertionFailedf("column %s missing type information", columnNames[i])
 		}
-		column, err := makeColumn(columnNames[i], columnTypes[i], defaultRepetitions)
+		fieldID := defaultSchemaFieldID
+		if fieldIDs != nil {
+			fieldID = fieldIDs[i]
+		}
+		column, err := makeColumn(columnNames[i], columnTypes[i], defaultRepetitions, fieldID)
 		if err != nil {
 			return nil, err
 		}
@@ -118,18 +148,20 @@ func NewSchema(columnNames []string, columnTypes []*types.T) (*SchemaDefinition,
 
 // makeColumn constructs a datumColumn. It does not populate
 // datumColumn.physicalColsStartIdx.
-func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (datumColumn, error) {
+func makeColumn(
+	colName string, typ *types.T, repetitions parquet.Repetition, fieldID int32,
+) (datumColumn, error) {
 	result := datumColumn{typ: typ, numPhysicalCols: 1}
 	var err error
 	switch typ.Family() {
 	case types.BoolFamily:
-		result.node = schema.NewBooleanNode(colName, repetitions, defaultSchemaFieldID)
+		result.node = schema.NewBooleanNode(colName, repetitions, fieldID)
 		result.colWriter = scalarWriter(writeBool)
 		return result, nil
 	case types.StringFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -141,7 +173,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 			result.node, err = schema.NewPrimitiveNodeLogical(colName,
 				repetitions, schema.NewIntLogicalType(64, true),
 				parquet.Types.Int64, defaultTypeLength,
-				defaultSchemaFieldID)
+				fieldID)
 			if err != nil {
 				return datumColumn{}, err
 			}
@@ -149,14 +181,14 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 			return result, nil
 		}
 
-		result.node = schema.NewInt32Node(colName, repetitions, defaultSchemaFieldID)
+		result.node = schema.NewInt32Node(colName, repetitions, fieldID)
 		result.colWriter = scalarWriter(writeInt32)
 		return result, nil
 	case types.PGLSNFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.NewIntLogicalType(64, true),
 			parquet.Types.Int64, defaultTypeLength,
-			defaultSchemaFieldID)
+			fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -180,7 +212,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.NewDecimalLogicalType(precision,
 				scale), parquet.Types.ByteArray, defaultTypeLength,
-			defaultSchemaFieldID)
+			fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -189,7 +221,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.UuidFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.UUIDLogicalType{},
-			parquet.Types.FixedLenByteArray, uuid.Size, defaultSchemaFieldID)
+			parquet.Types.FixedLenByteArray, uuid.Size, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -200,7 +232,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		// a physical type of int64, which is not sufficient for CRDB timestamps.
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -211,7 +243,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		// a physical type of int64, which is not sufficient for CRDB timestamps.
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -220,7 +252,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.INetFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -229,7 +261,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.JsonFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.JSONLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -238,7 +270,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.BitFamily:
 		result.node, err = schema.NewPrimitiveNode(colName,
 			repetitions, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -247,7 +279,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.BytesFamily:
 		result.node, err = schema.NewPrimitiveNode(colName,
 			repetitions, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -256,7 +288,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.EnumFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.EnumLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -267,7 +299,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		// a physical type of int32, which is not sufficient for CRDB timestamps.
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -276,7 +308,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.Box2DFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -285,7 +317,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.GeographyFamily:
 		result.node, err = schema.NewPrimitiveNode(colName,
 			repetitions, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -294,7 +326,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.GeometryFamily:
 		result.node, err = schema.NewPrimitiveNode(colName,
 			repetitions, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -303,7 +335,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 	case types.IntervalFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -314,7 +346,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		// See https://www.cockroachlabs.com/docs/stable/time.html.
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.NewTimeLogicalType(true, schema.TimeUnitMicros), parquet.Types.Int64,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -325,7 +357,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		// timezones.
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -335,7 +367,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		if typ.Oid() == oid.T_float4 {
 			result.node, err = schema.NewPrimitiveNode(colName,
 				repetitions, parquet.Types.Float,
-				defaultTypeLength, defaultSchemaFieldID)
+				defaultTypeLength, fieldID)
 			if err != nil {
 				return datumColumn{}, err
 			}
@@ -344,20 +376,20 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		}
 		result.node, err = schema.NewPrimitiveNode(colName,
 			repetitions, parquet.Types.Double,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
 		result.colWriter = scalarWriter(writeFloat64)
 		return result, nil
 	case types.OidFamily:
-		result.node = schema.NewInt32Node(colName, repetitions, defaultSchemaFieldID)
+		result.node = schema.NewInt32Node(colName, repetitions, fieldID)
 		result.colWriter = scalarWriter(writeOid)
 		return result, nil
 	case types.CollatedStringFamily:
 		result.node, err = schema.NewPrimitiveNodeLogical(colName,
 			repetitions, schema.StringLogicalType{}, parquet.Types.ByteArray,
-			defaultTypeLength, defaultSchemaFieldID)
+			defaultTypeLength, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -386,7 +418,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		}
 
 		elementCol, err := makeColumn("element", typ.ArrayContents(),
-			parquet.Repetitions.Optional)
+			parquet.Repetitions.Optional, defaultSchemaFieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -401,7 +433,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 		outerListFields := []schema.Node{innerListNode}
 
 		result.node, err = schema.NewGroupNodeLogical(colName, parquet.Repetitions.Optional,
-			outerListFields, schema.ListLogicalType{}, defaultSchemaFieldID)
+			outerListFields, schema.ListLogicalType{}, fieldID)
 		if err != nil {
 			return datumColumn{}, err
 		}
@@ -448,7 +480,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 			} else {
 				label = labels[i]
 			}
-			elementCol, err := makeColumn(label, innerTyp, defaultRepetitions)
+			elementCol, err := makeColumn(label, innerTyp, defaultRepetitions, defaultSchemaFieldID)
 			if err != nil {
 				return datumColumn{}, err
 			}
@@ -464,7 +496,7 @@ func makeColumn(colName string, typ *types.T, repetitions parquet.Repetition) (d
 
 		result.colWriter = tupleWriter(colWriters)
 		result.node, err = schema.NewGroupNode(colName, parquet.Repetitions.Optional,
-			nodes, defaultSchemaFieldID)
+			nodes, fieldID)
 		result.numPhysicalCols = len(colWriters)
 		if err != nil {
 			return datumColumn{}, err
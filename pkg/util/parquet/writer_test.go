@@ -662,3 +662,35 @@ func TestSquashTuples(t *testing.T) {
 		require.Equal(t, tc.tupleOutput, fmt.Sprint(squashedDatums))
 	}
 }
+
+// TestFieldIDs tests that field IDs supplied with the schema are written to the
+// file footer.
+func TestFieldIDs(t *testing.T) {
+	schemaDef, err := NewSchemaWithFieldIDs(
+		[]string{"a", "b", "c"},
+		[]*types.T{types.Int, types.String, types.IntArray},
+		[]int32{3, 1, 7},
+	)
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	writer, err := NewWriter(schemaDef, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.AddRow([]tree.Datum{tree.NewDInt(1), tree.NewDString("b"), tree.DNull}))
+	require.NoError(t, writer.Close())
+
+	reader, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, reader.Close()) }()
+
+	root := reader.MetaData().Schema.Root()
+	require.Equal(t, 3, root.NumFields())
+	for i, expected := range []int32{3, 1, 7} {
+		require.Equal(t, expected, root.Field(i).FieldID())
+	}
+
+	_, err = NewSchemaWithFieldIDs([]string{"a"}, []*types.T{types.Int}, []int32{-1})
+	require.Error(t, err)
+	_, err = NewSchemaWithFieldIDs([]string{"a"}, []*types.T{types.Int}, nil)
+	require.Error(t, err)
+}